@@ -0,0 +1,478 @@
+// Package client is a typed Go client for the Vigil v1 HTTP API.
+//
+//	c := client.New("https://vigil.example.com", client.WithToken(token))
+//	res, err := c.SubmitEvent(ctx, &client.Event{Title: "DiskFull", Severity: "warning"})
+//
+// Non-2xx responses are returned as *APIError. The API has no streaming
+// endpoint, so Watch polls.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the Vigil API. It is safe for concurrent use.
+type Client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken sets the bearer token sent with every request. Admin endpoints
+// such as Restore need a client built with the admin token.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient replaces http.DefaultClient, for timeouts, proxies or TLS
+// settings.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.http = hc
+		}
+	}
+}
+
+// New returns a client for the Vigil server at baseURL, such as
+// "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		base: strings.TrimRight(baseURL, "/"),
+		http: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is a non-2xx response. Code is one of the Code constants when the
+// server sent an error envelope, and empty otherwise.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	Code       string
+	Message    string
+	RequestID  string
+	// Problems lists the offending alerts of a webhook rejected by
+	// validation.
+	Problems []Problem
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.Path, e.Status)
+	}
+	return fmt.Sprintf("%s %s: %s: %s (%s)", e.Method, e.Path, e.Status, e.Message, e.Code)
+}
+
+// IsNotFound reports whether err is an APIError for a missing triage or route.
+func IsNotFound(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// SubmitAlerts posts an Alertmanager webhook. A batch where only some alerts
+// failed is not an error; check each AlertResult's Outcome.
+func (c *Client) SubmitAlerts(ctx context.Context, wh *Webhook) (*IngestResponse, error) {
+	var resp IngestResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/alerts", wh, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitAlertsDryRun posts an Alertmanager webhook as a dry run: the alerts
+// are triaged and stored, but nothing is notified, no issue is opened and no
+// action suggested.
+func (c *Client) SubmitAlertsDryRun(ctx context.Context, wh *Webhook) (*IngestResponse, error) {
+	var resp IngestResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/alerts?dry_run=true", wh, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitEvent posts a generic event.
+func (c *Client) SubmitEvent(ctx context.Context, ev *Event) (*EventResponse, error) {
+	var resp EventResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/events", ev, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Get returns a triage with its conversation.
+func (c *Client) Get(ctx context.Context, id string) (*Result, error) {
+	var r Result
+	if err := c.do(ctx, http.MethodGet, "/api/v1/triage/"+url.PathEscape(id), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// List returns triages matching the filter, newest first, without
+// conversations. Page with Before and BeforeID set to the last result's
+// CreatedAt and ID.
+func (c *Client) List(ctx context.Context, f ListFilter) ([]*Result, error) {
+	q := url.Values{}
+	if f.Status != "" {
+		q.Set("status", string(f.Status))
+	}
+	if f.Alert != "" {
+		q.Set("alert", f.Alert)
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if !f.Before.IsZero() {
+		q.Set("before", f.Before.Format(time.RFC3339Nano))
+		if f.BeforeID != "" {
+			q.Set("before_id", f.BeforeID)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(f.Labels)) {
+		q.Add("label", k+":"+f.Labels[k])
+	}
+	path := "/api/v1/triage"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var resp struct {
+		Results []*Result `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// Search returns past triages whose alert name, summary or analysis match
+// the query, best match first. limit <= 0 uses the server default.
+func (c *Client) Search(ctx context.Context, query string, limit int) ([]*SearchHit, error) {
+	q := url.Values{"q": {query}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp SearchResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/triage/search?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// Stats aggregates the triages created in the window ending at until. A zero
+// window or until uses the server defaults of 24h and now; top <= 0 ranks
+// the server's default number of alert names.
+func (c *Client) Stats(ctx context.Context, window time.Duration, until time.Time, top int) (*Stats, error) {
+	q := url.Values{}
+	if window > 0 {
+		q.Set("window", window.String())
+	}
+	if !until.IsZero() {
+		q.Set("until", until.Format(time.RFC3339Nano))
+	}
+	if top > 0 {
+		q.Set("top", strconv.Itoa(top))
+	}
+	path := "/api/v1/stats"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var st Stats
+	if err := c.do(ctx, http.MethodGet, path, nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Decisions returns why submitted alerts were triaged or skipped, newest
+// first. Page with Before and BeforeID set to the last decision's CreatedAt
+// and ID.
+func (c *Client) Decisions(ctx context.Context, f DecisionFilter) ([]*Decision, error) {
+	q := url.Values{}
+	if f.Fingerprint != "" {
+		q.Set("fingerprint", f.Fingerprint)
+	}
+	if f.Alert != "" {
+		q.Set("alert", f.Alert)
+	}
+	if f.Decision != "" {
+		q.Set("decision", f.Decision)
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if !f.Before.IsZero() {
+		q.Set("before", f.Before.Format(time.RFC3339Nano))
+		if f.BeforeID != "" {
+			q.Set("before_id", f.BeforeID)
+		}
+	}
+	path := "/api/v1/decisions"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var resp DecisionsResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Decisions, nil
+}
+
+// Notes returns a triage's investigation notes.
+func (c *Client) Notes(ctx context.Context, id string) (*NotesResponse, error) {
+	var resp NotesResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/triage/"+url.PathEscape(id)+"/notes", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Timeline returns the triage's events in order, from submission to
+// notification.
+func (c *Client) Timeline(ctx context.Context, id string) (*Timeline, error) {
+	var tl Timeline
+	if err := c.do(ctx, http.MethodGet, "/api/v1/triage/"+url.PathEscape(id)+"/timeline", nil, &tl); err != nil {
+		return nil, err
+	}
+	return &tl, nil
+}
+
+// Audit returns the triage's lifecycle transitions, oldest first.
+func (c *Client) Audit(ctx context.Context, id string) ([]*AuditEvent, error) {
+	var resp AuditResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/triage/"+url.PathEscape(id)+"/audit", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Events, nil
+}
+
+// Compare diffs two triages of the same fingerprint.
+func (c *Client) Compare(ctx context.Context, id, otherID string) (*Comparison, error) {
+	var cmp Comparison
+	path := "/api/v1/triage/" + url.PathEscape(id) + "/compare/" + url.PathEscape(otherID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &cmp); err != nil {
+		return nil, err
+	}
+	return &cmp, nil
+}
+
+// Share creates a link to a triage's report that works without an API
+// token. The returned URL is relative to the server.
+func (c *Client) Share(ctx context.Context, id string, req *ShareRequest) (*ShareResponse, error) {
+	var resp ShareResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/triage/"+url.PathEscape(id)+"/share", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Delete soft-deletes a finished triage.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/triage/"+url.PathEscape(id), nil, nil)
+}
+
+// Cancel stops a pending or in-progress triage. It returns once the server has
+// signalled the run; use Watch to wait for the final StatusError result.
+func (c *Client) Cancel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/triage/"+url.PathEscape(id)+"/cancel", nil, nil)
+}
+
+// Snooze skips triage of matching alerts for req.Duration. The server stores
+// it as a suppression with reason snoozed.
+func (c *Client) Snooze(ctx context.Context, req *SnoozeRequest) (*Suppression, error) {
+	var sn Suppression
+	if err := c.do(ctx, http.MethodPost, "/api/v1/snooze", req, &sn); err != nil {
+		return nil, err
+	}
+	return &sn, nil
+}
+
+// Snoozes returns the active suppressions with reason snoozed, soonest to
+// expire first.
+func (c *Client) Snoozes(ctx context.Context) ([]*Suppression, error) {
+	var resp struct {
+		Snoozes []*Suppression `json:"snoozes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/snooze", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Snoozes, nil
+}
+
+// Unsnooze removes a snooze before it expires.
+func (c *Client) Unsnooze(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/snooze/"+url.PathEscape(id), nil, nil)
+}
+
+// Suppress skips triage of matching alerts until req.TTL runs out. The
+// server stores it, so every replica honors it.
+func (c *Client) Suppress(ctx context.Context, req *SuppressionRequest) (*Suppression, error) {
+	var sup Suppression
+	if err := c.do(ctx, http.MethodPost, "/api/v1/suppressions", req, &sup); err != nil {
+		return nil, err
+	}
+	return &sup, nil
+}
+
+// Suppressions returns the active suppressions, soonest to expire first.
+func (c *Client) Suppressions(ctx context.Context) ([]*Suppression, error) {
+	var resp struct {
+		Suppressions []*Suppression `json:"suppressions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/suppressions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Suppressions, nil
+}
+
+// Unsuppress removes a suppression before it expires.
+func (c *Client) Unsuppress(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/suppressions/"+url.PathEscape(id), nil, nil)
+}
+
+// Noise scores each alert name by how noisy its triages were over window,
+// noisiest first. A zero window uses the server default of 7 days.
+func (c *Client) Noise(ctx context.Context, window time.Duration) ([]NoiseScore, error) {
+	path := "/api/v1/noise"
+	if window > 0 {
+		path += "?window=" + url.QueryEscape(window.String())
+	}
+	var resp struct {
+		Alerts []NoiseScore `json:"alerts"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Alerts, nil
+}
+
+// Tools lists the tools the caller's triages can use, with their recent
+// success rates.
+func (c *Client) Tools(ctx context.Context) ([]ToolInfo, error) {
+	var resp struct {
+		Tools []ToolInfo `json:"tools"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/tools", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tools, nil
+}
+
+// Restore undoes Delete. It needs the admin token.
+func (c *Client) Restore(ctx context.Context, id string) (*Result, error) {
+	var r Result
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/triage/"+url.PathEscape(id)+"/restore", nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Watch polls a triage every interval until it reaches a terminal status,
+// calling fn whenever the status changes or new turns arrive, and returns the
+// final result. An error from fn stops the watch and is returned.
+func (c *Client) Watch(ctx context.Context, id string, interval time.Duration, fn func(*Result) error) (*Result, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastStatus Status
+	lastTurns := -1
+	for {
+		r, err := c.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		turns := 0
+		if r.Conversation != nil {
+			turns = len(r.Conversation.Turns)
+		}
+		if fn != nil && (r.Status != lastStatus || turns != lastTurns) {
+			if err := fn(r); err != nil {
+				return r, err
+			}
+		}
+		lastStatus, lastTurns = r.Status, turns
+		if r.Status.IsTerminal() {
+			return r, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return r, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req) //nolint:gosec // G704: base URL is supplied by the caller
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode, Status: resp.Status}
+		var env ValidationResponse
+		if json.Unmarshal(msg, &env) == nil && env.Error.Message != "" {
+			apiErr.Code = env.Error.Code
+			apiErr.Message = env.Error.Message
+			apiErr.RequestID = env.Error.RequestID
+			apiErr.Problems = env.Problems
+		}
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,716 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/alertapi"
+	"github.com/linnemanlabs/vigil/internal/authmw"
+	"github.com/linnemanlabs/vigil/internal/share"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// fakeService backs the real API handlers so the client is tested against
+// the server's actual routes and encodings.
+type fakeService struct {
+	mu      sync.Mutex
+	results map[string]*triage.Result
+	deleted map[string]bool
+	filter  triage.ListFilter
+	// cancelled records Cancel calls that reached a running triage.
+	cancelled []string
+	submit    func(al *alert.Alert) (*triage.SubmitResult, error)
+	// polls advances a watched triage one step per Get.
+	polls        []*triage.Result
+	suppressions map[string]*triage.Suppression
+	// noiseWindow records the window of the last NoiseScores call.
+	noiseWindow time.Duration
+	// decisionFilter records the filter of the last Decisions call.
+	decisionFilter triage.DecisionFilter
+	// search records the query of the last Search call.
+	search triage.SearchQuery
+	// stats records the query of the last Stats call.
+	stats triage.StatsQuery
+}
+
+func (f *fakeService) Submit(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+	if f.submit != nil {
+		return f.submit(al)
+	}
+	return &triage.SubmitResult{ID: "01NEW"}, nil
+}
+
+func (f *fakeService) Get(_ context.Context, id string) (*triage.Result, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id == "watched" && len(f.polls) > 0 {
+		r := f.polls[0]
+		if len(f.polls) > 1 {
+			f.polls = f.polls[1:]
+		}
+		return r, true, nil
+	}
+	r, ok := f.results[id]
+	if !ok || f.deleted[id] {
+		return nil, false, nil
+	}
+	return r, true, nil
+}
+
+func (f *fakeService) Timeline(ctx context.Context, id string) (*triage.Timeline, bool, error) {
+	r, ok, err := f.Get(ctx, id)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return triage.BuildTimeline(r, nil), true, nil
+}
+
+func (f *fakeService) Audit(ctx context.Context, id string) ([]*triage.AuditEvent, bool, error) {
+	if _, ok, err := f.Get(ctx, id); err != nil || !ok {
+		return nil, false, err
+	}
+	return []*triage.AuditEvent{{TriageID: id, Event: triage.AuditSubmitted, Actor: "api-token"}}, true, nil
+}
+
+func (f *fakeService) List(_ context.Context, filter triage.ListFilter) ([]*triage.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.filter = filter
+	var out []*triage.Result
+	for _, r := range f.results {
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (f *fakeService) Delete(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.results[id]
+	if !ok || f.deleted[id] {
+		return false, nil
+	}
+	if !r.Status.IsTerminal() {
+		return false, triage.ErrTriageActive
+	}
+	f.deleted[id] = true
+	return true, nil
+}
+
+func (f *fakeService) Restore(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.deleted[id] {
+		return false, nil
+	}
+	delete(f.deleted, id)
+	return true, nil
+}
+
+func (f *fakeService) Cancel(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.results[id]
+	if !ok {
+		return false, nil
+	}
+	if r.Status.IsTerminal() {
+		return false, triage.ErrTriageNotRunning
+	}
+	f.cancelled = append(f.cancelled, id)
+	return true, nil
+}
+
+func (f *fakeService) Suppress(_ context.Context, sup triage.Suppression, ttl time.Duration) (*triage.Suppression, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sup.ID = "suppression-1"
+	sup.ExpiresAt = time.Now().Add(ttl)
+	f.suppressions[sup.ID] = &sup
+	return &sup, nil
+}
+
+func (f *fakeService) Suppressions(context.Context) ([]*triage.Suppression, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*triage.Suppression
+	for _, sup := range f.suppressions {
+		out = append(out, sup)
+	}
+	return out, nil
+}
+
+func (f *fakeService) Unsuppress(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.suppressions[id]
+	delete(f.suppressions, id)
+	return ok, nil
+}
+
+func (f *fakeService) NoiseScores(_ context.Context, window time.Duration) ([]triage.NoiseScore, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.noiseWindow = window
+	return []triage.NoiseScore{{Alert: "DiskFull", Score: 0.8, Triages: 40}}, nil
+}
+
+func (f *fakeService) Decisions(_ context.Context, flt triage.DecisionFilter) ([]*triage.Decision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decisionFilter = flt
+	return []*triage.Decision{{ID: "d1", Fingerprint: "fp-1", Decision: triage.DecisionSkipped, Reason: "duplicate", TriageID: "done"}}, nil
+}
+
+func (f *fakeService) Search(_ context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.search = q
+	return []*triage.SearchHit{{ID: "done", Alert: "DiskFull", Rank: 0.6, Snippet: "Root cause: **disk** full"}}, nil
+}
+
+func (f *fakeService) Stats(_ context.Context, q triage.StatsQuery) (*triage.Stats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats = q
+	cost := 0.42
+	return &triage.Stats{
+		Since: q.Since, Until: q.Until, Triages: 3,
+		ByStatus: map[triage.Status]int{triage.StatusComplete: 2, triage.StatusFailed: 1},
+		CostUSD:  cost,
+		Models:   []triage.ModelUsage{{Model: "claude-sonnet-4-20250514", Triages: 3, TokensIn: 90000, TokensOut: 10000, CostUSD: &cost}},
+	}, nil
+}
+
+func (f *fakeService) Tools(context.Context) ([]tools.ToolInfo, error) {
+	rate := 0.25
+	return []tools.ToolInfo{{Name: "query_logs", Description: "Query Loki", Available: true, RecentCalls: 8, SuccessRate: &rate}}, nil
+}
+
+const (
+	testToken  = "user-token"
+	adminToken = "admin-token"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *fakeService) {
+	t.Helper()
+	svc := &fakeService{
+		results: map[string]*triage.Result{
+			"done":    {ID: "done", Fingerprint: "fp-1", Status: triage.StatusComplete, Alert: "DiskFull", Analysis: "Root cause: disk full", Notes: []triage.Note{{Text: "checking disk"}}},
+			"again":   {ID: "again", Fingerprint: "fp-1", Status: triage.StatusComplete, Alert: "DiskFull", Analysis: "Root cause: disk full"},
+			"running": {ID: "running", Fingerprint: "fp-2", Status: triage.StatusInProgress},
+		},
+		deleted:      map[string]bool{},
+		suppressions: map[string]*triage.Suppression{},
+	}
+	signer, err := share.New([]byte(strings.Repeat("k", share.MinKeyLen)))
+	if err != nil {
+		t.Fatalf("share.New: %v", err)
+	}
+	api := alertapi.New(nil, svc, alertapi.WithSharing(signer))
+	r := chi.NewRouter()
+	api.RegisterPublicRoutes(r)
+	r.Group(func(r chi.Router) {
+		r.Use(authmw.BearerToken(testToken))
+		api.RegisterRoutes(r)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(authmw.BearerToken(adminToken))
+		api.RegisterAdminRoutes(r)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, svc
+}
+
+func TestSubmitEvent(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL+"/", WithToken(testToken))
+
+	resp, err := c.SubmitEvent(context.Background(), &Event{Title: "DiskFull", Severity: "warning"})
+	if err != nil {
+		t.Fatalf("SubmitEvent: %v", err)
+	}
+	if len(resp.Accepted) != 1 || resp.Accepted[0] != "01NEW" || resp.Fingerprint == "" {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestSubmitAlerts_PartialFailure(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	svc.submit = func(al *alert.Alert) (*triage.SubmitResult, error) {
+		if al.Fingerprint == "bad" {
+			return nil, errors.New("db down")
+		}
+		return &triage.SubmitResult{ID: "01OK"}, nil
+	}
+	c := New(srv.URL, WithToken(testToken))
+
+	resp, err := c.SubmitAlerts(context.Background(), &Webhook{Alerts: []Alert{
+		{Status: "firing", Fingerprint: "bad", Labels: map[string]string{"alertname": "A"}},
+		{Status: "firing", Fingerprint: "good", Labels: map[string]string{"alertname": "B"}},
+	}})
+	if err != nil {
+		t.Fatalf("SubmitAlerts: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Outcome != OutcomeFailed || resp.Results[1].Outcome != OutcomeAccepted {
+		t.Errorf("results = %+v", resp.Results)
+	}
+}
+
+func TestSubmitAlerts_AllFailed(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	svc.submit = func(*alert.Alert) (*triage.SubmitResult, error) { return nil, errors.New("db down") }
+	c := New(srv.URL, WithToken(testToken))
+
+	_, err := c.SubmitAlerts(context.Background(), &Webhook{Alerts: []Alert{{Status: "firing", Fingerprint: "fp", Labels: map[string]string{"alertname": "A"}}}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Code != CodeInternal {
+		t.Fatalf("err = %v, want internal APIError", err)
+	}
+}
+
+func TestSubmitAlerts_Invalid(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	_, err := c.SubmitAlerts(context.Background(), &Webhook{Alerts: []Alert{{Status: "firing", Fingerprint: "fp"}}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != CodeInvalidPayload {
+		t.Fatalf("err = %v, want invalid_payload APIError", err)
+	}
+	if len(apiErr.Problems) != 1 || apiErr.Problems[0].Field != "labels.alertname" {
+		t.Errorf("problems = %+v", apiErr.Problems)
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	r, err := c.Get(context.Background(), "done")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if r.ID != "done" || r.Status != StatusComplete {
+		t.Errorf("result = %+v", r)
+	}
+
+	_, err = c.Get(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code != CodeNotFound {
+		t.Errorf("Code = %q, want %q", apiErr.Code, CodeNotFound)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken("wrong"))
+
+	_, err := c.Get(context.Background(), "done")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeUnauthorized {
+		t.Fatalf("err = %v, want unauthorized", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	before := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	results, err := c.List(context.Background(), ListFilter{
+		Status: StatusComplete, Alert: "DiskFull", Limit: 5, Before: before, BeforeID: "01PREV",
+		Labels: map[string]string{"namespace": "prod", "team": "storage"},
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("got %d results, want 3", len(results))
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.filter.Status != StatusComplete || svc.filter.Alert != "DiskFull" || svc.filter.Limit != 5 || !svc.filter.Before.Equal(before) || svc.filter.BeforeID != "01PREV" ||
+		svc.filter.Labels["namespace"] != "prod" || svc.filter.Labels["team"] != "storage" {
+		t.Errorf("server saw filter %+v", svc.filter)
+	}
+}
+
+func TestNotesTimelineAndCompare(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	ctx := context.Background()
+
+	notes, err := c.Notes(ctx, "done")
+	if err != nil {
+		t.Fatalf("Notes: %v", err)
+	}
+	if len(notes.Notes) != 1 || notes.Notes[0].Text != "checking disk" {
+		t.Errorf("notes = %+v", notes)
+	}
+
+	tl, err := c.Timeline(ctx, "done")
+	if err != nil {
+		t.Fatalf("Timeline: %v", err)
+	}
+	if tl.TriageID != "done" || len(tl.Events) == 0 || tl.Events[0].Type != triage.EventSubmitted {
+		t.Errorf("timeline = %+v", tl)
+	}
+
+	audit, err := c.Audit(ctx, "done")
+	if err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	if len(audit) != 1 || audit[0].Event != triage.AuditSubmitted {
+		t.Errorf("audit = %+v", audit)
+	}
+
+	cmp, err := c.Compare(ctx, "done", "again")
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if cmp.Fingerprint != "fp-1" || cmp.RootCause.Changed {
+		t.Errorf("comparison = %+v", cmp)
+	}
+
+	_, err = c.Compare(ctx, "done", "running")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeFingerprintMismatch {
+		t.Errorf("err = %v, want fingerprint mismatch", err)
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	admin := New(srv.URL, WithToken(adminToken))
+	ctx := context.Background()
+
+	var apiErr *APIError
+	if err := c.Delete(ctx, "running"); !errors.As(err, &apiErr) || apiErr.Code != CodeTriageActive {
+		t.Fatalf("Delete running = %v, want triage_active", err)
+	}
+
+	if err := c.Delete(ctx, "done"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.Get(ctx, "done"); !IsNotFound(err) {
+		t.Fatalf("Get after delete = %v, want not found", err)
+	}
+
+	// The user token cannot reach admin routes.
+	if _, err := c.Restore(ctx, "done"); err == nil {
+		t.Fatal("Restore with user token succeeded")
+	}
+	r, err := admin.Restore(ctx, "done")
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if r.ID != "done" {
+		t.Errorf("restored = %+v", r)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	ctx := context.Background()
+
+	if err := c.Cancel(ctx, "running"); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	svc.mu.Lock()
+	if len(svc.cancelled) != 1 || svc.cancelled[0] != "running" {
+		t.Errorf("cancelled = %v, want [running]", svc.cancelled)
+	}
+	svc.mu.Unlock()
+
+	var apiErr *APIError
+	if err := c.Cancel(ctx, "done"); !errors.As(err, &apiErr) || apiErr.Code != CodeTriageNotRunning {
+		t.Errorf("Cancel done = %v, want triage_not_running", err)
+	}
+	if err := c.Cancel(ctx, "missing"); !IsNotFound(err) {
+		t.Errorf("Cancel missing = %v, want not found", err)
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	ctx := context.Background()
+
+	sn, err := c.Snooze(ctx, &SnoozeRequest{Fingerprint: "fp-1", Duration: "30m", Comment: "deploy"})
+	if err != nil {
+		t.Fatalf("Snooze: %v", err)
+	}
+	if sn.ID != "suppression-1" || sn.Fingerprint != "fp-1" || sn.Comment != "deploy" || sn.Reason != triage.SuppressionSnoozed {
+		t.Errorf("snooze = %+v", sn)
+	}
+
+	var apiErr *APIError
+	if _, err := c.Snooze(ctx, &SnoozeRequest{Fingerprint: "fp-1", Duration: "a while"}); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidPayload {
+		t.Errorf("Snooze bad duration = %v, want invalid_payload", err)
+	}
+
+	list, err := c.Snoozes(ctx)
+	if err != nil {
+		t.Fatalf("Snoozes: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != sn.ID {
+		t.Errorf("Snoozes = %+v", list)
+	}
+
+	if err := c.Unsnooze(ctx, sn.ID); err != nil {
+		t.Fatalf("Unsnooze: %v", err)
+	}
+	if err := c.Unsnooze(ctx, sn.ID); !IsNotFound(err) {
+		t.Errorf("second Unsnooze = %v, want not found", err)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	ctx := context.Background()
+
+	sup, err := c.Suppress(ctx, &SuppressionRequest{Matchers: map[string]string{"alertname": "Noisy"}, TTL: "168h", Comment: "known flapper"})
+	if err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+	if sup.ID != "suppression-1" || sup.Matchers["alertname"] != "Noisy" || sup.Comment != "known flapper" {
+		t.Errorf("suppression = %+v", sup)
+	}
+
+	var apiErr *APIError
+	if _, err := c.Suppress(ctx, &SuppressionRequest{Fingerprint: "fp-1", TTL: "a week"}); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidPayload {
+		t.Errorf("Suppress bad ttl = %v, want invalid_payload", err)
+	}
+
+	list, err := c.Suppressions(ctx)
+	if err != nil {
+		t.Fatalf("Suppressions: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != sup.ID {
+		t.Errorf("Suppressions = %+v", list)
+	}
+
+	if err := c.Unsuppress(ctx, sup.ID); err != nil {
+		t.Fatalf("Unsuppress: %v", err)
+	}
+	if err := c.Unsuppress(ctx, sup.ID); !IsNotFound(err) {
+		t.Errorf("second Unsuppress = %v, want not found", err)
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	before := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := DecisionFilter{Fingerprint: "fp-1", Decision: DecisionSkipped, Before: before, BeforeID: "d-9", Limit: 5}
+	got, err := c.Decisions(context.Background(), f)
+	if err != nil {
+		t.Fatalf("Decisions: %v", err)
+	}
+	if len(got) != 1 || got[0].Reason != "duplicate" || got[0].TriageID != "done" {
+		t.Errorf("decisions = %+v", got)
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.decisionFilter != f {
+		t.Errorf("server filter = %+v, want %+v", svc.decisionFilter, f)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	got, err := c.Search(context.Background(), "disk full & more", 5)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "done" || got[0].Snippet != "Root cause: **disk** full" {
+		t.Errorf("hits = %+v", got)
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.search.Text != "disk full & more" || svc.search.Limit != 5 {
+		t.Errorf("server query = %+v", svc.search)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	until := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	st, err := c.Stats(context.Background(), 7*24*time.Hour, until, 5)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if st.Triages != 3 || st.ByStatus[StatusComplete] != 2 || len(st.Models) != 1 || *st.Models[0].CostUSD != 0.42 {
+		t.Errorf("stats = %+v", st)
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if !svc.stats.Until.Equal(until) || !svc.stats.Since.Equal(until.Add(-7*24*time.Hour)) || svc.stats.TopAlerts != 5 {
+		t.Errorf("server query = %+v", svc.stats)
+	}
+}
+
+func TestShare(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+	ctx := context.Background()
+
+	sh, err := c.Share(ctx, "done", &ShareRequest{TTL: "1h"})
+	if err != nil {
+		t.Fatalf("Share: %v", err)
+	}
+	if time.Until(sh.ExpiresAt) > time.Hour {
+		t.Errorf("expires_at = %v, want within an hour", sh.ExpiresAt)
+	}
+
+	// The link works without a bearer token.
+	var rep alertapi.Report
+	if err := New(srv.URL).do(ctx, http.MethodGet, sh.URL, nil, &rep); err != nil || rep.Analysis != "Root cause: disk full" {
+		t.Errorf("report = %+v, %v", rep, err)
+	}
+
+	if _, err := c.Share(ctx, "missing", nil); !IsNotFound(err) {
+		t.Errorf("Share missing = %v, want not found", err)
+	}
+}
+
+func TestNoise(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	scores, err := c.Noise(context.Background(), 48*time.Hour)
+	if err != nil {
+		t.Fatalf("Noise: %v", err)
+	}
+	if len(scores) != 1 || scores[0].Alert != "DiskFull" || scores[0].Score != 0.8 {
+		t.Errorf("scores = %+v", scores)
+	}
+	svc.mu.Lock()
+	if svc.noiseWindow != 48*time.Hour {
+		t.Errorf("window = %s, want 48h", svc.noiseWindow)
+	}
+	svc.mu.Unlock()
+}
+
+func TestTools(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTestServer(t)
+	c := New(srv.URL, WithToken(testToken))
+
+	list, err := c.Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "query_logs" || list[0].SuccessRate == nil || *list[0].SuccessRate != 0.25 {
+		t.Errorf("tools = %+v", list)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	turn := Turn{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "looking"}}}
+	svc.polls = []*triage.Result{
+		{ID: "watched", Status: triage.StatusPending},
+		{ID: "watched", Status: triage.StatusPending},
+		{ID: "watched", Status: triage.StatusInProgress, Conversation: &Conversation{Turns: []Turn{turn}}},
+		{ID: "watched", Status: triage.StatusInProgress, Conversation: &Conversation{Turns: []Turn{turn, turn}}},
+		{ID: "watched", Status: triage.StatusComplete, Conversation: &Conversation{Turns: []Turn{turn, turn}}},
+	}
+	c := New(srv.URL, WithToken(testToken))
+
+	var seen []Status
+	final, err := c.Watch(context.Background(), "watched", time.Millisecond, func(r *Result) error {
+		seen = append(seen, r.Status)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if final.Status != StatusComplete {
+		t.Errorf("final status = %s", final.Status)
+	}
+	// The repeated pending poll is not reported.
+	want := []Status{StatusPending, StatusInProgress, StatusInProgress, StatusComplete}
+	if len(seen) != len(want) {
+		t.Fatalf("callbacks = %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("callback %d = %s, want %s", i, seen[i], want[i])
+		}
+	}
+}
+
+func TestWatch_CallbackErrorStops(t *testing.T) {
+	t.Parallel()
+
+	srv, svc := newTestServer(t)
+	svc.polls = []*triage.Result{{ID: "watched", Status: triage.StatusPending}}
+	c := New(srv.URL, WithToken(testToken))
+
+	stop := errors.New("stop")
+	_, err := c.Watch(context.Background(), "watched", time.Millisecond, func(*Result) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v, want callback error", err)
+	}
+}
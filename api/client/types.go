@@ -0,0 +1,98 @@
+package client
+
+import (
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/alertapi"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Wire types are aliases of the types the server encodes, so the client
+// cannot drift from the API and callers outside this module can still name
+// them.
+type (
+	Result         = triage.Result
+	Note           = triage.Note
+	Conversation   = triage.Conversation
+	Turn           = triage.Turn
+	ContentBlock   = triage.ContentBlock
+	Usage          = triage.Usage
+	Status         = triage.Status
+	ListFilter     = triage.ListFilter
+	SearchHit      = triage.SearchHit
+	Stats          = triage.Stats
+	ModelUsage     = triage.ModelUsage
+	AlertCount     = triage.AlertCount
+	ToolUsage      = triage.ToolUsage
+	Comparison     = triage.Comparison
+	Suppression    = triage.Suppression
+	NoiseScore     = triage.NoiseScore
+	Decision       = triage.Decision
+	DecisionFilter = triage.DecisionFilter
+	Timeline       = triage.Timeline
+	TimelineEvent  = triage.TimelineEvent
+	AuditEvent     = triage.AuditEvent
+
+	Webhook = alert.Webhook
+	Alert   = alert.Alert
+	Event   = alert.Event
+	Problem = alert.Problem
+
+	IngestResponse     = alertapi.IngestResponse
+	AlertResult        = alertapi.AlertResult
+	EventResponse      = alertapi.EventResponse
+	NotesResponse      = alertapi.NotesResponse
+	AuditResponse      = alertapi.AuditResponse
+	DecisionsResponse  = alertapi.DecisionsResponse
+	SearchResponse     = alertapi.SearchResponse
+	SnoozeRequest      = alertapi.SnoozeRequest
+	SuppressionRequest = alertapi.SuppressionRequest
+	ShareRequest       = alertapi.ShareRequest
+	ShareResponse      = alertapi.ShareResponse
+	ErrorBody          = alertapi.ErrorBody
+	ValidationResponse = alertapi.ValidationResponse
+
+	ToolInfo = tools.ToolInfo
+)
+
+// Triage statuses.
+const (
+	StatusPending        = triage.StatusPending
+	StatusInProgress     = triage.StatusInProgress
+	StatusComplete       = triage.StatusComplete
+	StatusFailed         = triage.StatusFailed
+	StatusError          = triage.StatusError
+	StatusMaxTurns       = triage.StatusMaxTurns
+	StatusBudgetExceeded = triage.StatusBudgetExceeded
+	StatusRefused        = triage.StatusRefused
+)
+
+// Submit decisions.
+const (
+	DecisionAccepted = triage.DecisionAccepted
+	DecisionSkipped  = triage.DecisionSkipped
+)
+
+// Per-alert ingest outcomes.
+const (
+	OutcomeAccepted = alertapi.OutcomeAccepted
+	OutcomeSkipped  = alertapi.OutcomeSkipped
+	OutcomeFailed   = alertapi.OutcomeFailed
+	OutcomeQueued   = alertapi.OutcomeQueued
+	OutcomeRejected = alertapi.OutcomeRejected
+)
+
+// Error codes, see APIError.
+const (
+	CodeInvalidPayload      = alertapi.CodeInvalidPayload
+	CodeInvalidParameter    = alertapi.CodeInvalidParameter
+	CodeUnauthorized        = alertapi.CodeUnauthorized
+	CodeNotFound            = alertapi.CodeNotFound
+	CodeMethodNotAllowed    = alertapi.CodeMethodNotAllowed
+	CodeFingerprintMismatch = alertapi.CodeFingerprintMismatch
+	CodeTriageActive        = alertapi.CodeTriageActive
+	CodeTriageNotRunning    = alertapi.CodeTriageNotRunning
+	CodeRateLimited         = alertapi.CodeRateLimited
+	CodeTooManyAlerts       = alertapi.CodeTooManyAlerts
+	CodeInternal            = alertapi.CodeInternal
+)
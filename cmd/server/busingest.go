@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/linnemanlabs/vigil/internal/busingest"
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+)
+
+// startBusIngest starts the configured NATS and Kafka consumers, which stop
+// when ctx is cancelled. A NATS consumer that cannot be set up fails startup;
+// Kafka brokers are only contacted once consuming starts and are retried.
+func startBusIngest(ctx context.Context, L log.Logger, appCfg *vc.Config, svc busingest.Submitter, reg prometheus.Registerer) error {
+	if appCfg.IngestNATSURL == "" && appCfg.IngestKafkaBrokers == "" {
+		return nil
+	}
+	messages := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vigil_bus_messages_total",
+		Help: "Alert payloads consumed from a message bus, by bus (nats, kafka) and result (submitted, invalid, retry).",
+	}, []string{"bus", "result"})
+	lag := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "vigil_bus_consumer_lag",
+		Help: "Messages left to consume after the last one received, by bus and partition (Kafka topic/partition, NATS stream).",
+	}, []string{"bus", "partition"})
+	reg.MustRegister(messages, lag)
+
+	consumer := busingest.New(svc, L, busingest.Hooks{
+		OnMessage: func(bus, result string) { messages.WithLabelValues(bus, result).Inc() },
+		OnLag:     func(bus, partition string, n int64) { lag.WithLabelValues(bus, partition).Set(float64(n)) },
+	})
+	if appCfg.IngestNATSURL != "" {
+		src, err := consumer.NATS(ctx, busingest.NATSConfig{
+			URL:     appCfg.IngestNATSURL,
+			Stream:  appCfg.IngestNATSStream,
+			Subject: appCfg.IngestNATSSubject,
+			Durable: appCfg.IngestNATSDurable,
+		})
+		if err != nil {
+			return err
+		}
+		go src.Run(ctx)
+		L.Info(ctx, "nats ingest enabled", "stream", appCfg.IngestNATSStream, "subject", appCfg.IngestNATSSubject, "durable", appCfg.IngestNATSDurable)
+	}
+	if appCfg.IngestKafkaBrokers != "" {
+		go consumer.Kafka(busingest.KafkaConfig{
+			Brokers: splitList(appCfg.IngestKafkaBrokers),
+			Topic:   appCfg.IngestKafkaTopic,
+			Group:   appCfg.IngestKafkaGroup,
+		}).Run(ctx)
+		L.Info(ctx, "kafka ingest enabled", "topic", appCfg.IngestKafkaTopic, "group", appCfg.IngestKafkaGroup)
+	}
+	return nil
+}
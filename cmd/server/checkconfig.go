@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/enrich"
+	"github.com/linnemanlabs/vigil/internal/filter"
+	"github.com/linnemanlabs/vigil/internal/maintenance"
+	"github.com/linnemanlabs/vigil/internal/mcp"
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/notify/discord"
+	"github.com/linnemanlabs/vigil/internal/notify/mattermost"
+	"github.com/linnemanlabs/vigil/internal/notify/slack"
+	"github.com/linnemanlabs/vigil/internal/notify/teams"
+	"github.com/linnemanlabs/vigil/internal/notify/webhook"
+	"github.com/linnemanlabs/vigil/internal/redact"
+	"github.com/linnemanlabs/vigil/internal/remediation"
+	"github.com/linnemanlabs/vigil/internal/routing"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// configCheck is one named group of checks; err is nil when it passed.
+type configCheck struct {
+	name string
+	err  error
+}
+
+// runCheckConfig loads configuration exactly as the server would (flags, then
+// VIGIL_* env vars, then the -config file) and reports every problem it finds without connecting to
+// anything, so it can gate deploys in CI. It returns an error if any check fails.
+func runCheckConfig(args []string, stdout, stderr io.Writer) error {
+	var sc serverConfig
+	fs := flag.NewFlagSet("check-config", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	sc.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fileErr := sc.fill(fs, func(format string, args ...any) {
+		fmt.Fprintf(stderr, format+"\n", args...)
+	})
+	return checkConfig(&sc, fileErr, stdout)
+}
+
+// checkConfig runs every check against a parsed config and prints the
+// outcome. fileErr is the result of applying the config file.
+func checkConfig(sc *serverConfig, fileErr error, stdout io.Writer) error {
+	checks := []configCheck{
+		{"config file", fileErr},
+		{"settings", sc.validate()},
+		{"datasources", checkDatasources(sc)},
+		{"notifiers", checkNotifiers(sc)},
+		{"routing", checkRouting(sc)},
+		{"filter", checkFilter(sc)},
+		{"enrichment", checkEnrichment(sc)},
+		{"maintenance", checkMaintenance(sc)},
+		{"redaction", checkRedaction(sc)},
+		{"prompt", checkPrompt(sc)},
+		{"mcp", checkMCP(sc)},
+		{"issues", checkIssues(sc)},
+		{"remediation", checkRemediation(sc)},
+		{"tenants", checkTenants(sc)},
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if c.err == nil {
+			fmt.Fprintf(stdout, "ok    %s\n", c.name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(stdout, "FAIL  %s\n", c.name)
+		for _, e := range flattenErrors(c.err) {
+			fmt.Fprintf(stdout, "      - %s\n", e)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkDatasources verifies endpoint and connection-string syntax for the
+// backends tools and storage talk to.
+func checkDatasources(sc *serverConfig) error {
+	var errs []error
+	if sc.App.PrometheusEndpoint != "" {
+		errs = append(errs, checkHTTPURL("prometheus-endpoint", sc.App.PrometheusEndpoint))
+	}
+	if sc.App.LokiEndpoint != "" {
+		errs = append(errs, checkHTTPURL("loki-endpoint", sc.App.LokiEndpoint))
+	}
+	if sc.App.DatabaseURL != "" {
+		if _, err := pgxpool.ParseConfig(sc.App.DatabaseURL); err != nil {
+			// pgx errors can echo the connection string, which holds the password
+			errs = append(errs, errors.New("database-url: not a valid PostgreSQL connection string"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkNotifiers validates notifier settings and the message templates, and
+// renders each configured notifier's payload against a sample result.
+func checkNotifiers(sc *serverConfig) error {
+	ts, err := loadNotifyTemplates(sc)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	check := func(backend, flag, webhookURL string, render func(*triage.Result) ([]byte, error)) {
+		if webhookURL == "" {
+			return
+		}
+		if err := checkHTTPURL(flag, webhookURL); err != nil {
+			errs = append(errs, err)
+		}
+		for _, r := range sampleResults() {
+			if _, err := render(r); err != nil {
+				errs = append(errs, fmt.Errorf("%s: render %s result: %w", backend, r.Status, err))
+			}
+		}
+	}
+	slackNotifier := slack.New(sc.App.SlackWebhookURL, log.Nop(), slack.WithTemplate(ts.Get(notify.DefaultTemplate)))
+	check("slack", "slack-webhook-url", sc.App.SlackWebhookURL, slackNotifier.Render)
+	check("mattermost", "mattermost-webhook-url", sc.App.MattermostWebhookURL, mattermost.Render)
+	check("teams", "teams-webhook-url", sc.App.TeamsWebhookURL, teams.Render)
+	check("discord", "discord-webhook-url", sc.App.DiscordWebhookURL, discord.New(sc.App.DiscordWebhookURL, log.Nop(), discord.WithExternalURL(sc.App.ExternalURL)).Render)
+	if sc.App.TraceURL != "" {
+		errs = append(errs, checkHTTPURL("trace-url", sc.App.TraceURL))
+	}
+	for _, u := range splitList(sc.App.WebhookOutURLs) {
+		check("webhook", "webhook-out-urls", u, webhook.Render)
+	}
+	return errors.Join(errs...)
+}
+
+// loadNotifyTemplates loads and parses the message templates, if configured.
+func loadNotifyTemplates(sc *serverConfig) (*notify.Templates, error) {
+	if sc.App.NotifyTemplates == "" {
+		return nil, nil
+	}
+	nc, err := notify.LoadConfig(sc.App.NotifyTemplates)
+	if err != nil {
+		return nil, err
+	}
+	return notify.New(nc, sc.App.ExternalURL)
+}
+
+// checkRouting loads and validates the receiver routing profiles, if
+// configured, including the templates they name.
+func checkRouting(sc *serverConfig) error {
+	if sc.App.RoutingConfig == "" {
+		return nil
+	}
+	rc, err := routing.LoadConfig(sc.App.RoutingConfig)
+	if err != nil {
+		return err
+	}
+	ts, err := loadNotifyTemplates(sc)
+	if err != nil {
+		// reported by checkNotifiers
+		return nil
+	}
+	_, err = routing.New(rc, log.Nop(), routing.WithNotifyTemplates(ts, sc.App.SlackWebhookURL))
+	return err
+}
+
+// checkFilter loads and validates the ingestion filter rules, if configured.
+func checkFilter(sc *serverConfig) error {
+	if sc.App.FilterConfig == "" {
+		return nil
+	}
+	_, err := filter.LoadConfig(sc.App.FilterConfig)
+	return err
+}
+
+// checkMaintenance loads and validates the maintenance windows, if
+// configured. Alertmanager is not contacted; an unreachable one only delays
+// silences, it does not stop the server.
+func checkMaintenance(sc *serverConfig) error {
+	if sc.App.MaintenanceConfig == "" {
+		return nil
+	}
+	_, err := maintenance.LoadConfig(sc.App.MaintenanceConfig)
+	return err
+}
+
+// checkEnrichment loads and validates the alert metadata, if configured,
+// fetching it when the source is a URL.
+func checkEnrichment(sc *serverConfig) error {
+	if sc.App.EnrichConfig == "" {
+		return nil
+	}
+	_, err := enrich.LoadConfig(context.Background(), sc.App.EnrichConfig)
+	return err
+}
+
+// checkRedaction loads and validates the tool output redaction patterns, if
+// configured.
+func checkRedaction(sc *serverConfig) error {
+	if sc.App.RedactConfig == "" {
+		return nil
+	}
+	_, err := redact.LoadConfig(sc.App.RedactConfig)
+	return err
+}
+
+// checkPrompt loads the prompt examples and redaction patterns, if
+// configured.
+func checkPrompt(sc *serverConfig) error {
+	_, err := promptMiddleware(&sc.App)
+	return err
+}
+
+// checkMCP loads and validates the MCP server config, if configured. It
+// does not start or connect to the servers.
+func checkMCP(sc *serverConfig) error {
+	if sc.App.MCPConfig == "" {
+		return nil
+	}
+	_, err := mcp.LoadConfig(sc.App.MCPConfig)
+	return err
+}
+
+// checkIssues builds the issue tracker, if configured, and renders an issue
+// for a sample result to catch template errors before the first triage does.
+func checkIssues(sc *serverConfig) error {
+	if sc.App.IssueTracker == "" {
+		return nil
+	}
+	tracker, err := newIssueTracker(&sc.App)
+	if err != nil {
+		return err
+	}
+	_, _, err = tracker.Render(sampleResults()[0])
+	return err
+}
+
+// checkRemediation loads and validates the remediation action catalog, if
+// configured. It does not run any action.
+func checkRemediation(sc *serverConfig) error {
+	if sc.App.RemediationConfig == "" {
+		return nil
+	}
+	_, err := remediation.LoadConfig(sc.App.RemediationConfig)
+	return err
+}
+
+// checkTenants loads and validates the tenants, if configured.
+func checkTenants(sc *serverConfig) error {
+	if sc.App.TenantsConfig == "" {
+		return nil
+	}
+	_, err := sc.loadTenants()
+	return err
+}
+
+func checkHTTPURL(name, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s: scheme must be http or https, got %q", name, u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%s: missing host in %q", name, raw)
+	}
+	return nil
+}
+
+// sampleResults returns a completed and a failed triage covering every field
+// notifiers render.
+func sampleResults() []*triage.Result {
+	created := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	return []*triage.Result{
+		{
+			ID:           "01CHECKCONFIGSAMPLE000001",
+			Fingerprint:  "0123456789abcdef",
+			Status:       triage.StatusComplete,
+			Alert:        "HighCPU",
+			Severity:     "critical",
+			Summary:      "CPU usage above 90% for 5 minutes",
+			GeneratorURL: "http://prometheus.example/graph?g0.expr=up",
+			Analysis:     "A runaway process on web-1 is saturating all cores.",
+			ToolsUsed:    []string{"query_metrics", "query_logs"},
+			CreatedAt:    created,
+			CompletedAt:  created.Add(42 * time.Second),
+			Duration:     42,
+			LLMTime:      30,
+			ToolTime:     12,
+			TokensIn:     1200,
+			TokensOut:    340,
+			ToolCalls:    3,
+			Model:        "claude-sonnet-4-20250514",
+		},
+		{
+			ID:        "01CHECKCONFIGSAMPLE000002",
+			Status:    triage.StatusFailed,
+			Alert:     "DiskFull",
+			Severity:  "warning",
+			CreatedAt: created,
+		},
+	}
+}
+
+// flattenErrors unwraps errors.Join trees into their leaf errors.
+func flattenErrors(err error) []error {
+	if j, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // walking the join tree, not matching
+		var out []error
+		for _, e := range j.Unwrap() {
+			if e != nil {
+				out = append(out, flattenErrors(e)...)
+			}
+		}
+		return out
+	}
+	return []error{err}
+}
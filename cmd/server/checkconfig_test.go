@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func validCheckArgs(extra ...string) []string {
+	return append([]string{
+		"-prometheus-endpoint", "http://prometheus:9090",
+		"-claude-api-key", "sk-test",
+		"-api-token", "t",
+	}, extra...)
+}
+
+func writeTenantsConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeIssueTemplate(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "issue.tmpl")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunCheckConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "valid",
+			args: validCheckArgs("-slack-webhook-url", "https://hooks.slack.com/services/x", "-database-url", "postgres://vigil@db/vigil"),
+			want: []string{"ok    config file", "ok    settings", "ok    datasources", "ok    notifiers", "ok    routing", "ok    filter", "ok    enrichment", "ok    redaction", "ok    prompt", "ok    mcp", "ok    issues", "ok    remediation", "ok    tenants"},
+		},
+		{
+			name:    "missing filter config",
+			args:    validCheckArgs("-filter-config", "/nonexistent/filter.json"),
+			wantErr: true,
+			want:    []string{"FAIL  filter", "read filter config"},
+		},
+		{
+			name:    "enrichment runbook not a url",
+			args:    validCheckArgs("-enrich-config", writeConfigFile(t, `{"entries":[{"name":"db","labels":["service=db"],"runbook":"wiki/db"}]}`)),
+			wantErr: true,
+			want:    []string{"FAIL  enrichment", `entry "db": runbook must be an absolute http(s) URL`},
+		},
+		{
+			name:    "redaction pattern does not compile",
+			args:    validCheckArgs("-redact-config", writeConfigFile(t, `{"patterns":[{"name":"ticket","regex":"TICKET-("}]}`)),
+			wantErr: true,
+			want:    []string{"FAIL  redaction", `pattern "ticket"`},
+		},
+		{
+			name:    "prompt redaction does not compile",
+			args:    validCheckArgs("-prompt-redact-file", writeConfigFile(t, "# tickets\nTICKET-(\n")),
+			wantErr: true,
+			want:    []string{"FAIL  prompt", "line 2"},
+		},
+		{
+			name:    "issue template with unknown field",
+			args:    validCheckArgs("-issue-tracker", "github", "-issue-project", "acme/ops", "-issue-token", "t", "-issue-template", writeIssueTemplate(t, "{{.Verdict}}")),
+			wantErr: true,
+			want:    []string{"ok    settings", "FAIL  issues", "can't evaluate field Verdict"},
+		},
+		{
+			name: "settings from config file",
+			args: []string{"-config", writeConfigFile(t, "prometheus-endpoint: http://prometheus:9090\nclaude-api-key: sk-test\napi-token: t\n")},
+			want: []string{"ok    config file", "ok    settings"},
+		},
+		{
+			name:    "config file with unknown setting",
+			args:    validCheckArgs("-config", writeConfigFile(t, "prometheus-url: http://prometheus:9090\n")),
+			wantErr: true,
+			want:    []string{"FAIL  config file", "prometheus-url: unknown setting", "ok    settings"},
+		},
+		{
+			name:    "missing mcp config",
+			args:    validCheckArgs("-mcp-config", "/nonexistent/mcp.json"),
+			wantErr: true,
+			want:    []string{"FAIL  mcp", "read mcp config"},
+		},
+		{
+			name:    "remediation command uses undeclared param",
+			args:    validCheckArgs("-remediation-approval-token", "approve", "-remediation-config", writeConfigFile(t, `{"actions":[{"name":"restart_unit","description":"Restart a unit.","command":["systemctl","restart","{unit}"]}]}`)),
+			wantErr: true,
+			want:    []string{"ok    settings", "FAIL  remediation", `undeclared param "unit"`},
+		},
+		{
+			name:    "missing routing config",
+			args:    validCheckArgs("-routing-config", "/nonexistent/routing.json"),
+			wantErr: true,
+			want:    []string{"FAIL  routing", "read routing config"},
+		},
+		{
+			name:    "tenant token reuses api token",
+			args:    validCheckArgs("-tenants-config", writeTenantsConfig(t, `{"tenants":[{"id":"acme","token":"t"}]}`)),
+			wantErr: true,
+			want:    []string{"FAIL  tenants", `tenant "acme": token must differ from API_TOKEN`},
+		},
+		{
+			name:    "missing required settings are all listed",
+			args:    nil,
+			wantErr: true,
+			want:    []string{"FAIL  settings", "PROMETHEUS_ENDPOINT is required", "API_TOKEN is required", "CLAUDE_API_KEY is required"},
+		},
+		{
+			name:    "bad datasource scheme",
+			args:    validCheckArgs("-loki-endpoint", "loki:3100"),
+			wantErr: true,
+			want:    []string{"ok    settings", "FAIL  datasources", "loki-endpoint: scheme must be http or https"},
+		},
+		{
+			name:    "database url does not leak password",
+			args:    validCheckArgs("-database-url", "postgres://vigil:hunter2@db:notaport/vigil"),
+			wantErr: true,
+			want:    []string{"FAIL  datasources", "database-url: not a valid PostgreSQL connection string"},
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "notify template with unknown field",
+			args:    validCheckArgs("-slack-webhook-url", "https://hooks.slack.com/services/x", "-notify-templates", writeConfigFile(t, `{"templates":{"default":{"*":"{{.NoSuchField}}"}}}`)),
+			wantErr: true,
+			want:    []string{"FAIL  notifiers", `template "default"`, "ok    routing"},
+		},
+		{
+			name:    "routing profile names unknown notify template",
+			args:    validCheckArgs("-notify-templates", writeConfigFile(t, `{"templates":{"default":{"*":"{{.Alert}}"}}}`), "-routing-config", writeConfigFile(t, `{"profiles":[{"name":"db","receivers":["db"],"notify_template":"oncall","slack_webhook_url":"https://hooks.slack.com/services/db"}]}`)),
+			wantErr: true,
+			want:    []string{"ok    notifiers", "FAIL  routing", `unknown notify_template "oncall"`},
+		},
+		{
+			name:    "teams url with bad scheme",
+			args:    validCheckArgs("-mattermost-webhook-url", "https://chat.example.com/hooks/x", "-teams-webhook-url", "ftp://example.com/hook"),
+			wantErr: true,
+			want:    []string{"FAIL  notifiers", "teams-webhook-url: scheme must be http or https"},
+			notWant: []string{"mattermost-webhook-url"},
+		},
+		{
+			name:    "outbound webhook url without host",
+			args:    validCheckArgs("-webhook-out-urls", "https://hooks.example/a,https:///b"),
+			wantErr: true,
+			want:    []string{"FAIL  notifiers", "webhook-out-urls: missing host"},
+		},
+		{
+			name:    "slack url without host",
+			args:    validCheckArgs("-slack-webhook-url", "https:///services/x"),
+			wantErr: true,
+			want:    []string{"FAIL  notifiers", "slack-webhook-url: missing host"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			err := runCheckConfig(tt.args, &stdout, &stderr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, stdout.String())
+			}
+			out := stdout.String()
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output missing %q:\n%s", w, out)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(out+stderr.String(), w) {
+					t.Errorf("output contains %q:\n%s", w, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/linnemanlabs/go-core/httpmw"
+	"github.com/linnemanlabs/go-core/httpserver"
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/go-core/opshttp"
+	"github.com/linnemanlabs/go-core/otelx"
+	"github.com/linnemanlabs/go-core/prof"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+)
+
+// serverConfig groups the per-package configs the server registers, so the
+// server and check-config parse and validate exactly the same settings.
+type serverConfig struct {
+	// File is the YAML config file filling in settings not given as flags
+	// or environment variables.
+	File string
+
+	App    vc.Config
+	HTTP   httpserver.Config
+	HTTPMW httpmw.Config
+	Log    log.Config
+	Ops    opshttp.Config
+	Prof   prof.Config
+	Trace  otelx.Config
+}
+
+// register binds every package's flags to fs.
+func (c *serverConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.File, "config", "", "YAML file of settings keyed by flag name, used for any not given as a flag or VIGIL_ environment variable (empty = none)")
+	c.App.RegisterFlags(fs)
+	c.HTTP.RegisterFlags(fs)
+	c.HTTPMW.RegisterFlags(fs)
+	c.Log.RegisterFlags(fs)
+	c.Ops.RegisterFlags(fs)
+	c.Prof.RegisterFlags(fs)
+	c.Trace.RegisterFlags(fs)
+}
+
+// validate runs each package's validation plus the cross-cutting checks that
+// only main can do.
+func (c *serverConfig) validate() error {
+	errs := []error{
+		c.App.Validate(),
+		c.HTTP.Validate(),
+		c.HTTPMW.Validate(),
+		c.Log.Validate(),
+		c.Ops.Validate(),
+		c.Prof.Validate(),
+		c.Trace.Validate(),
+	}
+	if c.App.APIPort == c.Ops.Port {
+		errs = append(errs, fmt.Errorf("http and admin ports must differ (both %d)", c.App.APIPort))
+	}
+	return errors.Join(errs...)
+}
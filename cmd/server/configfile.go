@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/linnemanlabs/go-core/cfg"
+	"gopkg.in/yaml.v3"
+)
+
+// fill completes fs after its command line is parsed: environment
+// variables first, then the config file for anything still unset, so
+// flags win over the environment and the environment over the file.
+func (c *serverConfig) fill(fs *flag.FlagSet, logf func(format string, args ...any)) error {
+	cfg.FillFromEnv(fs, "VIGIL_", logf)
+	if c.File == "" {
+		return nil
+	}
+	return applyConfigFile(fs, c.File)
+}
+
+// applyConfigFile sets the flags named in a YAML file that are not already
+// set. Keys are flag names without the dash, such as prometheus-endpoint.
+// A list is joined with commas for the comma-separated flags.
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	var values map[string]any
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	if err := dec.Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var errs []error
+	for _, k := range keys {
+		f := fs.Lookup(k)
+		switch {
+		case f == nil:
+			errs = append(errs, fmt.Errorf("%s: unknown setting", k))
+			continue
+		case k == "config":
+			errs = append(errs, errors.New("config: cannot be set in the config file"))
+			continue
+		case set[k]:
+			continue
+		}
+		v, err := flagValue(values[k])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			continue
+		}
+		if err := fs.Set(k, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", k, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// flagValue renders a YAML value as flag text.
+func flagValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			s, err := flagValue(e)
+			if err != nil {
+				return "", err
+			}
+			if _, isList := e.([]any); isList {
+				return "", errors.New("nested lists are not supported")
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("want a string, number, boolean or list, got %T", v)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vigil.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Not parallel: sets an environment variable.
+func TestServerConfigFill_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+prometheus-endpoint: http://prometheus-from-file:9090
+loki-endpoint: http://loki-from-file:3100
+http-port: 9000
+max-tool-rounds: 20
+redact-thinking: true
+batch-severities: [info, warning]
+incident-group-by: cluster,namespace
+claude-model: from-file
+`)
+	t.Setenv("VIGIL_LOKI_ENDPOINT", "http://loki-from-env:3100")
+	t.Setenv("VIGIL_CLAUDE_MODEL", "from-env")
+
+	var sc serverConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sc.register(fs)
+	if err := fs.Parse([]string{"-config", path, "-claude-model", "from-flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.fill(fs, nil); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+
+	app := sc.App
+	checks := []struct{ name, got, want string }{
+		{"file only", app.PrometheusEndpoint, "http://prometheus-from-file:9090"},
+		{"env over file", app.LokiEndpoint, "http://loki-from-env:3100"},
+		{"flag over env and file", app.ClaudeModel, "from-flag"},
+		{"list joined", app.BatchSeverities, "info,warning"},
+		{"string list kept", app.IncidentGroupBy, "cluster,namespace"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, c.got, c.want)
+		}
+	}
+	if app.APIPort != 9000 || app.MaxToolRounds != 20 || !app.RedactThinking {
+		t.Errorf("numbers and booleans not applied: port=%d rounds=%d redact=%v", app.APIPort, app.MaxToolRounds, app.RedactThinking)
+	}
+}
+
+func TestApplyConfigFile_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"unknown key", "prometheus-endpoint: x\nprometheus_url: y\n", []string{"prometheus_url: unknown setting"}},
+		{"bad value", "http-port: eighty\n", []string{"http-port: parse error"}},
+		{"nested map", "slack:\n  webhook: x\n", []string{"slack: unknown setting"}},
+		{"map value", "http-port: {a: 1}\n", []string{"want a string, number, boolean or list"}},
+		{"config key", "config: other.yaml\n", []string{"config: cannot be set in the config file"}},
+		{"not yaml", "http-port: [\n", []string{"parse config file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sc serverConfig
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			sc.register(fs)
+			err := applyConfigFile(fs, writeConfigFile(t, tt.body))
+			if err == nil {
+				t.Fatal("applyConfigFile returned nil, want an error")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("error %q missing %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyConfigFile_Empty(t *testing.T) {
+	t.Parallel()
+
+	var sc serverConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sc.register(fs)
+	if err := applyConfigFile(fs, writeConfigFile(t, "# nothing yet\n")); err != nil {
+		t.Errorf("applyConfigFile on an empty file: %v", err)
+	}
+	if err := applyConfigFile(fs, "/nonexistent/vigil.yaml"); err == nil || !strings.Contains(err.Error(), "read config file") {
+		t.Errorf("err = %v, want read error", err)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/cfg"
+
+	"github.com/linnemanlabs/vigil/internal/anonymize"
+	"github.com/linnemanlabs/vigil/internal/archive"
+	"github.com/linnemanlabs/vigil/internal/postgres"
+	"github.com/linnemanlabs/vigil/internal/triage/pgstore"
+)
+
+const dbUsage = `usage: vigil db <export|import|anonymize> [flags]
+
+  export     write triage_runs, messages and tool_calls to a portable archive
+  import     load an archive into the configured database
+  anonymize  rewrite an existing archive with hostnames/IPs hashed and patterns redacted
+
+run "vigil db <command> -h" for command flags.
+`
+
+// runDB implements the "db" maintenance subcommands. They talk to Postgres
+// directly and do not start any listeners.
+func runDB(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, dbUsage)
+		return errors.New("missing db command")
+	}
+	switch args[0] {
+	case "export":
+		return runDBExport(ctx, args[1:], stdout, stderr)
+	case "import":
+		return runDBImport(ctx, args[1:], stdout, stderr)
+	case "anonymize":
+		return runDBAnonymize(args[1:], stdout, stderr)
+	default:
+		fmt.Fprint(stderr, dbUsage)
+		return fmt.Errorf("unknown db command %q", args[0])
+	}
+}
+
+type dbExportFlags struct {
+	databaseURL     string
+	output          string
+	from            string
+	to              string
+	anonymize       bool
+	anonymizeConfig string
+}
+
+func parseDBExportFlags(args []string, stderr io.Writer) (*dbExportFlags, pgstore.ExportFilter, error) {
+	var f dbExportFlags
+	fs := flag.NewFlagSet("db export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&f.databaseURL, "database-url", "", "PostgreSQL connection URL")
+	fs.StringVar(&f.output, "o", "-", "output archive path (- for stdout)")
+	fs.StringVar(&f.from, "from", "", "only triages created at or after this RFC 3339 time")
+	fs.StringVar(&f.to, "to", "", "only triages created before this RFC 3339 time")
+	fs.BoolVar(&f.anonymize, "anonymize", false, "hash hostnames/IPs and redact configured patterns while exporting")
+	fs.StringVar(&f.anonymizeConfig, "anonymize-config", "", "JSON anonymizer config (implies -anonymize)")
+	if err := fs.Parse(args); err != nil {
+		return nil, pgstore.ExportFilter{}, err
+	}
+	cfg.FillFromEnv(fs, "VIGIL_", nil)
+
+	var filter pgstore.ExportFilter
+	var errs []error
+	if f.databaseURL == "" {
+		errs = append(errs, errors.New("database-url is required"))
+	}
+	if f.from != "" {
+		t, err := time.Parse(time.RFC3339, f.from)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid -from %q (must be RFC 3339)", f.from))
+		}
+		filter.From = t
+	}
+	if f.to != "" {
+		t, err := time.Parse(time.RFC3339, f.to)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid -to %q (must be RFC 3339)", f.to))
+		}
+		filter.To = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		errs = append(errs, errors.New("-from must be before -to"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, filter, err
+	}
+	return &f, filter, nil
+}
+
+func runDBExport(ctx context.Context, args []string, stdout, stderr io.Writer) (err error) {
+	f, filter, err := parseDBExportFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	var transforms []archive.Transform
+	if f.anonymize || f.anonymizeConfig != "" {
+		anon, err := newAnonymizer(f.anonymizeConfig)
+		if err != nil {
+			return err
+		}
+		transforms = append(transforms, anon.Record)
+	}
+
+	store, err := openDBStore(ctx, f.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	out := stdout
+	if f.output != "-" {
+		file, err := os.Create(f.output)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", f.output, err)
+		}
+		defer func() {
+			if cerr := file.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+		out = file
+	}
+
+	w, err := archive.NewWriter(out, archive.Header{From: filter.From, To: filter.To, Anonymized: len(transforms) > 0}, transforms...)
+	if err != nil {
+		return err
+	}
+	if err := store.Export(ctx, w, filter); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("flush archive: %w", err)
+	}
+
+	return json.NewEncoder(stderr).Encode(map[string]any{"exported": w.Counts()})
+}
+
+func runDBImport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var databaseURL, input string
+	fs := flag.NewFlagSet("db import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&databaseURL, "database-url", "", "PostgreSQL connection URL")
+	fs.StringVar(&input, "i", "-", "input archive path (- for stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg.FillFromEnv(fs, "VIGIL_", nil)
+	if databaseURL == "" {
+		return errors.New("database-url is required")
+	}
+
+	in := io.Reader(os.Stdin)
+	if input != "-" {
+		file, err := os.Open(input) //nolint:gosec // G304: path is supplied by the operator running the command
+		if err != nil {
+			return fmt.Errorf("open %s: %w", input, err)
+		}
+		defer func() { _ = file.Close() }()
+		in = file
+	}
+
+	r, err := archive.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	store, err := openDBStore(ctx, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	res, err := store.Import(ctx, r)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	return json.NewEncoder(stdout).Encode(res)
+}
+
+// runDBAnonymize rewrites an archive offline, for data exported without -anonymize.
+func runDBAnonymize(args []string, stdout, stderr io.Writer) (err error) {
+	var input, output, config string
+	fs := flag.NewFlagSet("db anonymize", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&input, "i", "-", "input archive path (- for stdin)")
+	fs.StringVar(&output, "o", "-", "output archive path (- for stdout)")
+	fs.StringVar(&config, "config", "", "JSON anonymizer config (default: hash IPs, internal hostnames, and host labels)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	anon, err := newAnonymizer(config)
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if input != "-" {
+		file, err := os.Open(input) //nolint:gosec // G304: path is supplied by the operator running the command
+		if err != nil {
+			return fmt.Errorf("open %s: %w", input, err)
+		}
+		defer func() { _ = file.Close() }()
+		in = file
+	}
+	r, err := archive.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	out := stdout
+	if output != "-" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", output, err)
+		}
+		defer func() {
+			if cerr := file.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+		out = file
+	}
+
+	h := r.Header()
+	h.CreatedAt = time.Time{}
+	h.Anonymized = true
+	w, err := archive.NewWriter(out, h, anon.Record)
+	if err != nil {
+		return err
+	}
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.Write(rec); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("flush archive: %w", err)
+	}
+	return json.NewEncoder(stderr).Encode(map[string]any{"anonymized": w.Counts()})
+}
+
+func newAnonymizer(configPath string) (*anonymize.Anonymizer, error) {
+	c := anonymize.DefaultConfig()
+	if configPath != "" {
+		var err error
+		if c, err = anonymize.LoadConfig(configPath); err != nil {
+			return nil, err
+		}
+	}
+	return anonymize.New(c)
+}
+
+func openDBStore(ctx context.Context, databaseURL string) (*pgstore.Store, error) {
+	pool, err := postgres.NewPool(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("postgres pool: %w", err)
+	}
+	store, err := pgstore.New(ctx, pool, noop.NewTracerProvider())
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("pgstore init: %w", err)
+	}
+	return store, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/archive"
+)
+
+func TestRunDB_UnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{nil, {"vacuum"}} {
+		if err := runDB(context.Background(), args, io.Discard, io.Discard); err == nil {
+			t.Errorf("runDB(%v) = nil, want error", args)
+		}
+	}
+}
+
+func TestParseDBExportFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"ok", []string{"-database-url", "postgres://x", "-from", "2026-01-01T00:00:00Z", "-to", "2026-02-01T00:00:00Z"}, ""},
+		{"missing url", []string{}, "database-url is required"},
+		{"bad from", []string{"-database-url", "postgres://x", "-from", "yesterday"}, "invalid -from"},
+		{"bad to", []string{"-database-url", "postgres://x", "-to", "2026-13-01"}, "invalid -to"},
+		{"inverted", []string{"-database-url", "postgres://x", "-from", "2026-02-01T00:00:00Z", "-to", "2026-01-01T00:00:00Z"}, "-from must be before -to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VIGIL_DATABASE_URL", "")
+
+			f, filter, err := parseDBExportFlags(tt.args, io.Discard)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if f.output != "-" {
+				t.Errorf("output = %q, want stdout default", f.output)
+			}
+			if !filter.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !filter.To.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("filter = %+v", filter)
+			}
+		})
+	}
+}
+
+func TestParseDBExportFlags_DatabaseURLFromEnv(t *testing.T) {
+	t.Setenv("VIGIL_DATABASE_URL", "postgres://from-env")
+
+	f, _, err := parseDBExportFlags(nil, io.Discard)
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if f.databaseURL != "postgres://from-env" {
+		t.Errorf("databaseURL = %q", f.databaseURL)
+	}
+}
+
+func TestRunDBAnonymize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.vigil.gz")
+	out := filepath.Join(dir, "out.vigil.gz")
+
+	f, err := os.Create(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := archive.NewWriter(f, archive.Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRun(&archive.Run{ID: "01A", Fingerprint: "fp", Summary: "host 10.0.0.1 down"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	if err := runDB(context.Background(), []string{"anonymize", "-i", in, "-o", out}, io.Discard, io.Discard); err != nil {
+		t.Fatalf("runDB anonymize: %v", err)
+	}
+
+	rf, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rf.Close() }()
+	r, err := archive.NewReader(rf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if !r.Header().Anonymized {
+		t.Error("header not marked anonymized")
+	}
+	rec, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if rec.Run.ID != "01A" || strings.Contains(rec.Run.Summary, "10.0.0.1") {
+		t.Errorf("run = %+v", rec.Run)
+	}
+}
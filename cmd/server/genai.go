@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/linnemanlabs/go-core/otelx"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/genai"
+)
+
+// newGenAIExporter sets up the OTLP log pipeline gen_ai events are exported
+// on, to the same collector as traces and under the same service name. The
+// returned shutdown flushes events still batched.
+func newGenAIExporter(ctx context.Context, appCfg *vc.Config, traceOpts *otelx.Options) (*genai.Exporter, func(context.Context) error, error) {
+	if traceOpts.Endpoint == "" {
+		return nil, nil, errors.New("GENAI_EVENTS requires OTLP_ENDPOINT")
+	}
+	capture, err := genai.ParseCapture(appCfg.GenAICapture)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(traceOpts.Endpoint)}
+	if traceOpts.Insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	exp, err := otlploggrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("otlp log exporter: %w", err)
+	}
+	res, _ := resource.New(ctx, resource.WithAttributes(
+		semconv.ServiceNameKey.String(traceOpts.Service+"."+traceOpts.Component),
+		semconv.ServiceVersionKey.String(traceOpts.Version),
+	))
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp)),
+	)
+	return genai.New(lp.Logger("github.com/linnemanlabs/vigil/internal/genai"), capture), lp.Shutdown, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/notify/issue"
+)
+
+// newIssueTracker builds the configured issue tracker, loading a custom
+// body template if one is set.
+func newIssueTracker(appCfg *vc.Config) (*issue.Tracker, error) {
+	opts := []issue.Option{
+		issue.WithAPIURL(appCfg.IssueAPIURL),
+		issue.WithLabels(splitList(appCfg.IssueLabels)),
+		issue.WithBaseURL(appCfg.ExternalURL),
+	}
+	if appCfg.IssueTemplate != "" {
+		tmpl, err := issue.LoadTemplate(appCfg.IssueTemplate)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, issue.WithTemplate(tmpl))
+	}
+	return issue.New(appCfg.IssueTracker, appCfg.IssueProject, appCfg.IssueToken, opts...)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -3,21 +3,21 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	otelpyroscope "github.com/grafana/otel-profiling-go"
-	"github.com/linnemanlabs/go-core/cfg"
 	"github.com/linnemanlabs/go-core/opshttp"
 	"github.com/linnemanlabs/go-core/prof"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -38,20 +38,66 @@ import (
 
 	"github.com/linnemanlabs/vigil/internal/alertapi"
 	"github.com/linnemanlabs/vigil/internal/authmw"
-	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/compressmw"
+	"github.com/linnemanlabs/vigil/internal/llm"
 	"github.com/linnemanlabs/vigil/internal/llm/claude"
+	"github.com/linnemanlabs/vigil/internal/maintenance"
+	"github.com/linnemanlabs/vigil/internal/mcp"
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/notify/discord"
+	"github.com/linnemanlabs/vigil/internal/notify/mattermost"
 	"github.com/linnemanlabs/vigil/internal/notify/slack"
+	"github.com/linnemanlabs/vigil/internal/notify/teams"
+	"github.com/linnemanlabs/vigil/internal/notify/webhook"
 	"github.com/linnemanlabs/vigil/internal/postgres"
+	"github.com/linnemanlabs/vigil/internal/ratelimitmw"
+	"github.com/linnemanlabs/vigil/internal/redact"
+	"github.com/linnemanlabs/vigil/internal/remediation"
+	"github.com/linnemanlabs/vigil/internal/replay"
+	"github.com/linnemanlabs/vigil/internal/routing"
+	"github.com/linnemanlabs/vigil/internal/share"
+	"github.com/linnemanlabs/vigil/internal/sizing"
 	"github.com/linnemanlabs/vigil/internal/tools"
 	"github.com/linnemanlabs/vigil/internal/triage"
 	"github.com/linnemanlabs/vigil/internal/triage/memstore"
 	"github.com/linnemanlabs/vigil/internal/triage/pgstore"
+	"github.com/linnemanlabs/vigil/internal/ui"
 )
 
 const appName = "vigil"
 const component = "server"
 
 func main() {
+	// maintenance subcommands run instead of the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "db":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			err := runDB(ctx, os.Args[2:], os.Stdout, os.Stderr)
+			stop()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "db:", err)
+				os.Exit(1)
+			}
+			return
+		case "replay":
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			err := runReplay(ctx, os.Args[2:], os.Stdout, os.Stderr)
+			stop()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "replay:", err)
+				os.Exit(1)
+			}
+			return
+		case "check-config":
+			if err := runCheckConfig(os.Args[2:], os.Stdout, os.Stderr); err != nil {
+				fmt.Fprintln(os.Stderr, "check-config:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	if err := run(); err != nil {
 		fmt.Fprintln(os.Stderr, "fatal error:", err)
 		os.Exit(1)
@@ -70,26 +116,12 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	vi := v.Get()
 
 	// each package registers its own flags and options struct
-	var (
-		appCfg    vc.Config
-		httpCfg   httpserver.Config
-		httpmwCfg httpmw.Config
-		logCfg    log.Config
-		opsCfg    opshttp.Config
-		profCfg   prof.Config
-		traceCfg  otelx.Config
-	)
-
-	// register flags for each package, which will be parsed into the shared config struct
-	appCfg.RegisterFlags(flag.CommandLine)
-	httpCfg.RegisterFlags(flag.CommandLine)
-	httpmwCfg.RegisterFlags(flag.CommandLine)
-	logCfg.RegisterFlags(flag.CommandLine)
-	opsCfg.RegisterFlags(flag.CommandLine)
-	profCfg.RegisterFlags(flag.CommandLine)
-	traceCfg.RegisterFlags(flag.CommandLine)
-	var showVersion bool
+	var sc serverConfig
+	sc.register(flag.CommandLine)
+	appCfg, httpCfg, httpmwCfg, logCfg, opsCfg, profCfg, traceCfg := &sc.App, &sc.HTTP, &sc.HTTPMW, &sc.Log, &sc.Ops, &sc.Prof, &sc.Trace
+	var showVersion, validateOnly bool
 	flag.BoolVar(&showVersion, "V", false, "Print version+build information and exit")
+	flag.BoolVar(&validateOnly, "validate-config", false, "Check the configuration, as check-config does, and exit")
 
 	// parse flags to get config values from cmdline, we check env vars next which do not override cmdline flags
 	flag.Parse()
@@ -103,26 +135,19 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	}
 
 	// Fill in config values from environment variables with prefix VIGIL_,
-	// these do not override cmdline flags
-	cfg.FillFromEnv(flag.CommandLine, "VIGIL_", func(format string, args ...any) {
+	// then from the config file; neither overrides cmdline flags
+	fileErr := sc.fill(flag.CommandLine, func(format string, args ...any) {
 		fmt.Fprintf(os.Stderr, format+"\n", args...)
 	})
-
-	if err := errors.Join(
-		appCfg.Validate(),
-		httpCfg.Validate(),
-		httpmwCfg.Validate(),
-		logCfg.Validate(),
-		opsCfg.Validate(),
-		profCfg.Validate(),
-		traceCfg.Validate(),
-	); err != nil {
-		return fmt.Errorf("configuration validation failed: %w", err)
+	if validateOnly {
+		return checkConfig(&sc, fileErr, os.Stdout)
+	}
+	if fileErr != nil {
+		return fileErr
 	}
 
-	// cross-cutting checks that only main can validate
-	if appCfg.APIPort == opsCfg.Port {
-		return fmt.Errorf("http and admin ports must differ (both %d)", appCfg.APIPort)
+	if err := sc.validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
 	// initialize logger early
@@ -163,6 +188,26 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 		"log_level", logCfg.Level,
 	)
 
+	// Derive container-aware defaults for anything left at 0 (auto) in config and hand the
+	// runtime a soft memory limit below the cgroup limit unless GOMEMLIMIT was set explicitly
+	sz := sizing.Detect()
+	memLimit := sizing.ApplyMemoryLimit(sz)
+	maxTriages := appCfg.MaxConcurrentTriages
+	if maxTriages == 0 {
+		maxTriages = sz.Workers
+	}
+	toolConcurrency := appCfg.ToolConcurrency
+	if toolConcurrency == 0 {
+		toolConcurrency = sz.ToolConcurrency
+	}
+	L.Info(ctx, "runtime sizing",
+		"gomaxprocs", sz.Procs,
+		"cgroup_memory_limit", sz.MemoryLimit,
+		"go_memory_limit", memLimit,
+		"max_concurrent_triages", maxTriages,
+		"tool_concurrency", toolConcurrency,
+	)
+
 	// Setup pyroscope profiling early so we get profiles from the entire app lifetime
 	profOpts := profCfg.ToOptions()
 	profOpts.AppName = v.AppName
@@ -215,42 +260,145 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	m.SetBuildInfoFromVersion(v.AppName, "server", &vi)
 	m.SetProfilingActive(profErr == nil && profCfg.EnablePyroscope)
 
-	// Initialize the tool registry and register available tools
-	registry := tools.NewRegistry()
-
-	// Register Prometheus query tools if endpoint is configured, this allows the triage engine to query metrics for alert investigation and correlation
-	if appCfg.PrometheusEndpoint != "" {
-		prometheusQuery := tools.NewPrometheusQuery(appCfg.PrometheusEndpoint, appCfg.PrometheusTenantID)
-		registry.Register(prometheusQuery)
-		L.Info(ctx, "registered tool", "name", prometheusQuery.Name(), "endpoint", appCfg.PrometheusEndpoint)
-		prometheusQueryRange := tools.NewPrometheusQueryRange(appCfg.PrometheusEndpoint, appCfg.PrometheusTenantID)
-		registry.Register(prometheusQueryRange)
-		L.Info(ctx, "registered tool", "name", prometheusQueryRange.Name(), "endpoint", appCfg.PrometheusEndpoint)
+	// Initialize the tool registry and register available tools. A tool whose
+	// data source keeps failing is withheld from the LLM until a probe succeeds,
+	// and with cache TTLs set, repeated identical calls reuse a recent result.
+	tm := &toolMetrics{
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vigil_tool_circuit_state",
+			Help: "Tool circuit breaker state (0 = closed, 1 = open, 2 = half-open), by tool and tenant (empty = default tenant).",
+		}, []string{"tool", "tenant"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_tool_cache_lookups_total",
+			Help: "Calls to cached tools, by tool, tenant (empty = default tenant) and result (hit, miss, bypass).",
+		}, []string{"tool", "tenant", "result"}),
+		limitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "vigil_tool_concurrency_wait_seconds",
+			Help:    "Time calls to concurrency-limited tools waited for a slot, by tool and tenant (empty = default tenant).",
+			Buckets: append([]float64{0}, prometheus.ExponentialBuckets(0.01, 2, 12)...), // 0, 10ms .. ~20s
+		}, []string{"tool", "tenant"}),
+	}
+	m.Registry().MustRegister(tm.circuitState, tm.cacheLookups, tm.limitWait)
+
+	registry, err := newToolRegistry(ctx, L, appCfg, datasources{
+		PrometheusEndpoint: appCfg.PrometheusEndpoint,
+		PrometheusTenantID: appCfg.PrometheusTenantID,
+		LokiEndpoint:       appCfg.LokiEndpoint,
+		LokiTenantID:       appCfg.LokiTenantID,
+	}, "", tm)
+	if err != nil {
+		return err
 	}
 
-	// Register Loki query tool if endpoint is configured, this allows the triage engine to query logs for alert investigation and correlation
-	if appCfg.LokiEndpoint != "" {
-		lokiQuery := tools.NewLokiQuery(appCfg.LokiEndpoint, appCfg.LokiTenantID)
-		registry.Register(lokiQuery)
-		L.Info(ctx, "registered tool", "name", lokiQuery.Name(), "endpoint", appCfg.LokiEndpoint)
+	// Tools from external MCP servers, registered for the default tenant only
+	// since the servers are not scoped to a tenant's datasources.
+	var mcpClients mcp.Clients
+	if appCfg.MCPConfig != "" {
+		mc, err := mcp.LoadConfig(appCfg.MCPConfig)
+		if err != nil {
+			return err
+		}
+		if mcpClients, err = mcp.ConnectAll(ctx, mc, L); err != nil {
+			return err
+		}
+		defer func() { _ = mcpClients.Close(context.Background()) }()
+		for _, c := range mcpClients {
+			ts, err := c.Tools(ctx)
+			if err != nil {
+				return err
+			}
+			for _, t := range ts {
+				if _, ok := registry.Get(t.Name()); ok {
+					return fmt.Errorf("mcp %s: tool %s is already registered", c.Name(), t.Name())
+				}
+				registry.Register(t)
+				L.Info(ctx, "registered tool", "name", t.Name(), "mcp_server", c.Name())
+			}
+		}
 	}
 
 	// Initialize the triage store
 	var triageStore triage.Store
+	var decisionLog triage.DecisionLog
+	var auditLog triage.AuditLog
+	var digestLog triage.DigestLog
+	var elector triage.Elector
+	var outbox triage.Outbox
+	var suppressions triage.SuppressionStore
+	var pingDB func(context.Context) error // for the readiness check
 	if appCfg.DatabaseURL != "" {
 		pool, err := postgres.NewPool(ctx, appCfg.DatabaseURL)
 		if err != nil {
 			return fmt.Errorf("postgres pool: %w", err)
 		}
 		defer pool.Close()
-		pgStore, err := pgstore.New(ctx, pool, otel.GetTracerProvider())
+		pingDB = pool.Ping
+		var storeOpts []pgstore.Option
+		if appCfg.DatabaseReadURL != "" {
+			replica, err := postgres.NewReplicaPool(ctx, appCfg.DatabaseReadURL)
+			if err != nil {
+				return fmt.Errorf("postgres read replica pool: %w", err)
+			}
+			defer replica.Close()
+			router := postgres.NewReadRouter(pool, replica, time.Duration(appCfg.DatabaseReadMaxLag)*time.Second)
+			m.Registry().MustRegister(
+				prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+					Name: "vigil_db_replica_lag_seconds",
+					Help: "Replay lag of the database read replica at its last successful check.",
+				}, func() float64 { return router.Lag().Seconds() }),
+				prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+					Name: "vigil_db_replica_in_use",
+					Help: "1 while triage reads go to the database read replica, 0 while they fall back to the primary.",
+				}, func() float64 {
+					if router.UsingReplica() {
+						return 1
+					}
+					return 0
+				}),
+			)
+			switch use, err := router.Check(ctx); {
+			case err != nil:
+				L.Warn(ctx, "database read replica unavailable, reading from the primary", "err", err)
+			case !use:
+				L.Warn(ctx, "database read replica lagging, reading from the primary", "lag", router.Lag())
+			default:
+				L.Info(ctx, "reading triages from the database read replica", "lag", router.Lag())
+			}
+			go router.Run(ctx, L, postgres.DefaultReplicaCheckInterval)
+			storeOpts = append(storeOpts, pgstore.WithReadPool(router))
+		}
+		if appCfg.StoredTurnMaxKB > 0 || appCfg.StoredConversationMB > 0 {
+			truncations := prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "vigil_store_truncations_total",
+				Help: "Conversation turns stored truncated, by the limit that cut them (turn, conversation).",
+			}, []string{"reason"})
+			m.Registry().MustRegister(truncations)
+			storeOpts = append(storeOpts, pgstore.WithContentLimits(pgstore.ContentLimits{
+				MaxTurnBytes:         appCfg.StoredTurnMaxKB << 10,
+				MaxConversationBytes: int64(appCfg.StoredConversationMB) << 20,
+				OnTruncate:           func(reason string) { truncations.WithLabelValues(reason).Inc() },
+			}))
+		}
+		pgStore, err := pgstore.New(ctx, pool, otel.GetTracerProvider(), storeOpts...)
 		if err != nil {
 			return fmt.Errorf("pgstore init: %w", err)
 		}
-		triageStore = pgStore
+		triageStore, decisionLog, auditLog, digestLog, elector, outbox, suppressions = pgStore, pgStore, pgStore, pgStore, pgStore, pgStore, pgStore
+		if appCfg.StoreCacheSize > 0 {
+			cacheLookups := prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "vigil_store_cache_lookups_total",
+				Help: "Triage reads by ID, by whether the finished result was served from memory (hit) or the database (miss).",
+			}, []string{"result"})
+			m.Registry().MustRegister(cacheLookups)
+			triageStore = triage.NewCachedStore(pgStore, triage.StoreCacheConfig{
+				MaxEntries: appCfg.StoreCacheSize,
+				OnLookup:   func(result string) { cacheLookups.WithLabelValues(result).Inc() },
+			})
+		}
 		L.Info(ctx, "using postgres store")
 	} else {
-		triageStore = memstore.New()
+		memStore := memstore.New()
+		triageStore, decisionLog, auditLog, digestLog, elector, outbox, suppressions = memStore, memStore, memStore, memStore, memStore, memStore, memStore
 		L.Info(ctx, "using in-memory store (no database-url configured)")
 	}
 
@@ -279,31 +427,458 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	))
 
 	// Initialize the triage engine (pure - no store dependency).
-	claudeEngine := triage.NewEngine(claudeProvider, registry, L, triageMetrics.Hooks(), otel.GetTracerProvider())
+	// Shared across all triages so parallel runs stay within the API tier's limits.
+	llmLimiter := triage.NewRateLimiter(triage.RateLimits{
+		RequestsPerMinute:     appCfg.LLMRequestsPerMinute,
+		InputTokensPerMinute:  appCfg.LLMInputTPM,
+		OutputTokensPerMinute: appCfg.LLMOutputTPM,
+		MaxWait:               time.Duration(appCfg.LLMMaxWaitSeconds) * time.Second,
+	})
+	// Per-triage limits; an alert can raise or lower them with vigil.io/max-* annotations.
+	runBudget := triage.Budget{
+		ToolCalls:    appCfg.MaxToolRounds,
+		InputTokens:  appCfg.MaxInputTokens,
+		OutputTokens: appCfg.MaxOutputTokens,
+	}
+	engineOpts := []triage.EngineOption{
+		triage.WithToolConcurrency(toolConcurrency),
+		triage.WithRateLimiter(llmLimiter),
+		triage.WithDefaultBudget(runBudget),
+		triage.WithThinking(appCfg.ThinkingBudget),
+	}
+	if appCfg.LLMTemperature != nil {
+		engineOpts = append(engineOpts, triage.WithTemperature(*appCfg.LLMTemperature))
+	}
+	// Conversations nearing the model's context window are summarized
+	// before the provider would reject them.
+	if appCfg.ContextWindowTokens > 0 {
+		engineOpts = append(engineOpts, triage.WithContextWindow(appCfg.ContextWindowTokens))
+		L.Info(ctx, "context window check enabled", "context_window_tokens", appCfg.ContextWindowTokens, "count_tokens", appCfg.LLMCountTokens)
+	}
+	// Log lines and query results can carry credentials and personal data,
+	// which are scrubbed before they reach the model, the store or Slack.
+	if appCfg.RedactToolOutput || appCfg.RedactConfig != "" {
+		var rc redact.Config
+		if appCfg.RedactConfig != "" {
+			if rc, err = redact.LoadConfig(appCfg.RedactConfig); err != nil {
+				return err
+			}
+		}
+		scrubber, err := redact.New(rc)
+		if err != nil {
+			return err
+		}
+		engineOpts = append(engineOpts, triage.WithScrubber(scrubber))
+		L.Info(ctx, "tool output redaction enabled", "redact_config", appCfg.RedactConfig, "builtin_patterns", !rc.DisableBuiltin, "custom_patterns", len(rc.Patterns), "entropy", !rc.Entropy.Disabled)
+	}
+	// Teams add worked examples, scrub what must not leave the process and
+	// trim old tool output from what is sent, without changing the engine.
+	promptChain, err := promptMiddleware(appCfg)
+	if err != nil {
+		return err
+	}
+	if len(promptChain) > 0 {
+		engineOpts = append(engineOpts, triage.WithPromptMiddleware(promptChain...))
+		L.Info(ctx, "prompt middleware enabled", "examples_file", appCfg.PromptExamplesFile, "redact_file", appCfg.PromptRedactFile, "compact_bytes", appCfg.PromptCompactBytes)
+	}
+	// Prompts, completions and tool calls go to LLM observability platforms
+	// as OpenTelemetry gen_ai events, alongside the llm.call spans.
+	if appCfg.GenAIEvents {
+		exporter, shutdownEvents, err := newGenAIExporter(ctx, appCfg, traceOpts)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = shutdownEvents(context.Background()) }()
+		engineOpts = append(engineOpts, triage.WithLLMObserver(exporter))
+		L.Info(ctx, "gen_ai events enabled", "otlp_endpoint", traceOpts.Endpoint, "capture", appCfg.GenAICapture)
+	}
+	// Recordings let a triage be replayed later without the model or the
+	// datasources, see the replay subcommand.
+	if appCfg.RecordDir != "" {
+		recorder, err := replay.NewRecorder(appCfg.RecordDir, L)
+		if err != nil {
+			return err
+		}
+		engineOpts = append(engineOpts, triage.WithLLMObserver(recorder), triage.WithToolObserver(recorder))
+		L.Info(ctx, "triage recording enabled", "record_dir", appCfg.RecordDir)
+	}
+	// Calls move to the fallback model while the primary keeps failing; each
+	// engine tracks its own provider's failures.
+	withFallback := func(provider triage.Provider) triage.Provider { return provider }
+	if appCfg.LLMFallbackModel != "" {
+		llmFallbacks := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_llm_fallbacks_total",
+			Help: "LLM calls sent to the fallback model, by failure class of the primary (or cooldown while it is skipped).",
+		}, []string{"class"})
+		m.Registry().MustRegister(llmFallbacks)
+		var failOn []string
+		for _, class := range strings.Split(appCfg.LLMFallbackOn, ",") {
+			failOn = append(failOn, strings.TrimSpace(class))
+		}
+		withFallback = func(provider triage.Provider) triage.Provider {
+			return llm.NewFallback(provider, claude.New(appCfg.ClaudeAPIKey, appCfg.LLMFallbackModel), llm.FallbackConfig{
+				Classify: claude.ClassifyError,
+				FailOn:   failOn,
+				Attempts: appCfg.LLMFallbackAttempts,
+				Timeout:  time.Duration(appCfg.LLMFallbackTimeout) * time.Second,
+				Cooldown: time.Duration(appCfg.LLMFallbackCooldown) * time.Second,
+				OnFallback: func(class string) {
+					llmFallbacks.WithLabelValues(class).Inc()
+					L.Warn(ctx, "llm call falling back", "class", class, "model", appCfg.LLMFallbackModel)
+				},
+			})
+		}
+		L.Info(ctx, "llm fallback enabled", "model", appCfg.LLMFallbackModel, "fail_on", failOn)
+	}
+	newEngine := func(provider triage.Provider, registry *tools.Registry) *triage.LLMEngine {
+		opts := engineOpts
+		// Tokens are counted with the engine's own model, whose tokenizer
+		// the estimate should match.
+		if counter, ok := provider.(triage.TokenCounter); ok && appCfg.LLMCountTokens {
+			opts = append(slices.Clip(opts), triage.WithTokenCounter(counter))
+		}
+		return triage.NewEngine(withFallback(provider), registry, L, triageMetrics.Hooks(), otel.GetTracerProvider(), opts...)
+	}
+	claudeEngine := newEngine(claudeProvider, registry)
 	if claudeEngine == nil {
 		return fmt.Errorf("failed to initialize triage engine for Claude provider")
 	}
 
-	// Initialize Slack notifier for triage result notifications.
-	var notifier triage.Notifier
+	// Operator message templates, checked against sample results here so a
+	// broken one stops startup instead of the first notification.
+	var notifyTemplates *notify.Templates
+	if appCfg.NotifyTemplates != "" {
+		nc, err := notify.LoadConfig(appCfg.NotifyTemplates)
+		if err != nil {
+			return err
+		}
+		if notifyTemplates, err = notify.New(nc, appCfg.ExternalURL); err != nil {
+			return fmt.Errorf("notify templates: %w", err)
+		}
+		L.Info(ctx, "notification templates loaded", "templates", notifyTemplates.Names())
+	}
+
+	// Initialize the chat notifiers for triage result notifications; every
+	// configured one gets each result.
+	var notifiers notify.Multi
+	var slackNotifier *slack.Notifier
 	if appCfg.SlackWebhookURL != "" {
-		notifier = slack.New(appCfg.SlackWebhookURL, L)
+		slackNotifier = slack.New(appCfg.SlackWebhookURL, L,
+			slack.WithSnapshots(appCfg.SlackBotToken, appCfg.SlackSnapshotChannel),
+			slack.WithThreadedNarrative(appCfg.SlackBotToken, appCfg.SlackThreadChannel),
+			slack.WithTemplate(notifyTemplates.Get(notify.DefaultTemplate)),
+			slack.WithTraceURL(appCfg.TraceURL))
+		notifiers = append(notifiers, slackNotifier)
 		L.Info(ctx, "notifier enabled", "type", "slack")
-	} else {
+	}
+	if appCfg.MattermostWebhookURL != "" {
+		notifiers = append(notifiers, mattermost.New(appCfg.MattermostWebhookURL, L))
+		L.Info(ctx, "notifier enabled", "type", "mattermost")
+	}
+	if appCfg.TeamsWebhookURL != "" {
+		notifiers = append(notifiers, teams.New(appCfg.TeamsWebhookURL, L))
+		L.Info(ctx, "notifier enabled", "type", "teams")
+	}
+	if appCfg.DiscordWebhookURL != "" {
+		notifiers = append(notifiers, discord.New(appCfg.DiscordWebhookURL, L, discord.WithExternalURL(appCfg.ExternalURL)))
+		L.Info(ctx, "notifier enabled", "type", "discord")
+	}
+	if appCfg.WebhookOutURLs != "" {
+		urls := splitList(appCfg.WebhookOutURLs)
+		notifiers = append(notifiers, webhook.New(webhook.Config{
+			URLs:     urls,
+			Secret:   appCfg.WebhookOutSecret,
+			Attempts: appCfg.WebhookOutAttempts,
+		}, L))
+		L.Info(ctx, "notifier enabled", "type", "webhook", "urls", len(urls), "signed", appCfg.WebhookOutSecret != "")
+	}
+	var notifier triage.Notifier
+	switch len(notifiers) {
+	case 0:
 		L.Warn(ctx, "no notifier configured, notifications will be silently dropped")
+	case 1:
+		notifier = notifiers[0]
+	default:
+		notifier = notifiers
+	}
+
+	svcOpts := []triage.ServiceOption{
+		triage.WithMaxConcurrent(maxTriages),
+		// Runs of one alertname beyond the cap wait, since they tend to reach the same conclusion.
+		triage.WithMaxPerAlertname(appCfg.MaxPerAlertname),
+		// Every accept or skip is recorded so a missing triage can be explained later.
+		triage.WithDecisionLog(decisionLog),
+		// Every lifecycle transition is kept, with who caused it.
+		triage.WithAuditLog(auditLog),
+		// Suppressions are stored, so every replica skips the same alerts.
+		triage.WithSuppressions(suppressions),
+		triage.WithElector(elector),
+		triage.WithOutbox(outbox),
+	}
+	// Reasoning text can quote sensitive alert or log data, so it may be kept out of the store.
+	if appCfg.RedactThinking {
+		svcOpts = append(svcOpts, triage.WithThinkingRedaction())
+	}
+
+	// Receiver-based routing profiles, so team intent encoded in Alertmanager
+	// routes carries over; operator rules that drop or downgrade alerts
+	// never worth a full triage, such as heartbeats; and service metadata
+	// the model would otherwise have to guess. All reload on SIGHUP and,
+	// with reload-seconds, when their sources change.
+	live := newLiveConfig(appCfg.RoutingConfig, appCfg.FilterConfig, appCfg.EnrichConfig, L)
+	live.routingOpts = []routing.Option{
+		routing.WithNotifyTemplates(notifyTemplates, appCfg.SlackWebhookURL),
+		routing.WithTraceURL(appCfg.TraceURL),
+	}
+	if appCfg.RoutingConfig != "" || appCfg.FilterConfig != "" || appCfg.EnrichConfig != "" {
+		configReloads := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_config_reloads_total",
+			Help: "Reloads of the routing, filter and enrichment configuration, by result (success, error).",
+		}, []string{"result"})
+		configGeneration := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_config_generation",
+			Help: "Generation of the routing, filter and enrichment configuration being served, starting at 1 and incremented by every successful reload.",
+		})
+		m.Registry().MustRegister(configReloads, configGeneration)
+		live.onReload = func(gen int64, err error) {
+			result := "success"
+			if err != nil {
+				result = "error"
+			}
+			configReloads.WithLabelValues(result).Inc()
+			configGeneration.Set(float64(gen))
+		}
+		if err := live.Load(ctx); err != nil {
+			return err
+		}
+		if appCfg.RoutingConfig != "" {
+			svcOpts = append(svcOpts, triage.WithProfiles(live))
+		}
+		if appCfg.FilterConfig != "" {
+			svcOpts = append(svcOpts, triage.WithFilter(live))
+		}
+		if appCfg.EnrichConfig != "" {
+			svcOpts = append(svcOpts, triage.WithEnricher(live))
+		}
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		defer signal.Stop(sighup)
+		go live.Run(ctx, sighup, time.Duration(appCfg.ReloadSeconds)*time.Second)
+	}
+
+	// Declared maintenance, from config windows and Alertmanager silences:
+	// matching alerts are skipped or triaged on a reduced budget with the
+	// maintenance named in the prompt.
+	if appCfg.MaintenanceConfig != "" {
+		mc, err := maintenance.LoadConfig(appCfg.MaintenanceConfig)
+		if err != nil {
+			return err
+		}
+		checker, err := maintenance.New(mc, L)
+		if err != nil {
+			return err
+		}
+		svcOpts = append(svcOpts, triage.WithMaintenance(checker))
+		go checker.Run(ctx)
+		L.Info(ctx, "maintenance windows enabled", "windows", len(mc.Windows), "alertmanager", mc.Alertmanager != nil)
+	}
+
+	// Completed triages of the configured severities open an issue, so follow-up work is tracked.
+	if appCfg.IssueTracker != "" {
+		tracker, err := newIssueTracker(appCfg)
+		if err != nil {
+			return fmt.Errorf("issue tracker init: %w", err)
+		}
+		severities := splitList(appCfg.IssueSeverities)
+		svcOpts = append(svcOpts, triage.WithIssueTracker(tracker, severities))
+		L.Info(ctx, "issue tracker enabled", "type", appCfg.IssueTracker, "project", appCfg.IssueProject, "severities", severities)
+	}
+
+	// The model may suggest remediations from the catalog; they only run when an operator approves one through the API.
+	var remediationCatalog *remediation.Catalog
+	if appCfg.RemediationConfig != "" {
+		rc, err := remediation.LoadConfig(appCfg.RemediationConfig)
+		if err != nil {
+			return err
+		}
+		if remediationCatalog, err = remediation.New(rc); err != nil {
+			return err
+		}
+		svcOpts = append(svcOpts, triage.WithActions(remediationCatalog))
+		L.Info(ctx, "remediation actions enabled", "actions", remediationCatalog.Names())
+	}
+
+	// Tenants authenticate with their own tokens and triage against their own datasources and settings.
+	apiTokens := map[string]string{appCfg.APIToken: ""}
+	if appCfg.TenantsConfig != "" {
+		tc, err := sc.loadTenants()
+		if err != nil {
+			return err
+		}
+		profiles := make(map[string]*triage.Profile, len(tc.Tenants))
+		for i := range tc.Tenants {
+			t := &tc.Tenants[i]
+			if profiles[t.ID], err = tenantProfile(ctx, L, appCfg, t, tm, newEngine); err != nil {
+				return err
+			}
+		}
+		maps.Copy(apiTokens, tc.Tokens())
+		svcOpts = append(svcOpts, triage.WithTenants(profiles))
+		L.Info(ctx, "tenants loaded", "path", appCfg.TenantsConfig, "tenants", len(tc.Tenants))
+	}
+
+	// Low-priority alerts go through the Message Batches API at half the cost, finishing whenever their batch does.
+	var batcher *claude.Batcher
+	if appCfg.BatchSeverities != "" {
+		var severities []string
+		for _, sev := range strings.Split(appCfg.BatchSeverities, ",") {
+			if sev = strings.TrimSpace(sev); sev != "" {
+				severities = append(severities, sev)
+			}
+		}
+		batcher = claude.NewBatcher(claudeProvider, claude.BatchConfig{
+			FlushInterval: time.Duration(appCfg.BatchFlushSeconds) * time.Second,
+			PollInterval:  time.Duration(appCfg.BatchPollSeconds) * time.Second,
+		}, L)
+		// Batches have their own rate limits, so the batch engine skips the shared limiter.
+		batchEngine := triage.NewEngine(batcher, registry, L, triageMetrics.Hooks(), otel.GetTracerProvider(),
+			triage.WithToolConcurrency(toolConcurrency),
+			triage.WithDefaultBudget(runBudget),
+			triage.WithThinking(appCfg.ThinkingBudget),
+			triage.WithContextWindow(appCfg.ContextWindowTokens),
+			triage.WithPromptMiddleware(promptChain...),
+		)
+		svcOpts = append(svcOpts, triage.WithBatch(batchEngine, severities))
+		L.Info(ctx, "batch mode enabled", "severities", severities, "flush_seconds", appCfg.BatchFlushSeconds, "poll_seconds", appCfg.BatchPollSeconds)
+	}
+
+	// Alerts that keep firing with the same analysis get a smaller budget, focusing spend on alerts that matter.
+	noiseWindow := time.Duration(appCfg.NoiseWindowHours) * time.Hour
+	if appCfg.NoiseDowngrade > 0 {
+		svcOpts = append(svcOpts, triage.WithNoiseDowngrade(appCfg.NoiseDowngrade, noiseWindow))
+	}
+
+	// Confident or urgent results reach Slack; the rest are only stored.
+	if appCfg.NotifyMinConfidence > 0 {
+		svcOpts = append(svcOpts, triage.WithNotifyMinConfidence(appCfg.NotifyMinConfidence))
+	}
+
+	// Related alerts completing together get one incident-level meta-triage over their analyses.
+	if appCfg.IncidentThreshold > 0 {
+		var groupBy []string
+		for _, l := range strings.Split(appCfg.IncidentGroupBy, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				groupBy = append(groupBy, l)
+			}
+		}
+		svcOpts = append(svcOpts, triage.WithIncidents(triage.IncidentConfig{
+			Threshold: appCfg.IncidentThreshold,
+			Window:    time.Duration(appCfg.IncidentWindowMinutes) * time.Minute,
+			GroupBy:   groupBy,
+		}))
+		L.Info(ctx, "incident mode enabled", "threshold", appCfg.IncidentThreshold, "window_minutes", appCfg.IncidentWindowMinutes, "group_by", groupBy)
+	}
+
+	// Past a submit rate, triage one alert per group and notify a single storm summary.
+	if appCfg.StormThreshold > 0 {
+		var groupBy []string
+		for _, l := range strings.Split(appCfg.StormGroupBy, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				groupBy = append(groupBy, l)
+			}
+		}
+		svcOpts = append(svcOpts, triage.WithStorms(triage.StormConfig{
+			Threshold: appCfg.StormThreshold,
+			GroupBy:   groupBy,
+		}))
+		L.Info(ctx, "storm mode enabled", "threshold_per_minute", appCfg.StormThreshold, "group_by", groupBy)
+	}
+
+	// Shed new alerts before an extreme storm runs the process out of memory.
+	if appCfg.MaxInFlightTriages > 0 || appCfg.MaxConversationMB > 0 {
+		svcOpts = append(svcOpts, triage.WithGuardrails(triage.Guardrails{
+			MaxInFlight:          appCfg.MaxInFlightTriages,
+			MaxConversationBytes: int64(appCfg.MaxConversationMB) << 20,
+		}))
+		L.Info(ctx, "load shedding guardrails enabled", "max_inflight", appCfg.MaxInFlightTriages, "max_conversation_mb", appCfg.MaxConversationMB)
 	}
 
 	// Initialize the triage service (owns dedup, lifecycle, async dispatch).
-	triageSvc := triage.NewService(triageStore, claudeEngine, L, triageMetrics, notifier, otel.GetTracerProvider())
+	triageSvc := triage.NewService(triageStore, claudeEngine, L, triageMetrics, notifier, otel.GetTracerProvider(), svcOpts...)
+
+	// Permanently remove soft-deleted triages once their restore window has passed.
+	if appCfg.DeletedRetentionHours > 0 {
+		go triageSvc.RunPurger(ctx, time.Duration(appCfg.DeletedRetentionHours)*time.Hour, time.Hour)
+		L.Info(ctx, "deleted triage purge enabled", "retention_hours", appCfg.DeletedRetentionHours)
+	}
+
+	// Submit queued batch requests and collect their results.
+	if batcher != nil {
+		go batcher.Run(ctx)
+	}
+
+	// Drop submit decisions once they are too old to be asked about.
+	if appCfg.DecisionRetentionDays > 0 {
+		go triageSvc.RunDecisionPurger(ctx, time.Duration(appCfg.DecisionRetentionDays)*24*time.Hour, time.Hour)
+		L.Info(ctx, "decision purge enabled", "retention_days", appCfg.DecisionRetentionDays)
+	}
+
+	// Retry notifications that could not be delivered when their triage
+	// finished. Profiles can notify without a default notifier, so this
+	// always runs.
+	go triageSvc.RunOutbox(ctx, 15*time.Second)
+
+	// Consume alert payloads from NATS JetStream or Kafka alongside HTTP.
+	if err := startBusIngest(ctx, L, appCfg, triageSvc, m.Registry()); err != nil {
+		return err
+	}
+
+	// End storm mode once the submit rate subsides and notify its summary.
+	if appCfg.StormThreshold > 0 {
+		go triageSvc.RunStormWatch(ctx, 10*time.Second)
+	}
+
+	// Keep noise scores current for the budget downgrade.
+	if appCfg.NoiseDowngrade > 0 {
+		go triageSvc.RunNoiseScorer(ctx, 15*time.Minute)
+		L.Info(ctx, "noise budget downgrade enabled", "threshold", appCfg.NoiseDowngrade, "window", noiseWindow)
+	}
+
+	// Post a digest of the previous period to Slack; replicas claim each one
+	// in the store so it is sent once.
+	if appCfg.Digest != "" && slackNotifier != nil {
+		go triageSvc.RunDigest(ctx, triage.DigestConfig{
+			Period:   appCfg.Digest,
+			Hour:     appCfg.DigestHour,
+			Notifier: slackNotifier,
+			Log:      digestLog,
+			BaseURL:  appCfg.ExternalURL,
+		})
+		L.Info(ctx, "digest enabled", "period", appCfg.Digest, "hour_utc", appCfg.DigestHour)
+	}
 
 	// setup toggle for server shutdown. this is used to fail readiness checks
 	// during shutdown to drain connections from load balancer before killing the process.
 	var shutdownGate health.ShutdownGate
 
-	// setup readiness checks, currently just the shutdown gate
+	// setup readiness checks: the shutdown gate and, with ready-check-seconds,
+	// the dependencies, probed in the background so the endpoints only read
+	// the last results.
 	readiness := health.All(
 		shutdownGate.Probe(),
 	)
+	readyHandler := health.ReadyzHandler(readiness)
+	if appCfg.ReadyCheckSeconds > 0 {
+		deps, err := newReadinessChecker(appCfg, pingDB, claudeProvider.Ping, m.Registry())
+		if err != nil {
+			return err
+		}
+		deps.CheckAll(ctx)
+		go deps.Run(ctx, time.Duration(appCfg.ReadyCheckSeconds)*time.Second)
+		readyHandler = deps.Handler(shutdownGate.Probe())
+		readiness = health.All(shutdownGate.Probe(), deps)
+		L.Info(ctx, "dependency readiness checks enabled", "interval_seconds", appCfg.ReadyCheckSeconds, "degraded", deps.Degraded())
+	}
 	// liveness is always true if the app is able to respond
 	liveness := health.Fixed(true, "")
 
@@ -333,8 +908,13 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	// setup main api chi router and middleware stack
 	r := chi.NewRouter()
 
-	// Compress text responses (we are JSON only for now)
-	r.Use(middleware.Compress(5, "application/json"))
+	// Compress text responses (JSON API plus the embedded UI assets)
+	r.Use(compressmw.Compress(compressmw.Options{
+		GzipLevel: appCfg.CompressGzipLevel,
+		ZstdLevel: appCfg.CompressZstdLevel,
+		MinSize:   appCfg.CompressMinBytes,
+		Types:     []string{"application/json", "text/html", "text/css", "text/javascript"},
+	}))
 
 	// Annotate logger (and tracer if trace is recording) with http.route from chi route pattern
 	r.Use(httpmw.AnnotateHTTPRoute)
@@ -352,17 +932,98 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	// Limit request body size, this is a wrapper around http.MaxBytesHandler which returns 413 if limit is exceeded
 	r.Use(httpmw.MaxBody(1024 * 64)) // 64KB to start with may adjust after i see real traffic
 
+	// JSON error envelope for unknown routes and methods outside /api/v1 too
+	r.NotFound(alertapi.NotFound)
+	r.MethodNotAllowed(alertapi.MethodNotAllowed)
+
 	// add health check endpoints to main listener
 	r.Get("/-/healthy", health.HealthzHandler(liveness))
-	r.Get("/-/ready", health.ReadyzHandler(readiness))
+	r.Get("/-/ready", readyHandler)
 
-	// register api routes behind bearer token auth
-	alertapiHTTP := alertapi.New(L, triageSvc)
+	// register api routes behind bearer token auth, each token authenticating as its tenant
+	var apiOpts []alertapi.Option
+	if appCfg.ShareKey != "" {
+		signer, err := share.New([]byte(appCfg.ShareKey))
+		if err != nil {
+			return fmt.Errorf("share links: %w", err)
+		}
+		apiOpts = append(apiOpts, alertapi.WithSharing(signer))
+		L.Info(ctx, "report share links enabled")
+	}
+	// test renders of the notification templates, for previewing edits
+	if notifyTemplates != nil {
+		apiOpts = append(apiOpts, alertapi.WithNotifyTemplates(notifyTemplates))
+	}
+	// suggested remediations run behind the approval token as well as the caller's bearer token
+	if remediationCatalog != nil {
+		apiOpts = append(apiOpts, alertapi.WithRemediation(triageSvc, appCfg.RemediationApprovalToken))
+	}
+	// storm mode state for dashboards and the on-call engineer
+	if appCfg.StormThreshold > 0 {
+		apiOpts = append(apiOpts, alertapi.WithStorm(triageSvc))
+	}
+	// throttle alert ingestion per client and token so one noisy or hostile sender cannot starve the rest
+	if appCfg.IngestIPRate > 0 || appCfg.IngestTokenRate > 0 || appCfg.IngestMaxConcurrent > 0 {
+		ingestThrottled := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_ingest_throttled_total",
+			Help: "Alert webhooks rejected with 429, by reason (client_ip, token, concurrency).",
+		}, []string{"reason"})
+		m.Registry().MustRegister(ingestThrottled)
+		apiOpts = append(apiOpts, alertapi.WithIngestLimit(ratelimitmw.Limit(ratelimitmw.Options{
+			IPRate:        appCfg.IngestIPRate,
+			IPBurst:       appCfg.IngestIPBurst,
+			TokenRate:     appCfg.IngestTokenRate,
+			TokenBurst:    appCfg.IngestTokenBurst,
+			MaxConcurrent: appCfg.IngestMaxConcurrent,
+			OnThrottle:    func(reason string) { ingestThrottled.WithLabelValues(reason).Inc() },
+		})))
+	}
+	// cap how much triage work one webhook can start, so a 500-alert storm payload is spread out or refused
+	if appCfg.WebhookMaxAlerts > 0 || appCfg.WebhookMaxTriages > 0 {
+		webhookOversized := prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "vigil_webhook_oversized_total",
+			Help: "Webhooks refused with 413 for carrying more than -webhook-max-alerts alerts.",
+		})
+		webhookOverflow := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_webhook_overflow_alerts_total",
+			Help: "Alerts past a webhook's -webhook-max-triages limit, by outcome (queued, rejected).",
+		}, []string{"outcome"})
+		webhookQueueDepth := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_webhook_overflow_queue_depth",
+			Help: "Overflow alerts waiting to be submitted with -webhook-overflow=queue.",
+		})
+		m.Registry().MustRegister(webhookOversized, webhookOverflow, webhookQueueDepth)
+		apiOpts = append(apiOpts, alertapi.WithBatchLimits(alertapi.BatchLimits{
+			MaxAlerts:    appCfg.WebhookMaxAlerts,
+			MaxTriages:   appCfg.WebhookMaxTriages,
+			Overflow:     appCfg.WebhookOverflow,
+			QueueSize:    appCfg.WebhookQueueSize,
+			OnOversized:  webhookOversized.Inc,
+			OnOverflow:   func(outcome string) { webhookOverflow.WithLabelValues(outcome).Inc() },
+			OnQueueDepth: func(n int) { webhookQueueDepth.Set(float64(n)) },
+		}))
+	}
+	alertapiHTTP := alertapi.New(L, triageSvc, apiOpts...)
+	go alertapiHTTP.RunOverflow(ctx)
 	r.Group(func(r chi.Router) {
-		r.Use(authmw.BearerToken(appCfg.APIToken))
+		r.Use(authmw.TenantTokens(apiTokens))
 		alertapiHTTP.RegisterRoutes(r)
 	})
 
+	// admin routes (restoring deleted triages) take their own token and are off without one
+	if appCfg.AdminAPIToken != "" {
+		r.Group(func(r chi.Router) {
+			r.Use(authmw.BearerToken(appCfg.AdminAPIToken))
+			alertapiHTTP.RegisterAdminRoutes(r)
+		})
+	}
+
+	// shared reports authenticate with the signed token in their link instead of a bearer token
+	alertapiHTTP.RegisterPublicRoutes(r)
+
+	// static UI is public; it asks for the API token and sends it with each /api/v1 call
+	ui.RegisterRoutes(r)
+
 	// middleware stack for main listener, order matters these are wrappers, outermost sees raw request
 	// first and is last to see response, innermost is last to see request and first to see response but
 	// has access to the full rich context from outer middleware and handlers
@@ -465,6 +1126,7 @@ func run() error { //nolint:gocognit // cognit of 37 is reasonable for now can s
 	stopFns := []stopFn{
 		{"alertapi http server", alertapiHTTPStop},
 		{"ops http server", opsHTTPStop},
+		{"mcp servers", mcpClients.Close},
 		{"otel", shutdownOtelx},
 	}
 
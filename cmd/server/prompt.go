@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// examplesSeparator is a line separating examples in -prompt-examples-file.
+const examplesSeparator = "---"
+
+// promptMiddleware builds the prompt middleware chain from the -prompt-*
+// settings: examples are added first, so the redaction that follows covers
+// them too, and compaction runs last.
+func promptMiddleware(c *vc.Config) ([]triage.PromptMiddleware, error) {
+	var chain []triage.PromptMiddleware
+	if c.PromptExamplesFile != "" {
+		examples, err := loadPromptExamples(c.PromptExamplesFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, triage.FewShotPrompt(examples...))
+	}
+	if c.PromptRedactFile != "" {
+		patterns, err := loadPromptRedactions(c.PromptRedactFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, triage.RedactPrompt(patterns...))
+	}
+	if c.PromptCompactBytes > 0 {
+		chain = append(chain, triage.CompactPrompt(c.PromptCompactKeep, c.PromptCompactBytes))
+	}
+	return chain, nil
+}
+
+// loadPromptExamples reads example analyses separated by lines of ---.
+func loadPromptExamples(path string) ([]string, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("read prompt examples: %w", err)
+	}
+	var examples []string
+	var cur []string
+	flush := func() {
+		if ex := strings.TrimSpace(strings.Join(cur, "\n")); ex != "" {
+			examples = append(examples, ex)
+		}
+		cur = nil
+	}
+	for line := range strings.Lines(string(b)) {
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) == examplesSeparator {
+			flush()
+			continue
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("prompt examples %s: no examples", path)
+	}
+	return examples, nil
+}
+
+// loadPromptRedactions reads one regular expression per line, skipping
+// blank lines and lines starting with #.
+func loadPromptRedactions(path string) ([]*regexp.Regexp, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("read prompt redactions: %w", err)
+	}
+	var patterns []*regexp.Regexp
+	var errs []error
+	n := 0
+	for line := range strings.Lines(string(b)) {
+		n++
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("prompt redactions %s line %d: %w", path, n, err))
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("prompt redactions %s: no patterns", path)
+	}
+	return patterns, nil
+}
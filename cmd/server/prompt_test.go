@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestPromptMiddleware(t *testing.T) {
+	t.Parallel()
+
+	examples := writeConfigFile(t, "Root cause: disk full.\n---\n\nRoot cause: expired certificate.\n---\n")
+	redactions := writeConfigFile(t, "# customer ids\n\ncust-[0-9]+\n")
+	chain, err := promptMiddleware(&vc.Config{
+		PromptExamplesFile: examples,
+		PromptRedactFile:   redactions,
+		PromptCompactBytes: 256,
+		PromptCompactKeep:  2,
+	})
+	if err != nil {
+		t.Fatalf("promptMiddleware: %v", err)
+	}
+	if len(chain) != 3 {
+		t.Fatalf("chain has %d middlewares, want 3", len(chain))
+	}
+
+	req := &triage.LLMRequest{
+		System:   "Investigate the alert for cust-42.",
+		Messages: []triage.Message{{Role: "user", Content: []triage.ContentBlock{{Type: "text", Text: "cust-42 reports errors"}}}},
+	}
+	for _, mw := range chain {
+		if err := mw.Process(context.Background(), req, triage.PromptInfo{}); err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+	}
+	if strings.Count(req.System, "<example>") != 2 || !strings.Contains(req.System, "expired certificate") {
+		t.Errorf("system prompt = %q, want both examples", req.System)
+	}
+	if strings.Contains(req.System, "cust-42") || strings.Contains(req.Messages[0].Content[0].Text, "cust-42") {
+		t.Errorf("request not redacted: %q, %q", req.System, req.Messages[0].Content[0].Text)
+	}
+
+	if chain, err := promptMiddleware(&vc.Config{}); err != nil || len(chain) != 0 {
+		t.Errorf("unconfigured chain = %d middlewares, %v; want none", len(chain), err)
+	}
+}
+
+func TestPromptMiddleware_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cfg  vc.Config
+		want string
+	}{
+		{"missing examples", vc.Config{PromptExamplesFile: "/nonexistent/examples.md"}, "read prompt examples"},
+		{"empty examples", vc.Config{PromptExamplesFile: writeConfigFile(t, "---\n\n---\n")}, "no examples"},
+		{"bad pattern", vc.Config{PromptRedactFile: writeConfigFile(t, "ok\n(\n")}, "line 2"},
+		{"no patterns", vc.Config{PromptRedactFile: writeConfigFile(t, "# nothing yet\n")}, "no patterns"},
+	}
+	for _, tt := range tests {
+		if _, err := promptMiddleware(&tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/readiness"
+)
+
+// Readiness check timeouts for dependencies READY_CHECK_TIMEOUTS leaves out.
+const (
+	defaultDBCheckTimeout    = 2 * time.Second
+	defaultReadyCheckTimeout = 5 * time.Second
+)
+
+// newReadinessChecker builds the checker for the configured dependencies.
+// Postgres is critical: a replica that cannot store results should leave
+// rotation. Prometheus, Loki and the Anthropic API only degrade readiness,
+// since they are shared by every replica and failing all of them would stop
+// alert intake without helping triage. pingDB is nil without a database.
+func newReadinessChecker(appCfg *vc.Config, pingDB func(context.Context) error, pingLLM func(context.Context) error, reg prometheus.Registerer) (*readiness.Checker, error) {
+	timeouts, err := vc.ParseReadyCheckTimeouts(appCfg.ReadyCheckTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	timeout := func(dep string, def time.Duration) time.Duration {
+		if d, ok := timeouts[dep]; ok {
+			return d
+		}
+		return def
+	}
+
+	client := &http.Client{}
+	var deps []readiness.Dependency
+	if pingDB != nil {
+		deps = append(deps, readiness.Dependency{
+			Name: "postgres", Critical: true, Timeout: timeout("postgres", defaultDBCheckTimeout), Check: pingDB,
+		})
+	}
+	if appCfg.PrometheusEndpoint != "" {
+		deps = append(deps, readiness.Dependency{
+			Name:    "prometheus",
+			Timeout: timeout("prometheus", defaultReadyCheckTimeout),
+			Check:   readiness.HTTPCheck(client, strings.TrimRight(appCfg.PrometheusEndpoint, "/")+"/-/ready", orgIDHeader(appCfg.PrometheusTenantID)),
+		})
+	}
+	if appCfg.LokiEndpoint != "" {
+		deps = append(deps, readiness.Dependency{
+			Name:    "loki",
+			Timeout: timeout("loki", defaultReadyCheckTimeout),
+			Check:   readiness.HTTPCheck(client, strings.TrimRight(appCfg.LokiEndpoint, "/")+"/ready", orgIDHeader(appCfg.LokiTenantID)),
+		})
+	}
+	deps = append(deps, readiness.Dependency{
+		Name: "anthropic", Timeout: timeout("anthropic", defaultReadyCheckTimeout), Check: pingLLM,
+	})
+	return readiness.New(deps, reg), nil
+}
+
+// orgIDHeader is the tenant header for Mimir and Loki, nil without a tenant.
+func orgIDHeader(tenantID string) map[string]string {
+	if tenantID == "" {
+		return nil
+	}
+	return map[string]string{"X-Scope-OrgID": tenantID}
+}
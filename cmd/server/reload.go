@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/enrich"
+	"github.com/linnemanlabs/vigil/internal/filter"
+	"github.com/linnemanlabs/vigil/internal/routing"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// liveConfig is the configuration that can change without a restart: the
+// routing profiles, which carry each team's prompt instructions and Slack
+// webhook, the filter rules and the enrichment metadata. It satisfies
+// triage.ProfileResolver, triage.Filter and triage.Enricher, serving
+// whichever generation was loaded last.
+//
+// A reload builds every source before swapping any in, so a bad edit to one
+// leaves the running configuration untouched.
+type liveConfig struct {
+	routingPath  string
+	filterPath   string
+	enrichSource string // a file or an http(s) URL
+	logger       log.Logger
+
+	// onReload, if set, is called after every reload attempt with the
+	// generation now being served and the attempt's error, if any.
+	onReload func(generation int64, err error)
+
+	// routingOpts are passed to every routing.New.
+	routingOpts []routing.Option
+
+	mu       sync.Mutex // serializes reloads
+	modTimes map[string]time.Time
+	// enrichSum is the SHA-256 of the enrichment body last read from a URL,
+	// which has no modification time to compare.
+	enrichSum  [sha256.Size]byte
+	router     atomic.Pointer[routing.Router]
+	filter     atomic.Pointer[filter.Filter]
+	enricher   atomic.Pointer[enrich.Enricher]
+	generation atomic.Int64
+}
+
+func newLiveConfig(routingPath, filterPath, enrichSource string, logger log.Logger) *liveConfig {
+	return &liveConfig{
+		routingPath:  routingPath,
+		filterPath:   filterPath,
+		enrichSource: enrichSource,
+		logger:       logger,
+		modTimes:     make(map[string]time.Time),
+	}
+}
+
+// Resolve returns the profile for al from the current routing profiles.
+func (c *liveConfig) Resolve(al *alert.Alert) *triage.Profile {
+	r := c.router.Load()
+	if r == nil {
+		return nil
+	}
+	return r.Resolve(al)
+}
+
+// Match returns the rule al matches in the current filter rules.
+func (c *liveConfig) Match(al *alert.Alert) *triage.FilterRule {
+	f := c.filter.Load()
+	if f == nil {
+		return nil
+	}
+	return f.Match(al)
+}
+
+// Enrich returns the metadata for al from the current enrichment entries.
+func (c *liveConfig) Enrich(al *alert.Alert) *triage.Metadata {
+	e := c.enricher.Load()
+	if e == nil {
+		return nil
+	}
+	return e.Enrich(al)
+}
+
+// Generation is the number of configurations loaded so far, starting at 1
+// for the one loaded at startup.
+func (c *liveConfig) Generation() int64 {
+	return c.generation.Load()
+}
+
+// Load reads, validates and builds every configured source, then swaps them
+// in together. On error the previous generation keeps serving.
+func (c *liveConfig) Load(ctx context.Context) error {
+	return c.reload(ctx, nil)
+}
+
+// reload is Load with the enrichment body already fetched by changed, so a
+// poll reads an enrichment URL once and loads exactly what it compared. A
+// nil enrichBody fetches it again.
+func (c *liveConfig) reload(ctx context.Context, enrichBody []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.load(ctx, enrichBody)
+	if c.onReload != nil {
+		c.onReload(c.generation.Load(), err)
+	}
+	return err
+}
+
+func (c *liveConfig) load(ctx context.Context, enrichBody []byte) error {
+	modTimes := make(map[string]time.Time)
+	var errs []error
+	stat := func(path string) {
+		fi, err := os.Stat(path)
+		if err == nil {
+			modTimes[path] = fi.ModTime()
+		}
+	}
+
+	var router *routing.Router
+	routingProfiles := 0
+	if c.routingPath != "" {
+		stat(c.routingPath)
+		rc, err := routing.LoadConfig(c.routingPath)
+		if err == nil {
+			router, err = routing.New(rc, c.logger, c.routingOpts...)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		routingProfiles = len(rc.Profiles)
+	}
+
+	var f *filter.Filter
+	filterRules := 0
+	if c.filterPath != "" {
+		stat(c.filterPath)
+		fc, err := filter.LoadConfig(c.filterPath)
+		if err == nil {
+			f, err = filter.New(fc)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		filterRules = len(fc.Rules)
+	}
+
+	var e *enrich.Enricher
+	var enrichSum [sha256.Size]byte
+	enrichEntries := 0
+	if c.enrichSource != "" {
+		if !enrich.IsURL(c.enrichSource) {
+			stat(c.enrichSource)
+		}
+		var ec enrich.Config
+		var err error
+		b := enrichBody
+		if b == nil {
+			b, err = enrich.Read(ctx, c.enrichSource)
+		}
+		if err == nil {
+			enrichSum = sha256.Sum256(b)
+			ec, err = enrich.ParseConfig(b, c.enrichSource)
+		}
+		if err == nil {
+			e, err = enrich.New(ec)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		enrichEntries = len(ec.Entries)
+	}
+
+	// Remember what was read even when it failed, so polling does not retry
+	// the same bad edit every interval.
+	c.modTimes = modTimes
+	c.enrichSum = enrichSum
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	c.router.Store(router)
+	c.filter.Store(f)
+	c.enricher.Store(e)
+	gen := c.generation.Add(1)
+	c.logger.Info(ctx, "configuration loaded",
+		"generation", gen,
+		"routing_config", c.routingPath,
+		"profiles", routingProfiles,
+		"filter_config", c.filterPath,
+		"rules", filterRules,
+		"enrich_config", c.enrichSource,
+		"enrich_entries", enrichEntries,
+	)
+	return nil
+}
+
+// changed reports whether any configured file's modification time differs
+// from the last load. An enrichment URL has no modification time, so it is
+// fetched, outside the lock so a slow server does not hold up reloads, and
+// its body compared with the last one loaded; a failed fetch counts as
+// changed, so the reload reports the error. The fetched body is returned
+// for reload.
+func (c *liveConfig) changed(ctx context.Context) (bool, []byte) {
+	var body []byte
+	if enrich.IsURL(c.enrichSource) {
+		b, err := enrich.Read(ctx, c.enrichSource)
+		if err != nil {
+			return true, nil
+		}
+		body = b
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if body != nil && sha256.Sum256(body) != c.enrichSum {
+		return true, body
+	}
+	for _, path := range []string{c.routingPath, c.filterPath, c.enrichSource} {
+		if path == "" || enrich.IsURL(path) {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			// A file being replaced can briefly be missing; the next
+			// check picks up the new one.
+			continue
+		}
+		if !fi.ModTime().Equal(c.modTimes[path]) {
+			return true, body
+		}
+	}
+	return false, nil
+}
+
+// Run reloads on every value from sighup and, when interval is positive,
+// whenever a source changes. It returns when ctx is done.
+func (c *liveConfig) Run(ctx context.Context, sighup <-chan os.Signal, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		var enrichBody []byte
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			c.logger.Info(ctx, "reloading configuration", "trigger", "sighup")
+		case <-tick:
+			var changed bool
+			if changed, enrichBody = c.changed(ctx); !changed {
+				continue
+			}
+			c.logger.Info(ctx, "reloading configuration", "trigger", "poll")
+		}
+		if err := c.reload(ctx, enrichBody); err != nil {
+			c.logger.Error(ctx, err, "configuration reload failed, keeping the previous configuration",
+				"generation", c.Generation())
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestLiveConfig_Load(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	routingPath := filepath.Join(dir, "routing.json")
+	filterPath := filepath.Join(dir, "filter.json")
+	write := func(path, body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(routingPath, `{"profiles":[{"name":"db","receivers":["db-team"],"instructions":"check replication first"}]}`)
+	write(filterPath, `{"rules":[{"name":"heartbeat","labels":["alertname=\"Watchdog\""],"action":"skip"}]}`)
+
+	var reloads []int64
+	var failures int
+	c := newLiveConfig(routingPath, filterPath, "", log.Nop())
+	c.onReload = func(gen int64, err error) {
+		reloads = append(reloads, gen)
+		if err != nil {
+			failures++
+		}
+	}
+	ctx := context.Background()
+	if err := c.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	db := &alert.Alert{Receiver: "db-team"}
+	watchdog := &alert.Alert{Labels: map[string]string{"alertname": "Watchdog"}}
+	if p := c.Resolve(db); p == nil || p.Instructions != "check replication first" {
+		t.Fatalf("Resolve = %+v, want the db profile", p)
+	}
+	if r := c.Match(watchdog); r == nil || r.Name != "heartbeat" {
+		t.Fatalf("Match = %+v, want the heartbeat rule", r)
+	}
+
+	// A bad edit to one file keeps both files' previous generation.
+	write(routingPath, `{"profiles":[{"name":"db","receivers":["db-team"],"instructions":"new"}]}`)
+	write(filterPath, `{"rules":[{"name":"heartbeat","action":"drop"}]}`)
+	if err := c.Load(ctx); err == nil {
+		t.Fatal("Load accepted an invalid filter file")
+	}
+	if p := c.Resolve(db); p.Instructions != "check replication first" {
+		t.Errorf("instructions = %q after a failed reload, want the previous ones", p.Instructions)
+	}
+	if c.Generation() != 1 {
+		t.Errorf("generation = %d after a failed reload, want 1", c.Generation())
+	}
+
+	// Fixing it swaps both in.
+	write(filterPath, `{"rules":[]}`)
+	if err := c.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p := c.Resolve(db); p.Instructions != "new" {
+		t.Errorf("instructions = %q, want the reloaded ones", p.Instructions)
+	}
+	if r := c.Match(watchdog); r != nil {
+		t.Errorf("Match = %+v, want no rule after reload", r)
+	}
+	if len(reloads) != 3 || reloads[2] != 2 || failures != 1 {
+		t.Errorf("reloads = %v with %d failures, want [1 1 2] with 1", reloads, failures)
+	}
+}
+
+func TestLiveConfig_Changed(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "filter.json")
+	if err := os.WriteFile(path, []byte(`{"rules":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := newLiveConfig("", path, "", log.Nop())
+	ctx := context.Background()
+	if err := c.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if changed, _ := c.changed(ctx); changed {
+		t.Error("changed = true right after Load")
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := c.changed(ctx); !changed {
+		t.Error("changed = false after the file was modified")
+	}
+}
+
+func TestLiveConfig_ChangedURL(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	fetches := 0
+	body := `{"entries":[{"name":"checkout","labels":["service=\"checkout\""],"owner":"payments"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newLiveConfig("", "", srv.URL, log.Nop())
+	ctx := context.Background()
+	if err := c.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if changed, _ := c.changed(ctx); changed {
+		t.Error("changed = true for an unchanged enrichment body")
+	}
+
+	mu.Lock()
+	body = `{"entries":[{"name":"checkout","labels":["service=\"checkout\""],"owner":"checkout-team"}]}`
+	fetches = 0
+	mu.Unlock()
+	changed, fetched := c.changed(ctx)
+	if !changed {
+		t.Error("changed = false after the enrichment body changed")
+	}
+	if err := c.reload(ctx, fetched); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	mu.Lock()
+	if fetches != 1 {
+		t.Errorf("poll and reload fetched the URL %d times, want once", fetches)
+	}
+	mu.Unlock()
+	if md := c.Enrich(&alert.Alert{Labels: map[string]string{"service": "checkout"}}); md == nil || md.Owner != "checkout-team" {
+		t.Errorf("enrichment after reload = %+v, want owner checkout-team", md)
+	}
+	if changed, _ := c.changed(ctx); changed {
+		t.Error("changed = true right after reloading the new body")
+	}
+	if c.Generation() != 2 {
+		t.Errorf("generation = %d, want 2", c.Generation())
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/cfg"
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/llm/claude"
+	"github.com/linnemanlabs/vigil/internal/replay"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const replayUsage = `usage: vigil replay [flags] RECORDING.jsonl
+
+Re-runs a triage recorded with -record-dir. Tool calls are answered from the
+recording, and so are model calls unless -live is set, in which case Claude
+sees the recorded alert and tool outputs with the current prompt.
+
+flags may also be set as VIGIL_<FLAG>, e.g. VIGIL_CLAUDE_API_KEY.
+`
+
+// replayResult is the -json output of the replay subcommand.
+type replayResult struct {
+	Status       triage.Status        `json:"status"`
+	Analysis     string               `json:"analysis"`
+	Model        string               `json:"model,omitempty"`
+	InputTokens  int                  `json:"input_tokens"`
+	OutputTokens int                  `json:"output_tokens"`
+	ToolCalls    int                  `json:"tool_calls"`
+	Conversation *triage.Conversation `json:"conversation"`
+}
+
+// runReplay implements the "replay" subcommand. It needs neither the
+// database nor the datasources, and only reaches Claude with -live.
+func runReplay(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, replayUsage)
+		fs.PrintDefaults()
+	}
+	live := fs.Bool("live", false, "send model calls to Claude instead of replaying the recorded responses")
+	apiKey := fs.String("claude-api-key", "", "API key for -live")
+	model := fs.String("claude-model", "claude-sonnet-4-20250514", "Claude model for -live")
+	asJSON := fs.Bool("json", false, "print the result and conversation as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg.FillFromEnv(fs, "VIGIL_", nil)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one recording file")
+	}
+
+	rec, err := replay.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	provider := rec.Provider()
+	if *live {
+		if *apiKey == "" {
+			return errors.New("-live requires -claude-api-key")
+		}
+		provider = claude.New(*apiKey, *model)
+	}
+
+	engine := triage.NewEngine(provider, rec.Registry(), log.Nop(), triage.EngineHooks{}, noop.NewTracerProvider())
+	rr := engine.Run(ctx, rec.TriageID, &rec.Alert, nil)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(replayResult{
+			Status:       rr.Status,
+			Analysis:     rr.Analysis,
+			Model:        rr.Model,
+			InputTokens:  rr.InputTokensUsed,
+			OutputTokens: rr.OutputTokensUsed,
+			ToolCalls:    rr.ToolCalls,
+			Conversation: rr.Conversation,
+		})
+	}
+	writeConversation(stdout, rr.Conversation)
+	fmt.Fprintf(stdout, "status: %s, tokens in %d, out %d, tool calls %d\n\n%s\n",
+		rr.Status, rr.InputTokensUsed, rr.OutputTokensUsed, rr.ToolCalls, rr.Analysis)
+	return nil
+}
+
+// writeConversation prints a turn-by-turn summary of conv, with tool
+// results cut short.
+func writeConversation(w io.Writer, conv *triage.Conversation) {
+	if conv == nil {
+		return
+	}
+	for i, turn := range conv.Turns {
+		fmt.Fprintf(w, "--- turn %d: %s\n", i+1, turn.Role)
+		for _, b := range turn.Content {
+			switch b.Type {
+			case "text":
+				fmt.Fprintln(w, b.Text)
+			case "tool_use":
+				fmt.Fprintf(w, "> %s %s\n", b.Name, b.Input)
+			case "tool_result":
+				prefix := "<"
+				if b.IsError {
+					prefix = "< error:"
+				}
+				fmt.Fprintf(w, "%s %s\n", prefix, shorten(b.Content, 200))
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// shorten returns s on one line, cut to at most n bytes.
+func shorten(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "") + "..."
+}
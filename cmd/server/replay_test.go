@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const recording = `{"kind":"start","triage_id":"t-1","alert":{"labels":{"alertname":"DiskFull"}},"tools":[{"name":"query_metrics","description":"PromQL","input_schema":{"type":"object"}}]}
+{"kind":"response","response":{"content":[{"type":"tool_use","id":"c1","name":"query_metrics","input":{"query":"disk"}}],"stop_reason":"tool_use","usage":{"input_tokens":100,"output_tokens":10}}}
+{"kind":"tool_call","tool_call":{"tool":"query_metrics","input":{"query":"disk"},"output":{"used_pct":97}}}
+{"kind":"response","response":{"content":[{"type":"text","text":"Disk on db-1 is 97% full."}],"stop_reason":"end_turn","usage":{"input_tokens":150,"output_tokens":20}}}
+`
+
+func TestRunReplay(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "t-1.jsonl")
+	if err := os.WriteFile(path, []byte(recording), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		verify func(t *testing.T, out string)
+	}{
+		{
+			name: "text",
+			args: []string{path},
+			verify: func(t *testing.T, out string) {
+				t.Helper()
+				for _, want := range []string{`> query_metrics {"query":"disk"}`, `< {"used_pct":97}`, "status: complete", "tokens in 250", "Disk on db-1 is 97% full."} {
+					if !strings.Contains(out, want) {
+						t.Errorf("output missing %q:\n%s", want, out)
+					}
+				}
+			},
+		},
+		{
+			name: "json",
+			args: []string{"-json", path},
+			verify: func(t *testing.T, out string) {
+				t.Helper()
+				var res replayResult
+				if err := json.Unmarshal([]byte(out), &res); err != nil {
+					t.Fatalf("decode: %v\n%s", err, out)
+				}
+				if res.Analysis != "Disk on db-1 is 97% full." || res.ToolCalls != 1 || len(res.Conversation.Turns) != 3 {
+					t.Errorf("result = %+v", res)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			if err := runReplay(context.Background(), tt.args, &stdout, &stderr); err != nil {
+				t.Fatalf("runReplay: %v\n%s", err, stderr.String())
+			}
+			tt.verify(t, stdout.String())
+		})
+	}
+}
+
+func TestRunReplay_BadArgs(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "t-1.jsonl")
+	if err := os.WriteFile(path, []byte(recording), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "expected one recording file"},
+		{args: []string{filepath.Join(t.TempDir(), "missing.jsonl")}, want: "open recording"},
+		{args: []string{"-live", "-claude-api-key", "", path}, want: "-live requires -claude-api-key"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		err := runReplay(context.Background(), tt.args, &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("runReplay(%v) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
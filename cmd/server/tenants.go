@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	vc "github.com/linnemanlabs/vigil/internal/cfg"
+	"github.com/linnemanlabs/vigil/internal/llm/claude"
+	"github.com/linnemanlabs/vigil/internal/notify/slack"
+	"github.com/linnemanlabs/vigil/internal/tenant"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// toolMetrics are the per-tenant tool metrics every registry reports to.
+type toolMetrics struct {
+	circuitState *prometheus.GaugeVec
+	cacheLookups *prometheus.CounterVec
+	limitWait    *prometheus.HistogramVec
+}
+
+// datasources are the backends one tool registry queries.
+type datasources struct {
+	PrometheusEndpoint string
+	PrometheusTenantID string
+	LokiEndpoint       string
+	LokiTenantID       string
+}
+
+// newToolRegistry builds a tool registry over ds and registers every tool the
+// configuration enables. tenantID labels the registry's metrics and is ""
+// for the default tenant. Each registry has its own result cache, so tenants
+// never see each other's results.
+func newToolRegistry(ctx context.Context, L log.Logger, appCfg *vc.Config, ds datasources, tenantID string, tm *toolMetrics) (*tools.Registry, error) {
+	opts := []tools.RegistryOption{tools.WithCircuitBreaker(tools.BreakerConfig{
+		Threshold: appCfg.ToolBreakerThreshold,
+		Cooldown:  time.Duration(appCfg.ToolBreakerCooldown) * time.Second,
+		OnStateChange: func(name string, s tools.BreakerState) {
+			tm.circuitState.WithLabelValues(name, tenantID).Set(float64(s))
+			L.Warn(ctx, "tool circuit breaker state changed", "tool", name, "state", s.String())
+		},
+	})}
+	if appCfg.ToolCacheTTLs != "" {
+		ttls, err := vc.ParseToolCacheTTLs(appCfg.ToolCacheTTLs)
+		if err != nil {
+			return nil, err
+		}
+		def := ttls["*"]
+		delete(ttls, "*")
+		opts = append(opts, tools.WithCache(tools.CacheConfig{
+			TTLs:       ttls,
+			Default:    def,
+			MaxEntries: appCfg.ToolCacheSize,
+			OnLookup: func(name, result string) {
+				tm.cacheLookups.WithLabelValues(name, tenantID, result).Inc()
+			},
+		}))
+	}
+	if len(appCfg.ToolMaxConcurrent) > 0 {
+		opts = append(opts, tools.WithConcurrencyLimits(tools.LimitConfig{
+			Limits: appCfg.ToolMaxConcurrent,
+			OnWait: func(name string, wait time.Duration) {
+				tm.limitWait.WithLabelValues(name, tenantID).Observe(wait.Seconds())
+			},
+		}))
+	}
+	registry := tools.NewRegistry(opts...)
+
+	// Register Prometheus query tools if endpoint is configured, this allows the triage engine to query metrics for alert investigation and correlation
+	if ds.PrometheusEndpoint != "" {
+		prometheusQuery := tools.NewPrometheusQuery(ds.PrometheusEndpoint, ds.PrometheusTenantID)
+		registry.Register(prometheusQuery)
+		L.Info(ctx, "registered tool", "name", prometheusQuery.Name(), "endpoint", ds.PrometheusEndpoint)
+		prometheusQueryRange := tools.NewPrometheusQueryRange(ds.PrometheusEndpoint, ds.PrometheusTenantID)
+		registry.Register(prometheusQueryRange)
+		L.Info(ctx, "registered tool", "name", prometheusQueryRange.Name(), "endpoint", ds.PrometheusEndpoint)
+		hostInfo := tools.NewHostInfo(ds.PrometheusEndpoint, ds.PrometheusTenantID)
+		registry.Register(hostInfo)
+		L.Info(ctx, "registered tool", "name", hostInfo.Name(), "endpoint", ds.PrometheusEndpoint)
+	}
+
+	// Register Loki query tool if endpoint is configured, this allows the triage engine to query logs for alert investigation and correlation
+	if ds.LokiEndpoint != "" {
+		lokiQuery := tools.NewLokiQuery(ds.LokiEndpoint, ds.LokiTenantID)
+		registry.Register(lokiQuery)
+		L.Info(ctx, "registered tool", "name", lokiQuery.Name(), "endpoint", ds.LokiEndpoint)
+	}
+
+	// Register the HTTP probe tool if an allowlist is configured, this lets the triage engine check whether a service is actually down
+	if appCfg.ProbeAllowlist != "" {
+		httpProbe, err := tools.NewHTTPProbe(strings.Split(appCfg.ProbeAllowlist, ","))
+		if err != nil {
+			return nil, fmt.Errorf("http probe: %w", err)
+		}
+		registry.Register(httpProbe)
+		L.Info(ctx, "registered tool", "name", httpProbe.Name(), "allowlist", appCfg.ProbeAllowlist)
+	}
+
+	// Register the DNS/TCP check tool if targets are configured, this lets the triage engine tell DNS failures from service failures
+	if appCfg.NetCheckTargets != "" {
+		netCheck, err := tools.NewNetCheck(strings.Split(appCfg.NetCheckTargets, ","))
+		if err != nil {
+			return nil, fmt.Errorf("net check: %w", err)
+		}
+		registry.Register(netCheck)
+		L.Info(ctx, "registered tool", "name", netCheck.Name(), "targets", appCfg.NetCheckTargets)
+	}
+
+	return registry, nil
+}
+
+// loadTenants loads the tenants config and checks that no tenant token
+// doubles as the global or admin token.
+func (c *serverConfig) loadTenants() (tenant.Config, error) {
+	tc, err := tenant.LoadConfig(c.App.TenantsConfig)
+	if err != nil {
+		return tc, err
+	}
+	var errs []error
+	for _, t := range tc.Tenants {
+		if t.Token == c.App.APIToken {
+			errs = append(errs, fmt.Errorf("tenant %q: token must differ from API_TOKEN", t.ID))
+		}
+		if c.App.AdminAPIToken != "" && t.Token == c.App.AdminAPIToken {
+			errs = append(errs, fmt.Errorf("tenant %q: token must differ from ADMIN_API_TOKEN", t.ID))
+		}
+	}
+	return tc, errors.Join(errs...)
+}
+
+// tenantProfile builds t's triage profile: an engine over its own tool
+// registry and model, its instructions and budget, and a Slack notifier when
+// it has its own webhook. Datasources t leaves unset fall back to the
+// server's, and org IDs default to the tenant ID.
+func tenantProfile(ctx context.Context, L log.Logger, appCfg *vc.Config, t *tenant.Tenant, tm *toolMetrics, newEngine func(triage.Provider, *tools.Registry) *triage.LLMEngine) (*triage.Profile, error) {
+	L = L.With("tenant", t.ID)
+	ds := datasources{
+		PrometheusEndpoint: cmp.Or(t.PrometheusEndpoint, appCfg.PrometheusEndpoint),
+		PrometheusTenantID: cmp.Or(t.PrometheusTenantID, t.ID),
+		LokiEndpoint:       cmp.Or(t.LokiEndpoint, appCfg.LokiEndpoint),
+		LokiTenantID:       cmp.Or(t.LokiTenantID, t.ID),
+	}
+	registry, err := newToolRegistry(ctx, L, appCfg, ds, t.ID, tm)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", t.ID, err)
+	}
+
+	model := cmp.Or(t.Model, appCfg.ClaudeModel)
+	p := &triage.Profile{
+		Name:         "tenant:" + t.ID,
+		Instructions: t.Instructions,
+		Engine:       newEngine(claude.New(appCfg.ClaudeAPIKey, model), registry),
+		Budget:       t.Budget,
+	}
+	// Snapshots and threaded narratives are left off: they post to the
+	// server's channels, which belong to the default tenant. So is the
+	// trace link, as the tenant may not have access to the server's tracing.
+	if t.SlackWebhookURL != "" {
+		p.Notifier = slack.New(t.SlackWebhookURL, L)
+	}
+	L.Info(ctx, "tenant configured", "model", model, "prometheus_endpoint", ds.PrometheusEndpoint, "loki_endpoint", ds.LokiEndpoint, "own_notifier", p.Notifier != nil)
+	return p, nil
+}
@@ -0,0 +1,294 @@
+// Vigil-backfill submits past alerts from Alertmanager or a JSON export to
+// Vigil as dry runs, to build up triage history and an evaluation corpus
+// from real alerts without paging anyone about them again.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/linnemanlabs/go-core/cfg"
+
+	"github.com/linnemanlabs/vigil/api/client"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+const usage = `usage: vigil-backfill (-alertmanager-url URL | -file FILE) [flags]
+
+Reads past alerts and submits each to Vigil as a dry run: it is triaged and
+stored like any other alert, but nothing is notified, no issue is opened and
+no action suggested. Alerts go one at a time, oldest first, at most -rate a
+minute, and an alert seen more than once is submitted once.
+
+-alertmanager-url reads the alerts Alertmanager holds, which includes
+resolved ones until it garbage collects them. -file reads a JSON array of
+alerts as returned by the Alertmanager API or "amtool alert query -o json",
+or Alertmanager webhook payloads one after another; - reads stdin.
+
+flags may also be set as VIGIL_<FLAG>, e.g. VIGIL_API_TOKEN.
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "vigil-backfill:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("vigil-backfill", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "http://localhost:8080", "Vigil API base URL")
+	token := fs.String("api-token", "", "Bearer token for API authentication")
+	amURL := fs.String("alertmanager-url", "", "Alertmanager base URL to read alerts from")
+	file := fs.String("file", "", "JSON file to read alerts from (- for stdin)")
+	since := fs.Duration("since", 0, "only alerts that started within this long ago (0 = all)")
+	limit := fs.Int("limit", 0, "submit at most this many alerts, the oldest first (0 = all)")
+	rate := fs.Float64("rate", 6, "alerts submitted per minute")
+	timeout := fs.Duration("timeout", 30*time.Second, "per-request timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg.FillFromEnv(fs, "VIGIL_", func(format string, args ...any) {
+		fmt.Fprintf(stderr, format+"\n", args...)
+	})
+
+	switch {
+	case (*amURL == "") == (*file == ""):
+		fs.Usage()
+		return errors.New("exactly one of -alertmanager-url and -file is required")
+	case *rate <= 0:
+		return errors.New("-rate must be positive")
+	case *limit < 0:
+		return errors.New("-limit must not be negative")
+	}
+
+	hc := &http.Client{Timeout: *timeout}
+	var (
+		alerts []alert.Alert
+		err    error
+	)
+	if *amURL != "" {
+		alerts, err = fromAlertmanager(ctx, hc, *amURL)
+	} else {
+		alerts, err = fromFile(*file)
+	}
+	if err != nil {
+		return err
+	}
+
+	alerts = prepare(alerts, *since, time.Now())
+	if *limit > 0 && len(alerts) > *limit {
+		alerts = alerts[:*limit]
+	}
+	fmt.Fprintf(stderr, "submitting %d alerts at %g a minute\n", len(alerts), *rate)
+
+	c := client.New(*addr, client.WithToken(*token), client.WithHTTPClient(hc))
+	return submit(ctx, c, alerts, time.Duration(float64(time.Minute) / *rate), stdout)
+}
+
+// amAlert is an alert as the Alertmanager v2 API returns it.
+type amAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+	Receivers    []struct {
+		Name string `json:"name"`
+	} `json:"receivers"`
+}
+
+func (a *amAlert) alert() alert.Alert {
+	al := alert.Alert{
+		Labels:       a.Labels,
+		Annotations:  a.Annotations,
+		StartsAt:     a.StartsAt,
+		EndsAt:       a.EndsAt,
+		GeneratorURL: a.GeneratorURL,
+		Fingerprint:  a.Fingerprint,
+	}
+	if len(a.Receivers) > 0 {
+		al.Receiver = a.Receivers[0].Name
+	}
+	return al
+}
+
+// fromAlertmanager reads every alert Alertmanager holds, silenced and
+// inhibited ones included.
+func fromAlertmanager(ctx context.Context, hc *http.Client, baseURL string) ([]alert.Alert, error) {
+	u := strings.TrimRight(baseURL, "/") + "/api/v2/alerts?active=true&silenced=true&inhibited=true&unprocessed=true"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build alertmanager request: %w", err)
+	}
+	resp, err := hc.Do(req) //nolint:gosec // G704: the URL is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("query alertmanager: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query alertmanager: %s", resp.Status)
+	}
+	var raw []amAlert
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode alertmanager alerts: %w", err)
+	}
+	alerts := make([]alert.Alert, len(raw))
+	for i := range raw {
+		alerts[i] = raw[i].alert()
+	}
+	return alerts, nil
+}
+
+// fromFile reads alerts from path, or stdin for "-".
+func fromFile(path string) ([]alert.Alert, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read alerts: %w", err)
+	}
+	alerts, err := parseAlerts(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return alerts, nil
+}
+
+// parseAlerts reads a sequence of JSON values, each either an array of
+// Alertmanager API alerts or a webhook payload.
+func parseAlerts(b []byte) ([]alert.Alert, error) {
+	var alerts []alert.Alert
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for n := 1; ; n++ {
+		var v json.RawMessage
+		if err := dec.Decode(&v); errors.Is(err, io.EOF) {
+			return alerts, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("value %d: %w", n, err)
+		}
+		if v[0] == '[' {
+			var raw []amAlert
+			if err := json.Unmarshal(v, &raw); err != nil {
+				return nil, fmt.Errorf("value %d: %w", n, err)
+			}
+			for i := range raw {
+				alerts = append(alerts, raw[i].alert())
+			}
+			continue
+		}
+		var wh alert.Webhook
+		if err := json.Unmarshal(v, &wh); err != nil {
+			return nil, fmt.Errorf("value %d: %w", n, err)
+		}
+		for _, al := range wh.Alerts {
+			al.Receiver = wh.Receiver
+			alerts = append(alerts, al)
+		}
+	}
+}
+
+// prepare drops alerts without a name, that started before since ago or
+// that repeat an earlier alert's fingerprint and start, and sorts the rest
+// oldest first. Every alert is submitted as firing: resolved ones fired
+// too, and Vigil skips resolved alerts.
+func prepare(alerts []alert.Alert, since time.Duration, now time.Time) []alert.Alert {
+	type key struct {
+		fingerprint string
+		startsAt    time.Time
+	}
+	seen := make(map[key]bool)
+	var out []alert.Alert
+	for _, al := range alerts {
+		k := key{al.Fingerprint, al.StartsAt.UTC()}
+		switch {
+		case al.Labels["alertname"] == "", seen[k]:
+			continue
+		case since > 0 && al.StartsAt.Before(now.Add(-since)):
+			continue
+		}
+		seen[k] = true
+		al.Status = "firing"
+		out = append(out, al)
+	}
+	slices.SortStableFunc(out, func(a, b alert.Alert) int { return a.StartsAt.Compare(b.StartsAt) })
+	return out
+}
+
+// submit sends each alert as its own dry run webhook, one per interval. A
+// failed alert is reported and skipped, but a rejected token ends the run.
+func submit(ctx context.Context, c *client.Client, alerts []alert.Alert, interval time.Duration, stdout io.Writer) error {
+	var accepted, skipped, failed int
+	for i := range alerts {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+		al := &alerts[i]
+		prefix := fmt.Sprintf("%s  %s  %s", al.StartsAt.UTC().Format(time.RFC3339), al.Labels["alertname"], al.Fingerprint)
+		resp, err := c.SubmitAlertsDryRun(ctx, &client.Webhook{
+			Version:  alert.WebhookVersion,
+			Status:   "firing",
+			Receiver: al.Receiver,
+			Alerts:   []client.Alert{*al},
+		})
+		var apiErr *client.APIError
+		switch {
+		case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+			return err
+		case err != nil:
+			failed++
+			fmt.Fprintf(stdout, "%s  failed: %v\n", prefix, err)
+			continue
+		}
+		for _, r := range resp.Results {
+			switch r.Outcome {
+			case client.OutcomeAccepted, client.OutcomeQueued:
+				accepted++
+				fmt.Fprintf(stdout, "%s  %s %s\n", prefix, r.Outcome, r.ID)
+			case client.OutcomeSkipped:
+				skipped++
+				fmt.Fprintf(stdout, "%s  skipped: %s\n", prefix, r.Reason)
+			default:
+				failed++
+				fmt.Fprintf(stdout, "%s  %s: %s\n", prefix, r.Outcome, cmp.Or(r.Error, r.Reason))
+			}
+		}
+	}
+	fmt.Fprintf(stdout, "%d accepted, %d skipped, %d failed\n", accepted, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d alerts failed", failed)
+	}
+	return nil
+}
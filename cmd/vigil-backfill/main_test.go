@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// fakeVigil records the webhooks posted to it and accepts every alert
+// except those with fingerprint "dup".
+type fakeVigil struct {
+	mu       sync.Mutex
+	webhooks []alert.Webhook
+	queries  []string
+}
+
+func (f *fakeVigil) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var wh alert.Webhook
+	_ = json.NewDecoder(r.Body).Decode(&wh)
+	f.mu.Lock()
+	f.webhooks = append(f.webhooks, wh)
+	f.queries = append(f.queries, r.URL.RawQuery)
+	n := len(f.webhooks)
+	f.mu.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+	fp := wh.Alerts[0].Fingerprint
+	if fp == "dup" {
+		fmt.Fprintf(w, `{"accepted":[],"results":[{"index":0,"fingerprint":%q,"outcome":"skipped","reason":"duplicate"}]}`, fp)
+		return
+	}
+	fmt.Fprintf(w, `{"accepted":["01T%d"],"results":[{"index":0,"fingerprint":%q,"outcome":"accepted","id":"01T%d"}]}`, n, fp, n)
+}
+
+func runBackfill(t *testing.T, srv *httptest.Server, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	full := append([]string{"-addr", srv.URL, "-api-token", "secret", "-rate", "60000"}, args...)
+	err := run(context.Background(), full, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestRun_FromFile(t *testing.T) {
+	t.Parallel()
+
+	vigil := &fakeVigil{}
+	srv := httptest.NewServer(vigil)
+	defer srv.Close()
+
+	// Two webhooks for the same alert, firing then resolved, and an API
+	// export with an older alert.
+	input := `{"receiver": "team-db", "alerts": [{"status": "firing", "fingerprint": "fp-2", "startsAt": "2026-03-02T10:00:00Z", "labels": {"alertname": "DiskFull"}}]}
+{"receiver": "team-db", "alerts": [{"status": "resolved", "fingerprint": "fp-2", "startsAt": "2026-03-02T10:00:00Z", "endsAt": "2026-03-02T11:00:00Z", "labels": {"alertname": "DiskFull"}}]}
+[{"fingerprint": "fp-1", "startsAt": "2026-03-01T10:00:00Z", "labels": {"alertname": "HighCPU"}, "receivers": [{"name": "team-web"}], "status": {"state": "active"}},
+ {"fingerprint": "dup", "startsAt": "2026-03-03T10:00:00Z", "labels": {"alertname": "HighCPU"}},
+ {"fingerprint": "fp-3", "startsAt": "2026-03-04T10:00:00Z", "labels": {}}]`
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runBackfill(t, srv, "-file", path)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "2 accepted, 1 skipped, 0 failed") {
+		t.Errorf("output = %q, want the totals", out)
+	}
+
+	vigil.mu.Lock()
+	defer vigil.mu.Unlock()
+	if len(vigil.webhooks) != 3 {
+		t.Fatalf("posted %d webhooks, want 3: %+v", len(vigil.webhooks), vigil.webhooks)
+	}
+	for i, want := range []struct{ fp, receiver string }{{"fp-1", "team-web"}, {"fp-2", "team-db"}, {"dup", ""}} {
+		wh := vigil.webhooks[i]
+		if vigil.queries[i] != "dry_run=true" {
+			t.Errorf("webhook %d query = %q, want dry_run=true", i, vigil.queries[i])
+		}
+		if len(wh.Alerts) != 1 || wh.Alerts[0].Fingerprint != want.fp || wh.Receiver != want.receiver || wh.Alerts[0].Status != "firing" {
+			t.Errorf("webhook %d = %+v, want firing %s for %q", i, wh, want.fp, want.receiver)
+		}
+	}
+}
+
+func TestRun_FromAlertmanager(t *testing.T) {
+	t.Parallel()
+
+	am := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/alerts" || r.URL.Query().Get("silenced") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		now := time.Now().UTC()
+		fmt.Fprintf(w, `[{"fingerprint": "old", "startsAt": %q, "labels": {"alertname": "A"}},
+			{"fingerprint": "new", "startsAt": %q, "labels": {"alertname": "B"}}]`,
+			now.Add(-48*time.Hour).Format(time.RFC3339), now.Add(-time.Hour).Format(time.RFC3339))
+	}))
+	defer am.Close()
+	vigil := &fakeVigil{}
+	srv := httptest.NewServer(vigil)
+	defer srv.Close()
+
+	if _, err := runBackfill(t, srv, "-alertmanager-url", am.URL, "-since", "24h"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	vigil.mu.Lock()
+	defer vigil.mu.Unlock()
+	if len(vigil.webhooks) != 1 || vigil.webhooks[0].Alerts[0].Fingerprint != "new" {
+		t.Errorf("webhooks = %+v, want only the alert within -since", vigil.webhooks)
+	}
+}
+
+func TestRun_StopsOnRejectedToken(t *testing.T) {
+	t.Parallel()
+
+	vigil := &fakeVigil{}
+	srv := httptest.NewServer(vigil)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	input := `[{"fingerprint": "a", "labels": {"alertname": "A"}}, {"fingerprint": "b", "labels": {"alertname": "B"}}]`
+	if err := os.WriteFile(path, []byte(input), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-addr", srv.URL, "-api-token", "wrong", "-rate", "60000", "-file", path}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("run error = %v, want the 401", err)
+	}
+}
+
+func TestRun_BadFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no source", nil, "exactly one of"},
+		{"two sources", []string{"-file", "x.json", "-alertmanager-url", "http://am:9093"}, "exactly one of"},
+		{"zero rate", []string{"-file", "x.json", "-rate", "0"}, "-rate"},
+		{"negative limit", []string{"-file", "x.json", "-limit", "-1"}, "-limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			err := run(context.Background(), tt.args, &stdout, &stderr)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("run error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
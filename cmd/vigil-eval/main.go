@@ -0,0 +1,154 @@
+// Vigil-eval replays a corpus of recorded alerts through the triage engine
+// with a baseline and a candidate prompt or model, scores both, and reports
+// which cases regressed.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/linnemanlabs/go-core/cfg"
+
+	"github.com/linnemanlabs/vigil/internal/eval"
+	"github.com/linnemanlabs/vigil/internal/llm/claude"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+const usage = `usage: vigil-eval -corpus DIR [flags]
+
+Runs every case in the corpus with the baseline and the candidate variant and
+prints how their scores compare. With -provider replay the cases' recorded
+responses stand in for the model, which checks the corpus and scoring offline.
+
+flags may also be set as VIGIL_<FLAG>, e.g. VIGIL_CLAUDE_API_KEY.
+`
+
+// errRegression is returned with -fail-on-regression when a case regressed.
+var errRegression = errors.New("candidate regressed")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "vigil-eval:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("vigil-eval", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	corpus := fs.String("corpus", "", "directory of JSON eval cases")
+	providerName := fs.String("provider", "replay", "model provider: replay (recorded responses) or claude")
+	apiKey := fs.String("claude-api-key", "", "API key for the Claude provider and judge")
+	model := fs.String("model", "claude-sonnet-4-20250514", "candidate Claude model")
+	baselineModel := fs.String("baseline-model", "", "baseline Claude model (empty = -model)")
+	instructions := fs.String("instructions", "", "file of candidate instructions appended to the system prompt")
+	baselineInstructions := fs.String("baseline-instructions", "", "file of baseline instructions appended to the system prompt")
+	judgeModel := fs.String("judge-model", "", "Claude model grading rubric criteria without a pattern (empty = no LLM judge)")
+	promEndpoint := fs.String("prometheus-endpoint", "", "Prometheus for cases without recorded tool outputs")
+	lokiEndpoint := fs.String("loki-endpoint", "", "Loki for cases without recorded tool outputs")
+	tolerance := fs.Float64("tolerance", 0.1, "score drop tolerated before a case counts as regressed")
+	format := fs.String("format", "text", "output format: text or json")
+	failOnRegression := fs.Bool("fail-on-regression", false, "exit 1 if any case regressed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg.FillFromEnv(fs, "VIGIL_", func(format string, args ...any) {
+		fmt.Fprintf(stderr, format+"\n", args...)
+	})
+	if *corpus == "" {
+		fs.Usage()
+		return errors.New("missing -corpus")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (must be text or json)", *format)
+	}
+	if *baselineModel == "" {
+		*baselineModel = *model
+	}
+
+	cases, err := eval.LoadCorpus(*corpus)
+	if err != nil {
+		return err
+	}
+	baseline := eval.Variant{Name: "baseline"}
+	candidate := eval.Variant{Name: "candidate"}
+	if baseline.Instructions, err = readInstructions(*baselineInstructions); err != nil {
+		return err
+	}
+	if candidate.Instructions, err = readInstructions(*instructions); err != nil {
+		return err
+	}
+	switch *providerName {
+	case "replay":
+	case "claude":
+		if *apiKey == "" {
+			return errors.New("-provider claude requires -claude-api-key")
+		}
+		baseline.Provider, baseline.Model = claude.New(*apiKey, *baselineModel), *baselineModel
+		candidate.Provider, candidate.Model = claude.New(*apiKey, *model), *model
+	default:
+		return fmt.Errorf("unknown provider %q (must be replay or claude)", *providerName)
+	}
+
+	runner := &eval.Runner{
+		Tools: []tools.Tool{
+			tools.NewPrometheusQuery(*promEndpoint, ""),
+			tools.NewPrometheusQueryRange(*promEndpoint, ""),
+			tools.NewHostInfo(*promEndpoint, ""),
+			tools.NewLokiQuery(*lokiEndpoint, ""),
+		},
+		Scorers: []eval.Scorer{eval.Keywords{}, eval.Rubric{}},
+	}
+	if *judgeModel != "" {
+		if *apiKey == "" {
+			return errors.New("-judge-model requires -claude-api-key")
+		}
+		runner.Scorers = append(runner.Scorers, eval.Judge{Provider: claude.New(*apiKey, *judgeModel)})
+	}
+
+	cmp := eval.Compare(runner.Run(ctx, cases, baseline), runner.Run(ctx, cases, candidate), *tolerance)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(cmp)
+	} else {
+		err = cmp.WriteText(stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if *failOnRegression && cmp.Regressions > 0 {
+		return fmt.Errorf("%w in %d of %d cases", errRegression, cmp.Regressions, len(cmp.Cases))
+	}
+	return nil
+}
+
+// readInstructions returns the contents of path, or "" for no path.
+func readInstructions(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return "", fmt.Errorf("read instructions: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
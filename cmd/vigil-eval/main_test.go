@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/eval"
+)
+
+const replayCase = `{
+	"alert": {"labels": {"alertname": "HighLatency", "service": "api"}},
+	"responses": [{"content": [{"type": "text", "text": "Latency rose after the api deploy."}], "stop_reason": "end_turn"}],
+	"expect": {"keywords": ["deploy", "rollback"]}
+}`
+
+func TestRun_ReplayCorpus(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "latency.json"), []byte(replayCase), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		verify func(t *testing.T, out string)
+	}{
+		{
+			name: "text",
+			args: []string{"-corpus", dir},
+			verify: func(t *testing.T, out string) {
+				t.Helper()
+				for _, want := range []string{"CASE", "latency", "0.50", "regressions: 0"} {
+					if !strings.Contains(out, want) {
+						t.Errorf("output missing %q:\n%s", want, out)
+					}
+				}
+			},
+		},
+		{
+			name: "json",
+			args: []string{"-corpus", dir, "-format", "json"},
+			verify: func(t *testing.T, out string) {
+				t.Helper()
+				var cmp eval.Comparison
+				if err := json.Unmarshal([]byte(out), &cmp); err != nil {
+					t.Fatalf("decode: %v\n%s", err, out)
+				}
+				if len(cmp.Cases) != 1 || cmp.Baseline.Cases[0].Scores["keywords"].Findings[0] != "missing keyword rollback" {
+					t.Errorf("comparison = %+v", cmp)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			if err := run(context.Background(), tt.args, &stdout, &stderr); err != nil {
+				t.Fatalf("run: %v\n%s", err, stderr.String())
+			}
+			tt.verify(t, stdout.String())
+		})
+	}
+}
+
+func TestRun_BadFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "missing -corpus"},
+		{args: []string{"-corpus", t.TempDir(), "-format", "yaml"}, want: "unknown format"},
+		{args: []string{"-corpus", t.TempDir()}, want: "no .json cases"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		err := run(context.Background(), tt.args, &stdout, &stderr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("run(%v) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Vigilctl is a command line client for the Vigil API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/linnemanlabs/go-core/cfg"
+
+	"github.com/linnemanlabs/vigil/api/client"
+)
+
+const usage = `usage: vigilctl [-addr URL] [-api-token TOKEN] <command> [flags] [args]
+
+commands:
+  submit      submit an ad-hoc alert from flags or a JSON file
+  get ID      show a triage result
+  list        list recent triage results
+  search Q    search past triages by alert name, summary and analysis
+  tail ID     follow a triage until it finishes, printing turns as they arrive
+  transcript  print the full conversation of a triage
+
+global flags may also be set with VIGIL_ADDR and VIGIL_API_TOKEN.
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "vigilctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("vigilctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	addr := fs.String("addr", "http://localhost:8080", "Vigil API base URL")
+	token := fs.String("api-token", "", "Bearer token for API authentication")
+	timeout := fs.Duration("timeout", 30*time.Second, "per-request timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg.FillFromEnv(fs, "VIGIL_", func(format string, args ...any) {
+		fmt.Fprintf(stderr, format+"\n", args...)
+	})
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing command")
+	}
+
+	c := client.New(*addr, client.WithToken(*token), client.WithHTTPClient(&http.Client{Timeout: *timeout}))
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "submit":
+		return cmdSubmit(ctx, c, rest, stdout, stderr)
+	case "get":
+		return cmdGet(ctx, c, rest, stdout, stderr)
+	case "list":
+		return cmdList(ctx, c, rest, stdout, stderr)
+	case "search":
+		return cmdSearch(ctx, c, rest, stdout, stderr)
+	case "tail":
+		return cmdTail(ctx, c, rest, stdout, stderr)
+	case "transcript":
+		return cmdTranscript(ctx, c, rest, stdout, stderr)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+// labelFlags collects repeated -label key=value flags.
+type labelFlags map[string]string
+
+func (l labelFlags) String() string { return fmt.Sprint(map[string]string(l)) }
+
+func (l labelFlags) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("label %q must be key=value", v)
+	}
+	l[k] = val
+	return nil
+}
+
+func cmdSubmit(ctx context.Context, c *client.Client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("submit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	labels := labelFlags{}
+	var ev client.Event
+	fs.StringVar(&ev.Title, "title", "", "alert title, becomes the alertname label")
+	fs.StringVar(&ev.Description, "description", "", "alert description")
+	fs.StringVar(&ev.Severity, "severity", "", "severity label")
+	fs.StringVar(&ev.Source, "source", "vigilctl", "source label")
+	fs.StringVar(&ev.Fingerprint, "fingerprint", "", "fingerprint (default: derived from labels)")
+	fs.StringVar(&ev.URL, "url", "", "link back to the alert source")
+	fs.Var(labels, "label", "extra label as key=value, repeatable")
+	file := fs.String("file", "", "read the alert from a JSON file instead of flags (generic event or Alertmanager webhook, - for stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		accepted []string
+		reason   string
+		results  []client.AlertResult
+	)
+	switch wh, err := submitInput(*file, &ev, labels); {
+	case err != nil:
+		return err
+	case wh != nil:
+		resp, err := c.SubmitAlerts(ctx, wh)
+		if err != nil {
+			return err
+		}
+		accepted, results = resp.Accepted, resp.Results
+	default:
+		resp, err := c.SubmitEvent(ctx, &ev)
+		if err != nil {
+			return err
+		}
+		accepted, reason = resp.Accepted, resp.Reason
+	}
+
+	for _, r := range results {
+		switch r.Outcome {
+		case client.OutcomeFailed:
+			fmt.Fprintf(stdout, "failed: %s: %s\n", r.Fingerprint, r.Error)
+		case client.OutcomeRejected:
+			fmt.Fprintf(stdout, "rejected: %s: %s\n", r.Fingerprint, r.Reason)
+		case client.OutcomeQueued:
+			fmt.Fprintf(stdout, "queued: %s\n", r.Fingerprint)
+		}
+	}
+	if len(accepted) == 0 {
+		for _, r := range results {
+			if reason == "" && r.Reason != "" {
+				reason = r.Reason
+			}
+		}
+		if reason == "" {
+			reason = "not accepted"
+		}
+		fmt.Fprintf(stdout, "skipped: %s\n", reason)
+		return nil
+	}
+	for _, id := range accepted {
+		fmt.Fprintln(stdout, id)
+	}
+	return nil
+}
+
+// submitInput fills ev from the flags, or from file when set. A file holding
+// an Alertmanager webhook is returned as wh instead.
+func submitInput(file string, ev *client.Event, labels labelFlags) (*client.Webhook, error) {
+	if file == "" {
+		if len(labels) > 0 {
+			ev.Labels = labels
+		}
+		return nil, ev.Validate()
+	}
+
+	b, err := readInput(file)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Alerts json.RawMessage `json:"alerts"`
+	}
+	if json.Unmarshal(b, &probe) == nil && probe.Alerts != nil {
+		var wh client.Webhook
+		if err := json.Unmarshal(b, &wh); err != nil {
+			return nil, fmt.Errorf("parse webhook: %w", err)
+		}
+		return &wh, nil
+	}
+	*ev = client.Event{}
+	if err := json.Unmarshal(b, ev); err != nil {
+		return nil, fmt.Errorf("parse event: %w", err)
+	}
+	return nil, nil
+}
+
+func cmdGet(ctx context.Context, c *client.Client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print the raw JSON result")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: vigilctl get [-json] ID")
+	}
+
+	r, err := c.Get(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	printSummary(stdout, r)
+	return nil
+}
+
+func cmdList(ctx context.Context, c *client.Client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var f client.ListFilter
+	labels := labelFlags{}
+	status := fs.String("status", "", "only results with this status")
+	fs.StringVar(&f.Alert, "alert", "", "only results for this alertname")
+	fs.Var(labels, "label", "only results whose alert has this label, as key=value, repeatable")
+	fs.IntVar(&f.Limit, "limit", 20, "maximum results")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f.Status = client.Status(*status)
+	if len(labels) > 0 {
+		f.Labels = labels
+	}
+
+	results, err := c.List(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tALERT\tSEVERITY\tCREATED\tDURATION\tTOKENS")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d/%d\n",
+			r.ID, r.Status, r.Alert, r.Severity,
+			r.CreatedAt.Local().Format(time.DateTime),
+			formatSeconds(r.Duration), r.TokensIn, r.TokensOut,
+		)
+	}
+	return tw.Flush()
+}
+
+// cmdTail polls the triage until it reaches a terminal status, printing turns
+// as they are persisted.
+func cmdSearch(ctx context.Context, c *client.Client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	limit := fs.Int("limit", 10, "maximum results")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("search: missing query")
+	}
+
+	hits, err := c.Search(ctx, strings.Join(fs.Args(), " "), *limit)
+	if err != nil {
+		return err
+	}
+	for i, h := range hits {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		fmt.Fprintf(stdout, "%s  %s  %s  %s\n", h.ID, h.Alert, h.Status, h.CreatedAt.Local().Format(time.DateTime))
+		fmt.Fprintf(stdout, "  %s\n", h.Snippet)
+	}
+	return nil
+}
+
+func cmdTail(ctx context.Context, c *client.Client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	interval := fs.Duration("interval", 2*time.Second, "poll interval")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: vigilctl tail [-interval D] ID")
+	}
+
+	printed := 0
+	var lastStatus client.Status
+	r, err := c.Watch(ctx, fs.Arg(0), *interval, func(r *client.Result) error {
+		if r.Status != lastStatus {
+			fmt.Fprintf(stdout, "== %s %s (%s)\n", r.ID, r.Status, r.Alert)
+			lastStatus = r.Status
+		}
+		if r.Conversation != nil {
+			for ; printed < len(r.Conversation.Turns); printed++ {
+				printTurn(stdout, printed, &r.Conversation.Turns[printed])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	printSummary(stdout, r)
+	return nil
+}
+
+func cmdTranscript(ctx context.Context, c *client.Client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("transcript", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	system := fs.Bool("system", false, "include the system prompt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: vigilctl transcript [-system] ID")
+	}
+
+	r, err := c.Get(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	printTranscript(stdout, r, *system)
+	return nil
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator running the CLI
+}
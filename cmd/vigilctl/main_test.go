@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func runCLI(t *testing.T, srv *httptest.Server, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	full := append([]string{"-addr", srv.URL, "-api-token", "secret"}, args...)
+	err := run(context.Background(), full, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestSubmit_FromFlags(t *testing.T) {
+	t.Parallel()
+
+	var got alert.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/events" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"accepted":["01ABC"]}`)
+	}))
+	defer srv.Close()
+
+	out, err := runCLI(t, srv, "submit", "-title", "DiskFull", "-severity", "warning", "-label", "instance=web-1")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.TrimSpace(out) != "01ABC" {
+		t.Errorf("output = %q, want triage id", out)
+	}
+	if got.Title != "DiskFull" || got.Severity != "warning" || got.Labels["instance"] != "web-1" || got.Source != "vigilctl" {
+		t.Errorf("event = %+v", got)
+	}
+}
+
+func TestSubmit_RequiresTitle(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	if _, err := runCLI(t, srv, "submit", "-severity", "warning"); err == nil {
+		t.Fatal("expected error without -title")
+	}
+}
+
+func TestSubmit_Skipped(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, `{"accepted":[],"skipped":true,"reason":"duplicate"}`)
+	}))
+	defer srv.Close()
+
+	out, err := runCLI(t, srv, "submit", "-title", "T")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out, "skipped: duplicate") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestSubmit_PartialFailure(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/alerts" {
+			t.Errorf("path = %s, want /api/v1/alerts", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = io.WriteString(w, `{"accepted":["01OK"],"results":[
+			{"index":0,"fingerprint":"fp-1","outcome":"failed","error":"db down"},
+			{"index":1,"fingerprint":"fp-2","outcome":"accepted","id":"01OK"}]}`)
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "webhook.json")
+	wh := `{"receiver":"team","alerts":[{"status":"firing","fingerprint":"fp-1"},{"status":"firing","fingerprint":"fp-2"}]}`
+	if err := os.WriteFile(file, []byte(wh), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, srv, "submit", "-file", file)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out, "failed: fp-1: db down") || !strings.Contains(out, "01OK") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestGet_APIError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"code":"not_found","message":"triage not found"}}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := runCLI(t, srv, "get", "missing")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("err = %v, want not found", err)
+	}
+}
+
+func TestList_Table(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("status") != "complete" || q.Get("limit") != "5" || q.Get("label") != "namespace:prod" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": []triage.Result{
+			{ID: "01A", Status: triage.StatusComplete, Alert: "HighCPU", Severity: "critical", TokensIn: 10, TokensOut: 5},
+		}})
+	}))
+	defer srv.Close()
+
+	out, err := runCLI(t, srv, "list", "-status", "complete", "-limit", "5", "-label", "namespace=prod")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, want := range []string{"ID", "STATUS", "01A", "HighCPU", "critical", "10/5"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSearch_PrintsSnippets(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/triage/search" || r.URL.Query().Get("q") != "xfs corruption" {
+			t.Errorf("request = %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": []triage.SearchHit{
+			{ID: "01A", Status: triage.StatusComplete, Alert: "DiskErrors", Snippet: "**xfs** metadata **corruption** on sdb"},
+		}})
+	}))
+	defer srv.Close()
+
+	out, err := runCLI(t, srv, "search", "xfs", "corruption")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, want := range []string{"01A", "DiskErrors", "**xfs** metadata"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTail_PrintsNewTurnsUntilTerminal(t *testing.T) {
+	t.Parallel()
+
+	turns := []triage.Turn{
+		{Role: "assistant", Content: []triage.ContentBlock{
+			{Type: "text", Text: "checking cpu"},
+			{Type: "tool_use", Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)},
+		}},
+		{Role: "user", Content: []triage.ContentBlock{{Type: "tool_result", Content: "up=1"}}},
+	}
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		r := triage.Result{ID: "01A", Alert: "HighCPU", Status: triage.StatusInProgress}
+		switch polls.Add(1) {
+		case 1:
+			r.Conversation = &triage.Conversation{Turns: turns[:1]}
+		default:
+			r.Conversation = &triage.Conversation{Turns: turns}
+			r.Status = triage.StatusComplete
+			r.Analysis = "runaway process"
+		}
+		_ = json.NewEncoder(w).Encode(r)
+	}))
+	defer srv.Close()
+
+	out, err := runCLI(t, srv, "tail", "-interval", time.Millisecond.String(), "01A")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if strings.Count(out, "checking cpu") != 1 {
+		t.Errorf("expected first turn printed once:\n%s", out)
+	}
+	for _, want := range []string{"in_progress", "> query_metrics {\"query\":\"up\"}", "< up=1", "complete", "runaway process"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	if _, err := runCLI(t, srv, "frobnicate"); err == nil {
+		t.Fatal("expected error for unknown command")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("truncate short = %q", got)
+	}
+	if got := truncate("0123456789abc", 10); got != "0123456789... (3 bytes truncated)" {
+		t.Errorf("truncate long = %q", got)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// maxToolOutput caps how much of each tool result is printed in transcripts.
+const maxToolOutput = 2000
+
+func printSummary(w io.Writer, r *triage.Result) {
+	fmt.Fprintf(w, "ID:        %s\n", r.ID)
+	fmt.Fprintf(w, "Status:    %s\n", r.Status)
+	fmt.Fprintf(w, "Alert:     %s\n", r.Alert)
+	if r.Severity != "" {
+		fmt.Fprintf(w, "Severity:  %s\n", r.Severity)
+	}
+	if r.Summary != "" {
+		fmt.Fprintf(w, "Summary:   %s\n", r.Summary)
+	}
+	fmt.Fprintf(w, "Created:   %s\n", r.CreatedAt.Local().Format(time.DateTime))
+	if r.Status.IsTerminal() {
+		fmt.Fprintf(w, "Duration:  %s (llm %s, tools %s)\n", formatSeconds(r.Duration), formatSeconds(r.LLMTime), formatSeconds(r.ToolTime))
+		fmt.Fprintf(w, "Tokens:    %d in / %d out", r.TokensIn, r.TokensOut)
+		if r.TokensThinking > 0 {
+			fmt.Fprintf(w, " (%d thinking)", r.TokensThinking)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Tools:     %d calls %s\n", r.ToolCalls, strings.Join(r.ToolsUsed, ", "))
+		if r.Redactions > 0 {
+			fmt.Fprintf(w, "Redacted:  %d secrets in tool output\n", r.Redactions)
+		}
+	}
+	if r.Model != "" {
+		fmt.Fprintf(w, "Model:     %s\n", r.Model)
+	}
+	if r.Strategy != "" && r.Strategy != triage.StrategyReAct {
+		fmt.Fprintf(w, "Strategy:  %s\n", r.Strategy)
+	}
+	if r.Provider != "" {
+		fmt.Fprintf(w, "Provider:  %s\n", r.Provider)
+	}
+	if r.TraceID != "" {
+		fmt.Fprintf(w, "Trace:     %s\n", r.TraceID)
+	}
+	if r.DryRun {
+		fmt.Fprintln(w, "Dry run:   not notified")
+	}
+	if r.Analysis != "" {
+		fmt.Fprintf(w, "\n%s\n", r.Analysis)
+	}
+}
+
+func printTranscript(w io.Writer, r *triage.Result, withSystem bool) {
+	fmt.Fprintf(w, "Triage %s: %s [%s]\n", r.ID, r.Alert, r.Status)
+	if withSystem && r.SystemPrompt != "" {
+		fmt.Fprintf(w, "\n--- system\n%s\n", r.SystemPrompt)
+	}
+	if r.Conversation == nil {
+		fmt.Fprintln(w, "\n(no conversation recorded)")
+		return
+	}
+	for i := range r.Conversation.Turns {
+		printTurn(w, i, &r.Conversation.Turns[i])
+	}
+}
+
+func printTurn(w io.Writer, seq int, t *triage.Turn) {
+	header := fmt.Sprintf("\n--- #%d %s %s", seq, t.Role, t.Timestamp.Local().Format(time.TimeOnly))
+	if t.Duration > 0 {
+		header += " " + formatSeconds(t.Duration)
+	}
+	if t.Usage != nil {
+		header += fmt.Sprintf(" tokens=%d/%d", t.Usage.InputTokens, t.Usage.OutputTokens)
+	}
+	if t.StopReason != "" {
+		header += " stop=" + t.StopReason
+	}
+	fmt.Fprintln(w, header)
+
+	for i := range t.Content {
+		b := &t.Content[i]
+		switch b.Type {
+		case "text":
+			fmt.Fprintln(w, b.Text)
+		case "tool_use":
+			fmt.Fprintf(w, "> %s %s\n", b.Name, string(b.Input))
+		case "tool_result":
+			marker := "<"
+			if b.IsError {
+				marker = "< error:"
+			}
+			fmt.Fprintf(w, "%s %s\n", marker, indent(truncate(b.Content, maxToolOutput)))
+		default:
+			fmt.Fprintf(w, "[%s]\n", b.Type)
+		}
+	}
+}
+
+func formatSeconds(s float64) string {
+	return (time.Duration(s * float64(time.Second))).Round(time.Millisecond).String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + fmt.Sprintf("... (%d bytes truncated)", len(s)-n)
+}
+
+func indent(s string) string {
+	return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n  ")
+}
@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Event is a generic alert payload for sources that are not Alertmanager,
+// such as Sentry releases, CloudWatch alarms, or manual reports.
+type Event struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels"`
+	Source      string            `json:"source"`
+	Severity    string            `json:"severity"`
+	Fingerprint string            `json:"fingerprint"`
+	StartsAt    time.Time         `json:"startsAt"`
+	URL         string            `json:"url"`
+}
+
+// ToAlert normalizes the event into the internal Alert model. Title and severity
+// become the alertname and severity labels, source is kept as a label, and a
+// fingerprint is synthesized from the resulting labels when none is supplied.
+func (e *Event) ToAlert() Alert {
+	labels := make(map[string]string, len(e.Labels)+3)
+	maps.Copy(labels, e.Labels)
+	labels["alertname"] = e.Title
+	if e.Severity != "" {
+		labels["severity"] = e.Severity
+	}
+	if e.Source != "" {
+		labels["source"] = e.Source
+	}
+
+	annotations := map[string]string{"summary": e.Title}
+	if e.Description != "" {
+		annotations["description"] = e.Description
+	}
+
+	startsAt := e.StartsAt
+	if startsAt.IsZero() {
+		startsAt = time.Now().UTC()
+	}
+
+	fp := e.Fingerprint
+	if fp == "" {
+		fp = Fingerprint(labels)
+	}
+
+	return Alert{
+		Status:       "firing",
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     startsAt,
+		GeneratorURL: e.URL,
+		Fingerprint:  fp,
+	}
+}
+
+// Fingerprint derives a stable 16 hex character identifier from a label set,
+// matching the length of Alertmanager fingerprints. Label order does not matter.
+func Fingerprint(labels map[string]string) string {
+	h := sha256.New()
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		h.Write([]byte(k))
+		h.Write([]byte{0xff})
+		h.Write([]byte(labels[k]))
+		h.Write([]byte{0xff})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Validate checks that the event carries enough information to be triaged.
+func (e *Event) Validate() error {
+	if strings.TrimSpace(e.Title) == "" {
+		return errors.New("title is required")
+	}
+	return nil
+}
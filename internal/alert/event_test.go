@@ -0,0 +1,57 @@
+package alert
+
+import "testing"
+
+func TestFingerprint_StableAndOrderIndependent(t *testing.T) {
+	t.Parallel()
+
+	a := Fingerprint(map[string]string{"alertname": "A", "instance": "web-1"})
+	b := Fingerprint(map[string]string{"instance": "web-1", "alertname": "A"})
+	if a != b {
+		t.Errorf("fingerprints differ for same labels: %q vs %q", a, b)
+	}
+	if len(a) != 16 {
+		t.Errorf("len = %d, want 16", len(a))
+	}
+
+	c := Fingerprint(map[string]string{"alertname": "A", "instance": "web-2"})
+	if a == c {
+		t.Error("expected different fingerprints for different label sets")
+	}
+}
+
+func TestFingerprint_NoKeyValueAmbiguity(t *testing.T) {
+	t.Parallel()
+
+	a := Fingerprint(map[string]string{"ab": "c"})
+	b := Fingerprint(map[string]string{"a": "bc"})
+	if a == b {
+		t.Error("expected distinct fingerprints for ab=c and a=bc")
+	}
+}
+
+func TestEventToAlert_DoesNotMutateLabels(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"team": "infra"}
+	ev := Event{Title: "T", Severity: "critical", Labels: labels}
+	al := ev.ToAlert()
+
+	if _, ok := labels["alertname"]; ok {
+		t.Error("ToAlert mutated the event labels")
+	}
+	if al.Labels["team"] != "infra" || al.Labels["severity"] != "critical" {
+		t.Errorf("labels = %v", al.Labels)
+	}
+}
+
+func TestEventValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := (&Event{}).Validate(); err == nil {
+		t.Error("expected error for empty title")
+	}
+	if err := (&Event{Title: "ok"}).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
@@ -25,4 +25,11 @@ type Alert struct {
 	EndsAt       time.Time         `json:"endsAt"`
 	GeneratorURL string            `json:"generatorURL"`
 	Fingerprint  string            `json:"fingerprint"`
+
+	// Receiver is the Alertmanager receiver from the enclosing webhook. It is
+	// not part of the per-alert payload and is empty for other sources.
+	Receiver string `json:"-"`
+	// ReceivedAt is when Vigil received the alert, for measuring how long it
+	// took to triage. It is zero when the source did not record it.
+	ReceivedAt time.Time `json:"-"`
 }
@@ -0,0 +1,152 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// WebhookVersion is the Alertmanager webhook payload version Vigil reads.
+const WebhookVersion = "4"
+
+// Limits applied by Normalize and Validate. Labels come from whatever alert
+// rules attach, and a runaway label ends up in every prompt and stored row.
+const (
+	MaxLabels          = 64
+	MaxLabelValueBytes = 1024
+
+	// MaxClockSkew is how far past the receive time startsAt may be.
+	MaxClockSkew = 10 * time.Minute
+)
+
+// Problem is one reason a webhook was rejected.
+type Problem struct {
+	// Index is the position of the offending alert in the webhook, or -1
+	// when the problem is with the webhook itself.
+	Index       int    `json:"index"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Field       string `json:"field"`
+	Message     string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Index < 0 {
+		return fmt.Sprintf("%s: %s", p.Field, p.Message)
+	}
+	return fmt.Sprintf("alerts[%d].%s: %s", p.Index, p.Field, p.Message)
+}
+
+// ValidationError lists every problem found in a webhook.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msg := "invalid webhook: " + e.Problems[0].String()
+	if n := len(e.Problems) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+// Normalize cleans up the webhook's alerts in place. Label values are trimmed
+// of surrounding whitespace, a missing fingerprint is derived from the
+// labels, and labels over MaxLabels or MaxLabelValueBytes are clamped. It
+// returns how many alerts had labels clamped.
+func (w *Webhook) Normalize() int {
+	clamped := 0
+	for i := range w.Alerts {
+		if w.Alerts[i].Normalize() {
+			clamped++
+		}
+	}
+	return clamped
+}
+
+// Normalize cleans up the alert in place, see Webhook.Normalize. It reports
+// whether any label was dropped or truncated.
+func (a *Alert) Normalize() bool {
+	for k, v := range a.Labels {
+		a.Labels[k] = strings.TrimSpace(v)
+	}
+	a.Fingerprint = strings.TrimSpace(a.Fingerprint)
+	// The fingerprint is taken before clamping so alerts that differ only
+	// in a dropped label are not merged.
+	if a.Fingerprint == "" {
+		a.Fingerprint = Fingerprint(a.Labels)
+	}
+	return clampLabels(a.Labels)
+}
+
+// clampLabels truncates long values and drops labels past MaxLabels, keeping
+// alertname and severity and then the rest in name order.
+func clampLabels(labels map[string]string) bool {
+	clamped := false
+	for k, v := range labels {
+		if len(v) > MaxLabelValueBytes {
+			labels[k] = truncateUTF8(v, MaxLabelValueBytes)
+			clamped = true
+		}
+	}
+	if len(labels) <= MaxLabels {
+		return clamped
+	}
+	keep := map[string]bool{"alertname": true, "severity": true}
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		if len(keep) >= MaxLabels {
+			break
+		}
+		keep[k] = true
+	}
+	for k := range labels {
+		if !keep[k] {
+			delete(labels, k)
+		}
+	}
+	return true
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Validate checks the webhook for payloads Vigil cannot triage: an unknown
+// version, alerts with an unknown status or no alertname, and timestamps
+// that are out of order or further in the future than MaxClockSkew past now.
+// It returns a *ValidationError listing every problem, or nil. A missing
+// version or startsAt is accepted, as hand-written payloads often omit them.
+func (w *Webhook) Validate(now time.Time) error {
+	var problems []Problem
+	if w.Version != "" && w.Version != WebhookVersion {
+		problems = append(problems, Problem{Index: -1, Field: "version", Message: fmt.Sprintf("unsupported version %q, want %q", w.Version, WebhookVersion)})
+	}
+	for i := range w.Alerts {
+		a := &w.Alerts[i]
+		bad := func(field, format string, args ...any) {
+			problems = append(problems, Problem{Index: i, Fingerprint: a.Fingerprint, Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+		if a.Status != "firing" && a.Status != "resolved" {
+			bad("status", "must be firing or resolved, got %q", a.Status)
+		}
+		if a.Labels["alertname"] == "" {
+			bad("labels.alertname", "required label is missing")
+		}
+		if a.StartsAt.After(now.Add(MaxClockSkew)) {
+			bad("startsAt", "%s is more than %s in the future", a.StartsAt.Format(time.RFC3339), MaxClockSkew)
+		}
+		if !a.StartsAt.IsZero() && !a.EndsAt.IsZero() && a.EndsAt.Before(a.StartsAt) {
+			bad("endsAt", "%s is before startsAt %s", a.EndsAt.Format(time.RFC3339), a.StartsAt.Format(time.RFC3339))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
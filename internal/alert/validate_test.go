@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ok := Alert{Status: "firing", Fingerprint: "fp", Labels: map[string]string{"alertname": "DiskFull"}, StartsAt: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name string
+		wh   Webhook
+		want []string // Problem.String of each problem, in order
+	}{
+		{name: "valid", wh: Webhook{Version: "4", Alerts: []Alert{ok}}},
+		{name: "no version or startsAt", wh: Webhook{Alerts: []Alert{{Status: "resolved", Labels: map[string]string{"alertname": "A"}}}}},
+		{name: "small clock skew", wh: Webhook{Alerts: []Alert{{Status: "firing", Labels: map[string]string{"alertname": "A"}, StartsAt: now.Add(time.Minute)}}}},
+		{
+			name: "unsupported version",
+			wh:   Webhook{Version: "3", Alerts: []Alert{ok}},
+			want: []string{`version: unsupported version "3", want "4"`},
+		},
+		{
+			name: "every problem listed",
+			wh: Webhook{Alerts: []Alert{
+				ok,
+				{Status: "pending", Fingerprint: "fp1", Labels: map[string]string{"severity": "critical"}},
+				{Status: "firing", Fingerprint: "fp2", Labels: map[string]string{"alertname": "A"}, StartsAt: now.Add(time.Hour)},
+				{Status: "resolved", Fingerprint: "fp3", Labels: map[string]string{"alertname": "A"}, StartsAt: now, EndsAt: now.Add(-time.Second)},
+			}},
+			want: []string{
+				`alerts[1].status: must be firing or resolved, got "pending"`,
+				"alerts[1].labels.alertname: required label is missing",
+				"alerts[2].startsAt: 2026-03-01T13:00:00Z is more than 10m0s in the future",
+				"alerts[3].endsAt: 2026-03-01T11:59:59Z is before startsAt 2026-03-01T12:00:00Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.wh.Validate(now)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate = %v, want *ValidationError", err)
+			}
+			var got []string
+			for _, p := range verr.Problems {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestAlertNormalize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("trims and fills fingerprint", func(t *testing.T) {
+		t.Parallel()
+		a := Alert{Labels: map[string]string{"alertname": " DiskFull\n", "instance": "db-1 "}}
+		if a.Normalize() {
+			t.Error("Normalize reported clamping of small labels")
+		}
+		if a.Labels["alertname"] != "DiskFull" || a.Labels["instance"] != "db-1" {
+			t.Errorf("labels = %q", a.Labels)
+		}
+		if want := Fingerprint(map[string]string{"alertname": "DiskFull", "instance": "db-1"}); a.Fingerprint != want {
+			t.Errorf("fingerprint = %q, want %q", a.Fingerprint, want)
+		}
+	})
+
+	t.Run("keeps given fingerprint", func(t *testing.T) {
+		t.Parallel()
+		a := Alert{Fingerprint: " abc ", Labels: map[string]string{"alertname": "A"}}
+		a.Normalize()
+		if a.Fingerprint != "abc" {
+			t.Errorf("fingerprint = %q, want abc", a.Fingerprint)
+		}
+	})
+
+	t.Run("clamps labels", func(t *testing.T) {
+		t.Parallel()
+		labels := map[string]string{"alertname": "A", "severity": "page", "zz": strings.Repeat("é", MaxLabelValueBytes)}
+		for i := range MaxLabels {
+			labels[fmt.Sprintf("l%02d", i)] = "v"
+		}
+		want := Fingerprint(labels)
+		a := Alert{Labels: labels}
+		if !a.Normalize() {
+			t.Error("Normalize did not report clamping")
+		}
+		if len(a.Labels) != MaxLabels {
+			t.Errorf("labels = %d, want %d", len(a.Labels), MaxLabels)
+		}
+		if a.Labels["alertname"] != "A" || a.Labels["severity"] != "page" || a.Labels["l00"] != "v" {
+			t.Errorf("kept labels = %v", a.Labels)
+		}
+		if _, ok := a.Labels["zz"]; ok {
+			t.Error("label past the limit in name order was kept")
+		}
+		if a.Fingerprint != want {
+			t.Errorf("fingerprint = %q, want %q taken before clamping", a.Fingerprint, want)
+		}
+	})
+
+	t.Run("truncates long values on a rune boundary", func(t *testing.T) {
+		t.Parallel()
+		a := Alert{Labels: map[string]string{"alertname": "A", "msg": strings.Repeat("é", MaxLabelValueBytes)}}
+		if !a.Normalize() {
+			t.Error("Normalize did not report clamping")
+		}
+		if got := a.Labels["msg"]; len(got) != MaxLabelValueBytes || !strings.HasSuffix(got, "é") {
+			t.Errorf("msg = %d bytes, want %d ending in a whole rune", len(got), MaxLabelValueBytes)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package alertapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// HeaderApprovalToken carries the approval token on an action execution,
+// in addition to the bearer token that authenticates the caller.
+const HeaderApprovalToken = "X-Vigil-Approval-Token"
+
+// ActionExecutor runs suggested remediations. *triage.Service implements it.
+type ActionExecutor interface {
+	ExecuteAction(ctx context.Context, id string, n int) (*triage.SuggestedAction, bool, error)
+}
+
+// ActionResponse is the body of POST /triage/{id}/actions/{n}/execute. The
+// action carries its outcome: Error is set if it ran and failed.
+type ActionResponse struct {
+	ID     string                  `json:"id"`
+	Index  int                     `json:"index"`
+	Action *triage.SuggestedAction `json:"action"`
+}
+
+// WithRemediation enables executing suggested actions through exec, for
+// callers that also present approvalToken. Without it the execute route is
+// not registered.
+func WithRemediation(exec ActionExecutor, approvalToken string) Option {
+	return func(a *API) {
+		a.actions = exec
+		a.approvalToken = approvalToken
+	}
+}
+
+// handleExecuteAction runs one suggested action of a triage. The bearer
+// token alone is not enough: reading triages must not imply changing
+// production, so the approval token is a second, separately held secret.
+func (a *API) handleExecuteAction(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.String("vigil.triage.id", id))
+
+	got := r.Header.Get(HeaderApprovalToken)
+	if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(a.approvalToken)) != 1 {
+		WriteError(w, r, http.StatusForbidden, CodeApprovalRequired, "missing or wrong "+HeaderApprovalToken)
+		return
+	}
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 0 {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid action index, want a non-negative integer")
+		return
+	}
+	span.SetAttributes(attribute.Int("vigil.action.index", n))
+
+	action, ok, err := a.actions.ExecuteAction(r.Context(), id, n)
+	switch {
+	case errors.Is(err, triage.ErrActionExecuted):
+		WriteError(w, r, http.StatusConflict, CodeActionExecuted, "action has already been executed")
+		return
+	case errors.Is(err, triage.ErrActionInvalid):
+		WriteError(w, r, http.StatusUnprocessableEntity, CodeActionInvalid, err.Error())
+		return
+	case err != nil:
+		a.logger.Error(r.Context(), err, "failed to execute action", "id", id, "index", n)
+		writeInternal(w, r)
+		return
+	case !ok:
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage or action not found")
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("vigil.action.name", action.Action),
+		attribute.Bool("vigil.action.failed", action.Error != ""),
+	)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ActionResponse{ID: id, Index: n, Action: action})
+}
+
+// actionRoutes are the routes enabled by WithRemediation.
+func (a *API) actionRoutes() []route {
+	if a.actions == nil {
+		return nil
+	}
+	return []route{{
+		method: http.MethodPost, pattern: "/triage/{id}/actions/{n}/execute", handler: a.handleExecuteAction,
+		summary:     "Execute a suggested remediation action",
+		description: "Runs the n-th entry of the triage's suggested_actions, counting from 0. Requires the " + HeaderApprovalToken + " header as well as the bearer token. Each action runs at most once; its outcome, including a failure, is returned and stored on the triage, and the approval and outcome are recorded in the audit trail.",
+		responses:   map[int]any{http.StatusOK: ActionResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}}
+}
@@ -0,0 +1,100 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+type stubExecutor struct {
+	calls int
+}
+
+func (s *stubExecutor) ExecuteAction(_ context.Context, id string, n int) (*triage.SuggestedAction, bool, error) {
+	s.calls++
+	switch {
+	case id != "01ACT" || n > 1:
+		return nil, false, nil
+	case n == 1:
+		return nil, false, fmt.Errorf("%w: unknown action", triage.ErrActionInvalid)
+	}
+	return &triage.SuggestedAction{Action: "restart_unit", ExecutedAt: time.Now(), ExecutedBy: "api-token", Output: "ok"}, true, nil
+}
+
+func TestExecuteAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+		wantCode   string
+		wantCalls  int
+	}{
+		{name: "ok", path: "/triage/01ACT/actions/0/execute", token: "approve", wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "no approval token", path: "/triage/01ACT/actions/0/execute", wantStatus: http.StatusForbidden, wantCode: CodeApprovalRequired},
+		{name: "wrong approval token", path: "/triage/01ACT/actions/0/execute", token: "approvE", wantStatus: http.StatusForbidden, wantCode: CodeApprovalRequired},
+		{name: "bad index", path: "/triage/01ACT/actions/first/execute", token: "approve", wantStatus: http.StatusBadRequest, wantCode: CodeInvalidParameter},
+		{name: "invalid action", path: "/triage/01ACT/actions/1/execute", token: "approve", wantStatus: http.StatusUnprocessableEntity, wantCode: CodeActionInvalid, wantCalls: 1},
+		{name: "unknown action", path: "/triage/01ACT/actions/5/execute", token: "approve", wantStatus: http.StatusNotFound, wantCode: CodeNotFound, wantCalls: 1},
+		{name: "unknown triage", path: "/triage/01NONE/actions/0/execute", token: "approve", wantStatus: http.StatusNotFound, wantCode: CodeNotFound, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exec := &stubExecutor{}
+			r := chi.NewRouter()
+			New(nil, &stubTriageService{}, WithRemediation(exec, "approve")).RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1"+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set(HeaderApprovalToken, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if exec.calls != tt.wantCalls {
+				t.Errorf("executor called %d times, want %d", exec.calls, tt.wantCalls)
+			}
+			if tt.wantCode != "" {
+				if body := decodeEnvelope(t, rec); body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+				return
+			}
+			var resp ActionResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.ID != "01ACT" || resp.Index != 0 || resp.Action == nil || resp.Action.Output != "ok" {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestExecuteAction_NotRegistered(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/triage/01ACT/actions/0/execute", nil)
+	req.Header.Set(HeaderApprovalToken, "approve")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without WithRemediation", rec.Code)
+	}
+}
@@ -2,50 +2,219 @@ package alertapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/linnemanlabs/go-core/httpmw"
 	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
 func (a *API) handleIngestAlert(w http.ResponseWriter, r *http.Request) {
+	received := time.Now()
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		dry, err := strconv.ParseBool(v)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid dry_run, want true or false")
+			return
+		}
+		if dry {
+			r = r.WithContext(triage.WithDryRun(r.Context()))
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.Bool("vigil.triage.dry_run", true))
+		}
+	}
 	body, _ := io.ReadAll(r.Body)
 	a.logger.Info(r.Context(), "raw webhook", "body", string(body))
 	r.Body = io.NopCloser(bytes.NewReader(body))
 
 	var wh alert.Webhook
 	if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
-		http.Error(w, `{"error":"invalid payload"}`, http.StatusBadRequest)
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload: "+err.Error())
 		return
 	}
+	if n := wh.Normalize(); n > 0 {
+		a.logger.Warn(r.Context(), "clamped oversized alert labels", "alerts", n, "max_labels", alert.MaxLabels, "max_value_bytes", alert.MaxLabelValueBytes)
+	}
+	if err := wh.Validate(received); err != nil {
+		writeInvalidWebhook(w, r, err)
+		return
+	}
+	for i := range wh.Alerts {
+		wh.Alerts[i].Receiver = wh.Receiver
+		wh.Alerts[i].ReceivedAt = received
+	}
 
-	var accepted []string
+	a.submitAlerts(w, r, wh.Alerts)
+}
 
-	for _, al := range wh.Alerts {
-		sr, err := a.svc.Submit(r.Context(), &al)
-		if err != nil {
-			a.logger.Error(r.Context(), err, "submit failed", "fingerprint", al.Fingerprint)
-			continue
+// ValidationResponse is the 400 body of the webhook ingest endpoint when the
+// webhook fails validation. It is an error envelope with the problems found,
+// so nothing from the batch is triaged until the sender fixes every alert.
+type ValidationResponse struct {
+	Error    ErrorBody       `json:"error"`
+	Problems []alert.Problem `json:"problems"`
+}
+
+func writeInvalidWebhook(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *alert.ValidationError
+	if !errors.As(err, &verr) {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(ValidationResponse{
+		Error: ErrorBody{
+			Code:      CodeInvalidPayload,
+			Message:   verr.Error(),
+			RequestID: httpmw.RequestIDFromContext(r.Context()),
+			DocsURL:   DocsURL,
+		},
+		Problems: verr.Problems,
+	})
+}
+
+// Per-alert outcomes reported by the ingest endpoints.
+const (
+	OutcomeAccepted = "accepted"
+	OutcomeSkipped  = "skipped"
+	OutcomeFailed   = "failed"
+	// OutcomeQueued and OutcomeRejected are alerts past the webhook's
+	// triage limit, see BatchLimits.
+	OutcomeQueued   = "queued"
+	OutcomeRejected = "rejected"
+)
+
+// AlertResult is the outcome of submitting one alert from a webhook batch.
+type AlertResult struct {
+	Index       int    `json:"index"`
+	Fingerprint string `json:"fingerprint"`
+	AlertName   string `json:"alert_name,omitempty"`
+	Outcome     string `json:"outcome"`
+	ID          string `json:"id,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	// Error is set for OutcomeFailed. The cause is logged with the request
+	// ID, not returned.
+	Error string `json:"error,omitempty"`
+}
+
+// IngestResponse is the body of the webhook ingest endpoints. Error is only
+// set when every alert in the batch failed.
+type IngestResponse struct {
+	Accepted []string      `json:"accepted"`
+	Results  []AlertResult `json:"results"`
+	Error    *ErrorBody    `json:"error,omitempty"`
+}
+
+// submitAlerts submits each alert for triage and writes a per-alert outcome.
+// One failing alert does not fail the batch: the response is 202 when nothing
+// failed or was rejected, 207 when some alerts were, and 500 when all of them
+// failed, so Alertmanager retries a batch that made no progress.
+func (a *API) submitAlerts(w http.ResponseWriter, r *http.Request, alerts []alert.Alert) {
+	if limit := a.batch.MaxAlerts; limit > 0 && len(alerts) > limit {
+		if a.batch.OnOversized != nil {
+			a.batch.OnOversized()
 		}
-		if sr.Skipped {
-			continue
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("vigil.alerts.count", len(alerts)))
+		WriteError(w, r, http.StatusRequestEntityTooLarge, CodeTooManyAlerts,
+			fmt.Sprintf("webhook carries %d alerts, the limit is %d", len(alerts), limit))
+		return
+	}
+
+	results := a.submitEach(r.Context(), alerts)
+
+	accepted := []string{}
+	var skipped, failed, queued, rejected int
+	for i := range results {
+		switch results[i].Outcome {
+		case OutcomeAccepted:
+			accepted = append(accepted, results[i].ID)
+		case OutcomeSkipped:
+			skipped++
+		case OutcomeFailed:
+			failed++
+		case OutcomeQueued:
+			queued++
+		case OutcomeRejected:
+			rejected++
 		}
-		accepted = append(accepted, sr.ID)
 	}
 
 	span := trace.SpanFromContext(r.Context())
 	span.SetAttributes(
-		attribute.Int("vigil.alerts.count", len(wh.Alerts)),
+		attribute.Int("vigil.alerts.count", len(alerts)),
 		attribute.Int("vigil.alerts.accepted", len(accepted)),
+		attribute.Int("vigil.alerts.skipped", skipped),
+		attribute.Int("vigil.alerts.failed", failed),
+		attribute.Int("vigil.alerts.queued", queued),
+		attribute.Int("vigil.alerts.rejected", rejected),
 	)
 
+	code := http.StatusAccepted
+	switch {
+	case failed > 0 && failed == len(results):
+		code = http.StatusInternalServerError
+	case failed > 0 || rejected > 0:
+		code = http.StatusMultiStatus
+	}
+
+	resp := IngestResponse{
+		Accepted: accepted,
+		Results:  results,
+	}
+	if code == http.StatusInternalServerError {
+		resp.Error = &ErrorBody{
+			Code:      CodeInternal,
+			Message:   "every alert in the batch failed to submit",
+			RequestID: httpmw.RequestIDFromContext(r.Context()),
+			DocsURL:   DocsURL,
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"accepted": accepted,
-	})
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// submitEach submits alerts in order. Once BatchLimits.MaxTriages of them
+// have started triages, the rest overflow instead.
+func (a *API) submitEach(ctx context.Context, alerts []alert.Alert) []AlertResult {
+	results := make([]AlertResult, len(alerts))
+	started := 0
+	for i := range alerts {
+		al := &alerts[i]
+		res := AlertResult{Index: i, Fingerprint: al.Fingerprint, AlertName: al.Labels["alertname"]}
+		if limit := a.batch.MaxTriages; limit > 0 && started >= limit {
+			a.overflowAlert(ctx, al, &res)
+			results[i] = res
+			continue
+		}
+		sr, err := a.svc.Submit(ctx, al)
+		switch {
+		case err != nil:
+			a.logger.Error(ctx, err, "submit failed", "fingerprint", al.Fingerprint)
+			res.Outcome = OutcomeFailed
+			res.Error = "internal error"
+		case sr.Skipped:
+			res.Outcome = OutcomeSkipped
+			res.Reason = sr.Reason
+			res.ID = sr.ID
+		default:
+			res.Outcome = OutcomeAccepted
+			res.ID = sr.ID
+			started++
+		}
+		results[i] = res
+	}
+	return results
 }
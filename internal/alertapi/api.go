@@ -3,7 +3,12 @@ package alertapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -12,6 +17,9 @@ import (
 	"github.com/linnemanlabs/go-core/log"
 	"github.com/linnemanlabs/go-core/xerrors"
 	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/share"
+	"github.com/linnemanlabs/vigil/internal/tools"
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
@@ -19,36 +27,135 @@ import (
 type TriageService interface {
 	Submit(ctx context.Context, al *alert.Alert) (*triage.SubmitResult, error)
 	Get(ctx context.Context, id string) (*triage.Result, bool, error)
+	List(ctx context.Context, filter triage.ListFilter) ([]*triage.Result, error)
+	Search(ctx context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error)
+	Delete(ctx context.Context, id string) (bool, error)
+	Restore(ctx context.Context, id string) (bool, error)
+	Cancel(ctx context.Context, id string) (bool, error)
+	Suppress(ctx context.Context, sup triage.Suppression, ttl time.Duration) (*triage.Suppression, error)
+	Suppressions(ctx context.Context) ([]*triage.Suppression, error)
+	Unsuppress(ctx context.Context, id string) (bool, error)
+	NoiseScores(ctx context.Context, window time.Duration) ([]triage.NoiseScore, error)
+	Decisions(ctx context.Context, f triage.DecisionFilter) ([]*triage.Decision, error)
+	Stats(ctx context.Context, q triage.StatsQuery) (*triage.Stats, error)
+	Timeline(ctx context.Context, id string) (*triage.Timeline, bool, error)
+	Audit(ctx context.Context, id string) ([]*triage.AuditEvent, bool, error)
+	Tools(ctx context.Context) ([]tools.ToolInfo, error)
 }
 
 // API holds dependencies for HTTP handlers.
 type API struct {
 	logger log.Logger
 	svc    TriageService
+	share  *share.Signer
+	// templates are the notification templates the render route serves,
+	// nil to leave it out.
+	templates *notify.Templates
+	// actions runs suggested remediations for callers presenting
+	// approvalToken, nil to leave the route out.
+	actions       ActionExecutor
+	approvalToken string
+	// storm reports storm mode, nil to leave the route out.
+	storm StormReporter
+	// ingestLimit wraps the ingest routes, nil for none.
+	ingestLimit func(http.Handler) http.Handler
+	batch       BatchLimits
+	overflow    chan queuedAlert
+
+	specOnce sync.Once
+	spec     []byte
+	specErr  error
 }
 
+// Option configures an API.
+type Option func(*API)
+
 // New creates a new API handler.
-func New(logger log.Logger, svc TriageService) *API {
+func New(logger log.Logger, svc TriageService, opts ...Option) *API {
 	if logger == nil {
 		logger = log.Nop()
 	}
 	if svc == nil {
 		panic(xerrors.New("triage service is required"))
 	}
-	return &API{
+	a := &API{
 		logger: logger,
 		svc:    svc,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithIngestLimit wraps the alert ingest route in mw, such as a rate limiter.
+// It runs after authentication, so mw can rely on the caller's token.
+func WithIngestLimit(mw func(http.Handler) http.Handler) Option {
+	return func(a *API) { a.ingestLimit = mw }
 }
 
-// RegisterRoutes attaches API endpoints to the router.
+// RegisterRoutes attaches API endpoints to the router. The same route table
+// generates the OpenAPI document, so every registered route is documented.
 func (a *API) RegisterRoutes(r chi.Router) {
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Post("/alerts", a.handleIngestAlert)
-		r.Get("/triage/{id}", a.handleGetTriage)
+	r.Route(basePath, func(r chi.Router) {
+		r.NotFound(NotFound)
+		r.MethodNotAllowed(MethodNotAllowed)
+		for _, rt := range a.routes() {
+			if rt.admin || rt.public {
+				continue
+			}
+			var h http.Handler = rt.handler
+			if rt.ingest && a.ingestLimit != nil {
+				h = a.ingestLimit(h)
+			}
+			r.Method(rt.method, rt.pattern, h)
+		}
 	})
 }
 
+// RegisterAdminRoutes attaches operator endpoints under /api/v1/admin. They
+// are kept separate so the caller can put them behind a different token.
+func (a *API) RegisterAdminRoutes(r chi.Router) {
+	r.Route(adminPath, func(r chi.Router) {
+		r.NotFound(NotFound)
+		r.MethodNotAllowed(MethodNotAllowed)
+		for _, rt := range a.routes() {
+			if rt.admin {
+				r.Method(rt.method, rt.pattern, rt.handler)
+			}
+		}
+	})
+}
+
+// RegisterPublicRoutes attaches the endpoints that check their own
+// credentials, such as shared reports. They must be mounted outside any
+// bearer token middleware.
+func (a *API) RegisterPublicRoutes(r chi.Router) {
+	for _, rt := range a.routes() {
+		if rt.public {
+			r.Method(rt.method, basePath+rt.pattern, rt.handler)
+		}
+	}
+}
+
+// ListResponse is the body of GET /triage.
+type ListResponse struct {
+	Results []*triage.Result `json:"results"`
+}
+
+// AuditResponse is the body of GET /triage/{id}/audit.
+type AuditResponse struct {
+	ID     string               `json:"id"`
+	Events []*triage.AuditEvent `json:"events"`
+}
+
+// NotesResponse is the body of GET /triage/{id}/notes.
+type NotesResponse struct {
+	ID     string        `json:"id"`
+	Status triage.Status `json:"status"`
+	Notes  []triage.Note `json:"notes"`
+}
+
 func (a *API) handleGetTriage(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -58,11 +165,11 @@ func (a *API) handleGetTriage(w http.ResponseWriter, r *http.Request) {
 	result, ok, err := a.svc.Get(r.Context(), id)
 	if err != nil {
 		a.logger.Error(r.Context(), err, "failed to get triage result", "id", id)
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		writeInternal(w, r)
 		return
 	}
 	if !ok {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
 		return
 	}
 
@@ -71,3 +178,247 @@ func (a *API) handleGetTriage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(result)
 }
+
+// handleGetTriageNotes returns just the investigation notes, for callers that
+// want the reasoning trail without the full conversation.
+func (a *API) handleGetTriageNotes(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.String("vigil.triage.id", id))
+
+	result, ok, err := a.svc.Get(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to get triage result", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+
+	notes := result.Notes
+	if notes == nil {
+		notes = []triage.Note{}
+	}
+	span.SetAttributes(attribute.Int("vigil.triage.notes", len(notes)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(NotesResponse{
+		ID:     result.ID,
+		Status: result.Status,
+		Notes:  notes,
+	})
+}
+
+// handleGetTriageTimeline returns the triage's events in order, for seeing
+// where its time went.
+func (a *API) handleGetTriageTimeline(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.String("vigil.triage.id", id))
+
+	tl, ok, err := a.svc.Timeline(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to get triage timeline", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+
+	span.SetAttributes(attribute.Int("vigil.triage.timeline_events", len(tl.Events)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tl)
+}
+
+// handleGetTriageAudit returns the triage's lifecycle transitions, oldest
+// first, with who caused each.
+func (a *API) handleGetTriageAudit(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.String("vigil.triage.id", id))
+
+	events, ok, err := a.svc.Audit(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to get triage audit trail", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+	if events == nil {
+		events = []*triage.AuditEvent{}
+	}
+
+	span.SetAttributes(attribute.Int("vigil.triage.audit_events", len(events)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(AuditResponse{ID: id, Events: events})
+}
+
+func (a *API) handleCompareTriage(w http.ResponseWriter, r *http.Request) {
+	id, otherID := chi.URLParam(r, "id"), chi.URLParam(r, "otherID")
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(
+		attribute.String("vigil.triage.id", id),
+		attribute.String("vigil.triage.other_id", otherID),
+	)
+
+	results := make([]*triage.Result, 0, 2)
+	for _, tid := range []string{id, otherID} {
+		result, ok, err := a.svc.Get(r.Context(), tid)
+		if err != nil {
+			a.logger.Error(r.Context(), err, "failed to get triage result", "id", tid)
+			writeInternal(w, r)
+			return
+		}
+		if !ok {
+			WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+			return
+		}
+		results = append(results, result)
+	}
+
+	cmp, err := triage.Compare(results[0], results[1])
+	if errors.Is(err, triage.ErrFingerprintMismatch) {
+		WriteError(w, r, http.StatusUnprocessableEntity, CodeFingerprintMismatch, "triages have different fingerprints")
+		return
+	}
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to compare triage results", "id", id, "other_id", otherID)
+		writeInternal(w, r)
+		return
+	}
+
+	span.SetAttributes(attribute.Bool("vigil.triage.root_cause_changed", cmp.RootCause.Changed))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cmp)
+}
+
+// handleDeleteTriage soft-deletes a finished triage. It can be undone through
+// the admin restore endpoint until the purge job removes it.
+func (a *API) handleDeleteTriage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("vigil.triage.id", id))
+
+	ok, err := a.svc.Delete(r.Context(), id)
+	if errors.Is(err, triage.ErrTriageActive) {
+		WriteError(w, r, http.StatusConflict, CodeTriageActive, "triage is still running")
+		return
+	}
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to delete triage", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCancelTriage stops a running triage. Cancellation is asynchronous:
+// the triage finishes with StatusError once the engine notices.
+func (a *API) handleCancelTriage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("vigil.triage.id", id))
+
+	ok, err := a.svc.Cancel(r.Context(), id)
+	if errors.Is(err, triage.ErrTriageNotRunning) {
+		WriteError(w, r, http.StatusConflict, CodeTriageNotRunning, "triage is not running")
+		return
+	}
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to cancel triage", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleRestoreTriage undoes a soft delete and returns the restored triage.
+func (a *API) handleRestoreTriage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("vigil.triage.id", id))
+
+	ok, err := a.svc.Restore(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to restore triage", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "no deleted triage with that id")
+		return
+	}
+	a.logger.Info(r.Context(), "triage restored via admin api", "id", id)
+
+	a.handleGetTriage(w, r)
+}
+
+func (a *API) handleListTriage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := triage.ListFilter{
+		Status: triage.Status(q.Get("status")),
+		Alert:  q.Get("alert"),
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid limit, want a positive integer")
+			return
+		}
+		f.Limit = n
+	}
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid before, want RFC 3339 timestamp")
+			return
+		}
+		f.Before = t
+		f.BeforeID = q.Get("before_id")
+	}
+	for _, v := range q["label"] {
+		name, value, ok := strings.Cut(v, ":")
+		if !ok || name == "" {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid label, want name:value")
+			return
+		}
+		if f.Labels == nil {
+			f.Labels = make(map[string]string)
+		}
+		f.Labels[name] = value
+	}
+
+	results, err := a.svc.List(r.Context(), f)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to list triage results")
+		writeInternal(w, r)
+		return
+	}
+	if results == nil {
+		results = []*triage.Result{}
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("vigil.triage.listed", len(results)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ListResponse{Results: results})
+}
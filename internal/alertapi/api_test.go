@@ -6,19 +6,35 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/linnemanlabs/go-core/log"
 	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
 // stubTriageService implements TriageService for testing.
 type stubTriageService struct {
-	submitFn func(ctx context.Context, al *alert.Alert) (*triage.SubmitResult, error)
-	getFn    func(ctx context.Context, id string) (*triage.Result, bool, error)
+	submitFn   func(ctx context.Context, al *alert.Alert) (*triage.SubmitResult, error)
+	getFn      func(ctx context.Context, id string) (*triage.Result, bool, error)
+	listFn     func(ctx context.Context, f triage.ListFilter) ([]*triage.Result, error)
+	searchFn   func(ctx context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error)
+	deleteFn   func(ctx context.Context, id string) (bool, error)
+	restoreFn  func(ctx context.Context, id string) (bool, error)
+	cancelFn   func(ctx context.Context, id string) (bool, error)
+	suppressFn func(ctx context.Context, sup triage.Suppression, ttl time.Duration) (*triage.Suppression, error)
+	suppressed []*triage.Suppression
+	noiseFn    func(ctx context.Context, window time.Duration) ([]triage.NoiseScore, error)
+	decideFn   func(ctx context.Context, f triage.DecisionFilter) ([]*triage.Decision, error)
+	statsFn    func(ctx context.Context, q triage.StatsQuery) (*triage.Stats, error)
+	timelineFn func(ctx context.Context, id string) (*triage.Timeline, bool, error)
+	auditFn    func(ctx context.Context, id string) ([]*triage.AuditEvent, bool, error)
+	toolsFn    func(ctx context.Context) ([]tools.ToolInfo, error)
 }
 
 func (s *stubTriageService) Submit(ctx context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
@@ -35,6 +51,103 @@ func (s *stubTriageService) Get(ctx context.Context, id string) (*triage.Result,
 	return nil, false, nil
 }
 
+func (s *stubTriageService) List(ctx context.Context, f triage.ListFilter) ([]*triage.Result, error) {
+	if s.listFn != nil {
+		return s.listFn(ctx, f)
+	}
+	return nil, nil
+}
+
+func (s *stubTriageService) Search(ctx context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error) {
+	if s.searchFn != nil {
+		return s.searchFn(ctx, q)
+	}
+	return nil, nil
+}
+
+func (s *stubTriageService) Delete(ctx context.Context, id string) (bool, error) {
+	if s.deleteFn != nil {
+		return s.deleteFn(ctx, id)
+	}
+	return false, nil
+}
+
+func (s *stubTriageService) NoiseScores(ctx context.Context, window time.Duration) ([]triage.NoiseScore, error) {
+	if s.noiseFn != nil {
+		return s.noiseFn(ctx, window)
+	}
+	return nil, nil
+}
+
+func (s *stubTriageService) Restore(ctx context.Context, id string) (bool, error) {
+	if s.restoreFn != nil {
+		return s.restoreFn(ctx, id)
+	}
+	return false, nil
+}
+
+func (s *stubTriageService) Cancel(ctx context.Context, id string) (bool, error) {
+	if s.cancelFn != nil {
+		return s.cancelFn(ctx, id)
+	}
+	return false, nil
+}
+
+func (s *stubTriageService) Suppress(ctx context.Context, sup triage.Suppression, ttl time.Duration) (*triage.Suppression, error) {
+	if s.suppressFn != nil {
+		return s.suppressFn(ctx, sup, ttl)
+	}
+	return &sup, nil
+}
+
+func (s *stubTriageService) Suppressions(context.Context) ([]*triage.Suppression, error) {
+	return s.suppressed, nil
+}
+
+func (s *stubTriageService) Unsuppress(_ context.Context, id string) (bool, error) {
+	for _, sup := range s.suppressed {
+		if sup.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *stubTriageService) Decisions(ctx context.Context, f triage.DecisionFilter) ([]*triage.Decision, error) {
+	if s.decideFn != nil {
+		return s.decideFn(ctx, f)
+	}
+	return nil, nil
+}
+
+func (s *stubTriageService) Stats(ctx context.Context, q triage.StatsQuery) (*triage.Stats, error) {
+	if s.statsFn != nil {
+		return s.statsFn(ctx, q)
+	}
+	return &triage.Stats{}, nil
+}
+
+func (s *stubTriageService) Timeline(ctx context.Context, id string) (*triage.Timeline, bool, error) {
+	if s.timelineFn != nil {
+		return s.timelineFn(ctx, id)
+	}
+	return nil, false, nil
+}
+
+func (s *stubTriageService) Audit(ctx context.Context, id string) ([]*triage.AuditEvent, bool, error) {
+	if s.auditFn != nil {
+		return s.auditFn(ctx, id)
+	}
+	return nil, false, nil
+}
+
+func (s *stubTriageService) Tools(ctx context.Context) ([]tools.ToolInfo, error) {
+	if s.toolsFn != nil {
+		return s.toolsFn(ctx)
+	}
+	return nil, nil
+}
+
 func newTestAPI(t *testing.T) (*API, *stubTriageService) {
 	t.Helper()
 	svc := &stubTriageService{}
@@ -47,6 +160,7 @@ func newTestRouter(t *testing.T) (chi.Router, *stubTriageService) {
 	api, svc := newTestAPI(t)
 	r := chi.NewRouter()
 	api.RegisterRoutes(r)
+	api.RegisterAdminRoutes(r)
 	return r, svc
 }
 
@@ -149,7 +263,8 @@ func TestRegisterRoutes_Triage(t *testing.T) {
 		{"GET with short string", http.MethodGet, "/api/v1/triage/abc", http.StatusNotFound},
 		{"POST not allowed", http.MethodPost, "/api/v1/triage/123", http.StatusMethodNotAllowed},
 		{"PUT not allowed", http.MethodPut, "/api/v1/triage/123", http.StatusMethodNotAllowed},
-		{"DELETE not allowed", http.MethodDelete, "/api/v1/triage/123", http.StatusMethodNotAllowed},
+		{"DELETE unknown ID", http.MethodDelete, "/api/v1/triage/123", http.StatusNotFound},
+		{"PATCH not allowed", http.MethodPatch, "/api/v1/triage/123", http.StatusMethodNotAllowed},
 	}
 
 	for _, tt := range tests {
@@ -176,7 +291,6 @@ func TestRegisterRoutes_NotFound(t *testing.T) {
 		"/",
 		"/api/v1",
 		"/api/v2/alerts",
-		"/api/v1/triage",
 		"/api/v1/triage/",
 		"/api/v1/unknown",
 	}
@@ -196,6 +310,28 @@ func TestRegisterRoutes_NotFound(t *testing.T) {
 	}
 }
 
+func TestRegisterRoutes_IngestLimit(t *testing.T) {
+	t.Parallel()
+
+	var wrapped []string
+	api := New(nil, &stubTriageService{}, WithIngestLimit(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped = append(wrapped, r.Method+" "+r.URL.Path)
+			WriteError(w, r, http.StatusTooManyRequests, CodeRateLimited, "slow down")
+		})
+	}))
+	r := chi.NewRouter()
+	api.RegisterRoutes(r)
+
+	for _, path := range []string{"/api/v1/alerts", "/api/v1/events"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if want := []string{"POST /api/v1/alerts"}; !slices.Equal(wrapped, want) {
+		t.Errorf("limited requests = %v, want %v", wrapped, want)
+	}
+}
+
 // Alert ingestion logic
 
 func TestHandleIngestAlert_ValidFiringAlert(t *testing.T) {
@@ -206,10 +342,14 @@ func TestHandleIngestAlert_ValidFiringAlert(t *testing.T) {
 		if al.Labels["alertname"] != "HighCPU" {
 			t.Errorf("alertname = %q, want HighCPU", al.Labels["alertname"])
 		}
+		if al.Receiver != "team-infra" {
+			t.Errorf("receiver = %q, want team-infra", al.Receiver)
+		}
 		return &triage.SubmitResult{ID: "test-id-001"}, nil
 	}
 
 	body := `{
+		"receiver": "team-infra",
 		"alerts": [{
 			"status": "firing",
 			"fingerprint": "fp-001",
@@ -355,6 +495,45 @@ func TestHandleIngestAlert_MultipleAlerts(t *testing.T) {
 	}
 }
 
+func TestHandleIngestAlert_DryRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantDry    bool
+	}{
+		{query: "", wantStatus: http.StatusAccepted},
+		{query: "?dry_run=true", wantStatus: http.StatusAccepted, wantDry: true},
+		{query: "?dry_run=false", wantStatus: http.StatusAccepted},
+		{query: "?dry_run=maybe", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			r, svc := newTestRouter(t)
+			var gotDry bool
+			svc.submitFn = func(ctx context.Context, _ *alert.Alert) (*triage.SubmitResult, error) {
+				gotDry = triage.IsDryRun(ctx)
+				return &triage.SubmitResult{ID: "test-id-001"}, nil
+			}
+
+			body := `{"alerts": [{"status": "firing", "fingerprint": "fp-001", "labels": {"alertname": "HighCPU"}}]}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts"+tt.query, strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if gotDry != tt.wantDry {
+				t.Errorf("dry run = %v, want %v", gotDry, tt.wantDry)
+			}
+		})
+	}
+}
+
 func TestHandleIngestAlert_InvalidJSON(t *testing.T) {
 	t.Parallel()
 
@@ -385,6 +564,67 @@ func TestHandleIngestAlert_EmptyAlerts(t *testing.T) {
 	}
 }
 
+func TestHandleIngestAlert_InvalidAlerts(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var submitted int
+	svc.submitFn = func(context.Context, *alert.Alert) (*triage.SubmitResult, error) {
+		submitted++
+		return &triage.SubmitResult{ID: "id"}, nil
+	}
+
+	body := `{"version": "4", "alerts": [
+		{"status": "firing", "fingerprint": "fp-ok", "labels": {"alertname": "A"}},
+		{"status": "firing", "fingerprint": "fp-bad", "labels": {"severity": "critical"}}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	var resp ValidationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Error.Code != CodeInvalidPayload {
+		t.Errorf("code = %q, want %q", resp.Error.Code, CodeInvalidPayload)
+	}
+	want := []alert.Problem{{Index: 1, Fingerprint: "fp-bad", Field: "labels.alertname", Message: "required label is missing"}}
+	if !slices.Equal(resp.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", resp.Problems, want)
+	}
+	if submitted != 0 {
+		t.Errorf("submitted %d alerts of a rejected webhook", submitted)
+	}
+}
+
+func TestHandleIngestAlert_NormalizesAlerts(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var got *alert.Alert
+	svc.submitFn = func(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+		got = al
+		return &triage.SubmitResult{ID: "id"}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts", strings.NewReader(`{"alerts": [{"status": "firing", "labels": {"alertname": " DiskFull "}}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got == nil || got.Labels["alertname"] != "DiskFull" || got.Fingerprint != alert.Fingerprint(map[string]string{"alertname": "DiskFull"}) {
+		t.Errorf("submitted alert = %+v", got)
+	}
+}
+
 // Triage GET handler
 
 func TestHandleGetTriage_Found(t *testing.T) {
@@ -481,21 +721,100 @@ func TestHandleIngestAlert_PartialSubmitError(t *testing.T) {
 	rec := httptest.NewRecorder()
 	r.ServeHTTP(rec, req)
 
-	if rec.Code != http.StatusAccepted {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMultiStatus)
 	}
 
-	var resp map[string]any
+	var resp struct {
+		Accepted []string      `json:"accepted"`
+		Results  []AlertResult `json:"results"`
+	}
 	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	accepted, ok := resp["accepted"].([]any)
-	if !ok || len(accepted) != 1 {
-		t.Fatalf("expected 1 accepted ID (first failed, second succeeded), got %v", resp["accepted"])
+	if len(resp.Accepted) != 1 || resp.Accepted[0] != "ok-id" {
+		t.Fatalf("accepted = %v, want [ok-id]", resp.Accepted)
+	}
+	want := []AlertResult{
+		{Index: 0, Fingerprint: "fp-1", AlertName: "A", Outcome: OutcomeFailed, Error: "internal error"},
+		{Index: 1, Fingerprint: "fp-2", AlertName: "B", Outcome: OutcomeAccepted, ID: "ok-id"},
 	}
-	if accepted[0].(string) != "ok-id" {
-		t.Errorf("accepted ID = %q, want %q", accepted[0], "ok-id")
+	if len(resp.Results) != len(want) {
+		t.Fatalf("results = %+v, want %+v", resp.Results, want)
+	}
+	for i := range want {
+		if resp.Results[i] != want[i] {
+			t.Errorf("results[%d] = %+v, want %+v", i, resp.Results[i], want[i])
+		}
+	}
+}
+
+func TestHandleIngestAlert_Outcomes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		submit   func(al *alert.Alert) (*triage.SubmitResult, error)
+		wantCode int
+	}{
+		{
+			name: "accepted and skipped",
+			submit: func(al *alert.Alert) (*triage.SubmitResult, error) {
+				if al.Fingerprint == "fp-1" {
+					return &triage.SubmitResult{ID: "existing", Skipped: true, Reason: "duplicate"}, nil
+				}
+				return &triage.SubmitResult{ID: "new"}, nil
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name: "all failed",
+			submit: func(*alert.Alert) (*triage.SubmitResult, error) {
+				return nil, errors.New("db down")
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	body := `{"alerts": [
+		{"status": "firing", "fingerprint": "fp-1", "labels": {"alertname": "A"}},
+		{"status": "firing", "fingerprint": "fp-2", "labels": {"alertname": "B"}}
+	]}`
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, svc := newTestRouter(t)
+			svc.submitFn = func(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+				return tt.submit(al)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var resp struct {
+				Results []AlertResult `json:"results"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(resp.Results) != 2 || resp.Results[0].Fingerprint != "fp-1" || resp.Results[1].Fingerprint != "fp-2" {
+				t.Fatalf("results = %+v", resp.Results)
+			}
+			if tt.wantCode == http.StatusAccepted {
+				if r0 := resp.Results[0]; r0.Outcome != OutcomeSkipped || r0.Reason != "duplicate" || r0.ID != "existing" {
+					t.Errorf("results[0] = %+v", r0)
+				}
+			} else if resp.Results[0].Outcome != OutcomeFailed || resp.Results[0].Error != "internal error" {
+				t.Errorf("results[0] = %+v", resp.Results[0])
+			}
+		})
 	}
 }
 
@@ -541,3 +860,436 @@ func FuzzAlertIngestion(f *testing.F) {
 		}
 	})
 }
+
+func TestHandleListTriage(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var gotFilter triage.ListFilter
+	svc.listFn = func(_ context.Context, f triage.ListFilter) ([]*triage.Result, error) {
+		gotFilter = f
+		return []*triage.Result{
+			{ID: "b", Status: triage.StatusComplete},
+			{ID: "a", Status: triage.StatusComplete},
+		}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/triage?status=complete&alert=HighCPU&limit=2&before=2026-01-02T03:04:05Z&before_id=01PREV&label=namespace:prod&label=url:http://x", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotFilter.Status != triage.StatusComplete || gotFilter.Alert != "HighCPU" || gotFilter.Limit != 2 {
+		t.Errorf("filter = %+v", gotFilter)
+	}
+	if gotFilter.Before.IsZero() || gotFilter.BeforeID != "01PREV" {
+		t.Errorf("cursor = %v %q, want before and before_id parsed", gotFilter.Before, gotFilter.BeforeID)
+	}
+	if len(gotFilter.Labels) != 2 || gotFilter.Labels["namespace"] != "prod" || gotFilter.Labels["url"] != "http://x" {
+		t.Errorf("labels = %v", gotFilter.Labels)
+	}
+
+	var resp struct {
+		Results []triage.Result `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].ID != "b" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+}
+
+func TestHandleListTriage_EmptyIsArray(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/triage", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), `"results":[]`) {
+		t.Errorf("body = %s, want empty results array", rec.Body.String())
+	}
+}
+
+func TestHandleListTriage_BadRequests(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+
+	for _, query := range []string{"limit=abc", "limit=0", "before=yesterday", "label=namespace", "label=:prod"} {
+		t.Run(query, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/triage?"+query, http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandleListTriage_StoreError(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.listFn = func(_ context.Context, _ triage.ListFilter) ([]*triage.Result, error) {
+		return nil, errors.New("db down")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/triage", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestHandleCompareTriage(t *testing.T) {
+	t.Parallel()
+
+	results := map[string]*triage.Result{
+		"a":     {ID: "a", Fingerprint: "fp", Analysis: "Root cause: disk full", TokensIn: 100},
+		"b":     {ID: "b", Fingerprint: "fp", Analysis: "Root cause: bad deploy", TokensIn: 250},
+		"other": {ID: "other", Fingerprint: "fp2"},
+	}
+	r, svc := newTestRouter(t)
+	svc.getFn = func(_ context.Context, id string) (*triage.Result, bool, error) {
+		if id == "broken" {
+			return nil, false, errors.New("db down")
+		}
+		res, ok := results[id]
+		return res, ok, nil
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/triage/a/compare/b", http.StatusOK},
+		{"/api/v1/triage/a/compare/missing", http.StatusNotFound},
+		{"/api/v1/triage/a/compare/other", http.StatusUnprocessableEntity},
+		{"/api/v1/triage/broken/compare/a", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var cmp triage.Comparison
+			if err := json.NewDecoder(rec.Body).Decode(&cmp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if cmp.Base.ID != "a" || cmp.Other.ID != "b" || !cmp.RootCause.Changed || cmp.Deltas.TokensIn != 150 {
+				t.Errorf("comparison = %+v", cmp)
+			}
+		})
+	}
+}
+
+func TestHandleGetTriageNotes(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.getFn = func(_ context.Context, id string) (*triage.Result, bool, error) {
+		switch id {
+		case "with-notes":
+			return &triage.Result{ID: id, Status: triage.StatusComplete, Notes: []triage.Note{{Turn: 0, Text: "checking load"}}}, true, nil
+		case "no-notes":
+			return &triage.Result{ID: id, Status: triage.StatusPending}, true, nil
+		}
+		return nil, false, nil
+	}
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantBody string
+	}{
+		{"with-notes", http.StatusOK, `"text":"checking load"`},
+		{"no-notes", http.StatusOK, `"notes":[]`},
+		{"missing", http.StatusNotFound, "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/triage/"+tt.id+"/notes", http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleGetTriageTimeline(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.timelineFn = func(_ context.Context, id string) (*triage.Timeline, bool, error) {
+		switch id {
+		case "done":
+			return triage.BuildTimeline(&triage.Result{ID: id, Status: triage.StatusComplete}, nil), true, nil
+		case "broken":
+			return nil, false, errors.New("db down")
+		}
+		return nil, false, nil
+	}
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantBody string
+	}{
+		{"done", http.StatusOK, `"type":"submitted"`},
+		{"missing", http.StatusNotFound, "not found"},
+		{"broken", http.StatusInternalServerError, "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/triage/"+tt.id+"/timeline", http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleGetTriageAudit(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.auditFn = func(_ context.Context, id string) ([]*triage.AuditEvent, bool, error) {
+		switch id {
+		case "done":
+			return []*triage.AuditEvent{{TriageID: id, Event: triage.AuditSubmitted, Actor: "api-token"}}, true, nil
+		case "quiet":
+			return nil, true, nil
+		case "broken":
+			return nil, false, errors.New("db down")
+		}
+		return nil, false, nil
+	}
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantBody string
+	}{
+		{"done", http.StatusOK, `"actor":"api-token"`},
+		{"quiet", http.StatusOK, `"events":[]`},
+		{"missing", http.StatusNotFound, "not found"},
+		{"broken", http.StatusInternalServerError, "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/triage/"+tt.id+"/audit", http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteTriage(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.deleteFn = func(_ context.Context, id string) (bool, error) {
+		switch id {
+		case "done":
+			return true, nil
+		case "running":
+			return false, triage.ErrTriageActive
+		case "broken":
+			return false, errors.New("db down")
+		}
+		return false, nil
+	}
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantErr  string
+	}{
+		{"done", http.StatusNoContent, ""},
+		{"running", http.StatusConflict, CodeTriageActive},
+		{"missing", http.StatusNotFound, CodeNotFound},
+		{"broken", http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/triage/"+tt.id, http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantErr == "" {
+				if rec.Body.Len() != 0 {
+					t.Errorf("body = %q, want empty", rec.Body.String())
+				}
+				return
+			}
+			if got := decodeEnvelope(t, rec); got.Code != tt.wantErr {
+				t.Errorf("error code = %q, want %q", got.Code, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandleCancelTriage(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.cancelFn = func(_ context.Context, id string) (bool, error) {
+		switch id {
+		case "running":
+			return true, nil
+		case "done":
+			return false, triage.ErrTriageNotRunning
+		case "broken":
+			return false, errors.New("db down")
+		}
+		return false, nil
+	}
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantErr  string
+	}{
+		{"running", http.StatusAccepted, ""},
+		{"done", http.StatusConflict, CodeTriageNotRunning},
+		{"missing", http.StatusNotFound, CodeNotFound},
+		{"broken", http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/triage/"+tt.id+"/cancel", http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantErr == "" {
+				return
+			}
+			if got := decodeEnvelope(t, rec); got.Code != tt.wantErr {
+				t.Errorf("error code = %q, want %q", got.Code, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandleRestoreTriage(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	restored := map[string]bool{}
+	svc.restoreFn = func(_ context.Context, id string) (bool, error) {
+		if id != "deleted" {
+			return false, nil
+		}
+		restored[id] = true
+		return true, nil
+	}
+	svc.getFn = func(_ context.Context, id string) (*triage.Result, bool, error) {
+		if !restored[id] {
+			return nil, false, nil
+		}
+		return &triage.Result{ID: id, Status: triage.StatusComplete}, true, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/triage/deleted/restore", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body = %s", rec.Code, rec.Body.String())
+	}
+	var got triage.Result
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.ID != "deleted" {
+		t.Errorf("ID = %q, want deleted", got.ID)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/triage/live/restore", http.NoBody)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	if got := decodeEnvelope(t, rec); got.Code != CodeNotFound {
+		t.Errorf("error code = %q, want %q", got.Code, CodeNotFound)
+	}
+}
+
+func TestRegisterAdminRoutes_SeparateFromAPI(t *testing.T) {
+	t.Parallel()
+
+	// Without RegisterAdminRoutes the admin path falls through to the API
+	// router, which does not know it.
+	api, svc := newTestAPI(t)
+	svc.restoreFn = func(context.Context, string) (bool, error) {
+		t.Error("restore reached without admin routes")
+		return true, nil
+	}
+	r := chi.NewRouter()
+	api.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/triage/x/restore", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,136 @@
+package alertapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Overflow behaviors for alerts past BatchLimits.MaxTriages.
+const (
+	// OverflowReject reports the remaining alerts as rejected. Alertmanager
+	// sends them again on its next repeat while they keep firing.
+	OverflowReject = "reject"
+	// OverflowQueue holds the remaining alerts and submits them in the
+	// background, at most MaxTriages triages a second.
+	OverflowQueue = "queue"
+)
+
+// BatchLimits caps the work one webhook can cause. Zero fields are unlimited.
+type BatchLimits struct {
+	// MaxAlerts is the most alerts one webhook may carry. Larger webhooks
+	// are refused whole with 413; set Alertmanager's max_alerts to match so
+	// it truncates instead.
+	MaxAlerts int
+	// MaxTriages is the most triages one webhook may start. Alerts that are
+	// skipped, such as duplicates, do not count.
+	MaxTriages int
+	// Overflow is OverflowReject or OverflowQueue, empty means reject.
+	Overflow string
+	// QueueSize is the most alerts held for OverflowQueue. Alerts that do
+	// not fit are rejected.
+	QueueSize int
+
+	// OnOversized, if set, is called for every webhook refused for
+	// MaxAlerts.
+	OnOversized func()
+	// OnOverflow, if set, is called with OutcomeQueued or OutcomeRejected
+	// for every alert past MaxTriages.
+	OnOverflow func(outcome string)
+	// OnQueueDepth, if set, is called with the number of queued alerts
+	// whenever it changes.
+	OnQueueDepth func(n int)
+}
+
+// queuedAlert is an alert held for a later Submit, with the tenant and dry
+// run mark of the webhook it arrived in.
+type queuedAlert struct {
+	tenant string
+	dryRun bool
+	alert  alert.Alert
+}
+
+// WithBatchLimits applies l to every webhook. With OverflowQueue, RunOverflow
+// must be running to submit the queued alerts.
+func WithBatchLimits(l BatchLimits) Option {
+	return func(a *API) {
+		a.batch = l
+		if l.MaxTriages > 0 && l.Overflow == OverflowQueue {
+			a.overflow = make(chan queuedAlert, max(l.QueueSize, 1))
+		}
+	}
+}
+
+// overflowReason is the AlertResult reason for alerts past MaxTriages.
+const overflowReason = "webhook triage limit reached"
+
+// overflowAlert queues or rejects an alert past MaxTriages and reports which.
+func (a *API) overflowAlert(ctx context.Context, al *alert.Alert, res *AlertResult) {
+	res.Outcome, res.Reason = OutcomeRejected, overflowReason
+	if a.overflow != nil {
+		select {
+		case a.overflow <- queuedAlert{tenant: triage.TenantFrom(ctx), dryRun: triage.IsDryRun(ctx), alert: *al}:
+			res.Outcome = OutcomeQueued
+			a.queueDepth()
+		default:
+			res.Reason = "webhook triage limit reached and overflow queue full"
+		}
+	}
+	if a.batch.OnOverflow != nil {
+		a.batch.OnOverflow(res.Outcome)
+	}
+}
+
+func (a *API) queueDepth() {
+	if a.batch.OnQueueDepth != nil {
+		a.batch.OnQueueDepth(len(a.overflow))
+	}
+}
+
+// RunOverflow submits queued overflow alerts until ctx is cancelled, starting
+// at most MaxTriages triages a second so a large webhook is spread out
+// instead of started at once. It returns at once unless OverflowQueue is in
+// use.
+func (a *API) RunOverflow(ctx context.Context) {
+	if a.overflow == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.drainOverflow(ctx)
+		}
+	}
+}
+
+// drainOverflow submits queued alerts until MaxTriages of them have started
+// triages or the queue is empty.
+func (a *API) drainOverflow(ctx context.Context) {
+	started := 0
+	for started < a.batch.MaxTriages {
+		var q queuedAlert
+		select {
+		case q = <-a.overflow:
+		default:
+			return
+		}
+		a.queueDepth()
+		sctx := triage.WithTenant(ctx, q.tenant)
+		if q.dryRun {
+			sctx = triage.WithDryRun(sctx)
+		}
+		sr, err := a.svc.Submit(sctx, &q.alert)
+		switch {
+		case err != nil:
+			a.logger.Error(ctx, err, "queued submit failed", "fingerprint", q.alert.Fingerprint, "tenant", q.tenant)
+		case !sr.Skipped:
+			started++
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+	"github.com/linnemanlabs/vigil/internal/triage/memstore"
+)
+
+func webhookBody(n int) string {
+	alerts := make([]string, n)
+	for i := range alerts {
+		alerts[i] = fmt.Sprintf(`{"status":"firing","fingerprint":"fp-%d","labels":{"alertname":"A"}}`, i)
+	}
+	return `{"alerts":[` + strings.Join(alerts, ",") + `]}`
+}
+
+// postBatch sends a webhook of n alerts as tenant.
+func postBatch(t *testing.T, api *API, tenant string, n int) (*httptest.ResponseRecorder, IngestResponse) {
+	t.Helper()
+	r := chi.NewRouter()
+	api.RegisterRoutes(r)
+	ctx := triage.WithTenant(context.Background(), tenant)
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/alerts", strings.NewReader(webhookBody(n)))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var resp IngestResponse
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+func TestBatchLimits_MaxAlerts(t *testing.T) {
+	t.Parallel()
+
+	submits, oversized := 0, 0
+	svc := &stubTriageService{submitFn: func(context.Context, *alert.Alert) (*triage.SubmitResult, error) {
+		submits++
+		return &triage.SubmitResult{ID: "id"}, nil
+	}}
+	api := New(nil, svc, WithBatchLimits(BatchLimits{MaxAlerts: 3, OnOversized: func() { oversized++ }}))
+
+	if rec, _ := postBatch(t, api, "", 3); rec.Code != http.StatusAccepted {
+		t.Fatalf("at the limit = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	rec, _ := postBatch(t, api, "", 4)
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), `"code":"too_many_alerts"`) {
+		t.Fatalf("over the limit = %d %s, want 413 too_many_alerts", rec.Code, rec.Body.String())
+	}
+	if submits != 3 || oversized != 1 {
+		t.Errorf("submits = %d, oversized = %d, want 3 and 1", submits, oversized)
+	}
+}
+
+func TestBatchLimits_Reject(t *testing.T) {
+	t.Parallel()
+
+	svc := &stubTriageService{submitFn: func(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+		if al.Fingerprint == "fp-0" {
+			return &triage.SubmitResult{ID: "old", Skipped: true, Reason: "duplicate"}, nil
+		}
+		return &triage.SubmitResult{ID: "new-" + al.Fingerprint}, nil
+	}}
+	var overflow []string
+	api := New(nil, svc, WithBatchLimits(BatchLimits{
+		MaxTriages: 2,
+		OnOverflow: func(outcome string) { overflow = append(overflow, outcome) },
+	}))
+
+	rec, resp := postBatch(t, api, "", 5)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMultiStatus)
+	}
+	// The duplicate does not count toward the limit.
+	want := []string{OutcomeSkipped, OutcomeAccepted, OutcomeAccepted, OutcomeRejected, OutcomeRejected}
+	for i, o := range want {
+		if got := resp.Results[i]; got.Outcome != o {
+			t.Errorf("results[%d] = %+v, want outcome %s", i, got, o)
+		}
+	}
+	if r := resp.Results[3]; r.Reason != overflowReason || r.ID != "" {
+		t.Errorf("rejected result = %+v", r)
+	}
+	if len(resp.Accepted) != 2 || len(overflow) != 2 || overflow[0] != OutcomeRejected {
+		t.Errorf("accepted = %v, overflow = %v", resp.Accepted, overflow)
+	}
+}
+
+func TestBatchLimits_Queue(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var submitted []string
+	svc := &stubTriageService{submitFn: func(ctx context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		submitted = append(submitted, triage.TenantFrom(ctx)+"/"+al.Fingerprint)
+		return &triage.SubmitResult{ID: al.Fingerprint}, nil
+	}}
+	depth := -1
+	api := New(nil, svc, WithBatchLimits(BatchLimits{
+		MaxTriages:   1,
+		Overflow:     OverflowQueue,
+		QueueSize:    2,
+		OnQueueDepth: func(n int) { depth = n },
+	}))
+
+	rec, resp := postBatch(t, api, "acme", 4)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want %d (one alert did not fit the queue)", rec.Code, http.StatusMultiStatus)
+	}
+	want := []string{OutcomeAccepted, OutcomeQueued, OutcomeQueued, OutcomeRejected}
+	for i, o := range want {
+		if got := resp.Results[i]; got.Outcome != o {
+			t.Errorf("results[%d] = %+v, want outcome %s", i, got, o)
+		}
+	}
+	if depth != 2 {
+		t.Errorf("queue depth = %d, want 2", depth)
+	}
+
+	// Each drain starts at most MaxTriages triages.
+	api.drainOverflow(context.Background())
+	api.drainOverflow(context.Background())
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(submitted, ","); got != "acme/fp-0,acme/fp-1,acme/fp-2" {
+		t.Errorf("submitted = %s", got)
+	}
+	if depth != 0 {
+		t.Errorf("queue depth after drain = %d, want 0", depth)
+	}
+}
+
+func TestBatchLimits_QueueKeepsDryRun(t *testing.T) {
+	t.Parallel()
+
+	store := memstore.New()
+	svc := triage.NewService(store, triage.NopEngine{}, log.Nop(), nil, nil, noop.NewTracerProvider())
+	api := New(nil, svc, WithBatchLimits(BatchLimits{MaxTriages: 1, Overflow: OverflowQueue, QueueSize: 2}))
+	r := chi.NewRouter()
+	api.RegisterRoutes(r)
+
+	ctx := triage.WithTenant(context.Background(), "acme")
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/v1/alerts?dry_run=true", strings.NewReader(webhookBody(2)))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	var resp IngestResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[1].Outcome != OutcomeQueued {
+		t.Fatalf("results = %+v, want the second alert queued", resp.Results)
+	}
+
+	api.drainOverflow(context.Background())
+	queued, ok, err := store.GetByFingerprint(ctx, "fp-1")
+	if err != nil || !ok {
+		t.Fatalf("queued alert not submitted: %v", err)
+	}
+	if !queued.DryRun {
+		t.Errorf("queued alert of a dry-run webhook stored with DryRun unset: %+v", queued)
+	}
+}
@@ -0,0 +1,65 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// DecisionsResponse is the body of GET /decisions.
+type DecisionsResponse struct {
+	Decisions []*triage.Decision `json:"decisions"`
+}
+
+// handleListDecisions answers "why was this alert (not) triaged?" from the
+// decision log.
+func (a *API) handleListDecisions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := triage.DecisionFilter{
+		Fingerprint: q.Get("fingerprint"),
+		Alert:       q.Get("alert"),
+		Decision:    q.Get("decision"),
+	}
+	if f.Decision != "" && f.Decision != triage.DecisionAccepted && f.Decision != triage.DecisionSkipped {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid decision, want accepted or skipped")
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid limit, want a positive integer")
+			return
+		}
+		f.Limit = n
+	}
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid before, want RFC 3339 timestamp")
+			return
+		}
+		f.Before = t
+		f.BeforeID = q.Get("before_id")
+	}
+
+	decisions, err := a.svc.Decisions(r.Context(), f)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to list decisions")
+		writeInternal(w, r)
+		return
+	}
+	if decisions == nil {
+		decisions = []*triage.Decision{}
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("vigil.decisions.listed", len(decisions)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(DecisionsResponse{Decisions: decisions})
+}
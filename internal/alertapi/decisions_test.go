@@ -0,0 +1,62 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleListDecisions(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var got triage.DecisionFilter
+	svc.decideFn = func(_ context.Context, f triage.DecisionFilter) ([]*triage.Decision, error) {
+		got = f
+		return []*triage.Decision{{ID: "d1", Fingerprint: "fp-1", Decision: triage.DecisionSkipped, Reason: "snoozed", Rule: "snooze-1"}}, nil
+	}
+
+	tests := []struct {
+		query    string
+		wantCode int
+		want     triage.DecisionFilter
+	}{
+		{"", http.StatusOK, triage.DecisionFilter{}},
+		{"?fingerprint=fp-1&alert=DiskFull&decision=skipped&limit=10", http.StatusOK, triage.DecisionFilter{Fingerprint: "fp-1", Alert: "DiskFull", Decision: triage.DecisionSkipped, Limit: 10}},
+		{"?before=2026-03-01T12:00:00Z&before_id=d9", http.StatusOK, triage.DecisionFilter{Before: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), BeforeID: "d9"}},
+		{"?decision=maybe", http.StatusBadRequest, triage.DecisionFilter{}},
+		{"?limit=0", http.StatusBadRequest, triage.DecisionFilter{}},
+		{"?before=yesterday", http.StatusBadRequest, triage.DecisionFilter{}},
+	}
+	for _, tt := range tests {
+		got = triage.DecisionFilter{}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/decisions"+tt.query, http.NoBody))
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%q: status = %d, want %d", tt.query, rec.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			if env := decodeEnvelope(t, rec); env.Code != CodeInvalidParameter {
+				t.Errorf("%q: code = %q, want %q", tt.query, env.Code, CodeInvalidParameter)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: filter = %+v, want %+v", tt.query, got, tt.want)
+		}
+		var resp DecisionsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Decisions) != 1 || resp.Decisions[0].Rule != "snooze-1" {
+			t.Errorf("%q: decisions = %+v", tt.query, resp.Decisions)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/linnemanlabs/go-core/httpmw"
+)
+
+// DocsURL points at the README section that lists error codes.
+const DocsURL = "https://github.com/linnemanlabs/vigil#error-codes"
+
+// Error codes returned in the error envelope. Clients should branch on these
+// rather than on messages, which are meant for humans and may change.
+const (
+	CodeInvalidPayload      = "invalid_payload"
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeUnauthorized        = "unauthorized"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeFingerprintMismatch = "fingerprint_mismatch"
+	CodeTriageActive        = "triage_active"
+	CodeTriageNotRunning    = "triage_not_running"
+	CodeApprovalRequired    = "approval_required"
+	CodeActionExecuted      = "action_executed"
+	CodeActionInvalid       = "action_invalid"
+	CodeRateLimited         = "rate_limited"
+	CodeTooManyAlerts       = "too_many_alerts"
+	CodeInternal            = "internal"
+)
+
+// ErrorBody is the machine-readable part of an error response.
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	DocsURL   string `json:"docs_url"`
+}
+
+// ErrorEnvelope is the JSON body of every API error response.
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// WriteError writes an error envelope with the request ID assigned by the
+// request ID middleware, if any.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorEnvelope{Error: ErrorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: httpmw.RequestIDFromContext(r.Context()),
+		DocsURL:   DocsURL,
+	}})
+}
+
+// NotFound is a router NotFound handler that answers with the error envelope.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusNotFound, CodeNotFound, "no route for "+r.Method+" "+r.URL.Path)
+}
+
+// MethodNotAllowed is a router MethodNotAllowed handler that answers with the
+// error envelope.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method "+r.Method+" not allowed on "+r.URL.Path)
+}
+
+func writeInternal(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusInternalServerError, CodeInternal, "internal error")
+}
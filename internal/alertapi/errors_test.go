@@ -0,0 +1,78 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/httpmw"
+)
+
+func decodeEnvelope(t *testing.T, rec *httptest.ResponseRecorder) ErrorBody {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var env ErrorEnvelope
+	if err := json.NewDecoder(rec.Body).Decode(&env); err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	return env.Error
+}
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/triage/x", http.NoBody)
+	req = req.WithContext(httpmw.WithRequestID(req.Context(), "req-123"))
+	rec := httptest.NewRecorder()
+
+	WriteError(rec, req, http.StatusNotFound, CodeNotFound, "triage not found")
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	got := decodeEnvelope(t, rec)
+	want := ErrorBody{Code: CodeNotFound, Message: "triage not found", RequestID: "req-123", DocsURL: DocsURL}
+	if got != want {
+		t.Errorf("envelope = %+v, want %+v", got, want)
+	}
+}
+
+func TestRouterErrors_UseEnvelope(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		method, path, body string
+		wantCode           int
+		wantErrCode        string
+	}{
+		{http.MethodGet, "/api/v1/unknown", "", http.StatusNotFound, CodeNotFound},
+		{http.MethodDelete, "/api/v1/alerts", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{http.MethodGet, "/api/v1/triage/missing", "", http.StatusNotFound, CodeNotFound},
+		{http.MethodGet, "/api/v1/triage?limit=0", "", http.StatusBadRequest, CodeInvalidParameter},
+		{http.MethodPost, "/api/v1/alerts", "{bad", http.StatusBadRequest, CodeInvalidPayload},
+		{http.MethodPost, "/api/v1/events", `{"title":""}`, http.StatusBadRequest, CodeInvalidPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			got := decodeEnvelope(t, rec)
+			if got.Code != tt.wantErrCode || got.Message == "" || got.DocsURL != DocsURL {
+				t.Errorf("envelope = %+v, want code %q", got, tt.wantErrCode)
+			}
+		})
+	}
+}
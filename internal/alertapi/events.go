@@ -0,0 +1,65 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// EventResponse is the body of POST /events.
+type EventResponse struct {
+	Accepted    []string `json:"accepted"`
+	Fingerprint string   `json:"fingerprint"`
+	Skipped     bool     `json:"skipped"`
+	Reason      string   `json:"reason"`
+}
+
+// handleIngestEvent accepts a generic event from a non-Alertmanager source,
+// normalizes it into an alert.Alert and submits it for triage.
+func (a *API) handleIngestEvent(w http.ResponseWriter, r *http.Request) {
+	received := time.Now()
+	var ev alert.Event
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	if err := ev.Validate(); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, err.Error())
+		return
+	}
+
+	al := ev.ToAlert()
+	al.ReceivedAt = received
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(
+		attribute.String("vigil.event.source", ev.Source),
+		attribute.String("vigil.alert.fingerprint", al.Fingerprint),
+	)
+
+	sr, err := a.svc.Submit(r.Context(), &al)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "submit failed", "fingerprint", al.Fingerprint, "source", ev.Source)
+		writeInternal(w, r)
+		return
+	}
+
+	accepted := []string{}
+	if !sr.Skipped {
+		accepted = append(accepted, sr.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(EventResponse{
+		Accepted:    accepted,
+		Fingerprint: al.Fingerprint,
+		Skipped:     sr.Skipped,
+		Reason:      sr.Reason,
+	})
+}
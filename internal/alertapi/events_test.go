@@ -0,0 +1,183 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleIngestEvent_NormalizesAndSubmits(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var got *alert.Alert
+	svc.submitFn = func(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+		got = al
+		return &triage.SubmitResult{ID: "event-id-001"}, nil
+	}
+
+	body := `{
+		"title": "DiskFull",
+		"description": "root volume at 98%",
+		"severity": "warning",
+		"source": "cloudwatch",
+		"labels": {"instance": "web-1"},
+		"url": "https://console.example.com/alarm/1"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got == nil {
+		t.Fatal("Submit was not called")
+	}
+	if got.Status != "firing" {
+		t.Errorf("status = %q, want firing", got.Status)
+	}
+	if got.Labels["alertname"] != "DiskFull" {
+		t.Errorf("alertname = %q, want DiskFull", got.Labels["alertname"])
+	}
+	if got.Labels["severity"] != "warning" {
+		t.Errorf("severity = %q, want warning", got.Labels["severity"])
+	}
+	if got.Labels["source"] != "cloudwatch" {
+		t.Errorf("source = %q, want cloudwatch", got.Labels["source"])
+	}
+	if got.Labels["instance"] != "web-1" {
+		t.Errorf("instance = %q, want web-1", got.Labels["instance"])
+	}
+	if got.Annotations["summary"] != "DiskFull" {
+		t.Errorf("summary = %q, want DiskFull", got.Annotations["summary"])
+	}
+	if got.Annotations["description"] != "root volume at 98%" {
+		t.Errorf("description = %q", got.Annotations["description"])
+	}
+	if got.GeneratorURL != "https://console.example.com/alarm/1" {
+		t.Errorf("generatorURL = %q", got.GeneratorURL)
+	}
+	if len(got.Fingerprint) != 16 {
+		t.Errorf("fingerprint = %q, want 16 hex chars", got.Fingerprint)
+	}
+	if got.StartsAt.IsZero() {
+		t.Error("expected StartsAt to default to now")
+	}
+
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	accepted, ok := resp["accepted"].([]any)
+	if !ok || len(accepted) != 1 || accepted[0] != "event-id-001" {
+		t.Errorf("accepted = %v, want [event-id-001]", resp["accepted"])
+	}
+	if resp["fingerprint"] != got.Fingerprint {
+		t.Errorf("fingerprint = %v, want %q", resp["fingerprint"], got.Fingerprint)
+	}
+}
+
+func TestHandleIngestEvent_KeepsSuppliedFingerprint(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var fp string
+	svc.submitFn = func(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+		fp = al.Fingerprint
+		return &triage.SubmitResult{ID: "x"}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(`{"title":"T","fingerprint":"sentry-release-42"}`))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if fp != "sentry-release-42" {
+		t.Errorf("fingerprint = %q, want sentry-release-42", fp)
+	}
+}
+
+func TestHandleIngestEvent_Skipped(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.submitFn = func(_ context.Context, _ *alert.Alert) (*triage.SubmitResult, error) {
+		return &triage.SubmitResult{Skipped: true, Reason: "duplicate"}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(`{"title":"T"}`))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if accepted, _ := resp["accepted"].([]any); len(accepted) != 0 {
+		t.Errorf("accepted = %v, want empty", accepted)
+	}
+	if resp["skipped"] != true || resp["reason"] != "duplicate" {
+		t.Errorf("skipped/reason = %v/%v, want true/duplicate", resp["skipped"], resp["reason"])
+	}
+}
+
+func TestHandleIngestEvent_BadRequests(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{bad`},
+		{"missing title", `{"description":"no title"}`},
+		{"blank title", `{"title":"   "}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandleIngestEvent_SubmitError(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.submitFn = func(_ context.Context, _ *alert.Alert) (*triage.SubmitResult, error) {
+		return nil, errors.New("db down")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(`{"title":"T"}`))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
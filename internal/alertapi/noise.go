@@ -0,0 +1,43 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// maxNoiseWindow bounds how much history GET /noise may scan.
+const maxNoiseWindow = 30 * 24 * time.Hour
+
+// NoiseResponse is the body of GET /noise.
+type NoiseResponse struct {
+	Window string              `json:"window"`
+	Alerts []triage.NoiseScore `json:"alerts"`
+}
+
+// handleNoise scores each alertname by how noisy its recent triages were.
+func (a *API) handleNoise(w http.ResponseWriter, r *http.Request) {
+	window := triage.DefaultNoiseWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxNoiseWindow {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid window, want a Go duration up to 720h")
+			return
+		}
+		window = d
+	}
+
+	scores, err := a.svc.NoiseScores(r.Context(), window)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to compute noise scores")
+		writeInternal(w, r)
+		return
+	}
+	if scores == nil {
+		scores = []triage.NoiseScore{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(NoiseResponse{Window: window.String(), Alerts: scores})
+}
@@ -0,0 +1,58 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleNoise(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var gotWindow time.Duration
+	svc.noiseFn = func(_ context.Context, window time.Duration) ([]triage.NoiseScore, error) {
+		gotWindow = window
+		return []triage.NoiseScore{{Alert: "Flapping", Score: 0.9, Triages: 120}}, nil
+	}
+
+	tests := []struct {
+		query      string
+		wantCode   int
+		wantWindow time.Duration
+	}{
+		{"", http.StatusOK, triage.DefaultNoiseWindow},
+		{"?window=24h", http.StatusOK, 24 * time.Hour},
+		{"?window=soon", http.StatusBadRequest, 0},
+		{"?window=-1h", http.StatusBadRequest, 0},
+		{"?window=1000h", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		gotWindow = 0
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/noise"+tt.query, http.NoBody))
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%q: status = %d, want %d", tt.query, rec.Code, tt.wantCode)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			if env := decodeEnvelope(t, rec); env.Code != CodeInvalidParameter {
+				t.Errorf("%q: code = %q, want %q", tt.query, env.Code, CodeInvalidParameter)
+			}
+			continue
+		}
+		var resp NoiseResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if gotWindow != tt.wantWindow || resp.Window != tt.wantWindow.String() || len(resp.Alerts) != 1 || resp.Alerts[0].Alert != "Flapping" {
+			t.Errorf("%q: window %s, response %+v", tt.query, gotWindow, resp)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+)
+
+// RenderRequest is the body of POST /admin/notify/render. Without a TriageID
+// the template is rendered against a sample critical result; Severity, if
+// set, overrides the result's severity to pick another variant.
+type RenderRequest struct {
+	Template string `json:"template"`
+	TriageID string `json:"triage_id,omitempty"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// RenderResponse is the body of POST /admin/notify/render. Variant is the
+// severity whose variant rendered the message, "*" for the fallback, or
+// empty when the template has none and notifiers keep their built-in message.
+type RenderResponse struct {
+	Template string `json:"template"`
+	TriageID string `json:"triage_id"`
+	Variant  string `json:"variant"`
+	Text     string `json:"text"`
+}
+
+// WithNotifyTemplates enables test renders of the message templates in ts.
+// Without it the render route is not registered.
+func WithNotifyTemplates(ts *notify.Templates) Option {
+	return func(a *API) { a.templates = ts }
+}
+
+// handleRenderNotification renders a message template the way the notifiers
+// would, so an operator can preview an edit without waiting for an alert.
+func (a *API) handleRenderNotification(w http.ResponseWriter, r *http.Request) {
+	var req RenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	tmpl := a.templates.Get(req.Template)
+	if tmpl == nil {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "template not found")
+		return
+	}
+
+	result := notify.SampleResults()[0]
+	if req.TriageID != "" {
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("vigil.triage.id", req.TriageID))
+		var ok bool
+		var err error
+		result, ok, err = a.svc.Get(r.Context(), req.TriageID)
+		if err != nil {
+			a.logger.Error(r.Context(), err, "failed to get triage result", "id", req.TriageID)
+			writeInternal(w, r)
+			return
+		}
+		if !ok {
+			WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+			return
+		}
+	}
+	if req.Severity != "" {
+		cp := *result
+		cp.Severity = req.Severity
+		result = &cp
+	}
+
+	text, _, err := tmpl.Render(result)
+	if err != nil {
+		WriteError(w, r, http.StatusUnprocessableEntity, CodeInvalidPayload, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(RenderResponse{
+		Template: tmpl.Name(),
+		TriageID: result.ID,
+		Variant:  tmpl.Variant(result),
+		Text:     text,
+	})
+}
+
+// notifyRoutes are the routes enabled by WithNotifyTemplates.
+func (a *API) notifyRoutes() []route {
+	if a.templates == nil {
+		return nil
+	}
+	return []route{{
+		method: http.MethodPost, pattern: "/notify/render", handler: a.handleRenderNotification, admin: true,
+		summary:     "Render a notification template",
+		description: "Renders a named message template against a stored triage, or a sample result without triage_id, and returns the message the notifiers would send. A template that fails against a real result, for example indexing past the end of a list, returns 422 with the template error.",
+		request:     RenderRequest{},
+		responses:   map[int]any{http.StatusOK: RenderResponse{}},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+	}}
+}
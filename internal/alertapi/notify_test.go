@@ -0,0 +1,90 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestRenderNotification(t *testing.T) {
+	t.Parallel()
+
+	ts, err := notify.New(notify.Config{Templates: map[string]map[string]string{
+		"oncall": {"critical": "page {{.Alert}} on {{.Labels.instance}}", "*": "fyi {{.Alert}}"},
+		"strict": {"critical": "{{if .Labels.team}}{{index .ToolsUsed 0}}{{end}}"},
+	}}, "")
+	if err != nil {
+		t.Fatalf("notify.New: %v", err)
+	}
+	svc := &stubTriageService{
+		getFn: func(_ context.Context, id string) (*triage.Result, bool, error) {
+			if id != "01STORED" {
+				return nil, false, nil
+			}
+			return &triage.Result{ID: id, Alert: "DiskFull", Severity: "warning", Labels: map[string]string{"instance": "db-1", "team": "storage"}}, true, nil
+		},
+	}
+	r := chi.NewRouter()
+	New(nil, svc, WithNotifyTemplates(ts)).RegisterAdminRoutes(r)
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantVariant string
+		wantText    string
+	}{
+		{name: "sample result", body: `{"template":"oncall"}`, wantStatus: http.StatusOK, wantVariant: "critical", wantText: "page HighCPU on web-1"},
+		{name: "stored triage", body: `{"template":"oncall","triage_id":"01STORED"}`, wantStatus: http.StatusOK, wantVariant: notify.AnySeverity, wantText: "fyi DiskFull"},
+		{name: "severity override", body: `{"template":"oncall","triage_id":"01STORED","severity":"critical"}`, wantStatus: http.StatusOK, wantVariant: "critical", wantText: "page DiskFull on db-1"},
+		{name: "no variant", body: `{"template":"strict","triage_id":"01STORED"}`, wantStatus: http.StatusOK},
+		{name: "render error", body: `{"template":"strict","triage_id":"01STORED","severity":"critical"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "unknown template", body: `{"template":"missing"}`, wantStatus: http.StatusNotFound},
+		{name: "unknown triage", body: `{"template":"oncall","triage_id":"01MISSING"}`, wantStatus: http.StatusNotFound},
+		{name: "bad json", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/notify/render", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var resp RenderResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Variant != tt.wantVariant || resp.Text != tt.wantText {
+				t.Errorf("variant, text = %q, %q, want %q, %q", resp.Variant, resp.Text, tt.wantVariant, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestRenderNotification_NotRegisteredWithoutTemplates(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/notify/render", strings.NewReader(`{"template":"default"}`))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// oncallWebhook is the Grafana OnCall outgoing webhook payload. Only the
+// fields Vigil maps are declared.
+type oncallWebhook struct {
+	Event struct {
+		Type string `json:"type"`
+	} `json:"event"`
+	AlertGroup struct {
+		ID         string            `json:"id"`
+		Title      string            `json:"title"`
+		State      string            `json:"state"`
+		CreatedAt  time.Time         `json:"created_at"`
+		Labels     map[string]string `json:"labels"`
+		Permalinks struct {
+			Web string `json:"web"`
+		} `json:"permalinks"`
+	} `json:"alert_group"`
+	AlertPayload struct {
+		Labels       map[string]string `json:"labels"`
+		Annotations  map[string]string `json:"annotations"`
+		GeneratorURL string            `json:"generatorURL"`
+		Message      string            `json:"message"`
+	} `json:"alert_payload"`
+}
+
+// toAlert maps an OnCall alert group into an alert.Alert. Labels from the
+// original alert payload (when it came from Alertmanager/Grafana) take
+// precedence over alert group labels. Any state other than firing is passed
+// through so Submit skips it.
+func (p *oncallWebhook) toAlert() alert.Alert {
+	ag := &p.AlertGroup
+
+	labels := make(map[string]string)
+	maps.Copy(labels, ag.Labels)
+	maps.Copy(labels, p.AlertPayload.Labels)
+	if labels["alertname"] == "" {
+		labels["alertname"] = ag.Title
+	}
+	labels["source"] = "grafana-oncall"
+
+	annotations := make(map[string]string)
+	maps.Copy(annotations, p.AlertPayload.Annotations)
+	if annotations["summary"] == "" {
+		annotations["summary"] = ag.Title
+	}
+	if annotations["description"] == "" && p.AlertPayload.Message != "" {
+		annotations["description"] = p.AlertPayload.Message
+	}
+
+	status := strings.ToLower(ag.State)
+	if status == "" || status == "new" {
+		status = "firing"
+	}
+
+	generatorURL := p.AlertPayload.GeneratorURL
+	if generatorURL == "" {
+		generatorURL = ag.Permalinks.Web
+	}
+
+	fp := ag.ID
+	if fp == "" {
+		fp = alert.Fingerprint(labels)
+	}
+
+	return alert.Alert{
+		Status:       status,
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     ag.CreatedAt,
+		GeneratorURL: generatorURL,
+		Fingerprint:  fp,
+	}
+}
+
+func (a *API) handleOnCallWebhook(w http.ResponseWriter, r *http.Request) {
+	var wh oncallWebhook
+	if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	if wh.AlertGroup.ID == "" && wh.AlertGroup.Title == "" {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "missing alert_group")
+		return
+	}
+
+	a.submitAlerts(w, r, []alert.Alert{wh.toAlert()})
+}
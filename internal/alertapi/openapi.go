@@ -0,0 +1,625 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// basePath is where RegisterRoutes mounts the API, and adminPath where
+// RegisterAdminRoutes mounts the admin endpoints.
+const (
+	basePath  = "/api/v1"
+	adminPath = basePath + "/admin"
+)
+
+// route is one API endpoint. It drives both the router and the OpenAPI
+// document.
+type route struct {
+	method  string
+	pattern string
+	handler http.HandlerFunc
+	// admin routes are relative to adminPath and use the admin token.
+	admin bool
+	// public routes take no bearer token and authenticate the request
+	// themselves.
+	public bool
+	// ingest routes are wrapped in the WithIngestLimit middleware.
+	ingest bool
+
+	summary     string
+	description string
+	query       []queryParam
+	// request is a zero value of the JSON request body type, nil for none.
+	request any
+	// responses maps status codes to a zero value of the body type, nil for
+	// an empty body.
+	responses map[int]any
+	// errors lists the statuses that answer with an ErrorEnvelope.
+	errors []int
+}
+
+type queryParam struct {
+	name        string
+	description string
+	schema      *schema
+	required    bool
+}
+
+func (a *API) routes() []route {
+	var shareRoutes []route
+	if a.share != nil {
+		shareRoutes = []route{
+			{
+				method: http.MethodPost, pattern: "/triage/{id}/share", handler: a.handleCreateShare,
+				summary:     "Create a share link to a triage report",
+				description: "Signs a link that lets anyone holding it read the triage's report, without an API token, until it expires. Links cannot be revoked individually; rotating the share key revokes all of them.",
+				request:     ShareRequest{},
+				responses:   map[int]any{http.StatusCreated: ShareResponse{}},
+				errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+			},
+			{
+				method: http.MethodGet, pattern: "/triage/{id}/report", handler: a.handleGetReport, public: true,
+				summary:     "Get a shared triage report",
+				description: "Authenticated by the share token instead of a bearer token. Returns the analysis without the conversation or system prompt. As html or markdown, the report is a document for incident reviews, with a sparkline of each of the triage's last six range queries that returned data: inline SVG in HTML, PNG data URIs in Markdown.",
+				query: []queryParam{
+					{name: "token", description: "Share token from the share link", schema: &schema{Type: "string"}},
+					{name: "format", description: "Report format, json by default", schema: &schema{Type: "string", Enum: []string{ReportJSON, ReportHTML, ReportMarkdown}}},
+				},
+				responses: map[int]any{http.StatusOK: Report{}},
+				errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+			},
+		}
+	}
+
+	ingestResponses := map[int]any{
+		http.StatusAccepted:    IngestResponse{},
+		http.StatusMultiStatus: IngestResponse{},
+		// All alerts failed: the body also carries the error.
+		http.StatusInternalServerError: IngestResponse{},
+	}
+	// A webhook failing validation: the error also lists the problems.
+	webhookResponses := maps.Clone(ingestResponses)
+	webhookResponses[http.StatusBadRequest] = ValidationResponse{}
+	return append([]route{
+		{
+			method: http.MethodPost, pattern: "/alerts", handler: a.handleIngestAlert, ingest: true,
+			summary: "Ingest an Alertmanager webhook",
+			query: []queryParam{
+				{name: "dry_run", description: "Triage and store the alerts without notifying, opening issues or suggesting actions, e.g. to backfill past alerts", schema: &schema{Type: "boolean"}},
+			},
+			request:   alert.Webhook{},
+			responses: webhookResponses,
+			errors:    []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests},
+		},
+		{
+			method: http.MethodPost, pattern: "/events", handler: a.handleIngestEvent,
+			summary:   "Ingest a generic event",
+			request:   alert.Event{},
+			responses: map[int]any{http.StatusAccepted: EventResponse{}},
+			errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodPost, pattern: "/webhooks/grafana-oncall", handler: a.handleOnCallWebhook,
+			summary:   "Ingest a Grafana OnCall outgoing webhook",
+			request:   oncallWebhook{},
+			responses: ingestResponses,
+			errors:    []int{http.StatusBadRequest},
+		},
+		{
+			method: http.MethodPost, pattern: "/webhooks/opsgenie", handler: a.handleOpsgenieWebhook,
+			summary:   "Ingest an Opsgenie webhook",
+			request:   opsgenieWebhook{},
+			responses: ingestResponses,
+			errors:    []int{http.StatusBadRequest},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage", handler: a.handleListTriage,
+			summary: "List triage results, newest first",
+			query: []queryParam{
+				{name: "status", description: "Only results with this status", schema: enumSchema(triageStatuses)},
+				{name: "alert", description: "Only results for this alert name", schema: &schema{Type: "string"}},
+				{name: "label", description: "Only results whose alert has this label, as name:value; repeat to require several", schema: &schema{Type: "array", Items: &schema{Type: "string"}}},
+				{name: "before", description: "Only results created before this RFC 3339 timestamp", schema: &schema{Type: "string", Format: "date-time"}},
+				{name: "before_id", description: "With before, also results created at that timestamp whose ID sorts before this one; pass the last result's ID to page", schema: &schema{Type: "string"}},
+				{name: "limit", description: "Maximum results to return, capped at " + strconv.Itoa(triage.MaxListLimit), schema: &schema{Type: "integer", Minimum: ptr(1.0)}},
+			},
+			responses: map[int]any{http.StatusOK: ListResponse{}},
+			errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage/search", handler: a.handleSearchTriage,
+			summary:     "Search triage results",
+			description: "Full-text search over alert names, summaries and analyses, best match first. Snippets mark matched words in **bold**.",
+			query: []queryParam{
+				{name: "q", description: "Search terms; quoted phrases, or and -word are supported with the Postgres store", schema: &schema{Type: "string"}, required: true},
+				{name: "limit", description: "Maximum results to return, capped at " + strconv.Itoa(triage.MaxListLimit), schema: &schema{Type: "integer", Minimum: ptr(1.0)}},
+			},
+			responses: map[int]any{http.StatusOK: SearchResponse{}},
+			errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage/{id}", handler: a.handleGetTriage,
+			summary:   "Get a triage result",
+			responses: map[int]any{http.StatusOK: triage.Result{}},
+			errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage/{id}/notes", handler: a.handleGetTriageNotes,
+			summary:     "Get a triage's investigation notes",
+			description: "The model's commentary between tool calls, without the full conversation.",
+			responses:   map[int]any{http.StatusOK: NotesResponse{}},
+			errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage/{id}/timeline", handler: a.handleGetTriageTimeline,
+			summary:     "Get a triage's timeline",
+			description: "Ordered events from submission through each LLM and tool call to completion and notification, with durations.",
+			responses:   map[int]any{http.StatusOK: triage.Timeline{}},
+			errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage/{id}/audit", handler: a.handleGetTriageAudit,
+			summary:     "Get a triage's audit trail",
+			description: "Every lifecycle transition, oldest first: submitted, duplicates folded in, started, completed or failed, cancelled, deleted and restored, each with the actor (system or the API token) and time.",
+			responses:   map[int]any{http.StatusOK: AuditResponse{}},
+			errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/triage/{id}/compare/{otherID}", handler: a.handleCompareTriage,
+			summary:   "Compare two triages of the same fingerprint",
+			responses: map[int]any{http.StatusOK: triage.Comparison{}},
+			errors:    []int{http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodDelete, pattern: "/triage/{id}", handler: a.handleDeleteTriage,
+			summary:     "Delete a finished triage",
+			description: "Soft delete: the triage is hidden from the API and can be restored by an admin until the purge job removes it.",
+			responses:   map[int]any{http.StatusNoContent: nil},
+			errors:      []int{http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodPost, pattern: "/triage/{id}/cancel", handler: a.handleCancelTriage,
+			summary:     "Cancel a running triage",
+			description: "Stops a pending or in-progress triage, which then finishes with status error. Only triages running on the server that receives the request can be cancelled.",
+			responses:   map[int]any{http.StatusAccepted: nil},
+			errors:      []int{http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodPost, pattern: "/snooze", handler: a.handleCreateSnooze,
+			summary:     "Snooze triage for matching alerts",
+			description: "Compatibility alias for POST /suppressions. Stores a suppression with reason snoozed, so matching alerts are skipped with reason snoozed until the duration runs out.",
+			request:     SnoozeRequest{},
+			responses:   map[int]any{http.StatusCreated: triage.Suppression{}},
+			errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/snooze", handler: a.handleListSnoozes,
+			summary:   "List active snoozes",
+			responses: map[int]any{http.StatusOK: SnoozeListResponse{}},
+			errors:    []int{http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodDelete, pattern: "/snooze/{id}", handler: a.handleDeleteSnooze,
+			summary:     "End a snooze early",
+			description: "Only suppressions with reason snoozed are found; end other suppressions with DELETE /suppressions/{id}.",
+			responses:   map[int]any{http.StatusNoContent: nil},
+			errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodPost, pattern: "/suppressions", handler: a.handleCreateSuppression,
+			summary:     "Suppress triage for matching alerts",
+			description: "Alerts matching the fingerprint and every label matcher are skipped with reason suppressed until the ttl runs out. Suppressions are stored, so they apply on every replica and survive restarts.",
+			request:     SuppressionRequest{},
+			responses:   map[int]any{http.StatusCreated: triage.Suppression{}},
+			errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/suppressions", handler: a.handleListSuppressions,
+			summary:   "List active suppressions",
+			responses: map[int]any{http.StatusOK: SuppressionListResponse{}},
+			errors:    []int{http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodDelete, pattern: "/suppressions/{id}", handler: a.handleDeleteSuppression,
+			summary:   "End a suppression early",
+			responses: map[int]any{http.StatusNoContent: nil},
+			errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/decisions", handler: a.handleListDecisions,
+			summary:     "List submit decisions, newest first",
+			description: "Why each submitted alert was triaged or skipped: the reason, the profile, suppression or guardrail that decided it, and the triage it started or was folded into.",
+			query: []queryParam{
+				{name: "fingerprint", description: "Only decisions for this alert fingerprint", schema: &schema{Type: "string"}},
+				{name: "alert", description: "Only decisions for this alert name", schema: &schema{Type: "string"}},
+				{name: "decision", description: "Only accepted or only skipped alerts", schema: enumSchema([]string{triage.DecisionAccepted, triage.DecisionSkipped})},
+				{name: "before", description: "Only decisions made before this RFC 3339 timestamp", schema: &schema{Type: "string", Format: "date-time"}},
+				{name: "before_id", description: "With before, also decisions made at that timestamp whose ID sorts before this one; pass the last decision's ID to page", schema: &schema{Type: "string"}},
+				{name: "limit", description: "Maximum decisions to return, capped at " + strconv.Itoa(triage.MaxListLimit), schema: &schema{Type: "integer", Minimum: ptr(1.0)}},
+			},
+			responses: map[int]any{http.StatusOK: DecisionsResponse{}},
+			errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/noise", handler: a.handleNoise,
+			summary:     "Score alert names by noise",
+			description: "Scores from 0 to 1 per alert name, noisiest first, from how often it was triaged within the window and how often its analysis repeated an earlier one.",
+			query: []queryParam{
+				{name: "window", description: "History to score, as a Go duration up to 720h. Default 168h.", schema: &schema{Type: "string"}},
+			},
+			responses: map[int]any{http.StatusOK: NoiseResponse{}},
+			errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/stats", handler: a.handleStats,
+			summary:     "Aggregate triages over a time window",
+			description: "Counts by status, duration percentiles of finished triages, token usage and list-price cost per model, the most triaged alert names, and tool error rates. Costs ignore batch discounts and prompt caching and are absent for models without a known price.",
+			query: []queryParam{
+				{name: "window", description: "Length of the window, as a Go duration up to 2160h. Default 24h.", schema: &schema{Type: "string"}},
+				{name: "until", description: "End of the window as an RFC 3339 timestamp. Default now.", schema: &schema{Type: "string", Format: "date-time"}},
+				{name: "top", description: "How many alert names to rank. Default " + strconv.Itoa(triage.DefaultStatsTopAlerts) + ".", schema: &schema{Type: "integer", Minimum: ptr(1.0)}},
+			},
+			responses: map[int]any{http.StatusOK: triage.Stats{}},
+			errors:    []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/tools", handler: a.handleListTools,
+			summary:     "List the tools available to triages",
+			description: "Each tool's description and input and output schemas as the model sees them, whether its circuit breaker currently withholds it, and its success rate over its most recent calls on this server. Tools that mostly failed recently are flagged to the model as unreliable.",
+			responses:   map[int]any{http.StatusOK: ToolsResponse{}},
+			errors:      []int{http.StatusInternalServerError},
+		},
+		{
+			method: http.MethodGet, pattern: "/openapi.json", handler: a.handleOpenAPI,
+			summary:   "This OpenAPI document",
+			responses: map[int]any{http.StatusOK: map[string]any{}},
+		},
+		{
+			method: http.MethodPost, pattern: "/triage/{id}/restore", handler: a.handleRestoreTriage, admin: true,
+			summary:   "Restore a deleted triage",
+			responses: map[int]any{http.StatusOK: triage.Result{}},
+			errors:    []int{http.StatusNotFound, http.StatusInternalServerError},
+		},
+	}, slices.Concat(shareRoutes, a.notifyRoutes(), a.actionRoutes(), a.stormRoutes())...)
+}
+
+var triageStatuses = []string{
+	string(triage.StatusPending),
+	string(triage.StatusInProgress),
+	string(triage.StatusComplete),
+	string(triage.StatusFailed),
+	string(triage.StatusError),
+	string(triage.StatusMaxTurns),
+	string(triage.StatusBudgetExceeded),
+	string(triage.StatusRefused),
+}
+
+// enums lists the allowed values of named string types.
+var enums = map[reflect.Type][]string{
+	reflect.TypeFor[triage.Status](): triageStatuses,
+}
+
+func (a *API) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	a.specOnce.Do(func() {
+		a.spec, a.specErr = json.Marshal(buildOpenAPI(a.routes()))
+	})
+	if a.specErr != nil {
+		a.logger.Error(r.Context(), a.specErr, "failed to build openapi document")
+		writeInternal(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(a.spec)
+}
+
+// document is the subset of OpenAPI 3.0 that the API needs.
+type document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       info                            `json:"info"`
+	Servers    []server                        `json:"servers"`
+	Security   []map[string][]string           `json:"security"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components components                      `json:"components"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type server struct {
+	URL string `json:"url"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Security    []map[string][]string `json:"security,omitzero"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type components struct {
+	Schemas         map[string]*schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// schema is a JSON Schema object as used by OpenAPI 3.0. An empty schema
+// allows any value.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func enumSchema(values []string) *schema {
+	return &schema{Type: "string", Enum: values}
+}
+
+const errorSchemaName = "ErrorEnvelope"
+
+// buildOpenAPI generates the document from the route table, deriving body
+// schemas from the Go types the handlers encode and decode.
+func buildOpenAPI(routes []route) *document {
+	g := &schemaGen{schemas: make(map[string]*schema), names: make(map[reflect.Type]string)}
+	errRef := g.schemaFor(reflect.TypeFor[ErrorEnvelope](), true)
+
+	doc := &document{
+		OpenAPI:  "3.0.3",
+		Info:     info{Title: "Vigil API", Version: "v1"},
+		Servers:  []server{{URL: basePath}},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+		Paths:    make(map[string]map[string]operation),
+		Components: components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]securityScheme{
+				"bearerAuth":      {Type: "http", Scheme: "bearer"},
+				"adminBearerAuth": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	for _, rt := range routes {
+		p := rt.pattern
+		if rt.admin {
+			p = strings.TrimPrefix(adminPath, basePath) + p
+		}
+		op := operation{
+			Summary:     rt.summary,
+			Description: rt.description,
+			OperationID: operationID(rt.method, p),
+			Responses:   make(map[string]response),
+		}
+		switch {
+		case rt.admin:
+			op.Security = []map[string][]string{{"adminBearerAuth": {}}}
+		case rt.public:
+			op.Security = []map[string][]string{}
+		}
+		for _, name := range pathParams(p) {
+			op.Parameters = append(op.Parameters, parameter{Name: name, In: "path", Required: true, Schema: &schema{Type: "string"}})
+		}
+		for _, q := range rt.query {
+			op.Parameters = append(op.Parameters, parameter{Name: q.name, In: "query", Description: q.description, Required: q.required, Schema: q.schema})
+		}
+		if rt.request != nil {
+			op.RequestBody = &requestBody{
+				Required: true,
+				Content:  jsonContent(g.schemaFor(reflect.TypeOf(rt.request), false)),
+			}
+		}
+		for code, body := range rt.responses {
+			resp := response{Description: http.StatusText(code)}
+			if body != nil {
+				resp.Content = jsonContent(g.schemaFor(reflect.TypeOf(body), true))
+			}
+			op.Responses[strconv.Itoa(code)] = resp
+		}
+		errs := slices.Concat(rt.errors, []int{http.StatusUnauthorized})
+		for _, code := range errs {
+			if _, ok := op.Responses[strconv.Itoa(code)]; ok {
+				continue
+			}
+			op.Responses[strconv.Itoa(code)] = response{Description: http.StatusText(code), Content: jsonContent(errRef)}
+		}
+
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]operation)
+		}
+		doc.Paths[p][strings.ToLower(rt.method)] = op
+	}
+	return doc
+}
+
+func jsonContent(s *schema) map[string]mediaType {
+	return map[string]mediaType{"application/json": {Schema: s}}
+}
+
+// pathParams returns the {name} segments of a chi pattern.
+func pathParams(pattern string) []string {
+	var names []string
+	for seg := range strings.SplitSeq(pattern, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, seg[1:len(seg)-1])
+		}
+	}
+	return names
+}
+
+// operationID derives a stable ID such as getTriageIdNotes from the method
+// and pattern.
+func operationID(method, pattern string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(pattern, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-'
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// schemaGen converts Go types to schemas the way encoding/json marshals them.
+// Named struct types become components referenced by $ref.
+type schemaGen struct {
+	schemas map[string]*schema
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType = reflect.TypeFor[time.Time]()
+	rawType  = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaFor returns the schema for t. Response schemas list fields without
+// omitempty as required; request schemas do not, since handlers fill in
+// defaults for missing fields. A component is built by whichever direction
+// reaches it first, and no type is currently used in both.
+func (g *schemaGen) schemaFor(t reflect.Type, response bool) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &schema{}
+	}
+	if values, ok := enums[t]; ok {
+		return enumSchema(values)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: g.schemaFor(t.Elem(), response)}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem(), response)}
+	case reflect.Struct:
+		return g.structSchema(t, response)
+	default:
+		return &schema{}
+	}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type, response bool) *schema {
+	if t.Name() == "" {
+		return g.objectSchema(t, response)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = g.componentName(t)
+		g.names[t] = name
+		// Register before recursing so self-referencing types terminate.
+		g.schemas[name] = &schema{}
+		*g.schemas[name] = *g.objectSchema(t, response)
+	}
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+// componentName is the exported form of the type name, qualified with its
+// package when another package already claimed the name.
+func (g *schemaGen) componentName(t reflect.Type) string {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+func (g *schemaGen) objectSchema(t reflect.Type, response bool) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	g.addFields(s, t, response)
+	return s
+}
+
+// addFields adds t's JSON fields to s, flattening embedded structs.
+func (g *schemaGen) addFields(s *schema, t reflect.Type, response bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft, response)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schemaFor(f.Type, response)
+		if response && !slices.Contains(strings.Split(opts, ","), "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func fetchOpenAPI(t *testing.T) map[string]any {
+	t.Helper()
+	r, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	t.Parallel()
+
+	doc := fetchOpenAPI(t)
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", doc["openapi"])
+	}
+	servers, _ := doc["servers"].([]any)
+	if len(servers) != 1 || servers[0].(map[string]any)["url"] != "/api/v1" {
+		t.Errorf("servers = %v, want [/api/v1]", servers)
+	}
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+	doc := buildOpenAPI(New(nil, &stubTriageService{}).routes())
+
+	var walked int
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		walked++
+		path := strings.TrimPrefix(route, basePath)
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("%s %s is not in the OpenAPI document", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	var documented int
+	for _, ops := range doc.Paths {
+		documented += len(ops)
+	}
+	if walked != documented {
+		t.Errorf("router has %d routes, document has %d operations", walked, documented)
+	}
+}
+
+func TestOpenAPI_RefsResolve(t *testing.T) {
+	t.Parallel()
+
+	doc := buildOpenAPI(New(nil, &stubTriageService{}).routes())
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var refs []string
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if k == "$ref" {
+					refs = append(refs, child.(string))
+					continue
+				}
+				collect(child)
+			}
+		case []any:
+			for _, child := range v {
+				collect(child)
+			}
+		}
+	}
+	var generic any
+	_ = json.Unmarshal(raw, &generic)
+	collect(generic)
+
+	if len(refs) == 0 {
+		t.Fatal("expected component references")
+	}
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("dangling reference %s", ref)
+		}
+	}
+}
+
+func TestOpenAPI_SchemasMatchTypes(t *testing.T) {
+	t.Parallel()
+
+	doc := buildOpenAPI(New(nil, &stubTriageService{}).routes())
+
+	tests := []struct {
+		component string
+		typ       reflect.Type
+	}{
+		{"Result", reflect.TypeFor[triage.Result]()},
+		{"Comparison", reflect.TypeFor[triage.Comparison]()},
+		{"Note", reflect.TypeFor[triage.Note]()},
+		{"AlertResult", reflect.TypeFor[AlertResult]()},
+		{"ErrorBody", reflect.TypeFor[ErrorBody]()},
+		{"IngestResponse", reflect.TypeFor[IngestResponse]()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.component, func(t *testing.T) {
+			t.Parallel()
+
+			s, ok := doc.Components.Schemas[tt.component]
+			if !ok {
+				t.Fatalf("component %s missing", tt.component)
+			}
+			var want []string
+			for i := range tt.typ.NumField() {
+				name, _, _ := strings.Cut(tt.typ.Field(i).Tag.Get("json"), ",")
+				if name != "-" {
+					want = append(want, name)
+				}
+			}
+			var got []string
+			for name := range s.Properties {
+				got = append(got, name)
+			}
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("properties = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_ResultSchema(t *testing.T) {
+	t.Parallel()
+
+	doc := buildOpenAPI(New(nil, &stubTriageService{}).routes())
+	s := doc.Components.Schemas["Result"]
+
+	if !slices.Contains(s.Required, "id") || !slices.Contains(s.Required, "status") {
+		t.Errorf("required = %v, want id and status", s.Required)
+	}
+	if slices.Contains(s.Required, "analysis") {
+		t.Error("omitempty field analysis should not be required")
+	}
+	if got := s.Properties["status"].Enum; !slices.Contains(got, string(triage.StatusComplete)) {
+		t.Errorf("status enum = %v, want triage statuses", got)
+	}
+	if got := s.Properties["created_at"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("created_at = %+v, want date-time string", got)
+	}
+	if got := s.Properties["investigation_notes"]; got.Type != "array" || got.Items.Ref != "#/components/schemas/Note" {
+		t.Errorf("investigation_notes = %+v, want array of Note", got)
+	}
+}
+
+func TestOpenAPI_RequestSchemasNotRequired(t *testing.T) {
+	t.Parallel()
+
+	doc := buildOpenAPI(New(nil, &stubTriageService{}).routes())
+	op := doc.Paths["/events"]["post"]
+	if op.RequestBody == nil {
+		t.Fatal("POST /events has no request body")
+	}
+	ref := op.RequestBody.Content["application/json"].Schema.Ref
+	s := doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+	if s == nil || s.Properties["title"] == nil {
+		t.Fatalf("event schema = %+v, want title property", s)
+	}
+	if len(s.Required) != 0 {
+		t.Errorf("request schema required = %v, want none", s.Required)
+	}
+}
+
+func TestOpenAPI_ErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	doc := buildOpenAPI(New(nil, &stubTriageService{}).routes())
+	op := doc.Paths["/triage/{id}/compare/{otherID}"]["get"]
+
+	for _, code := range []string{"401", "404", "422", "500"} {
+		resp, ok := op.Responses[code]
+		if !ok {
+			t.Errorf("missing %s response", code)
+			continue
+		}
+		if got := resp.Content["application/json"].Schema.Ref; got != "#/components/schemas/ErrorEnvelope" {
+			t.Errorf("%s schema = %q, want ErrorEnvelope", code, got)
+		}
+	}
+	var params []string
+	for _, p := range op.Parameters {
+		if p.In == "path" && p.Required {
+			params = append(params, p.Name)
+		}
+	}
+	if !slices.Equal(params, []string{"id", "otherID"}) {
+		t.Errorf("path params = %v, want [id otherID]", params)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method, pattern, want string
+	}{
+		{http.MethodGet, "/triage", "getTriage"},
+		{http.MethodGet, "/triage/{id}/notes", "getTriageIdNotes"},
+		{http.MethodPost, "/webhooks/grafana-oncall", "postWebhooksGrafanaOncall"},
+	}
+	for _, tt := range tests {
+		if got := operationID(tt.method, tt.pattern); got != tt.want {
+			t.Errorf("operationID(%s, %s) = %q, want %q", tt.method, tt.pattern, got, tt.want)
+		}
+	}
+}
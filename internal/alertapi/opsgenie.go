@@ -0,0 +1,121 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// opsgenieWebhook is the Opsgenie outgoing webhook integration payload. Only
+// the fields Vigil maps are declared.
+type opsgenieWebhook struct {
+	Action string `json:"action"`
+	Alert  struct {
+		AlertID     string            `json:"alertId"`
+		Alias       string            `json:"alias"`
+		Message     string            `json:"message"`
+		Description string            `json:"description"`
+		Priority    string            `json:"priority"`
+		Entity      string            `json:"entity"`
+		Source      string            `json:"source"`
+		Tags        []string          `json:"tags"`
+		Details     map[string]string `json:"details"`
+		CreatedAt   int64             `json:"createdAt"` // unix millis
+	} `json:"alert"`
+}
+
+// opsgenieFiringActions are the webhook actions that represent a (re)opened alert.
+var opsgenieFiringActions = map[string]bool{
+	"create":        true,
+	"escalate":      true,
+	"unacknowledge": true,
+}
+
+// opsgenieSeverity maps Opsgenie priorities onto the severity values used by Alertmanager rules.
+func opsgenieSeverity(priority string) string {
+	switch strings.ToUpper(priority) {
+	case "P1", "P2":
+		return "critical"
+	case "P3":
+		return "warning"
+	case "P4", "P5":
+		return "info"
+	default:
+		return ""
+	}
+}
+
+// toAlert maps an Opsgenie alert into an alert.Alert. The alias (Opsgenie's
+// dedup key) is used as fingerprint, falling back to the alert ID and then a
+// fingerprint synthesized from labels. Non-firing actions produce a
+// non-firing status so Submit skips them.
+func (p *opsgenieWebhook) toAlert() alert.Alert {
+	og := &p.Alert
+
+	labels := make(map[string]string, len(og.Details)+5)
+	maps.Copy(labels, og.Details)
+	labels["alertname"] = og.Message
+	labels["source"] = "opsgenie"
+	if sev := opsgenieSeverity(og.Priority); sev != "" {
+		labels["severity"] = sev
+	}
+	if og.Priority != "" {
+		labels["priority"] = og.Priority
+	}
+	if og.Entity != "" {
+		labels["entity"] = og.Entity
+	}
+	if len(og.Tags) > 0 {
+		labels["tags"] = strings.Join(og.Tags, ",")
+	}
+
+	annotations := map[string]string{"summary": og.Message}
+	if og.Description != "" {
+		annotations["description"] = og.Description
+	}
+
+	action := strings.ToLower(p.Action)
+	status := "firing"
+	if !opsgenieFiringActions[action] {
+		status = action
+	}
+
+	var startsAt time.Time
+	if og.CreatedAt > 0 {
+		startsAt = time.UnixMilli(og.CreatedAt).UTC()
+	}
+
+	fp := og.Alias
+	if fp == "" {
+		fp = og.AlertID
+	}
+	if fp == "" {
+		fp = alert.Fingerprint(labels)
+	}
+
+	return alert.Alert{
+		Status:      status,
+		Labels:      labels,
+		Annotations: annotations,
+		StartsAt:    startsAt,
+		Fingerprint: fp,
+	}
+}
+
+func (a *API) handleOpsgenieWebhook(w http.ResponseWriter, r *http.Request) {
+	var wh opsgenieWebhook
+	if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	if wh.Action == "" || wh.Alert.Message == "" {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "missing action or alert message")
+		return
+	}
+
+	a.submitAlerts(w, r, []alert.Alert{wh.toAlert()})
+}
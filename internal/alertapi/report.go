@@ -0,0 +1,175 @@
+package alertapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/chart"
+)
+
+// Report formats, the format query parameter of GET /triage/{id}/report.
+const (
+	ReportJSON     = "json"
+	ReportHTML     = "html"
+	ReportMarkdown = "markdown"
+)
+
+// Report chart limits: a triage can make dozens of range queries, but a
+// review needs the shape of a few.
+const (
+	maxReportCharts = 6
+	reportChartW    = 480
+	reportChartH    = 120
+)
+
+// reportChart is one range query of a report and its rendered sparkline,
+// SVG markup for HTML or a PNG for Markdown.
+type reportChart struct {
+	Query string
+	SVG   template.HTML
+	PNG   []byte
+}
+
+// reportCharts renders the last maxReportCharts range queries that
+// returned data, skipping any that cannot be drawn.
+func reportCharts(charts []*chart.Chart, format string) []reportChart {
+	if len(charts) > maxReportCharts {
+		charts = charts[len(charts)-maxReportCharts:]
+	}
+	var out []reportChart
+	for _, c := range charts {
+		rc := reportChart{Query: c.Query}
+		if format == ReportHTML {
+			b, err := c.SVG(reportChartW, reportChartH)
+			if err != nil {
+				continue
+			}
+			rc.SVG = template.HTML(b) //nolint:gosec // G203: chart.SVG escapes the query, the only text it carries
+		} else {
+			b, err := c.PNG(reportChartW, reportChartH)
+			if err != nil {
+				continue
+			}
+			rc.PNG = b
+		}
+		out = append(out, rc)
+	}
+	return out
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Alert}} - Vigil triage {{.ID}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; }
+pre { white-space: pre-wrap; background: #f6f6f6; padding: 1rem; }
+figure { margin: 1rem 0; }
+figcaption code { font-size: 0.85rem; }
+dt { font-weight: 600; }
+</style>
+</head>
+<body>
+<h1>{{.Alert}}</h1>
+<dl>
+<dt>Status</dt><dd>{{.Status}}</dd>
+{{- if .Severity}}
+<dt>Severity</dt><dd>{{.Severity}}</dd>
+{{- end}}
+<dt>Created</dt><dd>{{time .CreatedAt}}</dd>
+{{- if not .CompletedAt.IsZero}}
+<dt>Completed</dt><dd>{{time .CompletedAt}}</dd>
+{{- end}}
+{{- if .GeneratorURL}}
+<dt>Source</dt><dd><a href="{{.GeneratorURL}}">{{.GeneratorURL}}</a></dd>
+{{- end}}
+</dl>
+{{- if .Summary}}
+<p>{{.Summary}}</p>
+{{- end}}
+<h2>Analysis</h2>
+<pre>{{.Analysis}}</pre>
+{{- if .Charts}}
+<h2>Metrics</h2>
+{{- range .Charts}}
+<figure>{{.SVG}}<figcaption><code>{{.Query}}</code></figcaption></figure>
+{{- end}}
+{{- end}}
+{{- if .Notes}}
+<h2>Investigation notes</h2>
+<ul>
+{{- range .Notes}}
+<li>{{.Text}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .ToolsUsed}}
+<p>Tools used: {{range $i, $t := .ToolsUsed}}{{if $i}}, {{end}}{{$t}}{{end}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// writeReportHTML writes rep as a standalone HTML page with the charts
+// inlined as SVG.
+func writeReportHTML(w io.Writer, rep *Report, charts []reportChart) error {
+	return reportTemplate.Execute(w, struct {
+		*Report
+		Charts []reportChart
+	}{rep, charts})
+}
+
+// writeReportMarkdown writes rep as Markdown with the charts embedded as
+// PNG data URIs, so the document stands alone when pasted into a review.
+func writeReportMarkdown(w io.Writer, rep *Report, charts []reportChart) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", rep.Alert)
+	fmt.Fprintf(&b, "- **Status:** %s\n", rep.Status)
+	if rep.Severity != "" {
+		fmt.Fprintf(&b, "- **Severity:** %s\n", rep.Severity)
+	}
+	fmt.Fprintf(&b, "- **Created:** %s\n", rep.CreatedAt.UTC().Format(time.RFC3339))
+	if !rep.CompletedAt.IsZero() {
+		fmt.Fprintf(&b, "- **Completed:** %s\n", rep.CompletedAt.UTC().Format(time.RFC3339))
+	}
+	if rep.GeneratorURL != "" {
+		fmt.Fprintf(&b, "- **Source:** <%s>\n", rep.GeneratorURL)
+	}
+	if rep.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", rep.Summary)
+	}
+	fmt.Fprintf(&b, "\n## Analysis\n\n%s\n", rep.Analysis)
+	if len(charts) > 0 {
+		b.WriteString("\n## Metrics\n")
+		for _, c := range charts {
+			fmt.Fprintf(&b, "\n![%s](data:image/png;base64,%s)\n\n`%s`\n",
+				markdownAlt(c.Query), base64.StdEncoding.EncodeToString(c.PNG), markdownCode(c.Query))
+		}
+	}
+	if len(rep.Notes) > 0 {
+		b.WriteString("\n## Investigation notes\n\n")
+		for _, n := range rep.Notes {
+			fmt.Fprintf(&b, "- %s\n", n.Text)
+		}
+	}
+	if len(rep.ToolsUsed) > 0 {
+		fmt.Fprintf(&b, "\nTools used: %s\n", strings.Join(rep.ToolsUsed, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownAlt and markdownCode keep a query on one line as image alt text,
+// which ends at the first unbalanced bracket, and as a code span.
+var (
+	markdownAlt  = strings.NewReplacer("[", "(", "]", ")", "\n", " ").Replace
+	markdownCode = strings.NewReplacer("`", "'", "\n", " ").Replace
+)
@@ -0,0 +1,52 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// SearchResponse is the body of GET /triage/search.
+type SearchResponse struct {
+	Results []*triage.SearchHit `json:"results"`
+}
+
+// handleSearchTriage answers "have we seen this before?" with a full-text
+// search over past alert names, summaries and analyses.
+func (a *API) handleSearchTriage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sq := triage.SearchQuery{Text: strings.TrimSpace(q.Get("q"))}
+	if sq.Text == "" {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "missing q, want search terms")
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid limit, want a positive integer")
+			return
+		}
+		sq.Limit = n
+	}
+
+	hits, err := a.svc.Search(r.Context(), sq)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to search triage results")
+		writeInternal(w, r)
+		return
+	}
+	if hits == nil {
+		hits = []*triage.SearchHit{}
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("vigil.triage.search_hits", len(hits)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(SearchResponse{Results: hits})
+}
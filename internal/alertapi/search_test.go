@@ -0,0 +1,69 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleSearchTriage(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var got triage.SearchQuery
+	svc.searchFn = func(_ context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error) {
+		got = q
+		return []*triage.SearchHit{{ID: "01A", Alert: "DiskErrors", Rank: 0.4, Snippet: "**xfs** corruption"}}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/triage/search?q=xfs+corruption&limit=3", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp SearchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].ID != "01A" || resp.Results[0].Snippet != "**xfs** corruption" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+	if got.Text != "xfs corruption" || got.Limit != 3 {
+		t.Errorf("query = %+v", got)
+	}
+}
+
+func TestHandleSearchTriage_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  int
+	}{
+		{"missing q", "", nil, http.StatusBadRequest},
+		{"blank q", "?q=+", nil, http.StatusBadRequest},
+		{"bad limit", "?q=xfs&limit=0", nil, http.StatusBadRequest},
+		{"store error", "?q=xfs", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, svc := newTestRouter(t)
+			svc.searchFn = func(context.Context, triage.SearchQuery) ([]*triage.SearchHit, error) { return nil, tt.err }
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/triage/search"+tt.query, http.NoBody))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
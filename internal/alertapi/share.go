@@ -0,0 +1,177 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/chart"
+	"github.com/linnemanlabs/vigil/internal/share"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Share link lifetimes.
+const (
+	DefaultShareTTL = 24 * time.Hour
+	MaxShareTTL     = 30 * 24 * time.Hour
+)
+
+// ShareRequest is the body of POST /triage/{id}/share. TTL is a Go duration
+// such as "72h"; empty means DefaultShareTTL.
+type ShareRequest struct {
+	TTL string `json:"ttl,omitempty"`
+}
+
+// ShareResponse is the body of POST /triage/{id}/share. URL is relative to
+// the server and already carries the token.
+type ShareResponse struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Report is the body of GET /triage/{id}/report: what a stakeholder needs
+// from a triage, without the conversation, prompt or usage figures.
+type Report struct {
+	ID           string        `json:"id"`
+	Status       triage.Status `json:"status"`
+	Alert        string        `json:"alert_name"`
+	Severity     string        `json:"severity"`
+	Summary      string        `json:"summary"`
+	Analysis     string        `json:"analysis"`
+	ToolsUsed    []string      `json:"tools_used"`
+	CreatedAt    time.Time     `json:"created_at"`
+	CompletedAt  time.Time     `json:"completed_at,omitempty"`
+	Notes        []triage.Note `json:"investigation_notes,omitempty"`
+	GeneratorURL string        `json:"generator_url,omitempty"`
+}
+
+// WithSharing enables share links signed by s. Without it the share routes
+// are not registered.
+func WithSharing(s *share.Signer) Option {
+	return func(a *API) { a.share = s }
+}
+
+// handleCreateShare issues a link to one triage's report for callers without
+// an API token.
+func (a *API) handleCreateShare(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("vigil.triage.id", id))
+
+	var req ShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	ttl := DefaultShareTTL
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 || d > MaxShareTTL {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid ttl, want a Go duration up to 720h")
+			return
+		}
+		ttl = d
+	}
+
+	_, ok, err := a.svc.Get(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to get triage result", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	token := a.share.Sign(id, triage.TenantFrom(r.Context()), expires)
+	a.logger.Info(r.Context(), "share link created", "id", id, "expires_at", expires)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(ShareResponse{
+		URL:       basePath + "/triage/" + url.PathEscape(id) + "/report?token=" + url.QueryEscape(token),
+		Token:     token,
+		ExpiresAt: expires,
+	})
+}
+
+// handleGetReport serves a shared report. It is mounted outside the bearer
+// token group: the share token is the only credential, and it reads the
+// triage as the tenant that issued it.
+func (a *API) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("vigil.triage.id", id))
+
+	// The token is in the URL, so keep it out of caches and Referer headers.
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	tenant, err := a.share.Verify(r.URL.Query().Get("token"), id, time.Now())
+	if err != nil {
+		WriteError(w, r, http.StatusUnauthorized, CodeUnauthorized, err.Error())
+		return
+	}
+
+	result, ok, err := a.svc.Get(triage.WithTenant(r.Context(), tenant), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to get triage result", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "triage not found")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", ReportJSON, ReportHTML, ReportMarkdown:
+	default:
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid format, want json, html or markdown")
+		return
+	}
+
+	tools := result.ToolsUsed
+	if tools == nil {
+		tools = []string{}
+	}
+	rep := &Report{
+		ID:           result.ID,
+		Status:       result.Status,
+		Alert:        result.Alert,
+		Severity:     result.Severity,
+		Summary:      result.Summary,
+		Analysis:     result.Analysis,
+		ToolsUsed:    tools,
+		CreatedAt:    result.CreatedAt,
+		CompletedAt:  result.CompletedAt,
+		Notes:        result.Notes,
+		GeneratorURL: result.GeneratorURL,
+	}
+
+	switch format {
+	case ReportHTML:
+		// The page is ours alone: no scripts, no framing, no outside fetches.
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; img-src data:; frame-ancestors 'none'")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = writeReportHTML(w, rep, reportCharts(chart.All(result.Conversation), format))
+	case ReportMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		err = writeReportMarkdown(w, rep, reportCharts(chart.All(result.Conversation), format))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(rep)
+	}
+	if err != nil {
+		a.logger.Warn(r.Context(), "failed to write report", "id", id, "format", format, "error", err)
+	}
+}
@@ -0,0 +1,248 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/chart"
+	"github.com/linnemanlabs/vigil/internal/share"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// newShareRouter returns a router with sharing enabled, serving triage
+// "01SHARE" to tenant "acme" only.
+func newShareRouter(t *testing.T) (chi.Router, *share.Signer) {
+	t.Helper()
+	signer, err := share.New([]byte(strings.Repeat("k", share.MinKeyLen)))
+	if err != nil {
+		t.Fatalf("share.New: %v", err)
+	}
+	svc := &stubTriageService{
+		getFn: func(ctx context.Context, id string) (*triage.Result, bool, error) {
+			if id != "01SHARE" || triage.TenantFrom(ctx) != "acme" {
+				return nil, false, nil
+			}
+			return &triage.Result{
+				ID:           id,
+				TenantID:     "acme",
+				Status:       triage.StatusComplete,
+				Alert:        "HighLatency",
+				Analysis:     "The database is slow.",
+				SystemPrompt: "secret prompt",
+				Conversation: &triage.Conversation{Turns: []triage.Turn{{Role: "user"}}},
+			}, true, nil
+		},
+	}
+	api := New(nil, svc, WithSharing(signer))
+	r := chi.NewRouter()
+	api.RegisterPublicRoutes(r)
+	api.RegisterRoutes(r)
+	return r, signer
+}
+
+func TestCreateShare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		id         string
+		tenant     string
+		body       string
+		wantStatus int
+		wantTTL    time.Duration
+	}{
+		{name: "default ttl", id: "01SHARE", tenant: "acme", wantStatus: http.StatusCreated, wantTTL: DefaultShareTTL},
+		{name: "explicit ttl", id: "01SHARE", tenant: "acme", body: `{"ttl":"72h"}`, wantStatus: http.StatusCreated, wantTTL: 72 * time.Hour},
+		{name: "ttl too long", id: "01SHARE", tenant: "acme", body: `{"ttl":"721h"}`, wantStatus: http.StatusBadRequest},
+		{name: "bad ttl", id: "01SHARE", tenant: "acme", body: `{"ttl":"soon"}`, wantStatus: http.StatusBadRequest},
+		{name: "bad json", id: "01SHARE", tenant: "acme", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "other tenant", id: "01SHARE", tenant: "globex", wantStatus: http.StatusNotFound},
+		{name: "unknown triage", id: "01MISSING", tenant: "acme", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, _ := newShareRouter(t)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/triage/"+tt.id+"/share", strings.NewReader(tt.body))
+			req = req.WithContext(triage.WithTenant(req.Context(), tt.tenant))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var resp ShareResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !strings.HasPrefix(resp.URL, "/api/v1/triage/01SHARE/report?token=") || resp.Token == "" {
+				t.Errorf("response = %+v, want report URL with token", resp)
+			}
+			if d := time.Until(resp.ExpiresAt); d > tt.wantTTL || d < tt.wantTTL-time.Minute {
+				t.Errorf("expires in %v, want about %v", d, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	t.Parallel()
+
+	_, signer := newShareRouter(t)
+	valid := signer.Sign("01SHARE", "acme", time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name       string
+		id         string
+		token      string
+		wantStatus int
+	}{
+		{name: "valid", id: "01SHARE", token: valid, wantStatus: http.StatusOK},
+		{name: "no token", id: "01SHARE", wantStatus: http.StatusUnauthorized},
+		{name: "other triage", id: "01OTHER", token: valid, wantStatus: http.StatusUnauthorized},
+		{name: "expired", id: "01SHARE", token: signer.Sign("01SHARE", "acme", time.Now().Add(-time.Minute)), wantStatus: http.StatusUnauthorized},
+		// A validly signed token still only sees its own tenant's triages.
+		{name: "wrong tenant", id: "01SHARE", token: signer.Sign("01SHARE", "globex", time.Now().Add(time.Hour)), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, _ := newShareRouter(t)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/triage/"+tt.id+"/report?token="+tt.token, http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			body := rec.Body.String()
+			if strings.Contains(body, "secret prompt") || strings.Contains(body, "conversation") {
+				t.Errorf("report leaks prompt or conversation: %s", body)
+			}
+			var rep Report
+			if err := json.Unmarshal([]byte(body), &rep); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if rep.ID != "01SHARE" || rep.Analysis != "The database is slow." {
+				t.Errorf("report = %+v", rep)
+			}
+		})
+	}
+}
+
+func TestGetReport_Formats(t *testing.T) {
+	t.Parallel()
+
+	signer, err := share.New([]byte(strings.Repeat("k", share.MinKeyLen)))
+	if err != nil {
+		t.Fatalf("share.New: %v", err)
+	}
+	query, _ := json.Marshal(map[string]string{"query": `rate(errors_total[5m])`})
+	svc := &stubTriageService{
+		getFn: func(_ context.Context, id string) (*triage.Result, bool, error) {
+			return &triage.Result{
+				ID:       id,
+				Status:   triage.StatusComplete,
+				Alert:    "HighErrorRate",
+				Analysis: "Errors <spiked> after the deploy.",
+				Conversation: &triage.Conversation{Turns: []triage.Turn{
+					{Role: "assistant", Content: []triage.ContentBlock{{Type: "tool_use", ID: "t1", Name: chart.RangeToolName, Input: query}}},
+					{Role: "user", Content: []triage.ContentBlock{{Type: "tool_result", ToolUseID: "t1",
+						Content: `{"result_type":"matrix","results":[{"metric":{"job":"api"},"values":[[1700000000,"1"],[1700000060,"5"]]}]}`}}},
+				}},
+			}, true, nil
+		},
+	}
+	r := chi.NewRouter()
+	New(nil, svc, WithSharing(signer)).RegisterPublicRoutes(r)
+	token := signer.Sign("01SHARE", "", time.Now().Add(time.Hour))
+
+	tests := []struct {
+		format      string
+		wantStatus  int
+		contentType string
+		want        []string
+	}{
+		{format: "", wantStatus: http.StatusOK, contentType: "application/json", want: []string{`"analysis":"Errors \u003cspiked\u003e after the deploy."`}},
+		{format: "html", wantStatus: http.StatusOK, contentType: "text/html; charset=utf-8", want: []string{"Errors &lt;spiked&gt;", "<svg", "<polyline", "<code>rate(errors_total[5m])</code>"}},
+		{format: "markdown", wantStatus: http.StatusOK, contentType: "text/markdown; charset=utf-8", want: []string{"# HighErrorRate", "Errors <spiked>", "![rate(errors_total(5m))](data:image/png;base64,iVBOR", "`rate(errors_total[5m])`"}},
+		{format: "pdf", wantStatus: http.StatusBadRequest, contentType: "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/triage/01SHARE/report?format="+tt.format+"&token="+token, http.NoBody))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("report missing %q:\n%s", want, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestShareRoutes_DisabledWithoutSigner(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newTestAPI(t)
+	r := chi.NewRouter()
+	api.RegisterPublicRoutes(r)
+	api.RegisterRoutes(r)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/triage/01SHARE/share"},
+		{http.MethodGet, "/api/v1/triage/01SHARE/report?token=x"},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, http.NoBody))
+		if rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s = %d, want not routed", tc.method, tc.path, rec.Code)
+		}
+	}
+}
+
+func TestOpenAPI_ShareRoutes(t *testing.T) {
+	t.Parallel()
+
+	signer, _ := share.New([]byte(strings.Repeat("k", share.MinKeyLen)))
+	doc := buildOpenAPI(New(nil, &stubTriageService{}, WithSharing(signer)).routes())
+
+	op, ok := doc.Paths["/triage/{id}/report"]["get"]
+	if !ok {
+		t.Fatal("GET /triage/{id}/report is not documented")
+	}
+	if op.Security == nil || len(op.Security) != 0 {
+		t.Errorf("report security = %v, want empty (no bearer token)", op.Security)
+	}
+	if _, ok := doc.Paths["/triage/{id}/share"]["post"]; !ok {
+		t.Error("POST /triage/{id}/share is not documented")
+	}
+}
@@ -0,0 +1,110 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// SnoozeRequest is the body of POST /snooze. Duration is a Go duration such
+// as "90m" or "12h". The /snooze routes are a compatibility alias for
+// /suppressions: a snooze is a suppression with reason snoozed.
+type SnoozeRequest struct {
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Matchers    map[string]string `json:"matchers,omitempty"`
+	Duration    string            `json:"duration"`
+	Comment     string            `json:"comment,omitempty"`
+}
+
+// SnoozeListResponse is the body of GET /snooze.
+type SnoozeListResponse struct {
+	Snoozes []*triage.Suppression `json:"snoozes"`
+}
+
+// handleCreateSnooze stores a suppression with reason snoozed.
+func (a *API) handleCreateSnooze(w http.ResponseWriter, r *http.Request) {
+	var req SnoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid duration, want a Go duration such as 2h")
+		return
+	}
+
+	sn, err := a.svc.Suppress(r.Context(), triage.Suppression{
+		Fingerprint: req.Fingerprint,
+		Matchers:    req.Matchers,
+		Comment:     req.Comment,
+		Reason:      triage.SuppressionSnoozed,
+	}, d)
+	if errors.Is(err, triage.ErrInvalidSuppression) {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, err.Error())
+		return
+	}
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to create snooze")
+		writeInternal(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(sn)
+}
+
+// handleListSnoozes returns the active suppressions with reason snoozed.
+func (a *API) handleListSnoozes(w http.ResponseWriter, r *http.Request) {
+	sups, err := a.svc.Suppressions(r.Context())
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to list snoozes")
+		writeInternal(w, r)
+		return
+	}
+	snoozes := []*triage.Suppression{}
+	for _, sup := range sups {
+		if sup.Reason == triage.SuppressionSnoozed {
+			snoozes = append(snoozes, sup)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(SnoozeListResponse{Snoozes: snoozes})
+}
+
+// handleDeleteSnooze ends a snooze early. Suppressions with another reason
+// are not snoozes and are not found here; they are ended through
+// /suppressions.
+func (a *API) handleDeleteSnooze(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	sups, err := a.svc.Suppressions(r.Context())
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to look up snooze", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !slices.ContainsFunc(sups, func(sup *triage.Suppression) bool {
+		return sup.ID == id && sup.Reason == triage.SuppressionSnoozed
+	}) {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "snooze not found")
+		return
+	}
+	ok, err := a.svc.Unsuppress(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to delete snooze", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "snooze not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
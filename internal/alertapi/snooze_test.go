@@ -0,0 +1,99 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleCreateSnooze(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var gotDuration time.Duration
+	var got triage.Suppression
+	svc.suppressFn = func(_ context.Context, sn triage.Suppression, d time.Duration) (*triage.Suppression, error) {
+		if sn.Fingerprint == "" && len(sn.Matchers) == 0 {
+			return nil, triage.ErrInvalidSuppression
+		}
+		got, gotDuration = sn, d
+		sn.ID = "01SNOOZE"
+		return &sn, nil
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"fingerprint", `{"fingerprint":"fp-1","duration":"2h","comment":"flapping"}`, http.StatusCreated},
+		{"bad json", `{`, http.StatusBadRequest},
+		{"bad duration", `{"fingerprint":"fp-1","duration":"soon"}`, http.StatusBadRequest},
+		{"no matcher", `{"duration":"1h"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/snooze", strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d; body = %s", tt.name, rec.Code, tt.wantCode, rec.Body.String())
+			continue
+		}
+		if tt.wantCode != http.StatusCreated {
+			if env := decodeEnvelope(t, rec); env.Code != CodeInvalidPayload {
+				t.Errorf("%s: code = %q, want %q", tt.name, env.Code, CodeInvalidPayload)
+			}
+			continue
+		}
+		var sn triage.Suppression
+		if err := json.NewDecoder(rec.Body).Decode(&sn); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if sn.ID != "01SNOOZE" || got.Fingerprint != "fp-1" || got.Comment != "flapping" || got.Reason != triage.SuppressionSnoozed || gotDuration != 2*time.Hour {
+			t.Errorf("%s: snooze = %+v, service saw %+v for %s", tt.name, sn, got, gotDuration)
+		}
+	}
+}
+
+func TestHandleListAndDeleteSnoozes(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/snooze", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"snoozes":[]}` {
+		t.Errorf("empty list = %d %s", rec.Code, rec.Body.String())
+	}
+
+	svc.suppressed = []*triage.Suppression{
+		{ID: "s1", Matchers: map[string]string{"alertname": "Noisy"}, Reason: triage.SuppressionSnoozed},
+		{ID: "s2", Fingerprint: "fp-1", Reason: triage.SuppressionSuppressed},
+	}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/snooze", http.NoBody))
+	var list SnoozeListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Snoozes) != 1 || list.Snoozes[0].ID != "s1" {
+		t.Errorf("snoozes = %+v", list.Snoozes)
+	}
+
+	// s2 is a suppression, not a snooze, so the snooze alias does not end it.
+	for id, want := range map[string]int{"s1": http.StatusNoContent, "s2": http.StatusNotFound, "missing": http.StatusNotFound} {
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/snooze/"+id, http.NoBody))
+		if rec.Code != want {
+			t.Errorf("DELETE %s = %d, want %d", id, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Bounds of the window GET /stats aggregates.
+const (
+	defaultStatsWindow = 24 * time.Hour
+	maxStatsWindow     = 90 * 24 * time.Hour
+)
+
+// handleStats reports aggregates over the triages of a window, for
+// dashboards that need more than the Prometheus metrics.
+func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sq := triage.StatsQuery{Until: time.Now()}
+	if v := q.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid until, want RFC 3339 timestamp")
+			return
+		}
+		sq.Until = t
+	}
+	window := defaultStatsWindow
+	if v := q.Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxStatsWindow {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid window, want a Go duration up to 2160h")
+			return
+		}
+		window = d
+	}
+	sq.Since = sq.Until.Add(-window)
+	if v := q.Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > triage.MaxListLimit {
+			WriteError(w, r, http.StatusBadRequest, CodeInvalidParameter, "invalid top, want an integer from 1 to "+strconv.Itoa(triage.MaxListLimit))
+			return
+		}
+		sq.TopAlerts = n
+	}
+
+	st, err := a.svc.Stats(r.Context(), sq)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to compute stats")
+		writeInternal(w, r)
+		return
+	}
+	if st.Models == nil {
+		st.Models = []triage.ModelUsage{}
+	}
+	if st.TopAlerts == nil {
+		st.TopAlerts = []triage.AlertCount{}
+	}
+	if st.Tools == nil {
+		st.Tools = []triage.ToolUsage{}
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("vigil.stats.triages", st.Triages))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
@@ -0,0 +1,78 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleStats(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var got triage.StatsQuery
+	svc.statsFn = func(_ context.Context, q triage.StatsQuery) (*triage.Stats, error) {
+		got = q
+		return &triage.Stats{Triages: 2, ByStatus: map[triage.Status]int{triage.StatusComplete: 2}}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stats?window=168h&until=2026-03-08T00:00:00Z&top=3", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["triages"] != 2.0 || body["by_status"].(map[string]any)["complete"] != 2.0 {
+		t.Errorf("body = %v", body)
+	}
+	// Empty lists are encoded as [] for dashboards.
+	for _, k := range []string{"models", "top_alerts", "tools"} {
+		if l, ok := body[k].([]any); !ok || len(l) != 0 {
+			t.Errorf("%s = %v, want []", k, body[k])
+		}
+	}
+	until := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	if !got.Until.Equal(until) || !got.Since.Equal(until.Add(-168*time.Hour)) || got.TopAlerts != 3 {
+		t.Errorf("query = %+v", got)
+	}
+}
+
+func TestHandleStats_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  int
+	}{
+		{"bad window", "?window=1d", nil, http.StatusBadRequest},
+		{"window too long", "?window=2161h", nil, http.StatusBadRequest},
+		{"bad until", "?until=yesterday", nil, http.StatusBadRequest},
+		{"bad top", "?top=0", nil, http.StatusBadRequest},
+		{"store error", "", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, svc := newTestRouter(t)
+			svc.statsFn = func(context.Context, triage.StatsQuery) (*triage.Stats, error) { return &triage.Stats{}, tt.err }
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stats"+tt.query, http.NoBody))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// StormReporter reports storm mode. *triage.Service implements it.
+type StormReporter interface {
+	Storm(ctx context.Context) *triage.StormStatus
+}
+
+// WithStorm serves the storm state from sr. Without it the storm route is
+// not registered.
+func WithStorm(sr StormReporter) Option {
+	return func(a *API) {
+		a.storm = sr
+	}
+}
+
+// handleStorm reports whether the server is in storm mode and how the
+// caller's alerts were grouped.
+func (a *API) handleStorm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(a.storm.Storm(r.Context()))
+}
+
+func (a *API) stormRoutes() []route {
+	if a.storm == nil {
+		return nil
+	}
+	return []route{{
+		method: http.MethodGet, pattern: "/storm", handler: a.handleStorm,
+		summary:     "Show storm mode",
+		description: "Whether the server is in storm mode, the alerts submitted in the last minute against the threshold, and during a storm the groups of the caller's alerts: only the first alert of each group is triaged, without a notification, and a single storm summary is notified once the rate subsides.",
+		responses:   map[int]any{http.StatusOK: triage.StormStatus{}},
+	}}
+}
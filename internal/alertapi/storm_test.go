@@ -0,0 +1,51 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+type stubStorm struct{}
+
+func (stubStorm) Storm(_ context.Context) *triage.StormStatus {
+	return &triage.StormStatus{
+		Active:    true,
+		Rate:      240,
+		Threshold: 100,
+		Alerts:    3,
+		Groups:    []triage.StormGroup{{Labels: map[string]string{"alertname": "HighCPU"}, Alert: "HighCPU", RepresentativeID: "01REP", Alerts: 3}},
+	}
+}
+
+func TestHandleStorm(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	New(nil, &stubTriageService{}, WithStorm(stubStorm{})).RegisterRoutes(r)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/storm", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var st triage.StormStatus
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !st.Active || st.Rate != 240 || len(st.Groups) != 1 || st.Groups[0].RepresentativeID != "01REP" {
+		t.Errorf("storm = %+v", st)
+	}
+
+	plain, _ := newTestRouter(t)
+	rec = httptest.NewRecorder()
+	plain.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/storm", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without WithStorm", rec.Code)
+	}
+}
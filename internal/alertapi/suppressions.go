@@ -0,0 +1,91 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// SuppressionRequest is the body of POST /suppressions. TTL is a Go
+// duration such as "12h" or "168h".
+type SuppressionRequest struct {
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Matchers    map[string]string `json:"matchers,omitempty"`
+	TTL         string            `json:"ttl"`
+	Comment     string            `json:"comment,omitempty"`
+}
+
+// SuppressionListResponse is the body of GET /suppressions.
+type SuppressionListResponse struct {
+	Suppressions []*triage.Suppression `json:"suppressions"`
+}
+
+// handleCreateSuppression stops triage of matching alerts until the TTL
+// runs out.
+func (a *API) handleCreateSuppression(w http.ResponseWriter, r *http.Request) {
+	var req SuppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid payload")
+		return
+	}
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, "invalid ttl, want a Go duration such as 24h")
+		return
+	}
+
+	sup, err := a.svc.Suppress(r.Context(), triage.Suppression{
+		Fingerprint: req.Fingerprint,
+		Matchers:    req.Matchers,
+		Comment:     req.Comment,
+	}, ttl)
+	if errors.Is(err, triage.ErrInvalidSuppression) {
+		WriteError(w, r, http.StatusBadRequest, CodeInvalidPayload, err.Error())
+		return
+	}
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to create suppression")
+		writeInternal(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(sup)
+}
+
+// handleListSuppressions returns the active suppressions.
+func (a *API) handleListSuppressions(w http.ResponseWriter, r *http.Request) {
+	sups, err := a.svc.Suppressions(r.Context())
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to list suppressions")
+		writeInternal(w, r)
+		return
+	}
+	if sups == nil {
+		sups = []*triage.Suppression{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(SuppressionListResponse{Suppressions: sups})
+}
+
+// handleDeleteSuppression ends a suppression early.
+func (a *API) handleDeleteSuppression(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ok, err := a.svc.Unsuppress(r.Context(), id)
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to delete suppression", "id", id)
+		writeInternal(w, r)
+		return
+	}
+	if !ok {
+		WriteError(w, r, http.StatusNotFound, CodeNotFound, "suppression not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleCreateSuppression(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var gotTTL time.Duration
+	var got triage.Suppression
+	svc.suppressFn = func(_ context.Context, sup triage.Suppression, ttl time.Duration) (*triage.Suppression, error) {
+		if sup.Fingerprint == "" && len(sup.Matchers) == 0 {
+			return nil, triage.ErrInvalidSuppression
+		}
+		if sup.Fingerprint == "fp-db-down" {
+			return nil, errors.New("db down")
+		}
+		got, gotTTL = sup, ttl
+		sup.ID = "01SUPPRESS"
+		return &sup, nil
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"matchers", `{"matchers":{"alertname":"Noisy"},"ttl":"168h","comment":"known flapper"}`, http.StatusCreated},
+		{"bad json", `{`, http.StatusBadRequest},
+		{"bad ttl", `{"fingerprint":"fp-1","ttl":"a week"}`, http.StatusBadRequest},
+		{"no matcher", `{"ttl":"1h"}`, http.StatusBadRequest},
+		{"store error", `{"fingerprint":"fp-db-down","ttl":"1h"}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/suppressions", strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d; body = %s", tt.name, rec.Code, tt.wantCode, rec.Body.String())
+			continue
+		}
+		if tt.wantCode == http.StatusBadRequest {
+			if env := decodeEnvelope(t, rec); env.Code != CodeInvalidPayload {
+				t.Errorf("%s: code = %q, want %q", tt.name, env.Code, CodeInvalidPayload)
+			}
+			continue
+		}
+		if tt.wantCode != http.StatusCreated {
+			continue
+		}
+		var sup triage.Suppression
+		if err := json.NewDecoder(rec.Body).Decode(&sup); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if sup.ID != "01SUPPRESS" || got.Matchers["alertname"] != "Noisy" || got.Comment != "known flapper" || gotTTL != 168*time.Hour {
+			t.Errorf("%s: suppression = %+v, service saw %+v for %s", tt.name, sup, got, gotTTL)
+		}
+	}
+}
+
+func TestHandleListAndDeleteSuppressions(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/suppressions", http.NoBody))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"suppressions":[]}` {
+		t.Errorf("empty list = %d %s", rec.Code, rec.Body.String())
+	}
+
+	svc.suppressed = []*triage.Suppression{{ID: "s1", Fingerprint: "fp-1"}}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/suppressions", http.NoBody))
+	var list SuppressionListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Suppressions) != 1 || list.Suppressions[0].ID != "s1" {
+		t.Errorf("suppressions = %+v", list.Suppressions)
+	}
+
+	for id, want := range map[string]int{"s1": http.StatusNoContent, "missing": http.StatusNotFound} {
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/suppressions/"+id, http.NoBody))
+		if rec.Code != want {
+			t.Errorf("DELETE %s = %d, want %d", id, rec.Code, want)
+		}
+	}
+}
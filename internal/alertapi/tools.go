@@ -0,0 +1,35 @@
+package alertapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// ToolsResponse is the body of GET /tools.
+type ToolsResponse struct {
+	Tools []tools.ToolInfo `json:"tools"`
+}
+
+// handleListTools documents the tools the caller's triages can use and how
+// reliable they have been lately.
+func (a *API) handleListTools(w http.ResponseWriter, r *http.Request) {
+	list, err := a.svc.Tools(r.Context())
+	if err != nil {
+		a.logger.Error(r.Context(), err, "failed to list tools")
+		writeInternal(w, r)
+		return
+	}
+	if list == nil {
+		list = []tools.ToolInfo{}
+	}
+
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.Int("vigil.tools.listed", len(list)))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ToolsResponse{Tools: list})
+}
@@ -0,0 +1,56 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestHandleListTools(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	var gotTenant string
+	rate := 0.9
+	svc.toolsFn = func(ctx context.Context) ([]tools.ToolInfo, error) {
+		gotTenant = triage.TenantFrom(ctx)
+		return []tools.ToolInfo{{Name: "query_metrics", Description: "PromQL", InputSchema: json.RawMessage(`{"type":"object"}`), Available: true, RecentCalls: 10, SuccessRate: &rate}}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tools", http.NoBody)
+	r.ServeHTTP(rec, req.WithContext(triage.WithTenant(req.Context(), "team-a")))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp ToolsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Tools) != 1 || resp.Tools[0].Name != "query_metrics" || *resp.Tools[0].SuccessRate != 0.9 {
+		t.Errorf("tools = %+v", resp.Tools)
+	}
+	if gotTenant != "team-a" {
+		t.Errorf("tenant = %q, want team-a", gotTenant)
+	}
+}
+
+func TestHandleListTools_Error(t *testing.T) {
+	t.Parallel()
+
+	r, svc := newTestRouter(t)
+	svc.toolsFn = func(context.Context) ([]tools.ToolInfo, error) { return nil, errors.New("boom") }
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/tools", http.NoBody))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+}
@@ -0,0 +1,306 @@
+package alertapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func postWebhook(t *testing.T, path, body string) (*httptest.ResponseRecorder, *alert.Alert) {
+	t.Helper()
+
+	r, svc := newTestRouter(t)
+	var got *alert.Alert
+	svc.submitFn = func(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+		got = al
+		return &triage.SubmitResult{ID: "webhook-id-001"}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec, got
+}
+
+func TestHandleOnCallWebhook_MapsAlertGroup(t *testing.T) {
+	t.Parallel()
+
+	body := `{
+		"event": {"type": "escalation"},
+		"alert_group": {
+			"id": "IZ8VJ6Q3B2F4K",
+			"title": "HighErrorRate",
+			"state": "firing",
+			"created_at": "2026-01-02T03:04:05Z",
+			"labels": {"team": "payments"},
+			"permalinks": {"web": "https://oncall.example.com/alert-groups/IZ8VJ6Q3B2F4K"}
+		},
+		"alert_payload": {
+			"labels": {"alertname": "HighErrorRate", "severity": "critical", "service": "api"},
+			"annotations": {"summary": "5xx above 5%"},
+			"generatorURL": "https://grafana.example.com/alerting/1"
+		}
+	}`
+
+	rec, got := postWebhook(t, "/api/v1/webhooks/grafana-oncall", body)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got == nil {
+		t.Fatal("Submit was not called")
+	}
+	if got.Status != "firing" {
+		t.Errorf("status = %q, want firing", got.Status)
+	}
+	if got.Fingerprint != "IZ8VJ6Q3B2F4K" {
+		t.Errorf("fingerprint = %q, want alert group id", got.Fingerprint)
+	}
+	for k, want := range map[string]string{
+		"alertname": "HighErrorRate",
+		"severity":  "critical",
+		"service":   "api",
+		"team":      "payments",
+		"source":    "grafana-oncall",
+	} {
+		if got.Labels[k] != want {
+			t.Errorf("label %s = %q, want %q", k, got.Labels[k], want)
+		}
+	}
+	if got.Annotations["summary"] != "5xx above 5%" {
+		t.Errorf("summary = %q", got.Annotations["summary"])
+	}
+	if got.GeneratorURL != "https://grafana.example.com/alerting/1" {
+		t.Errorf("generatorURL = %q", got.GeneratorURL)
+	}
+	if !got.StartsAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("startsAt = %v", got.StartsAt)
+	}
+
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	accepted, ok := resp["accepted"].([]any)
+	if !ok || len(accepted) != 1 || accepted[0] != "webhook-id-001" {
+		t.Errorf("accepted = %v, want [webhook-id-001]", resp["accepted"])
+	}
+}
+
+func TestHandleOnCallWebhook_SynthesizesFingerprint(t *testing.T) {
+	t.Parallel()
+
+	body := `{"alert_group": {"title": "NodeDown", "permalinks": {"web": "https://oncall.example.com/x"}}}`
+
+	rec, got := postWebhook(t, "/api/v1/webhooks/grafana-oncall", body)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got == nil {
+		t.Fatal("Submit was not called")
+	}
+	if got.Fingerprint != alert.Fingerprint(got.Labels) {
+		t.Errorf("fingerprint = %q, want synthesized from labels", got.Fingerprint)
+	}
+	if got.Status != "firing" {
+		t.Errorf("status = %q, want firing for missing state", got.Status)
+	}
+	if got.Labels["alertname"] != "NodeDown" {
+		t.Errorf("alertname = %q, want title", got.Labels["alertname"])
+	}
+	if got.GeneratorURL != "https://oncall.example.com/x" {
+		t.Errorf("generatorURL = %q, want permalink fallback", got.GeneratorURL)
+	}
+}
+
+func TestHandleOnCallWebhook_ResolvedPassedThrough(t *testing.T) {
+	t.Parallel()
+
+	rec, got := postWebhook(t, "/api/v1/webhooks/grafana-oncall", `{"alert_group": {"id": "I1", "title": "T", "state": "resolved"}}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got == nil || got.Status != "resolved" {
+		t.Errorf("status = %v, want resolved", got)
+	}
+}
+
+func TestHandleOpsgenieWebhook_MapsAlert(t *testing.T) {
+	t.Parallel()
+
+	body := `{
+		"action": "Create",
+		"alert": {
+			"alertId": "70413a06-38d6-4c85-92b8-5ebc900d42e2",
+			"alias": "db-primary-lag",
+			"message": "ReplicationLag",
+			"description": "replica 40s behind",
+			"priority": "P2",
+			"entity": "db-primary",
+			"tags": ["db", "prod"],
+			"details": {"cluster": "main"},
+			"createdAt": 1767323045000
+		}
+	}`
+
+	rec, got := postWebhook(t, "/api/v1/webhooks/opsgenie", body)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if got == nil {
+		t.Fatal("Submit was not called")
+	}
+	if got.Status != "firing" {
+		t.Errorf("status = %q, want firing", got.Status)
+	}
+	if got.Fingerprint != "db-primary-lag" {
+		t.Errorf("fingerprint = %q, want alias", got.Fingerprint)
+	}
+	for k, want := range map[string]string{
+		"alertname": "ReplicationLag",
+		"severity":  "critical",
+		"priority":  "P2",
+		"entity":    "db-primary",
+		"tags":      "db,prod",
+		"cluster":   "main",
+		"source":    "opsgenie",
+	} {
+		if got.Labels[k] != want {
+			t.Errorf("label %s = %q, want %q", k, got.Labels[k], want)
+		}
+	}
+	if got.Annotations["description"] != "replica 40s behind" {
+		t.Errorf("description = %q", got.Annotations["description"])
+	}
+	if !got.StartsAt.Equal(time.UnixMilli(1767323045000)) {
+		t.Errorf("startsAt = %v", got.StartsAt)
+	}
+}
+
+func TestHandleOpsgenieWebhook_Fingerprint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		alert string
+		want  func(*alert.Alert) string
+	}{
+		{
+			name:  "alert id when no alias",
+			alert: `{"alertId": "abc-123", "message": "M"}`,
+			want:  func(*alert.Alert) string { return "abc-123" },
+		},
+		{
+			name:  "synthesized when no id",
+			alert: `{"message": "M", "priority": "P4"}`,
+			want:  func(al *alert.Alert) string { return alert.Fingerprint(al.Labels) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec, got := postWebhook(t, "/api/v1/webhooks/opsgenie", `{"action": "Create", "alert": `+tt.alert+`}`)
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+			}
+			if got == nil {
+				t.Fatal("Submit was not called")
+			}
+			if want := tt.want(got); got.Fingerprint != want {
+				t.Errorf("fingerprint = %q, want %q", got.Fingerprint, want)
+			}
+		})
+	}
+}
+
+func TestOpsgenieWebhook_ActionStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{"Create", "firing"},
+		{"Escalate", "firing"},
+		{"UnAcknowledge", "firing"},
+		{"Close", "close"},
+		{"Acknowledge", "acknowledge"},
+		{"AddNote", "addnote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			t.Parallel()
+
+			p := opsgenieWebhook{Action: tt.action}
+			p.Alert.Message = "M"
+			if got := p.toAlert().Status; got != tt.want {
+				t.Errorf("status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpsgenieSeverity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		priority string
+		want     string
+	}{
+		{"P1", "critical"},
+		{"p2", "critical"},
+		{"P3", "warning"},
+		{"P4", "info"},
+		{"P5", "info"},
+		{"", ""},
+		{"urgent", ""},
+	}
+
+	for _, tt := range tests {
+		if got := opsgenieSeverity(tt.priority); got != tt.want {
+			t.Errorf("opsgenieSeverity(%q) = %q, want %q", tt.priority, got, tt.want)
+		}
+	}
+}
+
+func TestHandleProviderWebhooks_BadRequests(t *testing.T) {
+	t.Parallel()
+
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"oncall invalid JSON", "/api/v1/webhooks/grafana-oncall", `{bad`},
+		{"oncall missing alert group", "/api/v1/webhooks/grafana-oncall", `{"event": {"type": "escalation"}}`},
+		{"opsgenie invalid JSON", "/api/v1/webhooks/opsgenie", `{bad`},
+		{"opsgenie missing action", "/api/v1/webhooks/opsgenie", `{"alert": {"message": "M"}}`},
+		{"opsgenie missing message", "/api/v1/webhooks/opsgenie", `{"action": "Create", "alert": {}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package anonymize scrubs infrastructure details from triage data so archives
+// can be shared outside the organization. IP addresses and hostnames are
+// replaced with keyed hashes, which keeps them distinguishable and consistent
+// within one export without revealing the original values, and free text
+// matching configured patterns is redacted outright.
+package anonymize
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/archive"
+)
+
+// Redacted replaces text matching a redact pattern.
+const Redacted = "[REDACTED]"
+
+// Config controls what the Anonymizer rewrites.
+type Config struct {
+	// Salt keys the hashes. Using the same salt across exports keeps mappings
+	// stable between them; empty means a random salt per Anonymizer.
+	Salt string `json:"salt"`
+
+	// HashIPs replaces IPv4 and IPv6 addresses with ip-<hash>.
+	HashIPs bool `json:"hash_ips"`
+
+	// HostnamePatterns are regular expressions for hostnames that are
+	// replaced with host-<hash> wherever they appear.
+	HostnamePatterns []string `json:"hostname_patterns"`
+
+	// HostnameLabels are label names whose values are replaced with
+	// host-<hash> when they appear in selectors or JSON, for example
+	// instance="web-1:9100" in a PromQL query.
+	HostnameLabels []string `json:"hostname_labels"`
+
+	// RedactPatterns are regular expressions whose matches are replaced with
+	// [REDACTED], for example ticket URLs or customer names in annotations.
+	RedactPatterns []string `json:"redact_patterns"`
+
+	// KeepSystemPrompt leaves system prompts untouched; by default they are
+	// anonymized like any other text.
+	KeepSystemPrompt bool `json:"keep_system_prompt"`
+}
+
+// DefaultConfig hashes IPs, common internal DNS suffixes, and the host-like
+// labels used by Prometheus and Loki.
+func DefaultConfig() Config {
+	return Config{
+		HashIPs: true,
+		HostnamePatterns: []string{
+			`(?i)\b(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:internal|local|lan|corp|intra|home|svc|cluster\.local)\b`,
+		},
+		HostnameLabels: []string{"instance", "host", "hostname", "node", "nodename", "pod", "container_name"},
+	}
+}
+
+// LoadConfig reads a JSON config file, filling unset fields from DefaultConfig.
+func LoadConfig(path string) (Config, error) {
+	c := DefaultConfig()
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read anonymizer config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse anonymizer config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+var (
+	ipv4Re = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)
+	// ipv6Re matches full and compressed IPv6 forms with at least two groups
+	// around a double colon or seven colons; it intentionally skips times like 12:30:00.
+	ipv6Re = regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b(?:[0-9a-f]{1,4}:){1,6}:(?:[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*)?\b`)
+)
+
+// Anonymizer rewrites strings, JSON documents, and archive records.
+type Anonymizer struct {
+	key        []byte
+	hashIPs    bool
+	hostnames  []*regexp.Regexp
+	labelRe    *regexp.Regexp
+	redact     []*regexp.Regexp
+	keepPrompt bool
+}
+
+// New compiles a Config into an Anonymizer.
+func New(c Config) (*Anonymizer, error) {
+	a := &Anonymizer{hashIPs: c.HashIPs, keepPrompt: c.KeepSystemPrompt}
+
+	if c.Salt != "" {
+		a.key = []byte(c.Salt)
+	} else {
+		a.key = make([]byte, 32)
+		if _, err := rand.Read(a.key); err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+	}
+
+	var errs []error
+	compile := func(kind string, patterns []string) []*regexp.Regexp {
+		out := make([]*regexp.Regexp, 0, len(patterns))
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s pattern %q: %w", kind, p, err))
+				continue
+			}
+			out = append(out, re)
+		}
+		return out
+	}
+	a.hostnames = compile("hostname", c.HostnamePatterns)
+	a.redact = compile("redact", c.RedactPatterns)
+
+	if len(c.HostnameLabels) > 0 {
+		names := make([]string, len(c.HostnameLabels))
+		for i, l := range c.HostnameLabels {
+			names[i] = regexp.QuoteMeta(l)
+		}
+		// label="value", label=~"value", "label":"value", label: value
+		a.labelRe = regexp.MustCompile(`\b(` + strings.Join(names, "|") + `)("?\s*(?:=~|!=|!~|=|:)\s*"?)([^"',}\s]+)`)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *Anonymizer) hash(prefix, v string) string {
+	m := hmac.New(sha256.New, a.key)
+	m.Write([]byte(v))
+	return prefix + hex.EncodeToString(m.Sum(nil))[:10]
+}
+
+// String anonymizes free text. Redaction runs first so redacted spans are not
+// partially hashed, then labels, hostnames, and IPs.
+func (a *Anonymizer) String(s string) string {
+	for _, re := range a.redact {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	if a.labelRe != nil {
+		s = a.labelRe.ReplaceAllStringFunc(s, func(m string) string {
+			sub := a.labelRe.FindStringSubmatch(m)
+			if strings.HasPrefix(sub[3], "host-") || sub[3] == Redacted {
+				return m
+			}
+			return sub[1] + sub[2] + a.hash("host-", sub[3])
+		})
+	}
+	for _, re := range a.hostnames {
+		s = re.ReplaceAllStringFunc(s, func(m string) string { return a.hash("host-", m) })
+	}
+	if a.hashIPs {
+		s = ipv4Re.ReplaceAllStringFunc(s, func(m string) string { return a.hash("ip-", m) })
+		s = ipv6Re.ReplaceAllStringFunc(s, func(m string) string { return a.hash("ip-", m) })
+	}
+	return s
+}
+
+// JSON anonymizes every string value in a JSON document, leaving keys and
+// structure intact. Empty input is returned unchanged.
+func (a *Anonymizer) JSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return raw, nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(a.walk(v))
+}
+
+func (a *Anonymizer) walk(v any) any {
+	switch x := v.(type) {
+	case string:
+		return a.String(x)
+	case []any:
+		for i := range x {
+			x[i] = a.walk(x[i])
+		}
+		return x
+	case map[string]any:
+		for k, val := range x {
+			x[k] = a.walk(val)
+		}
+		return x
+	default:
+		return v
+	}
+}
+
+// Record anonymizes an archive record in place. It satisfies archive.Transform.
+// Triage IDs are kept so records stay linked; fingerprints are rehashed since
+// they can be correlated with production alert data.
+func (a *Anonymizer) Record(rec *archive.Record) error {
+	switch {
+	case rec.Run != nil:
+		r := rec.Run
+		r.Fingerprint = a.hash("fp-", r.Fingerprint)
+		r.AlertName = a.String(r.AlertName)
+		r.Summary = a.String(r.Summary)
+		r.Analysis = a.String(r.Analysis)
+		r.GeneratorURL = a.String(r.GeneratorURL)
+		notes, err := a.JSON(r.Notes)
+		if err != nil {
+			return fmt.Errorf("run %s investigation_notes: %w", r.ID, err)
+		}
+		r.Notes = notes
+		if !a.keepPrompt {
+			r.SystemPrompt = a.String(r.SystemPrompt)
+		}
+	case rec.Message != nil:
+		content, err := a.JSON(rec.Message.Content)
+		if err != nil {
+			return fmt.Errorf("message %s seq %d: %w", rec.Message.TriageID, rec.Message.Seq, err)
+		}
+		rec.Message.Content = content
+	case rec.ToolCall != nil:
+		tc := rec.ToolCall
+		input, err := a.JSON(tc.Input)
+		if err != nil {
+			return fmt.Errorf("tool_call %s input: %w", tc.ToolName, err)
+		}
+		output, err := a.JSON(tc.Output)
+		if err != nil {
+			return fmt.Errorf("tool_call %s output: %w", tc.ToolName, err)
+		}
+		tc.Input, tc.Output = input, output
+		tc.InputBytes, tc.OutputBytes = len(input), len(output)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package anonymize
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/archive"
+)
+
+func newTestAnonymizer(t *testing.T, c Config) *Anonymizer {
+	t.Helper()
+	c.Salt = "test-salt"
+	a, err := New(c)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func TestString_DefaultConfig(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAnonymizer(t, DefaultConfig())
+
+	tests := []struct {
+		name    string
+		in      string
+		leaked  []string
+		keeps   []string
+		changed bool
+	}{
+		{
+			name:    "ipv4",
+			in:      "connection refused from 10.1.2.3 to 192.168.0.10:5432",
+			leaked:  []string{"10.1.2.3", "192.168.0.10"},
+			keeps:   []string{"connection refused from", ":5432"},
+			changed: true,
+		},
+		{
+			name:    "ipv6",
+			in:      "peer 2001:db8::1 and fe80:0:0:0:200:f8ff:fe21:67cf",
+			leaked:  []string{"2001:db8::1", "fe80:0:0:0:200:f8ff:fe21:67cf"},
+			changed: true,
+		},
+		{
+			name:    "internal hostname",
+			in:      "db-primary.prod.internal is lagging",
+			leaked:  []string{"db-primary", "prod.internal"},
+			keeps:   []string{"is lagging"},
+			changed: true,
+		},
+		{
+			name:    "promql label",
+			in:      `rate(node_cpu_seconds_total{instance="web-1:9100",mode="idle"}[5m])`,
+			leaked:  []string{"web-1"},
+			keeps:   []string{`instance="host-`, `mode="idle"`, "node_cpu_seconds_total"},
+			changed: true,
+		},
+		{
+			name:    "json label",
+			in:      `{"pod": "api-7d9f8-abcde"}`,
+			leaked:  []string{"api-7d9f8-abcde"},
+			keeps:   []string{`"pod": "host-`},
+			changed: true,
+		},
+		{
+			name:  "time is not an address",
+			in:    "started at 12:30:00 on 2026-01-02",
+			keeps: []string{"12:30:00", "2026-01-02"},
+		},
+		{
+			name:  "public hostname kept",
+			in:    "see https://prometheus.io/docs",
+			keeps: []string{"prometheus.io"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := a.String(tt.in)
+			for _, s := range tt.leaked {
+				if strings.Contains(got, s) {
+					t.Errorf("output %q still contains %q", got, s)
+				}
+			}
+			for _, s := range tt.keeps {
+				if !strings.Contains(got, s) {
+					t.Errorf("output %q lost %q", got, s)
+				}
+			}
+			if tt.changed == (got == tt.in) {
+				t.Errorf("changed = %v for %q -> %q", !tt.changed, tt.in, got)
+			}
+		})
+	}
+}
+
+func TestString_StableWithinSalt(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAnonymizer(t, DefaultConfig())
+	b := newTestAnonymizer(t, DefaultConfig())
+	if a.String("10.0.0.1") != b.String("10.0.0.1") {
+		t.Error("same salt should give same hash")
+	}
+	if a.String("10.0.0.1") == a.String("10.0.0.2") {
+		t.Error("different inputs should give different hashes")
+	}
+
+	c, err := New(DefaultConfig()) // random salt
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if a.String("10.0.0.1") == c.String("10.0.0.1") {
+		t.Error("random salt should not match fixed salt")
+	}
+}
+
+func TestString_RedactPatterns(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAnonymizer(t, Config{RedactPatterns: []string{`(?i)customer=\w+`, `https://jira\.example\.com/\S+`}})
+	got := a.String("customer=acme ticket https://jira.example.com/browse/OPS-1 ip 10.0.0.1")
+	if strings.Contains(got, "acme") || strings.Contains(got, "OPS-1") {
+		t.Errorf("redaction missed: %q", got)
+	}
+	if strings.Count(got, Redacted) != 2 {
+		t.Errorf("want 2 redactions: %q", got)
+	}
+	if !strings.Contains(got, "10.0.0.1") {
+		t.Errorf("HashIPs disabled but IP changed: %q", got)
+	}
+}
+
+func TestNew_InvalidPatterns(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(Config{HostnamePatterns: []string{"("}, RedactPatterns: []string{"[a-"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "hostname pattern") || !strings.Contains(err.Error(), "redact pattern") {
+		t.Errorf("err = %v, want both patterns reported", err)
+	}
+}
+
+func TestJSON_KeepsStructure(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAnonymizer(t, DefaultConfig())
+	in := json.RawMessage(`[{"type":"tool_use","input":{"query":"up{instance=\"10.0.0.5:9100\"}","limit":5}},{"type":"text","text":"ok"}]`)
+	out, err := a.JSON(in)
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if strings.Contains(string(out), "10.0.0.5") {
+		t.Errorf("IP leaked: %s", out)
+	}
+	var v []map[string]any
+	if err := json.Unmarshal(out, &v); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if v[0]["type"] != "tool_use" || v[0]["input"].(map[string]any)["limit"] != float64(5) || v[1]["text"] != "ok" {
+		t.Errorf("structure changed: %s", out)
+	}
+
+	for _, raw := range []json.RawMessage{nil, json.RawMessage("null")} {
+		got, err := a.JSON(raw)
+		if err != nil || string(got) != string(raw) {
+			t.Errorf("JSON(%q) = %q, %v", raw, got, err)
+		}
+	}
+	if _, err := a.JSON(json.RawMessage(`{bad`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	a := newTestAnonymizer(t, DefaultConfig())
+
+	run := &archive.Record{Type: archive.TypeRun, Run: &archive.Run{
+		ID: "01A", Fingerprint: "abc", Summary: "disk full on 10.0.0.9", SystemPrompt: "hosts: node-1.corp",
+		Notes: json.RawMessage(`[{"turn":0,"text":"checking 10.0.0.9 first"}]`),
+	}}
+	if err := a.Record(run); err != nil {
+		t.Fatalf("Record run: %v", err)
+	}
+	if run.Run.ID != "01A" {
+		t.Error("triage ID must be kept")
+	}
+	if run.Run.Fingerprint == "abc" || !strings.HasPrefix(run.Run.Fingerprint, "fp-") {
+		t.Errorf("fingerprint = %q, want rehashed", run.Run.Fingerprint)
+	}
+	if strings.Contains(run.Run.Summary, "10.0.0.9") || strings.Contains(run.Run.SystemPrompt, "node-1.corp") || strings.Contains(string(run.Run.Notes), "10.0.0.9") {
+		t.Errorf("run leaked: %+v", run.Run)
+	}
+
+	tc := &archive.Record{Type: archive.TypeToolCall, ToolCall: &archive.ToolCall{
+		ToolName: "query_logs", Input: json.RawMessage(`{"q":"{host=\"db-1\"}"}`), Output: json.RawMessage(`"from 10.0.0.9"`),
+	}}
+	if err := a.Record(tc); err != nil {
+		t.Fatalf("Record tool call: %v", err)
+	}
+	if strings.Contains(string(tc.ToolCall.Input), "db-1") || strings.Contains(string(tc.ToolCall.Output), "10.0.0.9") {
+		t.Errorf("tool call leaked: %s / %s", tc.ToolCall.Input, tc.ToolCall.Output)
+	}
+	if tc.ToolCall.InputBytes != len(tc.ToolCall.Input) || tc.ToolCall.OutputBytes != len(tc.ToolCall.Output) {
+		t.Error("byte counts not updated")
+	}
+}
+
+func TestRecord_KeepSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	c := DefaultConfig()
+	c.KeepSystemPrompt = true
+	a := newTestAnonymizer(t, c)
+	rec := &archive.Record{Type: archive.TypeRun, Run: &archive.Run{SystemPrompt: "hosts: node-1.corp"}}
+	if err := a.Record(rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Run.SystemPrompt != "hosts: node-1.corp" {
+		t.Errorf("system prompt changed: %q", rec.Run.SystemPrompt)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	if err := os.WriteFile(good, []byte(`{"salt":"s","redact_patterns":["secret"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(good)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if c.Salt != "s" || len(c.RedactPatterns) != 1 || !c.HashIPs || len(c.HostnameLabels) == 0 {
+		t.Errorf("config = %+v, want file values merged over defaults", c)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"salty":"s"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(bad); err == nil {
+		t.Error("expected error for unknown field")
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
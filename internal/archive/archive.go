@@ -0,0 +1,289 @@
+// Package archive defines a portable, gzip-compressed JSON Lines format for
+// triage data (triage_runs, messages, tool_calls) so it can be moved between
+// PostgreSQL instances or transformed offline.
+//
+// An archive starts with a single header record followed by all run records,
+// then all message records, then all tool call records. Readers can rely on
+// that order to satisfy foreign keys while importing.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Version is the archive format version written by this package.
+const Version = 1
+
+// Record types.
+const (
+	TypeHeader   = "header"
+	TypeRun      = "run"
+	TypeMessage  = "message"
+	TypeToolCall = "tool_call"
+)
+
+// Header describes an archive.
+type Header struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	From      time.Time `json:"from,omitzero"`
+	To        time.Time `json:"to,omitzero"`
+	// Anonymized is set when records passed through a Transform that scrubs
+	// infrastructure details before being written.
+	Anonymized bool `json:"anonymized,omitempty"`
+}
+
+// Run is a triage_runs row.
+type Run struct {
+	ID          string          `json:"id"`
+	Fingerprint string          `json:"fingerprint"`
+	Status      string          `json:"status"`
+	AlertName   string          `json:"alert_name"`
+	Severity    string          `json:"severity"`
+	Summary     string          `json:"summary"`
+	Analysis    string          `json:"analysis"`
+	ToolsUsed   json.RawMessage `json:"tools_used"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// ReceivedAt and StartedAt are nil in archives written before they were
+	// tracked.
+	ReceivedAt  *time.Time `json:"received_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	DurationS   float64    `json:"duration_s"`
+	LLMTimeS    float64    `json:"llm_time_s"`
+	ToolTimeS   float64    `json:"tool_time_s"`
+	TokensIn    int        `json:"tokens_in"`
+	TokensOut   int        `json:"tokens_out"`
+	// TokensThinking is zero in archives written before it was tracked.
+	TokensThinking int    `json:"tokens_thinking,omitempty"`
+	ToolCalls      int    `json:"tool_calls"`
+	SystemPrompt   string `json:"system_prompt"`
+	Model          string `json:"model"`
+	GeneratorURL   string `json:"generator_url,omitempty"`
+	// Notes is the investigation_notes JSON array; archives written before
+	// notes existed leave it empty.
+	Notes json.RawMessage `json:"investigation_notes,omitempty"`
+	// Children is the incident_children JSON array of a meta-triage.
+	Children json.RawMessage `json:"children,omitempty"`
+	// TenantID is empty for the default tenant.
+	TenantID string `json:"tenant_id,omitempty"`
+	// IssueURL is the issue opened for the run, if any.
+	IssueURL string `json:"issue_url,omitempty"`
+	// Redactions is the number of secrets scrubbed from tool output.
+	Redactions int `json:"redactions,omitempty"`
+	// Strategy is how the engine investigated, empty for the default.
+	Strategy string `json:"strategy,omitempty"`
+	// Provider is the provider that wrote the analysis, empty without a
+	// fallback provider.
+	Provider string `json:"provider,omitempty"`
+	// Labels and Annotations are the alert_labels and alert_annotations JSON
+	// objects; archives written before they were stored leave them empty.
+	Labels      json.RawMessage `json:"labels,omitempty"`
+	Annotations json.RawMessage `json:"annotations,omitempty"`
+	// Actions is the suggested_actions JSON array, empty when none were
+	// suggested.
+	Actions json.RawMessage `json:"suggested_actions,omitempty"`
+	// TraceID is the trace of the run's root span, empty without tracing.
+	TraceID string `json:"trace_id,omitempty"`
+	// DryRun marks a run that did not notify, see triage.Result.DryRun.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Metadata is the alert_metadata JSON object, empty when none was known.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	// DeletedAt is set for soft-deleted runs so they stay restorable, and
+	// purgeable, after import.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Message is a messages row. ID is the source database ID and is only used to
+// link tool calls; importers assign new IDs.
+type Message struct {
+	ID             int             `json:"id"`
+	TriageID       string          `json:"triage_id"`
+	Seq            int             `json:"seq"`
+	Role           string          `json:"role"`
+	Content        json.RawMessage `json:"content"`
+	TokensIn       *int            `json:"tokens_in,omitempty"`
+	TokensOut      *int            `json:"tokens_out,omitempty"`
+	TokensThinking *int            `json:"tokens_thinking,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DurationS      *float64        `json:"duration_s,omitempty"`
+	StopReason     *string         `json:"stop_reason,omitempty"`
+	Model          *string         `json:"model,omitempty"`
+}
+
+// ToolCall is a tool_calls row. MessageID refers to Message.ID in the same archive.
+type ToolCall struct {
+	TriageID    string          `json:"triage_id"`
+	MessageID   int             `json:"message_id"`
+	MessageSeq  int             `json:"message_seq"`
+	ToolName    string          `json:"tool_name"`
+	Input       json.RawMessage `json:"input"`
+	Output      json.RawMessage `json:"output,omitempty"`
+	InputBytes  int             `json:"input_bytes"`
+	OutputBytes int             `json:"output_bytes"`
+	IsError     bool            `json:"is_error"`
+	DurationS   float64         `json:"duration_s"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Record is one line of an archive. Exactly one payload field is set, matching Type.
+type Record struct {
+	Type     string    `json:"type"`
+	Header   *Header   `json:"header,omitempty"`
+	Run      *Run      `json:"run,omitempty"`
+	Message  *Message  `json:"message,omitempty"`
+	ToolCall *ToolCall `json:"tool_call,omitempty"`
+}
+
+// Counts summarizes the records written to or read from an archive.
+type Counts struct {
+	Runs      int `json:"runs"`
+	Messages  int `json:"messages"`
+	ToolCalls int `json:"tool_calls"`
+}
+
+// Transform rewrites a record in place before it is written.
+type Transform func(*Record) error
+
+// Writer writes records to an archive. Close must be called to flush it.
+type Writer struct {
+	gz         *gzip.Writer
+	enc        *json.Encoder
+	transforms []Transform
+	counts     Counts
+}
+
+// NewWriter starts an archive on w and writes its header. Transforms run on
+// every record after the header, in order.
+func NewWriter(w io.Writer, h Header, transforms ...Transform) (*Writer, error) {
+	gz := gzip.NewWriter(w)
+	aw := &Writer{gz: gz, enc: json.NewEncoder(gz), transforms: transforms}
+	h.Version = Version
+	if h.CreatedAt.IsZero() {
+		h.CreatedAt = time.Now().UTC()
+	}
+	if err := aw.enc.Encode(Record{Type: TypeHeader, Header: &h}); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+	return aw, nil
+}
+
+// WriteRun appends a run record.
+func (w *Writer) WriteRun(r *Run) error {
+	w.counts.Runs++
+	return w.encode(&Record{Type: TypeRun, Run: r})
+}
+
+// WriteMessage appends a message record.
+func (w *Writer) WriteMessage(m *Message) error {
+	w.counts.Messages++
+	return w.encode(&Record{Type: TypeMessage, Message: m})
+}
+
+// WriteToolCall appends a tool call record.
+func (w *Writer) WriteToolCall(tc *ToolCall) error {
+	w.counts.ToolCalls++
+	return w.encode(&Record{Type: TypeToolCall, ToolCall: tc})
+}
+
+func (w *Writer) encode(rec *Record) error {
+	for _, t := range w.transforms {
+		if err := t(rec); err != nil {
+			return fmt.Errorf("transform %s record: %w", rec.Type, err)
+		}
+	}
+	return w.enc.Encode(rec)
+}
+
+// Write appends any non-header record.
+func (w *Writer) Write(rec *Record) error {
+	switch {
+	case rec.Run != nil:
+		return w.WriteRun(rec.Run)
+	case rec.Message != nil:
+		return w.WriteMessage(rec.Message)
+	case rec.ToolCall != nil:
+		return w.WriteToolCall(rec.ToolCall)
+	default:
+		return fmt.Errorf("record type %q has no payload", rec.Type)
+	}
+}
+
+// Counts returns how many records have been written so far.
+func (w *Writer) Counts() Counts { return w.counts }
+
+// Close flushes the archive. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	return w.gz.Close()
+}
+
+// Reader reads records from an archive.
+type Reader struct {
+	gz     *gzip.Reader
+	dec    *json.Decoder
+	header Header
+}
+
+// NewReader opens an archive and reads its header.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	ar := &Reader{gz: gz, dec: json.NewDecoder(gz)}
+
+	var rec Record
+	if err := ar.dec.Decode(&rec); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if rec.Type != TypeHeader || rec.Header == nil {
+		return nil, errors.New("archive does not start with a header record")
+	}
+	if rec.Header.Version != Version {
+		return nil, fmt.Errorf("unsupported archive version %d (want %d)", rec.Header.Version, Version)
+	}
+	ar.header = *rec.Header
+	return ar, nil
+}
+
+// Header returns the archive header.
+func (r *Reader) Header() Header { return r.header }
+
+// Next returns the next record, or io.EOF at the end of the archive.
+func (r *Reader) Next() (*Record, error) {
+	var rec Record
+	if err := r.dec.Decode(&rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("read record: %w", err)
+	}
+	switch rec.Type {
+	case TypeRun:
+		if rec.Run == nil {
+			return nil, errors.New("run record without payload")
+		}
+	case TypeMessage:
+		if rec.Message == nil {
+			return nil, errors.New("message record without payload")
+		}
+	case TypeToolCall:
+		if rec.ToolCall == nil {
+			return nil, errors.New("tool_call record without payload")
+		}
+	default:
+		return nil, fmt.Errorf("unknown record type %q", rec.Type)
+	}
+	return &rec, nil
+}
+
+// Close releases the decompressor. It does not close the underlying reader.
+func (r *Reader) Close() error {
+	return r.gz.Close()
+}
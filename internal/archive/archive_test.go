@@ -0,0 +1,151 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterReader_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w, err := NewWriter(&buf, Header{From: from})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	in := 10
+	records := []*Record{
+		{Type: TypeRun, Run: &Run{ID: "r1", Fingerprint: "fp", Status: "complete", ToolsUsed: json.RawMessage(`["query_logs"]`)}},
+		{Type: TypeMessage, Message: &Message{ID: 7, TriageID: "r1", Role: "assistant", Content: json.RawMessage(`[{"type":"text","text":"hi"}]`), TokensIn: &in}},
+		{Type: TypeToolCall, ToolCall: &ToolCall{TriageID: "r1", MessageID: 7, ToolName: "query_logs", Input: json.RawMessage(`{}`)}},
+	}
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := w.Counts(); got != (Counts{Runs: 1, Messages: 1, ToolCalls: 1}) {
+		t.Errorf("Counts = %+v", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	h := r.Header()
+	if h.Version != Version || !h.From.Equal(from) || h.CreatedAt.IsZero() {
+		t.Errorf("header = %+v", h)
+	}
+
+	var got []*Record
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, rec)
+	}
+	if len(got) != 3 {
+		t.Fatalf("read %d records, want 3", len(got))
+	}
+	if got[0].Run.ID != "r1" || string(got[0].Run.ToolsUsed) != `["query_logs"]` {
+		t.Errorf("run = %+v", got[0].Run)
+	}
+	if got[1].Message.ID != 7 || *got[1].Message.TokensIn != 10 || got[1].Message.TokensOut != nil {
+		t.Errorf("message = %+v", got[1].Message)
+	}
+	if got[2].ToolCall.MessageID != 7 || got[2].ToolCall.ToolName != "query_logs" {
+		t.Errorf("tool call = %+v", got[2].ToolCall)
+	}
+}
+
+func gzipLines(t *testing.T, lines ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = io.WriteString(gz, strings.Join(lines, "\n"))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestNewReader_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   io.Reader
+	}{
+		{"not gzip", strings.NewReader("plain text")},
+		{"missing header", gzipLines(t, `{"type":"run","run":{"id":"r1"}}`)},
+		{"wrong version", gzipLines(t, `{"type":"header","header":{"version":99}}`)},
+		{"empty", gzipLines(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewReader(tt.in); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestReader_Next_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"unknown type", `{"type":"bogus"}`},
+		{"run without payload", `{"type":"run"}`},
+		{"message without payload", `{"type":"message"}`},
+		{"tool call without payload", `{"type":"tool_call"}`},
+		{"malformed", `{"type":`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewReader(gzipLines(t, `{"type":"header","header":{"version":1}}`, tt.line))
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+			if _, err := r.Next(); err == nil || errors.Is(err, io.EOF) {
+				t.Fatalf("Next err = %v, want decode/validation error", err)
+			}
+		})
+	}
+}
+
+func TestWriter_WriteRejectsEmptyRecord(t *testing.T) {
+	t.Parallel()
+
+	w, err := NewWriter(io.Discard, Header{})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := w.Write(&Record{Type: TypeRun}); err == nil {
+		t.Fatal("expected error for record without payload")
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"crypto/subtle"
 	"net/http"
 	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/alertapi"
+	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
 // BearerToken returns middleware that validates the Authorization header
@@ -16,14 +19,14 @@ func BearerToken(token string) func(http.Handler) http.Handler {
 			auth := r.Header.Get("Authorization")
 
 			if !strings.HasPrefix(auth, "Bearer ") {
-				http.Error(w, `{"error":"missing or malformed authorization header"}`, http.StatusUnauthorized)
+				alertapi.WriteError(w, r, http.StatusUnauthorized, alertapi.CodeUnauthorized, "missing or malformed authorization header")
 				return
 			}
 
 			got := []byte(auth[len("Bearer "):])
 
 			if subtle.ConstantTimeCompare(got, expected) != 1 {
-				http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+				alertapi.WriteError(w, r, http.StatusUnauthorized, alertapi.CodeUnauthorized, "invalid token")
 				return
 			}
 
@@ -31,3 +34,43 @@ func BearerToken(token string) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// TenantTokens returns middleware like BearerToken that accepts any of the
+// tokens, mapped to the tenant IDs they authenticate, and stores the tenant
+// in the request context for triage.TenantFrom. Every token is compared, so
+// the time taken does not reveal which one matched.
+func TenantTokens(tokens map[string]string) func(http.Handler) http.Handler {
+	type entry struct {
+		token  []byte
+		tenant string
+	}
+	entries := make([]entry, 0, len(tokens))
+	for token, tenant := range tokens {
+		entries = append(entries, entry{token: []byte(token), tenant: tenant})
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := r.Header.Get("Authorization")
+
+			if !strings.HasPrefix(auth, "Bearer ") {
+				alertapi.WriteError(w, r, http.StatusUnauthorized, alertapi.CodeUnauthorized, "missing or malformed authorization header")
+				return
+			}
+
+			got := []byte(auth[len("Bearer "):])
+
+			tenant, ok := "", false
+			for _, e := range entries {
+				if subtle.ConstantTimeCompare(got, e.token) == 1 {
+					tenant, ok = e.tenant, true
+				}
+			}
+			if !ok {
+				alertapi.WriteError(w, r, http.StatusUnauthorized, alertapi.CodeUnauthorized, "invalid token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(triage.WithTenant(r.Context(), tenant)))
+		})
+	}
+}
@@ -3,7 +3,10 @@ package authmw
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
 var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -38,6 +41,9 @@ func TestBearerToken_MissingHeader(t *testing.T) {
 	if rec.Code != http.StatusUnauthorized {
 		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
 	}
+	if !strings.Contains(rec.Body.String(), `"code":"unauthorized"`) {
+		t.Errorf("body = %s, want error envelope", rec.Body.String())
+	}
 }
 
 func TestBearerToken_WrongPrefix(t *testing.T) {
@@ -127,3 +133,49 @@ func TestBearerToken_PassesRequestThrough(t *testing.T) {
 		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
 	}
 }
+
+func TestTenantTokens(t *testing.T) {
+	t.Parallel()
+
+	h := TenantTokens(map[string]string{
+		"global-token": "",
+		"acme-token":   "acme",
+		"globex-token": "globex",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Tenant", triage.TenantFrom(r.Context()))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{"default tenant", "Bearer global-token", http.StatusOK, ""},
+		{"acme", "Bearer acme-token", http.StatusOK, "acme"},
+		{"globex", "Bearer globex-token", http.StatusOK, "globex"},
+		{"unknown token", "Bearer other-token", http.StatusUnauthorized, ""},
+		{"missing header", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("X-Tenant"); got != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", got, tt.wantTenant)
+			}
+		})
+	}
+}
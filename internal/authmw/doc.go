@@ -1,2 +1,3 @@
-// Package authmw provides HTTP middleware for bearer token authentication.
+// Package authmw provides HTTP middleware for bearer token authentication,
+// optionally mapping tokens to tenants.
 package authmw
@@ -0,0 +1,114 @@
+// Package busingest consumes Alertmanager webhook payloads from a message bus,
+// NATS JetStream or Kafka, as an alternative to POST /api/v1/alerts for
+// pipelines that already fan alerts out to a bus.
+//
+// Delivery is at least once. A message is acknowledged, or its offset
+// committed, only after every alert in it has been submitted, which persists
+// the pending triage. A message whose alerts fail to submit is delivered
+// again; the alerts that did go through are then folded into their active
+// triages by deduplication. Payloads that are not valid JSON are dropped,
+// since no number of redeliveries would fix them.
+package busingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Message results passed to Hooks.OnMessage.
+const (
+	ResultSubmitted = "submitted"
+	ResultInvalid   = "invalid"
+	ResultRetry     = "retry"
+)
+
+// Submitter accepts alerts for triage. *triage.Service satisfies it.
+type Submitter interface {
+	Submit(ctx context.Context, al *alert.Alert) (*triage.SubmitResult, error)
+}
+
+// Hooks observe consumption, for metrics. Nil fields are skipped.
+type Hooks struct {
+	// OnMessage is called with the bus name and a Result for every message
+	// handled, including each failed attempt.
+	OnMessage func(bus, result string)
+	// OnLag is called with the messages still to be consumed after the
+	// current one, per partition for Kafka and per stream for NATS.
+	OnLag func(bus, partition string, lag int64)
+}
+
+// Consumer submits the alerts of bus messages.
+type Consumer struct {
+	svc    Submitter
+	logger log.Logger
+	hooks  Hooks
+}
+
+// New returns a Consumer that submits to svc. Alerts are submitted for the
+// default tenant.
+func New(svc Submitter, logger log.Logger, hooks Hooks) *Consumer {
+	if logger == nil {
+		logger = log.Nop()
+	}
+	return &Consumer{svc: svc, logger: logger, hooks: hooks}
+}
+
+// errInvalid marks payloads that can never be handled.
+var errInvalid = errors.New("invalid payload")
+
+// handle decodes an Alertmanager webhook and submits each of its alerts. All
+// alerts are tried even if one fails, so a retry has less to redo.
+func (c *Consumer) handle(ctx context.Context, data []byte) error {
+	received := time.Now()
+	var wh alert.Webhook
+	if err := json.Unmarshal(data, &wh); err != nil {
+		return fmt.Errorf("%w: %w", errInvalid, err)
+	}
+	if n := wh.Normalize(); n > 0 {
+		c.logger.Warn(ctx, "clamped oversized alert labels", "alerts", n, "max_labels", alert.MaxLabels, "max_value_bytes", alert.MaxLabelValueBytes)
+	}
+	if err := wh.Validate(received); err != nil {
+		return fmt.Errorf("%w: %w", errInvalid, err)
+	}
+	var errs []error
+	for i := range wh.Alerts {
+		al := &wh.Alerts[i]
+		al.Receiver = wh.Receiver
+		al.ReceivedAt = received
+		if _, err := c.svc.Submit(ctx, al); err != nil {
+			errs = append(errs, fmt.Errorf("submit %s: %w", al.Fingerprint, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// result reports the outcome of handling one message and returns the Result
+// it was counted as.
+func (c *Consumer) result(ctx context.Context, bus string, err error) string {
+	res := ResultSubmitted
+	switch {
+	case errors.Is(err, errInvalid):
+		res = ResultInvalid
+		c.logger.Warn(ctx, "dropping undecodable bus message", "bus", bus, "err", err)
+	case err != nil:
+		res = ResultRetry
+		c.logger.Warn(ctx, "bus message not fully submitted, will retry", "bus", bus, "err", err)
+	}
+	if c.hooks.OnMessage != nil {
+		c.hooks.OnMessage(bus, res)
+	}
+	return res
+}
+
+func (c *Consumer) lag(bus, partition string, n int64) {
+	if c.hooks.OnLag != nil {
+		c.hooks.OnLag(bus, partition, max(n, 0))
+	}
+}
@@ -0,0 +1,213 @@
+package busingest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const twoAlerts = `{"receiver":"team-db","alerts":[
+	{"status":"firing","fingerprint":"fp-1","labels":{"alertname":"A"}},
+	{"status":"firing","fingerprint":"fp-2","labels":{"alertname":"B"}}
+]}`
+
+// fakeSubmitter fails its first failures calls, then succeeds.
+type fakeSubmitter struct {
+	mu        sync.Mutex
+	failures  int
+	submitted []string
+}
+
+func (f *fakeSubmitter) Submit(_ context.Context, al *alert.Alert) (*triage.SubmitResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("db down")
+	}
+	f.submitted = append(f.submitted, al.Receiver+"/"+al.Fingerprint)
+	return &triage.SubmitResult{ID: al.Fingerprint}, nil
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	results []string
+	lag     map[string]int64
+}
+
+func (r *recorder) hooks() Hooks {
+	return Hooks{
+		OnMessage: func(bus, result string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.results = append(r.results, bus+":"+result)
+		},
+		OnLag: func(bus, partition string, n int64) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.lag == nil {
+				r.lag = map[string]int64{}
+			}
+			r.lag[bus+":"+partition] = n
+		},
+	}
+}
+
+// fakeReader serves msgs in order, then blocks until ctx is cancelled.
+type fakeReader struct {
+	msgs      []kafka.Message
+	committed []int64
+	done      chan struct{}
+}
+
+func (f *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(f.msgs) == 0 {
+		close(f.done)
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	m := f.msgs[0]
+	f.msgs = f.msgs[1:]
+	return m, nil
+}
+
+func (f *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		f.committed = append(f.committed, m.Offset)
+	}
+	return nil
+}
+
+func (f *fakeReader) Close() error { return nil }
+
+func TestKafka_CommitAfterSubmit(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeSubmitter{failures: 1}
+	rec := &recorder{}
+	r := &fakeReader{
+		msgs: []kafka.Message{
+			{Topic: "alerts", Partition: 3, Offset: 7, HighWaterMark: 10, Value: []byte(twoAlerts)},
+			{Topic: "alerts", Partition: 3, Offset: 8, HighWaterMark: 10, Value: []byte(`not json`)},
+		},
+		done: make(chan struct{}),
+	}
+	k := &Kafka{c: New(svc, log.Nop(), rec.hooks()), r: r, retry: time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		k.Run(ctx)
+		close(finished)
+	}()
+	select {
+	case <-r.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("messages were not consumed")
+	}
+	cancel()
+	<-finished
+
+	// fp-1 failed on the first attempt, so the message was retried whole and
+	// fp-2 submitted twice; deduplication takes care of that in the service.
+	if want := []string{"team-db/fp-2", "team-db/fp-1", "team-db/fp-2"}; !slices.Equal(svc.submitted, want) {
+		t.Errorf("submitted = %v, want %v", svc.submitted, want)
+	}
+	if want := []int64{7, 8}; !slices.Equal(r.committed, want) {
+		t.Errorf("committed offsets = %v, want %v (invalid messages are committed too)", r.committed, want)
+	}
+	if want := []string{"kafka:retry", "kafka:submitted", "kafka:invalid"}; !slices.Equal(rec.results, want) {
+		t.Errorf("results = %v, want %v", rec.results, want)
+	}
+	if got := rec.lag["kafka:alerts/3"]; got != 1 {
+		t.Errorf("lag = %d, want 1", got)
+	}
+}
+
+func TestKafka_CancelLeavesOffsetUncommitted(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeSubmitter{failures: 1 << 30}
+	r := &fakeReader{msgs: []kafka.Message{{Offset: 1, Value: []byte(twoAlerts)}}, done: make(chan struct{})}
+	k := &Kafka{c: New(svc, nil, Hooks{}), r: r, retry: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	k.Run(ctx)
+
+	if len(r.committed) != 0 {
+		t.Errorf("committed %v for a message that never went through", r.committed)
+	}
+}
+
+// fakeMsg records how a JetStream message was acknowledged.
+type fakeMsg struct {
+	jetstream.Msg
+	data    string
+	pending uint64
+	acked   string
+}
+
+func (m *fakeMsg) Data() []byte { return []byte(m.data) }
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumPending: m.pending}, nil
+}
+func (m *fakeMsg) Ack() error                         { m.acked = "ack"; return nil }
+func (m *fakeMsg) Term() error                        { m.acked = "term"; return nil }
+func (m *fakeMsg) NakWithDelay(_ time.Duration) error { m.acked = "nak"; return nil }
+
+func TestNATS_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		data     string
+		failures int
+		want     string
+	}{
+		{name: "submitted", data: twoAlerts, want: "ack"},
+		{name: "submit failed", data: twoAlerts, failures: 1, want: "nak"},
+		{name: "undecodable", data: `{"alerts":`, want: "term"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &recorder{}
+			n := &NATS{c: New(&fakeSubmitter{failures: tt.failures}, nil, rec.hooks()), stream: "ALERTS"}
+			msg := &fakeMsg{data: tt.data, pending: 42}
+			n.process(context.Background(), msg)
+
+			if msg.acked != tt.want {
+				t.Errorf("message was %q, want %q", msg.acked, tt.want)
+			}
+			if rec.lag["nats:ALERTS"] != 42 {
+				t.Errorf("lag = %v, want 42", rec.lag)
+			}
+		})
+	}
+}
+
+func TestHandle_RejectsInvalidWebhook(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeSubmitter{}
+	c := New(svc, log.Nop(), Hooks{})
+	err := c.handle(context.Background(), []byte(`{"version":"4","alerts":[{"status":"firing","labels":{"severity":"page"}}]}`))
+	if !errors.Is(err, errInvalid) {
+		t.Errorf("handle = %v, want errInvalid", err)
+	}
+	if len(svc.submitted) != 0 {
+		t.Errorf("submitted = %v, want none", svc.submitted)
+	}
+}
@@ -0,0 +1,108 @@
+package busingest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Backoff between attempts at a Kafka message that failed to submit. Offsets
+// are committed in order, so a failing message holds up its partition until
+// it goes through.
+const (
+	kafkaRetryBase = time.Second
+	kafkaRetryMax  = time.Minute
+)
+
+// KafkaConfig selects a topic to consume.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	// Group is the consumer group, so replicas share partitions and offsets
+	// survive restarts.
+	Group string
+}
+
+// kafkaReader is the part of *kafka.Reader Kafka uses.
+type kafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Kafka consumes from a topic as a member of a consumer group.
+type Kafka struct {
+	c *Consumer
+	r kafkaReader
+	// retry is the first backoff after a failed attempt.
+	retry time.Duration
+}
+
+// Kafka returns a consumer for cfg. Brokers are not contacted until Run.
+func (c *Consumer) Kafka(cfg KafkaConfig) *Kafka {
+	return &Kafka{c: c, r: kafka.NewReader(kafka.ReaderConfig{
+		Brokers: cfg.Brokers,
+		Topic:   cfg.Topic,
+		GroupID: cfg.Group,
+	}), retry: kafkaRetryBase}
+}
+
+// Run consumes messages until ctx is cancelled, then leaves the group.
+func (k *Kafka) Run(ctx context.Context) {
+	defer func() {
+		if err := k.r.Close(); err != nil {
+			k.c.logger.Warn(ctx, "kafka ingest: close", "err", err)
+		}
+	}()
+	for {
+		msg, err := k.r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			k.c.logger.Warn(ctx, "kafka ingest: fetch", "err", err)
+			if !sleep(ctx, k.retry) {
+				return
+			}
+			continue
+		}
+		k.c.lag("kafka", msg.Topic+"/"+strconv.Itoa(msg.Partition), msg.HighWaterMark-msg.Offset-1)
+		if !k.process(ctx, msg) {
+			return
+		}
+		if err := k.r.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			// The message comes again after a rebalance or restart.
+			k.c.logger.Warn(ctx, "kafka ingest: commit", "err", err, "partition", msg.Partition, "offset", msg.Offset)
+		}
+	}
+}
+
+// process handles one message, retrying with backoff until it is submitted
+// or found invalid. It reports false if ctx was cancelled first, leaving the
+// offset uncommitted.
+func (k *Kafka) process(ctx context.Context, msg kafka.Message) bool {
+	backoff := k.retry
+	for {
+		if k.c.result(ctx, "kafka", k.c.handle(ctx, msg.Value)) != ResultRetry {
+			return true
+		}
+		if !sleep(ctx, backoff) {
+			return false
+		}
+		backoff = min(backoff*2, kafkaRetryMax)
+	}
+}
+
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
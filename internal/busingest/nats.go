@@ -0,0 +1,102 @@
+package busingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsRetryDelay is how long a message that failed to submit waits before
+// JetStream delivers it again.
+const natsRetryDelay = 10 * time.Second
+
+// NATSConfig selects a JetStream stream to consume.
+type NATSConfig struct {
+	URL    string
+	Stream string
+	// Subject filters the stream, empty for all of it.
+	Subject string
+	// Durable names the consumer, so replicas share its messages and its
+	// position survives restarts.
+	Durable string
+}
+
+// NATS consumes from a JetStream durable consumer.
+type NATS struct {
+	c      *Consumer
+	nc     *nats.Conn
+	cons   jetstream.Consumer
+	stream string
+}
+
+// NATS connects to the server and creates or updates the durable consumer.
+// The stream must already exist.
+func (c *Consumer) NATS(ctx context.Context, cfg NATSConfig) (*NATS, error) {
+	nc, err := nats.Connect(cfg.URL, nats.Name("vigil"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("jetstream: %w", err)
+	}
+	cons, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       cfg.Durable,
+		FilterSubject: cfg.Subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("nats consumer %s on stream %s: %w", cfg.Durable, cfg.Stream, err)
+	}
+	return &NATS{c: c, nc: nc, cons: cons, stream: cfg.Stream}, nil
+}
+
+// Run consumes messages until ctx is cancelled, then closes the connection.
+func (n *NATS) Run(ctx context.Context) {
+	defer n.nc.Close()
+	it, err := n.cons.Messages()
+	if err != nil {
+		n.c.logger.Error(ctx, err, "nats ingest stopped")
+		return
+	}
+	stop := context.AfterFunc(ctx, it.Stop)
+	defer stop()
+	for {
+		msg, err := it.Next()
+		switch {
+		case errors.Is(err, jetstream.ErrMsgIteratorClosed):
+			return
+		case err != nil:
+			n.c.logger.Warn(ctx, "nats ingest: next message", "err", err)
+			continue
+		}
+		n.process(ctx, msg)
+	}
+}
+
+// process handles one message: acked once submitted, terminated if it can
+// never be decoded, and otherwise redelivered after natsRetryDelay.
+func (n *NATS) process(ctx context.Context, msg jetstream.Msg) {
+	if md, err := msg.Metadata(); err == nil {
+		n.c.lag("nats", n.stream, int64(md.NumPending)) //nolint:gosec // G115: pending counts fit in int64
+	}
+	var ackErr error
+	switch n.c.result(ctx, "nats", n.c.handle(ctx, msg.Data())) {
+	case ResultSubmitted:
+		ackErr = msg.Ack()
+	case ResultInvalid:
+		ackErr = msg.Term()
+	default:
+		ackErr = msg.NakWithDelay(natsRetryDelay)
+	}
+	if ackErr != nil {
+		// Unacknowledged messages are redelivered once AckWait passes.
+		n.c.logger.Warn(ctx, "nats ingest: acknowledge", "err", ackErr)
+	}
+}
@@ -4,23 +4,136 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config adds log-specific configuration fields to the
 // common cfg.Registerable and cfg.Validatable interfaces
 type Config struct {
-	DrainSeconds          int
-	ShutdownBudgetSeconds int
-	APIPort               int
-	PrometheusEndpoint    string
-	PrometheusTenantID    string
-	LokiEndpoint          string
-	LokiTenantID          string
-	ClaudeAPIKey          string `json:"-"`
-	ClaudeModel           string
-	DatabaseURL           string `json:"-"`
-	SlackWebhookURL       string `json:"-"`
-	APIToken              string `json:"-"`
+	DrainSeconds             int
+	ShutdownBudgetSeconds    int
+	APIPort                  int
+	PrometheusEndpoint       string
+	PrometheusTenantID       string
+	LokiEndpoint             string
+	LokiTenantID             string
+	ProbeAllowlist           string
+	NetCheckTargets          string
+	ClaudeAPIKey             string `json:"-"`
+	ClaudeModel              string
+	DatabaseURL              string `json:"-"`
+	DatabaseReadURL          string `json:"-"`
+	DatabaseReadMaxLag       int
+	StoreCacheSize           int
+	StoredTurnMaxKB          int
+	StoredConversationMB     int
+	SlackWebhookURL          string `json:"-"`
+	SlackBotToken            string `json:"-"`
+	MattermostWebhookURL     string `json:"-"`
+	TeamsWebhookURL          string `json:"-"`
+	DiscordWebhookURL        string `json:"-"`
+	WebhookOutURLs           string `json:"-"`
+	WebhookOutSecret         string `json:"-"`
+	WebhookOutAttempts       int
+	SlackSnapshotChannel     string
+	SlackThreadChannel       string
+	APIToken                 string `json:"-"`
+	AdminAPIToken            string `json:"-"`
+	ShareKey                 string `json:"-"`
+	RemediationApprovalToken string `json:"-"`
+	DeletedRetentionHours    int
+	DecisionRetentionDays    int
+	MaxConcurrentTriages     int
+	ToolConcurrency          int
+	MaxPerAlertname          int
+	MaxToolRounds            int
+	MaxInputTokens           int
+	MaxOutputTokens          int
+	ThinkingBudget           int
+	ContextWindowTokens      int
+	LLMCountTokens           bool
+	LLMTemperature           *float64 // nil = provider default
+	LLMFallbackModel         string
+	LLMFallbackOn            string
+	LLMFallbackAttempts      int
+	LLMFallbackTimeout       int
+	LLMFallbackCooldown      int
+	RedactThinking           bool
+	RedactToolOutput         bool
+	RedactConfig             string
+	PromptRedactFile         string
+	PromptExamplesFile       string
+	PromptCompactBytes       int
+	PromptCompactKeep        int
+	GenAIEvents              bool
+	GenAICapture             string
+	RecordDir                string
+	ToolBreakerThreshold     int
+	ToolBreakerCooldown      int
+	ToolCacheTTLs            string
+	ToolCacheSize            int
+	ToolMaxConcurrent        map[string]int
+	RoutingConfig            string
+	NotifyTemplates          string
+	RemediationConfig        string
+	FilterConfig             string
+	EnrichConfig             string
+	ReloadSeconds            int
+	MaintenanceConfig        string
+	ReadyCheckSeconds        int
+	ReadyCheckTimeouts       string
+	MCPConfig                string
+	TenantsConfig            string
+	LLMRequestsPerMinute     int
+	LLMInputTPM              int
+	LLMOutputTPM             int
+	LLMMaxWaitSeconds        int
+	CompressGzipLevel        int
+	CompressZstdLevel        int
+	CompressMinBytes         int
+	IngestIPRate             float64
+	IngestIPBurst            int
+	IngestTokenRate          float64
+	IngestTokenBurst         int
+	IngestMaxConcurrent      int
+	WebhookMaxAlerts         int
+	WebhookMaxTriages        int
+	WebhookOverflow          string
+	WebhookQueueSize         int
+	IngestNATSURL            string
+	IngestNATSStream         string
+	IngestNATSSubject        string
+	IngestNATSDurable        string
+	IngestKafkaBrokers       string
+	IngestKafkaTopic         string
+	IngestKafkaGroup         string
+	NoiseDowngrade           float64
+	NoiseWindowHours         int
+	NotifyMinConfidence      float64
+	IncidentThreshold        int
+	IncidentWindowMinutes    int
+	IncidentGroupBy          string
+	StormThreshold           int
+	StormGroupBy             string
+	MaxInFlightTriages       int
+	MaxConversationMB        int
+	BatchSeverities          string
+	BatchFlushSeconds        int
+	BatchPollSeconds         int
+	Digest                   string
+	DigestHour               int
+	ExternalURL              string
+	TraceURL                 string
+	IssueTracker             string
+	IssueProject             string
+	IssueToken               string `json:"-"`
+	IssueAPIURL              string
+	IssueLabels              string
+	IssueSeverities          string
+	IssueTemplate            string
 }
 
 // RegisterFlags binds Config fields to the given FlagSet with defaults inline
@@ -33,10 +146,136 @@ func (c *Config) RegisterFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.ClaudeAPIKey, "claude-api-key", "", "API key for accessing the Claude LLM provider")
 	fs.StringVar(&c.ClaudeModel, "claude-model", "claude-sonnet-4-20250514", "Claude model to use)")
 	fs.StringVar(&c.DatabaseURL, "database-url", "", "PostgreSQL connection URL (empty = in-memory store)")
+	fs.StringVar(&c.DatabaseReadURL, "database-read-url", "", "PostgreSQL read replica URL for triage reads (empty = read from database-url)")
+	fs.IntVar(&c.DatabaseReadMaxLag, "database-read-max-lag-seconds", 10, "seconds the read replica may lag before reads go to the primary (0..3600, 0 = any lag)")
+	fs.IntVar(&c.StoreCacheSize, "store-cache-size", 256, "finished triages kept in memory in front of the database for repeated reads (0..100000, 0 = no caching)")
+	fs.IntVar(&c.StoredTurnMaxKB, "stored-turn-max-kb", 1024, "KiB of content stored per conversation turn in Postgres, beyond which its longest texts are truncated (0..1048576, 0 = unlimited)")
+	fs.IntVar(&c.StoredConversationMB, "stored-conversation-max-mb", 32, "MiB of conversation stored per triage in Postgres, beyond which later turns are truncated (0..65536, 0 = unlimited)")
 	fs.StringVar(&c.LokiEndpoint, "loki-endpoint", "", "Loki endpoint for log collection by tool use")
 	fs.StringVar(&c.LokiTenantID, "loki-tenant-id", "", "Loki tenant ID for multi-tenant setups")
+	fs.StringVar(&c.ProbeAllowlist, "probe-allowlist", "", "comma-separated URL prefixes or hosts (*.example.com) the http_probe tool may request (empty = tool disabled)")
+	fs.StringVar(&c.NetCheckTargets, "netcheck-targets", "", "comma-separated hosts or host:port (*.example.com) the net_check tool may resolve and connect to (empty = tool disabled)")
 	fs.StringVar(&c.SlackWebhookURL, "slack-webhook-url", "", "Slack webhook URL for notifications")
+	fs.StringVar(&c.MattermostWebhookURL, "mattermost-webhook-url", "", "Mattermost incoming webhook URL for notifications, alongside any other notifier")
+	fs.StringVar(&c.TeamsWebhookURL, "teams-webhook-url", "", "Microsoft Teams Workflows or incoming webhook URL for Adaptive Card notifications, alongside any other notifier")
+	fs.StringVar(&c.DiscordWebhookURL, "discord-webhook-url", "", "Discord webhook URL for embed notifications, alongside any other notifier")
+	fs.StringVar(&c.WebhookOutURLs, "webhook-out-urls", "", "comma-separated URLs every finished triage result is POSTed to as JSON (empty = disabled)")
+	fs.StringVar(&c.WebhookOutSecret, "webhook-out-secret", "", "secret signing outbound webhook requests with HMAC-SHA256 in X-Vigil-Signature (empty = unsigned)")
+	fs.IntVar(&c.WebhookOutAttempts, "webhook-out-attempts", 3, "times each outbound webhook is tried before the delivery fails (1..10)")
+	fs.StringVar(&c.SlackBotToken, "slack-bot-token", "", "Slack bot token with files:write or chat:write, used for metric snapshots and threaded narratives")
+	fs.StringVar(&c.SlackSnapshotChannel, "slack-snapshot-channel-id", "", "Slack channel ID that metric snapshots are uploaded to")
+	fs.StringVar(&c.SlackThreadChannel, "slack-thread-channel-id", "", "Slack channel ID that triage messages are posted to with the bot token, with the investigation narrative as a threaded reply")
 	fs.StringVar(&c.APIToken, "api-token", "", "Bearer token required for API authentication")
+	fs.StringVar(&c.ShareKey, "share-key", "", "secret of at least 32 bytes that signs triage report share links (empty = sharing disabled)")
+	fs.StringVar(&c.AdminAPIToken, "admin-api-token", "", "Bearer token for /api/v1/admin routes such as restoring deleted triages (empty = admin routes disabled)")
+	fs.IntVar(&c.DeletedRetentionHours, "deleted-retention-hours", 720, "hours a deleted triage stays restorable before it is purged (0..87600, 0 = never purge)")
+	fs.IntVar(&c.DecisionRetentionDays, "decision-retention-days", 180, "days submit decisions are kept for GET /api/v1/decisions (0..3650, 0 = keep forever)")
+	fs.IntVar(&c.MaxConcurrentTriages, "max-concurrent-triages", 0, "maximum triages running at once, excess stay pending (0 = derive from CPU/memory limits)")
+	fs.IntVar(&c.MaxPerAlertname, "max-concurrent-per-alertname", 0, "maximum triages of one alertname running at once, excess stay pending (0..1024, 0 = unlimited)")
+	fs.IntVar(&c.ToolConcurrency, "tool-concurrency", 0, "maximum tool calls executed in parallel within a single turn (0 = derive from CPU limits)")
+	fs.IntVar(&c.MaxToolRounds, "max-tool-rounds", 15, "tool calls a triage may make before it is stopped (0..100, 0 = 15)")
+	fs.IntVar(&c.MaxInputTokens, "max-input-tokens", 200000, "LLM input tokens a triage may use before it is stopped (0 or 1000..2000000, 0 = 200000)")
+	fs.IntVar(&c.MaxOutputTokens, "max-output-tokens", 50000, "LLM output tokens a triage may use before it is stopped (0 or 1000..500000, 0 = 50000)")
+	fs.Func("llm-temperature", "sampling temperature of triage LLM calls, not allowed with extended thinking (0..1, unset = provider default)", func(s string) error {
+		t, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		c.LLMTemperature = &t
+		return nil
+	})
+	fs.StringVar(&c.LLMFallbackModel, "llm-fallback-model", "", "Claude model triage calls fall back to when the primary model keeps failing (empty = no fallback)")
+	fs.StringVar(&c.LLMFallbackOn, "llm-fallback-on", "timeout,overloaded,server_error,network", "comma-separated failures that send calls to the fallback model: "+strings.Join(FallbackFailureClasses, ", "))
+	fs.IntVar(&c.LLMFallbackAttempts, "llm-fallback-attempts", 2, "calls to the primary model per request before it falls back (1..10)")
+	fs.IntVar(&c.LLMFallbackTimeout, "llm-fallback-timeout-seconds", 0, "seconds a primary model call may take before it counts as a timeout (0..600, 0 = no limit)")
+	fs.IntVar(&c.LLMFallbackCooldown, "llm-fallback-cooldown-seconds", 300, "seconds calls go straight to the fallback model after one fell back (1..3600)")
+	fs.IntVar(&c.ThinkingBudget, "thinking-budget-tokens", 0, "tokens the model may spend reasoning before each response, counted as output (0 or 1024..64000, 0 = extended thinking disabled)")
+	fs.IntVar(&c.ContextWindowTokens, "context-window-tokens", 0, "context window of the model in tokens; a conversation whose next request would come within a tenth of it is summarized first (0 or 10000..2000000, 0 = never summarize)")
+	fs.BoolVar(&c.LLMCountTokens, "llm-count-tokens", false, "count each request's input tokens with Anthropic's count_tokens endpoint before sending it, instead of estimating them from its size")
+	fs.BoolVar(&c.RedactThinking, "redact-thinking", false, "store thinking blocks as [redacted] instead of the model's reasoning text")
+	fs.BoolVar(&c.RedactToolOutput, "redact-tool-output", false, "scrub tokens, passwords, keys and email addresses from tool output with the built-in patterns and entropy check before the model sees it")
+	fs.StringVar(&c.RedactConfig, "redact-config", "", "JSON file of extra redaction patterns and entropy settings, implies -redact-tool-output (empty = built-in rules)")
+	fs.StringVar(&c.PromptRedactFile, "prompt-redact-file", "", "file of regular expressions, one per line, whose matches are replaced with [REDACTED] in every request sent to the model (empty = none)")
+	fs.StringVar(&c.PromptExamplesFile, "prompt-examples-file", "", "file of example analyses separated by lines of ---, added to the system prompt (empty = none)")
+	fs.IntVar(&c.PromptCompactBytes, "prompt-compact-bytes", 0, "bytes older tool results are cut to in requests sent to the model; the stored conversation keeps them whole (0 or 256..1048576, 0 = never cut)")
+	fs.IntVar(&c.PromptCompactKeep, "prompt-compact-keep", 4, "most recent messages whose tool results -prompt-compact-bytes leaves whole (0..100)")
+	fs.BoolVar(&c.GenAIEvents, "genai-events", false, "export prompts, completions and tool calls of every LLM call as OpenTelemetry gen_ai events to the OTLP endpoint, for LLM observability platforms")
+	fs.StringVar(&c.GenAICapture, "genai-capture", "truncated", "message content in gen_ai events: off, truncated to 1 KiB per message, or full")
+	fs.StringVar(&c.RecordDir, "record-dir", "", "directory recording every model response and tool call of each triage to <triage id>.jsonl, for replaying runs offline (empty = no recording)")
+	fs.IntVar(&c.ToolBreakerThreshold, "tool-breaker-threshold", 5, "consecutive data source failures that take a tool offline (0..100, 0 = never)")
+	fs.IntVar(&c.ToolBreakerCooldown, "tool-breaker-cooldown-seconds", 60, "seconds an offline tool is withheld before a probe call is let through (1..3600)")
+	fs.StringVar(&c.ToolCacheTTLs, "tool-cache-ttls", "", "comma-separated tool=duration pairs for how long identical tool calls reuse a result, * for unlisted tools, e.g. query_metrics=30s,*=1m (empty = no caching)")
+	fs.IntVar(&c.ToolCacheSize, "tool-cache-size", 1000, "tool results kept in the cache (1..100000)")
+	c.ToolMaxConcurrent = make(map[string]int)
+	for _, name := range ConcurrencyLimitedTools {
+		fs.Func("tool-max-concurrent-"+name, "maximum "+name+" calls running at once across all triages of a tenant, excess wait for a slot (0..1000, 0 = unlimited)", func(s string) error {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			c.ToolMaxConcurrent[name] = n
+			return nil
+		})
+	}
+	fs.IntVar(&c.LLMRequestsPerMinute, "llm-requests-per-minute", 0, "LLM calls per minute shared by all triages (0 = unlimited)")
+	fs.IntVar(&c.LLMInputTPM, "llm-input-tokens-per-minute", 0, "LLM input tokens per minute shared by all triages (0 = unlimited)")
+	fs.IntVar(&c.LLMOutputTPM, "llm-output-tokens-per-minute", 0, "LLM output tokens per minute shared by all triages (0 = unlimited)")
+	fs.IntVar(&c.LLMMaxWaitSeconds, "llm-rate-limit-max-wait-seconds", 120, "longest an LLM call may queue for rate limit capacity before the triage fails (0..3600, 0 = no limit)")
+	fs.IntVar(&c.CompressGzipLevel, "compress-gzip-level", 5, "gzip level for API and UI responses (0..9, 0 = gzip disabled)")
+	fs.IntVar(&c.CompressZstdLevel, "compress-zstd-level", 2, "zstd level for API and UI responses, preferred over gzip when the client accepts both (0..4, 0 = zstd disabled)")
+	fs.IntVar(&c.CompressMinBytes, "compress-min-bytes", 1024, "smallest response body in bytes that is compressed (0..1048576)")
+	fs.Float64Var(&c.IngestIPRate, "ingest-ip-rps", 0, "alert webhooks per second accepted from one client IP, excess get 429 (0 = unlimited)")
+	fs.IntVar(&c.IngestIPBurst, "ingest-ip-burst", 0, "alert webhooks one client IP may send at once before -ingest-ip-rps applies (0 = the rate rounded up)")
+	fs.Float64Var(&c.IngestTokenRate, "ingest-token-rps", 0, "alert webhooks per second accepted for one API token, excess get 429 (0 = unlimited)")
+	fs.IntVar(&c.IngestTokenBurst, "ingest-token-burst", 0, "alert webhooks one API token may send at once before -ingest-token-rps applies (0 = the rate rounded up)")
+	fs.IntVar(&c.IngestMaxConcurrent, "ingest-max-concurrent", 0, "alert webhooks processed at once across all clients, excess get 429 (0 = unlimited)")
+	fs.IntVar(&c.WebhookMaxAlerts, "webhook-max-alerts", 0, "alerts one webhook may carry, larger webhooks get 413 (0..100000, 0 = unlimited)")
+	fs.IntVar(&c.WebhookMaxTriages, "webhook-max-triages", 0, "triages one webhook may start, the remaining alerts overflow (0..10000, 0 = unlimited)")
+	fs.StringVar(&c.WebhookOverflow, "webhook-overflow", "reject", "what happens to alerts past -webhook-max-triages: reject, or queue to submit them in the background at that many per second")
+	fs.IntVar(&c.WebhookQueueSize, "webhook-overflow-queue-size", 1000, "alerts held for -webhook-overflow=queue, excess are rejected (1..100000)")
+	fs.StringVar(&c.IngestNATSURL, "ingest-nats-url", "", "NATS server URL to consume Alertmanager webhook payloads from a JetStream stream, in addition to HTTP (empty = disabled)")
+	fs.StringVar(&c.IngestNATSStream, "ingest-nats-stream", "", "JetStream stream holding alert payloads, required with -ingest-nats-url")
+	fs.StringVar(&c.IngestNATSSubject, "ingest-nats-subject", "", "subject filter within the stream (empty = the whole stream)")
+	fs.StringVar(&c.IngestNATSDurable, "ingest-nats-durable", "vigil", "durable JetStream consumer name, shared by all replicas")
+	fs.StringVar(&c.IngestKafkaBrokers, "ingest-kafka-brokers", "", "comma-separated Kafka brokers to consume Alertmanager webhook payloads from, in addition to HTTP (empty = disabled)")
+	fs.StringVar(&c.IngestKafkaTopic, "ingest-kafka-topic", "", "Kafka topic holding alert payloads, required with -ingest-kafka-brokers")
+	fs.StringVar(&c.IngestKafkaGroup, "ingest-kafka-group", "vigil", "Kafka consumer group, shared by all replicas")
+	fs.Float64Var(&c.NoiseDowngrade, "noise-downgrade-threshold", 0, "noise score at or above which alerts are triaged on a reduced budget (0..1, 0 = never)")
+	fs.IntVar(&c.NoiseWindowHours, "noise-window-hours", 168, "hours of triage history noise scores are computed from (1..720)")
+	fs.Float64Var(&c.NotifyMinConfidence, "notify-min-confidence", 0, "verdict confidence below which completed triages not judged urgent are stored without notifying; routing profiles can override it (0..1, 0 = notify every triage)")
+	fs.IntVar(&c.IncidentThreshold, "incident-threshold", 0, "related triages completing within the incident window that start an incident meta-triage (0 or 2..100, 0 = disabled)")
+	fs.IntVar(&c.IncidentWindowMinutes, "incident-window-minutes", 15, "minutes within which related triages count toward an incident (1..1440)")
+	fs.StringVar(&c.IncidentGroupBy, "incident-group-by", "cluster", "comma-separated labels whose values must match for alerts to be related (empty = all alerts are related)")
+	fs.IntVar(&c.StormThreshold, "storm-threshold", 0, "alerts submitted within a minute that start storm mode, triaging one alert per group (0 or 2..100000, 0 = disabled)")
+	fs.StringVar(&c.StormGroupBy, "storm-group-by", "alertname", "comma-separated labels whose values group alerts during a storm")
+	fs.IntVar(&c.MaxInFlightTriages, "max-inflight-triages", 0, "pending and running triages at which new alerts are shed (0..100000, 0 = unlimited)")
+	fs.IntVar(&c.MaxConversationMB, "max-conversation-mb", 0, "MiB of conversation held by in-flight triages at which new alerts are shed (0..65536, 0 = unlimited)")
+	fs.StringVar(&c.BatchSeverities, "batch-severities", "", "comma-separated alert severities triaged through the Message Batches API at lower cost and latency up to hours (empty = batch mode disabled)")
+	fs.IntVar(&c.BatchFlushSeconds, "batch-flush-seconds", 60, "seconds LLM requests wait to be grouped into one batch (1..3600)")
+	fs.IntVar(&c.BatchPollSeconds, "batch-poll-seconds", 30, "seconds between checks of a submitted batch for results (5..3600)")
+	fs.StringVar(&c.Digest, "digest", "", "period of the Slack digest of triage activity: daily or weekly (empty = no digest)")
+	fs.IntVar(&c.DigestHour, "digest-hour", 9, "UTC hour the digest is sent at, weekly digests on Mondays (0..23)")
+	fs.StringVar(&c.ExternalURL, "external-url", "", "URL Vigil is reachable at, for links to triages in notifications (empty = no links)")
+	fs.StringVar(&c.TraceURL, "trace-url", "", "tracing UI URL Slack messages link each triage's trace at, with {trace_id} replaced by the trace ID or else the ID appended (empty = no links)")
+	fs.StringVar(&c.IssueTracker, "issue-tracker", "", "issue tracker to open issues in for completed triages: github or gitlab (empty = no issues)")
+	fs.StringVar(&c.IssueProject, "issue-project", "", "repository (owner/repo) or GitLab project path issues are opened in")
+	fs.StringVar(&c.IssueToken, "issue-token", "", "token allowed to create issues in the issue project")
+	fs.StringVar(&c.IssueAPIURL, "issue-api-url", "", "REST API base URL of the issue tracker, for GitHub Enterprise or self-managed GitLab (empty = github.com or gitlab.com)")
+	fs.StringVar(&c.IssueLabels, "issue-labels", "vigil", "comma-separated labels set on opened issues")
+	fs.StringVar(&c.IssueSeverities, "issue-severities", "critical", "comma-separated alert severities whose completed triages open an issue (empty = all)")
+	fs.StringVar(&c.IssueTemplate, "issue-template", "", "Go text/template file rendering the issue body (empty = built-in template)")
+	fs.StringVar(&c.NotifyTemplates, "notify-templates", "", "JSON file of named Go text/template message templates per alert severity, for the default Slack notifier (template \"default\") and routing profiles (empty = built-in messages)")
+	fs.StringVar(&c.RemediationConfig, "remediation-config", "", "JSON file of remediation actions the model may suggest and operators may execute through the API (empty = disabled)")
+	fs.StringVar(&c.RemediationApprovalToken, "remediation-approval-token", "", "token required in X-Vigil-Approval-Token to execute a suggested remediation action")
+	fs.StringVar(&c.RoutingConfig, "routing-config", "", "JSON file mapping Alertmanager receivers to triage profiles (empty = no profiles)")
+	fs.StringVar(&c.FilterConfig, "filter-config", "", "JSON file of label and annotation rules deciding whether alerts are triaged, skipped or downgraded (empty = triage every alert)")
+	fs.StringVar(&c.EnrichConfig, "enrich-config", "", "JSON file or http(s) URL of service owners, tiers, runbooks and dependencies matched to alerts by label (empty = no enrichment)")
+	fs.IntVar(&c.ReloadSeconds, "reload-seconds", 0, "seconds between checks of the routing, filter and enrichment sources for changes (0..3600, 0 = reload on SIGHUP only)")
+	fs.IntVar(&c.ReadyCheckSeconds, "ready-check-seconds", 0, "seconds between readiness checks of Postgres, Prometheus, Loki and the Anthropic API (0..3600, 0 = readiness only reflects shutdown)")
+	fs.StringVar(&c.ReadyCheckTimeouts, "ready-check-timeouts", "", "comma-separated dependency=duration timeouts for readiness checks, of postgres, prometheus, loki or anthropic, e.g. anthropic=10s (unlisted = 2s for postgres, 5s for the others)")
+	fs.StringVar(&c.MaintenanceConfig, "maintenance-config", "", "JSON file of maintenance windows and the Alertmanager whose silences declare maintenance; matching alerts are skipped or triaged in lightweight mode (empty = none)")
+	fs.StringVar(&c.MCPConfig, "mcp-config", "", "JSON file of MCP servers whose tools are offered to the triage agent (empty = none)")
+	fs.StringVar(&c.TenantsConfig, "tenants-config", "", "JSON file of tenants with their own API tokens, datasources and triage settings (empty = single tenant)")
 }
 
 // Validate checks all configuration fields for correctness.
@@ -72,6 +311,38 @@ func (c *Config) Validate() error {
 		errs = append(errs, errors.New("API_TOKEN is required"))
 	}
 
+	// Admin token must not grant admin access to every API client
+	if c.AdminAPIToken != "" && c.AdminAPIToken == c.APIToken {
+		errs = append(errs, errors.New("ADMIN_API_TOKEN must differ from API_TOKEN"))
+	}
+
+	// Executing remediations takes a second secret, held apart from the
+	// tokens that read triages
+	if c.RemediationConfig != "" && c.RemediationApprovalToken == "" {
+		errs = append(errs, errors.New("REMEDIATION_CONFIG requires REMEDIATION_APPROVAL_TOKEN"))
+	}
+	if c.RemediationApprovalToken != "" && c.RemediationConfig == "" {
+		errs = append(errs, errors.New("REMEDIATION_APPROVAL_TOKEN requires REMEDIATION_CONFIG"))
+	}
+	if c.RemediationApprovalToken != "" && (c.RemediationApprovalToken == c.APIToken || c.RemediationApprovalToken == c.AdminAPIToken) {
+		errs = append(errs, errors.New("REMEDIATION_APPROVAL_TOKEN must differ from API_TOKEN and ADMIN_API_TOKEN"))
+	}
+
+	// Share links are only as strong as the key that signs them
+	if c.ShareKey != "" && len(c.ShareKey) < 32 {
+		errs = append(errs, fmt.Errorf("invalid SHARE_KEY length %d (must be at least 32 bytes)", len(c.ShareKey)))
+	}
+
+	// Deleted triage retention, 0 means keep forever
+	if c.DeletedRetentionHours < 0 || c.DeletedRetentionHours > 87600 {
+		errs = append(errs, fmt.Errorf("invalid DELETED_RETENTION_HOURS %d (must be 0..87600)", c.DeletedRetentionHours))
+	}
+
+	// Decision log retention, 0 means keep forever
+	if c.DecisionRetentionDays < 0 || c.DecisionRetentionDays > 3650 {
+		errs = append(errs, fmt.Errorf("invalid DECISION_RETENTION_DAYS %d (must be 0..3650)", c.DecisionRetentionDays))
+	}
+
 	// Claude API key is required for LLM access
 	if c.ClaudeAPIKey == "" {
 		errs = append(errs, errors.New("CLAUDE_API_KEY is required"))
@@ -82,8 +353,349 @@ func (c *Config) Validate() error {
 		errs = append(errs, errors.New("CLAUDE_MODEL is required"))
 	}
 
+	// Concurrency settings, 0 means auto-derive at startup
+	if c.MaxConcurrentTriages < 0 || c.MaxConcurrentTriages > 1024 {
+		errs = append(errs, fmt.Errorf("invalid MAX_CONCURRENT_TRIAGES %d (must be 0..1024)", c.MaxConcurrentTriages))
+	}
+	if c.MaxPerAlertname < 0 || c.MaxPerAlertname > 1024 {
+		errs = append(errs, fmt.Errorf("invalid MAX_CONCURRENT_PER_ALERTNAME %d (must be 0..1024)", c.MaxPerAlertname))
+	}
+	if c.ToolConcurrency < 0 || c.ToolConcurrency > 64 {
+		errs = append(errs, fmt.Errorf("invalid TOOL_CONCURRENCY %d (must be 0..64)", c.ToolConcurrency))
+	}
+
+	// Per-triage budget, capped like the vigil.io/max-* annotations; 0 keeps the built-in limit
+	if c.MaxToolRounds < 0 || c.MaxToolRounds > 100 {
+		errs = append(errs, fmt.Errorf("invalid MAX_TOOL_ROUNDS %d (must be 0..100)", c.MaxToolRounds))
+	}
+	if c.MaxInputTokens != 0 && (c.MaxInputTokens < 1000 || c.MaxInputTokens > 2000000) {
+		errs = append(errs, fmt.Errorf("invalid MAX_INPUT_TOKENS %d (must be 0 or 1000..2000000)", c.MaxInputTokens))
+	}
+	if c.MaxOutputTokens != 0 && (c.MaxOutputTokens < 1000 || c.MaxOutputTokens > 500000) {
+		errs = append(errs, fmt.Errorf("invalid MAX_OUTPUT_TOKENS %d (must be 0 or 1000..500000)", c.MaxOutputTokens))
+	}
+
+	// Extended thinking, the API minimum is 1024 tokens
+	if c.ThinkingBudget != 0 && (c.ThinkingBudget < 1024 || c.ThinkingBudget > 64000) {
+		errs = append(errs, fmt.Errorf("invalid THINKING_BUDGET_TOKENS %d (must be 0 or 1024..64000)", c.ThinkingBudget))
+	}
+	if c.ContextWindowTokens != 0 && (c.ContextWindowTokens < 10000 || c.ContextWindowTokens > 2000000) {
+		errs = append(errs, fmt.Errorf("invalid CONTEXT_WINDOW_TOKENS %d (must be 0 or 10000..2000000)", c.ContextWindowTokens))
+	}
+	if t := c.LLMTemperature; t != nil && (*t < 0 || *t > 1) {
+		errs = append(errs, fmt.Errorf("invalid LLM_TEMPERATURE %g (must be 0..1)", *t))
+	}
+	if c.LLMTemperature != nil && c.ThinkingBudget > 0 {
+		errs = append(errs, errors.New("LLM_TEMPERATURE cannot be set with THINKING_BUDGET_TOKENS, which requires the provider default"))
+	}
+
+	// Prompt compaction, 0 bytes disables it
+	if c.PromptCompactBytes != 0 && (c.PromptCompactBytes < 256 || c.PromptCompactBytes > 1<<20) {
+		errs = append(errs, fmt.Errorf("invalid PROMPT_COMPACT_BYTES %d (must be 0 or 256..1048576)", c.PromptCompactBytes))
+	}
+	if c.PromptCompactKeep < 0 || c.PromptCompactKeep > 100 {
+		errs = append(errs, fmt.Errorf("invalid PROMPT_COMPACT_KEEP %d (must be 0..100)", c.PromptCompactKeep))
+	}
+
+	if c.DatabaseReadURL != "" && c.DatabaseURL == "" {
+		errs = append(errs, errors.New("DATABASE_READ_URL requires DATABASE_URL"))
+	}
+	if c.DatabaseReadMaxLag < 0 || c.DatabaseReadMaxLag > 3600 {
+		errs = append(errs, fmt.Errorf("invalid DATABASE_READ_MAX_LAG_SECONDS %d (must be 0..3600)", c.DatabaseReadMaxLag))
+	}
+	if c.StoreCacheSize < 0 || c.StoreCacheSize > 100000 {
+		errs = append(errs, fmt.Errorf("invalid STORE_CACHE_SIZE %d (must be 0..100000)", c.StoreCacheSize))
+	}
+	if c.StoredTurnMaxKB < 0 || c.StoredTurnMaxKB > 1<<20 {
+		errs = append(errs, fmt.Errorf("invalid STORED_TURN_MAX_KB %d (must be 0..1048576)", c.StoredTurnMaxKB))
+	}
+	if c.StoredConversationMB < 0 || c.StoredConversationMB > 65536 {
+		errs = append(errs, fmt.Errorf("invalid STORED_CONVERSATION_MAX_MB %d (must be 0..65536)", c.StoredConversationMB))
+	}
+
+	// LLM fallback, no model disables it
+	if c.LLMFallbackModel != "" {
+		if c.LLMFallbackModel == c.ClaudeModel {
+			errs = append(errs, errors.New("LLM_FALLBACK_MODEL must differ from CLAUDE_MODEL"))
+		}
+		for _, class := range strings.Split(c.LLMFallbackOn, ",") {
+			if class = strings.TrimSpace(class); !slices.Contains(FallbackFailureClasses, class) {
+				errs = append(errs, fmt.Errorf("invalid LLM_FALLBACK_ON class %q (must be one of %s)", class, strings.Join(FallbackFailureClasses, ", ")))
+			}
+		}
+		if c.LLMFallbackAttempts < 1 || c.LLMFallbackAttempts > 10 {
+			errs = append(errs, fmt.Errorf("invalid LLM_FALLBACK_ATTEMPTS %d (must be 1..10)", c.LLMFallbackAttempts))
+		}
+		if c.LLMFallbackTimeout < 0 || c.LLMFallbackTimeout > 600 {
+			errs = append(errs, fmt.Errorf("invalid LLM_FALLBACK_TIMEOUT_SECONDS %d (must be 0..600)", c.LLMFallbackTimeout))
+		}
+		if c.LLMFallbackCooldown < 1 || c.LLMFallbackCooldown > 3600 {
+			errs = append(errs, fmt.Errorf("invalid LLM_FALLBACK_COOLDOWN_SECONDS %d (must be 1..3600)", c.LLMFallbackCooldown))
+		}
+	}
+
+	// Tool circuit breaker, threshold 0 disables it
+	if c.ToolBreakerThreshold < 0 || c.ToolBreakerThreshold > 100 {
+		errs = append(errs, fmt.Errorf("invalid TOOL_BREAKER_THRESHOLD %d (must be 0..100)", c.ToolBreakerThreshold))
+	}
+	if c.ToolBreakerThreshold > 0 && (c.ToolBreakerCooldown < 1 || c.ToolBreakerCooldown > 3600) {
+		errs = append(errs, fmt.Errorf("invalid TOOL_BREAKER_COOLDOWN_SECONDS %d (must be 1..3600)", c.ToolBreakerCooldown))
+	}
+
+	// Per-tool concurrency limits, 0 means unlimited
+	for _, name := range ConcurrencyLimitedTools {
+		if n := c.ToolMaxConcurrent[name]; n < 0 || n > 1000 {
+			errs = append(errs, fmt.Errorf("invalid TOOL_MAX_CONCURRENT_%s %d (must be 0..1000)", strings.ToUpper(name), n))
+		}
+	}
+
+	// Dependency readiness checks, 0 seconds disables background checks
+	if c.ReadyCheckSeconds < 0 || c.ReadyCheckSeconds > 3600 {
+		errs = append(errs, fmt.Errorf("invalid READY_CHECK_SECONDS %d (must be 0..3600)", c.ReadyCheckSeconds))
+	}
+	if _, err := ParseReadyCheckTimeouts(c.ReadyCheckTimeouts); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Tool result cache, no TTLs disables it
+	if _, err := ParseToolCacheTTLs(c.ToolCacheTTLs); err != nil {
+		errs = append(errs, err)
+	}
+	if c.ToolCacheTTLs != "" && (c.ToolCacheSize < 1 || c.ToolCacheSize > 100000) {
+		errs = append(errs, fmt.Errorf("invalid TOOL_CACHE_SIZE %d (must be 1..100000)", c.ToolCacheSize))
+	}
+
+	// Routing, filter and enrichment polling, 0 means SIGHUP only
+	if c.ReloadSeconds < 0 || c.ReloadSeconds > 3600 {
+		errs = append(errs, fmt.Errorf("invalid RELOAD_SECONDS %d (must be 0..3600)", c.ReloadSeconds))
+	}
+
+	// LLM rate limits, 0 means unlimited
+	if c.LLMRequestsPerMinute < 0 {
+		errs = append(errs, fmt.Errorf("invalid LLM_REQUESTS_PER_MINUTE %d (must be >= 0)", c.LLMRequestsPerMinute))
+	}
+	if c.LLMInputTPM < 0 {
+		errs = append(errs, fmt.Errorf("invalid LLM_INPUT_TOKENS_PER_MINUTE %d (must be >= 0)", c.LLMInputTPM))
+	}
+	if c.LLMOutputTPM < 0 {
+		errs = append(errs, fmt.Errorf("invalid LLM_OUTPUT_TOKENS_PER_MINUTE %d (must be >= 0)", c.LLMOutputTPM))
+	}
+	if c.LLMMaxWaitSeconds < 0 || c.LLMMaxWaitSeconds > 3600 {
+		errs = append(errs, fmt.Errorf("invalid LLM_RATE_LIMIT_MAX_WAIT_SECONDS %d (must be 0..3600)", c.LLMMaxWaitSeconds))
+	}
+
+	// gen_ai event content capture
+	if c.GenAICapture != "" && c.GenAICapture != "off" && c.GenAICapture != "truncated" && c.GenAICapture != "full" {
+		errs = append(errs, fmt.Errorf("invalid GENAI_CAPTURE %q (must be off, truncated or full)", c.GenAICapture))
+	}
+
+	// Response compression, level 0 disables an encoding
+	if c.CompressGzipLevel < 0 || c.CompressGzipLevel > 9 {
+		errs = append(errs, fmt.Errorf("invalid COMPRESS_GZIP_LEVEL %d (must be 0..9)", c.CompressGzipLevel))
+	}
+	if c.CompressZstdLevel < 0 || c.CompressZstdLevel > 4 {
+		errs = append(errs, fmt.Errorf("invalid COMPRESS_ZSTD_LEVEL %d (must be 0..4)", c.CompressZstdLevel))
+	}
+	if c.CompressMinBytes < 0 || c.CompressMinBytes > 1<<20 {
+		errs = append(errs, fmt.Errorf("invalid COMPRESS_MIN_BYTES %d (must be 0..1048576)", c.CompressMinBytes))
+	}
+
+	// Ingest rate limits, 0 means unlimited
+	if c.IngestIPRate < 0 {
+		errs = append(errs, fmt.Errorf("invalid INGEST_IP_RPS %g (must be >= 0)", c.IngestIPRate))
+	}
+	if c.IngestIPBurst < 0 {
+		errs = append(errs, fmt.Errorf("invalid INGEST_IP_BURST %d (must be >= 0)", c.IngestIPBurst))
+	}
+	if c.IngestTokenRate < 0 {
+		errs = append(errs, fmt.Errorf("invalid INGEST_TOKEN_RPS %g (must be >= 0)", c.IngestTokenRate))
+	}
+	if c.IngestTokenBurst < 0 {
+		errs = append(errs, fmt.Errorf("invalid INGEST_TOKEN_BURST %d (must be >= 0)", c.IngestTokenBurst))
+	}
+	if c.IngestMaxConcurrent < 0 {
+		errs = append(errs, fmt.Errorf("invalid INGEST_MAX_CONCURRENT %d (must be >= 0)", c.IngestMaxConcurrent))
+	}
+
+	// Webhook batch limits, 0 means unlimited
+	if c.WebhookMaxAlerts < 0 || c.WebhookMaxAlerts > 100000 {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_MAX_ALERTS %d (must be 0..100000)", c.WebhookMaxAlerts))
+	}
+	if c.WebhookMaxTriages < 0 || c.WebhookMaxTriages > 10000 {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_MAX_TRIAGES %d (must be 0..10000)", c.WebhookMaxTriages))
+	}
+	if c.WebhookOverflow != "" && c.WebhookOverflow != "reject" && c.WebhookOverflow != "queue" {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_OVERFLOW %q (must be reject or queue)", c.WebhookOverflow))
+	}
+	if c.WebhookOverflow == "queue" && (c.WebhookQueueSize < 1 || c.WebhookQueueSize > 100000) {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_OVERFLOW_QUEUE_SIZE %d (must be 1..100000)", c.WebhookQueueSize))
+	}
+
+	// Message bus ingestion, empty URL or brokers disables each
+	if c.IngestNATSURL != "" && c.IngestNATSStream == "" {
+		errs = append(errs, errors.New("INGEST_NATS_URL requires INGEST_NATS_STREAM"))
+	}
+	if c.IngestNATSURL != "" && c.IngestNATSDurable == "" {
+		errs = append(errs, errors.New("INGEST_NATS_URL requires INGEST_NATS_DURABLE"))
+	}
+	if c.IngestKafkaBrokers != "" && c.IngestKafkaTopic == "" {
+		errs = append(errs, errors.New("INGEST_KAFKA_BROKERS requires INGEST_KAFKA_TOPIC"))
+	}
+	if c.IngestKafkaBrokers != "" && c.IngestKafkaGroup == "" {
+		errs = append(errs, errors.New("INGEST_KAFKA_BROKERS requires INGEST_KAFKA_GROUP"))
+	}
+
+	// Noise scoring, threshold 0 disables the budget downgrade
+	if c.NoiseDowngrade < 0 || c.NoiseDowngrade > 1 {
+		errs = append(errs, fmt.Errorf("invalid NOISE_DOWNGRADE_THRESHOLD %g (must be 0..1)", c.NoiseDowngrade))
+	}
+	if c.NotifyMinConfidence < 0 || c.NotifyMinConfidence > 1 {
+		errs = append(errs, fmt.Errorf("invalid NOTIFY_MIN_CONFIDENCE %g (must be 0..1)", c.NotifyMinConfidence))
+	}
+	if c.NoiseDowngrade > 0 && (c.NoiseWindowHours < 1 || c.NoiseWindowHours > 720) {
+		errs = append(errs, fmt.Errorf("invalid NOISE_WINDOW_HOURS %d (must be 1..720)", c.NoiseWindowHours))
+	}
+
+	// Incident mode, threshold 0 disables it
+	if c.IncidentThreshold != 0 && (c.IncidentThreshold < 2 || c.IncidentThreshold > 100) {
+		errs = append(errs, fmt.Errorf("invalid INCIDENT_THRESHOLD %d (must be 0 or 2..100)", c.IncidentThreshold))
+	}
+	if c.IncidentThreshold > 0 && (c.IncidentWindowMinutes < 1 || c.IncidentWindowMinutes > 1440) {
+		errs = append(errs, fmt.Errorf("invalid INCIDENT_WINDOW_MINUTES %d (must be 1..1440)", c.IncidentWindowMinutes))
+	}
+
+	// Storm mode, threshold 0 disables it
+	if c.StormThreshold != 0 && (c.StormThreshold < 2 || c.StormThreshold > 100000) {
+		errs = append(errs, fmt.Errorf("invalid STORM_THRESHOLD %d (must be 0 or 2..100000)", c.StormThreshold))
+	}
+	if c.StormThreshold > 0 && strings.TrimSpace(strings.ReplaceAll(c.StormGroupBy, ",", "")) == "" {
+		errs = append(errs, errors.New("STORM_GROUP_BY must name at least one label when STORM_THRESHOLD is set"))
+	}
+
+	// Load shedding guardrails, 0 disables each
+	if c.MaxInFlightTriages < 0 || c.MaxInFlightTriages > 100000 {
+		errs = append(errs, fmt.Errorf("invalid MAX_INFLIGHT_TRIAGES %d (must be 0..100000)", c.MaxInFlightTriages))
+	}
+	if c.MaxConversationMB < 0 || c.MaxConversationMB > 65536 {
+		errs = append(errs, fmt.Errorf("invalid MAX_CONVERSATION_MB %d (must be 0..65536)", c.MaxConversationMB))
+	}
+
+	// Batch mode, no severities disables it
+	if c.BatchSeverities != "" && (c.BatchFlushSeconds < 1 || c.BatchFlushSeconds > 3600) {
+		errs = append(errs, fmt.Errorf("invalid BATCH_FLUSH_SECONDS %d (must be 1..3600)", c.BatchFlushSeconds))
+	}
+	if c.BatchSeverities != "" && (c.BatchPollSeconds < 5 || c.BatchPollSeconds > 3600) {
+		errs = append(errs, fmt.Errorf("invalid BATCH_POLL_SECONDS %d (must be 5..3600)", c.BatchPollSeconds))
+	}
+
+	// Digest, empty disables it
+	if c.Digest != "" && c.Digest != "daily" && c.Digest != "weekly" {
+		errs = append(errs, fmt.Errorf("invalid DIGEST %q (must be daily or weekly)", c.Digest))
+	}
+	if c.Digest != "" && (c.DigestHour < 0 || c.DigestHour > 23) {
+		errs = append(errs, fmt.Errorf("invalid DIGEST_HOUR %d (must be 0..23)", c.DigestHour))
+	}
+	if c.Digest != "" && c.SlackWebhookURL == "" {
+		errs = append(errs, errors.New("DIGEST requires SLACK_WEBHOOK_URL"))
+	}
+
+	// Outbound webhooks, no URLs disables them
+	if c.WebhookOutURLs != "" && (c.WebhookOutAttempts < 1 || c.WebhookOutAttempts > 10) {
+		errs = append(errs, fmt.Errorf("invalid WEBHOOK_OUT_ATTEMPTS %d (must be 1..10)", c.WebhookOutAttempts))
+	}
+	if c.WebhookOutSecret != "" && c.WebhookOutURLs == "" {
+		errs = append(errs, errors.New("WEBHOOK_OUT_SECRET requires WEBHOOK_OUT_URLS"))
+	}
+
+	// Issue tracker, empty disables it
+	if c.IssueTracker != "" && c.IssueTracker != "github" && c.IssueTracker != "gitlab" {
+		errs = append(errs, fmt.Errorf("invalid ISSUE_TRACKER %q (must be github or gitlab)", c.IssueTracker))
+	}
+	if c.IssueTracker != "" && (c.IssueProject == "" || c.IssueToken == "") {
+		errs = append(errs, errors.New("ISSUE_TRACKER requires ISSUE_PROJECT and ISSUE_TOKEN"))
+	}
+	if c.IssueTracker == "github" && c.IssueProject != "" && strings.Count(c.IssueProject, "/") != 1 {
+		errs = append(errs, fmt.Errorf("invalid ISSUE_PROJECT %q (must be owner/repo for github)", c.IssueProject))
+	}
+
+	// Snapshot uploads and threaded narratives need both a bot token and the
+	// channel to post into
+	if c.SlackBotToken != "" && c.SlackSnapshotChannel == "" && c.SlackThreadChannel == "" {
+		errs = append(errs, errors.New("SLACK_BOT_TOKEN requires SLACK_SNAPSHOT_CHANNEL_ID or SLACK_THREAD_CHANNEL_ID"))
+	}
+	if c.SlackBotToken == "" && c.SlackSnapshotChannel != "" {
+		errs = append(errs, errors.New("SLACK_SNAPSHOT_CHANNEL_ID requires SLACK_BOT_TOKEN"))
+	}
+	if c.SlackThreadChannel != "" && (c.SlackBotToken == "" || c.SlackWebhookURL == "") {
+		errs = append(errs, errors.New("SLACK_THREAD_CHANNEL_ID requires SLACK_BOT_TOKEN and SLACK_WEBHOOK_URL"))
+	}
+
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
 	return nil
 }
+
+// FallbackFailureClasses are the provider failures -llm-fallback-on accepts,
+// the failure classes of package llm.
+var FallbackFailureClasses = []string{"timeout", "rate_limit", "overloaded", "server_error", "network"}
+
+// ConcurrencyLimitedTools are the built-in tools that get a
+// -tool-max-concurrent-<tool> flag.
+var ConcurrencyLimitedTools = []string{"query_metrics", "query_metrics_range", "get_host_info", "query_logs", "http_probe", "net_check"}
+
+// maxToolCacheTTL bounds tool cache TTLs; older results would mislead a
+// triage about current state.
+const maxToolCacheTTL = time.Hour
+
+// ParseToolCacheTTLs parses TOOL_CACHE_TTLS, a comma-separated list of
+// tool=duration pairs where the tool "*" sets the default.
+func ParseToolCacheTTLs(s string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		tool, val, ok := strings.Cut(pair, "=")
+		tool = strings.TrimSpace(tool)
+		d, err := time.ParseDuration(strings.TrimSpace(val))
+		if !ok || tool == "" || err != nil || d < 0 || d > maxToolCacheTTL {
+			return nil, fmt.Errorf("invalid TOOL_CACHE_TTLS entry %q (must be tool=duration with a duration of 0..1h)", pair)
+		}
+		if _, dup := ttls[tool]; dup {
+			return nil, fmt.Errorf("invalid TOOL_CACHE_TTLS: %s listed twice", tool)
+		}
+		ttls[tool] = d
+	}
+	return ttls, nil
+}
+
+// ReadyCheckDependencies are the dependencies READY_CHECK_TIMEOUTS can name.
+var ReadyCheckDependencies = []string{"postgres", "prometheus", "loki", "anthropic"}
+
+// maxReadyCheckTimeout bounds a readiness check timeout; a check that slow
+// says the dependency is not usable anyway.
+const maxReadyCheckTimeout = time.Minute
+
+// ParseReadyCheckTimeouts parses READY_CHECK_TIMEOUTS, a comma-separated
+// list of dependency=duration pairs.
+func ParseReadyCheckTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		dep, val, ok := strings.Cut(pair, "=")
+		dep = strings.TrimSpace(dep)
+		d, err := time.ParseDuration(strings.TrimSpace(val))
+		if !ok || !slices.Contains(ReadyCheckDependencies, dep) || err != nil || d <= 0 || d > maxReadyCheckTimeout {
+			return nil, fmt.Errorf("invalid READY_CHECK_TIMEOUTS entry %q (must be dependency=duration with a dependency of %s and a duration of 0..1m)",
+				pair, strings.Join(ReadyCheckDependencies, ", "))
+		}
+		if _, dup := timeouts[dep]; dup {
+			return nil, fmt.Errorf("invalid READY_CHECK_TIMEOUTS: %s listed twice", dep)
+		}
+		timeouts[dep] = d
+	}
+	return timeouts, nil
+}
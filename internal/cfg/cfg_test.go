@@ -59,6 +59,8 @@ func TestRegisterFlags_Override(t *testing.T) {
 		"-prometheus-endpoint", "http://prom:9090",
 		"-claude-api-key", "sk-override",
 		"-claude-model", "claude-opus-4-20250514",
+		"-tool-max-concurrent-query_logs", "4",
+		"-llm-temperature", "0",
 	}
 	if err := fs.Parse(args); err != nil {
 		t.Fatalf("parse args: %v", err)
@@ -82,6 +84,12 @@ func TestRegisterFlags_Override(t *testing.T) {
 	if c.ClaudeModel != "claude-opus-4-20250514" {
 		t.Errorf("ClaudeModel = %q, want %q", c.ClaudeModel, "claude-opus-4-20250514")
 	}
+	if got := c.ToolMaxConcurrent["query_logs"]; got != 4 {
+		t.Errorf("ToolMaxConcurrent[query_logs] = %d, want 4", got)
+	}
+	if c.LLMTemperature == nil || *c.LLMTemperature != 0 {
+		t.Errorf("LLMTemperature = %v, want 0", c.LLMTemperature)
+	}
 }
 
 func TestValidate(t *testing.T) {
@@ -242,6 +250,729 @@ func TestValidate(t *testing.T) {
 			wantErr:   true,
 			errSubstr: []string{"CLAUDE_MODEL"},
 		},
+		// Concurrency settings
+		{
+			name: "concurrency auto",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxConcurrentTriages, c.ToolConcurrency = 0, 0
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "concurrency explicit",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxConcurrentTriages, c.ToolConcurrency = 16, 4
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "concurrency negative",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxConcurrentTriages, c.MaxPerAlertname, c.ToolConcurrency = -1, -1, -1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"MAX_CONCURRENT_TRIAGES", "MAX_CONCURRENT_PER_ALERTNAME", "TOOL_CONCURRENCY"},
+		},
+		{
+			name: "concurrency above max",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxConcurrentTriages, c.MaxPerAlertname, c.ToolConcurrency = 1025, 1025, 65
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"MAX_CONCURRENT_TRIAGES", "MAX_CONCURRENT_PER_ALERTNAME", "TOOL_CONCURRENCY"},
+		},
+		// LLM rate limits
+		{
+			name: "llm rate limits set",
+			cfg: func() Config {
+				c := validBase()
+				c.LLMRequestsPerMinute, c.LLMInputTPM, c.LLMOutputTPM, c.LLMMaxWaitSeconds = 50, 30000, 8000, 120
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "llm rate limits invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.LLMRequestsPerMinute, c.LLMInputTPM, c.LLMOutputTPM, c.LLMMaxWaitSeconds = -1, -1, -1, 3601
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"LLM_REQUESTS_PER_MINUTE", "LLM_INPUT_TOKENS_PER_MINUTE", "LLM_OUTPUT_TOKENS_PER_MINUTE", "LLM_RATE_LIMIT_MAX_WAIT_SECONDS"},
+		},
+		{
+			name: "budget out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxToolRounds, c.MaxInputTokens, c.MaxOutputTokens = 101, 999, 500001
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"MAX_TOOL_ROUNDS", "MAX_INPUT_TOKENS", "MAX_OUTPUT_TOKENS"},
+		},
+		{
+			name: "thinking budget below API minimum",
+			cfg: func() Config {
+				c := validBase()
+				c.ThinkingBudget = 1000
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"THINKING_BUDGET_TOKENS"},
+		},
+		{
+			name: "thinking budget valid",
+			cfg: func() Config {
+				c := validBase()
+				c.ThinkingBudget = 16000
+				return c
+			}(),
+		},
+		{
+			name: "context window too small",
+			cfg: func() Config {
+				c := validBase()
+				c.ContextWindowTokens = 8192
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"CONTEXT_WINDOW_TOKENS"},
+		},
+		{
+			name: "context window valid",
+			cfg: func() Config {
+				c := validBase()
+				c.ContextWindowTokens, c.LLMCountTokens = 200000, true
+				return c
+			}(),
+		},
+		{
+			name: "prompt compaction out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.PromptCompactBytes, c.PromptCompactKeep = 100, 101
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"PROMPT_COMPACT_BYTES 100", "PROMPT_COMPACT_KEEP 101"},
+		},
+		{
+			name: "prompt compaction valid",
+			cfg: func() Config {
+				c := validBase()
+				c.PromptCompactBytes, c.PromptCompactKeep = 4096, 2
+				return c
+			}(),
+		},
+		{
+			name: "temperature out of range",
+			cfg: func() Config {
+				c := validBase()
+				t := 1.5
+				c.LLMTemperature = &t
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"LLM_TEMPERATURE 1.5"},
+		},
+		{
+			name: "temperature with thinking",
+			cfg: func() Config {
+				c := validBase()
+				t := 0.2
+				c.LLMTemperature, c.ThinkingBudget = &t, 16000
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"LLM_TEMPERATURE cannot be set with THINKING_BUDGET_TOKENS"},
+		},
+		{
+			name: "llm fallback configured",
+			cfg: func() Config {
+				c := validBase()
+				c.LLMFallbackModel, c.LLMFallbackOn = "claude-haiku-4-5", "timeout, overloaded"
+				c.LLMFallbackAttempts, c.LLMFallbackCooldown = 2, 300
+				return c
+			}(),
+		},
+		{
+			name: "llm fallback invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.LLMFallbackModel, c.LLMFallbackOn = c.ClaudeModel, "timeout,teapot"
+				c.LLMFallbackAttempts, c.LLMFallbackTimeout, c.LLMFallbackCooldown = 0, 601, 0
+				return c
+			}(),
+			wantErr: true,
+			errSubstr: []string{
+				"LLM_FALLBACK_MODEL must differ", `LLM_FALLBACK_ON class "teapot"`, "LLM_FALLBACK_ATTEMPTS 0",
+				"LLM_FALLBACK_TIMEOUT_SECONDS 601", "LLM_FALLBACK_COOLDOWN_SECONDS 0",
+			},
+		},
+		{
+			name: "database read replica",
+			cfg: func() Config {
+				c := validBase()
+				c.DatabaseURL, c.DatabaseReadURL, c.DatabaseReadMaxLag = "postgres://primary/vigil", "postgres://replica/vigil", 10
+				return c
+			}(),
+		},
+		{
+			name: "database read replica invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.DatabaseReadURL, c.DatabaseReadMaxLag = "postgres://replica/vigil", 3601
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"DATABASE_READ_URL requires DATABASE_URL", "DATABASE_READ_MAX_LAG_SECONDS 3601"},
+		},
+		{
+			name: "store cache size invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.StoreCacheSize = -1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"STORE_CACHE_SIZE -1"},
+		},
+		{
+			name: "stored conversation limits invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.StoredTurnMaxKB, c.StoredConversationMB = -1, 65537
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"STORED_TURN_MAX_KB -1", "STORED_CONVERSATION_MAX_MB 65537"},
+		},
+		{
+			name: "outbound webhook settings invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.WebhookOutURLs, c.WebhookOutAttempts = "https://hooks.example/vigil", 0
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"WEBHOOK_OUT_ATTEMPTS 0"},
+		},
+		{
+			name: "outbound webhook secret without urls",
+			cfg: func() Config {
+				c := validBase()
+				c.WebhookOutSecret = "s3cret"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"WEBHOOK_OUT_SECRET requires WEBHOOK_OUT_URLS"},
+		},
+		{
+			name: "remediation config without approval token",
+			cfg: func() Config {
+				c := validBase()
+				c.RemediationConfig = "/etc/vigil/remediation.json"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"REMEDIATION_CONFIG requires REMEDIATION_APPROVAL_TOKEN"},
+		},
+		{
+			name: "remediation approval token reuses api token",
+			cfg: func() Config {
+				c := validBase()
+				c.RemediationConfig, c.RemediationApprovalToken = "/etc/vigil/remediation.json", c.APIToken
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"REMEDIATION_APPROVAL_TOKEN must differ"},
+		},
+		{
+			name: "temperature zero",
+			cfg: func() Config {
+				c := validBase()
+				var t float64
+				c.LLMTemperature = &t
+				return c
+			}(),
+		},
+		{
+			name: "tool breaker out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolBreakerThreshold = 101
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"TOOL_BREAKER_THRESHOLD"},
+		},
+		{
+			name: "tool breaker without cooldown",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolBreakerThreshold = 5
+				c.ToolBreakerCooldown = 0
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"TOOL_BREAKER_COOLDOWN_SECONDS"},
+		},
+		{
+			name: "admin token reuses api token",
+			cfg: func() Config {
+				c := validBase()
+				c.AdminAPIToken = c.APIToken
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"ADMIN_API_TOKEN must differ"},
+		},
+		{
+			name: "separate admin token",
+			cfg: func() Config {
+				c := validBase()
+				c.AdminAPIToken = "admin-" + c.APIToken
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "batch poll too frequent",
+			cfg: func() Config {
+				c := validBase()
+				c.BatchSeverities = "info"
+				c.BatchFlushSeconds = 60
+				c.BatchPollSeconds = 1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"invalid BATCH_POLL_SECONDS 1"},
+		},
+		{
+			name: "batch intervals ignored when disabled",
+			cfg: func() Config {
+				c := validBase()
+				c.BatchPollSeconds = 1
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "decision retention too long",
+			cfg: func() Config {
+				c := validBase()
+				c.DecisionRetentionDays = 3651
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"invalid DECISION_RETENTION_DAYS 3651"},
+		},
+		{
+			name: "short share key",
+			cfg: func() Config {
+				c := validBase()
+				c.ShareKey = "too-short"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"invalid SHARE_KEY length 9"},
+		},
+		{
+			name: "share key",
+			cfg: func() Config {
+				c := validBase()
+				c.ShareKey = strings.Repeat("k", 32)
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "deleted retention out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.DeletedRetentionHours = -1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"DELETED_RETENTION_HOURS"},
+		},
+		{
+			name: "gzip level out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.CompressGzipLevel = 10
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"COMPRESS_GZIP_LEVEL"},
+		},
+		{
+			name: "zstd level out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.CompressZstdLevel = 5
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"COMPRESS_ZSTD_LEVEL"},
+		},
+		{
+			name: "negative compression minimum",
+			cfg: func() Config {
+				c := validBase()
+				c.CompressMinBytes = -1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"COMPRESS_MIN_BYTES"},
+		},
+		{
+			name: "ingest rate limits invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.IngestIPRate, c.IngestIPBurst, c.IngestTokenRate, c.IngestTokenBurst, c.IngestMaxConcurrent = -1, -1, -0.5, -1, -1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"INGEST_IP_RPS", "INGEST_IP_BURST", "INGEST_TOKEN_RPS", "INGEST_TOKEN_BURST", "INGEST_MAX_CONCURRENT"},
+		},
+		{
+			name: "webhook batch limits invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.WebhookMaxAlerts, c.WebhookMaxTriages, c.WebhookOverflow, c.WebhookQueueSize = -1, 10001, "queue", 0
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"WEBHOOK_MAX_ALERTS", "WEBHOOK_MAX_TRIAGES", "WEBHOOK_OVERFLOW_QUEUE_SIZE"},
+		},
+		{
+			name: "unknown webhook overflow",
+			cfg: func() Config {
+				c := validBase()
+				c.WebhookOverflow = "drop"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"WEBHOOK_OVERFLOW"},
+		},
+		{
+			name: "bus ingestion incomplete",
+			cfg: func() Config {
+				c := validBase()
+				c.IngestNATSURL, c.IngestKafkaBrokers = "nats://localhost:4222", "localhost:9092"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"INGEST_NATS_STREAM", "INGEST_NATS_DURABLE", "INGEST_KAFKA_TOPIC", "INGEST_KAFKA_GROUP"},
+		},
+		{
+			name: "unknown genai capture mode",
+			cfg: func() Config {
+				c := validBase()
+				c.GenAICapture = "partial"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"GENAI_CAPTURE"},
+		},
+		{
+			name: "noise threshold out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.NoiseDowngrade = 1.5
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"NOISE_DOWNGRADE_THRESHOLD"},
+		},
+		{
+			name: "noise window out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.NoiseDowngrade = 0.9
+				c.NoiseWindowHours = 0
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"NOISE_WINDOW_HOURS"},
+		},
+		{
+			name: "weekly digest",
+			cfg: func() Config {
+				c := validBase()
+				c.Digest, c.DigestHour, c.SlackWebhookURL = "weekly", 23, "https://hooks.slack.com/x"
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "digest period and hour invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.Digest, c.DigestHour, c.SlackWebhookURL = "hourly", 24, "https://hooks.slack.com/x"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"DIGEST", "DIGEST_HOUR"},
+		},
+		{
+			name: "digest without webhook",
+			cfg: func() Config {
+				c := validBase()
+				c.Digest, c.DigestHour = "daily", 9
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"SLACK_WEBHOOK_URL"},
+		},
+		{
+			name: "github issues",
+			cfg: func() Config {
+				c := validBase()
+				c.IssueTracker, c.IssueProject, c.IssueToken = "github", "acme/ops", "ghp_x"
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "issue tracker invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.IssueTracker = "jira"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"ISSUE_TRACKER", "ISSUE_PROJECT and ISSUE_TOKEN"},
+		},
+		{
+			name: "github project without owner",
+			cfg: func() Config {
+				c := validBase()
+				c.IssueTracker, c.IssueProject, c.IssueToken = "github", "ops", "ghp_x"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"owner/repo"},
+		},
+		{
+			name: "ready check timeouts valid",
+			cfg: func() Config {
+				c := validBase()
+				c.ReadyCheckSeconds, c.ReadyCheckTimeouts = 30, "postgres=1s, anthropic=10s"
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "ready check settings invalid",
+			cfg: func() Config {
+				c := validBase()
+				c.ReadyCheckSeconds, c.ReadyCheckTimeouts = -1, "redis=1s"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"READY_CHECK_SECONDS", `READY_CHECK_TIMEOUTS entry "redis=1s"`},
+		},
+		{
+			name: "ready check timeout listed twice",
+			cfg: func() Config {
+				c := validBase()
+				c.ReadyCheckTimeouts = "loki=1s,loki=2s"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"loki listed twice"},
+		},
+		{
+			name: "tool cache ttls valid",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolCacheTTLs, c.ToolCacheSize = "query_metrics=30s, *=1m, http_probe=0s", 1000
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "tool cache ttls malformed",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolCacheTTLs, c.ToolCacheSize = "query_metrics=30,query_logs=2h", 0
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{`TOOL_CACHE_TTLS entry "query_metrics=30"`, "TOOL_CACHE_SIZE"},
+		},
+		{
+			name: "tool cache ttl listed twice",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolCacheTTLs, c.ToolCacheSize = "*=1m,*=2m", 1000
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"* listed twice"},
+		},
+		{
+			name: "tool max concurrent valid",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolMaxConcurrent = map[string]int{"query_logs": 4, "query_metrics": 0}
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "tool max concurrent out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.ToolMaxConcurrent = map[string]int{"query_logs": -1, "http_probe": 1001}
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"TOOL_MAX_CONCURRENT_QUERY_LOGS -1", "TOOL_MAX_CONCURRENT_HTTP_PROBE 1001"},
+		},
+		{
+			name: "notify min confidence out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.NotifyMinConfidence = -0.1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"NOTIFY_MIN_CONFIDENCE"},
+		},
+		{
+			name: "reload seconds out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.ReloadSeconds = -1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"RELOAD_SECONDS"},
+		},
+		{
+			name: "incident threshold of one",
+			cfg: func() Config {
+				c := validBase()
+				c.IncidentThreshold = 1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"INCIDENT_THRESHOLD"},
+		},
+		{
+			name: "incident window out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.IncidentThreshold = 5
+				c.IncidentWindowMinutes = 0
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"INCIDENT_WINDOW_MINUTES"},
+		},
+		{
+			name: "storm threshold of one",
+			cfg: func() Config {
+				c := validBase()
+				c.StormThreshold = 1
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"STORM_THRESHOLD"},
+		},
+		{
+			name: "storm without group-by labels",
+			cfg: func() Config {
+				c := validBase()
+				c.StormThreshold = 200
+				c.StormGroupBy = " , "
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"STORM_GROUP_BY"},
+		},
+		// Load shedding guardrails
+		{
+			name: "guardrails set",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxInFlightTriages, c.MaxConversationMB = 500, 1024
+				return c
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "guardrails out of range",
+			cfg: func() Config {
+				c := validBase()
+				c.MaxInFlightTriages, c.MaxConversationMB = -1, 65537
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"MAX_INFLIGHT_TRIAGES", "MAX_CONVERSATION_MB"},
+		},
+		{
+			name: "slack bot token without channel",
+			cfg: func() Config {
+				c := validBase()
+				c.SlackBotToken = "xoxb-test"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"SLACK_BOT_TOKEN requires SLACK_SNAPSHOT_CHANNEL_ID"},
+		},
+		{
+			name: "slack snapshot channel without token",
+			cfg: func() Config {
+				c := validBase()
+				c.SlackSnapshotChannel = "C123"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"SLACK_SNAPSHOT_CHANNEL_ID requires SLACK_BOT_TOKEN"},
+		},
+		{
+			name: "slack thread channel without webhook",
+			cfg: func() Config {
+				c := validBase()
+				c.SlackBotToken = "xoxb-test"
+				c.SlackThreadChannel = "C456"
+				return c
+			}(),
+			wantErr:   true,
+			errSubstr: []string{"SLACK_THREAD_CHANNEL_ID requires SLACK_BOT_TOKEN and SLACK_WEBHOOK_URL"},
+		},
+		{
+			name: "slack threaded narrative configured",
+			cfg: func() Config {
+				c := validBase()
+				c.SlackWebhookURL = "https://hooks.slack.com/services/x"
+				c.SlackBotToken = "xoxb-test"
+				c.SlackThreadChannel = "C456"
+				return c
+			}(),
+		},
+		{
+			name: "slack snapshots configured",
+			cfg: func() Config {
+				c := validBase()
+				c.SlackBotToken = "xoxb-test"
+				c.SlackSnapshotChannel = "C123"
+				return c
+			}(),
+		},
 		// Error accumulation: all fields invalid
 		{
 			name:      "all fields invalid",
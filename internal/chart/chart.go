@@ -0,0 +1,361 @@
+// Package chart renders small PNG and SVG sparklines from Prometheus range
+// query results that a triage already fetched, for attaching to
+// notifications and reports.
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// RangeToolName is the tool whose results are charted.
+const RangeToolName = "query_metrics_range"
+
+// MaxSeries caps how many series are drawn so the chart stays readable.
+const MaxSeries = 8
+
+// Point is one sample.
+type Point struct {
+	T time.Time
+	V float64
+}
+
+// Series is one labelled time series.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// Chart is the data for one snapshot: the query that produced it and its series.
+type Chart struct {
+	Query  string
+	Series []Series
+}
+
+// rangeOutput mirrors the JSON returned by the query_metrics_range tool.
+type rangeOutput struct {
+	ResultType string `json:"result_type"`
+	Results    []struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]any          `json:"values"`
+	} `json:"results"`
+}
+
+// ParseRangeOutput decodes a query_metrics_range tool result into series.
+func ParseRangeOutput(raw string) ([]Series, error) {
+	var out rangeOutput
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, fmt.Errorf("decode range output: %w", err)
+	}
+	if out.ResultType != "matrix" {
+		return nil, fmt.Errorf("unsupported result type %q", out.ResultType)
+	}
+	series := make([]Series, 0, len(out.Results))
+	for _, r := range out.Results {
+		s := Series{Labels: r.Metric}
+		for _, v := range r.Values {
+			ts, ok := v[0].(float64)
+			if !ok {
+				continue
+			}
+			str, ok := v[1].(string)
+			if !ok {
+				continue
+			}
+			f, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				continue
+			}
+			sec, frac := math.Modf(ts)
+			s.Points = append(s.Points, Point{T: time.Unix(int64(sec), int64(frac*1e9)), V: f})
+		}
+		if len(s.Points) > 0 {
+			series = append(series, s)
+		}
+	}
+	return series, nil
+}
+
+// FromConversation returns a chart for the most recent successful range query
+// in a triage conversation that returned data, if any.
+func FromConversation(conv *triage.Conversation) (*Chart, bool) {
+	charts := All(conv)
+	if len(charts) == 0 {
+		return nil, false
+	}
+	return charts[len(charts)-1], true
+}
+
+// All returns a chart for every successful range query in a triage
+// conversation that returned data, in the order they were made.
+func All(conv *triage.Conversation) []*Chart {
+	if conv == nil {
+		return nil
+	}
+	queries := make(map[string]string) // tool_use ID -> PromQL
+	var charts []*Chart
+	for _, turn := range conv.Turns {
+		for _, b := range turn.Content {
+			switch {
+			case b.Type == "tool_use" && b.Name == RangeToolName:
+				var in struct {
+					Query string `json:"query"`
+				}
+				_ = json.Unmarshal(b.Input, &in)
+				queries[b.ID] = in.Query
+			case b.Type == "tool_result" && !b.IsError:
+				q, ok := queries[b.ToolUseID]
+				if !ok {
+					continue
+				}
+				series, err := ParseRangeOutput(b.Content)
+				if err != nil || len(series) == 0 {
+					continue
+				}
+				charts = append(charts, &Chart{Query: q, Series: series})
+			}
+		}
+	}
+	return charts
+}
+
+// palette holds line colors for successive series.
+var palette = []color.RGBA{
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0x8c, 0x56, 0x4b, 0xff},
+	{0xe3, 0x77, 0xc2, 0xff},
+	{0x17, 0xbe, 0xcf, 0xff},
+}
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+)
+
+// margin is the blank border around the plot, in pixels.
+const margin = 4
+
+// plot maps samples of the drawn series onto a width x height image.
+type plot struct {
+	series     []Series
+	tMin, tMax int64
+	vMin, vMax float64
+	w, h       int
+}
+
+// newPlot fits the first MaxSeries series into a width x height image,
+// padding the value range by a tenth on either side.
+func (c *Chart) newPlot(width, height int) (*plot, error) {
+	if width < 16 || height < 16 {
+		return nil, errors.New("chart too small")
+	}
+	series := c.Series
+	if len(series) > MaxSeries {
+		series = series[:MaxSeries]
+	}
+
+	tMin, tMax := int64(math.MaxInt64), int64(math.MinInt64)
+	vMin, vMax := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, p := range s.Points {
+			if math.IsNaN(p.V) || math.IsInf(p.V, 0) {
+				continue
+			}
+			tMin, tMax = min(tMin, p.T.UnixNano()), max(tMax, p.T.UnixNano())
+			vMin, vMax = min(vMin, p.V), max(vMax, p.V)
+		}
+	}
+	if math.IsInf(vMin, 1) {
+		return nil, errors.New("no finite samples to chart")
+	}
+	if vMin == vMax {
+		vMin, vMax = vMin-1, vMax+1
+	}
+	pad := (vMax - vMin) * 0.1
+	vMin, vMax = vMin-pad, vMax+pad
+	if tMin == tMax {
+		tMax = tMin + 1
+	}
+	return &plot{series: series, tMin: tMin, tMax: tMax, vMin: vMin, vMax: vMax, w: width - 2*margin - 1, h: height - 2*margin - 1}, nil
+}
+
+func (p *plot) x(t time.Time) int {
+	return margin + int(math.Round(float64(t.UnixNano()-p.tMin)/float64(p.tMax-p.tMin)*float64(p.w)))
+}
+
+func (p *plot) y(v float64) int {
+	return margin + p.h - int(math.Round((v-p.vMin)/(p.vMax-p.vMin)*float64(p.h)))
+}
+
+// gridY returns the y of the guide at quartile q, 1 to 3.
+func (p *plot) gridY(q int) int {
+	return margin + p.h*q/4
+}
+
+// PNG draws the chart as a width x height sparkline: one line per series
+// (up to MaxSeries) over a shared time and value range, with light guides at
+// the quartiles. There are no axis labels; the notification carries the query.
+func (c *Chart) PNG(width, height int) ([]byte, error) {
+	pl, err := c.newPlot(width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, background)
+	for q := 1; q <= 3; q++ {
+		y := pl.gridY(q)
+		for x := margin; x <= margin+pl.w; x++ {
+			img.SetRGBA(x, y, gridColor)
+		}
+	}
+
+	for i, s := range pl.series {
+		col := palette[i%len(palette)]
+		havePrev := false
+		var px, py int
+		for _, p := range s.Points {
+			if math.IsNaN(p.V) || math.IsInf(p.V, 0) {
+				havePrev = false
+				continue
+			}
+			x, y := pl.x(p.T), pl.y(p.V)
+			if havePrev {
+				line(img, px, py, x, y, col)
+			} else {
+				dot(img, x, y, col)
+			}
+			px, py, havePrev = x, y, true
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG draws the same sparkline as PNG as an SVG document, titled with the
+// query, for inlining in HTML.
+func (c *Chart) SVG(width, height int) ([]byte, error) {
+	pl, err := c.newPlot(width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img">`, width, height, width, height)
+	b.WriteString("<title>")
+	_ = xml.EscapeText(&b, []byte(c.Query))
+	b.WriteString("</title>")
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, width, height, hex(background))
+	for q := 1; q <= 3; q++ {
+		y := pl.gridY(q)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`, margin, y, margin+pl.w, y, hex(gridColor))
+	}
+
+	for i, s := range pl.series {
+		col := hex(palette[i%len(palette)])
+		// Gaps at non-finite samples split a series into several lines.
+		var pts []image.Point
+		flush := func() {
+			switch len(pts) {
+			case 0:
+			case 1:
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="2" height="2" fill="%s"/>`, pts[0].X, pts[0].Y, col)
+			default:
+				b.WriteString(`<polyline points="`)
+				for j, pt := range pts {
+					if j > 0 {
+						b.WriteByte(' ')
+					}
+					fmt.Fprintf(&b, "%d,%d", pt.X, pt.Y)
+				}
+				fmt.Fprintf(&b, `" fill="none" stroke="%s" stroke-width="2" stroke-linejoin="round"/>`, col)
+			}
+			pts = pts[:0]
+		}
+		for _, p := range s.Points {
+			if math.IsNaN(p.V) || math.IsInf(p.V, 0) {
+				flush()
+				continue
+			}
+			pts = append(pts, image.Pt(pl.x(p.T), pl.y(p.V)))
+		}
+		flush()
+	}
+	b.WriteString("</svg>")
+	return b.Bytes(), nil
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func fill(img *image.RGBA, c color.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// dot plots a 2x2 pixel block so lines are visible at Slack's preview scale.
+func dot(img *image.RGBA, x, y int, c color.RGBA) {
+	img.SetRGBA(x, y, c)
+	img.SetRGBA(x+1, y, c)
+	img.SetRGBA(x, y+1, c)
+	img.SetRGBA(x+1, y+1, c)
+}
+
+// line draws a thick line from (x0,y0) to (x1,y1) with Bresenham's algorithm.
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		dot(img, x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
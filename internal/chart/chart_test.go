@@ -0,0 +1,178 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const rangeOutputJSON = `{
+	"result_type": "matrix",
+	"result_count": 2,
+	"truncated": false,
+	"results": [
+		{"metric": {"instance": "web-1"}, "values": [[1700000000, "0.1"], [1700000060, "0.2"], [1700000120, "0.9"]]},
+		{"metric": {"instance": "web-2"}, "values": [[1700000000, "0.1"], [1700000060.5, "NaN"], [1700000120, "bogus"]]}
+	]
+}`
+
+func TestParseRangeOutput(t *testing.T) {
+	t.Parallel()
+
+	series, err := ParseRangeOutput(rangeOutputJSON)
+	if err != nil {
+		t.Fatalf("ParseRangeOutput: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("series = %d, want 2", len(series))
+	}
+	if got := series[0].Points[2]; got.V != 0.9 || !got.T.Equal(time.Unix(1700000120, 0)) {
+		t.Errorf("point = %+v", got)
+	}
+	// unparsable values are dropped, NaN is kept for the renderer to skip
+	if n := len(series[1].Points); n != 2 || !math.IsNaN(series[1].Points[1].V) {
+		t.Errorf("web-2 points = %+v", series[1].Points)
+	}
+	if series[1].Points[1].T.Nanosecond() != 500000000 {
+		t.Errorf("fractional timestamp = %v", series[1].Points[1].T)
+	}
+}
+
+func TestParseRangeOutput_NotMatrix(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseRangeOutput(`{"result_type":"vector","results":[]}`); err == nil {
+		t.Error("expected error for vector result")
+	}
+	if _, err := ParseRangeOutput(`not json`); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestFromConversation_PicksLatestSuccessfulRangeQuery(t *testing.T) {
+	t.Parallel()
+
+	use := func(id, name, query string) triage.ContentBlock {
+		in, _ := json.Marshal(map[string]string{"query": query})
+		return triage.ContentBlock{Type: "tool_use", ID: id, Name: name, Input: in}
+	}
+	conv := &triage.Conversation{Turns: []triage.Turn{
+		{Role: "assistant", Content: []triage.ContentBlock{use("a", RangeToolName, "rate(cpu[5m])"), use("b", "query_metrics", "up")}},
+		{Role: "user", Content: []triage.ContentBlock{
+			{Type: "tool_result", ToolUseID: "a", Content: rangeOutputJSON},
+			{Type: "tool_result", ToolUseID: "b", Content: rangeOutputJSON},
+		}},
+		{Role: "assistant", Content: []triage.ContentBlock{use("c", RangeToolName, "mem_bytes"), use("d", RangeToolName, "broken")}},
+		{Role: "user", Content: []triage.ContentBlock{
+			{Type: "tool_result", ToolUseID: "c", Content: `{"result_type":"matrix","results":[]}`},
+			{Type: "tool_result", ToolUseID: "d", Content: "bad query", IsError: true},
+		}},
+	}}
+
+	c, ok := FromConversation(conv)
+	if !ok {
+		t.Fatal("expected a chart")
+	}
+	if c.Query != "rate(cpu[5m])" {
+		t.Errorf("query = %q, want the last range query with data", c.Query)
+	}
+
+	if _, ok := FromConversation(&triage.Conversation{}); ok {
+		t.Error("expected no chart for empty conversation")
+	}
+	if _, ok := FromConversation(nil); ok {
+		t.Error("expected no chart for nil conversation")
+	}
+
+	all := All(conv)
+	if len(all) != 1 || all[0].Query != c.Query {
+		t.Errorf("All = %+v, want only the range query with data", all)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	t.Parallel()
+
+	series, err := ParseRangeOutput(rangeOutputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := (&Chart{Series: series}).PNG(300, 80)
+	if err != nil {
+		t.Fatalf("PNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if img.Bounds().Dx() != 300 || img.Bounds().Dy() != 80 {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+
+	// the first series rises to its max at the right edge, so the top-right
+	// of the plot should hold its color
+	r, g, b2, _ := img.At(296, 11).RGBA()
+	if want := palette[0]; uint8(r>>8) != want.R || uint8(g>>8) != want.G || uint8(b2>>8) != want.B {
+		t.Errorf("pixel at series max = %d,%d,%d, want %v", r>>8, g>>8, b2>>8, want)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	t.Parallel()
+
+	series, err := ParseRangeOutput(rangeOutputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := (&Chart{Query: `rate(http_requests_total{code=~"5.."}[5m]) > 0 & <1`, Series: series}).SVG(300, 80)
+	if err != nil {
+		t.Fatalf("SVG: %v", err)
+	}
+	var doc struct {
+		XMLName   xml.Name `xml:"svg"`
+		Title     string   `xml:"title"`
+		Polylines []struct {
+			Points string `xml:"points,attr"`
+			Stroke string `xml:"stroke,attr"`
+		} `xml:"polyline"`
+		Rects []struct {
+			X string `xml:"x,attr"`
+		} `xml:"rect"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("invalid svg: %v\n%s", err, b)
+	}
+	if doc.Title != `rate(http_requests_total{code=~"5.."}[5m]) > 0 & <1` {
+		t.Errorf("title = %q", doc.Title)
+	}
+	// web-1 is one line that ends top right; web-2 has a single finite
+	// sample before its NaN and is drawn as a dot.
+	if len(doc.Polylines) != 1 || doc.Polylines[0].Stroke != "#1f77b4" || !strings.HasSuffix(doc.Polylines[0].Points, " 295,10") {
+		t.Errorf("polylines = %+v", doc.Polylines)
+	}
+	if len(doc.Rects) != 2 {
+		t.Errorf("rects = %d, want the background and one dot", len(doc.Rects))
+	}
+}
+
+func TestPNG_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (&Chart{}).PNG(300, 80); err == nil {
+		t.Error("expected error for chart without samples")
+	}
+	c := &Chart{Series: []Series{{Points: []Point{{T: time.Unix(0, 0), V: 1}}}}}
+	if _, err := c.PNG(8, 8); err == nil {
+		t.Error("expected error for tiny chart")
+	}
+	if _, err := c.PNG(100, 40); err != nil {
+		t.Errorf("single flat point: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package compressmw
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported by Compress.
+const (
+	EncodingZstd = "zstd"
+	EncodingGzip = "gzip"
+)
+
+// Options configures Compress. A zero level disables that encoding.
+type Options struct {
+	// GzipLevel is a compress/gzip level, 1 (fastest) to 9 (best).
+	GzipLevel int
+	// ZstdLevel is 1 (fastest) to 4 (best), see zstd.EncoderLevel.
+	ZstdLevel int
+	// MinSize is the smallest body, in bytes, worth compressing. Smaller
+	// responses are sent as is.
+	MinSize int
+	// Types lists the media types to compress, such as "application/json".
+	Types []string
+}
+
+// Compress returns middleware that compresses responses whose Content-Type is
+// in o.Types and whose body reaches o.MinSize bytes. When a client accepts
+// both encodings with equal weight, zstd wins.
+func Compress(o Options) func(http.Handler) http.Handler {
+	c := &compressor{minSize: o.MinSize, types: o.Types}
+	if o.ZstdLevel > 0 {
+		level := zstd.EncoderLevel(o.ZstdLevel)
+		c.encoders = append(c.encoders, &encoder{name: EncodingZstd, pool: sync.Pool{New: func() any {
+			zw, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				panic(fmt.Sprintf("compressmw: zstd level %d: %v", o.ZstdLevel, err))
+			}
+			return zw
+		}}})
+	}
+	if o.GzipLevel > 0 {
+		c.encoders = append(c.encoders, &encoder{name: EncodingGzip, pool: sync.Pool{New: func() any {
+			gw, err := gzip.NewWriterLevel(nil, o.GzipLevel)
+			if err != nil {
+				panic(fmt.Sprintf("compressmw: gzip level %d: %v", o.GzipLevel, err))
+			}
+			return gw
+		}}})
+	}
+
+	// Build one writer per encoding now so bad levels fail at startup, not on
+	// the first request.
+	for _, e := range c.encoders {
+		e.pool.Put(e.pool.Get())
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(c.encoders) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enc := c.negotiate(r.Header.Get("Accept-Encoding"))
+			if enc == nil || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, c: c, enc: enc, status: http.StatusOK}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+type compressor struct {
+	minSize  int
+	types    []string
+	encoders []*encoder // in server preference order
+}
+
+// encoder pools writers for one content coding. Pooled values implement
+// resetWriter.
+type encoder struct {
+	name string
+	pool sync.Pool
+}
+
+type resetWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+	Flush() error
+}
+
+// negotiate picks the encoder with the highest q-value in the Accept-Encoding
+// header, breaking ties by server preference. It returns nil when the client
+// accepts none of them.
+func (c *compressor) negotiate(header string) *encoder {
+	if header == "" {
+		return nil
+	}
+	weights := make(map[string]float64)
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	var best *encoder
+	var bestQ float64
+	for _, e := range c.encoders {
+		q, ok := weights[e.name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+func (c *compressor) compressible(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && slices.Contains(c.types, mediaType)
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response is big enough to compress.
+type compressWriter struct {
+	http.ResponseWriter
+	c   *compressor
+	enc *encoder
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	zw          resetWriter // nil when passing through
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.c.minSize {
+			return len(p), nil
+		}
+		buffered := cw.buf
+		cw.buf = nil
+		cw.decide(true)
+		if _, err := cw.body().Write(buffered); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return cw.body().Write(p)
+}
+
+// decide commits the headers. large reports whether the body reached the
+// minimum size; streamed responses count as large.
+func (cw *compressWriter) decide(large bool) {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if cw.c.compressible(h) {
+		h.Add("Vary", "Accept-Encoding")
+		if large {
+			h.Set("Content-Encoding", cw.enc.name)
+			h.Del("Content-Length")
+			zw := cw.enc.pool.Get().(resetWriter) //nolint:forcetypeassert // pool only holds resetWriter
+			zw.Reset(cw.ResponseWriter)
+			cw.zw = zw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressWriter) body() io.Writer {
+	if cw.zw != nil {
+		return cw.zw
+	}
+	return cw.ResponseWriter
+}
+
+// Flush sends buffered data now, compressing it if the type allows since a
+// streamed response's final size is unknown.
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		buffered := cw.buf
+		cw.buf = nil
+		cw.decide(true)
+		_, _ = cw.body().Write(buffered)
+	}
+	if cw.zw != nil {
+		_ = cw.zw.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets websocket-style handlers take over the connection.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if !cw.wroteHeader && len(cw.buf) == 0 {
+			// Nothing written; let net/http send its default response.
+			return
+		}
+		buffered := cw.buf
+		cw.buf = nil
+		cw.decide(false)
+		_, _ = cw.ResponseWriter.Write(buffered)
+		return
+	}
+	if cw.zw != nil {
+		_ = cw.zw.Close()
+		cw.enc.pool.Put(cw.zw)
+		cw.zw = nil
+	}
+}
@@ -0,0 +1,172 @@
+package compressmw
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var testOptions = Options{GzipLevel: 5, ZstdLevel: 2, MinSize: 64, Types: []string{"application/json"}}
+
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Content-Length", "999")
+		_, _ = io.WriteString(w, body)
+	})
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = gr
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("zstd reader: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode %s: %v", encoding, err)
+	}
+	return string(out)
+}
+
+func TestCompress(t *testing.T) {
+	t.Parallel()
+
+	large := `{"analysis":"` + strings.Repeat("disk full on node-1 ", 20) + `"}`
+	small := `{"ok":true}`
+
+	tests := []struct {
+		name           string
+		opts           Options
+		acceptEncoding string
+		body           string
+		wantEncoding   string
+	}{
+		{"prefers zstd on a tie", testOptions, "gzip, zstd", large, EncodingZstd},
+		{"gzip only client", testOptions, "gzip", large, EncodingGzip},
+		{"q-values win over preference", testOptions, "zstd;q=0.5, gzip;q=0.9", large, EncodingGzip},
+		{"refused encoding", testOptions, "zstd;q=0, gzip", large, EncodingGzip},
+		{"wildcard", testOptions, "*", large, EncodingZstd},
+		{"no accept-encoding", testOptions, "", large, ""},
+		{"unsupported encoding", testOptions, "br", large, ""},
+		{"below minimum size", testOptions, "zstd, gzip", small, ""},
+		{"zstd disabled", Options{GzipLevel: 5, MinSize: 64, Types: testOptions.Types}, "zstd, gzip", large, EncodingGzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := Compress(tt.opts)(jsonHandler(tt.body))
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantEncoding != "" && rec.Header().Get("Content-Length") != "" {
+				t.Error("Content-Length kept on a compressed response")
+			}
+			if got := decode(t, tt.wantEncoding, rec.Body.Bytes()); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestCompress_SkipsOtherTypes(t *testing.T) {
+	t.Parallel()
+
+	png := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 100)
+	h := Compress(testOptions)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(png)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.Header.Set("Accept-Encoding", "zstd, gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, want none", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), png) {
+		t.Error("body changed")
+	}
+}
+
+func TestCompress_KeepsStatusAndVary(t *testing.T) {
+	t.Parallel()
+
+	body := `{"error":{"code":"not_found","message":"` + strings.Repeat("x", 100) + `"}}`
+	h := Compress(testOptions)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, body[:10])
+		_, _ = io.WriteString(w, body[10:])
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	if got := decode(t, rec.Header().Get("Content-Encoding"), rec.Body.Bytes()); got != body {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestCompress_NoBody(t *testing.T) {
+	t.Parallel()
+
+	h := Compress(testOptions)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := httptest.NewRequest(http.MethodDelete, "/", http.NoBody)
+	req.Header.Set("Accept-Encoding", "zstd")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("got %d %q encoding %q, want bare 204", rec.Code, rec.Body.String(), rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompress_InvalidLevelPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Compress accepted gzip level 42")
+		}
+	}()
+	Compress(Options{GzipLevel: 42})
+}
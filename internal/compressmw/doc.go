@@ -0,0 +1,3 @@
+// Package compressmw provides HTTP middleware that compresses responses with
+// zstd or gzip, negotiated from the request's Accept-Encoding header.
+package compressmw
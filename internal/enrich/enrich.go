@@ -0,0 +1,221 @@
+// Package enrich adds operator-provided metadata to alerts before triage,
+// from entries of label matchers.
+//
+// Alerts carry what fired, but rarely who owns the service, how critical it
+// is, where its runbook lives or what it depends on. That knowledge usually
+// sits in a service catalog; an enrichment file, or an HTTP endpoint serving
+// one, hands it to the model and shows it next to the result.
+package enrich
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/filter"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// maxSourceBytes bounds an enrichment config fetched over HTTP.
+const maxSourceBytes = 10 << 20
+
+// fetchTimeout bounds fetching an enrichment config over HTTP.
+const fetchTimeout = 10 * time.Second
+
+// Entry is one entry in the enrichment config.
+type Entry struct {
+	// Name identifies the entry in errors.
+	Name string `json:"name"`
+
+	// Labels are matchers in Alertmanager syntax, such as service="checkout"
+	// or namespace=~"payments-.*". An alert must satisfy all of them; a
+	// missing label has the empty value.
+	Labels []string `json:"labels"`
+
+	Owner        string   `json:"owner"`
+	Tier         string   `json:"tier"`
+	Runbook      string   `json:"runbook"`
+	Dependencies []string `json:"dependencies"`
+}
+
+// Config is the enrichment file format.
+type Config struct {
+	Entries []Entry `json:"entries"`
+}
+
+// IsURL reports whether source names an HTTP endpoint rather than a file.
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// LoadConfig reads and validates a JSON enrichment config from a file, or
+// from an http(s) URL.
+func LoadConfig(ctx context.Context, source string) (Config, error) {
+	b, err := Read(ctx, source)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(b, source)
+}
+
+// Read returns the raw enrichment config from a file, or from an http(s)
+// URL.
+func Read(ctx context.Context, source string) ([]byte, error) {
+	var b []byte
+	var err error
+	if IsURL(source) {
+		b, err = fetch(ctx, source)
+	} else {
+		b, err = os.ReadFile(source) //nolint:gosec // G304: path is supplied by the operator
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read enrichment config: %w", err)
+	}
+	return b, nil
+}
+
+// ParseConfig parses and validates an enrichment config read from source,
+// which is only used in errors.
+func ParseConfig(b []byte, source string) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse enrichment config %s: %w", source, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("enrichment config %s: %w", source, err)
+	}
+	return c, nil
+}
+
+func fetch(ctx context.Context, source string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req) //nolint:gosec // G107: URL is supplied by the operator
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", source, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxSourceBytes {
+		return nil, fmt.Errorf("GET %s: body exceeds %d bytes", source, maxSourceBytes)
+	}
+	return b, nil
+}
+
+// Validate reports every problem in the config.
+func (c *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	for i, e := range c.Entries {
+		if e.Name == "" {
+			errs = append(errs, fmt.Errorf("entries[%d]: name is required", i))
+		} else if names[e.Name] {
+			errs = append(errs, fmt.Errorf("entry %q: duplicate name", e.Name))
+		}
+		names[e.Name] = true
+
+		if len(e.Labels) == 0 {
+			errs = append(errs, fmt.Errorf("entry %q: at least one label matcher is required", e.Name))
+		}
+		for _, m := range e.Labels {
+			if _, err := filter.ParseMatcher(m); err != nil {
+				errs = append(errs, fmt.Errorf("entry %q: labels: %w", e.Name, err))
+			}
+		}
+		if e.Owner == "" && e.Tier == "" && e.Runbook == "" && len(e.Dependencies) == 0 {
+			errs = append(errs, fmt.Errorf("entry %q: at least one of owner, tier, runbook or dependencies is required", e.Name))
+		}
+		if e.Runbook != "" {
+			u, err := url.Parse(e.Runbook)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("entry %q: runbook must be an absolute http(s) URL", e.Name))
+			}
+		}
+		if slices.Contains(e.Dependencies, "") {
+			errs = append(errs, fmt.Errorf("entry %q: empty dependency name", e.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Enricher looks up alert metadata. It satisfies triage.Enricher.
+type Enricher struct {
+	entries []entry
+}
+
+type entry struct {
+	md     triage.Metadata
+	labels []filter.Matcher
+}
+
+// New builds an Enricher from a config.
+func New(c Config) (*Enricher, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	e := &Enricher{entries: make([]entry, 0, len(c.Entries))}
+	for _, ce := range c.Entries {
+		en := entry{md: triage.Metadata{
+			Owner:        ce.Owner,
+			Tier:         ce.Tier,
+			Runbook:      ce.Runbook,
+			Dependencies: ce.Dependencies,
+		}}
+		for _, s := range ce.Labels {
+			m, _ := filter.ParseMatcher(s) // checked by Validate
+			en.labels = append(en.labels, m)
+		}
+		e.entries = append(e.entries, en)
+	}
+	return e, nil
+}
+
+// Enrich merges the entries matching al, in order: the first entry to set
+// owner, tier or runbook wins, and dependencies accumulate. Broad entries,
+// such as one per namespace, can so fill in what a service's own entry
+// leaves out. It returns nil when no entry matches.
+func (e *Enricher) Enrich(al *alert.Alert) *triage.Metadata {
+	var md *triage.Metadata
+	for i := range e.entries {
+		en := &e.entries[i]
+		if !filter.MatchAll(en.labels, al.Labels) {
+			continue
+		}
+		if md == nil {
+			md = &triage.Metadata{}
+		}
+		md.Owner = cmp.Or(md.Owner, en.md.Owner)
+		md.Tier = cmp.Or(md.Tier, en.md.Tier)
+		md.Runbook = cmp.Or(md.Runbook, en.md.Runbook)
+		for _, d := range en.md.Dependencies {
+			if !slices.Contains(md.Dependencies, d) {
+				md.Dependencies = append(md.Dependencies, d)
+			}
+		}
+	}
+	return md
+}
@@ -0,0 +1,150 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+const testConfig = `{"entries":[
+	{"name":"checkout","labels":["service=\"checkout\""],"owner":"team-payments","runbook":"https://wiki.example.com/checkout","dependencies":["payments-db"]},
+	{"name":"prod","labels":["namespace=~\"prod-.*\""],"owner":"sre","tier":"tier-1","dependencies":["payments-db","ceph"]}
+]}`
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "enrich.json")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/catalog.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testConfig))
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, source := range []string{path, srv.URL + "/catalog.json"} {
+		c, err := LoadConfig(context.Background(), source)
+		if err != nil {
+			t.Fatalf("LoadConfig(%s): %v", source, err)
+		}
+		if len(c.Entries) != 2 || c.Entries[1].Tier != "tier-1" {
+			t.Errorf("LoadConfig(%s) = %+v", source, c)
+		}
+	}
+
+	if _, err := LoadConfig(context.Background(), srv.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v, want the HTTP status", err)
+	}
+}
+
+func TestLoadConfig_UnknownField(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "enrich.json")
+	if err := os.WriteFile(path, []byte(`{"entries":[{"name":"a","labels":["a=b"],"owner":"x","team":"y"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(context.Background(), path); err == nil || !strings.Contains(err.Error(), "team") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			cfg:  Config{Entries: []Entry{{Name: "a", Labels: []string{"service=api"}, Owner: "team-api"}}},
+		},
+		{
+			name:    "empty entry",
+			cfg:     Config{Entries: []Entry{{}}},
+			wantErr: []string{"name is required", "at least one label matcher", "at least one of owner, tier, runbook or dependencies"},
+		},
+		{
+			name: "duplicate name",
+			cfg: Config{Entries: []Entry{
+				{Name: "a", Labels: []string{"x=y"}, Owner: "o"},
+				{Name: "a", Labels: []string{"x=z"}, Owner: "o"},
+			}},
+			wantErr: []string{"duplicate name"},
+		},
+		{
+			name:    "bad values",
+			cfg:     Config{Entries: []Entry{{Name: "a", Labels: []string{"service"}, Runbook: "wiki/a", Dependencies: []string{"db", ""}}}},
+			wantErr: []string{`matcher "service"`, "runbook must be an absolute http(s) URL", "empty dependency name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate returned nil, want an error")
+			}
+			for _, w := range tt.wantErr {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("error %q missing %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "enrich.json")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both entries match: the service's own owner wins, the namespace fills
+	// in the tier, and dependencies are merged without repeats.
+	md := e.Enrich(&alert.Alert{Labels: map[string]string{"service": "checkout", "namespace": "prod-eu"}})
+	if md == nil || md.Owner != "team-payments" || md.Tier != "tier-1" || md.Runbook != "https://wiki.example.com/checkout" {
+		t.Fatalf("Enrich = %+v", md)
+	}
+	if !slices.Equal(md.Dependencies, []string{"payments-db", "ceph"}) {
+		t.Errorf("Dependencies = %v, want [payments-db ceph]", md.Dependencies)
+	}
+
+	if md := e.Enrich(&alert.Alert{Labels: map[string]string{"namespace": "prod-us"}}); md == nil || md.Owner != "sre" {
+		t.Errorf("Enrich = %+v, want the namespace entry", md)
+	}
+	if md := e.Enrich(&alert.Alert{Labels: map[string]string{"service": "search"}}); md != nil {
+		t.Errorf("Enrich = %+v, want nil", md)
+	}
+}
@@ -0,0 +1,197 @@
+// Package eval replays a corpus of recorded alerts through the triage engine
+// and scores the analyses, so a prompt or model change can be compared with
+// the current one before it ships.
+//
+// A corpus is a directory of JSON case files. Each case holds an alert, what
+// a good analysis of it should contain, and optionally recorded tool outputs
+// and model responses. With recorded tool outputs the case is replayed
+// against them instead of live datasources, so both sides of a comparison see
+// the same data; with recorded responses it runs without a model at all,
+// which checks the corpus and scorers offline.
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/replay"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Case is one alert of the corpus.
+type Case struct {
+	// Name identifies the case in reports, defaulting to the file name
+	// without its extension.
+	Name  string      `json:"name,omitempty"`
+	Alert alert.Alert `json:"alert"`
+	// Fixtures are recorded tool outputs. A case with fixtures, or with a
+	// Recorded conversation, never calls live tools.
+	Fixtures []replay.ToolCall `json:"fixtures,omitempty"`
+	// Recorded is the conversation of a past triage of the alert, as
+	// returned by the API, whose tool results become fixtures.
+	Recorded *triage.Conversation `json:"recorded,omitempty"`
+	// Responses are model responses replayed in order when a variant has no
+	// provider.
+	Responses []replay.Response `json:"responses,omitempty"`
+	Expect    Expect            `json:"expect"`
+}
+
+// Expect describes a good analysis of the case.
+type Expect struct {
+	// Keywords must appear in the analysis, ignoring case.
+	Keywords []string `json:"keywords,omitempty"`
+	// Forbidden must not appear in the analysis, such as a known wrong root
+	// cause.
+	Forbidden []string    `json:"forbidden,omitempty"`
+	Rubric    []Criterion `json:"rubric,omitempty"`
+}
+
+// Criterion is one rubric item. Criteria with a Pattern are checked against
+// the analysis; the rest need an LLM judge.
+type Criterion struct {
+	Criterion string `json:"criterion"`
+	// Pattern is a regular expression matched against the analysis,
+	// ignoring case.
+	Pattern string `json:"pattern,omitempty"`
+	// Weight defaults to 1.
+	Weight float64 `json:"weight,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// weight returns the criterion's weight, defaulting to 1.
+func (c *Criterion) weight() float64 {
+	if c.Weight > 0 {
+		return c.Weight
+	}
+	return 1
+}
+
+// LoadCorpus reads every .json file in dir as a Case, in file name order.
+func LoadCorpus(dir string) ([]*Case, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("eval corpus %s: no .json cases", dir)
+	}
+	slices.Sort(paths)
+
+	cases := make([]*Case, 0, len(paths))
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		c, err := loadCase(path)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := names[c.Name]; ok {
+			return nil, fmt.Errorf("eval case %s: name %q already used by %s", path, c.Name, prev)
+		}
+		names[c.Name] = path
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+func loadCase(path string) (*Case, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("read eval case: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var c Case
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parse eval case %s: %w", path, err)
+	}
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("eval case %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// validate checks the case and compiles its rubric patterns.
+func (c *Case) validate() error {
+	var errs []error
+	if c.Alert.Labels["alertname"] == "" {
+		errs = append(errs, errors.New("alert has no alertname label"))
+	}
+	for i, f := range c.Fixtures {
+		if f.Tool == "" {
+			errs = append(errs, fmt.Errorf("fixture %d: tool is required", i))
+		}
+		if len(f.Input) > 0 && !json.Valid(f.Input) {
+			errs = append(errs, fmt.Errorf("fixture %d: input is not valid JSON", i))
+		}
+	}
+	if len(c.Expect.Keywords) == 0 && len(c.Expect.Forbidden) == 0 && len(c.Expect.Rubric) == 0 {
+		errs = append(errs, errors.New("expect has no keywords, forbidden terms or rubric"))
+	}
+	for i := range c.Expect.Rubric {
+		cr := &c.Expect.Rubric[i]
+		if cr.Criterion == "" {
+			errs = append(errs, fmt.Errorf("rubric %d: criterion is required", i))
+		}
+		if cr.Weight < 0 {
+			errs = append(errs, fmt.Errorf("rubric %d: weight %g must not be negative", i, cr.Weight))
+		}
+		if cr.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + cr.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rubric %d: pattern: %w", i, err))
+			continue
+		}
+		cr.re = re
+	}
+	return errors.Join(errs...)
+}
+
+// fixtures returns the case's fixtures followed by those taken from its
+// recorded conversation.
+func (c *Case) fixtures() []replay.ToolCall {
+	out := slices.Clone(c.Fixtures)
+	if c.Recorded == nil {
+		return out
+	}
+	calls := map[string]triage.ContentBlock{}
+	for _, turn := range c.Recorded.Turns {
+		for _, b := range turn.Content {
+			switch b.Type {
+			case "tool_use":
+				calls[b.ID] = b
+			case "tool_result":
+				use, ok := calls[b.ToolUseID]
+				if !ok {
+					continue
+				}
+				f := replay.ToolCall{Tool: use.Name, Input: use.Input}
+				if b.IsError {
+					f.Error = strings.TrimPrefix(b.Content, "tool error: ")
+				} else {
+					f.Output = replay.OutputJSON(b.Content)
+				}
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+// replayed reports whether the case runs against recorded tool outputs.
+func (c *Case) replayed() bool {
+	return len(c.Fixtures) > 0 || c.Recorded != nil
+}
@@ -0,0 +1,157 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// diskFullCase investigates with one metrics query and names the full disk.
+const diskFullCase = `{
+	"alert": {"status": "firing", "labels": {"alertname": "DiskFull", "instance": "db-1"}},
+	"fixtures": [
+		{"tool": "query_metrics", "input": {"query": "node_filesystem_avail_bytes"}, "output": {"series": 1, "value": "0"}}
+	],
+	"responses": [
+		{"content": [{"type": "tool_use", "id": "tu-1", "name": "query_metrics", "input": {"query":"node_filesystem_avail_bytes"}}], "stop_reason": "tool_use"},
+		{"content": [{"type": "text", "text": "Root cause: the WAL volume on db-1 is full."}], "stop_reason": "end_turn", "model": "claude-test"}
+	],
+	"expect": {
+		"keywords": ["WAL", "db-1"],
+		"forbidden": ["network"],
+		"rubric": [{"criterion": "Names the full volume", "pattern": "volume.*full"}]
+	}
+}`
+
+func writeCorpus(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadCorpus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      []string
+		errSubstr string
+	}{
+		{
+			name:  "named by file",
+			files: map[string]string{"b-disk.json": diskFullCase, "a-disk.json": strings.Replace(diskFullCase, `{`, `{"name": "first",`, 1), "notes.txt": "ignored"},
+			want:  []string{"first", "b-disk"},
+		},
+		{name: "empty", files: map[string]string{}, errSubstr: "no .json cases"},
+		{name: "unknown field", files: map[string]string{"x.json": `{"alert": {}, "expected": {}}`}, errSubstr: "unknown field"},
+		{
+			name:      "invalid case",
+			files:     map[string]string{"x.json": `{"alert": {"labels": {}}, "fixtures": [{"output": 1}], "expect": {"rubric": [{"criterion": "c", "pattern": "("}]}}`},
+			errSubstr: "alertname",
+		},
+		{
+			name:      "duplicate name",
+			files:     map[string]string{"a.json": strings.Replace(diskFullCase, `{`, `{"name": "b",`, 1), "b.json": diskFullCase},
+			errSubstr: "already used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cases, err := LoadCorpus(writeCorpus(t, tt.files))
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, c := range cases {
+				names = append(names, c.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("cases = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaseFixtures_FromRecorded(t *testing.T) {
+	t.Parallel()
+
+	c := &Case{Recorded: &triage.Conversation{Turns: []triage.Turn{
+		{Role: "assistant", Content: []triage.ContentBlock{
+			{Type: "tool_use", ID: "a", Name: "query_logs", Input: json.RawMessage(`{"query":"{app=\"api\"}"}`)},
+			{Type: "tool_use", ID: "b", Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)},
+		}},
+		{Role: "user", Content: []triage.ContentBlock{
+			{Type: "tool_result", ToolUseID: "a", Content: "plain text"},
+			{Type: "tool_result", ToolUseID: "b", Content: "tool error: prometheus unreachable", IsError: true},
+		}},
+	}}}
+	r := registry(c, []tools.Tool{tools.NewPrometheusQuery("", "")})
+
+	metrics, _ := r.Get("query_metrics")
+	if _, err := metrics.Execute(context.Background(), json.RawMessage(`{ "query": "up" }`)); err == nil || err.Error() != "prometheus unreachable" {
+		t.Errorf("recorded error = %v", err)
+	}
+	if _, err := metrics.Execute(context.Background(), json.RawMessage(`{"query":"down"}`)); err == nil || !strings.Contains(err.Error(), "no recorded output") {
+		t.Errorf("unrecorded input: err = %v", err)
+	}
+	logs, ok := r.Get("query_logs")
+	if !ok {
+		t.Fatal("query_logs is only in the recording but was not offered")
+	}
+	out, err := logs.Execute(context.Background(), json.RawMessage(`{"query":"{app=\"api\"}"}`))
+	if err != nil || string(out) != `"plain text"` {
+		t.Errorf("recorded output = %s, %v", out, err)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+
+	cases, err := LoadCorpus(writeCorpus(t, map[string]string{
+		"disk.json":     diskFullCase,
+		"unscored.json": `{"alert": {"labels": {"alertname": "Quiet"}}, "expect": {"keywords": ["x"]}}`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{Tools: []tools.Tool{tools.NewPrometheusQuery("http://unused.invalid", "")}, Scorers: []Scorer{Keywords{}, Rubric{}}}
+	rep := r.Run(context.Background(), cases, Variant{Name: "baseline"})
+
+	if len(rep.Cases) != 2 || rep.Completed != 1 {
+		t.Fatalf("report = %+v, want two cases with one completed", rep)
+	}
+	disk := rep.Cases[0]
+	if disk.Status != triage.StatusComplete || disk.Score != 1 || disk.ToolCalls != 1 {
+		t.Errorf("disk case = %+v, want complete with score 1 after one replayed tool call", disk)
+	}
+	if rep.Model != "claude-test" {
+		t.Errorf("model = %q, want the recorded model", rep.Model)
+	}
+	if quiet := rep.Cases[1]; quiet.Error == "" || quiet.Score != 0 {
+		t.Errorf("case without responses = %+v, want an error and score 0", quiet)
+	}
+	if rep.Score != 0.5 {
+		t.Errorf("report score = %g, want 0.5", rep.Score)
+	}
+}
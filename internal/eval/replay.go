@@ -0,0 +1,24 @@
+package eval
+
+import (
+	"github.com/linnemanlabs/vigil/internal/replay"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// registry returns the tools a case runs with: base as is, or replaying the
+// case's fixtures under base's definitions. Tools only named by fixtures, such
+// as MCP tools, are offered with a permissive schema.
+func registry(c *Case, base []tools.Tool) *tools.Registry {
+	if c.replayed() {
+		defs := make([]tools.ToolDef, 0, len(base))
+		for _, t := range base {
+			defs = append(defs, tools.ToolDef{Name: t.Name(), Description: t.Description(), InputSchema: t.Parameters()})
+		}
+		return replay.NewRegistry(defs, c.fixtures())
+	}
+	r := tools.NewRegistry()
+	for _, t := range base {
+		r.Register(t)
+	}
+	return r
+}
@@ -0,0 +1,166 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// CaseResult is how one case fared under a variant.
+type CaseResult struct {
+	Name      string        `json:"name"`
+	Status    triage.Status `json:"status"`
+	Analysis  string        `json:"analysis,omitempty"`
+	ToolsUsed []string      `json:"tools_used,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	// Score is the mean of the scorers' values, 0 for a case that did not
+	// complete.
+	Score     float64          `json:"score"`
+	Scores    map[string]Score `json:"scores,omitempty"`
+	Duration  float64          `json:"duration_seconds"`
+	TokensIn  int              `json:"tokens_in"`
+	TokensOut int              `json:"tokens_out"`
+	ToolCalls int              `json:"tool_calls"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// Report is the outcome of running a corpus with one variant.
+type Report struct {
+	Variant string       `json:"variant"`
+	Model   string       `json:"model,omitempty"`
+	Cases   []CaseResult `json:"cases"`
+	// Score is the mean case score.
+	Score     float64 `json:"score"`
+	Completed int     `json:"completed"`
+	TokensIn  int     `json:"tokens_in"`
+	TokensOut int     `json:"tokens_out"`
+}
+
+func (r *Report) add(cr CaseResult) {
+	r.Cases = append(r.Cases, cr)
+	if cr.Status == triage.StatusComplete {
+		r.Completed++
+	}
+	if r.Model == "" {
+		r.Model = cr.Model
+	}
+	r.TokensIn += cr.TokensIn
+	r.TokensOut += cr.TokensOut
+	var sum float64
+	for _, c := range r.Cases {
+		sum += c.Score
+	}
+	r.Score = sum / float64(len(r.Cases))
+}
+
+// Comparison diffs the reports of a baseline and a candidate variant. Deltas
+// are candidate minus baseline, so a negative score delta is a regression.
+type Comparison struct {
+	Baseline  *Report    `json:"baseline"`
+	Candidate *Report    `json:"candidate"`
+	Cases     []CaseDiff `json:"cases"`
+	// Tolerance is how far a case score may drop before it counts as a
+	// regression.
+	Tolerance    float64 `json:"tolerance"`
+	Regressions  int     `json:"regressions"`
+	Improvements int     `json:"improvements"`
+	ScoreDelta   float64 `json:"score_delta"`
+	TokensDelta  int     `json:"tokens_delta"`
+}
+
+// CaseDiff compares one case across the variants.
+type CaseDiff struct {
+	Name      string  `json:"name"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	Delta     float64 `json:"delta"`
+	// Change is "regressed", "improved" or empty.
+	Change string `json:"change,omitempty"`
+}
+
+// Case changes.
+const (
+	ChangeRegressed = "regressed"
+	ChangeImproved  = "improved"
+)
+
+// Compare diffs two reports of the same corpus. Cases only run by one side
+// are compared against a score of 0.
+func Compare(baseline, candidate *Report, tolerance float64) *Comparison {
+	c := &Comparison{
+		Baseline:    baseline,
+		Candidate:   candidate,
+		Tolerance:   tolerance,
+		ScoreDelta:  candidate.Score - baseline.Score,
+		TokensDelta: candidate.TokensIn + candidate.TokensOut - baseline.TokensIn - baseline.TokensOut,
+	}
+	other := make(map[string]float64, len(candidate.Cases))
+	for _, cr := range candidate.Cases {
+		other[cr.Name] = cr.Score
+	}
+	seen := make(map[string]bool, len(baseline.Cases))
+	for _, cr := range baseline.Cases {
+		seen[cr.Name] = true
+		c.add(cr.Name, cr.Score, other[cr.Name])
+	}
+	for _, cr := range candidate.Cases {
+		if !seen[cr.Name] {
+			c.add(cr.Name, 0, cr.Score)
+		}
+	}
+	return c
+}
+
+func (c *Comparison) add(name string, base, cand float64) {
+	d := CaseDiff{Name: name, Baseline: base, Candidate: cand, Delta: cand - base}
+	switch {
+	case d.Delta < -c.Tolerance:
+		d.Change = ChangeRegressed
+		c.Regressions++
+	case d.Delta > c.Tolerance:
+		d.Change = ChangeImproved
+		c.Improvements++
+	}
+	c.Cases = append(c.Cases, d)
+}
+
+// WriteText renders the comparison as a table followed by totals.
+func (c *Comparison) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CASE\t%s\t%s\tDELTA\t\n", label(c.Baseline), label(c.Candidate))
+	for _, d := range c.Cases {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%+.2f\t%s\n", d.Name, d.Baseline, d.Candidate, d.Delta, d.Change)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%.2f\t%.2f\t%+.2f\t\n", c.Baseline.Score, c.Candidate.Score, c.ScoreDelta)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\ncompleted: %d -> %d of %d\ntokens: %d -> %d (%+d)\nregressions: %d, improvements: %d (tolerance %.2f)\n",
+		c.Baseline.Completed, c.Candidate.Completed, max(len(c.Baseline.Cases), len(c.Candidate.Cases)),
+		c.Baseline.TokensIn+c.Baseline.TokensOut, c.Candidate.TokensIn+c.Candidate.TokensOut, c.TokensDelta,
+		c.Regressions, c.Improvements, c.Tolerance)
+	if err != nil {
+		return err
+	}
+	for _, r := range []*Report{c.Baseline, c.Candidate} {
+		for _, cr := range r.Cases {
+			if cr.Error == "" {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s: %s\n", r.Variant, cr.Name, cr.Error); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// label names a report's column.
+func label(r *Report) string {
+	if r.Model == "" || r.Model == r.Variant {
+		return r.Variant
+	}
+	return r.Variant + " (" + r.Model + ")"
+}
@@ -0,0 +1,54 @@
+package eval
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func report(variant string, scores map[string]float64, order ...string) *Report {
+	r := &Report{Variant: variant}
+	for _, name := range order {
+		r.add(CaseResult{Name: name, Status: triage.StatusComplete, Score: scores[name], TokensIn: 100, TokensOut: 10})
+	}
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	base := report("baseline", map[string]float64{"a": 1, "b": 0.5, "c": 0.8}, "a", "b", "c")
+	cand := report("candidate", map[string]float64{"a": 0.5, "b": 1, "c": 0.75, "d": 1}, "a", "b", "c", "d")
+	cand.Cases[2].Error = "judge: overloaded"
+
+	c := Compare(base, cand, 0.1)
+
+	want := map[string]string{"a": ChangeRegressed, "b": ChangeImproved, "c": "", "d": ChangeImproved}
+	if len(c.Cases) != len(want) {
+		t.Fatalf("cases = %+v", c.Cases)
+	}
+	for _, d := range c.Cases {
+		if d.Change != want[d.Name] {
+			t.Errorf("case %s change = %q, want %q", d.Name, d.Change, want[d.Name])
+		}
+	}
+	if c.Regressions != 1 || c.Improvements != 2 {
+		t.Errorf("regressions %d, improvements %d; want 1 and 2", c.Regressions, c.Improvements)
+	}
+	if c.TokensDelta != 110 {
+		t.Errorf("tokens delta = %d, want 110", c.TokensDelta)
+	}
+
+	var buf bytes.Buffer
+	if err := c.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"a ", "-0.50", "regressed", "TOTAL", "regressions: 1, improvements: 2", "candidate: c: judge: overloaded"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("report missing %q:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/replay"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Variant is one side of a comparison: a model and the prompt it runs with.
+type Variant struct {
+	Name string
+	// Provider answers the triages. Nil replays each case's recorded
+	// responses.
+	Provider triage.Provider
+	// Model labels the variant in reports; replayed runs report the model
+	// recorded in their responses.
+	Model string
+	// Instructions are appended to the system prompt, as a routing profile's
+	// would be.
+	Instructions string
+	Budget       triage.Budget
+}
+
+// Runner replays a corpus through the engine.
+type Runner struct {
+	// Tools are the live tools, whose definitions replayed cases also use.
+	Tools   []tools.Tool
+	Scorers []Scorer
+	Logger  log.Logger
+}
+
+// Run triages every case with v, one at a time, and scores the analyses. A
+// case that fails to run or score is reported with its error rather than
+// stopping the run.
+func (r *Runner) Run(ctx context.Context, cases []*Case, v Variant) *Report {
+	logger := r.Logger
+	if logger == nil {
+		logger = log.Nop()
+	}
+	rep := &Report{Variant: v.Name, Model: v.Model, Cases: make([]CaseResult, 0, len(cases))}
+	for _, c := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+		cr := r.runCase(ctx, logger, c, v)
+		logger.Info(ctx, "eval case complete", "variant", v.Name, "case", c.Name, "status", cr.Status, "score", cr.Score)
+		rep.add(cr)
+	}
+	return rep
+}
+
+func (r *Runner) runCase(ctx context.Context, logger log.Logger, c *Case, v Variant) CaseResult {
+	cr := CaseResult{Name: c.Name, Scores: map[string]Score{}}
+	provider := v.Provider
+	if provider == nil {
+		if len(c.Responses) == 0 {
+			cr.Status = triage.StatusError
+			cr.Error = "no recorded responses to replay"
+			return cr
+		}
+		provider = replay.NewProvider(c.Responses)
+	}
+
+	engine := triage.NewEngine(provider, registry(c, r.Tools), logger, triage.EngineHooks{}, noop.NewTracerProvider(),
+		triage.WithDefaultBudget(v.Budget))
+	al := c.Alert
+	rr := engine.Run(ctx, "eval-"+c.Name, &al, nil, triage.WithInstructions(v.Instructions))
+
+	cr.Status = rr.Status
+	cr.Analysis = rr.Analysis
+	cr.ToolsUsed = rr.ToolsUsed
+	cr.Model = rr.Model
+	cr.Duration = rr.Duration
+	cr.TokensIn = rr.InputTokensUsed
+	cr.TokensOut = rr.OutputTokensUsed
+	cr.ToolCalls = rr.ToolCalls
+	if rr.Status != triage.StatusComplete {
+		// Only a finished analysis is worth scoring; an empty one would pass
+		// every forbidden term.
+		cr.Error = fmt.Sprintf("triage %s: %s", rr.Status, rr.Analysis)
+		return cr
+	}
+
+	var sum float64
+	for _, s := range r.Scorers {
+		score, ok, err := s.Score(ctx, c, rr.Analysis)
+		if err != nil {
+			cr.Error = fmt.Sprintf("%s: %v", s.Name(), err)
+			continue
+		}
+		if ok {
+			cr.Scores[s.Name()] = score
+			sum += score.Value
+		}
+	}
+	if len(cr.Scores) > 0 {
+		cr.Score = sum / float64(len(cr.Scores))
+	}
+	return cr
+}
@@ -0,0 +1,197 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Score is one scorer's verdict on an analysis.
+type Score struct {
+	// Value runs from 0 (nothing expected was found) to 1.
+	Value float64 `json:"value"`
+	// Findings explain what cost the analysis points.
+	Findings []string `json:"findings,omitempty"`
+}
+
+// Scorer grades the analysis of a case. Score reports ok false when the case
+// gives the scorer nothing to check.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, c *Case, analysis string) (s Score, ok bool, err error)
+}
+
+// Keywords scores the share of expected keywords in the analysis. Any
+// forbidden term scores 0.
+type Keywords struct{}
+
+// Name implements Scorer.
+func (Keywords) Name() string { return "keywords" }
+
+// Score implements Scorer.
+func (Keywords) Score(_ context.Context, c *Case, analysis string) (Score, bool, error) {
+	exp := c.Expect
+	if len(exp.Keywords) == 0 && len(exp.Forbidden) == 0 {
+		return Score{}, false, nil
+	}
+	text := strings.ToLower(analysis)
+	s := Score{Value: 1}
+	found := 0
+	for _, k := range exp.Keywords {
+		if strings.Contains(text, strings.ToLower(k)) {
+			found++
+		} else {
+			s.Findings = append(s.Findings, "missing keyword "+k)
+		}
+	}
+	if len(exp.Keywords) > 0 {
+		s.Value = float64(found) / float64(len(exp.Keywords))
+	}
+	for _, k := range exp.Forbidden {
+		if strings.Contains(text, strings.ToLower(k)) {
+			s.Value = 0
+			s.Findings = append(s.Findings, "forbidden term "+k)
+		}
+	}
+	return s, true, nil
+}
+
+// Rubric scores the weighted share of rubric criteria whose pattern matches
+// the analysis. Criteria without a pattern are left to Judge.
+type Rubric struct{}
+
+// Name implements Scorer.
+func (Rubric) Name() string { return "rubric" }
+
+// Score implements Scorer.
+func (Rubric) Score(_ context.Context, c *Case, analysis string) (Score, bool, error) {
+	var s Score
+	var met, total float64
+	for i := range c.Expect.Rubric {
+		cr := &c.Expect.Rubric[i]
+		if cr.re == nil {
+			continue
+		}
+		total += cr.weight()
+		if cr.re.MatchString(analysis) {
+			met += cr.weight()
+		} else {
+			s.Findings = append(s.Findings, "unmet: "+cr.Criterion)
+		}
+	}
+	if total == 0 {
+		return Score{}, false, nil
+	}
+	s.Value = met / total
+	return s, true, nil
+}
+
+// judgeMaxTokens bounds the judge's reply, which is a short JSON verdict.
+const judgeMaxTokens = 1024
+
+const judgePrompt = `You grade infrastructure alert triage analyses written by an AI agent.
+You are given the alert, the analysis and numbered rubric criteria. Decide for
+each criterion whether the analysis meets it. Judge only what the analysis
+says, not whether you agree with the investigation.
+
+Reply with only a JSON object, no other text:
+{"criteria":[{"index":1,"met":true,"reason":"one short sentence"}]}`
+
+// Judge has a model grade the analysis against the rubric criteria that have
+// no pattern.
+type Judge struct {
+	Provider triage.Provider
+}
+
+// Name implements Scorer.
+func (Judge) Name() string { return "judge" }
+
+// Score implements Scorer.
+func (j Judge) Score(ctx context.Context, c *Case, analysis string) (Score, bool, error) {
+	var criteria []*Criterion
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Alert: %s\n", c.Alert.Labels["alertname"])
+	if summary := c.Alert.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&prompt, "Summary: %s\n", summary)
+	}
+	fmt.Fprintf(&prompt, "\nAnalysis:\n%s\n\nCriteria:\n", analysis)
+	for i := range c.Expect.Rubric {
+		cr := &c.Expect.Rubric[i]
+		if cr.re != nil {
+			continue
+		}
+		criteria = append(criteria, cr)
+		fmt.Fprintf(&prompt, "%d. %s\n", len(criteria), cr.Criterion)
+	}
+	if len(criteria) == 0 {
+		return Score{}, false, nil
+	}
+
+	resp, err := j.Provider.Send(ctx, &triage.LLMRequest{
+		MaxTokens: judgeMaxTokens,
+		System:    judgePrompt,
+		Messages: []triage.Message{{Role: "user", Content: []triage.ContentBlock{
+			{Type: "text", Text: prompt.String()},
+		}}},
+	})
+	if err != nil {
+		return Score{}, false, fmt.Errorf("judge: %w", err)
+	}
+	verdicts, err := parseVerdicts(resp)
+	if err != nil {
+		return Score{}, false, err
+	}
+
+	var s Score
+	var met, total float64
+	for i, cr := range criteria {
+		total += cr.weight()
+		v, ok := verdicts[i+1]
+		switch {
+		case !ok:
+			s.Findings = append(s.Findings, "not graded: "+cr.Criterion)
+		case v.Met:
+			met += cr.weight()
+		default:
+			s.Findings = append(s.Findings, fmt.Sprintf("unmet: %s (%s)", cr.Criterion, v.Reason))
+		}
+	}
+	s.Value = met / total
+	return s, true, nil
+}
+
+type verdict struct {
+	Index  int    `json:"index"`
+	Met    bool   `json:"met"`
+	Reason string `json:"reason"`
+}
+
+// parseVerdicts extracts the judge's JSON reply, keyed by criterion number.
+func parseVerdicts(resp *triage.LLMResponse) (map[int]verdict, error) {
+	var text strings.Builder
+	for _, b := range resp.Content {
+		if b.Type == "text" {
+			text.WriteString(b.Text)
+		}
+	}
+	s := text.String()
+	start, end := strings.Index(s, "{"), strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("judge: reply has no JSON object")
+	}
+	var reply struct {
+		Criteria []verdict `json:"criteria"`
+	}
+	if err := json.Unmarshal([]byte(s[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("judge: parse reply: %w", err)
+	}
+	out := make(map[int]verdict, len(reply.Criteria))
+	for _, v := range reply.Criteria {
+		out[v.Index] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,132 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestKeywords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		expect   Expect
+		analysis string
+		want     float64
+		ok       bool
+	}{
+		{name: "all found", expect: Expect{Keywords: []string{"OOM", "api"}}, analysis: "The API pods were oomkilled: OOM.", want: 1, ok: true},
+		{name: "half found", expect: Expect{Keywords: []string{"OOM", "disk"}}, analysis: "OOM", want: 0.5, ok: true},
+		{name: "forbidden", expect: Expect{Keywords: []string{"OOM"}, Forbidden: []string{"network"}}, analysis: "OOM after a network blip", want: 0, ok: true},
+		{name: "forbidden only", expect: Expect{Forbidden: []string{"network"}}, analysis: "OOM", want: 1, ok: true},
+		{name: "nothing to check", expect: Expect{Rubric: []Criterion{{Criterion: "x"}}}, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, ok, err := Keywords{}.Score(context.Background(), &Case{Expect: tt.expect}, tt.analysis)
+			if err != nil || ok != tt.ok || s.Value != tt.want {
+				t.Errorf("Score = %+v, %v, %v; want %g, %v", s, ok, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRubric(t *testing.T) {
+	t.Parallel()
+
+	c := &Case{Alert: alert.Alert{Labels: map[string]string{"alertname": "A"}}, Expect: Expect{Rubric: []Criterion{
+		{Criterion: "Names the deploy", Pattern: `deploy(ment)?\s+v\d+`, Weight: 3},
+		{Criterion: "Suggests a rollback", Pattern: "roll ?back"},
+		{Criterion: "Judged by a model"},
+	}}}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, ok, err := Rubric{}.Score(context.Background(), c, "Started with Deployment v42.")
+	if err != nil || !ok {
+		t.Fatalf("Score: ok %v, err %v", ok, err)
+	}
+	if s.Value != 0.75 {
+		t.Errorf("value = %g, want 0.75 (weight 3 of 4)", s.Value)
+	}
+	if len(s.Findings) != 1 || !strings.Contains(s.Findings[0], "rollback") {
+		t.Errorf("findings = %v", s.Findings)
+	}
+}
+
+// judgeProvider answers with a fixed reply and keeps the request.
+type judgeProvider struct {
+	reply string
+	err   error
+	req   *triage.LLMRequest
+}
+
+func (p *judgeProvider) Send(_ context.Context, req *triage.LLMRequest) (*triage.LLMResponse, error) {
+	p.req = req
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &triage.LLMResponse{Content: []triage.ContentBlock{{Type: "text", Text: p.reply}}, StopReason: triage.StopEnd}, nil
+}
+
+func TestJudge(t *testing.T) {
+	t.Parallel()
+
+	c := &Case{Alert: alert.Alert{Labels: map[string]string{"alertname": "A"}}, Expect: Expect{Rubric: []Criterion{
+		{Criterion: "Checked by pattern", Pattern: "x"},
+		{Criterion: "Explains the impact", Weight: 2},
+		{Criterion: "Gives a next step"},
+	}}}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		reply     string
+		sendErr   error
+		want      float64
+		errSubstr string
+	}{
+		{
+			name:  "graded",
+			reply: "Here you go:\n" + `{"criteria":[{"index":1,"met":true,"reason":"ok"},{"index":2,"met":false,"reason":"no step"}]}`,
+			want:  2.0 / 3,
+		},
+		{name: "ungraded criterion", reply: `{"criteria":[{"index":2,"met":true}]}`, want: 1.0 / 3},
+		{name: "not json", reply: "looks good", errSubstr: "no JSON"},
+		{name: "provider error", sendErr: errors.New("overloaded"), errSubstr: "overloaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := &judgeProvider{reply: tt.reply, err: tt.sendErr}
+			s, ok, err := Judge{Provider: p}.Score(context.Background(), c, "analysis text")
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil || !ok {
+				t.Fatalf("Score: ok %v, err %v", ok, err)
+			}
+			if s.Value != tt.want {
+				t.Errorf("value = %g, want %g", s.Value, tt.want)
+			}
+			prompt := p.req.Messages[0].Content[0].Text
+			if strings.Contains(prompt, "Checked by pattern") || !strings.Contains(prompt, "2. Gives a next step") {
+				t.Errorf("judge prompt should number only unpatterned criteria:\n%s", prompt)
+			}
+		})
+	}
+}
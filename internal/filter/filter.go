@@ -0,0 +1,216 @@
+// Package filter decides at ingestion which alerts are triaged, from
+// operator-defined rules of label and annotation matchers.
+//
+// Some alerts are never worth an LLM run, such as the Watchdog heartbeat or
+// anything from a dev namespace, and the routes that deliver them are not
+// always Vigil's to change. Rules are checked in order and the first match
+// decides; alerts no rule matches are triaged as usual.
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Rule is one entry in the filter config.
+type Rule struct {
+	// Name identifies the rule in logs, metrics, and submit decisions.
+	Name string `json:"name"`
+
+	// Labels and Annotations are matchers in Alertmanager syntax, such as
+	// alertname="Watchdog" or namespace=~"dev|staging". An alert must
+	// satisfy all of them; a missing label or annotation has the empty value.
+	Labels      []string `json:"labels"`
+	Annotations []string `json:"annotations"`
+
+	// Action is "triage", "skip", or "downgrade".
+	Action string `json:"action"`
+
+	// DryRun logs and counts matches without applying the action.
+	DryRun bool `json:"dry_run"`
+}
+
+// Config is the filter file format.
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadConfig reads and validates a JSON filter config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read filter config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse filter config %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("filter config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem in the config.
+func (c *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	for i, r := range c.Rules {
+		if r.Name == "" {
+			errs = append(errs, fmt.Errorf("rules[%d]: name is required", i))
+		} else if names[r.Name] {
+			errs = append(errs, fmt.Errorf("rule %q: duplicate name", r.Name))
+		}
+		names[r.Name] = true
+
+		switch r.Action {
+		case triage.FilterTriage, triage.FilterSkip, triage.FilterDowngrade:
+		default:
+			errs = append(errs, fmt.Errorf("rule %q: action must be triage, skip, or downgrade, got %q", r.Name, r.Action))
+		}
+		if len(r.Labels) == 0 && len(r.Annotations) == 0 {
+			errs = append(errs, fmt.Errorf("rule %q: at least one label or annotation matcher is required", r.Name))
+		}
+		for _, m := range r.Labels {
+			if _, err := ParseMatcher(m); err != nil {
+				errs = append(errs, fmt.Errorf("rule %q: labels: %w", r.Name, err))
+			}
+		}
+		for _, m := range r.Annotations {
+			if _, err := ParseMatcher(m); err != nil {
+				errs = append(errs, fmt.Errorf("rule %q: annotations: %w", r.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Filter matches alerts against the rules. It satisfies triage.Filter.
+type Filter struct {
+	rules []rule
+}
+
+type rule struct {
+	triage.FilterRule
+	labels      []Matcher
+	annotations []Matcher
+}
+
+// New builds a Filter from a config.
+func New(c Config) (*Filter, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	f := &Filter{rules: make([]rule, 0, len(c.Rules))}
+	for _, r := range c.Rules {
+		cr := rule{FilterRule: triage.FilterRule{Name: r.Name, Action: r.Action, DryRun: r.DryRun}}
+		for _, s := range r.Labels {
+			m, _ := ParseMatcher(s) // checked by Validate
+			cr.labels = append(cr.labels, m)
+		}
+		for _, s := range r.Annotations {
+			m, _ := ParseMatcher(s)
+			cr.annotations = append(cr.annotations, m)
+		}
+		f.rules = append(f.rules, cr)
+	}
+	return f, nil
+}
+
+// Match returns the first rule matching al, or nil.
+func (f *Filter) Match(al *alert.Alert) *triage.FilterRule {
+	for i := range f.rules {
+		r := &f.rules[i]
+		if MatchAll(r.labels, al.Labels) && MatchAll(r.annotations, al.Annotations) {
+			fr := r.FilterRule
+			return &fr
+		}
+	}
+	return nil
+}
+
+// MatchAll reports whether values satisfies every matcher. A missing value
+// matches as empty.
+func MatchAll(ms []Matcher, values map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(values[m.name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matcher is a parsed Alertmanager-style matcher.
+type Matcher struct {
+	name  string
+	op    string // =, !=, =~ or !~
+	value string
+	re    *regexp.Regexp
+}
+
+// matcherOps are checked longest first so "!=" is not read as "!" and "=".
+var matcherOps = []string{"=~", "!~", "!=", "="}
+
+// ParseMatcher parses name<op>value, where the value may be double-quoted.
+// Regular expressions are anchored at both ends, as in Alertmanager.
+func ParseMatcher(s string) (Matcher, error) {
+	i := strings.IndexAny(s, "=!")
+	if i < 0 {
+		return Matcher{}, fmt.Errorf("matcher %q: want name=value, name!=value, name=~regex, or name!~regex", s)
+	}
+	m := Matcher{name: strings.TrimSpace(s[:i])}
+	if m.name == "" {
+		return Matcher{}, fmt.Errorf("matcher %q: name is empty", s)
+	}
+	rest := s[i:]
+	for _, op := range matcherOps {
+		if strings.HasPrefix(rest, op) {
+			m.op, rest = op, rest[len(op):]
+			break
+		}
+	}
+	if m.op == "" {
+		return Matcher{}, fmt.Errorf("matcher %q: want name=value, name!=value, name=~regex, or name!~regex", s)
+	}
+	m.value = strings.TrimSpace(rest)
+	if strings.HasPrefix(m.value, `"`) {
+		v, err := strconv.Unquote(m.value)
+		if err != nil {
+			return Matcher{}, fmt.Errorf("matcher %q: bad quoted value: %w", s, err)
+		}
+		m.value = v
+	}
+	if m.op == "=~" || m.op == "!~" {
+		re, err := regexp.Compile("^(?:" + m.value + ")$")
+		if err != nil {
+			return Matcher{}, fmt.Errorf("matcher %q: %w", s, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches reports whether v satisfies the matcher.
+func (m Matcher) Matches(v string) bool {
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	case "=~":
+		return m.re.MatchString(v)
+	default:
+		return !m.re.MatchString(v)
+	}
+}
@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "filter.json")
+	body := `{"rules":[
+		{"name":"watchdog","labels":["alertname=\"Watchdog\""],"action":"skip"},
+		{"name":"dev","labels":["namespace=~\"dev-.*\""],"action":"downgrade","dry_run":true}
+	]}`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(c.Rules) != 2 || c.Rules[0].Action != triage.FilterSkip || !c.Rules[1].DryRun {
+		t.Errorf("config = %+v", c)
+	}
+}
+
+func TestLoadConfig_UnknownField(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "filter.json")
+	if err := os.WriteFile(path, []byte(`{"rules":[{"name":"a","labels":["a=b"],"action":"skip","receivers":["x"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "receivers") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			cfg: Config{Rules: []Rule{
+				{Name: "a", Labels: []string{"alertname=Watchdog"}, Action: "skip"},
+				{Name: "b", Annotations: []string{`runbook!~".+"`}, Action: "downgrade"},
+			}},
+		},
+		{
+			name:    "empty rule",
+			cfg:     Config{Rules: []Rule{{}}},
+			wantErr: []string{"name is required", "action must be triage, skip, or downgrade", "at least one label or annotation matcher"},
+		},
+		{
+			name: "duplicate name",
+			cfg: Config{Rules: []Rule{
+				{Name: "a", Labels: []string{"x=y"}, Action: "skip"},
+				{Name: "a", Labels: []string{"x=z"}, Action: "skip"},
+			}},
+			wantErr: []string{"duplicate name"},
+		},
+		{
+			name:    "bad matchers",
+			cfg:     Config{Rules: []Rule{{Name: "a", Labels: []string{"alertname", "=x", `ns=~"("`}, Annotations: []string{`a="b`}, Action: "skip"}}},
+			wantErr: []string{`matcher "alertname"`, "name is empty", "missing closing )", "bad quoted value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate returned nil, want errors")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	t.Parallel()
+
+	f, err := New(Config{Rules: []Rule{
+		{Name: "watchdog", Labels: []string{`alertname="Watchdog"`}, Action: "skip"},
+		{Name: "dev-critical", Labels: []string{"namespace=~dev|staging", "severity=critical"}, Action: "triage"},
+		{Name: "dev", Labels: []string{"namespace=~dev|staging"}, Action: "skip"},
+		{Name: "no-runbook", Annotations: []string{"runbook_url!~.+"}, Labels: []string{"team!=sre"}, Action: "downgrade", DryRun: true},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{"exact", map[string]string{"alertname": "Watchdog"}, nil, "watchdog"},
+		{"exception first", map[string]string{"namespace": "dev", "severity": "critical"}, nil, "dev-critical"},
+		{"regex", map[string]string{"namespace": "staging"}, map[string]string{"runbook_url": "x"}, "dev"},
+		{"regex is anchored", map[string]string{"namespace": "devops"}, map[string]string{"runbook_url": "x"}, ""},
+		{"missing annotation is empty", map[string]string{"team": "web"}, nil, "no-runbook"},
+		{"negative label", map[string]string{"team": "sre"}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := f.Match(&alert.Alert{Labels: tt.labels, Annotations: tt.annotations})
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("Match = %+v, want no rule", got)
+			case tt.want != "" && (got == nil || got.Name != tt.want):
+				t.Errorf("Match = %+v, want rule %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := f.Match(&alert.Alert{Labels: map[string]string{"team": "web"}}); got == nil || got.Action != triage.FilterDowngrade || !got.DryRun {
+		t.Errorf("Match = %+v, want the dry-run downgrade rule", got)
+	}
+}
@@ -0,0 +1,192 @@
+// Package genai exports triage LLM calls as OpenTelemetry gen_ai events, the
+// log records LLM observability platforms such as Langfuse, Phoenix and
+// Braintrust ingest over OTLP. Each provider call emits the prompt it was
+// sent (system, user, assistant and tool messages) and the choice it got
+// back, correlated with the call's llm.call span.
+//
+// Prompts hold alert labels and tool output, so how much message content
+// leaves Vigil is controlled by a Capture mode. Roles, tool names, tool call
+// ids and finish reasons are always exported.
+package genai
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	otellog "go.opentelemetry.io/otel/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Capture controls how much message content is exported.
+type Capture string
+
+// Capture modes.
+const (
+	CaptureOff       Capture = "off"
+	CaptureTruncated Capture = "truncated"
+	CaptureFull      Capture = "full"
+)
+
+// truncateAt is how many bytes of each piece of content CaptureTruncated
+// keeps.
+const truncateAt = 1024
+
+// ParseCapture validates a capture mode.
+func ParseCapture(s string) (Capture, error) {
+	switch c := Capture(s); c {
+	case CaptureOff, CaptureTruncated, CaptureFull:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown capture mode %q (must be off, truncated or full)", s)
+}
+
+// Exporter is a triage.LLMObserver that emits gen_ai events.
+type Exporter struct {
+	logger  otellog.Logger
+	capture Capture
+}
+
+var _ triage.LLMObserver = (*Exporter)(nil)
+
+// New returns an exporter emitting to logger.
+func New(logger otellog.Logger, capture Capture) *Exporter {
+	return &Exporter{logger: logger, capture: capture}
+}
+
+// ObserveLLMCall emits one event per message in the request, then a
+// gen_ai.choice for the response. A failed call emits the prompt only, with
+// the error on its events.
+func (x *Exporter) ObserveLLMCall(ctx context.Context, call *triage.LLMCall) {
+	attrs := []otellog.KeyValue{
+		otellog.String("gen_ai.system", "anthropic"),
+		otellog.String("gen_ai.provider.name", "anthropic"),
+		otellog.String("vigil.triage.id", call.TriageID),
+		otellog.Int("vigil.chat.seq", call.Seq),
+	}
+	if call.Response != nil && call.Response.Model != "" {
+		attrs = append(attrs,
+			otellog.String("gen_ai.request.model", call.Response.Model),
+			otellog.String("gen_ai.response.model", call.Response.Model))
+	}
+	if call.Err != nil {
+		attrs = append(attrs, otellog.String("error.type", fmt.Sprintf("%T", call.Err)))
+	}
+
+	if call.Request.System != "" {
+		x.emit(ctx, "gen_ai.system.message", attrs, otellog.MapValue(
+			x.content(call.Request.System)...))
+	}
+	for _, msg := range call.Request.Messages {
+		switch msg.Role {
+		case "assistant":
+			x.emit(ctx, "gen_ai.assistant.message", attrs, otellog.MapValue(x.assistant(msg.Content)...))
+		default:
+			// Tool results travel in user messages; each is its own event.
+			var text []triage.ContentBlock
+			for _, b := range msg.Content {
+				if b.Type == "tool_result" {
+					x.emit(ctx, "gen_ai.tool.message", attrs, otellog.MapValue(
+						append(x.content(b.Content), otellog.String("id", b.ToolUseID))...))
+					continue
+				}
+				text = append(text, b)
+			}
+			if len(text) > 0 {
+				x.emit(ctx, "gen_ai.user.message", attrs, otellog.MapValue(x.content(joinText(text))...))
+			}
+		}
+	}
+
+	if call.Response == nil {
+		return
+	}
+	resp := call.Response
+	choice := append(attrs[:len(attrs):len(attrs)],
+		otellog.Int("gen_ai.usage.input_tokens", resp.Usage.InputTokens),
+		otellog.Int("gen_ai.usage.output_tokens", resp.Usage.OutputTokens),
+		otellog.Float64("vigil.llm.duration_seconds", call.Duration.Seconds()))
+	x.emit(ctx, "gen_ai.choice", choice, otellog.MapValue(
+		otellog.Int("index", 0),
+		otellog.String("finish_reason", string(resp.StopReason)),
+		otellog.Map("message", x.assistant(resp.Content)...),
+	))
+}
+
+// emit sends one event record.
+func (x *Exporter) emit(ctx context.Context, name string, attrs []otellog.KeyValue, body otellog.Value) {
+	var r otellog.Record
+	r.SetEventName(name)
+	r.SetSeverity(otellog.SeverityInfo)
+	r.SetBody(body)
+	r.AddAttributes(attrs...)
+	x.logger.Emit(ctx, r)
+}
+
+// assistant builds the body of an assistant message: its text and the tools
+// it called. Thinking is not exported.
+func (x *Exporter) assistant(blocks []triage.ContentBlock) []otellog.KeyValue {
+	var calls []otellog.Value
+	for _, b := range blocks {
+		if b.Type != "tool_use" {
+			continue
+		}
+		fn := []otellog.KeyValue{otellog.String("name", b.Name)}
+		if args, ok := x.text(string(b.Input)); ok {
+			fn = append(fn, otellog.String("arguments", args))
+		}
+		calls = append(calls, otellog.MapValue(
+			otellog.String("id", b.ID),
+			otellog.String("type", "function"),
+			otellog.Map("function", fn...),
+		))
+	}
+	kvs := x.content(joinText(blocks))
+	if len(calls) > 0 {
+		kvs = append(kvs, otellog.Slice("tool_calls", calls...))
+	}
+	return kvs
+}
+
+// content returns the content field for s, which is omitted when capture is
+// off or s is empty.
+func (x *Exporter) content(s string) []otellog.KeyValue {
+	if t, ok := x.text(s); ok && t != "" {
+		return []otellog.KeyValue{otellog.String("content", t)}
+	}
+	return nil
+}
+
+// text applies the capture mode to s, reporting false if it is not exported.
+func (x *Exporter) text(s string) (string, bool) {
+	switch x.capture {
+	case CaptureFull:
+		return s, true
+	case CaptureTruncated:
+		if len(s) <= truncateAt {
+			return s, true
+		}
+		cut := truncateAt
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		return s[:cut] + fmt.Sprintf("\n[truncated: %d of %d bytes shown]", cut, len(s)), true
+	}
+	return "", false
+}
+
+// joinText concatenates the text blocks among blocks.
+func joinText(blocks []triage.ContentBlock) string {
+	var s string
+	for _, b := range blocks {
+		if b.Type != "text" || b.Text == "" {
+			continue
+		}
+		if s != "" {
+			s += "\n"
+		}
+		s += b.Text
+	}
+	return s
+}
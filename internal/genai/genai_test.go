@@ -0,0 +1,204 @@
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/noop"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// recordLogger keeps every record emitted to it.
+type recordLogger struct {
+	noop.Logger
+	mu      sync.Mutex
+	records []otellog.Record
+}
+
+func (l *recordLogger) Emit(_ context.Context, r otellog.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, r)
+}
+
+func (l *recordLogger) names() []string {
+	var names []string
+	for _, r := range l.records {
+		names = append(names, r.EventName())
+	}
+	return names
+}
+
+// field returns the body field at path, descending through maps.
+func field(v otellog.Value, path ...string) (otellog.Value, bool) {
+	for _, key := range path {
+		if v.Kind() != otellog.KindMap {
+			return otellog.Value{}, false
+		}
+		i := slices.IndexFunc(v.AsMap(), func(kv otellog.KeyValue) bool { return kv.Key == key })
+		if i < 0 {
+			return otellog.Value{}, false
+		}
+		v = v.AsMap()[i].Value
+	}
+	return v, true
+}
+
+func attr(r otellog.Record, key string) string {
+	var s string
+	r.WalkAttributes(func(kv otellog.KeyValue) bool {
+		if kv.Key == key {
+			s = kv.Value.String()
+			return false
+		}
+		return true
+	})
+	return s
+}
+
+func testCall() *triage.LLMCall {
+	return &triage.LLMCall{
+		TriageID: "tri-1",
+		Seq:      1,
+		Request: &triage.LLMRequest{
+			System: "You are an SRE.",
+			Messages: []triage.Message{
+				{Role: "user", Content: []triage.ContentBlock{{Type: "text", Text: "HighLatency firing on api"}}},
+				{Role: "assistant", Content: []triage.ContentBlock{
+					{Type: "thinking", Thinking: "check metrics"},
+					{Type: "tool_use", ID: "tu-1", Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)},
+				}},
+				{Role: "user", Content: []triage.ContentBlock{{Type: "tool_result", ToolUseID: "tu-1", Content: strings.Repeat("x", 2000)}}},
+			},
+		},
+		Response: &triage.LLMResponse{
+			Content:    []triage.ContentBlock{{Type: "text", Text: "The api pods are saturated."}},
+			StopReason: "end_turn",
+			Usage:      triage.Usage{InputTokens: 120, OutputTokens: 30},
+			Model:      "claude-test",
+		},
+		Duration: 2 * time.Second,
+	}
+}
+
+func TestObserveLLMCall_Events(t *testing.T) {
+	t.Parallel()
+
+	l := &recordLogger{}
+	New(l, CaptureFull).ObserveLLMCall(context.Background(), testCall())
+
+	want := []string{"gen_ai.system.message", "gen_ai.user.message", "gen_ai.assistant.message", "gen_ai.tool.message", "gen_ai.choice"}
+	if got := l.names(); !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for _, r := range l.records {
+		if attr(r, "vigil.triage.id") != "tri-1" || attr(r, "gen_ai.response.model") != "claude-test" {
+			t.Errorf("%s: missing triage or model attributes", r.EventName())
+		}
+	}
+
+	assistant := l.records[2].Body()
+	if v, _ := field(assistant, "content"); !v.Empty() {
+		t.Errorf("assistant content = %v, want none (thinking is not exported)", v)
+	}
+	calls, _ := field(assistant, "tool_calls")
+	if len(calls.AsSlice()) != 1 {
+		t.Fatalf("tool_calls = %v, want one", calls)
+	}
+	if v, _ := field(calls.AsSlice()[0], "function", "arguments"); v.AsString() != `{"query":"up"}` {
+		t.Errorf("arguments = %q", v.AsString())
+	}
+	if v, _ := field(l.records[3].Body(), "id"); v.AsString() != "tu-1" {
+		t.Errorf("tool message id = %q, want tu-1", v.AsString())
+	}
+
+	choice := l.records[4]
+	if v, _ := field(choice.Body(), "finish_reason"); v.AsString() != "end_turn" {
+		t.Errorf("finish_reason = %q", v.AsString())
+	}
+	if v, _ := field(choice.Body(), "message", "content"); v.AsString() != "The api pods are saturated." {
+		t.Errorf("choice content = %q", v.AsString())
+	}
+	if attr(choice, "gen_ai.usage.output_tokens") != "30" {
+		t.Errorf("output tokens = %q, want 30", attr(choice, "gen_ai.usage.output_tokens"))
+	}
+}
+
+func TestObserveLLMCall_Capture(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		capture Capture
+		// toolLen is the length of the exported tool result, -1 if omitted.
+		toolLen   int
+		arguments bool
+	}{
+		{capture: CaptureFull, toolLen: 2000, arguments: true},
+		{capture: CaptureTruncated, toolLen: truncateAt + len("\n[truncated: 1024 of 2000 bytes shown]"), arguments: true},
+		{capture: CaptureOff, toolLen: -1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.capture), func(t *testing.T) {
+			t.Parallel()
+
+			l := &recordLogger{}
+			New(l, tt.capture).ObserveLLMCall(context.Background(), testCall())
+
+			v, ok := field(l.records[3].Body(), "content")
+			switch {
+			case tt.toolLen < 0 && ok:
+				t.Errorf("tool content exported with capture off")
+			case tt.toolLen >= 0 && len(v.AsString()) != tt.toolLen:
+				t.Errorf("tool content is %d bytes, want %d", len(v.AsString()), tt.toolLen)
+			}
+			calls, _ := field(l.records[2].Body(), "tool_calls")
+			if len(calls.AsSlice()) != 1 {
+				t.Fatalf("tool_calls = %v, want one", calls)
+			}
+			if name, _ := field(calls.AsSlice()[0], "function", "name"); name.AsString() != "query_metrics" {
+				t.Errorf("tool name = %q, want it kept in every mode", name.AsString())
+			}
+			if _, ok := field(calls.AsSlice()[0], "function", "arguments"); ok != tt.arguments {
+				t.Errorf("arguments exported = %v, want %v", ok, tt.arguments)
+			}
+		})
+	}
+}
+
+func TestObserveLLMCall_Failed(t *testing.T) {
+	t.Parallel()
+
+	call := testCall()
+	call.Response = nil
+	call.Err = errors.New("overloaded")
+	l := &recordLogger{}
+	New(l, CaptureOff).ObserveLLMCall(context.Background(), call)
+
+	if got := l.names(); slices.Contains(got, "gen_ai.choice") || len(got) != 4 {
+		t.Errorf("events = %v, want the prompt without a choice", got)
+	}
+	if attr(l.records[0], "error.type") == "" {
+		t.Error("error.type not set on a failed call")
+	}
+}
+
+func TestParseCapture(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"off", "truncated", "full"} {
+		if _, err := ParseCapture(s); err != nil {
+			t.Errorf("ParseCapture(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseCapture("some"); err == nil {
+		t.Error("ParseCapture accepted an unknown mode")
+	}
+}
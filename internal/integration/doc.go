@@ -0,0 +1,8 @@
+// Package integration holds end-to-end tests that run a triage from Submit
+// through the Engine to Postgres and the Slack notifier, against real
+// Postgres in Docker, scripted LLM responses and httptest datasources.
+//
+// The tests are behind the integration build tag and need a Docker daemon:
+//
+//	go test -tags=integration ./internal/integration/
+package integration
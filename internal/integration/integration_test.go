@@ -0,0 +1,313 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/notify/slack"
+	"github.com/linnemanlabs/vigil/internal/postgres"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+	"github.com/linnemanlabs/vigil/internal/triage/pgstore"
+)
+
+// databaseURL is the DSN of the Postgres container started by TestMain.
+var databaseURL string
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "docker: %v\n", err)
+		return 1
+	}
+	pool.MaxWait = 2 * time.Minute
+
+	res, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "16-alpine",
+		Env:        []string{"POSTGRES_USER=vigil", "POSTGRES_PASSWORD=vigil", "POSTGRES_DB=vigil"},
+	}, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "start postgres: %v\n", err)
+		return 1
+	}
+	defer func() { _ = pool.Purge(res) }()
+	// Reaped by Docker even if the test binary is killed.
+	_ = res.Expire(600)
+
+	databaseURL = fmt.Sprintf("postgres://vigil:vigil@%s/vigil?sslmode=disable", res.GetHostPort("5432/tcp"))
+	if err := pool.Retry(func() error {
+		p, err := postgres.NewPool(context.Background(), databaseURL)
+		if err != nil {
+			return err
+		}
+		defer p.Close()
+		return p.Ping(context.Background())
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "postgres not ready: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// replayProvider plays back scripted LLM responses in order and records the
+// requests it was sent. Calls wait until hold is closed.
+type replayProvider struct {
+	hold chan struct{}
+
+	mu        sync.Mutex
+	responses []*triage.LLMResponse
+	reqs      []*triage.LLMRequest
+}
+
+func (p *replayProvider) Send(ctx context.Context, req *triage.LLMRequest) (*triage.LLMResponse, error) {
+	select {
+	case <-p.hold:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reqs = append(p.reqs, req)
+	if len(p.responses) == 0 {
+		return nil, fmt.Errorf("replay: no response left for call %d", len(p.reqs))
+	}
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+	return resp, nil
+}
+
+// slackSink collects the messages posted to a fake Slack webhook.
+type slackSink struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (s *slackSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.msgs = append(s.msgs, string(body))
+	s.mu.Unlock()
+	_, _ = io.WriteString(w, "ok")
+}
+
+func (s *slackSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+// harness wires a Service the way cmd/server does, with every external
+// dependency replaced by a local fake.
+type harness struct {
+	svc      *triage.Service
+	store    *pgstore.Store
+	provider *replayProvider
+	slack    *slackSink
+	promHits *atomic.Int64
+}
+
+func newHarness(t *testing.T, responses []*triage.LLMResponse, opts ...triage.EngineOption) *harness {
+	t.Helper()
+	ctx := context.Background()
+
+	pool, err := postgres.NewPool(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("postgres.NewPool: %v", err)
+	}
+	store, err := pgstore.New(ctx, pool, noop.NewTracerProvider())
+	if err != nil {
+		pool.Close()
+		t.Fatalf("pgstore.New: %v", err)
+	}
+	t.Cleanup(store.Close)
+
+	var promHits atomic.Int64
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		promHits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"instance":"node-1"},"value":[%d,"0.97"]}]}}`, time.Now().Unix())
+	}))
+	t.Cleanup(prom.Close)
+
+	sink := &slackSink{}
+	slackSrv := httptest.NewServer(sink)
+	t.Cleanup(slackSrv.Close)
+
+	registry := tools.NewRegistry()
+	registry.Register(tools.NewPrometheusQuery(prom.URL, ""))
+
+	provider := &replayProvider{hold: make(chan struct{}), responses: responses}
+	metrics := triage.NewMetrics(prometheus.NewRegistry())
+	engine := triage.NewEngine(provider, registry, log.Nop(), metrics.Hooks(), noop.NewTracerProvider(), opts...)
+	svc := triage.NewService(store, engine, log.Nop(), metrics, slack.New(slackSrv.URL, log.Nop()), noop.NewTracerProvider())
+
+	return &harness{svc: svc, store: store, provider: provider, slack: sink, promHits: &promHits}
+}
+
+// waitDone polls until the triage is terminal and its notification was sent.
+func (h *harness) waitDone(t *testing.T, id string) *triage.Result {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		r, ok, err := h.svc.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if ok && r.Status.IsTerminal() && len(h.slack.messages()) > 0 {
+			return r
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("triage %s did not finish within 30s", id)
+	return nil
+}
+
+func firingAlert(fingerprint string) *alert.Alert {
+	return &alert.Alert{
+		Status:      "firing",
+		Fingerprint: fingerprint,
+		Labels:      map[string]string{"alertname": "HighCPU", "severity": "critical", "instance": "node-1"},
+		Annotations: map[string]string{"summary": "CPU above 95% on node-1"},
+		StartsAt:    time.Now().Add(-5 * time.Minute),
+	}
+}
+
+func TestTriage_EndToEnd(t *testing.T) {
+	fp := fmt.Sprintf("fp-e2e-%d", time.Now().UnixNano())
+	h := newHarness(t, []*triage.LLMResponse{
+		{
+			Content: []triage.ContentBlock{
+				{Type: "text", Text: "Checking CPU on node-1."},
+				{Type: "tool_use", ID: "call-1", Name: "query_metrics", Input: json.RawMessage(`{"query":"node_cpu_utilisation{instance=\"node-1\"}"}`)},
+			},
+			StopReason: triage.StopToolUse,
+			Usage:      triage.Usage{InputTokens: 1200, OutputTokens: 80},
+			Model:      "replay",
+		},
+		{
+			Content:    []triage.ContentBlock{{Type: "text", Text: "node-1 is at 97% CPU from a runaway batch job."}},
+			StopReason: triage.StopEnd,
+			Usage:      triage.Usage{InputTokens: 1500, OutputTokens: 120},
+			Model:      "replay",
+		},
+	})
+
+	sr, err := h.svc.Submit(context.Background(), firingAlert(fp))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if sr.Skipped || sr.ID == "" {
+		t.Fatalf("Submit = %+v, want an accepted triage", sr)
+	}
+
+	// A second alert for the same fingerprint folds into the running triage.
+	dup, err := h.svc.Submit(context.Background(), firingAlert(fp))
+	if err != nil {
+		t.Fatalf("Submit duplicate: %v", err)
+	}
+	if !dup.Skipped || dup.ID != sr.ID || dup.Reason != "duplicate" {
+		t.Errorf("duplicate = %+v, want it folded into %s", dup, sr.ID)
+	}
+	close(h.provider.hold)
+
+	r := h.waitDone(t, sr.ID)
+	if r.Status != triage.StatusComplete {
+		t.Fatalf("status = %s (%s), want complete", r.Status, r.Analysis)
+	}
+	if !strings.Contains(r.Analysis, "runaway batch job") {
+		t.Errorf("analysis = %q", r.Analysis)
+	}
+	if r.TokensIn != 2700 || r.TokensOut != 200 || r.ToolCalls != 1 {
+		t.Errorf("usage = %d in, %d out, %d tool calls, want 2700, 200, 1", r.TokensIn, r.TokensOut, r.ToolCalls)
+	}
+	if len(r.Notes) != 1 || r.Notes[0].Text != "Checking CPU on node-1." {
+		t.Errorf("notes = %+v", r.Notes)
+	}
+	if n := h.promHits.Load(); n != 1 {
+		t.Errorf("prometheus hits = %d, want 1", n)
+	}
+
+	// The conversation is read back from Postgres, including the tool result.
+	if r.Conversation == nil || len(r.Conversation.Turns) != 4 {
+		t.Fatalf("conversation = %+v, want 4 turns", r.Conversation)
+	}
+	result := r.Conversation.Turns[2].Content[0]
+	if result.Type != "tool_result" || result.IsError || !strings.Contains(result.Content, "0.97") {
+		t.Errorf("tool result = %+v", result)
+	}
+
+	// The model saw the tool output on its second call.
+	if len(h.provider.reqs) != 2 {
+		t.Fatalf("LLM calls = %d, want 2", len(h.provider.reqs))
+	}
+
+	msgs := h.slack.messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "Triage Complete: HighCPU") || !strings.Contains(msgs[0], "runaway batch job") {
+		t.Errorf("slack messages = %q", msgs)
+	}
+
+	// Once finished the fingerprint can be triaged again.
+	h.provider.mu.Lock()
+	h.provider.responses = []*triage.LLMResponse{{
+		Content:    []triage.ContentBlock{{Type: "text", Text: "Still high."}},
+		StopReason: triage.StopEnd,
+	}}
+	h.provider.mu.Unlock()
+	again, err := h.svc.Submit(context.Background(), firingAlert(fp))
+	if err != nil || again.Skipped || again.ID == sr.ID {
+		t.Errorf("resubmit = %+v, %v, want a new triage", again, err)
+	}
+}
+
+func TestTriage_EndToEndBudgetStatus(t *testing.T) {
+	loop := make([]*triage.LLMResponse, 3)
+	for i := range loop {
+		loop[i] = &triage.LLMResponse{
+			Content:    []triage.ContentBlock{{Type: "tool_use", ID: fmt.Sprintf("call-%d", i), Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)}},
+			StopReason: triage.StopToolUse,
+			Usage:      triage.Usage{InputTokens: 100, OutputTokens: 10},
+		}
+	}
+	h := newHarness(t, loop, triage.WithDefaultBudget(triage.Budget{ToolCalls: 2}))
+	close(h.provider.hold)
+
+	sr, err := h.svc.Submit(context.Background(), firingAlert(fmt.Sprintf("fp-budget-%d", time.Now().UnixNano())))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	r := h.waitDone(t, sr.ID)
+	if r.Status != triage.StatusMaxTurns || r.ToolCalls != 2 {
+		t.Errorf("result = %s after %d tool calls, want max_turns after 2", r.Status, r.ToolCalls)
+	}
+	if msgs := h.slack.messages(); len(msgs) != 1 || !strings.Contains(msgs[0], "Triage Stopped (tool call limit)") {
+		t.Errorf("slack messages = %q", msgs)
+	}
+}
@@ -0,0 +1,291 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Batch defaults, used for zero BatchConfig fields.
+const (
+	DefaultBatchFlushInterval = time.Minute
+	DefaultBatchPollInterval  = 30 * time.Second
+	DefaultBatchMaxRequests   = 100
+)
+
+// maxBatchWait bounds how long a submitted batch is polled. The API ends
+// every batch within 24 hours, expiring whatever it has not processed.
+const maxBatchWait = 25 * time.Hour
+
+// BatchConfig tunes how requests are grouped into batches.
+type BatchConfig struct {
+	// FlushInterval is how long queued requests wait for others to join
+	// their batch.
+	FlushInterval time.Duration
+	// PollInterval is how often a submitted batch is checked for results.
+	PollInterval time.Duration
+	// MaxRequests submits a batch early once this many requests are queued.
+	MaxRequests int
+}
+
+// Batcher is a triage.Provider that sends requests through the Message
+// Batches API, at half the price of the Messages API, in exchange for
+// results that may take minutes to hours. Send blocks until the request's
+// batch has ended, so each turn of a triage waits for its own batch.
+//
+// Run must be running for queued requests to be submitted.
+type Batcher struct {
+	client *Client
+	api    batchAPI
+	cfg    BatchConfig
+	logger log.Logger
+
+	mu      sync.Mutex
+	queue   []*batchItem
+	nextID  int
+	flushCh chan struct{}
+}
+
+type batchItem struct {
+	id     string
+	params anthropic.MessageNewParams
+	done   chan batchResult
+}
+
+type batchResult struct {
+	resp *triage.LLMResponse
+	err  error
+}
+
+// batchAPI is the part of the Message Batches API the Batcher uses.
+type batchAPI interface {
+	create(ctx context.Context, params anthropic.MessageBatchNewParams) (string, error)
+	ended(ctx context.Context, batchID string) (bool, error)
+	results(ctx context.Context, batchID string) ([]anthropic.MessageBatchIndividualResponse, error)
+}
+
+// NewBatcher returns a Batcher that sends c's requests in batches.
+func NewBatcher(c *Client, cfg BatchConfig, logger log.Logger) *Batcher {
+	return newBatcher(c, sdkBatches{&c.client.Messages.Batches}, cfg, logger)
+}
+
+func newBatcher(c *Client, api batchAPI, cfg BatchConfig, logger log.Logger) *Batcher {
+	if logger == nil {
+		logger = log.Nop()
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultBatchFlushInterval
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultBatchPollInterval
+	}
+	if cfg.MaxRequests <= 0 {
+		cfg.MaxRequests = DefaultBatchMaxRequests
+	}
+	return &Batcher{
+		client:  c,
+		api:     api,
+		cfg:     cfg,
+		logger:  logger,
+		flushCh: make(chan struct{}, 1),
+	}
+}
+
+// Send queues req for the next batch and waits for its result. If ctx ends
+// before the batch is submitted the request is dropped from it; after that
+// the request is still processed and billed, and its result discarded.
+func (b *Batcher) Send(ctx context.Context, req *triage.LLMRequest) (*triage.LLMResponse, error) {
+	item := &batchItem{params: b.client.params(req), done: make(chan batchResult, 1)}
+
+	b.mu.Lock()
+	b.nextID++
+	item.id = "req-" + strconv.Itoa(b.nextID)
+	b.queue = append(b.queue, item)
+	full := len(b.queue) >= b.cfg.MaxRequests
+	b.mu.Unlock()
+	if full {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+
+	select {
+	case r := <-item.done:
+		return r.resp, r.err
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.queue = slices.DeleteFunc(b.queue, func(it *batchItem) bool { return it == item })
+		b.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Run submits queued requests every FlushInterval, or sooner once
+// MaxRequests are queued, until ctx is done.
+func (b *Batcher) Run(ctx context.Context) {
+	t := time.NewTicker(b.cfg.FlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-b.flushCh:
+		}
+		if items := b.take(); len(items) > 0 {
+			go b.process(ctx, items)
+		}
+	}
+}
+
+// take removes up to MaxRequests requests from the queue.
+func (b *Batcher) take() []*batchItem {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := min(len(b.queue), b.cfg.MaxRequests)
+	items := slices.Clone(b.queue[:n])
+	b.queue = slices.Delete(b.queue, 0, n)
+	if len(b.queue) >= b.cfg.MaxRequests {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return items
+}
+
+// process submits items as one batch, waits for it to end, and hands each
+// item its result.
+func (b *Batcher) process(ctx context.Context, items []*batchItem) {
+	byID := make(map[string]*batchItem, len(items))
+	reqs := make([]anthropic.MessageBatchNewParamsRequest, len(items))
+	for i, it := range items {
+		byID[it.id] = it
+		reqs[i] = anthropic.MessageBatchNewParamsRequest{CustomID: it.id, Params: batchParams(&it.params)}
+	}
+	fail := func(err error) {
+		for _, it := range byID {
+			it.done <- batchResult{err: err}
+		}
+	}
+
+	batchID, err := b.api.create(ctx, anthropic.MessageBatchNewParams{Requests: reqs})
+	if err != nil {
+		b.logger.Error(ctx, err, "failed to submit message batch", "requests", len(items))
+		fail(fmt.Errorf("claude api: create batch: %w", err))
+		return
+	}
+	L := b.logger.With("batch_id", batchID)
+	L.Info(ctx, "message batch submitted", "requests", len(items))
+
+	if err := b.wait(ctx, L, batchID); err != nil {
+		fail(err)
+		return
+	}
+
+	results, err := b.api.results(ctx, batchID)
+	if err != nil {
+		L.Error(ctx, err, "failed to fetch message batch results")
+		fail(fmt.Errorf("claude api: batch %s results: %w", batchID, err))
+		return
+	}
+	for i := range results {
+		r := &results[i]
+		it, ok := byID[r.CustomID]
+		if !ok {
+			continue
+		}
+		delete(byID, r.CustomID)
+		if r.Result.Type != "succeeded" {
+			msg := r.Result.Type
+			if r.Result.Type == "errored" {
+				msg += ": " + r.Result.Error.Error.Message
+			}
+			it.done <- batchResult{err: fmt.Errorf("claude api: batch request %s", msg)}
+			continue
+		}
+		it.done <- batchResult{resp: fromSDKResponse(&r.Result.Message)}
+	}
+	L.Info(ctx, "message batch ended", "requests", len(items), "missing", len(byID))
+	fail(fmt.Errorf("claude api: batch %s returned no result for request", batchID))
+}
+
+// wait polls batchID every PollInterval until it has ended. Poll errors are
+// retried; the batch is given up on after maxBatchWait or when ctx is done.
+func (b *Batcher) wait(ctx context.Context, L log.Logger, batchID string) error {
+	t := time.NewTicker(b.cfg.PollInterval)
+	defer t.Stop()
+	deadline := time.Now().Add(maxBatchWait)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		ended, err := b.api.ended(ctx, batchID)
+		switch {
+		case err != nil:
+			L.Warn(ctx, "message batch poll failed", "err", err)
+		case ended:
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("claude api: batch " + batchID + " did not end in time")
+		}
+	}
+}
+
+// batchParams copies the fields Client.params sets into a batch request.
+func batchParams(p *anthropic.MessageNewParams) anthropic.MessageBatchNewParamsRequestParams {
+	return anthropic.MessageBatchNewParamsRequestParams{
+		Model:     p.Model,
+		MaxTokens: p.MaxTokens,
+		System:    p.System,
+		Messages:  p.Messages,
+		Tools:     p.Tools,
+		Thinking:  p.Thinking,
+	}
+}
+
+// sdkBatches implements batchAPI over the SDK.
+type sdkBatches struct {
+	svc *anthropic.MessageBatchService
+}
+
+func (s sdkBatches) create(ctx context.Context, params anthropic.MessageBatchNewParams) (string, error) {
+	batch, err := s.svc.New(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	return batch.ID, nil
+}
+
+func (s sdkBatches) ended(ctx context.Context, batchID string) (bool, error) {
+	batch, err := s.svc.Get(ctx, batchID)
+	if err != nil {
+		return false, err
+	}
+	return batch.ProcessingStatus == anthropic.MessageBatchProcessingStatusEnded, nil
+}
+
+func (s sdkBatches) results(ctx context.Context, batchID string) ([]anthropic.MessageBatchIndividualResponse, error) {
+	stream := s.svc.ResultsStreaming(ctx, batchID)
+	defer stream.Close() //nolint:errcheck // nothing to do about a failed close of a drained stream
+
+	var out []anthropic.MessageBatchIndividualResponse
+	for stream.Next() {
+		out = append(out, stream.Current())
+	}
+	return out, stream.Err()
+}
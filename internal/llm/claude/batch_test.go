@@ -0,0 +1,150 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// fakeBatches answers every request in a batch with its own system prompt,
+// except prompts listed in errored.
+type fakeBatches struct {
+	mu        sync.Mutex
+	batches   [][]anthropic.MessageBatchNewParamsRequest
+	createErr error
+	errored   map[string]bool
+}
+
+func (f *fakeBatches) create(_ context.Context, params anthropic.MessageBatchNewParams) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.createErr != nil {
+		return "", f.createErr
+	}
+	f.batches = append(f.batches, params.Requests)
+	return "batch-1", nil
+}
+
+func (f *fakeBatches) ended(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeBatches) results(context.Context, string) ([]anthropic.MessageBatchIndividualResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []anthropic.MessageBatchIndividualResponse
+	for _, req := range f.batches[len(f.batches)-1] {
+		prompt := req.Params.System[0].Text
+		r := anthropic.MessageBatchIndividualResponse{CustomID: req.CustomID}
+		if f.errored[prompt] {
+			r.Result.Type = "errored"
+		} else {
+			r.Result.Type = "succeeded"
+			r.Result.Message = anthropic.Message{
+				Content:    []anthropic.ContentBlockUnion{{Type: textType, Text: prompt}},
+				StopReason: anthropic.StopReasonEndTurn,
+			}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (f *fakeBatches) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.batches)
+}
+
+func startBatcher(t *testing.T, api batchAPI, cfg BatchConfig) *Batcher {
+	t.Helper()
+	cfg.PollInterval = time.Millisecond
+	b := newBatcher(New("test-key", "test-model"), api, cfg, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go b.Run(ctx)
+	return b
+}
+
+func TestBatcher_GroupsRequests(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeBatches{errored: map[string]bool{"bad": true}}
+	// A long flush interval, so only MaxRequests can submit the batch.
+	b := startBatcher(t, api, BatchConfig{FlushInterval: time.Hour, MaxRequests: 3})
+
+	prompts := []string{"first", "second", "bad"}
+	type reply struct {
+		text string
+		err  error
+	}
+	replies := make([]reply, len(prompts))
+	var wg sync.WaitGroup
+	for i, p := range prompts {
+		wg.Go(func() {
+			resp, err := b.Send(context.Background(), &triage.LLMRequest{MaxTokens: 100, System: p})
+			if err == nil {
+				replies[i].text = resp.Content[0].Text
+			}
+			replies[i].err = err
+		})
+	}
+	wg.Wait()
+
+	if n := api.count(); n != 1 {
+		t.Fatalf("batches = %d, want 1", n)
+	}
+	for i, p := range prompts[:2] {
+		if replies[i].err != nil || replies[i].text != p {
+			t.Errorf("Send(%q) = %q, %v", p, replies[i].text, replies[i].err)
+		}
+	}
+	if replies[2].err == nil {
+		t.Error("errored batch request returned no error")
+	}
+}
+
+func TestBatcher_FlushInterval(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeBatches{}
+	b := startBatcher(t, api, BatchConfig{FlushInterval: 10 * time.Millisecond, MaxRequests: 100})
+
+	resp, err := b.Send(context.Background(), &triage.LLMRequest{MaxTokens: 100, System: "alone"})
+	if err != nil || resp.Content[0].Text != "alone" || resp.StopReason != triage.StopEnd {
+		t.Fatalf("Send = %+v, %v", resp, err)
+	}
+}
+
+func TestBatcher_CreateError(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeBatches{createErr: errors.New("overloaded")}
+	b := startBatcher(t, api, BatchConfig{FlushInterval: 10 * time.Millisecond})
+
+	if _, err := b.Send(context.Background(), &triage.LLMRequest{MaxTokens: 100, System: "x"}); err == nil {
+		t.Fatal("Send succeeded despite batch create failure")
+	}
+}
+
+func TestBatcher_CancelledBeforeSubmit(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeBatches{}
+	b := newBatcher(New("test-key", "test-model"), api, BatchConfig{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Send(ctx, &triage.LLMRequest{MaxTokens: 100, System: "x"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Send = %v, want context.Canceled", err)
+	}
+	if items := b.take(); len(items) != 0 {
+		t.Errorf("queue = %d requests, want cancelled request dropped", len(items))
+	}
+}
@@ -3,11 +3,14 @@ package claude
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 
+	"github.com/linnemanlabs/vigil/internal/llm"
 	"github.com/linnemanlabs/vigil/internal/tools"
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
@@ -30,7 +33,93 @@ func New(apiKey, model string) *Client {
 // Send sends a request to the Claude API, converting from our internal LLMRequest format to the SDK's expected format,
 // and then converts the response back to our internal LLMResponse format. It handles any errors that occur during the API call.
 func (c *Client) Send(ctx context.Context, req *triage.LLMRequest) (*triage.LLMResponse, error) {
-	params := anthropic.MessageNewParams{
+	resp, err := c.client.Messages.New(ctx, c.params(req))
+	if err != nil {
+		return nil, fmt.Errorf("claude api: %w", err)
+	}
+	return fromSDKResponse(resp), nil
+}
+
+// Ping checks that the API key is accepted and the model exists by looking
+// the model up, which costs no tokens.
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.client.Models.Get(ctx, string(c.model), anthropic.ModelGetParams{}); err != nil {
+		return fmt.Errorf("claude api: %w", err)
+	}
+	return nil
+}
+
+// SendStream is Send over the streaming API. onText is called with each piece
+// of response text as it arrives; the full response is returned once the
+// stream ends.
+func (c *Client) SendStream(ctx context.Context, req *triage.LLMRequest, onText func(text string)) (*triage.LLMResponse, error) {
+	stream := c.client.Messages.NewStreaming(ctx, c.params(req))
+	defer stream.Close() //nolint:errcheck // nothing to do about a failed close of a drained stream
+
+	var msg anthropic.Message
+	for stream.Next() {
+		event := stream.Current()
+		if err := msg.Accumulate(event); err != nil {
+			return nil, fmt.Errorf("claude api: accumulate stream: %w", err)
+		}
+		if ev, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent); ok {
+			if delta, ok := ev.Delta.AsAny().(anthropic.TextDelta); ok && delta.Text != "" {
+				onText(delta.Text)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("claude api: %w", err)
+	}
+	return fromSDKResponse(&msg), nil
+}
+
+// CountTokens asks the API how many input tokens req would use, without
+// running it. It implements triage.TokenCounter. Counting is free but has
+// its own rate limit.
+func (c *Client) CountTokens(ctx context.Context, req *triage.LLMRequest) (int, error) {
+	res, err := c.client.Messages.CountTokens(ctx, countParams(c.params(req)))
+	if err != nil {
+		return 0, fmt.Errorf("claude api: count tokens: %w", err)
+	}
+	return int(res.InputTokens), nil
+}
+
+// countParams is the token count request for the message request p.
+func countParams(p anthropic.MessageNewParams) anthropic.MessageCountTokensParams {
+	tools := make([]anthropic.MessageCountTokensToolUnionParam, len(p.Tools))
+	for i, t := range p.Tools {
+		tools[i] = anthropic.MessageCountTokensToolUnionParam{OfTool: t.OfTool}
+	}
+	return anthropic.MessageCountTokensParams{
+		Model:      p.Model,
+		Messages:   p.Messages,
+		System:     anthropic.MessageCountTokensParamsSystemUnion{OfTextBlockArray: p.System},
+		Thinking:   p.Thinking,
+		ToolChoice: p.ToolChoice,
+		Tools:      tools,
+	}
+}
+
+// ClassifyError is llm.ClassifyError for Claude API errors: 429 is a rate
+// limit, 529 an overloaded API and other 5xx responses server errors.
+func ClassifyError(err error) string {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return llm.FailureRateLimit
+		case apiErr.StatusCode == 529:
+			return llm.FailureOverloaded
+		case apiErr.StatusCode >= 500:
+			return llm.FailureServer
+		}
+	}
+	return llm.ClassifyError(err)
+}
+
+func (c *Client) params(req *triage.LLMRequest) anthropic.MessageNewParams {
+	p := anthropic.MessageNewParams{
 		Model:     c.model,
 		MaxTokens: int64(req.MaxTokens),
 		System: []anthropic.TextBlockParam{
@@ -39,12 +128,34 @@ func (c *Client) Send(ctx context.Context, req *triage.LLMRequest) (*triage.LLMR
 		Messages: toSDKMessages(req.Messages),
 		Tools:    toSDKTools(req.Tools),
 	}
+	if req.ThinkingBudget > 0 {
+		p.Thinking = anthropic.ThinkingConfigParamOfEnabled(int64(req.ThinkingBudget))
+	}
+	if req.Temperature != nil {
+		p.Temperature = anthropic.Float(*req.Temperature)
+	}
+	if len(req.StopSequences) > 0 {
+		p.StopSequences = req.StopSequences
+	}
+	p.ToolChoice = toSDKToolChoice(req.ToolChoice)
+	return p
+}
 
-	resp, err := c.client.Messages.New(ctx, params)
-	if err != nil {
-		return nil, fmt.Errorf("claude api: %w", err)
+// toSDKToolChoice maps a tool choice; the zero value maps to the zero param,
+// which the SDK omits.
+func toSDKToolChoice(tc triage.ToolChoice) anthropic.ToolChoiceUnionParam {
+	switch tc.Type {
+	case triage.ToolChoiceAuto:
+		return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+	case triage.ToolChoiceAny:
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	case triage.ToolChoiceNone:
+		return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}
+	case triage.ToolChoiceTool:
+		return anthropic.ToolChoiceUnionParam{OfTool: &anthropic.ToolChoiceToolParam{Name: tc.Name}}
+	default:
+		return anthropic.ToolChoiceUnionParam{}
 	}
-	return fromSDKResponse(resp), nil
 }
 
 func toSDKMessages(msgs []triage.Message) []anthropic.MessageParam {
@@ -57,6 +168,10 @@ func toSDKMessages(msgs []triage.Message) []anthropic.MessageParam {
 				blocks[j] = anthropic.ContentBlockParamUnion{
 					OfText: &anthropic.TextBlockParam{Text: m.Content[j].Text},
 				}
+			case "thinking":
+				blocks[j] = anthropic.NewThinkingBlock(m.Content[j].Signature, m.Content[j].Thinking)
+			case "redacted_thinking":
+				blocks[j] = anthropic.NewRedactedThinkingBlock(m.Content[j].Data)
 			case "tool_use":
 				blocks[j] = anthropic.ContentBlockParamUnion{
 					OfToolUse: &anthropic.ToolUseBlockParam{
@@ -105,6 +220,7 @@ func toSDKTools(defs []tools.ToolDef) []anthropic.ToolUnionParam {
 
 func fromSDKResponse(r *anthropic.Message) *triage.LLMResponse {
 	blocks := make([]triage.ContentBlock, len(r.Content))
+	var thinkingBytes int
 
 	for i := range r.Content {
 		b := &r.Content[i]
@@ -114,6 +230,18 @@ func fromSDKResponse(r *anthropic.Message) *triage.LLMResponse {
 				Type: "text",
 				Text: b.Text,
 			}
+		case "thinking":
+			blocks[i] = triage.ContentBlock{
+				Type:      "thinking",
+				Thinking:  b.Thinking,
+				Signature: b.Signature,
+			}
+			thinkingBytes += len(b.Thinking)
+		case "redacted_thinking":
+			blocks[i] = triage.ContentBlock{
+				Type: "redacted_thinking",
+				Data: b.Data,
+			}
 		case "tool_use":
 			blocks[i] = triage.ContentBlock{
 				Type:  "tool_use",
@@ -148,6 +276,9 @@ func fromSDKResponse(r *anthropic.Message) *triage.LLMResponse {
 		Usage: triage.Usage{
 			InputTokens:  int(r.Usage.InputTokens),
 			OutputTokens: int(r.Usage.OutputTokens),
+			// The API folds thinking into output_tokens, so estimate it at
+			// four bytes per token, capped at the reported output.
+			ThinkingTokens: min(thinkingBytes/4, int(r.Usage.OutputTokens)),
 		},
 		Model: string(r.Model),
 	}
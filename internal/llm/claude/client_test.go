@@ -1,11 +1,16 @@
 package claude
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/anthropics/anthropic-sdk-go"
 
+	"github.com/linnemanlabs/vigil/internal/llm"
 	"github.com/linnemanlabs/vigil/internal/tools"
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
@@ -118,6 +123,114 @@ func TestToSDKMessages_MixedBlocks(t *testing.T) {
 	}
 }
 
+func TestToSDKMessages_ThinkingBlocks(t *testing.T) {
+	t.Parallel()
+
+	msgs := []triage.Message{{
+		Role: "assistant",
+		Content: []triage.ContentBlock{
+			{Type: "thinking", Thinking: "check the error rate first", Signature: "sig-1"},
+			{Type: "redacted_thinking", Data: "opaque"},
+		},
+	}}
+
+	result := toSDKMessages(msgs)
+
+	thinking := result[0].Content[0].OfThinking
+	if thinking == nil {
+		t.Fatal("expected OfThinking to be set")
+	}
+	if thinking.Thinking != "check the error rate first" || thinking.Signature != "sig-1" {
+		t.Errorf("thinking = %q/%q, want text and signature passed back unchanged", thinking.Thinking, thinking.Signature)
+	}
+	redacted := result[0].Content[1].OfRedactedThinking
+	if redacted == nil || redacted.Data != "opaque" {
+		t.Errorf("redacted thinking = %+v, want data %q", redacted, "opaque")
+	}
+}
+
+func TestParams_Thinking(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{model: "test-model"}
+	if p := c.params(&triage.LLMRequest{MaxTokens: 4096}); p.Thinking.OfEnabled != nil {
+		t.Error("thinking enabled without a budget")
+	}
+	p := c.params(&triage.LLMRequest{MaxTokens: 4096 + 2048, ThinkingBudget: 2048})
+	if p.Thinking.OfEnabled == nil || p.Thinking.OfEnabled.BudgetTokens != 2048 {
+		t.Errorf("thinking = %+v, want enabled with budget 2048", p.Thinking)
+	}
+	if p.MaxTokens != 4096+2048 {
+		t.Errorf("max tokens = %d, want %d", p.MaxTokens, 4096+2048)
+	}
+}
+
+func TestParams_ToolChoiceAndSampling(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{model: "test-model"}
+	p := c.params(&triage.LLMRequest{MaxTokens: 4096})
+	if raw, _ := json.Marshal(p); strings.Contains(string(raw), "tool_choice") || strings.Contains(string(raw), "temperature") || strings.Contains(string(raw), "stop_sequences") {
+		t.Errorf("unset options sent: %s", raw)
+	}
+
+	temp := 0.2
+	p = c.params(&triage.LLMRequest{
+		MaxTokens:     4096,
+		ToolChoice:    triage.ToolChoice{Type: triage.ToolChoiceTool, Name: "query_logs"},
+		Temperature:   &temp,
+		StopSequences: []string{"</analysis>"},
+	})
+	if p.ToolChoice.OfTool == nil || p.ToolChoice.OfTool.Name != "query_logs" {
+		t.Errorf("tool choice = %+v, want tool query_logs", p.ToolChoice)
+	}
+	if p.Temperature.Value != 0.2 || len(p.StopSequences) != 1 {
+		t.Errorf("temperature = %v, stop sequences = %v", p.Temperature, p.StopSequences)
+	}
+
+	for _, tt := range []struct {
+		choice triage.ToolChoiceType
+		want   string
+	}{
+		{triage.ToolChoiceAuto, `"tool_choice":{"type":"auto"}`},
+		{triage.ToolChoiceAny, `"tool_choice":{"type":"any"}`},
+		{triage.ToolChoiceNone, `"tool_choice":{"type":"none"}`},
+	} {
+		raw, err := json.Marshal(c.params(&triage.LLMRequest{MaxTokens: 4096, ToolChoice: triage.ToolChoice{Type: tt.choice}}))
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if !strings.Contains(string(raw), tt.want) {
+			t.Errorf("%s: params = %s, want %s", tt.choice, raw, tt.want)
+		}
+	}
+}
+
+func TestCountParams(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{model: "test-model"}
+	p := countParams(c.params(&triage.LLMRequest{
+		MaxTokens:  4096,
+		System:     "You are an SRE.",
+		Messages:   []triage.Message{{Role: "user", Content: []triage.ContentBlock{{Type: textType, Text: "disk full"}}}},
+		Tools:      []tools.ToolDef{{Name: "query_logs", InputSchema: json.RawMessage(`{"type":"object"}`)}},
+		ToolChoice: triage.ToolChoice{Type: triage.ToolChoiceNone},
+	}))
+	raw, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, want := range []string{`"model":"test-model"`, `"You are an SRE."`, `"disk full"`, `"name":"query_logs"`, `"tool_choice":{"type":"none"}`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("params = %s, want %s", raw, want)
+		}
+	}
+	if strings.Contains(string(raw), "max_tokens") {
+		t.Errorf("params = %s, max_tokens is not a count parameter", raw)
+	}
+}
+
 func TestToSDKTools(t *testing.T) {
 	t.Parallel()
 
@@ -246,6 +359,41 @@ func TestFromSDKResponse_Usage(t *testing.T) {
 	}
 }
 
+func TestFromSDKResponse_Thinking(t *testing.T) {
+	t.Parallel()
+
+	msg := &anthropic.Message{
+		Content: []anthropic.ContentBlockUnion{
+			{Type: "thinking", Thinking: strings.Repeat("x", 400), Signature: "sig-1"},
+			{Type: "redacted_thinking", Data: "opaque"},
+			{Type: textType, Text: "done"},
+		},
+		StopReason: anthropic.StopReasonEndTurn,
+		Usage:      anthropic.Usage{InputTokens: 100, OutputTokens: 150},
+	}
+
+	result := fromSDKResponse(msg)
+
+	if len(result.Content) != 3 {
+		t.Fatalf("content len = %d, want 3", len(result.Content))
+	}
+	if b := result.Content[0]; b.Type != "thinking" || b.Signature != "sig-1" || len(b.Thinking) != 400 {
+		t.Errorf("thinking block = %q with signature %q, want 400 bytes signed sig-1", b.Type, b.Signature)
+	}
+	if b := result.Content[1]; b.Type != "redacted_thinking" || b.Data != "opaque" {
+		t.Errorf("redacted block = %q with data %q", b.Type, b.Data)
+	}
+	if result.Usage.ThinkingTokens != 100 {
+		t.Errorf("thinking tokens = %d, want 100", result.Usage.ThinkingTokens)
+	}
+
+	// The estimate never exceeds what the API billed as output.
+	msg.Usage.OutputTokens = 20
+	if got := fromSDKResponse(msg).Usage.ThinkingTokens; got != 20 {
+		t.Errorf("thinking tokens = %d, want capped at 20", got)
+	}
+}
+
 func FuzzFromSDKResponse(f *testing.F) {
 	// Seeds: text content, tool_use content, unknown type, empty
 	f.Add("text", "", "analysis result", "", "", "end_turn", int64(100), int64(50))
@@ -280,3 +428,28 @@ func FuzzFromSDKResponse(f *testing.F) {
 		}
 	})
 }
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"rate limited", &anthropic.Error{StatusCode: 429}, llm.FailureRateLimit},
+		{"overloaded", fmt.Errorf("claude api: %w", &anthropic.Error{StatusCode: 529}), llm.FailureOverloaded},
+		{"server error", &anthropic.Error{StatusCode: 503}, llm.FailureServer},
+		{"bad request", &anthropic.Error{StatusCode: 400}, ""},
+		{"timeout", fmt.Errorf("claude api: %w", context.DeadlineExceeded), llm.FailureTimeout},
+		{"other", errors.New("boom"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Failure classes of provider errors, as returned by a FallbackConfig's
+// Classify.
+const (
+	FailureTimeout    = "timeout"
+	FailureRateLimit  = "rate_limit"
+	FailureOverloaded = "overloaded"
+	FailureServer     = "server_error"
+	FailureNetwork    = "network"
+)
+
+// FailureClasses lists every failure class.
+var FailureClasses = []string{FailureTimeout, FailureRateLimit, FailureOverloaded, FailureServer, FailureNetwork}
+
+// Names a FallbackProvider records on the responses of each provider.
+const (
+	ProviderPrimary  = "primary"
+	ProviderFallback = "fallback"
+)
+
+// Fallback defaults, used for zero FallbackConfig fields.
+const (
+	DefaultFallbackAttempts = 2
+	DefaultFallbackCooldown = 5 * time.Minute
+)
+
+// FallbackConfig tunes when a FallbackProvider gives up on its primary.
+type FallbackConfig struct {
+	// Classify names the failure class of a provider error, or returns ""
+	// for errors a retry would not fix, such as an invalid request. It
+	// defaults to ClassifyError.
+	Classify func(error) string
+	// FailOn is the set of failure classes that count against the primary.
+	// Empty means every class.
+	FailOn []string
+	// Attempts is how many calls the primary gets per request before the
+	// request goes to the fallback.
+	Attempts int
+	// Timeout bounds each primary call; zero leaves it to the caller's
+	// context. A call that times out is a FailureTimeout.
+	Timeout time.Duration
+	// Cooldown is how long requests go straight to the fallback after one
+	// fell back, so an outage does not cost every request its retries.
+	Cooldown time.Duration
+	// OnFallback is called with the failure class each time a request falls
+	// back.
+	OnFallback func(class string)
+}
+
+// FallbackProvider is a triage.Provider that sends requests to a secondary
+// provider when the primary keeps failing, such as during an outage of the
+// primary's API. Responses carry the name of the provider that produced
+// them, ProviderPrimary or ProviderFallback.
+type FallbackProvider struct {
+	primary  triage.Provider
+	fallback triage.Provider
+	cfg      FallbackConfig
+	failOn   map[string]bool
+	now      func() time.Time
+
+	mu    sync.Mutex
+	until time.Time // requests skip the primary until then
+}
+
+// NewFallback returns a provider that tries primary and then fallback.
+func NewFallback(primary, fallback triage.Provider, cfg FallbackConfig) *FallbackProvider {
+	if cfg.Classify == nil {
+		cfg.Classify = ClassifyError
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = DefaultFallbackAttempts
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultFallbackCooldown
+	}
+	failOn := make(map[string]bool)
+	for _, c := range cfg.FailOn {
+		failOn[c] = true
+	}
+	return &FallbackProvider{primary: primary, fallback: fallback, cfg: cfg, failOn: failOn, now: time.Now}
+}
+
+// Send sends req to the primary, retrying failures up to Attempts times, and
+// then to the fallback. Errors outside FailOn are returned as they are.
+func (f *FallbackProvider) Send(ctx context.Context, req *triage.LLMRequest) (*triage.LLMResponse, error) {
+	return f.send(ctx, func(ctx context.Context, p triage.Provider) (*triage.LLMResponse, error) {
+		return p.Send(ctx, req)
+	})
+}
+
+// SendStream is Send over providers that stream, falling back to Send for
+// those that do not. Text streamed by a failed primary call is not taken
+// back, so onText may see a partial answer before the fallback's.
+func (f *FallbackProvider) SendStream(ctx context.Context, req *triage.LLMRequest, onText func(text string)) (*triage.LLMResponse, error) {
+	return f.send(ctx, func(ctx context.Context, p triage.Provider) (*triage.LLMResponse, error) {
+		if sp, ok := p.(triage.StreamingProvider); ok {
+			return sp.SendStream(ctx, req, onText)
+		}
+		return p.Send(ctx, req)
+	})
+}
+
+func (f *FallbackProvider) send(ctx context.Context, call func(context.Context, triage.Provider) (*triage.LLMResponse, error)) (*triage.LLMResponse, error) {
+	class := ""
+	if f.cooling() {
+		class = "cooldown"
+	} else {
+		for range f.cfg.Attempts {
+			resp, err := f.callPrimary(ctx, call)
+			if err == nil {
+				resp.Provider = ProviderPrimary
+				return resp, nil
+			}
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if class = f.classify(err); class == "" {
+				return nil, err
+			}
+		}
+		f.mu.Lock()
+		f.until = f.now().Add(f.cfg.Cooldown)
+		f.mu.Unlock()
+	}
+
+	if f.cfg.OnFallback != nil {
+		f.cfg.OnFallback(class)
+	}
+	resp, err := call(ctx, f.fallback)
+	if err != nil {
+		return nil, err
+	}
+	resp.Provider = ProviderFallback
+	return resp, nil
+}
+
+func (f *FallbackProvider) callPrimary(ctx context.Context, call func(context.Context, triage.Provider) (*triage.LLMResponse, error)) (*triage.LLMResponse, error) {
+	if f.cfg.Timeout <= 0 {
+		return call(ctx, f.primary)
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+	return call(ctx, f.primary)
+}
+
+// classify returns the failure class of err if it counts against the
+// primary, or "".
+func (f *FallbackProvider) classify(err error) string {
+	class := f.cfg.Classify(err)
+	if class == "" || (len(f.failOn) > 0 && !f.failOn[class]) {
+		return ""
+	}
+	return class
+}
+
+// cooling reports whether a recent fallback sends requests straight to the
+// fallback.
+func (f *FallbackProvider) cooling() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now().Before(f.until)
+}
+
+// ClassifyError classifies errors any provider can return: deadlines as
+// FailureTimeout and network errors as FailureNetwork. Provider packages
+// extend it with the status codes of their API.
+func ClassifyError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return FailureTimeout
+		}
+		return FailureNetwork
+	default:
+		return ""
+	}
+}
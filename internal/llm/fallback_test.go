@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+var errOverloaded = errors.New("overloaded")
+
+// stubProvider answers with its model name, or fails with errs in turn.
+type stubProvider struct {
+	model string
+	errs  []error
+	delay time.Duration
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *stubProvider) Send(ctx context.Context, _ *triage.LLMRequest) (*triage.LLMResponse, error) {
+	s.mu.Lock()
+	i := s.calls
+	s.calls++
+	s.mu.Unlock()
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stub: %w", ctx.Err())
+		}
+	}
+	if i < len(s.errs) && s.errs[i] != nil {
+		return nil, s.errs[i]
+	}
+	return &triage.LLMResponse{Model: s.model, StopReason: triage.StopEnd}, nil
+}
+
+func classifyStub(err error) string {
+	if errors.Is(err, errOverloaded) {
+		return FailureOverloaded
+	}
+	return ClassifyError(err)
+}
+
+func TestFallback_Send(t *testing.T) {
+	t.Parallel()
+
+	invalid := errors.New("invalid request")
+	tests := []struct {
+		name         string
+		primaryErrs  []error
+		failOn       []string
+		wantModel    string
+		wantProvider string
+		wantErr      error
+		wantCalls    int // primary calls
+		wantClass    string
+	}{
+		{name: "primary answers", wantModel: "primary-model", wantProvider: ProviderPrimary, wantCalls: 1},
+		{name: "retry succeeds", primaryErrs: []error{errOverloaded}, wantModel: "primary-model", wantProvider: ProviderPrimary, wantCalls: 2},
+		{
+			name:        "repeated failures fall back",
+			primaryErrs: []error{errOverloaded, errOverloaded},
+			wantModel:   "fallback-model", wantProvider: ProviderFallback, wantCalls: 2, wantClass: FailureOverloaded,
+		},
+		{name: "unclassified error returned", primaryErrs: []error{invalid}, wantErr: invalid, wantCalls: 1},
+		{
+			name:        "class not in FailOn",
+			primaryErrs: []error{errOverloaded},
+			failOn:      []string{FailureTimeout},
+			wantErr:     errOverloaded, wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			primary := &stubProvider{model: "primary-model", errs: tt.primaryErrs}
+			var classes []string
+			f := NewFallback(primary, &stubProvider{model: "fallback-model"}, FallbackConfig{
+				Classify:   classifyStub,
+				FailOn:     tt.failOn,
+				OnFallback: func(class string) { classes = append(classes, class) },
+			})
+
+			resp, err := f.Send(context.Background(), &triage.LLMRequest{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Send err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (resp.Model != tt.wantModel || resp.Provider != tt.wantProvider) {
+				t.Errorf("response from %s (%s), want %s (%s)", resp.Model, resp.Provider, tt.wantModel, tt.wantProvider)
+			}
+			if primary.calls != tt.wantCalls {
+				t.Errorf("primary calls = %d, want %d", primary.calls, tt.wantCalls)
+			}
+			if tt.wantClass != "" && (len(classes) != 1 || classes[0] != tt.wantClass) {
+				t.Errorf("fallbacks = %v, want [%s]", classes, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestFallback_TimeoutAndCooldown(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{model: "primary-model", delay: time.Second}
+	var classes []string
+	f := NewFallback(primary, &stubProvider{model: "fallback-model"}, FallbackConfig{
+		Attempts:   1,
+		Timeout:    10 * time.Millisecond,
+		Cooldown:   time.Minute,
+		OnFallback: func(class string) { classes = append(classes, class) },
+	})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	for range 2 {
+		resp, err := f.Send(context.Background(), &triage.LLMRequest{})
+		if err != nil || resp.Provider != ProviderFallback {
+			t.Fatalf("Send = %+v, %v; want fallback response", resp, err)
+		}
+	}
+	if primary.calls != 1 {
+		t.Errorf("primary calls = %d, want 1 before the cooldown skips it", primary.calls)
+	}
+	if len(classes) != 2 || classes[0] != FailureTimeout || classes[1] != "cooldown" {
+		t.Errorf("fallbacks = %v, want [timeout cooldown]", classes)
+	}
+
+	now = now.Add(time.Minute)
+	primary.delay = 0
+	if resp, err := f.Send(context.Background(), &triage.LLMRequest{}); err != nil || resp.Provider != ProviderPrimary {
+		t.Errorf("after cooldown Send = %+v, %v; want primary response", resp, err)
+	}
+}
+
+func TestFallback_CallerCancelled(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{model: "primary-model", delay: time.Second}
+	fallback := &stubProvider{model: "fallback-model"}
+	f := NewFallback(primary, fallback, FallbackConfig{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Send(ctx, &triage.LLMRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send err = %v, want deadline exceeded", err)
+	}
+	if primary.calls != 1 || fallback.calls != 0 {
+		t.Errorf("calls = %d primary, %d fallback; want 1, 0", primary.calls, fallback.calls)
+	}
+}
@@ -0,0 +1,370 @@
+// Package maintenance tells triage when an alert fires during declared
+// maintenance, from windows in a config file and from Alertmanager silences.
+//
+// Alerts during planned work are usually the work itself: a node drain
+// fires NodeNotReady, a database upgrade fires replication lag. Triaging
+// them at full cost mostly rediscovers the change calendar. A window either
+// skips matching alerts or triages them on a reduced budget with the
+// maintenance named in the prompt, so the model weighs it first.
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/filter"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Defaults for the Alertmanager source.
+const (
+	DefaultCommentPattern = "(?i)maintenance"
+	DefaultRefreshSeconds = 60
+)
+
+// Source values reported on triage.MaintenanceWindow.
+const (
+	SourceConfig       = "config"
+	SourceAlertmanager = "alertmanager"
+)
+
+// maxSilencesBytes bounds the silences response from Alertmanager.
+const maxSilencesBytes = 10 << 20
+
+// fetchTimeout bounds one silences request.
+const fetchTimeout = 10 * time.Second
+
+// Window is a maintenance window declared in the config.
+type Window struct {
+	// Name identifies the window in logs, metrics, submit decisions and the
+	// prompt.
+	Name string `json:"name"`
+
+	// Labels are matchers in Alertmanager syntax, such as cluster="db-1" or
+	// namespace=~"payments-.*". An alert must satisfy all of them; a missing
+	// label has the empty value.
+	Labels []string `json:"labels"`
+
+	// Start and End bound the window in RFC 3339.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Mode is "skip" or "lightweight".
+	Mode string `json:"mode"`
+
+	// Comment describes the work and is shown to the model.
+	Comment string `json:"comment"`
+}
+
+// AlertmanagerConfig declares maintenance through Alertmanager silences.
+type AlertmanagerConfig struct {
+	// URL is Alertmanager's base URL, such as http://alertmanager:9093.
+	URL string `json:"url"`
+
+	// CommentPattern selects the silences that declare maintenance, by a
+	// regular expression on their comment. Defaults to DefaultCommentPattern;
+	// ".*" takes every active silence.
+	CommentPattern string `json:"comment_pattern"`
+
+	// Mode is "skip" or "lightweight", defaulting to lightweight.
+	Mode string `json:"mode"`
+
+	// RefreshSeconds is how often silences are fetched, defaulting to
+	// DefaultRefreshSeconds.
+	RefreshSeconds int `json:"refresh_seconds"`
+}
+
+// Config is the maintenance file format.
+type Config struct {
+	Windows      []Window            `json:"windows"`
+	Alertmanager *AlertmanagerConfig `json:"alertmanager"`
+}
+
+// LoadConfig reads and validates a JSON maintenance config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read maintenance config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse maintenance config %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("maintenance config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem in the config.
+func (c *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	for i, w := range c.Windows {
+		if w.Name == "" {
+			errs = append(errs, fmt.Errorf("windows[%d]: name is required", i))
+		} else if names[w.Name] {
+			errs = append(errs, fmt.Errorf("window %q: duplicate name", w.Name))
+		}
+		names[w.Name] = true
+
+		if !validMode(w.Mode) {
+			errs = append(errs, fmt.Errorf("window %q: mode must be skip or lightweight, got %q", w.Name, w.Mode))
+		}
+		if w.Start.IsZero() || w.End.IsZero() {
+			errs = append(errs, fmt.Errorf("window %q: start and end are required", w.Name))
+		} else if !w.End.After(w.Start) {
+			errs = append(errs, fmt.Errorf("window %q: end must be after start", w.Name))
+		}
+		if len(w.Labels) == 0 {
+			errs = append(errs, fmt.Errorf("window %q: at least one label matcher is required", w.Name))
+		}
+		for _, m := range w.Labels {
+			if _, err := filter.ParseMatcher(m); err != nil {
+				errs = append(errs, fmt.Errorf("window %q: labels: %w", w.Name, err))
+			}
+		}
+	}
+	if am := c.Alertmanager; am != nil {
+		if u, err := url.Parse(am.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("alertmanager: url must be an absolute http(s) URL"))
+		}
+		if am.CommentPattern != "" {
+			if _, err := regexp.Compile(am.CommentPattern); err != nil {
+				errs = append(errs, fmt.Errorf("alertmanager: comment_pattern: %w", err))
+			}
+		}
+		if am.Mode != "" && !validMode(am.Mode) {
+			errs = append(errs, fmt.Errorf("alertmanager: mode must be skip or lightweight, got %q", am.Mode))
+		}
+		if am.RefreshSeconds < 0 {
+			errs = append(errs, errors.New("alertmanager: refresh_seconds must not be negative"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validMode(mode string) bool {
+	return mode == triage.MaintenanceSkip || mode == triage.MaintenanceLightweight
+}
+
+// Checker finds the maintenance window covering an alert. It satisfies
+// triage.MaintenanceChecker.
+type Checker struct {
+	windows []window
+	am      *AlertmanagerConfig
+	comment *regexp.Regexp
+	client  *http.Client
+	logger  log.Logger
+
+	// silences are the maintenance windows from the last successful fetch.
+	silences atomic.Pointer[[]window]
+}
+
+type window struct {
+	triage.MaintenanceWindow
+	labels []filter.Matcher
+}
+
+// New builds a Checker from a config. Silences are fetched by Refresh or Run;
+// until the first fetch only the config windows apply.
+func New(c Config, logger log.Logger) (*Checker, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	ch := &Checker{client: &http.Client{Timeout: fetchTimeout}, logger: logger}
+	for _, w := range c.Windows {
+		cw := window{MaintenanceWindow: triage.MaintenanceWindow{
+			Name:     w.Name,
+			Mode:     w.Mode,
+			Comment:  w.Comment,
+			Source:   SourceConfig,
+			StartsAt: w.Start,
+			EndsAt:   w.End,
+		}}
+		for _, s := range w.Labels {
+			m, _ := filter.ParseMatcher(s) // checked by Validate
+			cw.labels = append(cw.labels, m)
+		}
+		ch.windows = append(ch.windows, cw)
+	}
+	if c.Alertmanager != nil {
+		am := *c.Alertmanager
+		if am.CommentPattern == "" {
+			am.CommentPattern = DefaultCommentPattern
+		}
+		if am.Mode == "" {
+			am.Mode = triage.MaintenanceLightweight
+		}
+		if am.RefreshSeconds == 0 {
+			am.RefreshSeconds = DefaultRefreshSeconds
+		}
+		am.URL = strings.TrimRight(am.URL, "/")
+		ch.am = &am
+		ch.comment = regexp.MustCompile(am.CommentPattern)
+	}
+	return ch, nil
+}
+
+// Maintenance returns the first config window, then the first silence,
+// covering al at now, or nil.
+func (c *Checker) Maintenance(al *alert.Alert, now time.Time) *triage.MaintenanceWindow {
+	if w := match(c.windows, al, now); w != nil {
+		return w
+	}
+	if s := c.silences.Load(); s != nil {
+		return match(*s, al, now)
+	}
+	return nil
+}
+
+func match(ws []window, al *alert.Alert, now time.Time) *triage.MaintenanceWindow {
+	for i := range ws {
+		w := &ws[i]
+		if now.Before(w.StartsAt) || !now.Before(w.EndsAt) {
+			continue
+		}
+		if filter.MatchAll(w.labels, al.Labels) {
+			mw := w.MaintenanceWindow
+			return &mw
+		}
+	}
+	return nil
+}
+
+// silence is the part of Alertmanager's v2 silence model used here.
+type silence struct {
+	ID       string `json:"id"`
+	Matchers []struct {
+		Name    string `json:"name"`
+		Value   string `json:"value"`
+		IsRegex bool   `json:"isRegex"`
+		IsEqual *bool  `json:"isEqual"`
+	} `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+	Status    struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// Refresh fetches the active silences whose comment declares maintenance. On
+// error the silences from the last successful fetch keep applying.
+func (c *Checker) Refresh(ctx context.Context) error {
+	if c.am == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.am.URL+"/api/v2/silences", http.NoBody)
+	if err != nil {
+		return fmt.Errorf("fetch silences: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch silences: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch silences: GET %s: %s", req.URL, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSilencesBytes+1))
+	if err != nil {
+		return fmt.Errorf("fetch silences: %w", err)
+	}
+	if len(b) > maxSilencesBytes {
+		return fmt.Errorf("fetch silences: body exceeds %d bytes", maxSilencesBytes)
+	}
+	var silences []silence
+	if err := json.Unmarshal(b, &silences); err != nil {
+		return fmt.Errorf("parse silences: %w", err)
+	}
+
+	ws := make([]window, 0, len(silences))
+	for _, s := range silences {
+		if s.Status.State != "active" || !c.comment.MatchString(s.Comment) {
+			continue
+		}
+		w, err := c.silenceWindow(s)
+		if err != nil {
+			c.logger.Warn(ctx, "maintenance silence ignored", "silence_id", s.ID, "err", err)
+			continue
+		}
+		ws = append(ws, w)
+	}
+	c.silences.Store(&ws)
+	return nil
+}
+
+// silenceWindow converts a silence to a window. A silence with no matchers
+// would cover every alert, so it is rejected.
+func (c *Checker) silenceWindow(s silence) (window, error) {
+	if len(s.Matchers) == 0 {
+		return window{}, errors.New("silence has no matchers")
+	}
+	w := window{MaintenanceWindow: triage.MaintenanceWindow{
+		Name:     "silence:" + s.ID,
+		Mode:     c.am.Mode,
+		Comment:  s.Comment,
+		Source:   SourceAlertmanager,
+		StartsAt: s.StartsAt,
+		EndsAt:   s.EndsAt,
+	}}
+	for _, sm := range s.Matchers {
+		op := "="
+		switch equal := sm.IsEqual == nil || *sm.IsEqual; {
+		case sm.IsRegex && equal:
+			op = "=~"
+		case sm.IsRegex:
+			op = "!~"
+		case !equal:
+			op = "!="
+		}
+		m, err := filter.ParseMatcher(sm.Name + op + strconv.Quote(sm.Value))
+		if err != nil {
+			return window{}, err
+		}
+		w.labels = append(w.labels, m)
+	}
+	return w, nil
+}
+
+// Run fetches silences now and every refresh interval until ctx is done,
+// logging failures. It returns at once when no Alertmanager is configured.
+func (c *Checker) Run(ctx context.Context) {
+	if c.am == nil {
+		return
+	}
+	t := time.NewTicker(time.Duration(c.am.RefreshSeconds) * time.Second)
+	defer t.Stop()
+	for {
+		if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Error(ctx, err, "maintenance silences refresh failed, keeping the previous silences")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
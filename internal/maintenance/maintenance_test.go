@@ -0,0 +1,229 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+var (
+	start = time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	end   = start.Add(2 * time.Hour)
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "maintenance.json")
+	body := `{
+		"windows":[{"name":"db-upgrade","labels":["cluster=\"db-1\""],"start":"2026-03-01T02:00:00Z","end":"2026-03-01T04:00:00Z","mode":"skip","comment":"postgres 17"}],
+		"alertmanager":{"url":"http://alertmanager:9093"}
+	}`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(c.Windows) != 1 || !c.Windows[0].End.Equal(end) || c.Alertmanager == nil {
+		t.Errorf("config = %+v", c)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"windows":[],"silences":true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "silences") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := Window{Name: "a", Labels: []string{"cluster=db-1"}, Start: start, End: end, Mode: "skip"}
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{name: "valid", cfg: Config{Windows: []Window{valid}, Alertmanager: &AlertmanagerConfig{URL: "https://am.example.com"}}},
+		{name: "empty", cfg: Config{}},
+		{
+			name: "window problems",
+			cfg: Config{Windows: []Window{
+				valid,
+				valid,
+				{Labels: []string{"a=b"}, Start: start, End: end, Mode: "skip"},
+				{Name: "backwards", Labels: []string{"a=b"}, Start: end, End: start, Mode: "skip"},
+				{Name: "open", Labels: []string{"a=b"}, Start: start, Mode: "pause"},
+				{Name: "everything", Start: start, End: end, Mode: "lightweight"},
+				{Name: "bad", Labels: []string{"a=~("}, Start: start, End: end, Mode: "skip"},
+			}},
+			wantErr: []string{
+				`window "a": duplicate name`,
+				"windows[2]: name is required",
+				`window "backwards": end must be after start`,
+				`window "open": start and end are required`,
+				`window "open": mode must be skip or lightweight`,
+				`window "everything": at least one label matcher`,
+				`window "bad": labels:`,
+			},
+		},
+		{
+			name: "alertmanager problems",
+			cfg: Config{Alertmanager: &AlertmanagerConfig{
+				URL: "alertmanager:9093", CommentPattern: "(", Mode: "off", RefreshSeconds: -1,
+			}},
+			wantErr: []string{
+				"url must be an absolute http(s) URL",
+				"comment_pattern:",
+				"mode must be skip or lightweight",
+				"refresh_seconds must not be negative",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate: want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestChecker_Windows(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(Config{Windows: []Window{
+		{Name: "db-upgrade", Labels: []string{"cluster=db-1"}, Start: start, End: end, Mode: "skip", Comment: "postgres 17"},
+		{Name: "payments", Labels: []string{`namespace=~"payments-.*"`}, Start: start, End: end, Mode: "lightweight"},
+	}}, log.Nop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		now    time.Time
+		want   string
+	}{
+		{name: "exact match", labels: map[string]string{"cluster": "db-1"}, now: start, want: "db-upgrade"},
+		{name: "regex match", labels: map[string]string{"namespace": "payments-api"}, now: start.Add(time.Hour), want: "payments"},
+		{name: "no match", labels: map[string]string{"cluster": "db-2"}, now: start},
+		{name: "before start", labels: map[string]string{"cluster": "db-1"}, now: start.Add(-time.Second)},
+		{name: "at end", labels: map[string]string{"cluster": "db-1"}, now: end},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := c.Maintenance(&alert.Alert{Labels: tt.labels}, tt.now)
+			switch {
+			case tt.want == "" && w != nil:
+				t.Errorf("Maintenance = %+v, want nil", w)
+			case tt.want != "" && (w == nil || w.Name != tt.want):
+				t.Errorf("Maintenance = %+v, want window %q", w, tt.want)
+			case w != nil && w.Source != SourceConfig:
+				t.Errorf("Source = %q, want %q", w.Source, SourceConfig)
+			}
+		})
+	}
+}
+
+func TestChecker_AlertmanagerSilences(t *testing.T) {
+	t.Parallel()
+
+	body := `[
+		{"id":"s1","status":{"state":"active"},"comment":"Maintenance: kernel patch","createdBy":"ops",
+		 "startsAt":"2026-03-01T02:00:00Z","endsAt":"2026-03-01T04:00:00Z",
+		 "matchers":[{"name":"cluster","value":"k8s-.*","isRegex":true,"isEqual":true},{"name":"severity","value":"info","isRegex":false,"isEqual":false}]},
+		{"id":"s2","status":{"state":"active"},"comment":"flapping, ignore","startsAt":"2026-03-01T02:00:00Z","endsAt":"2026-03-01T04:00:00Z",
+		 "matchers":[{"name":"alertname","value":"Flappy","isRegex":false}]},
+		{"id":"s3","status":{"state":"expired"},"comment":"maintenance","startsAt":"2026-02-01T02:00:00Z","endsAt":"2026-02-01T04:00:00Z",
+		 "matchers":[{"name":"alertname","value":"Old","isRegex":false}]},
+		{"id":"s4","status":{"state":"active"},"comment":"maintenance everywhere","startsAt":"2026-03-01T02:00:00Z","endsAt":"2026-03-01T04:00:00Z",
+		 "matchers":[]}
+	]`
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/silences" {
+			http.NotFound(w, r)
+			return
+		}
+		if fail.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{Alertmanager: &AlertmanagerConfig{URL: srv.URL + "/"}}, log.Nop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := start.Add(time.Hour)
+	node := &alert.Alert{Labels: map[string]string{"alertname": "NodeNotReady", "cluster": "k8s-prod", "severity": "critical"}}
+	if w := c.Maintenance(node, now); w != nil {
+		t.Fatalf("Maintenance before refresh = %+v, want nil", w)
+	}
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	w := c.Maintenance(node, now)
+	if w == nil {
+		t.Fatal("Maintenance = nil, want the kernel patch silence")
+	}
+	if w.Name != "silence:s1" || w.Mode != triage.MaintenanceLightweight || w.Source != SourceAlertmanager || w.Comment != "Maintenance: kernel patch" {
+		t.Errorf("window = %+v", w)
+	}
+	for _, al := range []*alert.Alert{
+		{Labels: map[string]string{"cluster": "k8s-prod", "severity": "info"}}, // negative matcher
+		{Labels: map[string]string{"alertname": "Flappy"}},                     // comment does not declare maintenance
+		{Labels: map[string]string{"alertname": "Old"}},                        // expired
+		{Labels: map[string]string{"alertname": "Anything"}},                   // no matchers
+	} {
+		if w := c.Maintenance(al, now); w != nil {
+			t.Errorf("Maintenance(%v) = %+v, want nil", al.Labels, w)
+		}
+	}
+	if w := c.Maintenance(node, end); w != nil {
+		t.Errorf("Maintenance after the silence ends = %+v, want nil", w)
+	}
+
+	// A failed refresh keeps the silences already fetched.
+	fail.Store(true)
+	if err := c.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh: want error")
+	}
+	if w := c.Maintenance(node, now); w == nil {
+		t.Error("Maintenance after a failed refresh = nil, want the previous silence")
+	}
+}
@@ -0,0 +1,343 @@
+// Package mcp registers tools offered by external Model Context Protocol
+// servers, so operators can give the triage agent custom investigation
+// capabilities without changing Vigil.
+//
+// Servers are declared in a JSON config and reached either by starting a
+// command that speaks MCP over stdio or through an HTTP server-sent events
+// endpoint. Each server's tools are discovered at startup and registered
+// under the server's name.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// protocolVersion is the MCP revision Vigil speaks, the last one to define
+// the SSE transport.
+const protocolVersion = "2024-11-05"
+
+// handshakeTimeout bounds connecting to a server and listing its tools.
+const handshakeTimeout = 30 * time.Second
+
+// rpcError is a JSON-RPC error returned by the server.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  any              `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+// session is one initialized connection to a server.
+type session struct {
+	t transport
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan *rpcMessage
+
+	done chan struct{}
+}
+
+func newSession(t transport) *session {
+	s := &session{t: t, pending: make(map[int64]chan *rpcMessage), done: make(chan struct{})}
+	go s.readLoop()
+	return s
+}
+
+// readLoop hands responses to their waiting calls and answers the server's
+// pings until the transport closes.
+func (s *session) readLoop() {
+	defer close(s.done)
+	for b := range s.t.messages() {
+		var m rpcMessage
+		if err := json.Unmarshal(b, &m); err != nil || m.ID == nil {
+			continue // malformed, or a notification
+		}
+		if m.Method != "" {
+			s.answer(&m)
+			continue
+		}
+		id, err := strconv.ParseInt(string(*m.ID), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.mu.Lock()
+		ch := s.pending[id]
+		delete(s.pending, id)
+		s.mu.Unlock()
+		if ch != nil {
+			ch <- &m
+		}
+	}
+}
+
+// answer replies to a request from the server. Vigil offers no client
+// features, so anything but a ping is refused.
+func (s *session) answer(req *rpcMessage) {
+	resp := rpcMessage{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &rpcError{Code: -32601, Message: "method not found"}
+	}
+	if b, err := json.Marshal(resp); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.t.send(ctx, b)
+	}
+}
+
+// alive reports whether the connection is still open.
+func (s *session) alive() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// call sends a request and decodes its result into result.
+func (s *session) call(ctx context.Context, method string, params, result any) error {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	ch := make(chan *rpcMessage, 1)
+	s.pending[id] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	raw := json.RawMessage(strconv.FormatInt(id, 10))
+	b, err := json.Marshal(rpcMessage{JSONRPC: "2.0", ID: &raw, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if err := s.t.send(ctx, b); err != nil {
+		return fmt.Errorf("send %s: %w", method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+		return nil
+	case <-s.done:
+		if err := s.t.err(); err != nil {
+			return err
+		}
+		return errors.New("connection closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *session) notify(ctx context.Context, method string) error {
+	b, err := json.Marshal(rpcMessage{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	return s.t.send(ctx, b)
+}
+
+// Client is a connection to one MCP server. A command server that exits or
+// a stream that drops is reconnected on the next call.
+type Client struct {
+	srv    Server
+	logger log.Logger
+
+	mu   sync.Mutex
+	sess *session
+}
+
+// Connect starts or dials the server and completes the MCP handshake.
+func Connect(ctx context.Context, srv Server, logger log.Logger) (*Client, error) {
+	c := &Client{srv: srv, logger: logger}
+	if _, err := c.session(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Name returns the server's configured name.
+func (c *Client) Name() string { return c.srv.Name }
+
+// session returns the open session, reconnecting if it has closed.
+func (c *Client) session(ctx context.Context) (*session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sess != nil && c.sess.alive() {
+		return c.sess, nil
+	}
+	if c.sess != nil {
+		c.logger.Warn(ctx, "mcp server connection lost, reconnecting", "server", c.srv.Name, "err", c.sess.t.err())
+		_ = c.sess.t.close()
+		c.sess = nil
+	}
+
+	t, err := dial(ctx, &c.srv, c.logger)
+	if err != nil {
+		return nil, err
+	}
+	s := newSession(t)
+	params := map[string]any{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]string{"name": "vigil", "version": "1"},
+	}
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	if err := s.call(ctx, "initialize", params, &init); err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("mcp %s: initialize: %w", c.srv.Name, err)
+	}
+	if err := s.notify(ctx, "notifications/initialized"); err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("mcp %s: initialized: %w", c.srv.Name, err)
+	}
+	c.logger.Info(ctx, "mcp server connected", "server", c.srv.Name,
+		"server_name", init.ServerInfo.Name, "server_version", init.ServerInfo.Version, "protocol", init.ProtocolVersion)
+	c.sess = s
+	return s, nil
+}
+
+// call runs method on the current session.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	s, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	return s.call(ctx, method, params, result)
+}
+
+// remoteTool is a tool as listed by the server.
+type remoteTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// listTools returns the server's tools, following pagination.
+func (c *Client) listTools(ctx context.Context) ([]remoteTool, error) {
+	var out []remoteTool
+	cursor := ""
+	for {
+		var params any
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		var page struct {
+			Tools      []remoteTool `json:"tools"`
+			NextCursor string       `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("mcp %s: list tools: %w", c.srv.Name, err)
+		}
+		out = append(out, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return out, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// content is one block of a tool call result. Only text is passed to the
+// model.
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError"`
+}
+
+// callTool invokes the named tool on the server.
+func (c *Client) callTool(ctx context.Context, name string, args json.RawMessage) (*callResult, error) {
+	if len(args) == 0 {
+		args = json.RawMessage(`{}`)
+	}
+	var res callResult
+	params := map[string]any{"name": name, "arguments": args}
+	if err := c.call(ctx, "tools/call", params, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Close shuts the connection down, stopping a command server.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sess == nil {
+		return nil
+	}
+	err := c.sess.t.close()
+	c.sess = nil
+	return err
+}
+
+// Clients are the connections to every configured server.
+type Clients []*Client
+
+// ConnectAll connects to every server in c. On error the servers already
+// connected are closed again.
+func ConnectAll(ctx context.Context, c Config, logger log.Logger) (Clients, error) {
+	out := make(Clients, 0, len(c.Servers))
+	for _, srv := range c.Servers {
+		cctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+		client, err := Connect(cctx, srv, logger)
+		cancel()
+		if err != nil {
+			_ = out.Close(ctx)
+			return nil, err
+		}
+		out = append(out, client)
+	}
+	return out, nil
+}
+
+// Close closes every connection. It has the signature of a shutdown step.
+func (cs Clients) Close(context.Context) error {
+	var errs []error
+	for _, c := range slices.Backward(cs) {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
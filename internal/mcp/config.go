@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+)
+
+// Server is one entry in the MCP config.
+type Server struct {
+	// Name identifies the server and prefixes its tools, so a server "k8s"
+	// offering "get_pods" registers the tool "k8s__get_pods".
+	Name string `json:"name"`
+
+	// Command starts a server speaking MCP over stdin and stdout, with Env
+	// added to Vigil's environment.
+	Command []string          `json:"command"`
+	Env     map[string]string `json:"env"`
+
+	// URL is the SSE endpoint of a remote server, sent Headers on every
+	// request. Exactly one of Command and URL is set.
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+
+	// Tools limits which of the server's tools are registered, by their
+	// name on the server. Empty registers them all.
+	Tools []string `json:"tools"`
+
+	// TimeoutSeconds bounds one tool call. Zero means 30 seconds.
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// Config is the MCP file format.
+type Config struct {
+	Servers []Server `json:"servers"`
+}
+
+// serverNameRe keeps server names usable in LLM tool names.
+var serverNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// LoadConfig reads and validates a JSON MCP config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read mcp config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse mcp config %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("mcp config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem in the config.
+func (c *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	for i, s := range c.Servers {
+		switch {
+		case s.Name == "":
+			errs = append(errs, fmt.Errorf("servers[%d]: name is required", i))
+		case !serverNameRe.MatchString(s.Name):
+			errs = append(errs, fmt.Errorf("server %q: name must be 1-32 letters, digits, _ or -", s.Name))
+		case names[s.Name]:
+			errs = append(errs, fmt.Errorf("server %q: duplicate name", s.Name))
+		}
+		names[s.Name] = true
+
+		switch {
+		case len(s.Command) == 0 && s.URL == "":
+			errs = append(errs, fmt.Errorf("server %q: command or url is required", s.Name))
+		case len(s.Command) > 0 && s.URL != "":
+			errs = append(errs, fmt.Errorf("server %q: set only one of command and url", s.Name))
+		case len(s.Command) > 0 && s.Command[0] == "":
+			errs = append(errs, fmt.Errorf("server %q: command is empty", s.Name))
+		case s.URL != "":
+			if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("server %q: url must be an absolute http or https URL", s.Name))
+			}
+		}
+		if len(s.Env) > 0 && s.URL != "" {
+			errs = append(errs, fmt.Errorf("server %q: env only applies to command servers", s.Name))
+		}
+		if len(s.Headers) > 0 && len(s.Command) > 0 {
+			errs = append(errs, fmt.Errorf("server %q: headers only apply to url servers", s.Name))
+		}
+		if s.TimeoutSeconds < 0 || s.TimeoutSeconds > 600 {
+			errs = append(errs, fmt.Errorf("server %q: timeout_seconds must be 0..600, got %d", s.Name, s.TimeoutSeconds))
+		}
+	}
+	return errors.Join(errs...)
+}
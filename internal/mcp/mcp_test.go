@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// The test binary doubles as a stdio MCP server when this is set.
+const helperEnv = "VIGIL_MCP_TEST_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		runStdioServer()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeServer answers MCP requests with two tools: echo returns its
+// arguments, fail reports a tool error.
+func fakeServer(req map[string]any) any {
+	switch req["method"] {
+	case "initialize":
+		return map[string]any{"protocolVersion": protocolVersion, "serverInfo": map[string]string{"name": "fake", "version": "0.1"}}
+	case "tools/list":
+		params, _ := req["params"].(map[string]any)
+		if params["cursor"] == "page2" {
+			return map[string]any{"tools": []map[string]any{{"name": "fail", "description": "Always fails."}}}
+		}
+		return map[string]any{
+			"tools":      []map[string]any{{"name": "echo", "description": "Echoes its input.", "inputSchema": map[string]any{"type": "object"}}},
+			"nextCursor": "page2",
+		}
+	case "tools/call":
+		params, _ := req["params"].(map[string]any)
+		switch params["name"] {
+		case "echo":
+			args, _ := json.Marshal(params["arguments"])
+			return map[string]any{"content": []map[string]string{{"type": "text", "text": string(args)}}}
+		case "fail":
+			return map[string]any{"isError": true, "content": []map[string]string{{"type": "text", "text": "no such pod"}}}
+		}
+		return nil
+	}
+	return nil
+}
+
+// reply builds the response to a raw request, or nil for notifications.
+func reply(line []byte) []byte {
+	var req map[string]any
+	if err := json.Unmarshal(line, &req); err != nil || req["id"] == nil {
+		return nil
+	}
+	resp := map[string]any{"jsonrpc": "2.0", "id": req["id"]}
+	if res := fakeServer(req); res != nil {
+		resp["result"] = res
+	} else {
+		resp["error"] = map[string]any{"code": -32602, "message": "unknown tool"}
+	}
+	b, _ := json.Marshal(resp)
+	return b
+}
+
+func runStdioServer() {
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		if os.Getenv("VIGIL_MCP_TEST_CRASH") == "1" && strings.Contains(sc.Text(), `"tools/call"`) {
+			os.Exit(3)
+		}
+		if b := reply(sc.Bytes()); b != nil {
+			fmt.Println(string(b))
+		}
+	}
+}
+
+func stdioServer(t *testing.T, env map[string]string) Server {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env == nil {
+		env = map[string]string{}
+	}
+	env[helperEnv] = "1"
+	return Server{Name: "fake", Command: []string{exe}, Env: env}
+}
+
+// sseServer serves the fake server over the SSE transport.
+func sseServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	var stream chan []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ch := make(chan []byte, 16)
+		mu.Lock()
+		stream = ch
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": hello\n\nevent: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case b := <-ch:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if b := reply(raw); b != nil {
+			mu.Lock()
+			stream <- b
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_Tools(t *testing.T) {
+	t.Parallel()
+
+	sse := sseServer(t)
+	tests := []struct {
+		name string
+		srv  Server
+	}{
+		{"stdio", stdioServer(t, nil)},
+		{"sse", Server{Name: "fake", URL: sse.URL + "/sse", Headers: map[string]string{"Authorization": "Bearer secret"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			c, err := Connect(ctx, tt.srv, log.Nop())
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer func() { _ = c.Close() }()
+
+			ts, err := c.Tools(ctx)
+			if err != nil {
+				t.Fatalf("Tools: %v", err)
+			}
+			if len(ts) != 2 || ts[0].Name() != "fake__echo" || ts[1].Name() != "fake__fail" {
+				t.Fatalf("tools = %v, want fake__echo and fake__fail across both pages", ts)
+			}
+			if got := ts[0].Description(); got != "Echoes its input. (from the fake MCP server)" {
+				t.Errorf("description = %q", got)
+			}
+			if got := string(ts[1].Parameters()); got != `{"type":"object","properties":{}}` {
+				t.Errorf("default schema = %s", got)
+			}
+
+			out, err := ts[0].Execute(ctx, json.RawMessage(`{"pod":"web-1"}`))
+			if err != nil {
+				t.Fatalf("Execute echo: %v", err)
+			}
+			if string(out) != `{"pod":"web-1"}` {
+				t.Errorf("echo = %s, want the arguments back as JSON", out)
+			}
+
+			_, err = ts[1].Execute(ctx, nil)
+			if err == nil || !strings.Contains(err.Error(), "no such pod") || errors.Is(err, tools.ErrUnavailable) {
+				t.Errorf("fail = %v, want the tool's error, not unavailable", err)
+			}
+		})
+	}
+}
+
+func TestClient_ToolsAllowlist(t *testing.T) {
+	t.Parallel()
+
+	srv := stdioServer(t, nil)
+	srv.Tools = []string{"echo"}
+	c, err := Connect(context.Background(), srv, log.Nop())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	ts, err := c.Tools(context.Background())
+	if err != nil || len(ts) != 1 || ts[0].Name() != "fake__echo" {
+		t.Fatalf("Tools = %v, %v; want only fake__echo", ts, err)
+	}
+
+	c.srv.Tools = []string{"echo", "missing"}
+	if _, err := c.Tools(context.Background()); err == nil || !strings.Contains(err.Error(), `no tool "missing"`) {
+		t.Errorf("err = %v, want missing tool error", err)
+	}
+}
+
+func TestTool_ServerCrash(t *testing.T) {
+	t.Parallel()
+
+	c, err := Connect(context.Background(), stdioServer(t, map[string]string{"VIGIL_MCP_TEST_CRASH": "1"}), log.Nop())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	ts, err := c.Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := ts[0].Execute(ctx, nil); !errors.Is(err, tools.ErrUnavailable) {
+		t.Fatalf("err = %v, want unavailable after the server exits", err)
+	}
+	// The next call starts the server again.
+	if _, err := c.Tools(ctx); err != nil {
+		t.Errorf("Tools after reconnect: %v", err)
+	}
+}
+
+func TestConnect_Errors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if _, err := Connect(ctx, Server{Name: "x", Command: []string{"/nonexistent/mcp-server"}}, log.Nop()); err == nil {
+		t.Error("Connect started a missing command")
+	}
+
+	sse := sseServer(t)
+	if _, err := Connect(ctx, Server{Name: "x", URL: sse.URL + "/sse"}, log.Nop()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want the 401", err)
+	}
+
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: https://attacker.example/messages\n\n")
+		w.(http.Flusher).Flush()
+	}))
+	defer foreign.Close()
+	if _, err := Connect(ctx, Server{Name: "x", URL: foreign.URL}, log.Nop()); err == nil || !strings.Contains(err.Error(), "is not on") {
+		t.Errorf("err = %v, want the off-origin endpoint refused", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("é", maxOutputBytes)
+	got := truncate(long)
+	if !strings.HasSuffix(got, fmt.Sprintf("[truncated: %d of %d bytes shown]", maxOutputBytes, len(long))) {
+		t.Errorf("truncate suffix = %q", got[len(got)-60:])
+	}
+	if short := "fine"; truncate(short) != short {
+		t.Error("truncate changed a short string")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mcp.json")
+	body := `{"servers":[
+		{"name":"k8s","command":["kubectl-mcp","--read-only"],"env":{"KUBECONFIG":"/etc/kube"},"tools":["get_pods"]},
+		{"name":"gh","url":"https://mcp.example.com/sse","headers":{"Authorization":"Bearer x"},"timeout_seconds":10}
+	]}`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(c.Servers) != 2 || c.Servers[0].Command[1] != "--read-only" || c.Servers[1].TimeoutSeconds != 10 {
+		t.Errorf("config = %+v", c)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"servers":[{"name":"a","command":["x"],"args":["y"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "args") {
+		t.Errorf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			cfg:  Config{Servers: []Server{{Name: "a", Command: []string{"x"}}, {Name: "b-2", URL: "http://mcp:8080/sse"}}},
+		},
+		{
+			name:    "empty server",
+			cfg:     Config{Servers: []Server{{}}},
+			wantErr: []string{"name is required", "command or url is required"},
+		},
+		{
+			name: "bad fields",
+			cfg: Config{Servers: []Server{
+				{Name: "a.b", Command: []string{"x"}, URL: "http://x", TimeoutSeconds: -1},
+				{Name: "c", URL: "mcp:8080", Env: map[string]string{"A": "1"}},
+				{Name: "c", Command: []string{""}, Headers: map[string]string{"A": "1"}},
+			}},
+			wantErr: []string{"name must be", "only one of command and url", "timeout_seconds must be 0..600", "absolute http or https", "env only applies", "duplicate name", "command is empty", "headers only apply"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate returned nil, want errors")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+		})
+	}
+}
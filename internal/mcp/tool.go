@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+const (
+	defaultCallTimeout = 30 * time.Second
+
+	// maxOutputBytes caps the text a tool call returns to the model.
+	maxOutputBytes = 64 << 10
+
+	// Separator between the server and tool name in registered names.
+	nameSep = "__"
+)
+
+// toolNameRe is what the Claude API accepts as a tool name.
+var toolNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Tools lists the server's tools, keeps those the config allows, and wraps
+// each as a tools.Tool named "<server>__<tool>". A tool whose name would not
+// be a valid LLM tool name is an error.
+func (c *Client) Tools(ctx context.Context) ([]tools.Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	remote, err := c.listTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultCallTimeout
+	if c.srv.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.srv.TimeoutSeconds) * time.Second
+	}
+	var out []tools.Tool
+	seen := make(map[string]bool)
+	for _, rt := range remote {
+		if len(c.srv.Tools) > 0 && !slices.Contains(c.srv.Tools, rt.Name) {
+			continue
+		}
+		seen[rt.Name] = true
+		name := c.srv.Name + nameSep + rt.Name
+		if !toolNameRe.MatchString(name) {
+			return nil, fmt.Errorf("mcp %s: tool %q: name %q must be at most 64 letters, digits, _ or -", c.srv.Name, rt.Name, name)
+		}
+		schema := rt.InputSchema
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		out = append(out, &tool{
+			client:      c,
+			name:        name,
+			remote:      rt.Name,
+			description: fmt.Sprintf("%s (from the %s MCP server)", strings.TrimSpace(rt.Description), c.srv.Name),
+			schema:      schema,
+			timeout:     timeout,
+		})
+	}
+	for _, want := range c.srv.Tools {
+		if !seen[want] {
+			return nil, fmt.Errorf("mcp %s: server has no tool %q", c.srv.Name, want)
+		}
+	}
+	return out, nil
+}
+
+// tool is a tool offered by an MCP server.
+type tool struct {
+	client      *Client
+	name        string
+	remote      string
+	description string
+	schema      json.RawMessage
+	timeout     time.Duration
+}
+
+func (t *tool) Name() string                { return t.name }
+func (t *tool) Description() string         { return t.description }
+func (t *tool) Parameters() json.RawMessage { return t.schema }
+
+// Execute calls the tool on its server. Failing to reach the server counts
+// against the tool's circuit breaker; an error the server or tool reports
+// is returned to the model as is. Text output that is JSON is passed
+// through, anything else becomes a JSON string.
+func (t *tool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	res, err := t.client.callTool(ctx, t.remote, params)
+	if err != nil {
+		var rerr *rpcError
+		if errors.As(err, &rerr) {
+			return nil, fmt.Errorf("%s: %w", t.name, err)
+		}
+		return nil, fmt.Errorf("%s: %w: %w", t.name, tools.ErrUnavailable, err)
+	}
+
+	var texts []string
+	for _, c := range res.Content {
+		if c.Type == "text" {
+			texts = append(texts, c.Text)
+		} else {
+			texts = append(texts, fmt.Sprintf("[%s content omitted]", c.Type))
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if res.IsError {
+		return nil, fmt.Errorf("%s: %s", t.name, truncate(text))
+	}
+	if len(res.Content) == 1 && len(text) <= maxOutputBytes && json.Valid([]byte(text)) {
+		return json.RawMessage(text), nil
+	}
+	return json.Marshal(truncate(text))
+}
+
+func truncate(s string) string {
+	if len(s) <= maxOutputBytes {
+		return s
+	}
+	cut := maxOutputBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("\n[truncated: %d of %d bytes shown]", cut, len(s))
+}
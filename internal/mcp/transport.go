@@ -0,0 +1,310 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// maxMessageBytes caps one incoming JSON-RPC message.
+const maxMessageBytes = 16 << 20
+
+// stopGrace is how long a command server gets to exit after its stdin is
+// closed before it is killed.
+const stopGrace = 2 * time.Second
+
+// transport carries JSON-RPC messages to and from one server.
+type transport interface {
+	send(ctx context.Context, msg []byte) error
+	// messages delivers incoming messages and is closed when the connection
+	// ends, after which err reports why.
+	messages() <-chan []byte
+	err() error
+	close() error
+}
+
+// dial connects to s over the transport its config selects.
+func dial(ctx context.Context, s *Server, logger log.Logger) (transport, error) {
+	if len(s.Command) > 0 {
+		return startCommand(s, logger)
+	}
+	return dialSSE(ctx, s)
+}
+
+// stdioTransport runs a server as a child process and exchanges
+// newline-delimited messages over its stdin and stdout.
+type stdioTransport struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	in    chan []byte
+	exit  chan struct{}
+
+	wmu sync.Mutex
+
+	mu      sync.Mutex
+	exitErr error
+}
+
+func startCommand(s *Server, logger log.Logger) (*stdioTransport, error) {
+	// The process outlives the dial context, so it is not started with one.
+	cmd := exec.Command(s.Command[0], s.Command[1:]...) //nolint:gosec,noctx // G204: the command is supplied by the operator
+	cmd.Env = os.Environ()
+	for k, v := range s.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = &stderrLog{logger: logger, server: s.Name}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp %s: %w", s.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp %s: %w", s.Name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp %s: start %s: %w", s.Name, s.Command[0], err)
+	}
+
+	t := &stdioTransport{cmd: cmd, stdin: stdin, in: make(chan []byte), exit: make(chan struct{})}
+	go func() {
+		defer close(t.in)
+		sc := bufio.NewScanner(stdout)
+		sc.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+		for sc.Scan() {
+			if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+				t.in <- bytes.Clone(line)
+			}
+		}
+		// Wait only after stdout is drained, as exec.Cmd requires.
+		err := sc.Err()
+		if werr := cmd.Wait(); err == nil {
+			err = werr
+		}
+		if err == nil {
+			err = errors.New("server exited")
+		}
+		t.mu.Lock()
+		t.exitErr = fmt.Errorf("mcp %s: %w", s.Name, err)
+		t.mu.Unlock()
+		close(t.exit)
+	}()
+	return t, nil
+}
+
+func (t *stdioTransport) send(_ context.Context, msg []byte) error {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	_, err := t.stdin.Write(append(msg, '\n'))
+	return err
+}
+
+func (t *stdioTransport) messages() <-chan []byte { return t.in }
+
+func (t *stdioTransport) err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exitErr
+}
+
+// close closes the server's stdin, which the MCP spec treats as the signal
+// to exit, and kills the process if it has not exited after stopGrace.
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	select {
+	case <-t.exit:
+		return nil
+	case <-time.After(stopGrace):
+		return t.cmd.Process.Kill()
+	}
+}
+
+// stderrLog logs a command server's stderr line by line.
+type stderrLog struct {
+	logger log.Logger
+	server string
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *stderrLog) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.buf[:i])); line != "" {
+			w.logger.Info(context.Background(), "mcp server stderr", "server", w.server, "line", line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// sseTransport is the HTTP with server-sent events transport: responses
+// arrive on a long-lived event stream, and requests are POSTed to the
+// endpoint the stream announces first.
+type sseTransport struct {
+	server   string
+	client   *http.Client
+	headers  map[string]string
+	endpoint string
+	body     io.ReadCloser
+	in       chan []byte
+
+	mu      sync.Mutex
+	readErr error
+}
+
+func dialSSE(ctx context.Context, s *Server) (*sseTransport, error) {
+	base, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("mcp %s: %w", s.Name, err)
+	}
+	// The stream outlives the dial context, so it gets its own.
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, s.URL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("mcp %s: %w", s.Name, err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	// No client timeout: it would cut the event stream.
+	client := &http.Client{}
+	resp, err := client.Do(req) //nolint:gosec // G704: URL is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("mcp %s: %w", s.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("mcp %s: event stream returned %d", s.Name, resp.StatusCode)
+	}
+
+	t := &sseTransport{server: s.Name, client: client, headers: s.Headers, body: resp.Body, in: make(chan []byte)}
+	endpoint := make(chan string, 1)
+	go t.read(bufio.NewReader(resp.Body), endpoint)
+
+	select {
+	case ep, ok := <-endpoint:
+		if !ok {
+			return nil, fmt.Errorf("mcp %s: event stream closed before announcing an endpoint: %w", s.Name, t.err())
+		}
+		u, err := base.Parse(ep)
+		// Requests carry the configured headers, so they must not leave the
+		// configured origin.
+		if err != nil || u.Scheme != base.Scheme || u.Host != base.Host {
+			_ = t.close()
+			return nil, fmt.Errorf("mcp %s: endpoint %q is not on %s", s.Name, ep, base.Host)
+		}
+		t.endpoint = u.String()
+	case <-ctx.Done():
+		_ = t.close()
+		return nil, fmt.Errorf("mcp %s: waiting for endpoint: %w", s.Name, ctx.Err())
+	}
+	return t, nil
+}
+
+// read parses the event stream, handing the first endpoint event to endpoint
+// and message events to t.in.
+func (t *sseTransport) read(r *bufio.Reader, endpoint chan<- string) {
+	defer close(t.in)
+	announced := false
+	defer func() {
+		if !announced {
+			close(endpoint)
+		}
+	}()
+
+	var event string
+	var data bytes.Buffer
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.mu.Lock()
+			t.readErr = fmt.Errorf("mcp %s: event stream: %w", t.server, err)
+			t.mu.Unlock()
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			// A blank line dispatches the event.
+			switch event {
+			case "endpoint":
+				if !announced {
+					endpoint <- strings.TrimSpace(data.String())
+					announced = true
+				}
+			case "", "message":
+				if data.Len() > 0 {
+					t.in <- bytes.Clone(data.Bytes())
+				}
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment, used as keepalive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		if data.Len() > maxMessageBytes {
+			t.mu.Lock()
+			t.readErr = fmt.Errorf("mcp %s: event exceeds %d bytes", t.server, maxMessageBytes)
+			t.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (t *sseTransport) send(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req) //nolint:gosec // G704: endpoint is on the operator-supplied origin
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mcp %s: post returned %d", t.server, resp.StatusCode)
+	}
+	return nil
+}
+
+func (t *sseTransport) messages() <-chan []byte { return t.in }
+
+func (t *sseTransport) err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.readErr
+}
+
+func (t *sseTransport) close() error {
+	return t.body.Close()
+}
@@ -0,0 +1,153 @@
+// Package discord sends triage notifications to Discord via webhooks, as
+// rich embeds.
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Discord's embed limits, in characters.
+const (
+	maxTitleLen       = 256
+	maxDescriptionLen = 4096
+	maxFieldValueLen  = 1024
+	maxFooterLen      = 2048
+)
+
+const (
+	// maxAnalysisLen leaves room in the description for the links after
+	// the analysis.
+	maxAnalysisLen = 3500
+	httpTimeout    = 10 * time.Second
+)
+
+// Notifier sends triage results to a Discord webhook.
+type Notifier struct {
+	webhookURL string
+	baseURL    string
+	client     *http.Client
+	logger     log.Logger
+}
+
+// Option configures optional Notifier behavior.
+type Option func(*Notifier)
+
+// WithExternalURL links each embed to its triage in the web UI served at
+// baseURL. Empty leaves the embeds without a link.
+func WithExternalURL(baseURL string) Option {
+	return func(n *Notifier) { n.baseURL = baseURL }
+}
+
+// New creates a new Discord notifier. If webhookURL is empty, Send is a
+// no-op.
+func New(webhookURL string, logger log.Logger, opts ...Option) *Notifier {
+	n := &Notifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: httpTimeout},
+		logger:     logger,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Send posts a triage result to the configured webhook.
+// If no webhook URL is configured, it returns nil immediately.
+func (n *Notifier) Send(ctx context.Context, result *triage.Result) error {
+	if n.webhookURL == "" {
+		return nil
+	}
+	body, err := n.Render(result)
+	if err != nil {
+		return err
+	}
+	n.logger.Debug(ctx, "discord webhook request", "body", string(body))
+	return notify.PostJSON(ctx, n.client, "discord", n.webhookURL, body)
+}
+
+// Render returns the webhook payload Send would post for result: one embed,
+// colored by level, with the run's figures as inline fields.
+func (n *Notifier) Render(result *triage.Result) ([]byte, error) {
+	body, err := json.Marshal(map[string]any{
+		"embeds": []map[string]any{n.buildEmbed(result)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discord: marshal message: %w", err)
+	}
+	return body, nil
+}
+
+func (n *Notifier) buildEmbed(r *triage.Result) map[string]any {
+	m := notify.NewMessage(r)
+	triageURL := notify.TriageURL(n.baseURL, r.ID)
+
+	fields := make([]map[string]any, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		fields = append(fields, map[string]any{
+			"name":   f.Name,
+			"value":  fieldValue(f.Value),
+			"inline": true,
+		})
+	}
+
+	description := notify.Truncate(m.Analysis, maxAnalysisLen)
+	if description == "" {
+		description = "_No analysis available._"
+	}
+	var links []string
+	if triageURL != "" && description != m.Analysis {
+		links = append(links, fmt.Sprintf("[Full analysis](%s)", triageURL))
+	}
+	for _, l := range m.Links {
+		links = append(links, fmt.Sprintf("[%s](%s)", l.Label, l.URL))
+	}
+	if len(links) > 0 {
+		description += "\n\n" + strings.Join(links, " · ")
+	}
+
+	embed := map[string]any{
+		"title":       notify.Truncate(m.Level.Emoji()+" "+m.Title, maxTitleLen),
+		"description": notify.Truncate(description, maxDescriptionLen),
+		"color":       color(m.Level),
+		"fields":      fields,
+		"footer":      map[string]any{"text": notify.Truncate(m.Footer, maxFooterLen)},
+	}
+	if triageURL != "" {
+		embed["url"] = triageURL
+	}
+	ts := r.CompletedAt
+	if ts.IsZero() {
+		ts = r.CreatedAt
+	}
+	if !ts.IsZero() {
+		embed["timestamp"] = ts.UTC().Format(time.RFC3339)
+	}
+	return embed
+}
+
+// fieldValue fits a value to an embed field, which Discord rejects when
+// empty.
+func fieldValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return notify.Truncate(v, maxFieldValueLen)
+}
+
+// color returns the level's color as the integer embeds take.
+func color(l notify.Level) int {
+	c, _ := strconv.ParseInt(strings.TrimPrefix(l.Color(), "#"), 16, 32) // Color is always #rrggbb
+	return int(c)
+}
@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+type embed struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+	Timestamp   string `json:"timestamp"`
+	Fields      []struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline"`
+	} `json:"fields"`
+	Footer struct {
+		Text string `json:"text"`
+	} `json:"footer"`
+}
+
+func TestSend_PostsEmbed(t *testing.T) {
+	t.Parallel()
+
+	var got struct {
+		Embeds []embed `json:"embeds"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	result := &triage.Result{
+		ID:          "t1",
+		Status:      triage.StatusComplete,
+		Alert:       "HighCPU",
+		Severity:    "warning",
+		Analysis:    "A runaway process.",
+		Model:       "claude-sonnet-4-20250514",
+		TokensIn:    1200,
+		TokensOut:   340,
+		Duration:    4.2,
+		CompletedAt: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+	}
+	n := New(srv.URL, log.Nop(), WithExternalURL("https://vigil.example/"))
+	if err := n.Send(context.Background(), result); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(got.Embeds) != 1 {
+		t.Fatalf("embeds = %d, want 1", len(got.Embeds))
+	}
+	e := got.Embeds[0]
+	if !strings.HasSuffix(e.Title, "Triage Complete: HighCPU") {
+		t.Errorf("title = %q", e.Title)
+	}
+	if e.URL != "https://vigil.example/ui/#/triage/t1" {
+		t.Errorf("url = %q", e.URL)
+	}
+	if e.Description != "A runaway process." {
+		t.Errorf("description = %q, want the analysis without a link", e.Description)
+	}
+	if e.Color != 0xfbc02d {
+		t.Errorf("color = %#x, want %#x", e.Color, 0xfbc02d)
+	}
+	if e.Timestamp != "2026-01-02T15:04:00Z" {
+		t.Errorf("timestamp = %q", e.Timestamp)
+	}
+	want := map[string]string{"Duration": "4.2s", "Tokens": "1200 in / 340 out", "Model": "claude-sonnet-4"}
+	for _, f := range e.Fields {
+		if !f.Inline || f.Value == "" {
+			t.Errorf("field %+v, want inline with a value", f)
+		}
+		if v, ok := want[f.Name]; ok && v != f.Value {
+			t.Errorf("field %s = %q, want %q", f.Name, f.Value, v)
+		}
+		delete(want, f.Name)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing fields %v", want)
+	}
+}
+
+func TestBuildEmbed_TruncatedAnalysisLinks(t *testing.T) {
+	t.Parallel()
+
+	r := &triage.Result{
+		ID:       "t1",
+		Status:   triage.StatusComplete,
+		Analysis: strings.Repeat("x", maxAnalysisLen+100),
+		IssueURL: "https://github.com/acme/ops/issues/7",
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{"with external url", "https://vigil.example", "...\n\n[Full analysis](https://vigil.example/ui/#/triage/t1) · [Issue](https://github.com/acme/ops/issues/7)"},
+		{"without external url", "", "...\n\n[Issue](https://github.com/acme/ops/issues/7)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e := New("https://discord.example/hook", log.Nop(), WithExternalURL(tt.baseURL)).buildEmbed(r)
+			desc := e["description"].(string)
+			if !strings.HasSuffix(desc, tt.want) {
+				t.Errorf("description ends %q, want %q", desc[len(desc)-120:], tt.want)
+			}
+			if len(desc) > maxDescriptionLen {
+				t.Errorf("description is %d long, over the %d limit", len(desc), maxDescriptionLen)
+			}
+		})
+	}
+}
+
+func TestBuildEmbed_EmptyFieldValues(t *testing.T) {
+	t.Parallel()
+
+	e := New("https://discord.example/hook", log.Nop()).buildEmbed(&triage.Result{ID: "t1", Status: triage.StatusFailed})
+	for _, f := range e["fields"].([]map[string]any) {
+		if f["value"] == "" {
+			t.Errorf("field %v has an empty value", f["name"])
+		}
+	}
+	if _, ok := e["url"]; ok {
+		t.Errorf("url set without an external URL: %v", e["url"])
+	}
+}
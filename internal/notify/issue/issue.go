@@ -0,0 +1,279 @@
+// Package issue opens GitHub or GitLab issues for finished triages, so a
+// diagnosis that needs follow-up work lands in the team's backlog with a
+// link back to the triage.
+package issue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Supported trackers.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+
+	defaultGitHubAPI = "https://api.github.com"
+	defaultGitLabAPI = "https://gitlab.com/api/v4"
+
+	// maxTitleLen stays under GitHub's 256 character title limit.
+	maxTitleLen = 250
+)
+
+// DefaultTemplate renders the issue body: the verdict first, then the
+// numbers behind it.
+const DefaultTemplate = `**{{.Alert}}**{{with .Severity}} ({{.}}){{end}}{{with .Summary}}: {{.}}{{end}}
+
+## Root cause
+
+{{.RootCause}}
+
+## Analysis
+
+{{.Analysis}}
+
+## Triage
+
+| | |
+|---|---|
+| Status | {{.Status}} |
+| Fingerprint | ` + "`{{.Fingerprint}}`" + ` |
+| Model | {{.Model}} |
+| Duration | {{printf "%.1f" .Duration}}s |
+| Tool calls | {{.ToolCalls}} |
+| Tokens | {{.TokensIn}} in / {{.TokensOut}} out |
+{{- if .CostUSD}}
+| Cost | ${{printf "%.2f" .CostUSD}} |
+{{- end}}
+{{- with .GeneratorURL}}
+| Source | [alert rule]({{.}}) |
+{{- end}}
+{{with .TriageURL}}
+[View the triage in Vigil]({{.}})
+{{- else}}
+Vigil triage ` + "`{{.ID}}`" + `
+{{- end}}
+`
+
+// TemplateData is what issue templates are executed with: the triage result
+// plus values derived from it.
+type TemplateData struct {
+	*triage.Result
+	// RootCause is the root cause section of the analysis, or the whole
+	// analysis when it has none.
+	RootCause string
+	// CostUSD is the estimated LLM cost, zero for models without a price.
+	CostUSD float64
+	// TriageURL links to the triage in the UI, empty without a base URL.
+	TriageURL string
+}
+
+// ParseTemplate parses an issue body template.
+func ParseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("issue").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse issue template: %w", err)
+	}
+	return t, nil
+}
+
+// LoadTemplate reads and parses an issue body template file.
+func LoadTemplate(path string) (*template.Template, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("read issue template: %w", err)
+	}
+	return ParseTemplate(string(b))
+}
+
+// Tracker opens issues through the GitHub or GitLab REST API. It satisfies
+// triage.IssueTracker.
+type Tracker struct {
+	kind    string
+	project string
+	token   string
+	apiURL  string
+	labels  []string
+	tmpl    *template.Template
+	baseURL string
+	client  *http.Client
+}
+
+// Option configures optional Tracker behavior.
+type Option func(*Tracker)
+
+// WithAPIURL points the tracker at a GitHub Enterprise or self-managed
+// GitLab API instead of the public one.
+func WithAPIURL(u string) Option {
+	return func(t *Tracker) {
+		if u != "" {
+			t.apiURL = strings.TrimRight(u, "/")
+		}
+	}
+}
+
+// WithLabels sets the labels of opened issues.
+func WithLabels(labels []string) Option {
+	return func(t *Tracker) { t.labels = labels }
+}
+
+// WithTemplate replaces DefaultTemplate for issue bodies.
+func WithTemplate(tmpl *template.Template) Option {
+	return func(t *Tracker) { t.tmpl = tmpl }
+}
+
+// WithBaseURL links issues back to the triage in the UI served at base.
+func WithBaseURL(base string) Option {
+	return func(t *Tracker) { t.baseURL = strings.TrimRight(base, "/") }
+}
+
+// New creates a tracker that opens issues in project, owner/repo on GitHub
+// or the project path on GitLab, authenticating with token.
+func New(kind, project, token string, opts ...Option) (*Tracker, error) {
+	t := &Tracker{
+		kind:    kind,
+		project: project,
+		token:   token,
+		client:  &http.Client{Timeout: httpTimeout},
+	}
+	switch kind {
+	case GitHub:
+		t.apiURL = defaultGitHubAPI
+	case GitLab:
+		t.apiURL = defaultGitLabAPI
+	default:
+		return nil, fmt.Errorf("issue: unknown tracker %q", kind)
+	}
+	if project == "" || token == "" {
+		return nil, errors.New("issue: project and token are required")
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.tmpl == nil {
+		tmpl, err := ParseTemplate(DefaultTemplate)
+		if err != nil {
+			return nil, err
+		}
+		t.tmpl = tmpl
+	}
+	return t, nil
+}
+
+// Render returns the title and body of the issue for r.
+func (t *Tracker) Render(r *triage.Result) (title, body string, err error) {
+	data := TemplateData{Result: r, RootCause: triage.RootCause(r.Analysis)}
+	if price, ok := triage.PriceOf(r.Model); ok {
+		data.CostUSD = price.Cost(int64(r.TokensIn), int64(r.TokensOut))
+	}
+	if t.baseURL != "" {
+		data.TriageURL = t.baseURL + "/ui/#/triage/" + url.PathEscape(r.ID)
+	}
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", "", fmt.Errorf("issue: render body: %w", err)
+	}
+	return issueTitle(r), b.String(), nil
+}
+
+func issueTitle(r *triage.Result) string {
+	title := "[Vigil] " + r.Alert
+	if s := strings.Join(strings.Fields(r.Summary), " "); s != "" {
+		title += ": " + s
+	}
+	if runes := []rune(title); len(runes) > maxTitleLen {
+		title = string(runes[:maxTitleLen-1]) + "…"
+	}
+	return title
+}
+
+// CreateIssue opens an issue for r and returns its web URL.
+func (t *Tracker) CreateIssue(ctx context.Context, r *triage.Result) (string, error) {
+	title, body, err := t.Render(r)
+	if err != nil {
+		return "", err
+	}
+	if t.kind == GitLab {
+		return t.createGitLab(ctx, title, body)
+	}
+	return t.createGitHub(ctx, title, body)
+}
+
+func (t *Tracker) createGitHub(ctx context.Context, title, body string) (string, error) {
+	payload := map[string]any{"title": title, "body": body}
+	if len(t.labels) > 0 {
+		payload["labels"] = t.labels
+	}
+	var resp struct {
+		HTMLURL string `json:"html_url"`
+	}
+	header := http.Header{
+		"Authorization":        {"Bearer " + t.token},
+		"Accept":               {"application/vnd.github+json"},
+		"X-GitHub-Api-Version": {"2022-11-28"},
+	}
+	if err := t.post(ctx, t.apiURL+"/repos/"+t.project+"/issues", header, payload, &resp); err != nil {
+		return "", err
+	}
+	return resp.HTMLURL, nil
+}
+
+func (t *Tracker) createGitLab(ctx context.Context, title, body string) (string, error) {
+	payload := map[string]any{"title": title, "description": body}
+	if len(t.labels) > 0 {
+		payload["labels"] = strings.Join(t.labels, ",")
+	}
+	var resp struct {
+		WebURL string `json:"web_url"`
+	}
+	header := http.Header{"PRIVATE-TOKEN": {t.token}}
+	if err := t.post(ctx, t.apiURL+"/projects/"+url.PathEscape(t.project)+"/issues", header, payload, &resp); err != nil {
+		return "", err
+	}
+	return resp.WebURL, nil
+}
+
+// post sends payload as JSON and decodes a 2xx response into out.
+func (t *Tracker) post(ctx context.Context, endpoint string, header http.Header, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("issue: marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("issue: create request: %w", err)
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req) //nolint:gosec // G704: endpoint is built from trusted config, not user input
+	if err != nil {
+		return fmt.Errorf("issue: %s: %w", t.kind, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("issue: %s returned %d: %s", t.kind, resp.StatusCode, string(msg))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("issue: decode %s response: %w", t.kind, err)
+	}
+	return nil
+}
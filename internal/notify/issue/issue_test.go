@@ -0,0 +1,170 @@
+package issue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func testResult() *triage.Result {
+	return &triage.Result{
+		ID:          "01JTRIAGE",
+		Fingerprint: "abc123",
+		Status:      triage.StatusComplete,
+		Alert:       "DiskFull",
+		Severity:    "critical",
+		Summary:     "Disk /var is 98% full",
+		Analysis:    "1. What is happening\nDisk filling.\n\n2. Likely root cause\nLog rotation stopped on db-1.\n\n3. Recommended actions\nRestart logrotate.",
+		Model:       "claude-sonnet-4-20250514",
+		Duration:    42.5,
+		ToolCalls:   4,
+		TokensIn:    100000,
+		TokensOut:   2000,
+	}
+}
+
+func TestCreateIssue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		kind     string
+		project  string
+		wantPath string
+		wantAuth [2]string
+		reply    string
+		want     string
+	}{
+		{GitHub, "acme/ops", "/repos/acme/ops/issues", [2]string{"Authorization", "Bearer tok"}, `{"html_url":"https://github.com/acme/ops/issues/7"}`, "https://github.com/acme/ops/issues/7"},
+		{GitLab, "acme/infra/ops", "/projects/acme%2Finfra%2Fops/issues", [2]string{"Private-Token", "tok"}, `{"web_url":"https://gitlab.com/acme/infra/ops/-/issues/3"}`, "https://gitlab.com/acme/infra/ops/-/issues/3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			t.Parallel()
+
+			var got map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != tt.wantPath {
+					t.Errorf("path = %s, want %s", r.URL.EscapedPath(), tt.wantPath)
+				}
+				if v := r.Header.Get(tt.wantAuth[0]); v != tt.wantAuth[1] {
+					t.Errorf("%s = %q, want %q", tt.wantAuth[0], v, tt.wantAuth[1])
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(tt.reply))
+			}))
+			defer srv.Close()
+
+			tr, err := New(tt.kind, tt.project, "tok", WithAPIURL(srv.URL), WithLabels([]string{"vigil", "incident"}), WithBaseURL("https://vigil.example.com/"))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			u, err := tr.CreateIssue(context.Background(), testResult())
+			if err != nil {
+				t.Fatalf("CreateIssue: %v", err)
+			}
+			if u != tt.want {
+				t.Errorf("url = %q, want %q", u, tt.want)
+			}
+
+			if got["title"] != "[Vigil] DiskFull: Disk /var is 98% full" {
+				t.Errorf("title = %v", got["title"])
+			}
+			body, _ := got["body"].(string)
+			labels := any([]any{"vigil", "incident"})
+			if tt.kind == GitLab {
+				body, _ = got["description"].(string)
+				labels = "vigil,incident"
+			}
+			if !strings.Contains(body, "## Root cause\n\nLog rotation stopped on db-1.") ||
+				!strings.Contains(body, "[View the triage in Vigil](https://vigil.example.com/ui/#/triage/01JTRIAGE)") {
+				t.Errorf("body missing verdict or link:\n%s", body)
+			}
+			if gotLabels, _ := json.Marshal(got["labels"]); string(gotLabels) != mustJSON(labels) {
+				t.Errorf("labels = %s, want %s", gotLabels, mustJSON(labels))
+			}
+		})
+	}
+}
+
+func mustJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestCreateIssue_Error(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	tr, err := New(GitHub, "acme/ops", "bad", WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := tr.CreateIssue(context.Background(), testResult()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want the 401", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New(GitHub, "acme/ops", "tok")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, body, err := tr.Render(testResult())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{
+		"**DiskFull** (critical): Disk /var is 98% full",
+		"| Duration | 42.5s |",
+		"| Cost | $0.33 |",
+		"Vigil triage `01JTRIAGE`",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+
+	long := testResult()
+	long.Summary = strings.Repeat("x", 300)
+	if title, _, _ := tr.Render(long); len([]rune(title)) != maxTitleLen {
+		t.Errorf("title length = %d, want %d", len([]rune(title)), maxTitleLen)
+	}
+
+	custom, err := ParseTemplate("{{.Alert}} -> {{.RootCause}}")
+	if err != nil {
+		t.Fatalf("ParseTemplate: %v", err)
+	}
+	tr, _ = New(GitLab, "ops", "tok", WithTemplate(custom))
+	if _, body, _ := tr.Render(testResult()); body != "DiskFull -> Log rotation stopped on db-1." {
+		t.Errorf("custom body = %q", body)
+	}
+
+	if _, err := ParseTemplate("{{.Alert"); err == nil {
+		t.Error("ParseTemplate accepted a broken template")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New("jira", "ops", "tok"); err == nil {
+		t.Error("New accepted an unknown tracker")
+	}
+	if _, err := New(GitHub, "", "tok"); err == nil {
+		t.Error("New accepted an empty project")
+	}
+}
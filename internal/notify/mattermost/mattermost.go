@@ -0,0 +1,98 @@
+// Package mattermost sends triage notifications to Mattermost via incoming
+// webhooks.
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const (
+	// maxAnalysisLen keeps the attachment well inside Mattermost's 16383
+	// character post limit.
+	maxAnalysisLen = 6000
+	httpTimeout    = 10 * time.Second
+)
+
+// Notifier sends triage results to a Mattermost incoming webhook.
+type Notifier struct {
+	webhookURL string
+	client     *http.Client
+	logger     log.Logger
+}
+
+// New creates a new Mattermost notifier. If webhookURL is empty, Send is a
+// no-op.
+func New(webhookURL string, logger log.Logger) *Notifier {
+	return &Notifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: httpTimeout},
+		logger:     logger,
+	}
+}
+
+// Send posts a triage result to the configured webhook.
+// If no webhook URL is configured, it returns nil immediately.
+func (n *Notifier) Send(ctx context.Context, result *triage.Result) error {
+	if n.webhookURL == "" {
+		return nil
+	}
+	body, err := Render(result)
+	if err != nil {
+		return err
+	}
+	n.logger.Debug(ctx, "mattermost webhook request", "body", string(body))
+	return notify.PostJSON(ctx, n.client, "mattermost", n.webhookURL, body)
+}
+
+// Render returns the webhook payload Send would post for result: one
+// message attachment, colored by level, in Mattermost's Slack-compatible
+// format.
+func Render(result *triage.Result) ([]byte, error) {
+	body, err := json.Marshal(buildMessage(notify.NewMessage(result)))
+	if err != nil {
+		return nil, fmt.Errorf("mattermost: marshal message: %w", err)
+	}
+	return body, nil
+}
+
+func buildMessage(m notify.Message) map[string]any {
+	title := m.Level.Emoji() + " " + m.Title
+
+	fields := make([]map[string]any, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		fields = append(fields, map[string]any{"title": f.Name, "value": f.Value, "short": true})
+	}
+
+	text := notify.Truncate(m.Analysis, maxAnalysisLen)
+	if text == "" {
+		text = "_No analysis available._"
+	}
+	if len(m.Links) > 0 {
+		links := make([]string, 0, len(m.Links))
+		for _, l := range m.Links {
+			links = append(links, fmt.Sprintf("[%s](%s)", l.Label, l.URL))
+		}
+		text += "\n\n" + strings.Join(links, " · ")
+	}
+
+	return map[string]any{
+		"attachments": []map[string]any{{
+			"fallback": title,
+			"color":    m.Level.Color(),
+			"title":    title,
+			"fields":   fields,
+			"text":     text,
+			"footer":   m.Footer,
+		}},
+	}
+}
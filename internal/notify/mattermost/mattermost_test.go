@@ -0,0 +1,97 @@
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestSend_PostsAttachment(t *testing.T) {
+	t.Parallel()
+
+	var got struct {
+		Attachments []struct {
+			Color  string `json:"color"`
+			Title  string `json:"title"`
+			Text   string `json:"text"`
+			Footer string `json:"footer"`
+			Fields []struct {
+				Title string `json:"title"`
+				Value string `json:"value"`
+				Short bool   `json:"short"`
+			} `json:"fields"`
+		} `json:"attachments"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	result := &triage.Result{
+		ID:          "t1",
+		Status:      triage.StatusComplete,
+		Alert:       "HighCPU",
+		Severity:    "critical",
+		Analysis:    "A runaway process.",
+		Model:       "claude-sonnet-4-20250514",
+		IssueURL:    "https://github.com/acme/ops/issues/7",
+		CompletedAt: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+	}
+	if err := New(srv.URL, log.Nop()).Send(context.Background(), result); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(got.Attachments) != 1 {
+		t.Fatalf("attachments = %d, want 1", len(got.Attachments))
+	}
+	a := got.Attachments[0]
+	if a.Color != notify.LevelCritical.Color() {
+		t.Errorf("color = %q, want %q", a.Color, notify.LevelCritical.Color())
+	}
+	if !strings.HasSuffix(a.Title, "Triage Complete: HighCPU") {
+		t.Errorf("title = %q", a.Title)
+	}
+	if !strings.HasPrefix(a.Text, "A runaway process.") || !strings.Contains(a.Text, "[Issue](https://github.com/acme/ops/issues/7)") {
+		t.Errorf("text = %q, want the analysis and an issue link", a.Text)
+	}
+	if a.Footer != "vigil • triage t1 • 2026-01-02 15:04 UTC" {
+		t.Errorf("footer = %q", a.Footer)
+	}
+	if len(a.Fields) == 0 || a.Fields[0].Title != "Status" || a.Fields[0].Value != "complete" || !a.Fields[0].Short {
+		t.Errorf("fields = %+v, want status first", a.Fields)
+	}
+}
+
+func TestSend_NonOKStatus(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad hook", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL, log.Nop()).Send(context.Background(), &triage.Result{ID: "t1"})
+	if err == nil || !strings.Contains(err.Error(), "mattermost: webhook returned 400") {
+		t.Fatalf("Send error = %v, want a 400 error", err)
+	}
+}
+
+func TestSend_NoOpWithoutURL(t *testing.T) {
+	t.Parallel()
+
+	if err := New("", log.Nop()).Send(context.Background(), &triage.Result{ID: "t1"}); err != nil {
+		t.Errorf("Send with no URL = %v, want nil", err)
+	}
+}
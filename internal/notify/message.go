@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Level is how alarming a message is, which backends show as an emoji or a
+// color.
+type Level string
+
+// Message levels, from the triage's outcome and the alert's severity.
+const (
+	LevelFailed   Level = "failed"
+	LevelStopped  Level = "stopped"
+	LevelCritical Level = "critical"
+	LevelWarning  Level = "warning"
+	LevelOK       Level = "ok"
+)
+
+// LevelOf returns the level of a triage with the given outcome. A triage
+// that did not finish is flagged as such whatever the alert's severity.
+func LevelOf(status triage.Status, severity string) Level {
+	switch status {
+	case triage.StatusFailed, triage.StatusError:
+		return LevelFailed
+	case triage.StatusMaxTurns, triage.StatusBudgetExceeded, triage.StatusRefused:
+		return LevelStopped
+	}
+	switch strings.ToLower(severity) {
+	case "critical":
+		return LevelCritical
+	case "warning":
+		return LevelWarning
+	default:
+		return LevelOK
+	}
+}
+
+// Emoji returns the emoji leading the message title.
+func (l Level) Emoji() string {
+	switch l {
+	case LevelFailed, LevelCritical:
+		return "\U0001f534" // red circle
+	case LevelStopped:
+		return "\u26a0\ufe0f" // warning sign
+	case LevelWarning:
+		return "\U0001f7e1" // yellow circle
+	default:
+		return "\U0001f7e2" // green circle
+	}
+}
+
+// Color returns the level as a hex RGB color, for attachment and embed
+// sidebars.
+func (l Level) Color() string {
+	switch l {
+	case LevelFailed, LevelCritical:
+		return "#d32f2f"
+	case LevelStopped:
+		return "#f57c00"
+	case LevelWarning:
+		return "#fbc02d"
+	default:
+		return "#388e3c"
+	}
+}
+
+// Field is one labeled value in a message's summary. Values may span lines.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Link is a labeled link in a message's footer.
+type Link struct {
+	Label string
+	URL   string
+}
+
+// Message is a triage result laid out for a chat notification, without any
+// backend's markup. Each backend renders the same parts, so they agree on
+// what a notification says.
+type Message struct {
+	Level Level
+	// Title is how the triage ended and the alert name, e.g.
+	// "Triage Complete: HighCPU".
+	Title  string
+	Fields []Field
+	// Analysis is the model's analysis in Markdown, untruncated; empty when
+	// there is none.
+	Analysis string
+	// Footer identifies the triage and when it finished.
+	Footer string
+	// Links are the runbook and issue, when the result has them.
+	Links []Link
+}
+
+// NewMessage lays out r as a Message.
+func NewMessage(r *triage.Result) Message {
+	m := Message{
+		Level:    LevelOf(r.Status, r.Severity),
+		Title:    fmt.Sprintf("%s: %s", Title(r.Status), r.Alert),
+		Analysis: r.Analysis,
+	}
+
+	tokens := fmt.Sprintf("%d in / %d out", r.TokensIn, r.TokensOut)
+	if r.TokensThinking > 0 {
+		tokens += fmt.Sprintf(" (%d thinking)", r.TokensThinking)
+	}
+	m.Fields = []Field{
+		{"Status", string(r.Status)},
+		{"Severity", r.Severity},
+		{"Duration", fmt.Sprintf("%.1fs", r.Duration)},
+		{"Model", ShortModel(r.Model)},
+		{"Tokens", tokens},
+		{"Tool calls", toolCalls(r)},
+	}
+	if price, ok := triage.PriceOf(r.Model); ok {
+		m.Fields = append(m.Fields, Field{"Est. cost", FormatCost(price.Cost(int64(r.TokensIn), int64(r.TokensOut)))})
+	}
+	if md := r.Metadata; md != nil {
+		if md.Owner != "" {
+			m.Fields = append(m.Fields, Field{"Owner", md.Owner})
+		}
+		if md.Tier != "" {
+			m.Fields = append(m.Fields, Field{"Tier", md.Tier})
+		}
+		if len(md.Dependencies) > 0 {
+			m.Fields = append(m.Fields, Field{"Depends on", strings.Join(md.Dependencies, ", ")})
+		}
+	}
+
+	ts := r.CompletedAt
+	if ts.IsZero() {
+		ts = r.CreatedAt
+	}
+	m.Footer = fmt.Sprintf("vigil • triage %s • %s", r.ID, ts.UTC().Format("2006-01-02 15:04 UTC"))
+	if r.Metadata != nil && r.Metadata.Runbook != "" {
+		m.Links = append(m.Links, Link{"Runbook", r.Metadata.Runbook})
+	}
+	if r.IssueURL != "" {
+		m.Links = append(m.Links, Link{"Issue", r.IssueURL})
+	}
+	return m
+}
+
+// toolCalls counts calls per tool from the conversation, e.g.
+// "5\nquery_metrics ×3, query_logs ×2", falling back to the tool names when
+// the result comes without one, as on an outbox retry.
+func toolCalls(r *triage.Result) string {
+	text := fmt.Sprint(r.ToolCalls)
+	counts := triage.ToolCallCounts(r.Conversation)
+	if len(counts) == 0 {
+		if len(r.ToolsUsed) > 0 {
+			text += " (" + strings.Join(r.ToolsUsed, ", ") + ")"
+		}
+		return text
+	}
+	parts := make([]string, 0, len(counts))
+	for _, c := range counts {
+		part := fmt.Sprintf("%s ×%d", c.Tool, c.Calls)
+		if c.Errors > 0 {
+			part += fmt.Sprintf(" (%d failed)", c.Errors)
+		}
+		parts = append(parts, part)
+	}
+	return text + "\n" + strings.Join(parts, ", ")
+}
+
+// FormatCost shows cents, or that the cost was below one.
+func FormatCost(usd float64) string {
+	if usd < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// dateModelRe matches model names ending with a YYYYMMDD date suffix.
+var dateModelRe = regexp.MustCompile(`-\d{8}$`)
+
+// ShortModel drops the date suffix from a model name.
+func ShortModel(model string) string {
+	return dateModelRe.ReplaceAllString(model, "")
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestLevelOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   triage.Status
+		severity string
+		want     string
+	}{
+		{"failed", triage.StatusFailed, "warning", "\U0001f534"},
+		{"error", triage.StatusError, "info", "\U0001f534"},
+		{"max turns", triage.StatusMaxTurns, "critical", "\u26a0\ufe0f"},
+		{"budget exceeded", triage.StatusBudgetExceeded, "info", "\u26a0\ufe0f"},
+		{"critical", triage.StatusComplete, "critical", "\U0001f534"},
+		{"warning", triage.StatusComplete, "warning", "\U0001f7e1"},
+		{"info", triage.StatusComplete, "info", "\U0001f7e2"},
+		{"empty", triage.StatusComplete, "", "\U0001f7e2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := LevelOf(tt.status, tt.severity).Emoji()
+			if got != tt.want {
+				t.Errorf("LevelOf(%q, %q).Emoji() = %q, want %q", tt.status, tt.severity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortModel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"claude-sonnet-4-20250514", "claude-sonnet-4"},
+		{"claude-opus-4-20250514", "claude-opus-4"},
+		{"gpt-4o", "gpt-4o"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			if got := ShortModel(tt.input); got != tt.want {
+				t.Errorf("ShortModel(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
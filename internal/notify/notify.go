@@ -0,0 +1,283 @@
+// Package notify holds what the notifiers share: the wording of a triage's
+// outcome, and the operator's message templates with the data they are
+// rendered from.
+//
+// Templates are Go text/template documents, grouped by name. A named
+// template has a variant per alert severity, plus an optional fallback for
+// severities without one, so a team can page loudly for critical alerts and
+// summarize warnings. A notifier given a template renders each result with
+// the variant for its severity, and keeps its built-in message when there is
+// none.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// DefaultTemplate names the template of the server's default notifier.
+const DefaultTemplate = "default"
+
+// AnySeverity keys the variant used for severities without their own.
+const AnySeverity = "*"
+
+// Title says how a triage ended, so a run cut short by its budget is not
+// mistaken for a finished analysis.
+func Title(status triage.Status) string {
+	switch status {
+	case triage.StatusFailed, triage.StatusError:
+		return "Triage Failed"
+	case triage.StatusMaxTurns:
+		return "Triage Stopped (tool call limit)"
+	case triage.StatusBudgetExceeded:
+		return "Triage Stopped (token budget)"
+	case triage.StatusRefused:
+		return "Triage Refused"
+	default:
+		return "Triage Complete"
+	}
+}
+
+// Truncate cuts s to at most limit bytes, ending it with "..." when cut.
+func Truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit-3] + "..."
+}
+
+// Data is what message templates are executed with: the triage result plus
+// values derived from it.
+type Data struct {
+	*triage.Result
+	// Title says how the triage ended, see Title.
+	Title string
+	// RootCause is the root cause section of the analysis, or the whole
+	// analysis when it has none.
+	RootCause string
+	// CostUSD is the estimated LLM cost, zero for models without a price.
+	CostUSD float64
+	// TriageURL links to the triage in the UI, empty without a base URL.
+	TriageURL string
+}
+
+// NewData derives the template data for r. baseURL is where the UI is
+// served, empty for none.
+func NewData(r *triage.Result, baseURL string) Data {
+	d := Data{Result: r, Title: Title(r.Status), RootCause: triage.RootCause(r.Analysis)}
+	if price, ok := triage.PriceOf(r.Model); ok {
+		d.CostUSD = price.Cost(int64(r.TokensIn), int64(r.TokensOut))
+	}
+	d.TriageURL = TriageURL(baseURL, r.ID)
+	return d
+}
+
+// TriageURL links to a triage in the web UI, or returns "" without a base
+// URL.
+func TriageURL(baseURL, id string) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(baseURL, "/") + "/ui/#/triage/" + url.PathEscape(id)
+}
+
+// TraceURL links to a trace in a tracing UI such as Jaeger or Grafana.
+// "{trace_id}" in baseURL is replaced with the ID, for UIs that take it in a
+// query; otherwise the ID is appended as a path segment. It returns "" if
+// either is empty.
+func TraceURL(baseURL, traceID string) string {
+	if baseURL == "" || traceID == "" {
+		return ""
+	}
+	if strings.Contains(baseURL, "{trace_id}") {
+		return strings.ReplaceAll(baseURL, "{trace_id}", url.QueryEscape(traceID))
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + url.PathEscape(traceID)
+}
+
+// funcs are available to every template.
+var funcs = template.FuncMap{
+	"truncate": func(n int, s string) string { return Truncate(s, n) },
+	"join":     strings.Join,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// Config is the templates file format: template names to the template text
+// per severity, AnySeverity for the rest.
+type Config struct {
+	Templates map[string]map[string]string `json:"templates"`
+}
+
+// LoadConfig reads and validates a JSON templates file.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read notify templates: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse notify templates %s: %w", path, err)
+	}
+	if _, err := New(c, ""); err != nil {
+		return c, fmt.Errorf("notify templates %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Templates is a parsed set of named templates.
+type Templates struct {
+	byName map[string]*Template
+}
+
+// New parses every template in c and renders each against sample results,
+// so a template referring to a field that does not exist fails here rather
+// than on the first notification. baseURL fills Data.TriageURL.
+func New(c Config, baseURL string) (*Templates, error) {
+	var errs []error
+	ts := &Templates{byName: make(map[string]*Template)}
+	for _, name := range slices.Sorted(maps.Keys(c.Templates)) {
+		variants := c.Templates[name]
+		if name == "" {
+			errs = append(errs, errors.New("template name is required"))
+			continue
+		}
+		if len(variants) == 0 {
+			errs = append(errs, fmt.Errorf("template %q: at least one severity is required", name))
+			continue
+		}
+		t := &Template{name: name, baseURL: baseURL, bySeverity: make(map[string]*template.Template)}
+		for _, sev := range slices.Sorted(maps.Keys(variants)) {
+			key := strings.ToLower(sev)
+			if _, dup := t.bySeverity[key]; dup || key == "" {
+				errs = append(errs, fmt.Errorf("template %q: severity %q is empty or repeated", name, sev))
+				continue
+			}
+			tmpl, err := template.New(name + "/" + key).Funcs(funcs).Parse(variants[sev])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("template %q: %w", name, err))
+				continue
+			}
+			for _, r := range SampleResults() {
+				if err := tmpl.Execute(new(strings.Builder), NewData(r, baseURL)); err != nil {
+					errs = append(errs, fmt.Errorf("template %q: render %s result: %w", name, r.Status, err))
+					break
+				}
+			}
+			t.bySeverity[key] = tmpl
+		}
+		ts.byName[name] = t
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Get returns the named template, or nil when there is none. A nil
+// Templates has none.
+func (ts *Templates) Get(name string) *Template {
+	if ts == nil {
+		return nil
+	}
+	return ts.byName[name]
+}
+
+// Names lists the templates in order.
+func (ts *Templates) Names() []string {
+	if ts == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(ts.byName))
+}
+
+// Template is one named template with its variants by severity.
+type Template struct {
+	name       string
+	baseURL    string
+	bySeverity map[string]*template.Template
+}
+
+// Name returns the template's name.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Variant returns the severity whose variant renders r, AnySeverity for the
+// fallback, or "" when the template has none for r.
+func (t *Template) Variant(r *triage.Result) string {
+	if t == nil {
+		return ""
+	}
+	if sev := strings.ToLower(r.Severity); t.bySeverity[sev] != nil {
+		return sev
+	}
+	if t.bySeverity[AnySeverity] != nil {
+		return AnySeverity
+	}
+	return ""
+}
+
+// Render executes the variant for r's severity. ok is false when t is nil
+// or has no variant for it, in which case the notifier's built-in message
+// applies.
+func (t *Template) Render(r *triage.Result) (text string, ok bool, err error) {
+	v := t.Variant(r)
+	if v == "" {
+		return "", false, nil
+	}
+	var b strings.Builder
+	if err := t.bySeverity[v].Execute(&b, NewData(r, t.baseURL)); err != nil {
+		return "", true, fmt.Errorf("notify: render template %q: %w", t.name, err)
+	}
+	return b.String(), true, nil
+}
+
+// SampleResults returns results that templates are checked against: a
+// completed critical triage with every common field set, and a failed
+// warning with almost none.
+func SampleResults() []*triage.Result {
+	created := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	return []*triage.Result{
+		{
+			ID:           "01NOTIFYTEMPLATESAMPLE001",
+			Fingerprint:  "0123456789abcdef",
+			Status:       triage.StatusComplete,
+			Alert:        "HighCPU",
+			Severity:     "critical",
+			Summary:      "CPU usage above 90% for 5 minutes",
+			GeneratorURL: "http://prometheus.example/graph?g0.expr=up",
+			Labels:       map[string]string{"alertname": "HighCPU", "severity": "critical", "instance": "web-1"},
+			Annotations:  map[string]string{"summary": "CPU usage above 90% for 5 minutes"},
+			Analysis:     "## Root cause\n\nA runaway process on web-1 is saturating all cores.",
+			ToolsUsed:    []string{"query_metrics", "query_logs"},
+			CreatedAt:    created,
+			CompletedAt:  created.Add(42 * time.Second),
+			Duration:     42,
+			TokensIn:     1200,
+			TokensOut:    340,
+			ToolCalls:    3,
+			Model:        "claude-sonnet-4-20250514",
+		},
+		{
+			ID:        "01NOTIFYTEMPLATESAMPLE002",
+			Status:    triage.StatusFailed,
+			Alert:     "DiskFull",
+			Severity:  "warning",
+			CreatedAt: created,
+		},
+	}
+}
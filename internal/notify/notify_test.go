@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status triage.Status
+		want   string
+	}{
+		{triage.StatusComplete, "Triage Complete"},
+		{triage.StatusFailed, "Triage Failed"},
+		{triage.StatusError, "Triage Failed"},
+		{triage.StatusMaxTurns, "Triage Stopped (tool call limit)"},
+		{triage.StatusBudgetExceeded, "Triage Stopped (token budget)"},
+		{triage.StatusRefused, "Triage Refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			t.Parallel()
+			if got := Title(tt.status); got != tt.want {
+				t.Errorf("Title(%q) = %q, want %q", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceURL(t *testing.T) {
+	t.Parallel()
+
+	const id = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name string
+		base string
+		id   string
+		want string
+	}{
+		{"appended", "https://jaeger.example.com/trace/", id, "https://jaeger.example.com/trace/" + id},
+		{"placeholder", "https://grafana.example.com/explore?traceId={trace_id}&orgId=1", id, "https://grafana.example.com/explore?traceId=" + id + "&orgId=1"},
+		{"no base", "", id, ""},
+		{"no trace", "https://jaeger.example.com/trace", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := TraceURL(tt.base, tt.id); got != tt.want {
+				t.Errorf("TraceURL(%q, %q) = %q, want %q", tt.base, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		c         Config
+		errSubstr string
+	}{
+		{
+			name: "valid",
+			c: Config{Templates: map[string]map[string]string{
+				"default": {"*": "{{.Title}}: {{.Alert}} {{truncate 40 .RootCause}}"},
+				"oncall":  {"critical": "<!here> {{upper .Alert}} {{.Labels.instance}}", "warning": "{{.Alert}}"},
+			}},
+		},
+		{
+			name:      "parse error",
+			c:         Config{Templates: map[string]map[string]string{"default": {"*": "{{.Alert"}}},
+			errSubstr: `template "default"`,
+		},
+		{
+			name:      "unknown field",
+			c:         Config{Templates: map[string]map[string]string{"default": {"*": "{{.NoSuchField}}"}}},
+			errSubstr: "render complete result",
+		},
+		{
+			name:      "no severities",
+			c:         Config{Templates: map[string]map[string]string{"default": {}}},
+			errSubstr: "at least one severity",
+		},
+		{
+			name:      "repeated severity",
+			c:         Config{Templates: map[string]map[string]string{"default": {"critical": "a", "CRITICAL": "b"}}},
+			errSubstr: "empty or repeated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ts, err := New(tt.c, "")
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("New() error = %v, want containing %q", err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := len(ts.Names()); got != len(tt.c.Templates) {
+				t.Errorf("Names() = %v, want %d templates", ts.Names(), len(tt.c.Templates))
+			}
+		})
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	t.Parallel()
+
+	ts, err := New(Config{Templates: map[string]map[string]string{
+		"oncall":   {"critical": "page {{.Alert}} {{.TriageURL}}", "*": "fyi {{.Alert}}"},
+		"critical": {"critical": "page {{.Alert}}"},
+	}}, "https://vigil.example/")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		template    string
+		severity    string
+		wantVariant string
+		wantText    string
+	}{
+		{"own variant", "oncall", "critical", "critical", "page HighCPU https://vigil.example/ui/#/triage/t1"},
+		{"severity case ignored", "oncall", "Critical", "critical", "page HighCPU https://vigil.example/ui/#/triage/t1"},
+		{"fallback", "oncall", "warning", AnySeverity, "fyi HighCPU"},
+		{"no variant", "critical", "warning", "", ""},
+		{"unknown template", "missing", "critical", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &triage.Result{ID: "t1", Alert: "HighCPU", Severity: tt.severity, Status: triage.StatusComplete}
+			tmpl := ts.Get(tt.template)
+			if got := tmpl.Variant(r); got != tt.wantVariant {
+				t.Errorf("Variant() = %q, want %q", got, tt.wantVariant)
+			}
+			text, ok, err := tmpl.Render(r)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if ok != (tt.wantVariant != "") || text != tt.wantText {
+				t.Errorf("Render() = %q, %v, want %q", text, ok, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// SendDigest posts a periodic digest to the configured Slack webhook.
+// If no webhook URL is configured, it returns nil immediately.
+func (n *Notifier) SendDigest(ctx context.Context, d *triage.Digest) error {
+	if n.webhookURL == "" {
+		return nil
+	}
+
+	body, err := RenderDigest(d)
+	if err != nil {
+		return err
+	}
+	return n.post(ctx, body)
+}
+
+// RenderDigest returns the webhook payload SendDigest would post for d.
+func RenderDigest(d *triage.Digest) ([]byte, error) {
+	body, err := json.Marshal(buildDigestMessage(d))
+	if err != nil {
+		return nil, fmt.Errorf("slack: marshal digest: %w", err)
+	}
+	return body, nil
+}
+
+func buildDigestMessage(d *triage.Digest) map[string]any {
+	st := d.Stats
+	failed := 0
+	for status, n := range st.ByStatus {
+		if status.IsTerminal() && status != triage.StatusComplete {
+			failed += n
+		}
+	}
+
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{
+				"type": "plain_text",
+				"text": fmt.Sprintf("\U0001f4ca Vigil %s digest", d.Period),
+			},
+		},
+		{
+			"type": "section",
+			"fields": []map[string]any{
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Triaged:* %d", st.Triages)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Completed:* %d", st.ByStatus[triage.StatusComplete])},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Did not complete:* %d", failed)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Spend:* $%.2f", st.CostUSD)},
+				{"type": "mrkdwn", "text": fmt.Sprintf("*Duration:* p50 %.1fs / p95 %.1fs", st.DurationP50, st.DurationP95)},
+			},
+		},
+	}
+
+	if len(d.Recurring) > 0 {
+		lines := make([]string, 0, len(d.Recurring))
+		for _, fc := range d.Recurring {
+			lines = append(lines, fmt.Sprintf("• %s `%s` × %d", fc.Alert, shortFingerprint(fc.Fingerprint), fc.Triages))
+		}
+		blocks = append(blocks, digestList("Recurring alerts", lines))
+	}
+	if len(d.Failures) > 0 {
+		lines := make([]string, 0, len(d.Failures))
+		for _, r := range d.Failures {
+			lines = append(lines, fmt.Sprintf("• %s: %s (%s)", runLink(r), r.Alert, r.Status))
+		}
+		blocks = append(blocks, digestList("Did not complete", lines))
+	}
+	if len(d.Costliest) > 0 {
+		lines := make([]string, 0, len(d.Costliest))
+		for _, r := range d.Costliest {
+			lines = append(lines, fmt.Sprintf("• %s: %s ($%.2f)", runLink(r), r.Alert, r.CostUSD))
+		}
+		blocks = append(blocks, digestList("Costliest triages", lines))
+	}
+	if len(d.Noisiest) > 0 {
+		lines := make([]string, 0, len(d.Noisiest))
+		for _, ns := range d.Noisiest {
+			lines = append(lines, fmt.Sprintf("• %s: %.2f (%.1f/day, %.0f%% repeated)", ns.Alert, ns.Score, ns.PerDay, ns.RepeatRatio*100))
+		}
+		blocks = append(blocks, digestList("Noisiest alerts", lines))
+	}
+
+	blocks = append(blocks, map[string]any{
+		"type": "context",
+		"elements": []map[string]any{
+			{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("vigil • %s – %s",
+					st.Since.UTC().Format("2006-01-02 15:04"), st.Until.UTC().Format("2006-01-02 15:04 UTC")),
+			},
+		},
+	})
+	return map[string]any{"blocks": blocks}
+}
+
+func digestList(title string, lines []string) map[string]any {
+	return map[string]any{
+		"type": "section",
+		"text": map[string]any{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("*%s*\n%s", title, strings.Join(lines, "\n")),
+		},
+	}
+}
+
+// runLink links the run's ID to its page, or returns the bare ID without a URL.
+func runLink(r triage.DigestRun) string {
+	if r.URL == "" {
+		return r.ID
+	}
+	return fmt.Sprintf("<%s|%s>", r.URL, r.ID)
+}
+
+func shortFingerprint(fp string) string {
+	if len(fp) > 12 {
+		return fp[:12]
+	}
+	return fp
+}
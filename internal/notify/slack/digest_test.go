@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestSendDigest(t *testing.T) {
+	t.Parallel()
+
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Decoding undoes the escaping of <, > and & in the link markup.
+		var got any
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		var b strings.Builder
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(got)
+		body = b.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	until := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	d := &triage.Digest{
+		Period: triage.DigestDaily,
+		Stats: &triage.Stats{
+			Since: until.Add(-24 * time.Hour), Until: until, Triages: 12, CostUSD: 3.456,
+			ByStatus: map[triage.Status]int{triage.StatusComplete: 9, triage.StatusFailed: 2, triage.StatusMaxTurns: 1},
+		},
+		Recurring: []triage.FingerprintCount{{Fingerprint: "0123456789abcdef", Alert: "DiskFull", Triages: 4}},
+		Failures:  []triage.DigestRun{{ID: "01JFAIL", Alert: "HighCPU", Status: triage.StatusFailed, URL: "https://vigil.example.com/ui/#/triage/01JFAIL"}},
+		Costliest: []triage.DigestRun{{ID: "01JCOST", Alert: "DiskFull", CostUSD: 1.5}},
+		Noisiest:  []triage.NoiseScore{{Alert: "DiskFull", Score: 0.54, Triages: 4, PerDay: 4, RepeatRatio: 0.75}},
+	}
+	if err := New(srv.URL, log.Nop()).SendDigest(context.Background(), d); err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+
+	for _, want := range []string{
+		"Vigil daily digest",
+		"*Triaged:* 12",
+		"*Did not complete:* 3",
+		"*Spend:* $3.46",
+		"DiskFull `0123456789ab` × 4",
+		"<https://vigil.example.com/ui/#/triage/01JFAIL|01JFAIL>: HighCPU (failed)",
+		"01JCOST: DiskFull ($1.50)",
+		"DiskFull: 0.54 (4.0/day, 75% repeated)",
+		"2026-03-10 09:00 – 2026-03-11 09:00 UTC",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("digest missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestSendDigest_NoOpWithoutURL(t *testing.T) {
+	t.Parallel()
+
+	if err := New("", log.Nop()).SendDigest(context.Background(), &triage.Digest{}); err != nil {
+		t.Errorf("SendDigest without URL: %v", err)
+	}
+}
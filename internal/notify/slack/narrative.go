@@ -0,0 +1,175 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const (
+	maxFindingLen    = 160
+	maxConclusionLen = 300
+)
+
+// narrative condenses the conversation into the steps the agent took, e.g.
+// "→ query_metrics: CPU is high on node-3." for each assistant turn that
+// called tools, followed by the conclusion. A step's finding is the first
+// sentence the model wrote after seeing its results. It returns "" when the
+// result has no conversation or no tool was called.
+func narrative(r *triage.Result) string {
+	if r.Conversation == nil {
+		return ""
+	}
+	turns := r.Conversation.Turns
+	var steps []string
+	for i := range turns {
+		turn := &turns[i]
+		if turn.Role != "assistant" {
+			continue
+		}
+		var names []string
+		calls := map[string]int{}
+		ids := map[string]bool{}
+		for _, b := range turn.Content {
+			if b.Type != "tool_use" {
+				continue
+			}
+			if calls[b.Name] == 0 {
+				names = append(names, b.Name)
+			}
+			calls[b.Name]++
+			ids[b.ID] = true
+		}
+		if len(names) == 0 {
+			continue
+		}
+		for j, name := range names {
+			if calls[name] > 1 {
+				names[j] = fmt.Sprintf("%s ×%d", name, calls[name])
+			}
+		}
+		step := "→ " + strings.Join(names, ", ")
+		if finding := nextFinding(turns[i+1:]); finding != "" {
+			step += ": " + finding
+		} else if allFailed(turns[i+1:], ids) {
+			step += ": failed"
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return ""
+	}
+
+	conclusion := notify.Truncate(firstSentence(r.Analysis), maxConclusionLen)
+	if conclusion == "" {
+		conclusion = notify.Title(r.Status)
+	}
+	return "*Investigation*\n" + strings.Join(steps, "\n") + "\n→ *Conclusion:* " + conclusion
+}
+
+// nextFinding returns the first sentence of the next assistant turn's notes.
+// The last text of a turn that called no tools is the analysis, which the
+// conclusion covers.
+func nextFinding(turns []triage.Turn) string {
+	for _, turn := range turns {
+		if turn.Role != "assistant" {
+			continue
+		}
+		var texts []string
+		toolUse := false
+		for _, b := range turn.Content {
+			switch b.Type {
+			case "text":
+				if t := strings.TrimSpace(b.Text); t != "" {
+					texts = append(texts, t)
+				}
+			case "tool_use":
+				toolUse = true
+			}
+		}
+		if !toolUse && len(texts) > 0 {
+			texts = texts[:len(texts)-1]
+		}
+		if len(texts) == 0 {
+			return ""
+		}
+		return notify.Truncate(firstSentence(texts[0]), maxFindingLen)
+	}
+	return ""
+}
+
+// allFailed reports whether every tool_result answering ids is an error.
+func allFailed(turns []triage.Turn, ids map[string]bool) bool {
+	seen := 0
+	for _, turn := range turns {
+		for _, b := range turn.Content {
+			if b.Type != "tool_result" || !ids[b.ToolUseID] {
+				continue
+			}
+			if !b.IsError {
+				return false
+			}
+			seen++
+		}
+	}
+	return seen > 0
+}
+
+// firstSentence returns s up to the end of its first sentence or line.
+func firstSentence(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, ". "); i >= 0 {
+		s = s[:i+1]
+	}
+	return strings.TrimSpace(s)
+}
+
+// postThreaded posts body to the thread channel with chat.postMessage and
+// replies in its thread with the investigation narrative. A failed reply is
+// only logged, since the message itself landed.
+func (n *Notifier) postThreaded(ctx context.Context, r *triage.Result, body []byte) error {
+	var msg map[string]any
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return fmt.Errorf("slack: decode message: %w", err)
+	}
+	msg["channel"] = n.threadChannelID
+	// The text is the notification fallback for clients that show no blocks.
+	// A templated message may already have its own.
+	if _, ok := msg["text"]; !ok {
+		msg["text"] = fmt.Sprintf("%s: %s", notify.Title(r.Status), r.Alert)
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("slack: marshal message: %w", err)
+	}
+	var posted struct {
+		TS string `json:"ts"`
+	}
+	if err := n.callAPI(ctx, "chat.postMessage", "application/json; charset=utf-8", payload, &posted); err != nil {
+		return err
+	}
+
+	text := narrative(r)
+	if text == "" || posted.TS == "" {
+		return nil
+	}
+	reply, err := json.Marshal(map[string]any{
+		"channel":   n.threadChannelID,
+		"thread_ts": posted.TS,
+		"text":      text,
+	})
+	if err != nil {
+		return fmt.Errorf("slack: marshal narrative: %w", err)
+	}
+	if err := n.callAPI(ctx, "chat.postMessage", "application/json; charset=utf-8", reply, nil); err != nil {
+		n.logger.Warn(ctx, "slack narrative reply failed", "triage_id", r.ID, "err", err)
+	}
+	return nil
+}
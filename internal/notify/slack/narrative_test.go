@@ -0,0 +1,143 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func narrativeResult() *triage.Result {
+	return &triage.Result{
+		ID:       "01NARR",
+		Status:   triage.StatusComplete,
+		Alert:    "PodCrashLooping",
+		Analysis: "The pod is being OOM killed. Raise its memory limit.",
+		Conversation: &triage.Conversation{Turns: []triage.Turn{
+			{Role: "user", Content: []triage.ContentBlock{{Type: "text", Text: "alert"}}},
+			{Role: "assistant", Content: []triage.ContentBlock{
+				{Type: "tool_use", ID: "t1", Name: "query_metrics"},
+				{Type: "tool_use", ID: "t2", Name: "query_metrics"},
+			}},
+			{Role: "user", Content: []triage.ContentBlock{
+				{Type: "tool_result", ToolUseID: "t1"},
+				{Type: "tool_result", ToolUseID: "t2"},
+			}},
+			{Role: "assistant", Content: []triage.ContentBlock{
+				{Type: "text", Text: "CPU is high on node-3. Memory looks tight too."},
+				{Type: "tool_use", ID: "t3", Name: "query_logs"},
+			}},
+			{Role: "user", Content: []triage.ContentBlock{{Type: "tool_result", ToolUseID: "t3", IsError: true}}},
+			{Role: "assistant", Content: []triage.ContentBlock{
+				{Type: "tool_use", ID: "t4", Name: "get_host_info"},
+			}},
+			{Role: "user", Content: []triage.ContentBlock{{Type: "tool_result", ToolUseID: "t4"}}},
+			{Role: "assistant", Content: []triage.ContentBlock{
+				{Type: "text", Text: "Found OOM kills in the kernel log\nfor the pod."},
+				{Type: "text", Text: "The pod is being OOM killed. Raise its memory limit."},
+			}},
+		}},
+	}
+}
+
+func TestNarrative(t *testing.T) {
+	t.Parallel()
+
+	want := strings.Join([]string{
+		"*Investigation*",
+		"→ query_metrics ×2: CPU is high on node-3.",
+		"→ query_logs: failed",
+		"→ get_host_info: Found OOM kills in the kernel log",
+		"→ *Conclusion:* The pod is being OOM killed.",
+	}, "\n")
+	if got := narrative(narrativeResult()); got != want {
+		t.Errorf("narrative:\n%s\nwant:\n%s", got, want)
+	}
+
+	noTools := &triage.Result{Analysis: "fine", Conversation: &triage.Conversation{Turns: []triage.Turn{
+		{Role: "assistant", Content: []triage.ContentBlock{{Type: "text", Text: "fine"}}},
+	}}}
+	if got := narrative(noTools); got != "" {
+		t.Errorf("narrative without tool calls = %q, want empty", got)
+	}
+	if got := narrative(&triage.Result{}); got != "" {
+		t.Errorf("narrative without conversation = %q, want empty", got)
+	}
+}
+
+func TestSend_ThreadsNarrative(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var posts []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat.postMessage" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		var msg map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		posts = append(posts, msg)
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"ok":true,"ts":"1700000000.000100"}`)
+	}))
+	defer srv.Close()
+
+	n := New(srv.URL+"/webhook", log.Nop(), WithThreadedNarrative("xoxb-test", "C456"))
+	n.apiBase = srv.URL + "/api"
+	if err := n.Send(context.Background(), narrativeResult()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posts) != 2 {
+		t.Fatalf("posts = %d, want message and reply", len(posts))
+	}
+	top, reply := posts[0], posts[1]
+	if top["channel"] != "C456" || top["blocks"] == nil || top["thread_ts"] != nil {
+		t.Errorf("main message = %v", top)
+	}
+	if reply["channel"] != "C456" || reply["thread_ts"] != "1700000000.000100" {
+		t.Errorf("reply = %v", reply)
+	}
+	if text, _ := reply["text"].(string); !strings.HasPrefix(text, "*Investigation*") {
+		t.Errorf("reply text = %q", text)
+	}
+}
+
+func TestSend_NarrativeReplyFailureDoesNotFailSend(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls > 1 {
+			_, _ = io.WriteString(w, `{"ok":false,"error":"thread_not_found"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"ok":true,"ts":"1.2"}`)
+	}))
+	defer srv.Close()
+
+	n := New(srv.URL+"/webhook", log.Nop(), WithThreadedNarrative("xoxb-test", "C456"))
+	n.apiBase = srv.URL + "/api"
+	if err := n.Send(context.Background(), narrativeResult()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
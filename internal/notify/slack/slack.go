@@ -8,17 +8,21 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/notify"
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
 const (
 	maxAnalysisLen = 3000
 	httpTimeout    = 10 * time.Second
+
+	defaultAPIBase = "https://slack.com/api"
+	snapshotWidth  = 600
+	snapshotHeight = 160
 )
 
 // Notifier sends triage results to a Slack webhook.
@@ -26,15 +30,78 @@ type Notifier struct {
 	webhookURL string
 	client     *http.Client
 	logger     log.Logger
+
+	// botToken and channelID enable metric snapshot uploads, which incoming
+	// webhooks cannot do. threadChannelID posts messages with the bot token
+	// instead, so the narrative can be threaded under them.
+	botToken        string
+	channelID       string
+	threadChannelID string
+	apiBase         string
+
+	// tmpl replaces the built-in message for the severities it has a
+	// variant for, nil for none.
+	tmpl *notify.Template
+
+	// traceURL is the tracing UI the built-in message links the triage's
+	// trace in, empty for no link.
+	traceURL string
+}
+
+// Option configures optional Notifier behavior.
+type Option func(*Notifier)
+
+// WithSnapshots uploads a PNG sparkline of the latest range query a triage ran
+// to channelID after each notification, using a bot token with files:write.
+// Either value empty disables snapshots.
+func WithSnapshots(botToken, channelID string) Option {
+	return func(n *Notifier) {
+		if botToken != "" && channelID != "" {
+			n.botToken, n.channelID = botToken, channelID
+		}
+	}
+}
+
+// WithThreadedNarrative posts triage messages to channelID with
+// chat.postMessage instead of the webhook and replies in their thread with a
+// condensed narrative of the investigation, keeping the message itself
+// short. It needs a bot token with chat:write; either value empty disables it.
+func WithThreadedNarrative(botToken, channelID string) Option {
+	return func(n *Notifier) {
+		if botToken != "" && channelID != "" {
+			n.botToken, n.threadChannelID = botToken, channelID
+		}
+	}
+}
+
+// WithTemplate renders messages with tmpl instead of the built-in blocks,
+// for results whose severity it has a variant for. A rendered JSON object
+// is posted as the whole payload, so a template can lay out its own Block
+// Kit blocks; any other output is posted as the message text, in Slack
+// mrkdwn. A nil tmpl keeps the built-in message.
+func WithTemplate(tmpl *notify.Template) Option {
+	return func(n *Notifier) { n.tmpl = tmpl }
+}
+
+// WithTraceURL links the built-in message to the triage's trace in a
+// tracing UI, see notify.TraceURL for the URL forms. Results without a
+// trace ID get no link.
+func WithTraceURL(baseURL string) Option {
+	return func(n *Notifier) { n.traceURL = baseURL }
 }
 
 // New creates a new Slack notifier. If webhookURL is empty, Send is a no-op.
-func New(webhookURL string, logger log.Logger) *Notifier {
-	return &Notifier{
+func New(webhookURL string, logger log.Logger, opts ...Option) *Notifier {
+	n := &Notifier{
 		webhookURL: webhookURL,
 		client:     &http.Client{Timeout: httpTimeout},
 		logger:     logger,
+		apiBase:    defaultAPIBase,
 	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 // Send posts a triage result to the configured Slack webhook.
@@ -44,13 +111,31 @@ func (n *Notifier) Send(ctx context.Context, result *triage.Result) error {
 		return nil
 	}
 
-	msg := buildMessage(result)
+	body, err := n.Render(result)
+	if err != nil {
+		return err
+	}
 
-	body, err := json.Marshal(msg)
+	if n.threadChannelID != "" {
+		err = n.postThreaded(ctx, result, body)
+	} else {
+		err = n.post(ctx, body)
+	}
 	if err != nil {
-		return fmt.Errorf("slack: marshal message: %w", err)
+		return err
+	}
+
+	// The text notification already landed, so a failed snapshot is only logged.
+	if n.channelID != "" {
+		if err := n.uploadSnapshot(ctx, result); err != nil {
+			n.logger.Warn(ctx, "slack snapshot upload failed", "triage_id", result.ID, "err", err)
+		}
 	}
+	return nil
+}
 
+// post sends a rendered payload to the webhook.
+func (n *Notifier) post(ctx context.Context, body []byte) error {
 	n.logger.Debug(ctx, "slack webhook request", "body", string(body))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
@@ -74,73 +159,94 @@ func (n *Notifier) Send(ctx context.Context, result *triage.Result) error {
 	return nil
 }
 
-func buildMessage(r *triage.Result) map[string]any {
+// Render returns the webhook payload Send would post for result.
+func (n *Notifier) Render(result *triage.Result) ([]byte, error) {
+	text, ok, err := n.tmpl.Render(result)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("slack: %w", err)
+	case !ok:
+		m := notify.NewMessage(result)
+		if u := notify.TraceURL(n.traceURL, result.TraceID); u != "" {
+			m.Links = append(m.Links, notify.Link{Label: "Trace", URL: u})
+		}
+		return marshalMessage(m)
+	}
+	return templatePayload(text)
+}
+
+// templatePayload turns rendered template text into a webhook payload.
+func templatePayload(text string) ([]byte, error) {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") {
+		var msg map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &msg); err != nil {
+			return nil, fmt.Errorf("slack: template rendered an invalid JSON payload: %w", err)
+		}
+		return []byte(trimmed), nil
+	}
+	body, err := json.Marshal(map[string]any{"text": text})
+	if err != nil {
+		return nil, fmt.Errorf("slack: marshal message: %w", err)
+	}
+	return body, nil
+}
+
+// Render returns the webhook payload of the built-in message for result.
+func Render(result *triage.Result) ([]byte, error) {
+	return marshalMessage(notify.NewMessage(result))
+}
+
+func marshalMessage(m notify.Message) ([]byte, error) {
+	body, err := json.Marshal(buildMessage(m))
+	if err != nil {
+		return nil, fmt.Errorf("slack: marshal message: %w", err)
+	}
+	return body, nil
+}
+
+func buildMessage(m notify.Message) map[string]any {
 	return map[string]any{
 		"blocks": []map[string]any{
-			headerBlock(r),
+			headerBlock(m),
 			{"type": "divider"},
-			fieldsBlock(r),
+			fieldsBlock(m),
 			{"type": "divider"},
-			analysisBlock(r),
+			analysisBlock(m),
 			{"type": "divider"},
-			contextBlock(r),
+			contextBlock(m),
 		},
 	}
 }
 
-func headerBlock(r *triage.Result) map[string]any {
-	emoji := severityEmoji(r.Status, r.Severity)
-	title := "Triage Complete"
-	if r.Status == triage.StatusFailed {
-		title = "Triage Failed"
-	}
-	text := fmt.Sprintf("%s %s: %s", emoji, title, r.Alert)
-
+func headerBlock(m notify.Message) map[string]any {
 	return map[string]any{
 		"type": "header",
 		"text": map[string]any{
 			"type": "plain_text",
-			"text": text,
+			"text": m.Level.Emoji() + " " + m.Title,
 		},
 	}
 }
 
-func fieldsBlock(r *triage.Result) map[string]any {
-	fields := []map[string]any{
-		{
-			"type": "mrkdwn",
-			"text": fmt.Sprintf("*Status:* %s", r.Status),
-		},
-		{
-			"type": "mrkdwn",
-			"text": fmt.Sprintf("*Severity:* %s", r.Severity),
-		},
-		{
-			"type": "mrkdwn",
-			"text": fmt.Sprintf("*Duration:* %.1fs", r.Duration),
-		},
-		{
-			"type": "mrkdwn",
-			"text": fmt.Sprintf("*Model:* %s", shortModel(r.Model)),
-		},
-		{
-			"type": "mrkdwn",
-			"text": fmt.Sprintf("*Tokens:* %d in / %d out", r.TokensIn, r.TokensOut),
-		},
-		{
+// fieldsBlock lays out the message fields; a section holds at most 10,
+// which the fields NewMessage sets never exceed.
+func fieldsBlock(m notify.Message) map[string]any {
+	fields := make([]map[string]any, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		fields = append(fields, map[string]any{
 			"type": "mrkdwn",
-			"text": fmt.Sprintf("*Tool calls:* %d", r.ToolCalls),
-		},
+			"text": fmt.Sprintf("*%s:* %s", f.Name, f.Value),
+		})
 	}
-
 	return map[string]any{
 		"type":   "section",
 		"fields": fields,
 	}
 }
 
-func analysisBlock(r *triage.Result) map[string]any {
-	text := truncate(r.Analysis, maxAnalysisLen)
+func analysisBlock(m notify.Message) map[string]any {
+	text := notify.Truncate(m.Analysis, maxAnalysisLen)
 	if text == "" {
 		text = "_No analysis available._"
 	}
@@ -154,17 +260,15 @@ func analysisBlock(r *triage.Result) map[string]any {
 	}
 }
 
-func contextBlock(r *triage.Result) map[string]any {
-	ts := r.CompletedAt
-	if ts.IsZero() {
-		ts = r.CreatedAt
-	}
-
+func contextBlock(m notify.Message) map[string]any {
 	elements := []map[string]any{
-		{
+		{"type": "mrkdwn", "text": m.Footer},
+	}
+	for _, l := range m.Links {
+		elements = append(elements, map[string]any{
 			"type": "mrkdwn",
-			"text": fmt.Sprintf("vigil • triage %s • %s", r.ID, ts.UTC().Format("2006-01-02 15:04 UTC")),
-		},
+			"text": fmt.Sprintf("<%s|%s>", l.URL, l.Label),
+		})
 	}
 
 	return map[string]any{
@@ -172,31 +276,3 @@ func contextBlock(r *triage.Result) map[string]any {
 		"elements": elements,
 	}
 }
-
-func severityEmoji(status triage.Status, severity string) string {
-	if status == triage.StatusFailed {
-		return "\U0001f534" // red circle
-	}
-	switch strings.ToLower(severity) {
-	case "critical":
-		return "\U0001f534" // red circle
-	case "warning":
-		return "\U0001f7e1" // yellow circle
-	default:
-		return "\U0001f7e2" // green circle
-	}
-}
-
-// dateModelRe matches model names ending with a YYYYMMDD date suffix.
-var dateModelRe = regexp.MustCompile(`-\d{8}$`)
-
-func shortModel(model string) string {
-	return dateModelRe.ReplaceAllString(model, "")
-}
-
-func truncate(s string, limit int) string {
-	if len(s) <= limit {
-		return s
-	}
-	return s[:limit-3] + "..."
-}
@@ -3,13 +3,16 @@ package slack
 import (
 	"context"
 	"encoding/json"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/notify"
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
 
@@ -114,56 +117,136 @@ func TestSend_TruncatesLongAnalysis(t *testing.T) {
 	}
 }
 
-func TestSeverityEmoji(t *testing.T) {
+func TestFieldsBlock_Tokens(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		name     string
-		status   triage.Status
-		severity string
+		thinking int
 		want     string
 	}{
-		{"failed", triage.StatusFailed, "warning", "\U0001f534"},
-		{"critical", triage.StatusComplete, "critical", "\U0001f534"},
-		{"warning", triage.StatusComplete, "warning", "\U0001f7e1"},
-		{"info", triage.StatusComplete, "info", "\U0001f7e2"},
-		{"empty", triage.StatusComplete, "", "\U0001f7e2"},
+		{"without thinking", 0, "*Tokens:* 800 in / 450 out"},
+		{"with thinking", 120, "*Tokens:* 800 in / 450 out (120 thinking)"},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got := severityEmoji(tt.status, tt.severity)
-			if got != tt.want {
-				t.Errorf("severityEmoji(%q, %q) = %q, want %q", tt.status, tt.severity, got, tt.want)
+			block := fieldsBlock(notify.NewMessage(&triage.Result{TokensIn: 800, TokensOut: 450, TokensThinking: tt.thinking}))
+			var found bool
+			for _, f := range block["fields"].([]map[string]any) {
+				if f["text"] == tt.want {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("fields = %v, want one with %q", block["fields"], tt.want)
 			}
 		})
 	}
 }
 
-func TestShortModel(t *testing.T) {
+func TestFieldsBlock_ToolCallsAndCost(t *testing.T) {
 	t.Parallel()
 
+	use := func(id, name string) triage.ContentBlock {
+		return triage.ContentBlock{Type: "tool_use", ID: id, Name: name}
+	}
+	result := func(id string, isErr bool) triage.ContentBlock {
+		return triage.ContentBlock{Type: "tool_result", ToolUseID: id, IsError: isErr}
+	}
+	conv := &triage.Conversation{Turns: []triage.Turn{
+		{Role: "assistant", Content: []triage.ContentBlock{use("1", "query_logs"), use("2", "query_metrics"), use("3", "query_metrics")}},
+		{Role: "user", Content: []triage.ContentBlock{result("1", false), result("2", true), result("3", false)}},
+		{Role: "assistant", Content: []triage.ContentBlock{use("4", "query_metrics")}},
+		{Role: "user", Content: []triage.ContentBlock{result("4", false)}},
+	}}
+
 	tests := []struct {
-		input string
-		want  string
+		name   string
+		result *triage.Result
+		want   []string
+		absent string
 	}{
-		{"claude-sonnet-4-20250514", "claude-sonnet-4"},
-		{"claude-opus-4-20250514", "claude-opus-4"},
-		{"gpt-4o", "gpt-4o"},
-		{"", ""},
+		{
+			name:   "counts per tool",
+			result: &triage.Result{ToolCalls: 4, Conversation: conv, Model: "claude-sonnet-4-20250514", TokensIn: 100000, TokensOut: 2000},
+			want:   []string{"*Tool calls:* 4\nquery_metrics ×3 (1 failed), query_logs ×1", "*Est. cost:* $0.33"},
+		},
+		{
+			name:   "names without conversation",
+			result: &triage.Result{ToolCalls: 2, ToolsUsed: []string{"query_logs", "query_metrics"}, Model: "claude-haiku-4-5", TokensIn: 1000, TokensOut: 100},
+			want:   []string{"*Tool calls:* 2 (query_logs, query_metrics)", "*Est. cost:* <$0.01"},
+		},
+		{
+			name:   "unknown model has no cost",
+			result: &triage.Result{Model: "gpt-4o"},
+			want:   []string{"*Tool calls:* 0"},
+			absent: "Est. cost",
+		},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
+		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			if got := shortModel(tt.input); got != tt.want {
-				t.Errorf("shortModel(%q) = %q, want %q", tt.input, got, tt.want)
+			var texts []string
+			for _, f := range fieldsBlock(notify.NewMessage(tt.result))["fields"].([]map[string]any) {
+				texts = append(texts, f["text"].(string))
+			}
+			for _, want := range tt.want {
+				if !slices.Contains(texts, want) {
+					t.Errorf("fields = %q, want one with %q", texts, want)
+				}
+			}
+			if tt.absent != "" && strings.Contains(strings.Join(texts, "\n"), tt.absent) {
+				t.Errorf("fields = %q, want none with %q", texts, tt.absent)
 			}
 		})
 	}
 }
 
+func TestContextBlock_IssueLink(t *testing.T) {
+	t.Parallel()
+
+	block := contextBlock(notify.NewMessage(&triage.Result{ID: "t1"}))
+	if n := len(block["elements"].([]map[string]any)); n != 1 {
+		t.Errorf("elements without issue = %d, want 1", n)
+	}
+
+	block = contextBlock(notify.NewMessage(&triage.Result{ID: "t1", IssueURL: "https://github.com/acme/ops/issues/7"}))
+	elements := block["elements"].([]map[string]any)
+	if len(elements) != 2 || elements[1]["text"] != "<https://github.com/acme/ops/issues/7|Issue>" {
+		t.Errorf("elements = %v, want an issue link", elements)
+	}
+}
+
+func TestBuildMessage_Metadata(t *testing.T) {
+	t.Parallel()
+
+	r := &triage.Result{ID: "t1", Metadata: &triage.Metadata{
+		Owner:        "team-db",
+		Runbook:      "https://wiki.example.com/db",
+		Dependencies: []string{"etcd", "ceph"},
+	}}
+	fields := fieldsBlock(notify.NewMessage(r))["fields"].([]map[string]any)
+	var texts []string
+	for _, f := range fields {
+		texts = append(texts, f["text"].(string))
+	}
+	joined := strings.Join(texts, "\n")
+	for _, want := range []string{"*Owner:* team-db", "*Depends on:* etcd, ceph"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("fields missing %q: %v", want, texts)
+		}
+	}
+	if strings.Contains(joined, "Tier") {
+		t.Errorf("fields include an empty tier: %v", texts)
+	}
+
+	elements := contextBlock(notify.NewMessage(r))["elements"].([]map[string]any)
+	if len(elements) != 2 || elements[1]["text"] != "<https://wiki.example.com/db|Runbook>" {
+		t.Errorf("elements = %v, want a runbook link", elements)
+	}
+}
+
 func FuzzSlackBuild(f *testing.F) {
 	f.Add("HighCPU", "critical", "CPU is very high on node-1.", "claude-sonnet-4-20250514")
 	f.Add("", "", "", "")
@@ -188,7 +271,7 @@ func FuzzSlackBuild(f *testing.F) {
 		}
 
 		// Must not panic
-		msg := buildMessage(result)
+		msg := buildMessage(notify.NewMessage(result))
 
 		// Must produce valid JSON
 		data, err := json.Marshal(msg)
@@ -233,3 +316,84 @@ func TestSend_NonOKStatus(t *testing.T) {
 		t.Errorf("error = %q, want to contain status code 500", err.Error())
 	}
 }
+
+func TestRender_TraceLink(t *testing.T) {
+	t.Parallel()
+
+	n := New("https://hooks.slack.com/services/x", log.Nop(), WithTraceURL("https://jaeger.example.com/trace"))
+	tests := []struct {
+		name    string
+		traceID string
+		want    bool
+	}{
+		{"traced", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"untraced", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			body, err := n.Render(&triage.Result{ID: "t1", Alert: "HighCPU", Status: triage.StatusComplete, TraceID: tt.traceID})
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			link := "https://jaeger.example.com/trace/4bf92f3577b34da6a3ce929d0e0e4736|Trace"
+			if got := strings.Contains(string(body), link); got != tt.want {
+				t.Errorf("trace link present = %v, want %v: %s", got, tt.want, body)
+			}
+		})
+	}
+}
+
+func TestRender_Template(t *testing.T) {
+	t.Parallel()
+
+	ts, err := notify.New(notify.Config{Templates: map[string]map[string]string{
+		"oncall": {
+			"critical": "<!here> *{{.Alert}}* {{.Title}}",
+			"warning":  `{"text":"{{.Alert}}","blocks":[]}`,
+			"info":     `{"text":`,
+		},
+	}}, "")
+	if err != nil {
+		t.Fatalf("notify.New: %v", err)
+	}
+	n := New("https://hooks.slack.com/services/x", log.Nop(), WithTemplate(ts.Get("oncall")))
+
+	tests := []struct {
+		severity  string
+		wantText  string
+		wantKeys  []string
+		errSubstr string
+	}{
+		{severity: "critical", wantText: "<!here> *HighCPU* Triage Complete", wantKeys: []string{"text"}},
+		{severity: "warning", wantText: "HighCPU", wantKeys: []string{"blocks", "text"}},
+		{severity: "info", errSubstr: "invalid JSON payload"},
+		{severity: "none", wantKeys: []string{"blocks"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			t.Parallel()
+			body, err := n.Render(&triage.Result{ID: "t1", Alert: "HighCPU", Severity: tt.severity, Status: triage.StatusComplete})
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("Render() error = %v, want containing %q", err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			var msg map[string]any
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := slices.Sorted(maps.Keys(msg)); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("payload keys = %v, want %v", got, tt.wantKeys)
+			}
+			if tt.wantText != "" && msg["text"] != tt.wantText {
+				t.Errorf("text = %q, want %q", msg["text"], tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/linnemanlabs/vigil/internal/chart"
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// uploadSnapshot charts the latest range query in the triage conversation and
+// posts it to the snapshot channel with Slack's external upload flow. Triages
+// without range query data are skipped.
+func (n *Notifier) uploadSnapshot(ctx context.Context, r *triage.Result) error {
+	c, ok := chart.FromConversation(r.Conversation)
+	if !ok {
+		return nil
+	}
+	img, err := c.PNG(snapshotWidth, snapshotHeight)
+	if err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("vigil-%s.png", r.ID)
+
+	var up struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	form := url.Values{"filename": {filename}, "length": {strconv.Itoa(len(img))}}
+	if err := n.callAPI(ctx, "files.getUploadURLExternal", "application/x-www-form-urlencoded", []byte(form.Encode()), &up); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, up.UploadURL, bytes.NewReader(img))
+	if err != nil {
+		return fmt.Errorf("slack: create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "image/png")
+	resp, err := n.client.Do(req) //nolint:gosec // G704: upload URL is issued by the Slack API
+	if err != nil {
+		return fmt.Errorf("slack: upload snapshot: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 512))
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack: upload snapshot returned %d", resp.StatusCode)
+	}
+
+	complete, err := json.Marshal(map[string]any{
+		"files":           []map[string]string{{"id": up.FileID, "title": notify.Truncate(c.Query, 250)}},
+		"channel_id":      n.channelID,
+		"initial_comment": fmt.Sprintf("%s: `%s`", r.Alert, notify.Truncate(c.Query, 500)),
+	})
+	if err != nil {
+		return fmt.Errorf("slack: marshal complete upload: %w", err)
+	}
+	return n.callAPI(ctx, "files.completeUploadExternal", "application/json; charset=utf-8", complete, nil)
+}
+
+// callAPI posts to a Slack Web API method and decodes the response into out,
+// turning {"ok": false} responses into errors.
+func (n *Notifier) callAPI(ctx context.Context, method, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.apiBase+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("slack: create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+n.botToken)
+
+	resp, err := n.client.Do(req) //nolint:gosec // G704: apiBase is a constant outside tests
+	if err != nil {
+		return fmt.Errorf("slack: %s: %w", method, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return fmt.Errorf("slack: read %s response: %w", method, err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("slack: %s returned %d: %s", method, resp.StatusCode, notify.Truncate(string(raw), 200))
+	}
+	if !status.OK {
+		return fmt.Errorf("slack: %s: %s", method, status.Error)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("slack: decode %s response: %w", method, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/chart"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func snapshotResult() *triage.Result {
+	return &triage.Result{
+		ID:     "01SNAP",
+		Status: triage.StatusComplete,
+		Alert:  "HighCPU",
+		Conversation: &triage.Conversation{Turns: []triage.Turn{
+			{Role: "assistant", Content: []triage.ContentBlock{{
+				Type: "tool_use", ID: "t1", Name: chart.RangeToolName, Input: json.RawMessage(`{"query":"rate(cpu[5m])"}`),
+			}}},
+			{Role: "user", Content: []triage.ContentBlock{{
+				Type: "tool_result", ToolUseID: "t1",
+				Content: `{"result_type":"matrix","results":[{"metric":{},"values":[[1700000000,"1"],[1700000060,"5"]]}]}`,
+			}}},
+		}},
+	}
+}
+
+func TestSend_UploadsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var calls []string
+	var uploaded []byte
+	var complete map[string]any
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	record := func(r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.URL.Path)
+	}
+	mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/files.getUploadURLExternal", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		_ = r.ParseForm()
+		if r.Form.Get("filename") != "vigil-01SNAP.png" || r.Form.Get("length") == "" {
+			t.Errorf("form = %v", r.Form)
+		}
+		_, _ = io.WriteString(w, `{"ok":true,"upload_url":"`+srv.URL+`/upload","file_id":"F123"}`)
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		uploaded = b
+		mu.Unlock()
+	})
+	mux.HandleFunc("/api/files.completeUploadExternal", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		mu.Lock()
+		_ = json.NewDecoder(r.Body).Decode(&complete)
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"ok":true}`)
+	})
+
+	n := New(srv.URL+"/webhook", log.Nop(), WithSnapshots("xoxb-test", "C123"))
+	n.apiBase = srv.URL + "/api"
+
+	if err := n.Send(context.Background(), snapshotResult()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := "/webhook,/api/files.getUploadURLExternal,/upload,/api/files.completeUploadExternal"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+	if !strings.HasPrefix(string(uploaded), "\x89PNG") {
+		t.Error("uploaded body is not a PNG")
+	}
+	if complete["channel_id"] != "C123" || !strings.Contains(complete["initial_comment"].(string), "rate(cpu[5m])") {
+		t.Errorf("complete = %v", complete)
+	}
+}
+
+func TestSend_SnapshotFailureDoesNotFailSend(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			_, _ = io.WriteString(w, `{"ok":false,"error":"missing_scope"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	n := New(srv.URL+"/webhook", log.Nop(), WithSnapshots("xoxb-test", "C123"))
+	n.apiBase = srv.URL + "/api"
+	if err := n.Send(context.Background(), snapshotResult()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := n.uploadSnapshot(context.Background(), snapshotResult()); err == nil || !strings.Contains(err.Error(), "missing_scope") {
+		t.Errorf("uploadSnapshot err = %v, want missing_scope", err)
+	}
+}
+
+func TestWithSnapshots_RequiresTokenAndChannel(t *testing.T) {
+	t.Parallel()
+
+	if n := New("https://hooks.slack.com/x", log.Nop(), WithSnapshots("xoxb", "")); n.botToken != "" {
+		t.Error("snapshots enabled without a channel")
+	}
+}
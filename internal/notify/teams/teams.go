@@ -0,0 +1,125 @@
+// Package teams sends triage notifications to Microsoft Teams as Adaptive
+// Cards, via a Workflows or legacy incoming webhook.
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const (
+	// maxAnalysisLen keeps the card well inside the 28 KB webhook payload
+	// limit.
+	maxAnalysisLen = 8000
+	httpTimeout    = 10 * time.Second
+
+	cardContentType = "application/vnd.microsoft.card.adaptive"
+	cardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	cardVersion     = "1.4"
+)
+
+// Notifier sends triage results to a Teams webhook.
+type Notifier struct {
+	webhookURL string
+	client     *http.Client
+	logger     log.Logger
+}
+
+// New creates a new Teams notifier. If webhookURL is empty, Send is a no-op.
+func New(webhookURL string, logger log.Logger) *Notifier {
+	return &Notifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: httpTimeout},
+		logger:     logger,
+	}
+}
+
+// Send posts a triage result to the configured webhook.
+// If no webhook URL is configured, it returns nil immediately.
+func (n *Notifier) Send(ctx context.Context, result *triage.Result) error {
+	if n.webhookURL == "" {
+		return nil
+	}
+	body, err := Render(result)
+	if err != nil {
+		return err
+	}
+	n.logger.Debug(ctx, "teams webhook request", "body", string(body))
+	return notify.PostJSON(ctx, n.client, "teams", n.webhookURL, body)
+}
+
+// Render returns the webhook payload Send would post for result: a message
+// carrying one Adaptive Card.
+func Render(result *triage.Result) ([]byte, error) {
+	body, err := json.Marshal(map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": cardContentType,
+			"content":     buildCard(notify.NewMessage(result)),
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("teams: marshal message: %w", err)
+	}
+	return body, nil
+}
+
+func buildCard(m notify.Message) map[string]any {
+	facts := make([]map[string]any, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		facts = append(facts, map[string]any{"title": f.Name, "value": f.Value})
+	}
+
+	analysis := notify.Truncate(m.Analysis, maxAnalysisLen)
+	if analysis == "" {
+		analysis = "_No analysis available._"
+	}
+
+	card := map[string]any{
+		"$schema": cardSchema,
+		"type":    "AdaptiveCard",
+		"version": cardVersion,
+		"msteams": map[string]any{"width": "Full"},
+		"body": []map[string]any{
+			{
+				"type":   "TextBlock",
+				"text":   m.Level.Emoji() + " " + m.Title,
+				"size":   "Large",
+				"weight": "Bolder",
+				"color":  color(m.Level),
+				"wrap":   true,
+			},
+			{"type": "FactSet", "facts": facts},
+			{"type": "TextBlock", "text": analysis, "wrap": true, "separator": true},
+			{"type": "TextBlock", "text": m.Footer, "size": "Small", "isSubtle": true, "wrap": true},
+		},
+	}
+	if len(m.Links) > 0 {
+		actions := make([]map[string]any, 0, len(m.Links))
+		for _, l := range m.Links {
+			actions = append(actions, map[string]any{"type": "Action.OpenUrl", "title": l.Label, "url": l.URL})
+		}
+		card["actions"] = actions
+	}
+	return card
+}
+
+// color maps a level to one of the Adaptive Card text colors.
+func color(l notify.Level) string {
+	switch l {
+	case notify.LevelFailed, notify.LevelCritical:
+		return "Attention"
+	case notify.LevelStopped, notify.LevelWarning:
+		return "Warning"
+	default:
+		return "Good"
+	}
+}
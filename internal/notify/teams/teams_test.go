@@ -0,0 +1,93 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestSend_PostsAdaptiveCard(t *testing.T) {
+	t.Parallel()
+
+	var got struct {
+		Type        string `json:"type"`
+		Attachments []struct {
+			ContentType string `json:"contentType"`
+			Content     struct {
+				Type    string           `json:"type"`
+				Version string           `json:"version"`
+				Body    []map[string]any `json:"body"`
+				Actions []map[string]any `json:"actions"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	result := &triage.Result{
+		ID:       "t1",
+		Status:   triage.StatusFailed,
+		Alert:    "DiskFull",
+		Severity: "warning",
+		Metadata: &triage.Metadata{Runbook: "https://wiki.example.com/disk"},
+	}
+	if err := New(srv.URL, log.Nop()).Send(context.Background(), result); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got.Type != "message" || len(got.Attachments) != 1 || got.Attachments[0].ContentType != cardContentType {
+		t.Fatalf("payload = %+v, want one adaptive card attachment", got)
+	}
+	card := got.Attachments[0].Content
+	if card.Type != "AdaptiveCard" || card.Version != cardVersion || len(card.Body) != 4 {
+		t.Fatalf("card = %+v", card)
+	}
+	title := card.Body[0]
+	if !strings.HasSuffix(title["text"].(string), "Triage Failed: DiskFull") || title["color"] != "Attention" {
+		t.Errorf("title block = %v", title)
+	}
+	if card.Body[2]["text"] != "_No analysis available._" {
+		t.Errorf("analysis block = %v", card.Body[2])
+	}
+	if len(card.Actions) != 1 || card.Actions[0]["url"] != "https://wiki.example.com/disk" {
+		t.Errorf("actions = %v, want a runbook link", card.Actions)
+	}
+}
+
+func TestColor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status   triage.Status
+		severity string
+		want     string
+	}{
+		{triage.StatusComplete, "critical", "Attention"},
+		{triage.StatusError, "info", "Attention"},
+		{triage.StatusMaxTurns, "info", "Warning"},
+		{triage.StatusComplete, "warning", "Warning"},
+		{triage.StatusComplete, "info", "Good"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status)+"/"+tt.severity, func(t *testing.T) {
+			t.Parallel()
+			card := buildCard(notify.NewMessage(&triage.Result{Status: tt.status, Severity: tt.severity}))
+			if got := card["body"].([]map[string]any)[0]["color"]; got != tt.want {
+				t.Errorf("color = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
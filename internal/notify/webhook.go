@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// PostJSON posts a JSON payload to a chat webhook, failing on any status
+// other than 2xx with the start of the response body. backend prefixes
+// errors, such as "mattermost".
+func PostJSON(ctx context.Context, client *http.Client, backend, webhookURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: create request: %w", backend, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req) //nolint:gosec // G704: webhookURL is from trusted config, not user input
+	if err != nil {
+		return fmt.Errorf("%s: post webhook: %w", backend, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: webhook returned %d: %s", backend, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// Multi sends each result to every notifier in turn, returning their
+// errors joined. A notifier failing does not stop the others, but a retry
+// of the result from the outbox goes to all of them again.
+type Multi []triage.Notifier
+
+// Send implements triage.Notifier.
+func (m Multi) Send(ctx context.Context, result *triage.Result) error {
+	var errs []error
+	for _, n := range m {
+		errs = append(errs, n.Send(ctx, result))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,173 @@
+// Package webhook posts finished triage results as JSON to operator
+// endpoints, for automation that would otherwise need a bespoke
+// integration, such as ticketing or remediation pipelines.
+//
+// Each delivery carries the result exactly as GET /api/v1/triage/{id}
+// returns it. With a secret, it is signed the way receivers of GitHub and
+// Stripe webhooks expect: an HMAC-SHA256 of the timestamp and body, in
+// hex, so a receiver can reject forged and replayed requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Request headers set on every delivery.
+const (
+	// HeaderDelivery carries the triage ID, the same on every retry, so a
+	// receiver can drop duplicates.
+	HeaderDelivery = "X-Vigil-Delivery"
+	// HeaderTimestamp carries the Unix time the request was signed at.
+	HeaderTimestamp = "X-Vigil-Timestamp"
+	// HeaderSignature carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a ".", and the body, keyed with the shared secret. It is
+	// only set with a secret.
+	HeaderSignature = "X-Vigil-Signature"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+
+	// DefaultAttempts is how many times a delivery is tried when Config
+	// leaves Attempts zero.
+	DefaultAttempts = 3
+	// baseBackoff is the wait before the second attempt, doubling after.
+	baseBackoff = time.Second
+)
+
+// Config configures a Notifier.
+type Config struct {
+	// URLs are the endpoints each result is posted to.
+	URLs []string
+	// Secret keys the request signature; empty sends requests unsigned.
+	Secret string
+	// Attempts is how many times each endpoint is tried before the
+	// delivery fails. Network errors, 429 and 5xx responses are retried;
+	// other 4xx responses are not. Zero means DefaultAttempts.
+	Attempts int
+}
+
+// Notifier posts triage results to the configured endpoints.
+type Notifier struct {
+	cfg     Config
+	client  *http.Client
+	logger  log.Logger
+	now     func() time.Time
+	backoff time.Duration
+}
+
+// New creates a webhook notifier. Without URLs, Send is a no-op.
+func New(c Config, logger log.Logger) *Notifier {
+	if c.Attempts <= 0 {
+		c.Attempts = DefaultAttempts
+	}
+	return &Notifier{
+		cfg:     c,
+		client:  &http.Client{Timeout: httpTimeout},
+		logger:  logger,
+		now:     time.Now,
+		backoff: baseBackoff,
+	}
+}
+
+// Send posts result to every endpoint, retrying each on its own. It returns
+// the errors of the endpoints that still failed, joined; a retry of the
+// whole notification from the outbox posts to all of them again.
+func (n *Notifier) Send(ctx context.Context, result *triage.Result) error {
+	if len(n.cfg.URLs) == 0 {
+		return nil
+	}
+	body, err := Render(result)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, u := range n.cfg.URLs {
+		if err := n.deliver(ctx, u, result.ID, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Render returns the request body Send would post for result.
+func Render(result *triage.Result) ([]byte, error) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: marshal result: %w", err)
+	}
+	return body, nil
+}
+
+// Sign returns the HeaderSignature value for body sent at timestamp, for
+// receivers to compare against with hmac.Equal.
+func Sign(secret string, timestamp int64, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	m.Write([]byte("."))
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+// deliver posts body to one endpoint, doubling the wait between attempts.
+func (n *Notifier) deliver(ctx context.Context, url, deliveryID string, body []byte) error {
+	wait := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, url, deliveryID, body)
+		if err == nil || !retry || attempt == n.cfg.Attempts {
+			return err
+		}
+		n.logger.Warn(ctx, "webhook delivery failed, retrying", "triage_id", deliveryID, "attempt", attempt, "retry_in", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post makes one delivery attempt, reporting whether a failure is worth
+// retrying. Each attempt is signed afresh, so a retry is not mistaken for a
+// replay.
+func (n *Notifier) post(ctx context.Context, url, deliveryID string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("webhook: create request: %w", err)
+	}
+	ts := n.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	if n.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(n.cfg.Secret, ts, body))
+	}
+
+	resp, err := n.client.Do(req) //nolint:gosec // G704: url is from trusted config, not user input
+	if err != nil {
+		return true, fmt.Errorf("webhook: post %s: %w", req.URL.Redacted(), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook: %s returned %d: %s", req.URL.Redacted(), resp.StatusCode, string(respBody))
+	}
+	return false, nil
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestSend_SignsResult(t *testing.T) {
+	t.Parallel()
+
+	result := &triage.Result{ID: "t1", Status: triage.StatusComplete, Alert: "HighCPU", Analysis: "A runaway process."}
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || ts != 1767366245 {
+			t.Errorf("timestamp = %q, want 1767366245", r.Header.Get(HeaderTimestamp))
+		}
+		if got, want := r.Header.Get(HeaderSignature), Sign("s3cret", ts, body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if got := r.Header.Get(HeaderDelivery); got != "t1" {
+			t.Errorf("delivery = %q, want t1", got)
+		}
+		var got triage.Result
+		if err := json.Unmarshal(body, &got); err != nil || got.ID != "t1" || got.Analysis != result.Analysis {
+			t.Errorf("body = %s, %v, want the result", body, err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := New(Config{URLs: []string{srv.URL, srv.URL + "/second"}, Secret: "s3cret"}, log.Nop())
+	n.now = func() time.Time { return time.Unix(1767366245, 0) }
+	if err := n.Send(context.Background(), result); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("deliveries = %d, want one per URL", hits.Load())
+	}
+}
+
+func TestSend_Retries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		statuses  []int // returned in turn, the last one repeating
+		wantHits  int32
+		errSubstr string
+	}{
+		{name: "recovers after 503", statuses: []int{503, 200}, wantHits: 2},
+		{name: "retries 429", statuses: []int{429, 429, 202}, wantHits: 3},
+		{name: "gives up after attempts", statuses: []int{500}, wantHits: 3, errSubstr: "returned 500"},
+		{name: "client error not retried", statuses: []int{400}, wantHits: 1, errSubstr: "returned 400"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				i := int(hits.Add(1)) - 1
+				w.WriteHeader(tt.statuses[min(i, len(tt.statuses)-1)])
+			}))
+			defer srv.Close()
+
+			n := New(Config{URLs: []string{srv.URL}}, log.Nop())
+			n.backoff = time.Millisecond
+			err := n.Send(context.Background(), &triage.Result{ID: "t1"})
+			if tt.errSubstr == "" && err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if tt.errSubstr != "" && (err == nil || !strings.Contains(err.Error(), tt.errSubstr)) {
+				t.Fatalf("Send error = %v, want containing %q", err, tt.errSubstr)
+			}
+			if hits.Load() != tt.wantHits {
+				t.Errorf("attempts = %d, want %d", hits.Load(), tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestSend_UnsignedWithoutSecret(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sig := r.Header.Get(HeaderSignature); sig != "" {
+			t.Errorf("signature = %q, want none", sig)
+		}
+	}))
+	defer srv.Close()
+
+	if err := New(Config{URLs: []string{srv.URL}}, log.Nop()).Send(context.Background(), &triage.Result{ID: "t1"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+}
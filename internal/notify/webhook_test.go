@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+type notifierFunc func(ctx context.Context, r *triage.Result) error
+
+func (f notifierFunc) Send(ctx context.Context, r *triage.Result) error { return f(ctx, r) }
+
+func TestMulti_SendsToAll(t *testing.T) {
+	t.Parallel()
+
+	errFirst := errors.New("first down")
+	var sent []string
+	m := Multi{
+		notifierFunc(func(context.Context, *triage.Result) error { sent = append(sent, "first"); return errFirst }),
+		notifierFunc(func(context.Context, *triage.Result) error { sent = append(sent, "second"); return nil }),
+	}
+	err := m.Send(context.Background(), &triage.Result{ID: "t1"})
+	if !errors.Is(err, errFirst) {
+		t.Errorf("Send error = %v, want %v", err, errFirst)
+	}
+	if len(sent) != 2 {
+		t.Errorf("sent to %v, want both notifiers", sent)
+	}
+}
@@ -10,6 +10,27 @@ import (
 
 // NewPool creates a pgxpool.Pool with OTel tracing and structured query logging.
 func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	pool, err := newPool(ctx, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping: %w", err)
+	}
+
+	return pool, nil
+}
+
+// NewReplicaPool is NewPool for a read replica behind a ReadRouter. It does
+// not connect up front, so a replica that is down at startup only sends reads
+// to the primary.
+func NewReplicaPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	return newPool(ctx, databaseURL)
+}
+
+func newPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 	cfg, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parse database URL: %w", err)
@@ -22,11 +43,5 @@ func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 	if err != nil {
 		return nil, fmt.Errorf("pgxpool.NewWithConfig: %w", err)
 	}
-
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("ping: %w", err)
-	}
-
 	return pool, nil
 }
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// DefaultReplicaCheckInterval is how often ReadRouter.Run checks the replica.
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+// replicaLagSQL reports how far the replica's replay trails the primary, in
+// seconds. A replica that has replayed everything it received is caught up
+// however old its last transaction is, and a server that is not in recovery
+// is not behind anything.
+const replicaLagSQL = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END::float8`
+
+// ReadRouter sends read-only queries to a replica while it is reachable and
+// within maxLag of the primary, and to the primary otherwise. Reads go to the
+// primary until the first check passes.
+type ReadRouter struct {
+	primary *pgxpool.Pool
+	replica *pgxpool.Pool
+	maxLag  time.Duration
+
+	useReplica atomic.Bool
+	lag        atomic.Int64 // nanoseconds, from the last successful check
+}
+
+// NewReadRouter returns a router over the two pools. maxLag <= 0 accepts any
+// lag, falling back only when the replica is down.
+func NewReadRouter(primary, replica *pgxpool.Pool, maxLag time.Duration) *ReadRouter {
+	return &ReadRouter{primary: primary, replica: replica, maxLag: maxLag}
+}
+
+// Read returns the pool read-only queries should use.
+func (r *ReadRouter) Read() *pgxpool.Pool {
+	if r.useReplica.Load() {
+		return r.replica
+	}
+	return r.primary
+}
+
+// UsingReplica reports whether reads currently go to the replica.
+func (r *ReadRouter) UsingReplica() bool {
+	return r.useReplica.Load()
+}
+
+// Lag returns the replica lag measured by the last successful check.
+func (r *ReadRouter) Lag() time.Duration {
+	return time.Duration(r.lag.Load())
+}
+
+// Check measures the replica's lag and routes reads by it. It reports whether
+// reads now go to the replica, and the error that sent them to the primary
+// if the replica could not be checked.
+func (r *ReadRouter) Check(ctx context.Context) (bool, error) {
+	var seconds float64
+	err := r.replica.QueryRow(ctx, replicaLagSQL).Scan(&seconds)
+	lag := time.Duration(seconds * float64(time.Second))
+	if err != nil {
+		err = fmt.Errorf("check replica lag: %w", err)
+	} else {
+		r.lag.Store(int64(lag))
+	}
+	use := r.route(lag, err)
+	r.useReplica.Store(use)
+	return use, err
+}
+
+// route decides whether reads go to a replica with the given lag.
+func (r *ReadRouter) route(lag time.Duration, err error) bool {
+	return err == nil && (r.maxLag <= 0 || lag <= r.maxLag)
+}
+
+// Run checks the replica every interval until ctx is done, logging each time
+// reads move between the replica and the primary. The first check is one
+// interval in; call Check before Run to route reads from the start.
+func (r *ReadRouter) Run(ctx context.Context, logger log.Logger, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		was := r.UsingReplica()
+		use, err := r.Check(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case use && !was:
+			logger.Info(ctx, "routing reads to the database replica", "lag", r.Lag())
+		case !use && was && err != nil:
+			logger.Warn(ctx, "database replica unavailable, routing reads to the primary", "err", err)
+		case !use && was:
+			logger.Warn(ctx, "database replica lagging, routing reads to the primary", "lag", r.Lag(), "max_lag", r.maxLag)
+		}
+	}
+}
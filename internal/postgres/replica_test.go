@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestReadRouter_Route(t *testing.T) {
+	t.Parallel()
+
+	down := errors.New("connection refused")
+	tests := []struct {
+		name   string
+		maxLag time.Duration
+		lag    time.Duration
+		err    error
+		want   bool
+	}{
+		{name: "caught up", maxLag: 10 * time.Second, want: true},
+		{name: "within max lag", maxLag: 10 * time.Second, lag: 10 * time.Second, want: true},
+		{name: "lagging", maxLag: 10 * time.Second, lag: 11 * time.Second, want: false},
+		{name: "down", maxLag: 10 * time.Second, err: down, want: false},
+		{name: "no max lag", lag: time.Hour, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := NewReadRouter(nil, nil, tt.maxLag)
+			if got := r.route(tt.lag, tt.err); got != tt.want {
+				t.Errorf("route(%v, %v) = %v, want %v", tt.lag, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRouter_FallsBackWhenReplicaDown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// Pools connect lazily, so these only fail once queried.
+	primary, err := pgxpool.New(ctx, "postgres://vigil@127.0.0.1:1/primary?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("primary pool: %v", err)
+	}
+	defer primary.Close()
+	replica, err := pgxpool.New(ctx, "postgres://vigil@127.0.0.1:1/replica?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("replica pool: %v", err)
+	}
+	defer replica.Close()
+
+	r := NewReadRouter(primary, replica, time.Second)
+	if r.Read() != primary {
+		t.Error("Read before the first check should use the primary")
+	}
+	r.useReplica.Store(true)
+	if r.Read() != replica {
+		t.Error("Read should use a healthy replica")
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if use, err := r.Check(cctx); use || err == nil {
+		t.Errorf("Check = %v, %v; want the primary and an error", use, err)
+	}
+	if r.Read() != primary {
+		t.Error("Read after a failed check should use the primary")
+	}
+}
@@ -0,0 +1,4 @@
+// Package ratelimitmw provides HTTP middleware that throttles requests per
+// client IP and per bearer token with token buckets, and caps how many run at
+// once.
+package ratelimitmw
@@ -0,0 +1,174 @@
+package ratelimitmw
+
+import (
+	"crypto/sha256"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/linnemanlabs/go-core/httpmw"
+	"github.com/linnemanlabs/vigil/internal/alertapi"
+)
+
+// Throttle reasons passed to Options.OnThrottle.
+const (
+	ReasonClientIP    = "client_ip"
+	ReasonToken       = "token"
+	ReasonConcurrency = "concurrency"
+)
+
+// Options configures Limit. A zero rate or MaxConcurrent disables that check.
+type Options struct {
+	// IPRate is the sustained requests per second allowed from one client
+	// IP, as resolved by httpmw.ClientIP.
+	IPRate float64
+	// IPBurst is how many requests one client IP may send at once before
+	// IPRate applies, 0 means IPRate rounded up.
+	IPBurst int
+	// TokenRate and TokenBurst are the same for one bearer token.
+	TokenRate  float64
+	TokenBurst int
+	// MaxConcurrent is how many requests may be served at once across all
+	// clients.
+	MaxConcurrent int
+	// OnThrottle, if set, is called with the reason for every rejected
+	// request.
+	OnThrottle func(reason string)
+}
+
+// Limit returns middleware that answers 429 with a Retry-After header when a
+// client IP or bearer token exceeds its rate, or when MaxConcurrent requests
+// are already being served. Rate checks come first so throttled requests do
+// not take a concurrency slot.
+func Limit(o Options) func(http.Handler) http.Handler {
+	ips := newKeyed(o.IPRate, o.IPBurst)
+	tokens := newKeyed(o.TokenRate, o.TokenBurst)
+	var slots chan struct{}
+	if o.MaxConcurrent > 0 {
+		slots = make(chan struct{}, o.MaxConcurrent)
+	}
+	throttle := func(w http.ResponseWriter, r *http.Request, reason string, retry time.Duration, msg string) {
+		if o.OnThrottle != nil {
+			o.OnThrottle(reason)
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(retry.Seconds())))))
+		alertapi.WriteError(w, r, http.StatusTooManyRequests, alertapi.CodeRateLimited, msg)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			if d := ips.reserve(clientIP(r), now); d > 0 {
+				throttle(w, r, ReasonClientIP, d, "too many requests from this client")
+				return
+			}
+			if token, ok := bearer(r); ok {
+				if d := tokens.reserve(token, now); d > 0 {
+					throttle(w, r, ReasonToken, d, "too many requests for this token")
+					return
+				}
+			}
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				default:
+					throttle(w, r, ReasonConcurrency, time.Second, "too many requests in progress")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if ip := httpmw.ClientIPFromContext(r.Context()); ip != "" {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// bearer returns a digest of the request's bearer token, so raw tokens are
+// not kept in memory as map keys.
+func bearer(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(auth[len("Bearer "):]))
+	return string(sum[:]), true
+}
+
+// keyed holds one token bucket per key. Buckets idle long enough to have
+// refilled completely are indistinguishable from new ones, so they are
+// dropped to keep the map from growing with every client ever seen.
+type keyed struct {
+	limit rate.Limit
+	burst int
+	idle  time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	lim  *rate.Limiter
+	seen time.Time
+}
+
+// newKeyed returns nil, which allows everything, for a zero rate.
+func newKeyed(perSecond float64, burst int) *keyed {
+	if perSecond <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(perSecond))
+	}
+	refill := time.Duration(float64(burst) / perSecond * float64(time.Second))
+	return &keyed{
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+		idle:    max(refill, time.Minute),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// reserve takes a token from key's bucket and returns 0, or, if the bucket
+// is empty, leaves it alone and returns how long until a token is available.
+func (k *keyed) reserve(key string, now time.Time) time.Duration {
+	if k == nil {
+		return 0
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if now.Sub(k.lastSweep) >= k.idle {
+		for key, b := range k.buckets {
+			if now.Sub(b.seen) >= k.idle {
+				delete(k.buckets, key)
+			}
+		}
+		k.lastSweep = now
+	}
+	b := k.buckets[key]
+	if b == nil {
+		b = &bucket{lim: rate.NewLimiter(k.limit, k.burst)}
+		k.buckets[key] = b
+	}
+	b.seen = now
+	res := b.lim.ReserveN(now, 1)
+	if d := res.DelayFrom(now); d > 0 {
+		res.CancelAt(now)
+		return d
+	}
+	return 0
+}
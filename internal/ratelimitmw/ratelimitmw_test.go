@@ -0,0 +1,153 @@
+package ratelimitmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+})
+
+type throttles struct {
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (th *throttles) record(reason string) {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	th.reasons = append(th.reasons, reason)
+}
+
+func send(h http.Handler, remote, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts", http.NoBody)
+	req.RemoteAddr = remote
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLimit_ClientIP(t *testing.T) {
+	t.Parallel()
+
+	th := &throttles{}
+	// One request per 100 seconds, so the bucket does not refill mid-test.
+	h := Limit(Options{IPRate: 0.01, IPBurst: 2, OnThrottle: th.record})(okHandler)
+
+	for i := range 2 {
+		if rec := send(h, "10.0.0.1:5000", "a"); rec.Code != http.StatusAccepted {
+			t.Fatalf("request %d = %d, want %d", i, rec.Code, http.StatusAccepted)
+		}
+	}
+	rec := send(h, "10.0.0.1:5001", "b")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over burst = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "100" {
+		t.Errorf("Retry-After = %q, want 100", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"rate_limited"`) {
+		t.Errorf("body = %s, want error envelope", rec.Body.String())
+	}
+	if rec := send(h, "10.0.0.2:5000", "a"); rec.Code != http.StatusAccepted {
+		t.Errorf("other client = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if len(th.reasons) != 1 || th.reasons[0] != ReasonClientIP {
+		t.Errorf("throttle reasons = %v, want [%s]", th.reasons, ReasonClientIP)
+	}
+}
+
+func TestLimit_Token(t *testing.T) {
+	t.Parallel()
+
+	th := &throttles{}
+	h := Limit(Options{TokenRate: 0.01, TokenBurst: 1, OnThrottle: th.record})(okHandler)
+
+	if rec := send(h, "10.0.0.1:5000", "a"); rec.Code != http.StatusAccepted {
+		t.Fatalf("first = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if rec := send(h, "10.0.0.2:5000", "a"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("same token from another IP = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec := send(h, "10.0.0.1:5000", "b"); rec.Code != http.StatusAccepted {
+		t.Errorf("other token = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if len(th.reasons) != 1 || th.reasons[0] != ReasonToken {
+		t.Errorf("throttle reasons = %v, want [%s]", th.reasons, ReasonToken)
+	}
+}
+
+func TestLimit_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	th := &throttles{}
+	started, release := make(chan struct{}), make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	})
+	h := Limit(Options{MaxConcurrent: 1, OnThrottle: th.record})(blocking)
+
+	done := make(chan int)
+	go func() { done <- send(h, "10.0.0.1:5000", "a").Code }()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first request did not start")
+	}
+
+	rec := send(h, "10.0.0.2:5000", "b")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("second = %d Retry-After %q, want %d and 1", rec.Code, rec.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+	close(release)
+	if code := <-done; code != http.StatusAccepted {
+		t.Errorf("first = %d, want %d", code, http.StatusAccepted)
+	}
+
+	// The slot is free again.
+	go func() { <-started }()
+	if rec := send(h, "10.0.0.2:5000", "b"); rec.Code != http.StatusAccepted {
+		t.Errorf("after release = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if len(th.reasons) != 1 || th.reasons[0] != ReasonConcurrency {
+		t.Errorf("throttle reasons = %v, want [%s]", th.reasons, ReasonConcurrency)
+	}
+}
+
+func TestLimit_Disabled(t *testing.T) {
+	t.Parallel()
+
+	h := Limit(Options{})(okHandler)
+	for i := range 100 {
+		if rec := send(h, "10.0.0.1:5000", "a"); rec.Code != http.StatusAccepted {
+			t.Fatalf("request %d = %d, want %d", i, rec.Code, http.StatusAccepted)
+		}
+	}
+}
+
+func TestKeyed_EvictsIdleBuckets(t *testing.T) {
+	t.Parallel()
+
+	k := newKeyed(1, 1)
+	now := time.Now()
+	k.reserve("a", now)
+	k.reserve("b", now.Add(30*time.Second))
+	k.reserve("c", now.Add(61*time.Second))
+
+	if _, ok := k.buckets["a"]; ok {
+		t.Error("idle bucket a was kept")
+	}
+	if _, ok := k.buckets["b"]; !ok {
+		t.Error("recent bucket b was dropped")
+	}
+}
@@ -0,0 +1,244 @@
+// Package readiness probes the services Vigil depends on and reports them
+// through the readiness endpoint and as gauges.
+//
+// A replica that cannot reach its database should be taken out of rotation,
+// but one whose Loki is down can still triage with the tools left, so each
+// dependency is either critical, failing readiness, or only degrades it.
+// Probes run in the background on an interval rather than per request, so a
+// load balancer polling /-/ready does not turn into API calls to Anthropic.
+package readiness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/linnemanlabs/go-core/health"
+)
+
+// Dependency is one probed service.
+type Dependency struct {
+	// Name identifies the dependency in the detail output and metrics.
+	Name string
+
+	// Critical dependencies fail readiness; the others only mark it
+	// degraded.
+	Critical bool
+
+	// Timeout bounds one check of this dependency.
+	Timeout time.Duration
+
+	// Check returns nil when the dependency is usable.
+	Check func(ctx context.Context) error
+}
+
+// Status is the result of the last check of a dependency.
+type Status struct {
+	Name      string
+	Critical  bool
+	Err       error
+	Duration  time.Duration
+	CheckedAt time.Time
+}
+
+// Checker probes dependencies and serves their last results. It satisfies
+// health.Probe, failing while a critical dependency is failing.
+type Checker struct {
+	deps []Dependency
+
+	mu       sync.Mutex
+	statuses []Status
+
+	up       *prometheus.GaugeVec
+	duration *prometheus.GaugeVec
+	degraded prometheus.Gauge
+}
+
+// New builds a Checker for deps, registering its gauges on reg. Nothing is
+// checked until CheckAll or Run.
+func New(deps []Dependency, reg prometheus.Registerer) *Checker {
+	c := &Checker{
+		deps: deps,
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vigil_dependency_up",
+			Help: "Whether the last readiness check of a dependency succeeded (1) or failed (0), by dependency and whether it is critical.",
+		}, []string{"dependency", "critical"}),
+		duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vigil_dependency_check_duration_seconds",
+			Help: "Duration of the last readiness check of a dependency in seconds.",
+		}, []string{"dependency"}),
+		degraded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_readiness_degraded",
+			Help: "1 while a non-critical dependency is failing and every critical one is up: ready, but triages may lack data.",
+		}),
+	}
+	reg.MustRegister(c.up, c.duration, c.degraded)
+	return c
+}
+
+// CheckAll checks every dependency concurrently, each under its own timeout,
+// and records the results.
+func (c *Checker) CheckAll(ctx context.Context) {
+	statuses := make([]Status, len(c.deps))
+	var wg sync.WaitGroup
+	for i, d := range c.deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = check(ctx, d)
+		}()
+	}
+	wg.Wait()
+
+	degraded := false
+	for _, st := range statuses {
+		up := 1.0
+		if st.Err != nil {
+			up = 0
+			degraded = degraded || !st.Critical
+		}
+		c.up.WithLabelValues(st.Name, strconv.FormatBool(st.Critical)).Set(up)
+		c.duration.WithLabelValues(st.Name).Set(st.Duration.Seconds())
+	}
+
+	c.mu.Lock()
+	c.statuses = statuses
+	c.mu.Unlock()
+	if degraded && c.Check(ctx) == nil {
+		c.degraded.Set(1)
+	} else {
+		c.degraded.Set(0)
+	}
+}
+
+func check(ctx context.Context, d Dependency) Status {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+	start := time.Now()
+	err := d.Check(ctx)
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("no answer within %s: %w", d.Timeout, err)
+	}
+	return Status{Name: d.Name, Critical: d.Critical, Err: err, Duration: time.Since(start), CheckedAt: start}
+}
+
+// Run checks every dependency every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			c.CheckAll(ctx)
+		}
+	}
+}
+
+// Statuses returns the results of the last check, in dependency order.
+func (c *Checker) Statuses() []Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Status(nil), c.statuses...)
+}
+
+// Check returns an error naming every critical dependency that failed its
+// last check.
+func (c *Checker) Check(context.Context) error {
+	var errs []error
+	for _, st := range c.Statuses() {
+		if st.Critical && st.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", st.Name, st.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Degraded reports whether a non-critical dependency failed its last check.
+func (c *Checker) Degraded() bool {
+	for _, st := range c.Statuses() {
+		if !st.Critical && st.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler serves readiness with a line per dependency. gate is checked
+// first, such as the shutdown gate; a failing gate or critical dependency
+// answers 503, a failing non-critical one answers 200 "ready (degraded)".
+func (c *Checker) Handler(gate health.Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		var gateErr error
+		if gate != nil {
+			gateErr = gate.Check(r.Context())
+		}
+		var b strings.Builder
+		status := http.StatusOK
+		switch {
+		case gateErr != nil:
+			status = http.StatusServiceUnavailable
+			fmt.Fprintf(&b, "not ready: %v\n", gateErr)
+		case c.Check(r.Context()) != nil:
+			status = http.StatusServiceUnavailable
+			b.WriteString("not ready\n")
+		case c.Degraded():
+			b.WriteString("ready (degraded)\n")
+		default:
+			b.WriteString("ready\n")
+		}
+		for _, st := range c.Statuses() {
+			writeStatus(&b, st)
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, b.String())
+	}
+}
+
+func writeStatus(b *strings.Builder, st Status) {
+	kind := "optional"
+	if st.Critical {
+		kind = "critical"
+	}
+	if st.Err != nil {
+		fmt.Fprintf(b, "%s (%s): failing after %s: %v\n", st.Name, kind, st.Duration.Round(time.Millisecond), st.Err)
+		return
+	}
+	fmt.Fprintf(b, "%s (%s): ok in %s\n", st.Name, kind, st.Duration.Round(time.Millisecond))
+}
+
+// HTTPCheck returns a check that GETs url and expects a 2xx answer, such as
+// Prometheus's /-/ready or Loki's /ready. Headers, if any, are set on the
+// request.
+func HTTPCheck(client *http.Client, url string, headers map[string]string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+		if err != nil {
+			return err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/linnemanlabs/go-core/health"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func hanging(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		deps         []Dependency
+		gate         health.Probe
+		wantStatus   int
+		wantBody     []string
+		wantDegraded float64
+	}{
+		{
+			name: "all up",
+			deps: []Dependency{
+				{Name: "postgres", Critical: true, Timeout: time.Second, Check: ok},
+				{Name: "loki", Timeout: time.Second, Check: ok},
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"ready\n", "postgres (critical): ok in", "loki (optional): ok in"},
+		},
+		{
+			name: "optional down",
+			deps: []Dependency{
+				{Name: "postgres", Critical: true, Timeout: time.Second, Check: ok},
+				{Name: "loki", Timeout: time.Second, Check: failing},
+			},
+			wantStatus:   http.StatusOK,
+			wantBody:     []string{"ready (degraded)\n", "loki (optional): failing after", "connection refused"},
+			wantDegraded: 1,
+		},
+		{
+			name: "critical down",
+			deps: []Dependency{
+				{Name: "postgres", Critical: true, Timeout: 20 * time.Millisecond, Check: hanging},
+				{Name: "loki", Timeout: time.Second, Check: failing},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   []string{"not ready\n", "postgres (critical): failing after", "no answer within 20ms"},
+		},
+		{
+			name:       "gate closed",
+			deps:       []Dependency{{Name: "postgres", Critical: true, Timeout: time.Second, Check: ok}},
+			gate:       health.Fixed(false, "shutting down"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   []string{"not ready: shutting down", "postgres (critical): ok in"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reg := prometheus.NewRegistry()
+			c := New(tt.deps, reg)
+			c.CheckAll(context.Background())
+
+			rec := httptest.NewRecorder()
+			c.Handler(tt.gate).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/ready", http.NoBody))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body missing %q:\n%s", want, rec.Body.String())
+				}
+			}
+			if got := testutil.ToFloat64(c.degraded); got != tt.wantDegraded {
+				t.Errorf("vigil_readiness_degraded = %v, want %v", got, tt.wantDegraded)
+			}
+			for _, st := range c.Statuses() {
+				want := 1.0
+				if st.Err != nil {
+					want = 0
+				}
+				if got := testutil.ToFloat64(c.up.WithLabelValues(st.Name, strconv.FormatBool(st.Critical))); got != want {
+					t.Errorf("vigil_dependency_up{dependency=%q} = %v, want %v", st.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Scope-OrgID") != "acme" {
+			http.Error(w, "no tenant", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/-/ready" {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("Prometheus Server is Ready.\n"))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	if err := HTTPCheck(srv.Client(), srv.URL+"/-/ready", map[string]string{"X-Scope-OrgID": "acme"})(ctx); err != nil {
+		t.Errorf("ready endpoint: %v", err)
+	}
+	if err := HTTPCheck(srv.Client(), srv.URL+"/ready", map[string]string{"X-Scope-OrgID": "acme"})(ctx); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("failing endpoint: err = %v, want 503", err)
+	}
+	if err := HTTPCheck(srv.Client(), srv.URL+"/-/ready", nil)(ctx); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("missing tenant: err = %v, want 401", err)
+	}
+}
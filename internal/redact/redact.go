@@ -0,0 +1,293 @@
+// Package redact scrubs secrets and personal data from tool output before it
+// enters a triage's conversation, and with it the LLM request, the store and
+// notifications.
+//
+// Log lines in particular carry whatever the application printed: bearer
+// tokens, connection strings with passwords, customer email addresses. Two
+// detectors run over every output. Patterns are regular expressions for
+// secrets with a known shape; the built-in set covers the common ones and the
+// operator can add more. The entropy heuristic catches the rest: long tokens
+// of mixed-case letters and digits that look random, as generated keys do.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SecretGroup is the name of the capture group that limits a pattern's
+// replacement to part of the match, so password=hunter2 keeps the key.
+const SecretGroup = "secret"
+
+// Pattern is one named regular expression.
+type Pattern struct {
+	// Name appears in the replacement, [REDACTED:<name>], so the model and
+	// readers know what kind of value was there.
+	Name string `json:"name"`
+	// Regex is an RE2 expression. If it has a capture group named secret,
+	// only that group is replaced; otherwise the whole match is.
+	Regex string `json:"regex"`
+}
+
+// Entropy configures the high-entropy token heuristic.
+type Entropy struct {
+	// Disabled turns the heuristic off.
+	Disabled bool `json:"disabled"`
+	// MinLength is the shortest token considered, 0 means 20.
+	MinLength int `json:"min_length"`
+	// Threshold is the Shannon entropy in bits per character at or above
+	// which a token is redacted, 0 means 4.0.
+	Threshold float64 `json:"threshold"`
+}
+
+// Config is the redaction file format. The zero Config runs the built-in
+// patterns and the entropy heuristic with its defaults.
+type Config struct {
+	// DisableBuiltin drops the built-in patterns, leaving only Patterns.
+	DisableBuiltin bool      `json:"disable_builtin"`
+	Patterns       []Pattern `json:"patterns"`
+	Entropy        Entropy   `json:"entropy"`
+}
+
+const (
+	defaultMinLength = 20
+	defaultThreshold = 4.0
+)
+
+// Builtin are the patterns run unless Config.DisableBuiltin is set.
+var Builtin = []Pattern{
+	{Name: "private-key", Regex: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "bearer", Regex: `(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/-]{8,}=*)`},
+	{Name: "jwt", Regex: `\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`},
+	{Name: "aws-access-key", Regex: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "github-token", Regex: `\bgh[pousr]_[A-Za-z0-9]{36,}\b`},
+	{Name: "slack-token", Regex: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{Name: "url-password", Regex: `[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`},
+	{Name: "credential", Regex: `(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)"?\s*[=:]\s*"?(?P<secret>[^\s"',;&}]{4,})`},
+	{Name: "email", Regex: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`},
+}
+
+// LoadConfig reads and validates a JSON redaction config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read redaction config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse redaction config %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("redaction config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem in the config.
+func (c *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	if !c.DisableBuiltin {
+		for _, p := range Builtin {
+			names[p.Name] = true
+		}
+	}
+	for i, p := range c.Patterns {
+		switch {
+		case p.Name == "":
+			errs = append(errs, fmt.Errorf("patterns[%d]: name is required", i))
+		case names[p.Name]:
+			errs = append(errs, fmt.Errorf("pattern %q: duplicate name", p.Name))
+		}
+		names[p.Name] = true
+		if _, err := compile(p); err != nil {
+			errs = append(errs, fmt.Errorf("pattern %q: %w", p.Name, err))
+		}
+	}
+	if c.Entropy.MinLength < 0 {
+		errs = append(errs, fmt.Errorf("entropy: min_length must not be negative, got %d", c.Entropy.MinLength))
+	}
+	if c.Entropy.Threshold < 0 || c.Entropy.Threshold > 8 {
+		errs = append(errs, fmt.Errorf("entropy: threshold must be between 0 and 8 bits per character, got %v", c.Entropy.Threshold))
+	}
+	return errors.Join(errs...)
+}
+
+func compile(p Pattern) (*regexp.Regexp, error) {
+	if p.Regex == "" {
+		return nil, errors.New("regex is required")
+	}
+	re, err := regexp.Compile(p.Regex)
+	if err != nil {
+		return nil, err
+	}
+	if re.MatchString("") {
+		return nil, errors.New("regex matches the empty string")
+	}
+	return re, nil
+}
+
+// Scrubber redacts text. It satisfies triage.Scrubber and is safe for
+// concurrent use.
+type Scrubber struct {
+	patterns  []pattern
+	entropy   bool
+	minLength int
+	threshold float64
+}
+
+type pattern struct {
+	name   string
+	re     *regexp.Regexp
+	secret int // index of the secret group, 0 for the whole match
+}
+
+// New builds a Scrubber from a config.
+func New(c Config) (*Scrubber, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Scrubber{
+		entropy:   !c.Entropy.Disabled,
+		minLength: c.Entropy.MinLength,
+		threshold: c.Entropy.Threshold,
+	}
+	if s.minLength == 0 {
+		s.minLength = defaultMinLength
+	}
+	if s.threshold == 0 {
+		s.threshold = defaultThreshold
+	}
+	var all []Pattern
+	if !c.DisableBuiltin {
+		all = append(all, Builtin...)
+	}
+	for _, p := range append(all, c.Patterns...) {
+		re, _ := compile(p) // checked by Validate
+		s.patterns = append(s.patterns, pattern{name: p.Name, re: re, secret: max(re.SubexpIndex(SecretGroup), 0)})
+	}
+	return s, nil
+}
+
+// Scrub returns text with every detected secret replaced by
+// [REDACTED:<name>], and how many were replaced.
+func (s *Scrubber) Scrub(text string) (string, int) {
+	n := 0
+	for _, p := range s.patterns {
+		var c int
+		text, c = p.replace(text)
+		n += c
+	}
+	if s.entropy {
+		var c int
+		text, c = s.replaceHighEntropy(text)
+		n += c
+	}
+	return text, n
+}
+
+func marker(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+func (p *pattern) replace(text string) (string, int) {
+	matches := p.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text, 0
+	}
+	var b strings.Builder
+	last, n := 0, 0
+	for _, m := range matches {
+		start, end := m[2*p.secret], m[2*p.secret+1]
+		if start < 0 {
+			continue // the secret group did not take part in this match
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(marker(p.name))
+		last = end
+		n++
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+// replaceHighEntropy redacts tokens that look generated: at least minLength
+// characters of the base64 alphabet, mixing upper case, lower case and
+// digits, with entropy at or above the threshold. Requiring all three classes
+// spares identifiers such as pod names and hex hashes, which are long but not
+// secret. The slash and equals sign are left out of the alphabet so file
+// paths, URLs and key=value pairs are split into their parts.
+func (s *Scrubber) replaceHighEntropy(text string) (string, int) {
+	var b strings.Builder
+	n, last := 0, 0
+	for i := 0; i < len(text); {
+		if !isTokenChar(text[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && isTokenChar(text[j]) {
+			j++
+		}
+		if tok := text[i:j]; len(tok) >= s.minLength && !strings.HasPrefix(tok, "REDACTED") && looksRandom(tok, s.threshold) {
+			b.WriteString(text[last:i])
+			b.WriteString(marker("high-entropy"))
+			last = j
+			n++
+		}
+		i = j
+	}
+	if n == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+func isTokenChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '+' || c == '_' || c == '-'
+}
+
+func looksRandom(tok string, threshold float64) bool {
+	var upper, lower, digit bool
+	var counts [256]int
+	for i := 0; i < len(tok); i++ {
+		c := tok[i]
+		counts[c]++
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	return shannon(counts[:], len(tok)) >= threshold
+}
+
+// shannon returns the entropy in bits per character of a string of length n
+// with the given byte counts.
+func shannon(counts []int, n int) float64 {
+	var h float64
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
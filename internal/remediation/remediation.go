@@ -0,0 +1,276 @@
+// Package remediation is the allow-list of actions the model may suggest to
+// fix what it found, such as restarting a systemd unit or scaling a
+// deployment, and runs them once an operator approves.
+//
+// Nothing here runs on the model's say-so. The model only names an action
+// and its parameters; each parameter must match the pattern the operator
+// declared for it, and the action runs as the operator wrote it: a command
+// run without a shell, with parameters filled into its arguments, or a POST
+// to a webhook.
+package remediation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+const (
+	// DefaultTimeoutSeconds bounds an action whose config sets no timeout.
+	DefaultTimeoutSeconds = 60
+	// maxOutputBytes bounds the output kept from an action.
+	maxOutputBytes = 4096
+)
+
+// Config is the catalog file format.
+type Config struct {
+	Actions []Action `json:"actions"`
+}
+
+// Action is one remediation in the catalog. Exactly one of Command and
+// Webhook is set.
+type Action struct {
+	// Name is what the model suggests and the API shows, such as
+	// "restart_unit".
+	Name string `json:"name"`
+	// Description tells the model what the action does and when it helps.
+	Description string `json:"description"`
+	// Params maps each parameter to a regular expression its value must
+	// match in full. Every parameter is required.
+	Params map[string]string `json:"params,omitempty"`
+	// Command is the program and arguments to run, without a shell.
+	// "{name}" in an argument is replaced with parameter name's value.
+	Command []string `json:"command,omitempty"`
+	// Webhook is a URL POSTed {"action": ..., "params": {...}} as JSON.
+	Webhook string `json:"webhook,omitempty"`
+	// TimeoutSeconds bounds a run, DefaultTimeoutSeconds when zero.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// LoadConfig reads and validates a JSON catalog file.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read remediation config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse remediation config %s: %w", path, err)
+	}
+	if _, err := New(c); err != nil {
+		return c, fmt.Errorf("remediation config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Catalog implements triage.ActionCatalog over the actions of a Config.
+type Catalog struct {
+	actions map[string]*action
+	names   []string
+	client  *http.Client
+}
+
+type action struct {
+	Action
+	params  map[string]*regexp.Regexp
+	timeout time.Duration
+}
+
+var (
+	nameRe        = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	placeholderRe = regexp.MustCompile(`\{([a-z][a-z0-9_]*)\}`)
+)
+
+// New validates c and builds its catalog, reporting every problem at once.
+func New(c Config) (*Catalog, error) {
+	var errs []error
+	cat := &Catalog{actions: make(map[string]*action), client: &http.Client{}}
+	for i, a := range c.Actions {
+		if !nameRe.MatchString(a.Name) {
+			errs = append(errs, fmt.Errorf("actions[%d]: name %q must be lowercase letters, digits and underscores", i, a.Name))
+			continue
+		}
+		if _, dup := cat.actions[a.Name]; dup {
+			errs = append(errs, fmt.Errorf("action %q: defined more than once", a.Name))
+			continue
+		}
+		if a.Description == "" {
+			errs = append(errs, fmt.Errorf("action %q: description is required", a.Name))
+		}
+		if (len(a.Command) == 0) == (a.Webhook == "") {
+			errs = append(errs, fmt.Errorf("action %q: exactly one of command and webhook is required", a.Name))
+		}
+		if a.Webhook != "" && !strings.HasPrefix(a.Webhook, "http://") && !strings.HasPrefix(a.Webhook, "https://") {
+			errs = append(errs, fmt.Errorf("action %q: webhook must be an http or https URL", a.Name))
+		}
+		if a.TimeoutSeconds < 0 {
+			errs = append(errs, fmt.Errorf("action %q: timeout_seconds must not be negative", a.Name))
+		}
+		act := &action{Action: a, params: make(map[string]*regexp.Regexp, len(a.Params))}
+		for name, pattern := range a.Params {
+			if !nameRe.MatchString(name) {
+				errs = append(errs, fmt.Errorf("action %q: param name %q must be lowercase letters, digits and underscores", a.Name, name))
+				continue
+			}
+			re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("action %q: param %q: %w", a.Name, name, err))
+				continue
+			}
+			act.params[name] = re
+		}
+		for _, arg := range a.Command {
+			for _, m := range placeholderRe.FindAllStringSubmatch(arg, -1) {
+				if _, ok := a.Params[m[1]]; !ok {
+					errs = append(errs, fmt.Errorf("action %q: command refers to undeclared param %q", a.Name, m[1]))
+				}
+			}
+		}
+		act.timeout = time.Duration(a.TimeoutSeconds) * time.Second
+		if act.timeout == 0 {
+			act.timeout = DefaultTimeoutSeconds * time.Second
+		}
+		cat.actions[a.Name] = act
+		cat.names = append(cat.names, a.Name)
+	}
+	if len(c.Actions) == 0 {
+		errs = append(errs, errors.New("at least one action is required"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+// Names returns the action names in config order.
+func (c *Catalog) Names() []string {
+	return c.names
+}
+
+// Prompt implements triage.ActionCatalog, listing each action with its
+// parameters and their patterns.
+func (c *Catalog) Prompt() string {
+	var b strings.Builder
+	for _, name := range c.names {
+		a := c.actions[name]
+		fmt.Fprintf(&b, "- %s: %s", name, a.Description)
+		if len(a.Params) > 0 {
+			b.WriteString(" Params:")
+			for i, p := range slices.Sorted(maps.Keys(a.Params)) {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				fmt.Fprintf(&b, " %s (matching %s)", p, a.Params[p])
+			}
+			b.WriteString(".")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Validate implements triage.ActionCatalog. Every declared parameter must be
+// given and match its pattern, and no others may be.
+func (c *Catalog) Validate(sa triage.SuggestedAction) error {
+	a, ok := c.actions[sa.Action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", sa.Action)
+	}
+	for name, re := range a.params {
+		v, ok := sa.Params[name]
+		if !ok {
+			return fmt.Errorf("%s: param %q is required", sa.Action, name)
+		}
+		if !re.MatchString(v) {
+			return fmt.Errorf("%s: param %q value %q does not match %s", sa.Action, name, v, a.Params[name])
+		}
+	}
+	for name := range sa.Params {
+		if _, ok := a.params[name]; !ok {
+			return fmt.Errorf("%s: unknown param %q", sa.Action, name)
+		}
+	}
+	return nil
+}
+
+// Execute implements triage.ActionCatalog. It validates sa again, so a
+// caller cannot run what the catalog would not accept.
+func (c *Catalog) Execute(ctx context.Context, sa triage.SuggestedAction) (string, error) {
+	if err := c.Validate(sa); err != nil {
+		return "", err
+	}
+	a := c.actions[sa.Action]
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	if a.Webhook != "" {
+		return c.post(ctx, a, sa)
+	}
+	return run(ctx, a, sa)
+}
+
+// run runs the action's command with sa's parameters filled in.
+func run(ctx context.Context, a *action, sa triage.SuggestedAction) (string, error) {
+	argv := make([]string, len(a.Command))
+	for i, arg := range a.Command {
+		argv[i] = placeholderRe.ReplaceAllStringFunc(arg, func(m string) string {
+			return sa.Params[m[1:len(m)-1]]
+		})
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // G204: the command is from the operator's catalog, parameters are pattern-checked
+	out, err := cmd.CombinedOutput()
+	text := truncate(out)
+	if ctx.Err() != nil {
+		return text, fmt.Errorf("%s: timed out after %s", sa.Action, a.timeout)
+	}
+	if err != nil {
+		return text, fmt.Errorf("%s: %w", sa.Action, err)
+	}
+	return text, nil
+}
+
+// post sends the action and its parameters to the action's webhook.
+func (c *Catalog) post(ctx context.Context, a *action, sa triage.SuggestedAction) (string, error) {
+	body, err := json.Marshal(map[string]any{"action": sa.Action, "params": sa.Params})
+	if err != nil {
+		return "", fmt.Errorf("%s: marshal request: %w", sa.Action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("%s: create request: %w", sa.Action, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req) //nolint:gosec // G704: the URL is from the operator's catalog
+	if err != nil {
+		return "", fmt.Errorf("%s: post webhook: %w", sa.Action, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	out, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutputBytes+1))
+	text := truncate(out)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return text, fmt.Errorf("%s: webhook returned %d", sa.Action, resp.StatusCode)
+	}
+	return text, nil
+}
+
+// truncate keeps the start of out, marking a cut.
+func truncate(out []byte) string {
+	if len(out) <= maxOutputBytes {
+		return string(out)
+	}
+	return string(out[:maxOutputBytes]) + "\n[truncated]"
+}
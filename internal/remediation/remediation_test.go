@@ -0,0 +1,203 @@
+package remediation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func testConfig() Config {
+	return Config{Actions: []Action{
+		{
+			Name:        "restart_unit",
+			Description: "Restart a systemd unit.",
+			Params:      map[string]string{"unit": `[a-z0-9@._-]+\.service`},
+			Command:     []string{"echo", "restart", "{unit}"},
+		},
+		{
+			Name:        "scale_deployment",
+			Description: "Scale a deployment.",
+			Params:      map[string]string{"deployment": `[a-z0-9-]+`, "replicas": `[1-9]`},
+			Command:     []string{"echo", "deployment/{deployment}", "--replicas={replicas}"},
+		},
+	}}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		errSubs string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"empty", func(c *Config) { c.Actions = nil }, "at least one action"},
+		{"bad name", func(c *Config) { c.Actions[0].Name = "Restart Unit" }, "lowercase"},
+		{"duplicate", func(c *Config) { c.Actions[1].Name = "restart_unit" }, "more than once"},
+		{"no description", func(c *Config) { c.Actions[0].Description = "" }, "description is required"},
+		{"no command or webhook", func(c *Config) { c.Actions[0].Command = nil }, "exactly one of command and webhook"},
+		{"command and webhook", func(c *Config) { c.Actions[0].Webhook = "https://example.com" }, "exactly one of command and webhook"},
+		{"bad webhook", func(c *Config) { c.Actions[0].Command, c.Actions[0].Webhook = nil, "ftp://example.com" }, "http or https"},
+		{"bad pattern", func(c *Config) { c.Actions[0].Params["unit"] = "(" }, `param "unit"`},
+		{"undeclared placeholder", func(c *Config) { c.Actions[0].Command = []string{"systemctl", "{host}"} }, `undeclared param "host"`},
+		{"negative timeout", func(c *Config) { c.Actions[0].TimeoutSeconds = -1 }, "timeout_seconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := testConfig()
+			tt.mutate(&c)
+			_, err := New(c)
+			if tt.errSubs == "" {
+				if err != nil {
+					t.Fatalf("New: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSubs) {
+				t.Fatalf("New error = %v, want containing %q", err, tt.errSubs)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	b, _ := json.Marshal(testConfig())
+	if err := os.WriteFile(good, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(good); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	unknown := filepath.Join(dir, "unknown.json")
+	if err := os.WriteFile(unknown, []byte(`{"actions": [], "auto_execute": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(unknown); err == nil || !strings.Contains(err.Error(), "auto_execute") {
+		t.Fatalf("LoadConfig error = %v, want unknown field", err)
+	}
+}
+
+func TestCatalog_Prompt(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- restart_unit: Restart a systemd unit. Params: unit (matching [a-z0-9@._-]+\\.service).\n" +
+		"- scale_deployment: Scale a deployment. Params: deployment (matching [a-z0-9-]+), replicas (matching [1-9]).\n"
+	if got := c.Prompt(); got != want {
+		t.Errorf("Prompt =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCatalog_Validate(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		action  triage.SuggestedAction
+		errSubs string
+	}{
+		{"valid", triage.SuggestedAction{Action: "restart_unit", Params: map[string]string{"unit": "nginx.service"}}, ""},
+		{"unknown action", triage.SuggestedAction{Action: "drop_database"}, "unknown action"},
+		{"missing param", triage.SuggestedAction{Action: "restart_unit"}, "is required"},
+		{"pattern is anchored", triage.SuggestedAction{Action: "restart_unit", Params: map[string]string{"unit": "nginx.service; reboot"}}, "does not match"},
+		{"extra param", triage.SuggestedAction{Action: "restart_unit", Params: map[string]string{"unit": "nginx.service", "host": "db-1"}}, `unknown param "host"`},
+		{"out of range", triage.SuggestedAction{Action: "scale_deployment", Params: map[string]string{"deployment": "api", "replicas": "50"}}, "does not match"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := c.Validate(tt.action)
+			if tt.errSubs == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSubs) {
+				t.Fatalf("Validate error = %v, want containing %q", err, tt.errSubs)
+			}
+		})
+	}
+}
+
+func TestCatalog_ExecuteCommand(t *testing.T) {
+	t.Parallel()
+
+	c, err := New(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Execute(context.Background(), triage.SuggestedAction{
+		Action: "scale_deployment",
+		Params: map[string]string{"deployment": "api", "replicas": "3"},
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out != "deployment/api --replicas=3\n" {
+		t.Errorf("output = %q", out)
+	}
+
+	if _, err := c.Execute(context.Background(), triage.SuggestedAction{Action: "restart_unit", Params: map[string]string{"unit": "$(reboot)"}}); err == nil {
+		t.Error("Execute ran an action that fails validation")
+	}
+}
+
+func TestCatalog_ExecuteWebhook(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got["params"].(map[string]any)["cache"] == "broken" {
+			http.Error(w, "no such cache", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("flushed"))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(Config{Actions: []Action{{
+		Name:        "clear_cache",
+		Description: "Flush an application cache.",
+		Params:      map[string]string{"cache": `[a-z]+`},
+		Webhook:     srv.URL,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := c.Execute(context.Background(), triage.SuggestedAction{Action: "clear_cache", Params: map[string]string{"cache": "sessions"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out != "flushed" || got["action"] != "clear_cache" {
+		t.Errorf("output = %q, request = %v", out, got)
+	}
+
+	out, err = c.Execute(context.Background(), triage.SuggestedAction{Action: "clear_cache", Params: map[string]string{"cache": "broken"}})
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(out, "no such cache") {
+		t.Errorf("Execute = %q, %v; want the 404 and its body", out, err)
+	}
+}
@@ -0,0 +1,106 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Recorder appends every model response and tool call of the engine's
+// triages to <dir>/<triage id>.jsonl. It is both a triage.LLMObserver and a
+// triage.ToolObserver and must be given to the engine as both. Tool outputs
+// are recorded as scrubbed for the model; write failures are logged and
+// never fail the triage.
+type Recorder struct {
+	dir    string
+	logger log.Logger
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a Recorder writing to dir, creating it if needed.
+func NewRecorder(dir string, logger log.Logger) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create record dir: %w", err)
+	}
+	return &Recorder{dir: dir, logger: logger}, nil
+}
+
+// Path returns the file the triage is recorded to.
+func (r *Recorder) Path(triageID string) string {
+	return filepath.Join(r.dir, triageID+".jsonl")
+}
+
+// ObserveLLMCall records the call's response, preceded by a start record
+// on the first call of a run.
+func (r *Recorder) ObserveLLMCall(ctx context.Context, call *triage.LLMCall) {
+	var recs []Record
+	if call.Seq == 0 {
+		start := Record{Kind: KindStart, TriageID: call.TriageID, Alert: call.Alert}
+		if call.Request != nil {
+			start.System, start.Tools = call.Request.System, call.Request.Tools
+		}
+		recs = append(recs, start)
+	}
+	resp := &Response{}
+	if call.Err != nil {
+		resp.Error = call.Err.Error()
+	} else if call.Response != nil {
+		resp.Content, resp.StopReason = call.Response.Content, call.Response.StopReason
+		resp.Usage, resp.Model = call.Response.Usage, call.Response.Model
+	}
+	recs = append(recs, Record{Kind: KindResponse, Response: resp})
+	r.write(ctx, call.TriageID, recs...)
+}
+
+// ObserveToolCall records the call and what the model was shown.
+func (r *Recorder) ObserveToolCall(ctx context.Context, call *triage.ToolExecution) {
+	tc := &ToolCall{Tool: call.Tool, Input: call.Input}
+	if call.IsError {
+		tc.Error = call.Output
+	} else {
+		tc.Output = OutputJSON(call.Output)
+	}
+	r.write(ctx, call.TriageID, Record{Kind: KindToolCall, ToolCall: tc})
+}
+
+// write appends recs to the triage's file.
+func (r *Recorder) write(ctx context.Context, triageID string, recs ...Record) {
+	if err := r.append(triageID, recs); err != nil {
+		r.logger.Error(ctx, err, "record triage", "triage_id", triageID)
+	}
+}
+
+func (r *Recorder) append(triageID string, recs []Record) error {
+	if triageID == "" || strings.ContainsAny(triageID, `/\`) || triageID == "." || triageID == ".." {
+		return fmt.Errorf("triage id %q is not a valid file name", triageID)
+	}
+	var buf []byte
+	for i := range recs {
+		recs[i].Time = time.Now()
+		b, err := json.Marshal(&recs[i])
+		if err != nil {
+			return fmt.Errorf("encode %s record: %w", recs[i].Kind, err)
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(r.Path(triageID), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	return errors.Join(err, f.Close())
+}
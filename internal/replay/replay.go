@@ -0,0 +1,259 @@
+// Package replay records the model responses and tool calls of real triages
+// and plays them back, so a run can be reproduced without the model or the
+// datasources it queried.
+//
+// A Recorder writes one JSON Lines file per triage. Loading the file gives a
+// Recording whose Provider answers with the recorded responses in order and
+// whose Registry answers tool calls with the recorded outputs, which makes
+// the full engine loop deterministic for tests and lets odd model behavior
+// be debugged offline, against the recorded model or a live one.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Record kinds, one per line of a recording.
+const (
+	KindStart    = "start"
+	KindResponse = "response"
+	KindToolCall = "tool_call"
+)
+
+// maxRecordBytes bounds one line of a recording; tool outputs and model
+// responses are well below it.
+const maxRecordBytes = 16 << 20
+
+// Record is one line of a recording. A start record opens each run of the
+// triage; the response and tool call records that follow it belong to
+// that run.
+type Record struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+
+	// Set on start records.
+	TriageID string          `json:"triage_id,omitempty"`
+	Alert    *alert.Alert    `json:"alert,omitempty"`
+	System   string          `json:"system,omitempty"`
+	Tools    []tools.ToolDef `json:"tools,omitempty"`
+
+	Response *Response `json:"response,omitempty"`
+	ToolCall *ToolCall `json:"tool_call,omitempty"`
+}
+
+// Response is a recorded model response, or the error the provider
+// returned instead.
+type Response struct {
+	Content    []triage.ContentBlock `json:"content,omitempty"`
+	StopReason triage.StopReason     `json:"stop_reason,omitempty"`
+	Usage      triage.Usage          `json:"usage,omitzero"`
+	Model      string                `json:"model,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// ToolCall is the recorded outcome of a tool call.
+type ToolCall struct {
+	Tool string `json:"tool"`
+	// Input restricts the call to replays with this input, compared as
+	// JSON. Empty matches any call to the tool.
+	Input  json.RawMessage `json:"input,omitempty"`
+	Output json.RawMessage `json:"output,omitempty"`
+	// Error makes the call fail with this message instead.
+	Error string `json:"error,omitempty"`
+}
+
+// Recording is the last run recorded for a triage.
+type Recording struct {
+	TriageID  string
+	Alert     alert.Alert
+	System    string
+	Tools     []tools.ToolDef
+	Responses []Response
+	ToolCalls []ToolCall
+}
+
+// Load reads a recording written by a Recorder. When the triage ran more
+// than once, only the last run is kept.
+func Load(path string) (*Recording, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var rec *Recording
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, maxRecordBytes)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("recording %s line %d: %w", path, line, err)
+		}
+		switch r.Kind {
+		case KindStart:
+			if r.Alert == nil {
+				return nil, fmt.Errorf("recording %s line %d: start record has no alert", path, line)
+			}
+			rec = &Recording{TriageID: r.TriageID, Alert: *r.Alert, System: r.System, Tools: r.Tools}
+		case KindResponse, KindToolCall:
+			if rec == nil {
+				return nil, fmt.Errorf("recording %s line %d: %s record before the start record", path, line, r.Kind)
+			}
+			if r.Kind == KindResponse && r.Response != nil {
+				rec.Responses = append(rec.Responses, *r.Response)
+			}
+			if r.Kind == KindToolCall && r.ToolCall != nil {
+				rec.ToolCalls = append(rec.ToolCalls, *r.ToolCall)
+			}
+		default:
+			return nil, fmt.Errorf("recording %s line %d: unknown record kind %q", path, line, r.Kind)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read recording %s: %w", path, err)
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("recording %s: no start record", path)
+	}
+	return rec, nil
+}
+
+// Provider returns a provider answering with the recorded responses.
+func (r *Recording) Provider() triage.Provider {
+	return NewProvider(r.Responses)
+}
+
+// Registry returns the recorded tools, answering from the recorded calls.
+func (r *Recording) Registry() *tools.Registry {
+	return NewRegistry(r.Tools, r.ToolCalls)
+}
+
+// ErrExhausted fails a provider call beyond the recorded responses, which
+// happens when a replay diverges from the recorded run.
+var ErrExhausted = errors.New("no recorded response left")
+
+// NewProvider returns a provider answering each call with the next of
+// responses. Recorded errors are returned as plain errors, so the engine
+// handles them as it would any provider failure.
+func NewProvider(responses []Response) triage.Provider {
+	return &script{responses: slices.Clone(responses)}
+}
+
+// script is a provider replaying recorded responses in order.
+type script struct {
+	mu        sync.Mutex
+	responses []Response
+}
+
+func (s *script) Send(_ context.Context, _ *triage.LLMRequest) (*triage.LLMResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.responses) == 0 {
+		return nil, ErrExhausted
+	}
+	r := s.responses[0]
+	s.responses = s.responses[1:]
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+	return &triage.LLMResponse{Content: r.Content, StopReason: r.StopReason, Usage: r.Usage, Model: r.Model}, nil
+}
+
+// NewRegistry returns a registry of tools answering from calls: one per
+// definition in defs, plus one with a permissive schema for each tool only
+// named by calls.
+func NewRegistry(defs []tools.ToolDef, calls []ToolCall) *tools.Registry {
+	r := tools.NewRegistry()
+	for _, d := range defs {
+		r.Register(NewTool(d, calls))
+	}
+	for _, c := range calls {
+		if _, ok := r.Get(c.Tool); !ok {
+			r.Register(NewTool(tools.ToolDef{Name: c.Tool, Description: "Recorded tool.", InputSchema: json.RawMessage(`{"type":"object"}`)}, calls))
+		}
+	}
+	return r
+}
+
+// NewTool returns a tool described by def that answers from the calls
+// recorded for it.
+func NewTool(def tools.ToolDef, calls []ToolCall) tools.Tool {
+	return &tool{def: def, calls: calls, used: make([]bool, len(calls))}
+}
+
+// tool answers calls from recorded ones.
+type tool struct {
+	def   tools.ToolDef
+	calls []ToolCall
+
+	mu   sync.Mutex
+	used []bool
+}
+
+func (t *tool) Name() string                { return t.def.Name }
+func (t *tool) Description() string         { return t.def.Description }
+func (t *tool) Parameters() json.RawMessage { return t.def.InputSchema }
+
+// Execute answers with the first recorded call to the tool whose input
+// matches and that has not answered yet, so repeated calls replay in the
+// recorded order. Once all matching calls have answered, the last one keeps
+// answering.
+func (t *tool) Execute(_ context.Context, params json.RawMessage) (json.RawMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	match := -1
+	for i, c := range t.calls {
+		if c.Tool != t.def.Name || (len(c.Input) > 0 && !jsonEqual(c.Input, params)) {
+			continue
+		}
+		match = i
+		if !t.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("no recorded output for %s with input %s", t.def.Name, params)
+	}
+	t.used[match] = true
+	if c := t.calls[match]; c.Error != "" {
+		return nil, errors.New(c.Error)
+	}
+	return t.calls[match].Output, nil
+}
+
+// jsonEqual compares two JSON documents ignoring formatting and key order.
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	xb, _ := json.Marshal(x)
+	yb, _ := json.Marshal(y)
+	return bytes.Equal(xb, yb)
+}
+
+// OutputJSON returns a tool result as recorded output: as is when it is
+// JSON, otherwise as a JSON string.
+func OutputJSON(s string) json.RawMessage {
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	b, _ := json.Marshal(s)
+	return b
+}
@@ -0,0 +1,199 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// liveTool stands in for a datasource, counting its calls.
+type liveTool struct {
+	name   string
+	output string
+	err    error
+	calls  int
+}
+
+func (t *liveTool) Name() string                { return t.name }
+func (t *liveTool) Description() string         { return "live " + t.name }
+func (t *liveTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *liveTool) Execute(_ context.Context, _ json.RawMessage) (json.RawMessage, error) {
+	t.calls++
+	return json.RawMessage(t.output), t.err
+}
+
+// runEngine triages a fixed alert with provider and registry, recording
+// into rec when it is non-nil.
+func runEngine(t *testing.T, provider triage.Provider, registry *tools.Registry, rec *Recorder) *triage.RunResult {
+	t.Helper()
+	var opts []triage.EngineOption
+	if rec != nil {
+		opts = append(opts, triage.WithLLMObserver(rec), triage.WithToolObserver(rec))
+	}
+	e := triage.NewEngine(provider, registry, log.Nop(), triage.EngineHooks{}, noop.NewTracerProvider(), opts...)
+	al := &alert.Alert{Fingerprint: "fp-1", Labels: map[string]string{"alertname": "DiskFull", "instance": "db-1"}}
+	return e.Run(context.Background(), "t-1", al, nil)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	metrics := &liveTool{name: "query_metrics", output: `{"used_pct":97}`}
+	logs := &liveTool{name: "query_logs", err: errors.New("loki unreachable")}
+	live := tools.NewRegistry()
+	live.Register(metrics)
+	live.Register(logs)
+	model := NewProvider([]Response{
+		{Content: []triage.ContentBlock{
+			{Type: "tool_use", ID: "c1", Name: "query_metrics", Input: json.RawMessage(`{"query":"disk"}`)},
+			{Type: "tool_use", ID: "c2", Name: "query_logs", Input: json.RawMessage(`{"query":"{host=\"db-1\"}"}`)},
+		}, StopReason: triage.StopToolUse, Usage: triage.Usage{InputTokens: 100, OutputTokens: 10}, Model: "m"},
+		{Content: []triage.ContentBlock{{Type: "text", Text: "Disk is 97% full."}}, StopReason: triage.StopEnd, Model: "m"},
+	})
+
+	rec, err := NewRecorder(filepath.Join(t.TempDir(), "rec"), log.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := runEngine(t, model, live, rec)
+	if want.Status != triage.StatusComplete {
+		t.Fatalf("recorded run status = %s: %s", want.Status, want.Analysis)
+	}
+
+	r, err := Load(rec.Path("t-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.TriageID != "t-1" || r.Alert.Fingerprint != "fp-1" || len(r.Tools) != 2 || r.System == "" {
+		t.Errorf("start = %+v", r)
+	}
+	if len(r.Responses) != 2 || len(r.ToolCalls) != 2 {
+		t.Fatalf("recorded %d responses and %d tool calls, want 2 and 2", len(r.Responses), len(r.ToolCalls))
+	}
+
+	got := runEngine(t, r.Provider(), r.Registry(), nil)
+	if metrics.calls != 1 || logs.calls != 1 {
+		t.Errorf("replay called live tools: metrics %d, logs %d", metrics.calls, logs.calls)
+	}
+	if got.Status != want.Status || got.Analysis != want.Analysis || got.InputTokensUsed != want.InputTokensUsed {
+		t.Errorf("replay = %s %q %d, want %s %q %d", got.Status, got.Analysis, got.InputTokensUsed, want.Status, want.Analysis, want.InputTokensUsed)
+	}
+	gotTurns, _ := json.Marshal(stripDurations(got.Conversation))
+	wantTurns, _ := json.Marshal(stripDurations(want.Conversation))
+	if string(gotTurns) != string(wantTurns) {
+		t.Errorf("replayed conversation differs:\n got %s\nwant %s", gotTurns, wantTurns)
+	}
+}
+
+// stripDurations returns the conversation's content without timings,
+// which differ between runs.
+func stripDurations(c *triage.Conversation) [][]triage.ContentBlock {
+	var out [][]triage.ContentBlock
+	for _, turn := range c.Turns {
+		blocks := make([]triage.ContentBlock, len(turn.Content))
+		for i, b := range turn.Content {
+			b.Duration = 0
+			blocks[i] = b
+		}
+		out = append(out, blocks)
+	}
+	return out
+}
+
+func TestRecorder_RerunKeepsLastRun(t *testing.T) {
+	t.Parallel()
+
+	rec, err := NewRecorder(t.TempDir(), log.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := NewProvider([]Response{{Error: "overloaded"}})
+	runEngine(t, first, tools.NewRegistry(), rec)
+	second := NewProvider([]Response{{Content: []triage.ContentBlock{{Type: "text", Text: "ok"}}, StopReason: triage.StopEnd}})
+	runEngine(t, second, tools.NewRegistry(), rec)
+
+	r, err := Load(rec.Path("t-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Responses) != 1 || r.Responses[0].Error != "" {
+		t.Errorf("responses = %+v, want only the second run's", r.Responses)
+	}
+
+	if _, err := r.Provider().Send(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProvider([]Response{{Error: "overloaded"}})
+	if _, err := p.Send(context.Background(), nil); err == nil || err.Error() != "overloaded" {
+		t.Errorf("recorded error = %v", err)
+	}
+	if _, err := p.Send(context.Background(), nil); !errors.Is(err, ErrExhausted) {
+		t.Errorf("past the recording: err = %v, want ErrExhausted", err)
+	}
+}
+
+func TestTool_ReplaysRepeatedCallsInOrder(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry(nil, []ToolCall{
+		{Tool: "query_metrics", Input: json.RawMessage(`{"query":"up"}`), Output: json.RawMessage(`1`)},
+		{Tool: "query_metrics", Input: json.RawMessage(`{"query":"up"}`), Output: json.RawMessage(`2`)},
+		{Tool: "query_metrics", Output: json.RawMessage(`"any"`)},
+	})
+	tool, ok := r.Get("query_metrics")
+	if !ok {
+		t.Fatal("query_metrics only named by calls was not offered")
+	}
+	var got []string
+	for _, in := range []string{`{"query":"up"}`, `{ "query" : "up" }`, `{"query":"up"}`, `{"query":"down"}`} {
+		out, err := tool.Execute(context.Background(), json.RawMessage(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(out))
+	}
+	if want := []string{`1`, `2`, `"any"`, `"any"`}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("outputs = %v, want %v", got, want)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		content   string
+		errSubstr string
+	}{
+		{name: "empty", content: "", errSubstr: "no start record"},
+		{name: "response first", content: `{"kind":"response","response":{}}`, errSubstr: "before the start record"},
+		{name: "start without alert", content: `{"kind":"start"}`, errSubstr: "has no alert"},
+		{name: "unknown kind", content: `{"kind":"start","alert":{}}` + "\n" + `{"kind":"note"}`, errSubstr: `line 2: unknown record kind "note"`},
+		{name: "not json", content: "{", errSubstr: "line 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "t.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Errorf("Load = %v, want it to contain %q", err, tt.errSubstr)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Package routing maps alerts to triage profiles by Alertmanager receiver.
+//
+// Alertmanager's route tree already encodes which team owns an alert, and the
+// webhook carries the receiver it chose. Keying profiles on receiver names
+// lets Vigil inherit that routing instead of duplicating label matchers.
+package routing
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/notify/slack"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Profile is one entry in the routing config.
+type Profile struct {
+	// Name identifies the profile in logs and spans.
+	Name string `json:"name"`
+
+	// Receivers are the Alertmanager receiver names routed to this profile.
+	Receivers []string `json:"receivers"`
+
+	// Skip drops alerts for these receivers without triaging them, for
+	// example a "null" receiver used to silence noisy routes.
+	Skip bool `json:"skip"`
+
+	// Instructions are appended to the system prompt for this team.
+	Instructions string `json:"instructions"`
+
+	// SlackWebhookURL sends results to the team's channel instead of the
+	// default webhook.
+	SlackWebhookURL string `json:"slack_webhook_url"`
+
+	// NotifyTemplate names the notification template the team's messages
+	// are rendered with, from the server's templates file. Without
+	// slack_webhook_url they go to the default webhook.
+	NotifyTemplate string `json:"notify_template"`
+
+	// NotifyMinConfidence holds back notifications of completed triages
+	// whose verdict confidence is below it, from 0 to 1, unless the model
+	// judged the alert urgent. Results are still stored. 0 keeps the
+	// service-wide threshold.
+	NotifyMinConfidence float64 `json:"notify_min_confidence"`
+
+	// Strategy is how alerts for these receivers are investigated: react
+	// (the default), single_shot for one call without tools, or the
+	// experimental plan_execute.
+	Strategy string `json:"strategy"`
+}
+
+// Config is the routing file format.
+type Config struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+// LoadConfig reads and validates a JSON routing config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read routing config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse routing config %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("routing config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem in the config.
+func (c *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	owner := make(map[string]string) // receiver -> profile name
+	for i, p := range c.Profiles {
+		if p.Name == "" {
+			errs = append(errs, fmt.Errorf("profiles[%d]: name is required", i))
+		} else if names[p.Name] {
+			errs = append(errs, fmt.Errorf("profile %q: duplicate name", p.Name))
+		}
+		names[p.Name] = true
+
+		if len(p.Receivers) == 0 {
+			errs = append(errs, fmt.Errorf("profile %q: at least one receiver is required", p.Name))
+		}
+		for _, r := range p.Receivers {
+			if r == "" {
+				errs = append(errs, fmt.Errorf("profile %q: empty receiver name", p.Name))
+				continue
+			}
+			if prev, ok := owner[r]; ok {
+				errs = append(errs, fmt.Errorf("profile %q: receiver %q already routed to profile %q", p.Name, r, prev))
+				continue
+			}
+			owner[r] = p.Name
+		}
+
+		if p.SlackWebhookURL != "" {
+			u, err := url.Parse(p.SlackWebhookURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("profile %q: slack_webhook_url must be an absolute http(s) URL", p.Name))
+			}
+		}
+		if p.NotifyMinConfidence < 0 || p.NotifyMinConfidence > 1 {
+			errs = append(errs, fmt.Errorf("profile %q: notify_min_confidence must be between 0 and 1", p.Name))
+		}
+		if _, err := triage.ParseStrategy(p.Strategy); err != nil {
+			errs = append(errs, fmt.Errorf("profile %q: %w", p.Name, err))
+		}
+		if p.Skip && (p.Instructions != "" || p.SlackWebhookURL != "" || p.NotifyTemplate != "" || p.NotifyMinConfidence != 0 || p.Strategy != "") {
+			errs = append(errs, fmt.Errorf("profile %q: skip profiles cannot set instructions, slack_webhook_url, notify_template, notify_min_confidence or strategy", p.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Router resolves alerts to triage profiles. It satisfies triage.ProfileResolver.
+type Router struct {
+	byReceiver map[string]*triage.Profile
+}
+
+// Option configures New.
+type Option func(*options)
+
+type options struct {
+	templates      *notify.Templates
+	defaultWebhook string
+	traceURL       string
+}
+
+// WithNotifyTemplates resolves the profiles' notify_template names in ts.
+// Profiles with a template but no webhook of their own post to
+// defaultWebhook. Without this option a profile naming a template is an
+// error.
+func WithNotifyTemplates(ts *notify.Templates, defaultWebhook string) Option {
+	return func(o *options) {
+		o.templates = ts
+		o.defaultWebhook = defaultWebhook
+	}
+}
+
+// WithTraceURL links the profiles' Slack messages to each triage's trace,
+// see slack.WithTraceURL.
+func WithTraceURL(baseURL string) Option {
+	return func(o *options) { o.traceURL = baseURL }
+}
+
+// New builds a Router from a config, creating a Slack notifier for each
+// profile that overrides the webhook or the message template.
+func New(c Config, logger log.Logger, opts ...Option) (*Router, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var errs []error
+	r := &Router{byReceiver: make(map[string]*triage.Profile)}
+	for _, p := range c.Profiles {
+		strategy, _ := triage.ParseStrategy(p.Strategy) // checked by Validate
+		tp := &triage.Profile{
+			Name:                p.Name,
+			Skip:                p.Skip,
+			Instructions:        p.Instructions,
+			NotifyMinConfidence: p.NotifyMinConfidence,
+			Strategy:            strategy,
+		}
+		var tmpl *notify.Template
+		if p.NotifyTemplate != "" {
+			if tmpl = o.templates.Get(p.NotifyTemplate); tmpl == nil {
+				errs = append(errs, fmt.Errorf("profile %q: unknown notify_template %q", p.Name, p.NotifyTemplate))
+				continue
+			}
+		}
+		switch webhook := cmp.Or(p.SlackWebhookURL, o.defaultWebhook); {
+		case p.SlackWebhookURL != "" || (tmpl != nil && webhook != ""):
+			tp.Notifier = slack.New(webhook, logger, slack.WithTemplate(tmpl), slack.WithTraceURL(o.traceURL))
+		case tmpl != nil:
+			errs = append(errs, fmt.Errorf("profile %q: notify_template needs slack_webhook_url or a default Slack webhook", p.Name))
+			continue
+		}
+		for _, recv := range p.Receivers {
+			r.byReceiver[recv] = tp
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Resolve returns the profile for the alert's receiver, or nil when the alert
+// has no receiver or no profile claims it.
+func (r *Router) Resolve(al *alert.Alert) *triage.Profile {
+	if al.Receiver == "" {
+		return nil
+	}
+	return r.byReceiver[al.Receiver]
+}
@@ -0,0 +1,208 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/notify"
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "routing.json")
+	body := `{"profiles":[
+		{"name":"payments","receivers":["payments-pager","payments-slack"],"instructions":"Check payments-db first.","slack_webhook_url":"https://hooks.slack.com/services/p"},
+		{"name":"silenced","receivers":["null"],"skip":true}
+	]}`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(c.Profiles) != 2 || c.Profiles[0].Instructions != "Check payments-db first." || !c.Profiles[1].Skip {
+		t.Errorf("config = %+v", c)
+	}
+}
+
+func TestLoadConfig_UnknownField(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "routing.json")
+	if err := os.WriteFile(path, []byte(`{"profiles":[{"name":"a","receivers":["a"],"matchers":["team=a"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "matchers") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			cfg:  Config{Profiles: []Profile{{Name: "a", Receivers: []string{"a"}}, {Name: "b", Receivers: []string{"b"}, Skip: true}}},
+		},
+		{
+			name:    "empty profile",
+			cfg:     Config{Profiles: []Profile{{}}},
+			wantErr: []string{"name is required", "at least one receiver"},
+		},
+		{
+			name:    "duplicate name and receiver",
+			cfg:     Config{Profiles: []Profile{{Name: "a", Receivers: []string{"x"}}, {Name: "a", Receivers: []string{"x"}}}},
+			wantErr: []string{"duplicate name", `receiver "x" already routed to profile "a"`},
+		},
+		{
+			name:    "bad slack url",
+			cfg:     Config{Profiles: []Profile{{Name: "a", Receivers: []string{"a"}, SlackWebhookURL: "hooks.slack.com/x"}}},
+			wantErr: []string{"slack_webhook_url must be an absolute http(s) URL"},
+		},
+		{
+			name:    "skip with instructions",
+			cfg:     Config{Profiles: []Profile{{Name: "a", Receivers: []string{"a"}, Skip: true, Instructions: "x"}}},
+			wantErr: []string{"skip profiles cannot set"},
+		},
+		{
+			name:    "unknown strategy",
+			cfg:     Config{Profiles: []Profile{{Name: "a", Receivers: []string{"a"}, Strategy: "swarm"}}},
+			wantErr: []string{`profile "a": unknown strategy "swarm"`},
+		},
+		{
+			name:    "notify confidence out of range",
+			cfg:     Config{Profiles: []Profile{{Name: "a", Receivers: []string{"a"}, NotifyMinConfidence: 1.5}}},
+			wantErr: []string{"notify_min_confidence must be between 0 and 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterResolve(t *testing.T) {
+	t.Parallel()
+
+	r, err := New(Config{Profiles: []Profile{
+		{Name: "payments", Receivers: []string{"payments-pager"}, Instructions: "i", SlackWebhookURL: "https://hooks.slack.com/services/p", NotifyMinConfidence: 0.7, Strategy: "single_shot"},
+		{Name: "plain", Receivers: []string{"plain"}},
+	}}, log.Nop())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	p := r.Resolve(&alert.Alert{Receiver: "payments-pager"})
+	if p == nil || p.Name != "payments" || p.Instructions != "i" || p.Notifier == nil || p.NotifyMinConfidence != 0.7 || p.Strategy != triage.StrategySingleShot {
+		t.Errorf("payments profile = %+v", p)
+	}
+	if p := r.Resolve(&alert.Alert{Receiver: "plain"}); p == nil || p.Notifier != nil {
+		t.Errorf("plain profile = %+v, want no notifier override", p)
+	}
+	if p := r.Resolve(&alert.Alert{Receiver: "unknown"}); p != nil {
+		t.Errorf("unknown receiver resolved to %+v", p)
+	}
+	if p := r.Resolve(&alert.Alert{}); p != nil {
+		t.Errorf("empty receiver resolved to %+v", p)
+	}
+}
+
+func TestNew_NotifyTemplates(t *testing.T) {
+	t.Parallel()
+
+	ts, err := notify.New(notify.Config{Templates: map[string]map[string]string{
+		"payments": {"critical": "<!here> {{.Alert}}"},
+	}}, "")
+	if err != nil {
+		t.Fatalf("notify.New: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		profile      Profile
+		opts         []Option
+		wantNotifier bool
+		errSubstr    string
+	}{
+		{
+			name:         "own webhook",
+			profile:      Profile{Name: "p", Receivers: []string{"r"}, NotifyTemplate: "payments", SlackWebhookURL: "https://hooks.slack.com/services/p"},
+			opts:         []Option{WithNotifyTemplates(ts, "")},
+			wantNotifier: true,
+		},
+		{
+			name:         "default webhook",
+			profile:      Profile{Name: "p", Receivers: []string{"r"}, NotifyTemplate: "payments"},
+			opts:         []Option{WithNotifyTemplates(ts, "https://hooks.slack.com/services/default")},
+			wantNotifier: true,
+		},
+		{
+			name:      "no webhook",
+			profile:   Profile{Name: "p", Receivers: []string{"r"}, NotifyTemplate: "payments"},
+			opts:      []Option{WithNotifyTemplates(ts, "")},
+			errSubstr: "needs slack_webhook_url",
+		},
+		{
+			name:      "unknown template",
+			profile:   Profile{Name: "p", Receivers: []string{"r"}, NotifyTemplate: "missing"},
+			opts:      []Option{WithNotifyTemplates(ts, "https://hooks.slack.com/services/default")},
+			errSubstr: `unknown notify_template "missing"`,
+		},
+		{
+			name:      "no templates configured",
+			profile:   Profile{Name: "p", Receivers: []string{"r"}, NotifyTemplate: "payments"},
+			errSubstr: "unknown notify_template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r, err := New(Config{Profiles: []Profile{tt.profile}}, log.Nop(), tt.opts...)
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("New() error = %v, want containing %q", err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if p := r.Resolve(&alert.Alert{Receiver: "r"}); (p.Notifier != nil) != tt.wantNotifier {
+				t.Errorf("Notifier = %v, want set %v", p.Notifier, tt.wantNotifier)
+			}
+		})
+	}
+}
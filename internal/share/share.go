@@ -0,0 +1,79 @@
+// Package share signs and verifies links that grant read access to a single
+// triage report.
+//
+// A token names no triage itself: it is bound to the triage ID in the link
+// and the tenant that created it, and carries its own expiry. Tokens are
+// HMAC-SHA256 signed, so the server keeps no state and rotating the key
+// revokes every outstanding link.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MinKeyLen is the shortest signing key New accepts.
+const MinKeyLen = 32
+
+// ErrInvalidToken is returned for a token that is malformed, signed for
+// another triage or key, or expired. The cases are not told apart so a
+// holder learns nothing about why a link stopped working.
+var ErrInvalidToken = errors.New("invalid or expired share token")
+
+// Signer issues and checks share tokens.
+type Signer struct {
+	key []byte
+}
+
+// New returns a Signer using key, which must be at least MinKeyLen bytes.
+func New(key []byte) (*Signer, error) {
+	if len(key) < MinKeyLen {
+		return nil, errors.New("share key too short")
+	}
+	return &Signer{key: append([]byte(nil), key...)}, nil
+}
+
+// Sign returns a token granting read access to triageID, as seen by tenant,
+// until expires.
+func (s *Signer) Sign(triageID, tenant string, expires time.Time) string {
+	payload := binary.BigEndian.AppendUint64(nil, uint64(expires.Unix())) //nolint:gosec // G115: expiry is after 1970
+	payload = append(payload, tenant...)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(triageID, payload))
+}
+
+// Verify checks token against triageID at now and returns the tenant it was
+// issued by.
+func (s *Signer) Verify(token, triageID string, now time.Time) (string, error) {
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil || len(payload) < 8 {
+		return "", ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(triageID, payload)) {
+		return "", ErrInvalidToken
+	}
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0) //nolint:gosec // G115: signed by us from a Unix time
+	if !now.Before(expires) {
+		return "", ErrInvalidToken
+	}
+	return string(payload[8:]), nil
+}
+
+func (s *Signer) mac(triageID string, payload []byte) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte("vigil-share-v1\x00"))
+	h.Write([]byte(triageID))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)
+}
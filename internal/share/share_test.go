@@ -0,0 +1,74 @@
+package share
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew_ShortKey(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New([]byte("short")); err == nil {
+		t.Fatal("New accepted a short key")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	t.Parallel()
+
+	key := []byte(strings.Repeat("k", MinKeyLen))
+	s, err := New(key)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	other, _ := New([]byte(strings.Repeat("o", MinKeyLen)))
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	token := s.Sign("01TRIAGE", "acme", now.Add(time.Hour))
+
+	tests := []struct {
+		name       string
+		signer     *Signer
+		token      string
+		triageID   string
+		now        time.Time
+		wantTenant string
+		wantErr    bool
+	}{
+		{name: "valid", signer: s, token: token, triageID: "01TRIAGE", now: now, wantTenant: "acme"},
+		{name: "default tenant", signer: s, token: s.Sign("01TRIAGE", "", now.Add(time.Hour)), triageID: "01TRIAGE", now: now},
+		{name: "other triage", signer: s, token: token, triageID: "01OTHER", now: now, wantErr: true},
+		{name: "expired", signer: s, token: token, triageID: "01TRIAGE", now: now.Add(time.Hour), wantErr: true},
+		{name: "other key", signer: other, token: token, triageID: "01TRIAGE", now: now, wantErr: true},
+		{name: "tampered tenant", signer: s, token: swapPayload(s.Sign("01TRIAGE", "globex", now.Add(time.Hour)), token), triageID: "01TRIAGE", now: now, wantErr: true},
+		{name: "no signature", signer: s, token: strings.Split(token, ".")[0], triageID: "01TRIAGE", now: now, wantErr: true},
+		{name: "garbage", signer: s, token: "!!.!!", triageID: "01TRIAGE", now: now, wantErr: true},
+		{name: "empty", signer: s, token: "", triageID: "01TRIAGE", now: now, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tenant, err := tt.signer.Verify(tt.token, tt.triageID, tt.now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify = %q, %v, want ErrInvalidToken", tenant, err)
+				}
+				return
+			}
+			if err != nil || tenant != tt.wantTenant {
+				t.Errorf("Verify = %q, %v, want %q", tenant, err, tt.wantTenant)
+			}
+		})
+	}
+}
+
+// swapPayload returns from's payload with to's signature.
+func swapPayload(from, to string) string {
+	p, _, _ := strings.Cut(from, ".")
+	_, sig, _ := strings.Cut(to, ".")
+	return p + "." + sig
+}
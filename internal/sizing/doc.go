@@ -0,0 +1,2 @@
+// Package sizing derives container-aware runtime defaults (memory limit, triage workers, tool concurrency) from GOMAXPROCS and cgroup limits.
+package sizing
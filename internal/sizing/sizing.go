@@ -0,0 +1,98 @@
+package sizing
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	// triageMemoryBytes is the rough per-triage working set (conversation, tool
+	// outputs, SDK buffers) used to cap worker counts on small containers.
+	triageMemoryBytes = 64 << 20
+
+	// memoryLimitRatio is the fraction of the cgroup limit handed to the Go
+	// runtime as a soft limit, leaving headroom for non-heap memory.
+	memoryLimitRatio = 0.9
+
+	maxWorkers         = 64
+	maxToolConcurrency = 8
+)
+
+// cgroup paths, vars so tests can point them at fixtures.
+var (
+	cgroupV2MemoryMax = "/sys/fs/cgroup/memory.max"
+	cgroupV1MemoryMax = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+)
+
+// Defaults are the derived values for settings left at 0 (auto) in config.
+type Defaults struct {
+	Procs           int
+	MemoryLimit     int64 // bytes, 0 when unlimited or unknown
+	Workers         int
+	ToolConcurrency int
+}
+
+// Detect inspects GOMAXPROCS (which is cgroup CPU-quota aware since Go 1.25)
+// and the cgroup memory limit and returns sensible defaults.
+func Detect() Defaults {
+	procs := runtime.GOMAXPROCS(0)
+	mem, _ := CgroupMemoryLimit()
+	return compute(procs, mem)
+}
+
+func compute(procs int, mem int64) Defaults {
+	d := Defaults{Procs: procs, MemoryLimit: mem}
+
+	// Triages are I/O bound (LLM and datasource round-trips), so oversubscribe
+	// CPUs but never beyond what memory can hold.
+	d.Workers = clamp(procs*4, 2, maxWorkers)
+	if mem > 0 {
+		byMem := int(mem / triageMemoryBytes)
+		d.Workers = clamp(min(d.Workers, byMem), 1, maxWorkers)
+	}
+
+	d.ToolConcurrency = clamp(procs, 1, maxToolConcurrency)
+	return d
+}
+
+// CgroupMemoryLimit returns the container memory limit in bytes, reading
+// cgroup v2 first and falling back to v1. ok is false when no limit is set.
+func CgroupMemoryLimit() (limit int64, ok bool) {
+	for _, p := range []string{cgroupV2MemoryMax, cgroupV1MemoryMax} {
+		b, err := os.ReadFile(p) //nolint:gosec // G304: fixed cgroup paths, not user input
+		if err != nil {
+			continue
+		}
+		v := strings.TrimSpace(string(b))
+		if v == "" || v == "max" {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		// cgroup v1 reports "unlimited" as a huge page-aligned number
+		if err != nil || n <= 0 || n >= math.MaxInt64/2 {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// ApplyMemoryLimit sets the Go runtime soft memory limit to a fraction of the
+// cgroup limit unless GOMEMLIMIT was set explicitly. It returns the limit
+// applied, or 0 if nothing was changed.
+func ApplyMemoryLimit(d Defaults) int64 {
+	if d.MemoryLimit <= 0 || os.Getenv("GOMEMLIMIT") != "" {
+		return 0
+	}
+	limit := int64(float64(d.MemoryLimit) * memoryLimitRatio)
+	debug.SetMemoryLimit(limit)
+	return limit
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -0,0 +1,81 @@
+package sizing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		procs     int
+		mem       int64
+		wantWork  int
+		wantTools int
+	}{
+		{"single cpu unlimited", 1, 0, 4, 1},
+		{"four cpus unlimited", 4, 0, 16, 4},
+		{"many cpus capped", 64, 0, maxWorkers, maxToolConcurrency},
+		{"memory bound", 8, 256 << 20, 4, 8},
+		{"tiny container floors at one", 2, 16 << 20, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := compute(tt.procs, tt.mem)
+			if d.Workers != tt.wantWork {
+				t.Errorf("Workers = %d, want %d", d.Workers, tt.wantWork)
+			}
+			if d.ToolConcurrency != tt.wantTools {
+				t.Errorf("ToolConcurrency = %d, want %d", d.ToolConcurrency, tt.wantTools)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // mutates package-level cgroup paths
+func TestCgroupMemoryLimit(t *testing.T) {
+	dir := t.TempDir()
+	v2 := filepath.Join(dir, "memory.max")
+	v1 := filepath.Join(dir, "memory.limit_in_bytes")
+
+	origV2, origV1 := cgroupV2MemoryMax, cgroupV1MemoryMax
+	cgroupV2MemoryMax, cgroupV1MemoryMax = v2, v1
+	t.Cleanup(func() { cgroupV2MemoryMax, cgroupV1MemoryMax = origV2, origV1 })
+
+	if _, ok := CgroupMemoryLimit(); ok {
+		t.Error("expected no limit when cgroup files are missing")
+	}
+
+	writeFile(t, v1, "536870912\n")
+	if n, ok := CgroupMemoryLimit(); !ok || n != 536870912 {
+		t.Errorf("v1 limit = %d/%v, want 536870912/true", n, ok)
+	}
+
+	writeFile(t, v1, "9223372036854771712\n")
+	if _, ok := CgroupMemoryLimit(); ok {
+		t.Error("expected v1 unlimited sentinel to be treated as no limit")
+	}
+
+	writeFile(t, v2, "max\n")
+	if _, ok := CgroupMemoryLimit(); ok {
+		t.Error("expected v2 max to be treated as no limit")
+	}
+
+	writeFile(t, v2, "1073741824\n")
+	if n, ok := CgroupMemoryLimit(); !ok || n != 1<<30 {
+		t.Errorf("v2 limit = %d/%v, want %d/true", n, ok, 1<<30)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
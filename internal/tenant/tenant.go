@@ -0,0 +1,134 @@
+// Package tenant loads the tenants one Vigil deployment serves.
+//
+// Each tenant authenticates with its own API token, and its alerts are
+// investigated against its own Prometheus and Loki, with its own prompt,
+// model, budget and Slack channel. Results are stored with the tenant ID and
+// the API only shows a tenant its own triages.
+package tenant
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Tenant is one entry in the tenants config. Datasource fields left empty
+// fall back to the server's settings.
+type Tenant struct {
+	// ID is stored with every triage the tenant submits and labels its metrics.
+	ID string `json:"id"`
+
+	// Token authenticates the tenant's API and webhook requests.
+	Token string `json:"token"`
+
+	// PrometheusEndpoint and LokiEndpoint point the tenant's tools at its own
+	// datasources.
+	PrometheusEndpoint string `json:"prometheus_endpoint"`
+	LokiEndpoint       string `json:"loki_endpoint"`
+
+	// PrometheusTenantID and LokiTenantID are sent as X-Scope-OrgID, for
+	// tenants sharing a Mimir or Loki cluster. They default to ID.
+	PrometheusTenantID string `json:"prometheus_tenant_id"`
+	LokiTenantID       string `json:"loki_tenant_id"`
+
+	// SlackWebhookURL sends the tenant's results to its own channel instead
+	// of the default webhook.
+	SlackWebhookURL string `json:"slack_webhook_url"`
+
+	// Instructions are appended to the system prompt for the tenant's alerts.
+	Instructions string `json:"instructions"`
+
+	// Model overrides the server's Claude model.
+	Model string `json:"model"`
+
+	// Budget overrides the per-triage limits; zero fields keep the defaults.
+	Budget triage.Budget `json:"budget"`
+}
+
+// Config is the tenants file format.
+type Config struct {
+	Tenants []Tenant `json:"tenants"`
+}
+
+// idPattern keeps tenant IDs safe as metric label values and org IDs, and
+// rules out triage.AnyTenant.
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// LoadConfig reads and validates a JSON tenants config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the operator
+	if err != nil {
+		return c, fmt.Errorf("read tenants config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parse tenants config %s: %w", path, err)
+	}
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("tenants config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem in the config. Errors name tenants by ID
+// and never echo tokens.
+func (c *Config) Validate() error {
+	var errs []error
+	ids := make(map[string]bool)
+	tokens := make(map[string]string) // token -> tenant ID
+	for i, t := range c.Tenants {
+		switch {
+		case t.ID == "":
+			errs = append(errs, fmt.Errorf("tenants[%d]: id is required", i))
+		case !idPattern.MatchString(t.ID):
+			errs = append(errs, fmt.Errorf("tenant %q: id must be lowercase letters, digits, '-' or '_' (at most 63)", t.ID))
+		case ids[t.ID]:
+			errs = append(errs, fmt.Errorf("tenant %q: duplicate id", t.ID))
+		}
+		ids[t.ID] = true
+
+		if t.Token == "" {
+			errs = append(errs, fmt.Errorf("tenant %q: token is required", t.ID))
+		} else if prev, ok := tokens[t.Token]; ok {
+			errs = append(errs, fmt.Errorf("tenant %q: token already used by tenant %q", t.ID, prev))
+		} else {
+			tokens[t.Token] = t.ID
+		}
+
+		for _, f := range []struct{ name, raw string }{
+			{"prometheus_endpoint", t.PrometheusEndpoint},
+			{"loki_endpoint", t.LokiEndpoint},
+			{"slack_webhook_url", t.SlackWebhookURL},
+		} {
+			if f.raw == "" {
+				continue
+			}
+			u, err := url.Parse(f.raw)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("tenant %q: %s must be an absolute http(s) URL", t.ID, f.name))
+			}
+		}
+
+		if t.Budget.ToolCalls < 0 || t.Budget.InputTokens < 0 || t.Budget.OutputTokens < 0 {
+			errs = append(errs, fmt.Errorf("tenant %q: budget limits cannot be negative", t.ID))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Tokens maps each tenant's token to its ID, for authmw.TenantTokens.
+func (c *Config) Tokens() map[string]string {
+	out := make(map[string]string, len(c.Tenants))
+	for _, t := range c.Tenants {
+		out[t.Token] = t.ID
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	body := `{"tenants":[
+		{"id":"acme","token":"acme-token","prometheus_endpoint":"http://mimir:9009/prometheus","loki_tenant_id":"acme-logs",
+		 "instructions":"Acme runs on EKS.","model":"claude-haiku","budget":{"tool_calls":5}},
+		{"id":"globex","token":"globex-token","slack_webhook_url":"https://hooks.slack.com/services/g"}
+	]}`
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(c.Tenants) != 2 || c.Tenants[0].LokiTenantID != "acme-logs" || c.Tenants[0].Budget.ToolCalls != 5 || c.Tenants[1].SlackWebhookURL == "" {
+		t.Errorf("config = %+v", c)
+	}
+	if got := c.Tokens(); len(got) != 2 || got["acme-token"] != "acme" || got["globex-token"] != "globex" {
+		t.Errorf("Tokens() = %v", got)
+	}
+}
+
+func TestLoadConfig_UnknownField(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	if err := os.WriteFile(path, []byte(`{"tenants":[{"id":"a","token":"t","receivers":["a"]}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "receivers") {
+		t.Fatalf("err = %v, want unknown field error", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr []string
+		notWant string
+	}{
+		{
+			name: "valid",
+			cfg:  Config{Tenants: []Tenant{{ID: "a", Token: "ta"}, {ID: "b-2", Token: "tb"}}},
+		},
+		{
+			name:    "empty tenant",
+			cfg:     Config{Tenants: []Tenant{{}}},
+			wantErr: []string{"id is required", "token is required"},
+		},
+		{
+			name:    "bad ids",
+			cfg:     Config{Tenants: []Tenant{{ID: triage.AnyTenant, Token: "t1"}, {ID: "Acme", Token: "t2"}}},
+			wantErr: []string{`tenant "*": id must be`, `tenant "Acme": id must be`},
+		},
+		{
+			name:    "duplicate id and token",
+			cfg:     Config{Tenants: []Tenant{{ID: "a", Token: "secret-1"}, {ID: "a", Token: "secret-1"}}},
+			wantErr: []string{"duplicate id", `token already used by tenant "a"`},
+			notWant: "secret-1",
+		},
+		{
+			name:    "bad urls",
+			cfg:     Config{Tenants: []Tenant{{ID: "a", Token: "t", PrometheusEndpoint: "mimir:9009", SlackWebhookURL: "https:///x"}}},
+			wantErr: []string{"prometheus_endpoint must be an absolute http(s) URL", "slack_webhook_url must be"},
+		},
+		{
+			name:    "negative budget",
+			cfg:     Config{Tenants: []Tenant{{ID: "a", Token: "t", Budget: triage.Budget{InputTokens: -1}}}},
+			wantErr: []string{"budget limits cannot be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+			if tt.notWant != "" && strings.Contains(err.Error(), tt.notWant) {
+				t.Errorf("error %q leaks %q", err, tt.notWant)
+			}
+		})
+	}
+}
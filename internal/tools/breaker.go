@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable marks tool errors caused by the backing data source rather
+// than the caller's input: transport failures, timeouts, 5xx and 429
+// responses. Only these count against a tool's circuit breaker, so a run of
+// malformed queries from the LLM does not take a healthy tool offline.
+var ErrUnavailable = errors.New("data source unavailable")
+
+// unavailableError keeps the original message while matching ErrUnavailable.
+type unavailableError struct{ err error }
+
+func (e *unavailableError) Error() string   { return e.err.Error() }
+func (e *unavailableError) Unwrap() []error { return []error{e.err, ErrUnavailable} }
+
+// unavailable marks err as a data source failure.
+func unavailable(err error) error {
+	return &unavailableError{err: err}
+}
+
+// statusError reports a non-200 response, marking server-side and throttling
+// statuses as unavailable.
+func statusError(source string, code int, body []byte) error {
+	err := fmt.Errorf("%s returned %d: %s", source, code, string(body))
+	if code >= 500 || code == 429 {
+		return unavailable(err)
+	}
+	return err
+}
+
+// BreakerState is the state of a tool's circuit breaker.
+type BreakerState int
+
+// Breaker states.
+const (
+	// BreakerClosed is normal operation.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen withholds the tool until the cooldown elapses.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe call through to test recovery.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig configures per-tool circuit breakers.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive unavailable errors that opens
+	// the breaker. Zero disables circuit breaking.
+	Threshold int
+
+	// Cooldown is how long an open breaker withholds its tool before letting
+	// a probe call through.
+	Cooldown time.Duration
+
+	// OnStateChange, if set, is called on every transition. It runs with the
+	// breaker's lock held and must not call back into the registry.
+	OnStateChange func(tool string, state BreakerState)
+}
+
+// breaker tracks consecutive failures for one tool.
+type breaker struct {
+	name string
+	cfg  *BreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (b *breaker) setState(s BreakerState) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, s)
+	}
+}
+
+// available reports whether the tool should be offered to the LLM: closed,
+// or open past its cooldown with no probe in flight.
+func (b *breaker) available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		return !b.now().Before(b.openedAt.Add(b.cfg.Cooldown))
+	default:
+		return !b.probing
+	}
+}
+
+// allow admits a call. Once the cooldown has elapsed the first caller becomes
+// the half-open probe and everyone else is rejected until it reports back.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.cfg.Cooldown)) {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		fallthrough
+	default:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of an admitted call.
+func (b *breaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.state == BreakerHalfOpen
+	b.probing = false
+
+	// A triage being cancelled says nothing about the data source.
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	if err == nil || !errors.Is(err, ErrUnavailable) {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.cfg.Threshold {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// guardedTool wraps a Tool with its breaker.
+type guardedTool struct {
+	Tool
+	b *breaker
+}
+
+func (g *guardedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	if !g.b.allow() {
+		return nil, unavailable(fmt.Errorf("%s is temporarily disabled after repeated data source failures", g.Name()))
+	}
+	out, err := g.Tool.Execute(ctx, params)
+	g.b.record(ctx, err)
+	return out, err
+}
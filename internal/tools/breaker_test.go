@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyTool fails with err until err is cleared.
+type flakyTool struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (f *flakyTool) Name() string                { return "query_logs" }
+func (f *flakyTool) Description() string         { return "flaky" }
+func (f *flakyTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (f *flakyTool) Execute(_ context.Context, _ json.RawMessage) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return json.RawMessage(`"ok"`), nil
+}
+
+func (f *flakyTool) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newBreakerRegistry(t *testing.T, tool Tool) (*Registry, *fakeClock, *[]string) {
+	t.Helper()
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	var transitions []string
+	r := NewRegistry(WithCircuitBreaker(BreakerConfig{
+		Threshold: 3,
+		Cooldown:  time.Minute,
+		OnStateChange: func(name string, s BreakerState) {
+			transitions = append(transitions, name+"="+s.String())
+		},
+	}))
+	r.now = clock.now
+	r.Register(tool)
+	return r, clock, &transitions
+}
+
+func call(t *testing.T, r *Registry) error {
+	t.Helper()
+	tool, ok := r.Get("query_logs")
+	if !ok {
+		t.Fatal("tool not registered")
+	}
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{}`))
+	return err
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	t.Parallel()
+
+	ft := &flakyTool{err: unavailable(errors.New("connection refused"))}
+	r, _, transitions := newBreakerRegistry(t, ft)
+
+	for range 2 {
+		_ = call(t, r)
+	}
+	if len(r.ToToolDefs()) != 1 {
+		t.Fatal("breaker opened before threshold")
+	}
+	_ = call(t, r)
+
+	if defs := r.ToToolDefs(); len(defs) != 0 {
+		t.Errorf("ToToolDefs = %v, want tool withheld", defs)
+	}
+	if got := r.Unavailable(); len(got) != 1 || got[0] != "query_logs" {
+		t.Errorf("Unavailable = %v, want [query_logs]", got)
+	}
+
+	err := call(t, r)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("call while open: err = %v, want ErrUnavailable", err)
+	}
+	if ft.calls != 3 {
+		t.Errorf("underlying calls = %d, want 3 (open breaker must not call through)", ft.calls)
+	}
+	if fmt.Sprint(*transitions) != "[query_logs=open]" {
+		t.Errorf("transitions = %v", *transitions)
+	}
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	t.Parallel()
+
+	ft := &flakyTool{err: unavailable(errors.New("503"))}
+	r, clock, transitions := newBreakerRegistry(t, ft)
+	for range 3 {
+		_ = call(t, r)
+	}
+
+	// Failed probe reopens for another full cooldown.
+	clock.advance(time.Minute)
+	if len(r.ToToolDefs()) != 1 {
+		t.Fatal("tool not offered after cooldown")
+	}
+	_ = call(t, r)
+	if len(r.ToToolDefs()) != 0 {
+		t.Fatal("failed probe did not reopen the breaker")
+	}
+
+	// Successful probe closes it.
+	clock.advance(time.Minute)
+	ft.setErr(nil)
+	if err := call(t, r); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if len(r.ToToolDefs()) != 1 || len(r.Unavailable()) != 0 {
+		t.Error("successful probe did not close the breaker")
+	}
+
+	want := "[query_logs=open query_logs=half_open query_logs=open query_logs=half_open query_logs=closed]"
+	if got := fmt.Sprint(*transitions); got != want {
+		t.Errorf("transitions = %s, want %s", got, want)
+	}
+}
+
+func TestBreaker_SingleProbeInFlight(t *testing.T) {
+	t.Parallel()
+
+	b := &breaker{name: "t", cfg: &BreakerConfig{Threshold: 1, Cooldown: time.Minute}, now: time.Now}
+	b.record(context.Background(), unavailable(errors.New("down")))
+	b.openedAt = time.Now().Add(-time.Hour)
+
+	if !b.allow() {
+		t.Fatal("first probe rejected")
+	}
+	if b.allow() {
+		t.Error("second concurrent probe admitted")
+	}
+	if b.available() {
+		t.Error("tool offered while a probe is in flight")
+	}
+}
+
+func TestBreaker_IgnoresCallerErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		ctx  func() context.Context
+	}{
+		{name: "bad query", err: statusError("loki", 400, []byte("parse error")), ctx: context.Background},
+		{name: "not marked unavailable", err: errors.New("query is required"), ctx: context.Background},
+		{name: "cancelled triage", err: unavailable(context.Canceled), ctx: func() context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := &breaker{name: "t", cfg: &BreakerConfig{Threshold: 1, Cooldown: time.Minute}, now: time.Now}
+			for range 5 {
+				b.record(tt.ctx(), tt.err)
+			}
+			if b.state != BreakerClosed {
+				t.Errorf("state = %s, want closed", b.state)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code        int
+		unavailable bool
+	}{
+		{400, false},
+		{422, false},
+		{429, true},
+		{500, true},
+		{503, true},
+	}
+	for _, tt := range tests {
+		err := statusError("prometheus", tt.code, []byte("body"))
+		if got := errors.Is(err, ErrUnavailable); got != tt.unavailable {
+			t.Errorf("statusError(%d) unavailable = %v, want %v", tt.code, got, tt.unavailable)
+		}
+		if want := fmt.Sprintf("prometheus returned %d: body", tt.code); err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	}
+}
+
+func TestWithCircuitBreaker_ZeroThresholdDisables(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry(WithCircuitBreaker(BreakerConfig{Threshold: 0, Cooldown: time.Minute}))
+	r.Register(&flakyTool{err: unavailable(errors.New("down"))})
+	for range 10 {
+		_ = call(t, r)
+	}
+	if len(r.ToToolDefs()) != 1 || r.Unavailable() != nil {
+		t.Error("tool withheld with circuit breaking disabled")
+	}
+}
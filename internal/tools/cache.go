@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache lookup outcomes reported to CacheConfig.OnLookup.
+const (
+	CacheHit    = "hit"
+	CacheMiss   = "miss"
+	CacheBypass = "bypass"
+)
+
+// DefaultCacheEntries is the cache size when CacheConfig.MaxEntries is zero.
+const DefaultCacheEntries = 1000
+
+// CacheConfig configures the tool result cache.
+type CacheConfig struct {
+	// TTLs maps tool names to how long their results are reused. Default
+	// applies to tools not listed. A tool with no positive TTL is never
+	// cached.
+	TTLs    map[string]time.Duration
+	Default time.Duration
+
+	// MaxEntries bounds the number of cached results. Zero means
+	// DefaultCacheEntries.
+	MaxEntries int
+
+	// OnLookup, if set, is called for every call to a cached tool with one
+	// of CacheHit, CacheMiss or CacheBypass.
+	OnLookup func(tool, result string)
+}
+
+func (c *CacheConfig) ttl(tool string) time.Duration {
+	if d, ok := c.TTLs[tool]; ok {
+		return d
+	}
+	return c.Default
+}
+
+// WithCache reuses tool results for identical calls, within one triage and
+// across triages. A call is identical when it is to the same tool with the
+// same input, after normalizing key order and whitespace, in the same time
+// bucket: time is cut into windows of the tool's TTL, and a result is only
+// reused within the window it was produced in. Errors are not cached.
+// Concurrent identical calls wait for the first one instead of repeating it.
+func WithCache(c CacheConfig) RegistryOption {
+	return func(r *Registry) {
+		if c.MaxEntries <= 0 {
+			c.MaxEntries = DefaultCacheEntries
+		}
+		r.cache = &resultCache{cfg: c, entries: make(map[string]*cacheEntry), now: func() time.Time { return r.now() }}
+	}
+}
+
+type noCacheKey struct{}
+
+// WithoutCache returns a context whose tool calls skip the result cache,
+// neither reading nor filling it.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	v, _ := ctx.Value(noCacheKey{}).(bool)
+	return v
+}
+
+// resultCache holds tool results for a registry.
+type resultCache struct {
+	cfg CacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	order   []string // keys in insertion order, for eviction
+}
+
+// cacheEntry is a result, or a call in flight that will produce one.
+type cacheEntry struct {
+	done    chan struct{}
+	out     json.RawMessage
+	err     error
+	expires time.Time
+}
+
+// canonicalInput re-encodes params so inputs that differ only in key order
+// or whitespace share a key. It reports false for invalid JSON.
+func canonicalInput(params json.RawMessage) (string, bool) {
+	if len(bytes.TrimSpace(params)) == 0 {
+		return "{}", true
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// cachedTool serves repeated calls from the registry's cache.
+type cachedTool struct {
+	Tool
+	cache *resultCache
+	ttl   time.Duration
+}
+
+func (t *cachedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	c := t.cache
+	name := t.Name()
+	input, ok := canonicalInput(params)
+	if !ok || cacheBypassed(ctx) {
+		c.report(name, CacheBypass)
+		return t.Tool.Execute(ctx, params)
+	}
+
+	now := c.now()
+	bucket := now.UnixNano() / int64(t.ttl)
+	key := name + "\x00" + strconv.FormatInt(bucket, 10) + "\x00" + input
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// The call it waited on failed, and failures are not shared.
+		if e.err != nil {
+			c.report(name, CacheMiss)
+			return t.Tool.Execute(ctx, params)
+		}
+		c.report(name, CacheHit)
+		return e.out, nil
+	}
+	e := &cacheEntry{done: make(chan struct{}), expires: time.Unix(0, (bucket+1)*int64(t.ttl))}
+	c.entries[key] = e
+	c.order = append(c.order, key)
+	c.evict(now)
+	c.mu.Unlock()
+
+	c.report(name, CacheMiss)
+	e.out, e.err = t.Tool.Execute(ctx, params)
+	if e.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return e.out, e.err
+}
+
+// evict drops expired entries from the front of the insertion order, then
+// the oldest finished entries until the cache is within MaxEntries. It is
+// called with c.mu held.
+func (c *resultCache) evict(now time.Time) {
+	for len(c.order) > 0 {
+		key := c.order[0]
+		e, ok := c.entries[key]
+		switch {
+		case !ok:
+			// already removed after an error
+		case !now.Before(e.expires), len(c.entries) > c.cfg.MaxEntries && finished(e):
+			delete(c.entries, key)
+		default:
+			return
+		}
+		c.order = c.order[1:]
+	}
+}
+
+func finished(e *cacheEntry) bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *resultCache) report(tool, result string) {
+	if c.cfg.OnLookup != nil {
+		c.cfg.OnLookup(tool, result)
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type cacheLookups struct {
+	mu  sync.Mutex
+	got map[string]int
+}
+
+func (l *cacheLookups) record(_, result string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.got[result]++
+}
+
+func newCacheRegistry(t *testing.T, c CacheConfig, tool Tool) (*Registry, *fakeClock, *cacheLookups) {
+	t.Helper()
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	lookups := &cacheLookups{got: make(map[string]int)}
+	c.OnLookup = lookups.record
+	r := NewRegistry(WithCache(c))
+	r.now = clock.now
+	r.Register(tool)
+	return r, clock, lookups
+}
+
+func TestCache_ReusesIdenticalCalls(t *testing.T) {
+	t.Parallel()
+
+	tool := &flakyTool{}
+	r, clock, lookups := newCacheRegistry(t, CacheConfig{TTLs: map[string]time.Duration{"query_logs": time.Minute}}, tool)
+	cached, _ := r.Get("query_logs")
+	ctx := context.Background()
+
+	calls := []string{
+		`{"query":"{app=\"web\"}","limit":100}`,
+		"{ \"limit\": 100,\n \"query\": \"{app=\\\"web\\\"}\" }", // same input, other key order and spacing
+		`{"query":"{app=\"api\"}","limit":100}`,
+	}
+	for _, in := range calls {
+		if _, err := cached.Execute(ctx, json.RawMessage(in)); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	if tool.calls != 2 {
+		t.Errorf("tool calls = %d, want 2 (one per distinct input)", tool.calls)
+	}
+
+	// A new time bucket starts over.
+	clock.advance(time.Minute)
+	_, _ = cached.Execute(ctx, json.RawMessage(calls[0]))
+	if tool.calls != 3 {
+		t.Errorf("tool calls after TTL = %d, want 3", tool.calls)
+	}
+
+	// A bypassed call neither reads nor fills the cache.
+	_, _ = cached.Execute(WithoutCache(ctx), json.RawMessage(calls[0]))
+	if tool.calls != 4 {
+		t.Errorf("tool calls with bypass = %d, want 4", tool.calls)
+	}
+
+	want := map[string]int{CacheHit: 1, CacheMiss: 3, CacheBypass: 1}
+	for k, v := range want {
+		if lookups.got[k] != v {
+			t.Errorf("%s lookups = %d, want %d", k, lookups.got[k], v)
+		}
+	}
+}
+
+func TestCache_ErrorsNotCached(t *testing.T) {
+	t.Parallel()
+
+	tool := &flakyTool{err: errors.New("loki down")}
+	r, _, _ := newCacheRegistry(t, CacheConfig{Default: time.Minute}, tool)
+	cached, _ := r.Get("query_logs")
+
+	if _, err := cached.Execute(context.Background(), json.RawMessage(`{}`)); err == nil {
+		t.Fatal("Execute succeeded, want the tool's error")
+	}
+	tool.setErr(nil)
+	if out, err := cached.Execute(context.Background(), json.RawMessage(`{}`)); err != nil || string(out) != `"ok"` {
+		t.Fatalf("Execute = %s, %v; want a fresh call", out, err)
+	}
+	if tool.calls != 2 {
+		t.Errorf("tool calls = %d, want 2", tool.calls)
+	}
+}
+
+func TestCache_PerToolTTL(t *testing.T) {
+	t.Parallel()
+
+	// Listing a tool with a zero TTL opts it out of the default.
+	tool := &flakyTool{}
+	r, _, lookups := newCacheRegistry(t, CacheConfig{Default: time.Minute, TTLs: map[string]time.Duration{"query_logs": 0}}, tool)
+	cached, _ := r.Get("query_logs")
+	for range 2 {
+		_, _ = cached.Execute(context.Background(), json.RawMessage(`{}`))
+	}
+	if tool.calls != 2 || len(lookups.got) != 0 {
+		t.Errorf("tool calls = %d, lookups = %v; want an uncached tool", tool.calls, lookups.got)
+	}
+}
+
+// gatedTool blocks every call until release is closed.
+type gatedTool struct {
+	flakyTool
+	release chan struct{}
+}
+
+func (g *gatedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	<-g.release
+	return g.flakyTool.Execute(ctx, params)
+}
+
+func TestCache_ConcurrentCallsShareOne(t *testing.T) {
+	t.Parallel()
+
+	tool := &gatedTool{release: make(chan struct{})}
+	r, _, lookups := newCacheRegistry(t, CacheConfig{Default: time.Minute}, tool)
+	cached, _ := r.Get("query_logs")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			if _, err := cached.Execute(context.Background(), json.RawMessage(`{"q":"up"}`)); err != nil {
+				t.Errorf("Execute: %v", err)
+			}
+		})
+	}
+	// Let the callers queue up behind the first before it finishes.
+	for {
+		lookups.mu.Lock()
+		n := lookups.got[CacheMiss]
+		lookups.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(tool.release)
+	wg.Wait()
+
+	if tool.calls != 1 {
+		t.Errorf("tool calls = %d, want 1", tool.calls)
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	t.Parallel()
+
+	tool := &flakyTool{}
+	r, _, _ := newCacheRegistry(t, CacheConfig{Default: time.Minute, MaxEntries: 2}, tool)
+	cached, _ := r.Get("query_logs")
+	for _, in := range []string{`{"q":"a"}`, `{"q":"b"}`, `{"q":"c"}`, `{"q":"a"}`} {
+		_, _ = cached.Execute(context.Background(), json.RawMessage(in))
+	}
+	if tool.calls != 4 {
+		t.Errorf("tool calls = %d, want 4 (the oldest entry evicted)", tool.calls)
+	}
+	if n := len(r.cache.entries); n > 2 {
+		t.Errorf("entries = %d, want at most 2", n)
+	}
+}
+
+func TestCanonicalInput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{``, `{}`, true},
+		{`{"b":1,"a":[1.50, "x"]}`, `{"a":[1.50,"x"],"b":1}`, true},
+		{`{"big":12345678901234567890}`, `{"big":12345678901234567890}`, true},
+		{`{"a":`, ``, false},
+	}
+	for _, tt := range tests {
+		got, ok := canonicalInput(json.RawMessage(tt.in))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("canonicalInput(%s) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// statsWindow is how many recent calls a tool's success rate covers.
+const statsWindow = 50
+
+// A tool whose success rate over at least hintMinCalls recent calls is below
+// hintMaxSuccess has a warning appended to its description, so the model
+// reaches for other datasources first.
+const (
+	hintMinCalls   = 10
+	hintMaxSuccess = 0.5
+)
+
+// ToolInfo describes a registered tool for the tool catalog.
+type ToolInfo struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	InputSchema  json.RawMessage `json:"input_schema"`
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
+
+	// Available is false while the tool's circuit breaker withholds it.
+	Available bool `json:"available"`
+
+	// RecentCalls is how many of the last statsWindow calls SuccessRate is
+	// computed over. SuccessRate is absent until the tool has been called.
+	RecentCalls int      `json:"recent_calls"`
+	SuccessRate *float64 `json:"success_rate,omitempty"`
+}
+
+// callStats is a ring of the outcomes of a tool's most recent calls.
+type callStats struct {
+	mu       sync.Mutex
+	outcomes [statsWindow]bool
+	n        int
+	next     int
+}
+
+func (s *callStats) record(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes[s.next] = ok
+	s.next = (s.next + 1) % statsWindow
+	s.n = min(s.n+1, statsWindow)
+}
+
+// rate returns the number of recent calls and how many of them succeeded.
+func (s *callStats) rate() (calls, ok int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.n {
+		if s.outcomes[i] {
+			ok++
+		}
+	}
+	return s.n, ok
+}
+
+// hint returns the warning for a chronically failing tool, or "".
+func (s *callStats) hint() string {
+	calls, ok := s.rate()
+	if calls < hintMinCalls || float64(ok) >= hintMaxSuccess*float64(calls) {
+		return ""
+	}
+	return fmt.Sprintf("Note: only %d of the last %d calls to this tool succeeded. Prefer other tools when they can answer the question.", ok, calls)
+}
+
+// trackedTool records the outcome of every call in its callStats.
+type trackedTool struct {
+	Tool
+	stats *callStats
+}
+
+func (t *trackedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	out, err := t.Tool.Execute(ctx, params)
+	// A triage being cancelled says nothing about the tool.
+	if err == nil || ctx.Err() == nil {
+		t.stats.record(err == nil)
+	}
+	return out, err
+}
+
+// describe returns t's description, with a warning appended when most of
+// its recent calls failed.
+func (r *Registry) describe(name string, t Tool) string {
+	desc := t.Description()
+	if hint := r.stats[name].hint(); hint != "" {
+		desc = strings.TrimRight(desc, "\n") + "\n\n" + hint
+	}
+	return desc
+}
+
+// Catalog describes every registered tool with its recent success rate,
+// sorted by name.
+func (r *Registry) Catalog() []ToolInfo {
+	out := make([]ToolInfo, 0, len(r.tools))
+	for name, t := range r.tools {
+		info := ToolInfo{
+			Name:         name,
+			Description:  t.Description(),
+			InputSchema:  t.Parameters(),
+			OutputSchema: r.outputSchemas[name],
+			Available:    true,
+		}
+		if b := r.breakers[name]; b != nil {
+			info.Available = b.available()
+		}
+		calls, ok := r.stats[name].rate()
+		info.RecentCalls = calls
+		if calls > 0 {
+			rate := float64(ok) / float64(calls)
+			info.SuccessRate = &rate
+		}
+		out = append(out, info)
+	}
+	slices.SortFunc(out, func(a, b ToolInfo) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
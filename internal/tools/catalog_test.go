@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Catalog(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry(WithCircuitBreaker(BreakerConfig{Threshold: 3, Cooldown: time.Minute}))
+	r.Register(&contractTool{stubTool: stubTool{name: "b_metrics", desc: "metrics"}, schema: `{"type":"object"}`, output: `{}`})
+	flaky := &flakyTool{}
+	r.Register(flaky)
+
+	metrics, _ := r.Get("b_metrics")
+	_, _ = metrics.Execute(context.Background(), nil)
+	logs, _ := r.Get("query_logs")
+	_, _ = logs.Execute(context.Background(), nil)
+	flaky.setErr(unavailable(errors.New("connection refused")))
+	for range 3 {
+		_, _ = logs.Execute(context.Background(), nil)
+	}
+
+	got := r.Catalog()
+	if len(got) != 2 || got[0].Name != "b_metrics" || got[1].Name != "query_logs" {
+		t.Fatalf("catalog = %+v, want b_metrics then query_logs", got)
+	}
+	got[0], got[1] = got[1], got[0]
+	if got[0].Available || got[0].RecentCalls != 4 || got[0].SuccessRate == nil || *got[0].SuccessRate != 0.25 {
+		t.Errorf("query_logs = available %v, %d calls, rate %v, want withheld after 4 calls at 0.25", got[0].Available, got[0].RecentCalls, got[0].SuccessRate)
+	}
+	if !got[1].Available || got[1].RecentCalls != 1 || *got[1].SuccessRate != 1 || string(got[1].OutputSchema) != `{"type":"object"}` {
+		t.Errorf("b_metrics = %+v", got[1])
+	}
+
+	r.Register(&stubTool{name: "c_unused"})
+	if c := r.Catalog()[1]; c.RecentCalls != 0 || c.SuccessRate != nil {
+		t.Errorf("uncalled tool = %d calls, rate %v, want no rate", c.RecentCalls, c.SuccessRate)
+	}
+}
+
+func TestCallStats_Window(t *testing.T) {
+	t.Parallel()
+
+	var s callStats
+	for range statsWindow {
+		s.record(false)
+	}
+	for range 10 {
+		s.record(true)
+	}
+	if calls, ok := s.rate(); calls != statsWindow || ok != 10 {
+		t.Errorf("rate = %d of %d, want 10 of %d", ok, calls, statsWindow)
+	}
+}
+
+func TestToToolDefs_FailureHint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		ok, fail int
+		wantHint bool
+	}{
+		{name: "too few calls", fail: hintMinCalls - 1},
+		{name: "mostly failing", ok: 2, fail: 8, wantHint: true},
+		{name: "half succeeding", ok: 5, fail: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRegistry()
+			flaky := &flakyTool{}
+			r.Register(flaky)
+			tool, _ := r.Get("query_logs")
+			for range tt.ok {
+				_, _ = tool.Execute(context.Background(), nil)
+			}
+			flaky.setErr(unavailable(errors.New("connection refused")))
+			for range tt.fail {
+				_, _ = tool.Execute(context.Background(), nil)
+			}
+
+			desc := r.ToToolDefs()[0].Description
+			if got := strings.Contains(desc, "Prefer other tools"); got != tt.wantHint {
+				t.Errorf("description = %q, want hint %v", desc, tt.wantHint)
+			}
+			if !strings.HasPrefix(desc, "flaky") {
+				t.Errorf("description = %q, want the tool's own first", desc)
+			}
+		})
+	}
+}
+
+func TestTrackedTool_IgnoresCancellation(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	flaky := &flakyTool{}
+	flaky.setErr(unavailable(errors.New("connection refused")))
+	r.Register(flaky)
+	tool, _ := r.Get("query_logs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = tool.Execute(ctx, nil)
+	if calls := r.Catalog()[0].RecentCalls; calls != 0 {
+		t.Errorf("recent calls = %d after a cancelled call, want 0", calls)
+	}
+}
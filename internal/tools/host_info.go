@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostInfoMaxFilesystems caps the filesystems in a host summary; the fullest
+// are kept.
+const hostInfoMaxFilesystems = 15
+
+// hostInfoFSFilter drops pseudo and container filesystems, which are noise
+// in a capacity summary.
+const hostInfoFSFilter = `fstype!~"tmpfs|overlay|squashfs|ramfs|nsfs|autofs"`
+
+// HostInfo summarizes a host from node_exporter metrics with a fixed set of
+// PromQL queries, so the model doesn't have to discover and issue them itself
+// each run.
+type HostInfo struct {
+	endpoint   string
+	tenantID   string
+	httpClient *http.Client
+}
+
+type hostFilesystem struct {
+	Mountpoint  string  `json:"mountpoint"`
+	Device      string  `json:"device,omitempty"`
+	FSType      string  `json:"fstype,omitempty"`
+	SizeBytes   float64 `json:"size_bytes"`
+	AvailBytes  float64 `json:"avail_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+type hostInfoResult struct {
+	Instance             string            `json:"instance"`
+	CPUs                 *float64          `json:"cpus,omitempty"`
+	MemoryTotalBytes     *float64          `json:"memory_total_bytes,omitempty"`
+	MemoryAvailableBytes *float64          `json:"memory_available_bytes,omitempty"`
+	MemoryUsedPercent    *float64          `json:"memory_used_percent,omitempty"`
+	UptimeSeconds        *float64          `json:"uptime_seconds,omitempty"`
+	Reboots7d            *float64          `json:"reboots_7d,omitempty"`
+	Filesystems          []hostFilesystem  `json:"filesystems,omitempty"`
+	Errors               map[string]string `json:"errors,omitempty"`
+}
+
+// promSample is one series of an instant vector result.
+type promSample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+// NewHostInfo creates the host summary tool for the given Prometheus API
+// endpoint and tenant ID.
+func NewHostInfo(endpoint, tenantID string) *HostInfo {
+	return &HostInfo{
+		endpoint:   endpoint,
+		tenantID:   tenantID,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the tool name.
+func (h *HostInfo) Name() string { return "get_host_info" }
+
+// Description returns an llm-friendly description of the host info tool.
+func (h *HostInfo) Description() string {
+	return `Get a compact summary of a host from its node_exporter metrics: CPU count, total and available memory,
+uptime, reboots in the last 7 days, and usage of its real filesystems (fullest first). Use this early when an
+alert names an instance, instead of writing these queries with query_metrics. The instance is the Prometheus
+"instance" label, usually host:port of the node exporter. Fields that could not be read are listed under errors.
+`
+}
+
+// Parameters returns the JSON schema for the host info input.
+func (h *HostInfo) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+        "type": "object",
+        "properties": {
+            "instance": {
+                "type": "string",
+                "description": "Value of the instance label. Example: web-1.example.com:9100"
+            }
+        },
+        "required": ["instance"]
+    }`)
+}
+
+// Execute runs the host summary queries for one instance.
+func (h *HostInfo) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	var input struct {
+		Instance string `json:"instance"`
+	}
+	if err := json.Unmarshal(params, &input); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	instance := strings.TrimSpace(input.Instance)
+	if instance == "" {
+		return nil, errors.New("instance is required")
+	}
+	// The instance is spliced into PromQL, so anything that could close the
+	// label matcher is refused.
+	if strings.ContainsAny(instance, "\"\\{}\n") {
+		return nil, fmt.Errorf("invalid instance %q", instance)
+	}
+
+	sel := `instance="` + instance + `"`
+	fsSel := sel + "," + hostInfoFSFilter
+	queries := map[string]string{
+		"cpus":             `count(node_cpu_seconds_total{mode="idle",` + sel + `})`,
+		"memory_total":     `node_memory_MemTotal_bytes{` + sel + `}`,
+		"memory_available": `node_memory_MemAvailable_bytes{` + sel + `}`,
+		"uptime":           `time() - node_boot_time_seconds{` + sel + `}`,
+		"reboots_7d":       `changes(node_boot_time_seconds{` + sel + `}[7d])`,
+		"filesystem_size":  `node_filesystem_size_bytes{` + fsSel + `}`,
+		"filesystem_avail": `node_filesystem_avail_bytes{` + fsSel + `}`,
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		samples = make(map[string][]promSample, len(queries))
+		errs    = make(map[string]error)
+	)
+	for field, q := range queries {
+		wg.Go(func() {
+			s, err := h.query(ctx, q)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[field] = err
+				return
+			}
+			samples[field] = s
+		})
+	}
+	wg.Wait()
+
+	// With every query failing the backend is the problem, not the host;
+	// return an error so the circuit breaker sees it.
+	if len(errs) == len(queries) {
+		return nil, errs["uptime"]
+	}
+
+	res := hostInfoResult{Instance: instance}
+	first := func(field string) *float64 {
+		if s := samples[field]; len(s) > 0 {
+			v := s[0].Value
+			return &v
+		}
+		return nil
+	}
+	res.CPUs = first("cpus")
+	res.MemoryTotalBytes = first("memory_total")
+	res.MemoryAvailableBytes = first("memory_available")
+	res.UptimeSeconds = first("uptime")
+	res.Reboots7d = first("reboots_7d")
+	if res.MemoryTotalBytes != nil && res.MemoryAvailableBytes != nil && *res.MemoryTotalBytes > 0 {
+		used := percent(*res.MemoryTotalBytes-*res.MemoryAvailableBytes, *res.MemoryTotalBytes)
+		res.MemoryUsedPercent = &used
+	}
+	res.Filesystems = joinFilesystems(samples["filesystem_size"], samples["filesystem_avail"])
+
+	if len(errs) > 0 {
+		res.Errors = make(map[string]string, len(errs))
+		for field, err := range errs {
+			res.Errors[field] = err.Error()
+		}
+	}
+	if len(errs) == 0 && res.CPUs == nil && res.MemoryTotalBytes == nil && res.UptimeSeconds == nil && len(res.Filesystems) == 0 {
+		return nil, fmt.Errorf("no node_exporter metrics found for instance %q", instance)
+	}
+	return json.Marshal(res)
+}
+
+// joinFilesystems pairs size and available series by mountpoint and device,
+// fullest first.
+func joinFilesystems(sizes, avails []promSample) []hostFilesystem {
+	key := func(m map[string]string) string { return m["mountpoint"] + "\x00" + m["device"] }
+	avail := make(map[string]float64, len(avails))
+	for _, s := range avails {
+		avail[key(s.Metric)] = s.Value
+	}
+	var out []hostFilesystem
+	for _, s := range sizes {
+		a, ok := avail[key(s.Metric)]
+		if !ok || s.Value <= 0 {
+			continue
+		}
+		out = append(out, hostFilesystem{
+			Mountpoint:  s.Metric["mountpoint"],
+			Device:      s.Metric["device"],
+			FSType:      s.Metric["fstype"],
+			SizeBytes:   s.Value,
+			AvailBytes:  a,
+			UsedPercent: percent(s.Value-a, s.Value),
+		})
+	}
+	slices.SortFunc(out, func(a, b hostFilesystem) int {
+		return cmp.Or(cmp.Compare(b.UsedPercent, a.UsedPercent), cmp.Compare(a.Mountpoint, b.Mountpoint))
+	})
+	if len(out) > hostInfoMaxFilesystems {
+		out = out[:hostInfoMaxFilesystems]
+	}
+	return out
+}
+
+func percent(part, total float64) float64 {
+	return math.Round(part/total*1000) / 10
+}
+
+// query runs an instant query and returns its vector samples.
+func (h *HostInfo) query(ctx context.Context, promql string) ([]promSample, error) {
+	u, err := url.Parse(h.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	u.Path = path.Join(u.Path, "api/v1/query")
+	q := u.Query()
+	q.Set("query", promql)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if h.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", h.tenantID)
+	}
+
+	resp, err := h.httpClient.Do(req) //nolint:gosec // G704 - endpoint is set at construction from config, not from tool params.
+	if err != nil {
+		return nil, unavailable(fmt.Errorf("prometheus query failed: %w", err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20)) // 5 MB
+	if err != nil {
+		return nil, unavailable(fmt.Errorf("read response: %w", err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("prometheus", resp.StatusCode, body)
+	}
+
+	var promResp struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Value  [2]any            `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &promResp); err != nil {
+		return nil, fmt.Errorf("decode prometheus response: %w", err)
+	}
+	if promResp.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", string(body))
+	}
+	if promResp.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("unexpected prometheus result type %q", promResp.Data.ResultType)
+	}
+
+	out := make([]promSample, 0, len(promResp.Data.Result))
+	for _, r := range promResp.Data.Result {
+		s, ok := r.Value[1].(string)
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		out = append(out, promSample{Metric: r.Metric, Value: v})
+	}
+	return out, nil
+}
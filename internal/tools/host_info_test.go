@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// hostInfoAnswers maps a metric name appearing in a query to its vector result.
+var hostInfoAnswers = map[string]string{
+	"node_cpu_seconds_total":          `[{"metric":{},"value":[1,"8"]}]`,
+	"node_memory_MemTotal_bytes":      `[{"metric":{"instance":"web-1:9100"},"value":[1,"16000000000"]}]`,
+	"node_memory_MemAvailable_bytes":  `[{"metric":{"instance":"web-1:9100"},"value":[1,"4000000000"]}]`,
+	"time() - node_boot_time_seconds": `[{"metric":{"instance":"web-1:9100"},"value":[1,"3600"]}]`,
+	"changes(node_boot_time_seconds":  `[{"metric":{"instance":"web-1:9100"},"value":[1,"2"]}]`,
+	"node_filesystem_size_bytes": `[
+		{"metric":{"mountpoint":"/","device":"/dev/sda1","fstype":"ext4"},"value":[1,"100"]},
+		{"metric":{"mountpoint":"/data","device":"/dev/sdb1","fstype":"xfs"},"value":[1,"200"]}]`,
+	"node_filesystem_avail_bytes": `[
+		{"metric":{"mountpoint":"/","device":"/dev/sda1","fstype":"ext4"},"value":[1,"50"]},
+		{"metric":{"mountpoint":"/data","device":"/dev/sdb1","fstype":"xfs"},"value":[1,"10"]}]`,
+}
+
+func newTestHostInfo(t *testing.T, handler http.HandlerFunc) *HostInfo {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewHostInfo(srv.URL, "test")
+}
+
+func TestHostInfo_Summary(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHostInfo(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("query")
+		if !strings.Contains(q, `instance="web-1:9100"`) {
+			t.Errorf("query %q missing instance matcher", q)
+		}
+		if r.Header.Get("X-Scope-OrgID") != "test" {
+			t.Errorf("X-Scope-OrgID = %q, want test", r.Header.Get("X-Scope-OrgID"))
+		}
+		for name, result := range hostInfoAnswers {
+			if strings.Contains(q, name) {
+				_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":%s}}`, result)
+				return
+			}
+		}
+		t.Errorf("unexpected query %q", q)
+	})
+
+	out, err := h.Execute(context.Background(), json.RawMessage(`{"instance":"web-1:9100"}`))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var res hostInfoResult
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	checks := []struct {
+		name string
+		got  *float64
+		want float64
+	}{
+		{"cpus", res.CPUs, 8},
+		{"memory_used_percent", res.MemoryUsedPercent, 75},
+		{"uptime_seconds", res.UptimeSeconds, 3600},
+		{"reboots_7d", res.Reboots7d, 2},
+	}
+	for _, c := range checks {
+		if c.got == nil || *c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if len(res.Filesystems) != 2 || res.Filesystems[0].Mountpoint != "/data" || res.Filesystems[0].UsedPercent != 95 {
+		t.Errorf("filesystems = %+v, want /data (95%%) first", res.Filesystems)
+	}
+	if res.Errors != nil {
+		t.Errorf("errors = %v, want none", res.Errors)
+	}
+}
+
+func TestHostInfo_PartialFailure(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHostInfo(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("query"), "node_filesystem") {
+			http.Error(w, "too many samples", http.StatusUnprocessableEntity)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"4"]}]}}`)
+	})
+
+	out, err := h.Execute(context.Background(), json.RawMessage(`{"instance":"web-1:9100"}`))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var res hostInfoResult
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if res.CPUs == nil || *res.CPUs != 4 {
+		t.Errorf("cpus = %v, want 4", res.CPUs)
+	}
+	if len(res.Errors) != 2 || res.Errors["filesystem_size"] == "" || res.Errors["filesystem_avail"] == "" {
+		t.Errorf("errors = %v, want both filesystem queries", res.Errors)
+	}
+}
+
+func TestHostInfo_Errors(t *testing.T) {
+	t.Parallel()
+
+	empty := newTestHostInfo(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	})
+	down := newTestHostInfo(t, func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	tests := []struct {
+		name    string
+		tool    *HostInfo
+		params  string
+		wantErr string
+	}{
+		{"missing instance", empty, `{}`, "instance is required"},
+		{"matcher injection", empty, `{"instance":"x\"} or vector(1) or {a=\""}`, "invalid instance"},
+		{"unknown host", empty, `{"instance":"gone:9100"}`, "no node_exporter metrics"},
+		{"backend down", down, `{"instance":"web-1:9100"}`, "503"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tt.tool.Execute(context.Background(), json.RawMessage(tt.params))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Probe limits. Redirects are followed by hand so every hop is checked.
+const (
+	probeDefaultTimeout = 5 * time.Second
+	probeMaxTimeout     = 10 * time.Second
+	probeMaxRedirects   = 3
+)
+
+// HTTPProbe performs blackbox GET or HEAD requests against an allowlist of
+// URLs and hosts, so the model can check whether a service is really down.
+//
+// SSRF protections: only http and https URLs on the allowlist are requested,
+// each redirect hop must also be on the allowlist and may not resolve to a
+// private, loopback or link-local address, and link-local addresses (cloud
+// metadata endpoints) are refused even when allowlisted.
+type HTTPProbe struct {
+	allow      []probeRule
+	httpClient *http.Client
+}
+
+// probeRule is one allowlist entry: a URL prefix, or a host pattern matching
+// any path over http or https.
+type probeRule struct {
+	scheme string // empty for host patterns
+	host   string // hostname, or ".example.com" for a wildcard
+	port   string // empty matches any port
+	path   string // path prefix for URL entries
+}
+
+type probeInput struct {
+	URL            string `json:"url"`
+	Method         string `json:"method,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+type probeTLS struct {
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+}
+
+type probeResult struct {
+	URL        string    `json:"url"`
+	Method     string    `json:"method"`
+	Reachable  bool      `json:"reachable"`
+	StatusCode int       `json:"status_code,omitempty"`
+	LatencyMS  int64     `json:"latency_ms"`
+	Redirects  []string  `json:"redirects,omitempty"`
+	FinalURL   string    `json:"final_url,omitempty"`
+	TLS        *probeTLS `json:"tls,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// NewHTTPProbe creates the probe tool for the given allowlist. Entries are
+// either URL prefixes ("https://status.example.com/health") or host
+// patterns ("api.example.com", "api.example.com:8443", "*.example.com").
+func NewHTTPProbe(allowlist []string) (*HTTPProbe, error) {
+	p := &HTTPProbe{}
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rule, err := parseProbeRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		p.allow = append(p.allow, rule)
+	}
+	if len(p.allow) == 0 {
+		return nil, errors.New("http probe allowlist is empty")
+	}
+
+	dialer := &net.Dialer{Timeout: probeMaxTimeout, ControlContext: probeDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // DefaultTransport is always *http.Transport
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	transport.DisableKeepAlives = true
+	p.httpClient = &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return p, nil
+}
+
+func parseProbeRule(entry string) (probeRule, error) {
+	if strings.Contains(entry, "://") {
+		u, err := url.Parse(entry)
+		if err != nil {
+			return probeRule{}, fmt.Errorf("invalid probe allowlist entry %q: %w", entry, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return probeRule{}, fmt.Errorf("invalid probe allowlist entry %q: scheme must be http or https", entry)
+		}
+		if u.Hostname() == "" || u.User != nil {
+			return probeRule{}, fmt.Errorf("invalid probe allowlist entry %q: need a host and no credentials", entry)
+		}
+		return probeRule{scheme: u.Scheme, host: strings.ToLower(u.Hostname()), port: u.Port(), path: u.EscapedPath()}, nil
+	}
+
+	host, port := entry, ""
+	if h, p, err := net.SplitHostPort(entry); err == nil {
+		host, port = h, p
+	}
+	host = strings.ToLower(host)
+	if wildcard, ok := strings.CutPrefix(host, "*."); ok {
+		host = "." + wildcard
+	}
+	if host == "" || host == "." || strings.ContainsAny(host, "/*?#@") {
+		return probeRule{}, fmt.Errorf("invalid probe allowlist entry %q", entry)
+	}
+	return probeRule{host: host, port: port}, nil
+}
+
+func (r probeRule) matches(u *url.URL) bool {
+	if r.scheme != "" && u.Scheme != r.scheme {
+		return false
+	}
+	if !r.matchesHost(u.Hostname()) {
+		return false
+	}
+	if r.port != "" && effectivePort(u) != r.port {
+		return false
+	}
+	if r.path != "" && !strings.HasPrefix(u.EscapedPath(), r.path) {
+		return false
+	}
+	return true
+}
+
+// matchesHost checks host against the rule's host pattern, ignoring the port.
+func (r probeRule) matchesHost(host string) bool {
+	host = strings.ToLower(host)
+	if strings.HasPrefix(r.host, ".") {
+		return strings.HasSuffix(host, r.host)
+	}
+	return host == r.host
+}
+
+func effectivePort(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// checkURL validates a probe target against the scheme rules and allowlist.
+func (p *HTTPProbe) checkURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("url %q: scheme must be http or https", raw)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("url %q: credentials are not allowed", raw)
+	}
+	for _, r := range p.allow {
+		if r.matches(u) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("url %q is not on the probe allowlist", raw)
+}
+
+// redirectHopKey marks a request context as a redirect hop, which may not
+// reach private or loopback addresses.
+type redirectHopKey struct{}
+
+// probeDialControl runs after DNS resolution, so it sees the address actually
+// dialed and cannot be bypassed by rebinding. Link-local (cloud metadata) is
+// always refused; redirect hops also refuse private and loopback addresses.
+func probeDialControl(ctx context.Context, _, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unexpected address %q", address)
+	}
+	if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("address %s is not allowed", ip)
+	}
+	if ctx.Value(redirectHopKey{}) != nil && (ip.IsPrivate() || ip.IsLoopback()) {
+		return fmt.Errorf("redirect to private address %s is not allowed", ip)
+	}
+	return nil
+}
+
+// Name returns the tool name.
+func (p *HTTPProbe) Name() string { return "http_probe" }
+
+// Description returns an llm-friendly description of the probe tool.
+func (p *HTTPProbe) Description() string {
+	return `Send a single GET or HEAD request to a service URL and report the status code, latency, redirects,
+and TLS certificate expiry. Use this during availability alerts to check whether the service is actually
+down from Vigil's point of view, or whether the alert is about something else (a scrape failure, one replica).
+
+Only URLs on the operator's allowlist can be probed; others are rejected. A connection failure is not a tool
+error: the result has reachable=false and the error, which is itself evidence of an outage.
+Prefer HEAD for large pages. Probe at most a few URLs; this is a spot check, not monitoring.
+`
+}
+
+// Parameters returns the JSON schema for the probe input.
+func (p *HTTPProbe) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+        "type": "object",
+        "properties": {
+            "url": {
+                "type": "string",
+                "description": "Absolute http or https URL on the probe allowlist. Example: https://api.example.com/healthz"
+            },
+            "method": {
+                "type": "string",
+                "enum": ["GET", "HEAD"],
+                "description": "HTTP method. Default GET."
+            },
+            "timeout_seconds": {
+                "type": "integer",
+                "description": "Request timeout in seconds. Default 5, max 10."
+            }
+        },
+        "required": ["url"]
+    }`)
+}
+
+func parseProbeInput(params json.RawMessage) (probeInput, time.Duration, error) {
+	var input probeInput
+	if err := json.Unmarshal(params, &input); err != nil {
+		return input, 0, fmt.Errorf("invalid params: %w", err)
+	}
+	if input.URL == "" {
+		return input, 0, errors.New("url is required")
+	}
+	input.Method = strings.ToUpper(input.Method)
+	switch input.Method {
+	case "":
+		input.Method = http.MethodGet
+	case http.MethodGet, http.MethodHead:
+	default:
+		return input, 0, fmt.Errorf("method must be GET or HEAD, got %q", input.Method)
+	}
+	timeout := probeDefaultTimeout
+	if input.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(input.TimeoutSeconds)*time.Second, probeMaxTimeout)
+	}
+	return input, timeout, nil
+}
+
+// Execute probes the URL, following up to three allowlisted redirects.
+func (p *HTTPProbe) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	input, timeout, err := parseProbeInput(params)
+	if err != nil {
+		return nil, err
+	}
+	target, err := p.checkURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := probeResult{URL: input.URL, Method: input.Method}
+	start := time.Now()
+	for hop := 0; ; hop++ {
+		hopCtx := ctx
+		if hop > 0 {
+			hopCtx = context.WithValue(ctx, redirectHopKey{}, true)
+		}
+		resp, err := p.do(hopCtx, input.Method, target)
+		if err != nil {
+			if hop > 0 {
+				// The previous hop answered; only the redirect failed.
+				res.Error = "redirect not followed: " + err.Error()
+			} else {
+				res.Error = err.Error()
+			}
+			break
+		}
+		res.Reachable = true
+		res.StatusCode = resp.StatusCode
+		res.FinalURL = target.String()
+		res.TLS = nil
+		if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+			cert := resp.TLS.PeerCertificates[0]
+			res.TLS = &probeTLS{
+				Subject:       cert.Subject.CommonName,
+				Issuer:        cert.Issuer.CommonName,
+				NotAfter:      cert.NotAfter.UTC(),
+				DaysRemaining: int(time.Until(cert.NotAfter).Hours() / 24),
+			}
+		}
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode > 399 || loc == "" {
+			break
+		}
+		if hop == probeMaxRedirects {
+			res.Error = fmt.Sprintf("stopped after %d redirects", probeMaxRedirects)
+			break
+		}
+		next, err := target.Parse(loc)
+		if err == nil {
+			next, err = p.checkURL(next.String())
+		}
+		if err != nil {
+			res.Error = "redirect not followed: " + err.Error()
+			break
+		}
+		res.Redirects = append(res.Redirects, next.String())
+		target = next
+	}
+	res.LatencyMS = time.Since(start).Milliseconds()
+	return json.Marshal(res)
+}
+
+func (p *HTTPProbe) do(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "vigil-http-probe")
+
+	resp, err := p.httpClient.Do(req) //nolint:gosec // G704 - URL is checked against the operator allowlist and dial control.
+	if err != nil {
+		return nil, err
+	}
+	// Only the status matters; drain a little so the response completes.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	return resp, nil
+}
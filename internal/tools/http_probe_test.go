@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestProbe(t *testing.T, allow ...string) *HTTPProbe {
+	t.Helper()
+	p, err := NewHTTPProbe(allow)
+	if err != nil {
+		t.Fatalf("NewHTTPProbe: %v", err)
+	}
+	return p
+}
+
+func runProbe(t *testing.T, p *HTTPProbe, params string) probeResult {
+	t.Helper()
+	out, err := p.Execute(context.Background(), json.RawMessage(params))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var res probeResult
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res
+}
+
+func TestHTTPProbe_Success(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	p := newTestProbe(t, srv.URL+"/healthz")
+
+	res := runProbe(t, p, `{"url":"`+srv.URL+`/healthz","method":"head"}`)
+	if !res.Reachable || res.StatusCode != http.StatusServiceUnavailable || res.Error != "" {
+		t.Errorf("result = %+v, want reachable 503", res)
+	}
+	if res.TLS != nil {
+		t.Errorf("TLS = %+v, want none over http", res.TLS)
+	}
+}
+
+func TestHTTPProbe_TLSExpiry(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	p := newTestProbe(t, "127.0.0.1")
+	p.httpClient.Transport.(*http.Transport).TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig //nolint:forcetypeassert // test transports
+
+	res := runProbe(t, p, `{"url":"`+srv.URL+`"}`)
+	if res.StatusCode != http.StatusOK || res.TLS == nil {
+		t.Fatalf("result = %+v, want 200 with TLS details", res)
+	}
+	if !res.TLS.NotAfter.Equal(srv.Certificate().NotAfter) || res.TLS.DaysRemaining <= 0 {
+		t.Errorf("TLS = %+v, want not_after %s", res.TLS, srv.Certificate().NotAfter)
+	}
+}
+
+func TestHTTPProbe_RejectsUnlistedURLs(t *testing.T) {
+	t.Parallel()
+
+	p := newTestProbe(t, "https://status.example.com/health", "*.internal.example.com")
+
+	tests := []struct {
+		name, params, wantErr string
+	}{
+		{"other host", `{"url":"https://evil.example.net/"}`, "not on the probe allowlist"},
+		{"outside path prefix", `{"url":"https://status.example.com/admin"}`, "not on the probe allowlist"},
+		{"scheme mismatch", `{"url":"http://status.example.com/health"}`, "not on the probe allowlist"},
+		{"file scheme", `{"url":"file:///etc/passwd"}`, "scheme must be http or https"},
+		{"credentials", `{"url":"https://user:pw@api.internal.example.com/"}`, "credentials"},
+		{"bad method", `{"url":"https://status.example.com/health","method":"POST"}`, "GET or HEAD"},
+		{"missing url", `{}`, "url is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := p.Execute(context.Background(), json.RawMessage(tt.params))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPProbe_Redirects(t *testing.T) {
+	t.Parallel()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("redirect to a private address was followed")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(target.Close)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private":
+			http.Redirect(w, r, target.URL+"/", http.StatusFound)
+		case "/unlisted":
+			http.Redirect(w, r, "https://elsewhere.example.net/", http.StatusFound)
+		}
+	}))
+	t.Cleanup(origin.Close)
+	p := newTestProbe(t, "127.0.0.1")
+
+	tests := []struct {
+		path, wantErr string
+	}{
+		{"/private", "private address"},
+		{"/unlisted", "not on the probe allowlist"},
+	}
+	for _, tt := range tests {
+		res := runProbe(t, p, `{"url":"`+origin.URL+tt.path+`"}`)
+		if !res.Reachable || res.StatusCode != http.StatusFound {
+			t.Errorf("%s: result = %+v, want the origin's 302", tt.path, res)
+		}
+		if !strings.Contains(res.Error, "redirect not followed") || !strings.Contains(res.Error, tt.wantErr) {
+			t.Errorf("%s: error = %q, want %q", tt.path, res.Error, tt.wantErr)
+		}
+	}
+}
+
+func TestHTTPProbe_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	p := newTestProbe(t, "127.0.0.1")
+
+	res := runProbe(t, p, `{"url":"http://`+addr+`/"}`)
+	if res.Reachable || res.Error == "" {
+		t.Errorf("result = %+v, want unreachable with an error", res)
+	}
+}
+
+func TestProbeDialControl(t *testing.T) {
+	t.Parallel()
+
+	hop := context.WithValue(context.Background(), redirectHopKey{}, true)
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		address string
+		wantErr bool
+	}{
+		{"metadata endpoint", context.Background(), "169.254.169.254:80", true},
+		{"unspecified", context.Background(), "0.0.0.0:80", true},
+		{"allowlisted private host", context.Background(), "10.0.0.5:443", false},
+		{"private redirect", hop, "10.0.0.5:443", true},
+		{"loopback redirect", hop, "[::1]:80", true},
+		{"public redirect", hop, "93.184.216.34:443", false},
+	}
+	for _, tt := range tests {
+		if err := probeDialControl(tt.ctx, "tcp", tt.address, nil); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProbeRule_Matches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry, url string
+		want       bool
+	}{
+		{"api.example.com", "https://API.example.com/anything", true},
+		{"api.example.com", "http://api.example.com:8080/", true},
+		{"api.example.com:8443", "https://api.example.com:8443/", true},
+		{"api.example.com:443", "https://api.example.com/", true},
+		{"api.example.com:8443", "https://api.example.com/", false},
+		{"*.example.com", "https://a.b.example.com/", true},
+		{"*.example.com", "https://example.com/", false},
+		{"*.example.com", "https://badexample.com/", false},
+		{"https://status.example.com/health", "https://status.example.com/healthz", true},
+		{"https://status.example.com/health", "https://status.example.com.evil.net/health", false},
+	}
+	for _, tt := range tests {
+		r, err := parseProbeRule(tt.entry)
+		if err != nil {
+			t.Fatalf("parseProbeRule(%q): %v", tt.entry, err)
+		}
+		u, _ := url.Parse(tt.url)
+		if got := r.matches(u); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.entry, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPProbe_InvalidAllowlist(t *testing.T) {
+	t.Parallel()
+
+	for _, allow := range [][]string{nil, {" "}, {"ftp://files.example.com"}, {"https://user@example.com"}, {"*"}} {
+		if _, err := NewHTTPProbe(allow); err == nil {
+			t.Errorf("NewHTTPProbe(%q) succeeded", allow)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// LimitConfig configures per-tool concurrency limits.
+type LimitConfig struct {
+	// Limits maps tool names to the most calls of that tool running at
+	// once, across every triage using the registry. Tools not listed, or
+	// with a limit below 1, are unlimited.
+	Limits map[string]int
+
+	// OnWait, if set, is called for every call to a limited tool with how
+	// long it waited for a slot, including calls that gave up because their
+	// context ended.
+	OnWait func(tool string, wait time.Duration)
+}
+
+// WithConcurrencyLimits caps how many calls of each listed tool run at once,
+// so parallel tool calls and concurrent triages do not overload a data
+// source. Calls over the limit wait for a slot; cache hits and calls
+// rejected by an open circuit breaker never take one.
+func WithConcurrencyLimits(c LimitConfig) RegistryOption {
+	return func(r *Registry) {
+		r.limits = &c
+	}
+}
+
+// limitedTool runs at most cap(sem) calls of a tool at once.
+type limitedTool struct {
+	Tool
+	sem    chan struct{}
+	onWait func(tool string, wait time.Duration)
+}
+
+func (t *limitedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	start := time.Now()
+	select {
+	case t.sem <- struct{}{}:
+	case <-ctx.Done():
+		t.waited(time.Since(start))
+		return nil, ctx.Err()
+	}
+	t.waited(time.Since(start))
+	defer func() { <-t.sem }()
+	return t.Tool.Execute(ctx, params)
+}
+
+func (t *limitedTool) waited(d time.Duration) {
+	if t.onWait != nil {
+		t.onWait(t.Name(), d)
+	}
+}
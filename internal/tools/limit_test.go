@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slotTool blocks every call until release is closed and records the most
+// calls it saw running at once.
+type slotTool struct {
+	release chan struct{}
+	started chan struct{}
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (g *slotTool) Name() string                { return "query_logs" }
+func (g *slotTool) Description() string         { return "gated" }
+func (g *slotTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (g *slotTool) Execute(ctx context.Context, _ json.RawMessage) (json.RawMessage, error) {
+	n := g.running.Add(1)
+	defer g.running.Add(-1)
+	for {
+		p := g.peak.Load()
+		if n <= p || g.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	g.started <- struct{}{}
+	select {
+	case <-g.release:
+		return json.RawMessage(`"ok"`), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestConcurrencyLimit_CapsRunningCalls(t *testing.T) {
+	t.Parallel()
+
+	var waits atomic.Int32
+	tool := &slotTool{release: make(chan struct{}), started: make(chan struct{}, 8)}
+	r := NewRegistry(WithConcurrencyLimits(LimitConfig{
+		Limits: map[string]int{"query_logs": 2},
+		OnWait: func(name string, _ time.Duration) {
+			if name == "query_logs" {
+				waits.Add(1)
+			}
+		},
+	}))
+	r.Register(tool)
+	limited, _ := r.Get("query_logs")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := limited.Execute(context.Background(), json.RawMessage(`{}`)); err != nil {
+				t.Errorf("Execute: %v", err)
+			}
+		}()
+	}
+
+	// Two calls take the slots; the rest wait for them.
+	<-tool.started
+	<-tool.started
+	select {
+	case <-tool.started:
+		t.Fatal("third call started while two were running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(tool.release)
+	wg.Wait()
+
+	if got := tool.peak.Load(); got != 2 {
+		t.Errorf("peak concurrent calls = %d, want 2", got)
+	}
+	if got := waits.Load(); got != 5 {
+		t.Errorf("OnWait calls = %d, want 5", got)
+	}
+}
+
+func TestConcurrencyLimit_WaitEndsWithContext(t *testing.T) {
+	t.Parallel()
+
+	tool := &slotTool{release: make(chan struct{}), started: make(chan struct{}, 1)}
+	defer close(tool.release)
+	r := NewRegistry(WithConcurrencyLimits(LimitConfig{Limits: map[string]int{"query_logs": 1}}))
+	r.Register(tool)
+	limited, _ := r.Get("query_logs")
+
+	go func() { _, _ = limited.Execute(context.Background(), json.RawMessage(`{}`)) }()
+	<-tool.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limited.Execute(ctx, json.RawMessage(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Execute while full: err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestConcurrencyLimit_UnlistedToolUnlimited(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry(WithConcurrencyLimits(LimitConfig{Limits: map[string]int{"query_metrics": 1}}))
+	r.Register(&flakyTool{})
+	got, _ := r.Get("query_logs")
+	if _, ok := got.(*trackedTool).Tool.(*limitedTool); ok {
+		t.Error("query_logs wrapped in a limit it was not given")
+	}
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Pattern mode limits. Lines are grouped Drain style: variable tokens are
+// masked, then lines with the same token count join the first pattern whose
+// tokens mostly agree, and positions that differ become wildcards.
+const (
+	patternWildcard   = "<*>"
+	patternMaxTokens  = 64
+	patternSimilarity = 0.5
+	patternMaxResults = 30
+	patternExamples   = 2
+	patternExampleLen = 300
+)
+
+// logPattern is one group of similar log lines.
+type logPattern struct {
+	Pattern   string   `json:"pattern"`
+	Count     int      `json:"count"`
+	FirstSeen string   `json:"first_ts"`
+	LastSeen  string   `json:"last_ts"`
+	Examples  []string `json:"examples"`
+
+	tokens      []string
+	first, last int64
+}
+
+// summarizePatterns groups lines into patterns, most frequent first. Past
+// maxPatterns the remaining lines are only counted, in the second result.
+func summarizePatterns(lines []logLine, maxPatterns int) ([]*logPattern, int) {
+	byLen := make(map[int][]*logPattern)
+	var all []*logPattern
+	for _, ll := range lines {
+		toks := patternTokens(ll.Line)
+		ts, _ := strconv.ParseInt(ll.Timestamp, 10, 64)
+
+		var best *logPattern
+		bestSim := 0.0
+		for _, p := range byLen[len(toks)] {
+			if sim := patternSimilarityOf(p.tokens, toks); sim > bestSim {
+				best, bestSim = p, sim
+			}
+		}
+		if best == nil || bestSim < patternSimilarity {
+			best = &logPattern{tokens: toks, first: ts, last: ts, FirstSeen: ll.Timestamp, LastSeen: ll.Timestamp}
+			byLen[len(toks)] = append(byLen[len(toks)], best)
+			all = append(all, best)
+		} else {
+			for i, tok := range toks {
+				if best.tokens[i] != tok {
+					best.tokens[i] = patternWildcard
+				}
+			}
+		}
+
+		best.Count++
+		if ts < best.first {
+			best.first, best.FirstSeen = ts, ll.Timestamp
+		}
+		if ts > best.last {
+			best.last, best.LastSeen = ts, ll.Timestamp
+		}
+		if len(best.Examples) < patternExamples {
+			best.Examples = append(best.Examples, truncateLine(ll.Line))
+		}
+	}
+
+	for _, p := range all {
+		p.Pattern = strings.Join(p.tokens, " ")
+	}
+	slices.SortStableFunc(all, func(a, b *logPattern) int { return cmp.Compare(b.Count, a.Count) })
+	other := 0
+	if len(all) > maxPatterns {
+		for _, p := range all[maxPatterns:] {
+			other += p.Count
+		}
+		all = all[:maxPatterns]
+	}
+	return all, other
+}
+
+// patternTokens splits a line into at most patternMaxTokens tokens, masking
+// the ones that look variable: anything with a digit, such as IDs,
+// addresses, durations and counts, and the values of key=value pairs that
+// contain one.
+func patternTokens(line string) []string {
+	fields := strings.Fields(line)
+	if len(fields) > patternMaxTokens {
+		fields = append(fields[:patternMaxTokens-1], patternWildcard)
+	}
+	for i, f := range fields {
+		if !strings.ContainsAny(f, "0123456789") {
+			continue
+		}
+		if k, _, ok := strings.Cut(f, "="); ok && k != "" && !strings.ContainsAny(k, "0123456789") {
+			fields[i] = k + "=" + patternWildcard
+			continue
+		}
+		fields[i] = patternWildcard
+	}
+	return fields
+}
+
+// patternSimilarityOf is the share of positions where a line's tokens match
+// a pattern's, wildcards matching anything.
+func patternSimilarityOf(pattern, toks []string) float64 {
+	if len(toks) == 0 {
+		return 1
+	}
+	same := 0
+	for i, tok := range toks {
+		if pattern[i] == tok || pattern[i] == patternWildcard {
+			same++
+		}
+	}
+	return float64(same) / float64(len(toks))
+}
+
+func truncateLine(s string) string {
+	if len(s) <= patternExampleLen {
+		return s
+	}
+	return strings.ToValidUTF8(s[:patternExampleLen], "") + "..."
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSummarizePatterns(t *testing.T) {
+	t.Parallel()
+
+	var lines []logLine
+	for i := range 6 {
+		lines = append(lines, logLine{
+			Timestamp: fmt.Sprint(1000 + i),
+			Line:      fmt.Sprintf("GET /api/users/%d took %dms status=200", i, 10*i),
+		})
+	}
+	for i, user := range []string{"alice", "bob", "carol"} {
+		lines = append(lines, logLine{
+			Timestamp: fmt.Sprint(2000 + i),
+			Line:      "connection reset by peer for " + user,
+		})
+	}
+	lines = append(lines, logLine{Timestamp: "3000", Line: "shutting down"})
+
+	patterns, other := summarizePatterns(lines, 2)
+
+	if len(patterns) != 2 || other != 1 {
+		t.Fatalf("got %d patterns, %d other lines; want 2 and 1", len(patterns), other)
+	}
+	want := []struct {
+		pattern     string
+		count       int
+		first, last string
+	}{
+		{"GET <*> took <*> status=<*>", 6, "1000", "1005"},
+		{"connection reset by peer for <*>", 3, "2000", "2002"},
+	}
+	for i, w := range want {
+		p := patterns[i]
+		if p.Pattern != w.pattern || p.Count != w.count || p.FirstSeen != w.first || p.LastSeen != w.last {
+			t.Errorf("pattern %d = %+v, want %+v", i, p, w)
+		}
+		if len(p.Examples) != patternExamples {
+			t.Errorf("pattern %d has %d examples, want %d", i, len(p.Examples), patternExamples)
+		}
+	}
+}
+
+func TestPatternTokens(t *testing.T) {
+	t.Parallel()
+
+	got := strings.Join(patternTokens("user 42 logged in from 10.0.0.1 id=abc123 retry=3 ok"), " ")
+	if want := "user <*> logged in from <*> id=<*> retry=<*> ok"; got != want {
+		t.Errorf("patternTokens = %q, want %q", got, want)
+	}
+	long := strings.Repeat("word ", 100)
+	if n := len(patternTokens(long)); n != patternMaxTokens {
+		t.Errorf("long line has %d tokens, want %d", n, patternMaxTokens)
+	}
+}
+
+func TestLokiQuery_PatternsMode(t *testing.T) {
+	t.Parallel()
+
+	values := make([]string, 0, 50)
+	for i := range 50 {
+		values = append(values, fmt.Sprintf(`["%d","worker %d finished job in %dms"]`, 1000+i, i%4, i))
+	}
+	loki := newTestLoki(t, "test", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"streams","result":[
+			{"stream":{"job":"a"},"values":[%s]}
+		]}}`, strings.Join(values, ","))
+	})
+
+	out, err := loki.Execute(context.Background(), json.RawMessage(`{"query":"{job=\"a\"}","mode":"patterns"}`))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var parsed struct {
+		Mode      string       `json:"mode"`
+		LineCount int          `json:"line_count"`
+		Patterns  []logPattern `json:"patterns"`
+		Lines     []logLine    `json:"lines"`
+	}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if parsed.Mode != "patterns" || parsed.LineCount != 50 || len(parsed.Lines) != 0 {
+		t.Errorf("output = %+v, want 50 lines summarized without raw lines", parsed)
+	}
+	if len(parsed.Patterns) != 1 || parsed.Patterns[0].Count != 50 || parsed.Patterns[0].Pattern != "worker <*> finished job in <*>" {
+		t.Errorf("patterns = %+v, want one pattern covering all lines", parsed.Patterns)
+	}
+
+	if _, err := loki.Execute(context.Background(), json.RawMessage(`{"query":"{job=\"a\"}","mode":"stats"}`)); err == nil || !strings.Contains(err.Error(), "mode must be") {
+		t.Errorf("err = %v, want invalid mode", err)
+	}
+}
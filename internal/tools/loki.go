@@ -23,8 +23,19 @@ type lokiInput struct {
 	Start string `json:"start,omitempty"`
 	End   string `json:"end,omitempty"`
 	Limit int    `json:"limit,omitempty"`
+	Mode  string `json:"mode,omitempty"`
 }
 
+// Loki output modes. Pattern mode reads more lines than it returns, so it
+// has its own limits.
+const (
+	lokiModeLines    = "lines"
+	lokiModePatterns = "patterns"
+
+	lokiPatternDefaultLimit = 1000
+	lokiPatternMaxLimit     = 5000
+)
+
 type logLine struct {
 	Timestamp string            `json:"ts"`
 	Line      string            `json:"line"`
@@ -79,11 +90,24 @@ func parseLokiInput(params json.RawMessage) (lokiInput, error) {
 		return input, fmt.Errorf("query is required")
 	}
 
-	switch {
-	case input.Limit <= 0:
-		input.Limit = 100
-	case input.Limit > 500:
-		input.Limit = 500
+	switch input.Mode {
+	case "", lokiModeLines:
+		input.Mode = lokiModeLines
+		switch {
+		case input.Limit <= 0:
+			input.Limit = 100
+		case input.Limit > 500:
+			input.Limit = 500
+		}
+	case lokiModePatterns:
+		switch {
+		case input.Limit <= 0:
+			input.Limit = lokiPatternDefaultLimit
+		case input.Limit > lokiPatternMaxLimit:
+			input.Limit = lokiPatternMaxLimit
+		}
+	default:
+		return input, fmt.Errorf("mode must be %s or %s, got %q", lokiModeLines, lokiModePatterns, input.Mode)
 	}
 
 	now := time.Now().UTC()
@@ -133,6 +157,11 @@ Prefer exact string matches (|= "exact") over regex (|~) when possible, as regex
 Avoid short common substrings in regex alternations (e.g. "log", "tmp", "clean") as they match too broadly and cause timeouts.
 Use specific terms: |= "logrotate" is fast, |~ "log|tmp|clean" is slow.
 When searching for multiple terms, prefer multiple sequential queries with |= over one regex with many alternations.
+
+Set mode="patterns" to get the shape of the logs instead of raw lines: up to 1000 lines (limit, max 5000) are
+grouped into patterns with variable parts such as IDs and numbers replaced by <*>, each with a count, first and
+last timestamp, and example lines. Start with patterns mode on noisy streams to see what is there cheaply, then
+query raw lines with a filter for the pattern that matters.
 `
 }
 
@@ -155,13 +184,47 @@ func (l *LokiQuery) Parameters() json.RawMessage {
             },
             "limit": {
                 "type": "integer",
-                "description": "Maximum number of log lines to return. Default 100, max 500."
+                "description": "Maximum number of log lines to return. Default 100, max 500. In patterns mode, lines to read: default 1000, max 5000."
+            },
+            "mode": {
+                "type": "string",
+                "enum": ["lines", "patterns"],
+                "description": "lines returns raw log lines (default). patterns groups them into templates with counts and examples."
             }
         },
         "required": ["query"]
     }`)
 }
 
+// OutputSchema returns the JSON schema of a query result, in either mode.
+func (l *LokiQuery) OutputSchema() json.RawMessage {
+	return json.RawMessage(`{
+        "type": "object",
+        "properties": {
+            "mode": {"enum": ["patterns"]},
+            "stream_count": {"type": "integer"},
+            "line_count": {"type": "integer"},
+            "lines": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "ts": {"type": "string"},
+                        "line": {"type": "string"},
+                        "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+                    },
+                    "required": ["ts", "line"]
+                }
+            },
+            "pattern_count": {"type": "integer"},
+            "patterns": {"type": "array"},
+            "other_lines": {"type": "integer"},
+            "truncated": {"type": "boolean"}
+        },
+        "required": ["stream_count", "line_count", "truncated"]
+    }`)
+}
+
 // Execute performs the Loki query based on the provided parameters, handling HTTP communication and response parsing.
 func (l *LokiQuery) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
 	input, err := parseLokiInput(params)
@@ -211,17 +274,17 @@ func (l *LokiQuery) Execute(ctx context.Context, params json.RawMessage) (json.R
 	resp, err := l.httpClient.Do(req) //nolint:gosec // G704 - endpoint is set at construction from config, not from tool params.
 	// LLM-controlled inputs (query, start, end, limit) are query-string encoded via url.Values.Set().
 	if err != nil {
-		return nil, fmt.Errorf("loki query failed: %w", err)
+		return nil, unavailable(fmt.Errorf("loki query failed: %w", err))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20)) // 5 MB
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, unavailable(fmt.Errorf("read response: %w", err))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("loki returned %d: %s", resp.StatusCode, string(body))
+		return nil, statusError("loki", resp.StatusCode, body)
 	}
 
 	var lokiResp lokiResponse
@@ -234,6 +297,19 @@ func (l *LokiQuery) Execute(ctx context.Context, params json.RawMessage) (json.R
 
 	lines := flattenStreams(lokiResp.Data.Result, input.Limit)
 
+	if input.Mode == lokiModePatterns {
+		patterns, other := summarizePatterns(lines, patternMaxResults)
+		return json.Marshal(map[string]any{
+			"mode":          lokiModePatterns,
+			"stream_count":  len(lokiResp.Data.Result),
+			"line_count":    len(lines),
+			"pattern_count": len(patterns),
+			"patterns":      patterns,
+			"other_lines":   other,
+			"truncated":     len(lines) >= input.Limit,
+		})
+	}
+
 	output := map[string]any{
 		"stream_count": len(lokiResp.Data.Result),
 		"line_count":   len(lines),
@@ -167,6 +167,8 @@ func TestLokiQuery_LimitClamping(t *testing.T) {
 		{"zero defaults to 100", `{"query":"{job=\"a\"}","limit":0}`, "100"},
 		{"negative defaults to 100", `{"query":"{job=\"a\"}","limit":-5}`, "100"},
 		{"over max caps to 500", `{"query":"{job=\"a\"}","limit":9999}`, "500"},
+		{"patterns defaults to 1000", `{"query":"{job=\"a\"}","mode":"patterns"}`, "1000"},
+		{"patterns over max caps to 5000", `{"query":"{job=\"a\"}","mode":"patterns","limit":9999}`, "5000"},
 	}
 
 	for _, tt := range tests {
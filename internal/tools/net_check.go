@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Net check limits. The timeout covers resolution and every connect attempt.
+const (
+	netCheckDefaultTimeout = 3 * time.Second
+	netCheckMaxTimeout     = 10 * time.Second
+)
+
+// resolver is the part of *net.Resolver the net check uses, so tests can
+// fake DNS answers.
+type resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// NetCheck resolves hostnames and opens TCP connections to an allowlist of
+// targets, so the model can tell a DNS failure from a service that is down.
+//
+// Targets use the same host patterns as the HTTP probe ("db.example.com",
+// "db.example.com:5432", "*.example.com"). A target with a port only allows
+// TCP checks to that port; DNS checks need only the host to match. Link-local
+// addresses, such as cloud metadata endpoints, are never dialed.
+type NetCheck struct {
+	allow    []probeRule
+	resolver resolver
+	dial     func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+type netCheckInput struct {
+	Check          string `json:"check"`
+	Host           string `json:"host"`
+	Port           int    `json:"port,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+type netCheckResult struct {
+	Check       string   `json:"check"`
+	Host        string   `json:"host"`
+	Port        int      `json:"port,omitempty"`
+	OK          bool     `json:"ok"`
+	FailedStage string   `json:"failed_stage,omitempty"`
+	ErrorKind   string   `json:"error_kind,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	CNAME       string   `json:"cname,omitempty"`
+	Addresses   []string `json:"addresses,omitempty"`
+	ResolveMS   int64    `json:"resolve_ms"`
+	ConnectMS   int64    `json:"connect_ms,omitempty"`
+	ConnectedTo string   `json:"connected_to,omitempty"`
+}
+
+// NewNetCheck creates the DNS and TCP check tool for the given targets.
+func NewNetCheck(targets []string) (*NetCheck, error) {
+	c := &NetCheck{resolver: net.DefaultResolver}
+	for _, entry := range targets {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rule, err := parseProbeRule(entry)
+		if err == nil && rule.scheme != "" {
+			err = fmt.Errorf("invalid net check target %q: want a host or host:port, not a URL", entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.allow = append(c.allow, rule)
+	}
+	if len(c.allow) == 0 {
+		return nil, errors.New("net check target list is empty")
+	}
+	dialer := &net.Dialer{ControlContext: probeDialControl}
+	c.dial = dialer.DialContext
+	return c, nil
+}
+
+// allowed reports whether a check of host, and port when non-zero, is on the
+// target list.
+func (c *NetCheck) allowed(host string, port int) bool {
+	for _, r := range c.allow {
+		if !r.matchesHost(host) {
+			continue
+		}
+		if port == 0 || r.port == "" || r.port == strconv.Itoa(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the tool name.
+func (c *NetCheck) Name() string { return "net_check" }
+
+// Description returns an llm-friendly description of the net check tool.
+func (c *NetCheck) Description() string {
+	return `Check network reachability of a host from Vigil. check="dns" resolves the hostname and reports its
+addresses and CNAME. check="tcp" resolves the hostname and then opens a TCP connection to the port, trying each
+address in turn, and reports which stage failed.
+
+Use this during connectivity alerts to separate DNS failures (failed_stage="dns", error_kind="not_found" or
+"timeout") from a service that resolves but does not accept connections (failed_stage="connect",
+error_kind="refused" or "timeout"). A failed check is not a tool error; the result has ok=false and is itself
+evidence. Only hosts on the operator's target list can be checked.
+`
+}
+
+// Parameters returns the JSON schema for the net check input.
+func (c *NetCheck) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+        "type": "object",
+        "properties": {
+            "check": {
+                "type": "string",
+                "enum": ["dns", "tcp"],
+                "description": "dns resolves the host only; tcp also connects to the port."
+            },
+            "host": {
+                "type": "string",
+                "description": "Hostname or IP address on the target list. Example: db.example.com"
+            },
+            "port": {
+                "type": "integer",
+                "description": "TCP port, required for check=tcp. Example: 5432"
+            },
+            "timeout_seconds": {
+                "type": "integer",
+                "description": "Timeout for the whole check in seconds. Default 3, max 10."
+            }
+        },
+        "required": ["check", "host"]
+    }`)
+}
+
+func parseNetCheckInput(params json.RawMessage) (netCheckInput, time.Duration, error) {
+	var input netCheckInput
+	if err := json.Unmarshal(params, &input); err != nil {
+		return input, 0, fmt.Errorf("invalid params: %w", err)
+	}
+	input.Host = strings.TrimSuffix(strings.TrimSpace(input.Host), ".")
+	if input.Host == "" {
+		return input, 0, errors.New("host is required")
+	}
+	switch input.Check {
+	case "dns":
+		input.Port = 0
+	case "tcp":
+		if input.Port < 1 || input.Port > 65535 {
+			return input, 0, fmt.Errorf("port must be between 1 and 65535 for check=tcp, got %d", input.Port)
+		}
+	default:
+		return input, 0, fmt.Errorf("check must be dns or tcp, got %q", input.Check)
+	}
+	timeout := netCheckDefaultTimeout
+	if input.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(input.TimeoutSeconds)*time.Second, netCheckMaxTimeout)
+	}
+	return input, timeout, nil
+}
+
+// Execute runs a DNS or TCP check against an allowlisted target.
+func (c *NetCheck) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	input, timeout, err := parseNetCheckInput(params)
+	if err != nil {
+		return nil, err
+	}
+	if !c.allowed(input.Host, input.Port) {
+		if input.Port != 0 {
+			return nil, fmt.Errorf("%s:%d is not on the net check target list", input.Host, input.Port)
+		}
+		return nil, fmt.Errorf("host %q is not on the net check target list", input.Host)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := netCheckResult{Check: input.Check, Host: input.Host, Port: input.Port}
+	start := time.Now()
+	addrs, err := c.resolve(ctx, &res)
+	res.ResolveMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.FailedStage, res.ErrorKind, res.Error = "dns", netErrorKind(err), err.Error()
+		return json.Marshal(res)
+	}
+	if input.Check == "dns" {
+		res.OK = true
+		return json.Marshal(res)
+	}
+
+	start = time.Now()
+	for _, ip := range addrs {
+		var conn net.Conn
+		conn, err = c.dial(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(input.Port)))
+		if err == nil {
+			res.ConnectedTo = conn.RemoteAddr().String()
+			_ = conn.Close()
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	res.ConnectMS = time.Since(start).Milliseconds()
+	if err != nil {
+		res.FailedStage, res.ErrorKind, res.Error = "connect", netErrorKind(err), err.Error()
+		return json.Marshal(res)
+	}
+	res.OK = true
+	return json.Marshal(res)
+}
+
+// resolve looks up the host's addresses, recording them and any CNAME on res.
+// IP literals are returned as is.
+func (c *NetCheck) resolve(ctx context.Context, res *netCheckResult) ([]string, error) {
+	if ip := net.ParseIP(res.Host); ip != nil {
+		res.Addresses = []string{ip.String()}
+		return res.Addresses, nil
+	}
+	ips, err := c.resolver.LookupIPAddr(ctx, res.Host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		res.Addresses = append(res.Addresses, ip.String())
+	}
+	if len(res.Addresses) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: res.Host, IsNotFound: true}
+	}
+	// The CNAME is context for the model; a failed lookup is not a DNS failure.
+	if cname, err := c.resolver.LookupCNAME(ctx, res.Host); err == nil {
+		if cname = strings.TrimSuffix(cname, "."); !strings.EqualFold(cname, res.Host) {
+			res.CNAME = cname
+		}
+	}
+	return res.Addresses, nil
+}
+
+// netErrorKind classifies a resolve or connect error for the model.
+func netErrorKind(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "not_found"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "unreachable"
+	case errors.As(err, &dnsErr):
+		return "dns_error"
+	default:
+		return "error"
+	}
+}
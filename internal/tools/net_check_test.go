@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers from a fixed table; unknown hosts are NXDOMAIN.
+type fakeResolver struct {
+	addrs  map[string][]string
+	cnames map[string]string
+}
+
+func (f *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addrs, ok := f.addrs[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	out := make([]net.IPAddr, 0, len(addrs))
+	for _, a := range addrs {
+		out = append(out, net.IPAddr{IP: net.ParseIP(a)})
+	}
+	return out, nil
+}
+
+func (f *fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if c, ok := f.cnames[host]; ok {
+		return c, nil
+	}
+	return host + ".", nil
+}
+
+func newTestNetCheck(t *testing.T, targets ...string) *NetCheck {
+	t.Helper()
+	c, err := NewNetCheck(targets)
+	if err != nil {
+		t.Fatalf("NewNetCheck: %v", err)
+	}
+	c.resolver = &fakeResolver{
+		addrs: map[string][]string{
+			"db.example.com":   {"127.0.0.1"},
+			"api.example.com":  {"127.0.0.1"},
+			"meta.example.com": {"169.254.169.254"},
+		},
+		cnames: map[string]string{"api.example.com": "lb.example.net."},
+	}
+	return c
+}
+
+func runNetCheck(t *testing.T, c *NetCheck, params string) netCheckResult {
+	t.Helper()
+	out, err := c.Execute(context.Background(), json.RawMessage(params))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var res netCheckResult
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return res
+}
+
+func TestNetCheck_DNS(t *testing.T) {
+	t.Parallel()
+
+	c := newTestNetCheck(t, "*.example.com")
+
+	res := runNetCheck(t, c, `{"check":"dns","host":"api.example.com"}`)
+	if !res.OK || len(res.Addresses) != 1 || res.Addresses[0] != "127.0.0.1" || res.CNAME != "lb.example.net" {
+		t.Errorf("result = %+v, want resolved via lb.example.net", res)
+	}
+
+	res = runNetCheck(t, c, `{"check":"dns","host":"gone.example.com"}`)
+	if res.OK || res.FailedStage != "dns" || res.ErrorKind != "not_found" {
+		t.Errorf("result = %+v, want dns not_found", res)
+	}
+}
+
+func TestNetCheck_TCP(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	open := ln.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert // tcp listener
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert // tcp listener
+	_ = closed.Close()
+
+	c := newTestNetCheck(t, "db.example.com", "meta.example.com")
+
+	res := runNetCheck(t, c, `{"check":"tcp","host":"db.example.com","port":`+strconv.Itoa(open)+`}`)
+	if !res.OK || res.ConnectedTo != ln.Addr().String() {
+		t.Errorf("result = %+v, want connected to %s", res, ln.Addr())
+	}
+
+	res = runNetCheck(t, c, `{"check":"tcp","host":"db.example.com","port":`+strconv.Itoa(closedPort)+`}`)
+	if res.OK || res.FailedStage != "connect" || res.ErrorKind != "refused" || len(res.Addresses) != 1 {
+		t.Errorf("result = %+v, want connect refused after resolving", res)
+	}
+
+	res = runNetCheck(t, c, `{"check":"tcp","host":"meta.example.com","port":80}`)
+	if res.OK || res.FailedStage != "connect" || !strings.Contains(res.Error, "not allowed") {
+		t.Errorf("result = %+v, want link-local address refused", res)
+	}
+}
+
+func TestNetCheck_RejectsUnlistedTargets(t *testing.T) {
+	t.Parallel()
+
+	c := newTestNetCheck(t, "db.example.com:5432", "*.internal.example.com")
+
+	tests := []struct {
+		name, params, wantErr string
+	}{
+		{"other host", `{"check":"dns","host":"evil.example.net"}`, "not on the net check target list"},
+		{"other port", `{"check":"tcp","host":"db.example.com","port":22}`, "not on the net check target list"},
+		{"wildcard apex", `{"check":"dns","host":"internal.example.com"}`, "not on the net check target list"},
+		{"missing port", `{"check":"tcp","host":"db.example.com"}`, "port must be between"},
+		{"bad check", `{"check":"icmp","host":"db.example.com"}`, "check must be dns or tcp"},
+		{"missing host", `{"check":"dns"}`, "host is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := c.Execute(context.Background(), json.RawMessage(tt.params))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A port-restricted target still allows resolving its host.
+	if res := runNetCheck(t, c, `{"check":"dns","host":"db.example.com"}`); !res.OK {
+		t.Errorf("dns on port-restricted target = %+v, want ok", res)
+	}
+}
+
+func TestNewNetCheck_InvalidTargets(t *testing.T) {
+	t.Parallel()
+
+	for _, targets := range [][]string{nil, {" "}, {"https://db.example.com"}, {"*"}} {
+		if _, err := NewNetCheck(targets); err == nil {
+			t.Errorf("NewNetCheck(%q) succeeded", targets)
+		}
+	}
+}
@@ -36,7 +36,9 @@ func (p *PrometheusQuery) Name() string { return "query_metrics" }
 func (p *PrometheusQuery) Description() string {
 	return `Query Prometheus/Mimir metrics using PromQL. Use this to investigate metric values, 
 check current and historical resource usage, labels that carry metadata, and correlate alert conditions with raw data. 
-Returns instant query results with labels and values.`
+Returns instant query results with labels and values. Queries are checked before they run: counters (_total,
+_count, _sum, _bucket) must be wrapped in rate() or increase(), selectors need a metric name or a narrowing matcher,
+and windows are capped at 7d. A rejected query comes back as an error explaining what to change.`
 }
 
 // Parameters returns the JSON schema for the input parameters required to execute a Prometheus query.
@@ -57,6 +59,26 @@ func (p *PrometheusQuery) Parameters() json.RawMessage {
     }`)
 }
 
+// promOutputSchema describes the slimmed-down result shared by the instant
+// and range query tools.
+const promOutputSchema = `{
+    "type": "object",
+    "properties": {
+        "result_type": {"enum": ["vector", "matrix", "scalar", "string"]},
+        "result_count": {"type": "integer"},
+        "results": {"type": "array"},
+        "truncated": {"type": "boolean"},
+        "query": {"type": "string"},
+        "rewrites": {"type": "array", "items": {"type": "string"}}
+    },
+    "required": ["result_type", "result_count", "results", "truncated"]
+}`
+
+// OutputSchema returns the JSON schema of a query result.
+func (p *PrometheusQuery) OutputSchema() json.RawMessage {
+	return json.RawMessage(promOutputSchema)
+}
+
 // Execute performs the Prometheus query based on the provided parameters, handling HTTP communication and response parsing.
 func (p *PrometheusQuery) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
 	var input struct {
@@ -70,6 +92,10 @@ func (p *PrometheusQuery) Execute(ctx context.Context, params json.RawMessage) (
 	if input.Query == "" {
 		return nil, fmt.Errorf("query is required")
 	}
+	query, rewrites, err := checkPromQL(input.Query)
+	if err != nil {
+		return nil, fmt.Errorf("query rejected: %w", err)
+	}
 
 	u, err := url.Parse(p.endpoint)
 	if err != nil {
@@ -79,7 +105,7 @@ func (p *PrometheusQuery) Execute(ctx context.Context, params json.RawMessage) (
 	u.Path = path.Join(u.Path, "api/v1/query")
 
 	q := u.Query()
-	q.Set("query", input.Query)
+	q.Set("query", query)
 	if input.Time != "" {
 		q.Set("time", input.Time)
 	}
@@ -97,17 +123,17 @@ func (p *PrometheusQuery) Execute(ctx context.Context, params json.RawMessage) (
 	resp, err := p.httpClient.Do(req) //nolint:gosec // G704 - endpoint is set at construction from config, not from tool params.
 	// LLM-controlled inputs (query, start, end, limit) are query-string encoded via url.Values.Set().
 	if err != nil {
-		return nil, fmt.Errorf("prometheus query failed: %w", err)
+		return nil, unavailable(fmt.Errorf("prometheus query failed: %w", err))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20)) // 5 MB
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, unavailable(fmt.Errorf("read response: %w", err))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus returned %d: %s", resp.StatusCode, string(body))
+		return nil, statusError("prometheus", resp.StatusCode, body)
 	}
 
 	// parse and slim down the response so we don't waste context
@@ -140,6 +166,10 @@ func (p *PrometheusQuery) Execute(ctx context.Context, params json.RawMessage) (
 		"results":      results,
 		"truncated":    truncated,
 	}
+	if len(rewrites) > 0 {
+		output["query"] = query
+		output["rewrites"] = rewrites
+	}
 
 	return json.Marshal(output)
 }
@@ -34,7 +34,7 @@ func (p *PrometheusQueryRange) Name() string { return "query_metrics_range" }
 func (p *PrometheusQueryRange) Description() string {
 	return `Query Prometheus/Mimir metrics over a time range using PromQL. Use this to see trends, 
 check how a metric changed over time, and identify when problems started. Returns a series 
-of timestamped values for each matching time series.`
+of timestamped values for each matching time series. The same query checks as query_metrics apply.`
 }
 
 // Parameters returns the JSON schema for the input parameters required to execute a Prometheus range query.
@@ -63,6 +63,11 @@ func (p *PrometheusQueryRange) Parameters() json.RawMessage {
     }`)
 }
 
+// OutputSchema returns the JSON schema of a range query result.
+func (p *PrometheusQueryRange) OutputSchema() json.RawMessage {
+	return json.RawMessage(promOutputSchema)
+}
+
 // Execute performs the Prometheus range query based on the provided parameters, handling HTTP communication and response parsing.
 func (p *PrometheusQueryRange) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
 	var input struct {
@@ -78,6 +83,10 @@ func (p *PrometheusQueryRange) Execute(ctx context.Context, params json.RawMessa
 	if input.Query == "" {
 		return nil, fmt.Errorf("query is required")
 	}
+	query, rewrites, err := checkPromQL(input.Query)
+	if err != nil {
+		return nil, fmt.Errorf("query rejected: %w", err)
+	}
 	if input.Start == "" {
 		return nil, fmt.Errorf("start is required")
 	}
@@ -89,7 +98,7 @@ func (p *PrometheusQueryRange) Execute(ctx context.Context, params json.RawMessa
 	u.Path = path.Join(u.Path, "api/v1/query_range")
 
 	q := u.Query()
-	q.Set("query", input.Query)
+	q.Set("query", query)
 	q.Set("start", input.Start)
 
 	if input.End != "" {
@@ -118,17 +127,17 @@ func (p *PrometheusQueryRange) Execute(ctx context.Context, params json.RawMessa
 	resp, err := p.httpClient.Do(req) //nolint:gosec // G704 - endpoint is set at construction from config, not from tool params.
 	// LLM-controlled inputs (query, start, end, limit) are query-string encoded via url.Values.Set().
 	if err != nil {
-		return nil, fmt.Errorf("prometheus range query failed: %w", err)
+		return nil, unavailable(fmt.Errorf("prometheus range query failed: %w", err))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20)) // 5 MB
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, unavailable(fmt.Errorf("read response: %w", err))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("prometheus returned %d: %s", resp.StatusCode, string(body))
+		return nil, statusError("prometheus", resp.StatusCode, body)
 	}
 
 	var promResp struct {
@@ -159,6 +168,10 @@ func (p *PrometheusQueryRange) Execute(ctx context.Context, params json.RawMessa
 		"results":      results,
 		"truncated":    truncated,
 	}
+	if len(rewrites) > 0 {
+		output["query"] = query
+		output["rewrites"] = rewrites
+	}
 
 	return json.Marshal(output)
 }
@@ -0,0 +1,450 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PromQL guardrails applied to model-written queries before they reach the
+// datasource.
+const (
+	// promqlDefaultLookback is the range added to a range function argument
+	// written without one, e.g. rate(x) becomes rate(x[5m]).
+	promqlDefaultLookback = "5m"
+
+	// promqlMaxLookback caps range and subquery windows.
+	promqlMaxLookback = 7 * 24 * time.Hour
+
+	// promqlMaxRegexLen and promqlMaxAlternatives cap regex matchers, which
+	// the model tends to grow by listing every value it has seen.
+	promqlMaxRegexLen     = 512
+	promqlMaxAlternatives = 50
+)
+
+// promqlRangeFuncs take a range vector as their vector argument.
+var promqlRangeFuncs = map[string]bool{
+	"rate": true, "irate": true, "increase": true, "delta": true, "idelta": true, "deriv": true,
+	"predict_linear": true, "resets": true, "changes": true, "holt_winters": true,
+	"double_exponential_smoothing": true, "avg_over_time": true, "min_over_time": true,
+	"max_over_time": true, "sum_over_time": true, "count_over_time": true, "quantile_over_time": true,
+	"stddev_over_time": true, "stdvar_over_time": true, "last_over_time": true, "present_over_time": true,
+	"absent_over_time": true, "mad_over_time": true,
+}
+
+// promqlCounterSafe are calls under which a raw counter value is meaningful,
+// because only its presence or count matters.
+var promqlCounterSafe = map[string]bool{
+	"count": true, "count_values": true, "group": true, "absent": true, "timestamp": true,
+}
+
+// promqlAggregations may be written with a by/without clause before their
+// arguments.
+var promqlAggregations = map[string]bool{
+	"sum": true, "avg": true, "min": true, "max": true, "count": true, "group": true, "stddev": true,
+	"stdvar": true, "topk": true, "bottomk": true, "quantile": true, "count_values": true,
+	"limitk": true, "limit_ratio": true,
+}
+
+// promqlLabelLists are followed by a parenthesized list of label names.
+var promqlLabelLists = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// promqlKeywords are identifiers that are not metric names.
+var promqlKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "offset": true, "bool": true, "atan2": true,
+	"inf": true, "nan": true, "by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true,
+}
+
+// promqlCounterSuffixes mark metric names that follow the counter naming
+// convention.
+var promqlCounterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// checkPromQL validates a model-written query before it is sent. It returns
+// the query to run, with missing range windows filled in, and a note per
+// rewrite. Errors say what to change, so the model can fix the query instead
+// of retrying it against the datasource. Only the guardrails are checked;
+// other syntax errors are left for Prometheus to report.
+func checkPromQL(query string) (string, []string, error) {
+	toks, err := lexPromQL(query)
+	if err != nil {
+		return "", nil, err
+	}
+	c := &promqlChecker{toks: toks}
+	if err := c.run(); err != nil {
+		return "", nil, err
+	}
+	if len(c.inserts) == 0 {
+		return query, nil, nil
+	}
+	var b strings.Builder
+	last := 0
+	for _, at := range c.inserts {
+		b.WriteString(query[last:at])
+		b.WriteString("[" + promqlDefaultLookback + "]")
+		last = at
+	}
+	b.WriteString(query[last:])
+	return b.String(), c.notes, nil
+}
+
+type promTokenKind int
+
+const (
+	promIdent promTokenKind = iota
+	promString
+	promNumber
+	promPunct
+	promOp
+)
+
+type promToken struct {
+	kind     promTokenKind
+	text     string // for strings, the unquoted value
+	pos, end int
+}
+
+func (t promToken) is(kind promTokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+// lexPromQL splits a query into tokens, failing only on unterminated strings
+// and unbalanced brackets.
+func lexPromQL(q string) ([]promToken, error) {
+	var toks []promToken
+	var depth []byte
+	for i := 0; i < len(q); {
+		ch := q[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '#':
+			for i < len(q) && q[i] != '\n' {
+				i++
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			val, end, ok := scanPromString(q, i)
+			if !ok {
+				return nil, fmt.Errorf("unterminated string starting at offset %d", i)
+			}
+			toks = append(toks, promToken{kind: promString, text: val, pos: i, end: end})
+			i = end
+		case isPromIdentStart(ch):
+			j := i + 1
+			for j < len(q) && (isPromIdentStart(q[j]) || isDigit(q[j])) {
+				j++
+			}
+			toks = append(toks, promToken{kind: promIdent, text: q[i:j], pos: i, end: j})
+			i = j
+		case isDigit(ch) || (ch == '.' && i+1 < len(q) && isDigit(q[i+1])):
+			j := i + 1
+			for j < len(q) && q[j] != ':' && (isDigit(q[j]) || isPromIdentStart(q[j]) || q[j] == '.') {
+				j++
+			}
+			toks = append(toks, promToken{kind: promNumber, text: q[i:j], pos: i, end: j})
+			i = j
+		case strings.IndexByte("(){}[],", ch) >= 0:
+			switch ch {
+			case '(', '{', '[':
+				depth = append(depth, ch)
+			case ')', '}', ']':
+				open := map[byte]byte{')': '(', '}': '{', ']': '['}[ch]
+				if len(depth) == 0 || depth[len(depth)-1] != open {
+					return nil, fmt.Errorf("unbalanced %q at offset %d", ch, i)
+				}
+				depth = depth[:len(depth)-1]
+			}
+			toks = append(toks, promToken{kind: promPunct, text: string(ch), pos: i, end: i + 1})
+			i++
+		default:
+			n := 1
+			if i+1 < len(q) && slices.Contains([]string{"=~", "!~", "!=", "==", ">=", "<="}, q[i:i+2]) {
+				n = 2
+			}
+			toks = append(toks, promToken{kind: promOp, text: q[i : i+n], pos: i, end: i + n})
+			i += n
+		}
+	}
+	if len(depth) > 0 {
+		return nil, fmt.Errorf("unclosed %q", depth[len(depth)-1])
+	}
+	return toks, nil
+}
+
+// scanPromString reads the quoted string starting at q[start], returning its
+// value and the offset just past the closing quote.
+func scanPromString(q string, start int) (string, int, bool) {
+	quote := q[start]
+	var b strings.Builder
+	for i := start + 1; i < len(q); i++ {
+		switch {
+		case q[i] == quote:
+			return b.String(), i + 1, true
+		case q[i] == '\\' && quote != '`' && i+1 < len(q):
+			i++
+			if q[i] != quote && q[i] != '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(q[i])
+		default:
+			b.WriteByte(q[i])
+		}
+	}
+	return "", 0, false
+}
+
+func isPromIdentStart(ch byte) bool {
+	return ch == '_' || ch == ':' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool { return ch >= '0' && ch <= '9' }
+
+type promqlChecker struct {
+	toks []promToken
+	// calls holds the enclosing function or aggregation names, "" for a
+	// plain parenthesized expression.
+	calls   []string
+	inserts []int
+	notes   []string
+}
+
+func (c *promqlChecker) tok(i int) promToken {
+	if i < 0 || i >= len(c.toks) {
+		return promToken{kind: promOp}
+	}
+	return c.toks[i]
+}
+
+func (c *promqlChecker) run() error {
+	pending := ""
+	for i := 0; i < len(c.toks); i++ {
+		t := c.toks[i]
+		next := c.tok(i + 1)
+		switch {
+		case t.kind == promIdent && promqlLabelLists[t.text] && next.is(promPunct, "("):
+			i = c.closing(i + 1)
+		case t.kind == promIdent && promqlAggregations[t.text] && next.kind == promIdent && promqlLabelLists[next.text]:
+			pending = t.text
+		case t.kind == promIdent && next.is(promPunct, "("):
+			c.calls = append(c.calls, t.text)
+			i++
+		case t.kind == promIdent && promqlKeywords[t.text]:
+		case t.kind == promIdent && c.tok(i-1).is(promOp, "@"):
+		case t.kind == promIdent, t.is(promPunct, "{"):
+			end, err := c.selector(i)
+			if err != nil {
+				return err
+			}
+			i = end
+		case t.is(promPunct, "("):
+			c.calls = append(c.calls, pending)
+			pending = ""
+		case t.is(promPunct, ")"):
+			c.calls = c.calls[:len(c.calls)-1]
+		case t.is(promPunct, "["):
+			// A subquery window, [1h:5m] after a parenthesized expression.
+			end, err := c.window(i)
+			if err != nil {
+				return err
+			}
+			i = end
+		}
+	}
+	return nil
+}
+
+// closing returns the index of the bracket closing the one at open.
+func (c *promqlChecker) closing(open int) int {
+	depth := 0
+	for i := open; i < len(c.toks); i++ {
+		switch {
+		case c.toks[i].is(promPunct, "("), c.toks[i].is(promPunct, "{"), c.toks[i].is(promPunct, "["):
+			depth++
+		case c.toks[i].is(promPunct, ")"), c.toks[i].is(promPunct, "}"), c.toks[i].is(promPunct, "]"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(c.toks) - 1
+}
+
+type promMatcher struct {
+	label, op, value string
+}
+
+// selector checks the vector selector starting at token i and returns the
+// index of its last token.
+func (c *promqlChecker) selector(i int) (int, error) {
+	start := i
+	name := ""
+	if c.toks[i].kind == promIdent {
+		name = c.toks[i].text
+		if c.tok(i+1).is(promPunct, "{") {
+			i++
+		}
+	}
+
+	var matchers []promMatcher
+	if c.toks[i].is(promPunct, "{") {
+		closeIdx := c.closing(i)
+		for j := i + 1; j < closeIdx; j++ {
+			t := c.toks[j]
+			switch {
+			case t.kind == promString && (c.tok(j+1).is(promPunct, ",") || j+1 == closeIdx):
+				// A quoted metric name, {"http.server.duration"}.
+				name = t.text
+			case (t.kind == promIdent || t.kind == promString) && c.tok(j+1).kind == promOp && c.tok(j+2).kind == promString:
+				matchers = append(matchers, promMatcher{label: t.text, op: c.tok(j + 1).text, value: c.tok(j + 2).text})
+				j += 2
+			}
+		}
+		i = closeIdx
+	}
+	text := c.text(start, i)
+
+	for _, m := range matchers {
+		if m.label == "__name__" && m.op == "=" {
+			name = m.value
+		}
+		if err := checkPromRegex(m); err != nil {
+			return 0, err
+		}
+	}
+	if name == "" && !hasNarrowMatcher(matchers) {
+		return 0, fmt.Errorf("selector %s matches every series; add a metric name or a label matcher that narrows it", text)
+	}
+
+	hasRange := false
+	if c.tok(i+1).is(promPunct, "[") {
+		end, err := c.window(i + 1)
+		if err != nil {
+			return 0, err
+		}
+		i, hasRange = end, true
+	}
+
+	fn := ""
+	if len(c.calls) > 0 {
+		fn = c.calls[len(c.calls)-1]
+	}
+	if !hasRange && promqlRangeFuncs[fn] && c.isArgument(start, i) {
+		c.inserts = append(c.inserts, c.toks[i].end)
+		c.notes = append(c.notes, fmt.Sprintf("%s() needs a range; ran it over %s[%s]", fn, text, promqlDefaultLookback))
+		hasRange = true
+	}
+
+	if !hasRange && isCounterName(name) && !slices.ContainsFunc(c.calls, func(f string) bool { return promqlCounterSafe[f] }) {
+		return 0, fmt.Errorf("%s is a counter and its raw value only ever grows; use rate(%s[5m]) for a per-second rate or increase(%s[1h]) for the change over a window",
+			name, name, name)
+	}
+	return i, nil
+}
+
+// isArgument reports whether tokens start..end are a whole call argument.
+func (c *promqlChecker) isArgument(start, end int) bool {
+	before, after := c.tok(start-1), c.tok(end+1)
+	return (before.is(promPunct, "(") || before.is(promPunct, ",")) &&
+		(after.is(promPunct, ")") || after.is(promPunct, ",") || after.is(promIdent, "offset") || after.is(promOp, "@"))
+}
+
+// window checks the range or subquery window opening at token i and returns
+// the index of its closing bracket.
+func (c *promqlChecker) window(i int) (int, error) {
+	closeIdx := c.closing(i)
+	if closeIdx > i+1 {
+		tok := c.toks[i+1]
+		if d, ok := parsePromDuration(tok.text); ok && d > promqlMaxLookback {
+			return 0, fmt.Errorf("window [%s] is longer than the 7d maximum; query a shorter window or use query_metrics_range with a larger step", tok.text)
+		}
+	}
+	return closeIdx, nil
+}
+
+func (c *promqlChecker) text(start, end int) string {
+	var b strings.Builder
+	for i := start; i <= end; i++ {
+		t := c.toks[i]
+		if t.kind == promString {
+			b.WriteString(strconv.Quote(t.text))
+		} else {
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// promRegexProbe is a label value no real selector should match; a regex
+// that matches it matches anything.
+const promRegexProbe = "vigil-probe-\x00-value"
+
+// hasNarrowMatcher reports whether any matcher limits the selector to a
+// subset of series.
+func hasNarrowMatcher(ms []promMatcher) bool {
+	for _, m := range ms {
+		switch m.op {
+		case "=":
+			if m.value != "" {
+				return true
+			}
+		case "=~":
+			re, err := regexp.Compile("^(?:" + m.value + ")$")
+			if err != nil || !re.MatchString(promRegexProbe) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkPromRegex(m promMatcher) error {
+	if m.op != "=~" && m.op != "!~" {
+		return nil
+	}
+	if len(m.value) > promqlMaxRegexLen || strings.Count(m.value, "|")+1 > promqlMaxAlternatives {
+		return fmt.Errorf("regex for label %q is too large (%d bytes, %d alternatives; max %d bytes, %d alternatives); match a common prefix or aggregate by the label instead of listing values",
+			m.label, len(m.value), strings.Count(m.value, "|")+1, promqlMaxRegexLen, promqlMaxAlternatives)
+	}
+	return nil
+}
+
+func isCounterName(name string) bool {
+	return slices.ContainsFunc(promqlCounterSuffixes, func(s string) bool { return strings.HasSuffix(name, s) })
+}
+
+// parsePromDuration parses a PromQL duration such as 90s, 1h30m or 2d. A bare
+// number is seconds.
+func parsePromDuration(s string) (time.Duration, bool) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), true
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}
+	var total time.Duration
+	for s != "" {
+		j := 0
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		k := j
+		for k < len(s) && !isDigit(s[k]) {
+			k++
+		}
+		unit, ok := units[s[j:k]]
+		n, err := strconv.Atoi(s[:j])
+		if !ok || err != nil {
+			return 0, false
+		}
+		total += time.Duration(n) * unit
+		s = s[k:]
+	}
+	return total, true
+}
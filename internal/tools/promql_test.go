@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckPromQL(t *testing.T) {
+	t.Parallel()
+
+	longRegex := strings.Repeat("pod-", 200)
+	manyValues := strings.TrimSuffix(strings.Repeat("a|", 60), "|")
+
+	tests := []struct {
+		name      string
+		query     string
+		want      string // rewritten query, empty when unchanged
+		wantErr   string
+		wantNotes int
+	}{
+		{name: "plain metric", query: `up`},
+		{name: "counter with rate", query: `sum by (job) (rate(http_requests_total{job="api"}[5m]))`},
+		{name: "histogram quantile", query: `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket[5m])))`},
+		{name: "counter under count", query: `count by (instance) (node_cpu_seconds_total{mode="idle"})`},
+		{name: "label lists are not selectors", query: `sum without (instance) (up) / on (job) group_left (team) team_info`},
+		{name: "narrow regex", query: `{job=~"api.*"}`},
+		{name: "quoted metric name", query: `{"http.server.duration"}`},
+		{name: "subquery", query: `max_over_time(rate(errors_total[5m])[1h:1m])`},
+		{name: "offset", query: `rate(http_requests_total[5m] offset 1h)`},
+		{
+			name:      "missing range rewritten",
+			query:     `sum(rate(http_requests_total{code=~"5.."}))`,
+			want:      `sum(rate(http_requests_total{code=~"5.."}[5m]))`,
+			wantNotes: 1,
+		},
+		{
+			name:      "missing range before offset",
+			query:     `increase(errors_total offset 1h) + delta(temp_celsius)`,
+			want:      `increase(errors_total[5m] offset 1h) + delta(temp_celsius[5m])`,
+			wantNotes: 2,
+		},
+		{
+			name:      "second argument",
+			query:     `quantile_over_time(0.9, latency_seconds)`,
+			want:      `quantile_over_time(0.9, latency_seconds[5m])`,
+			wantNotes: 1,
+		},
+		{name: "match everything", query: `{__name__=~".+"}`, wantErr: "matches every series"},
+		{name: "only negative matchers", query: `count({job!="api"})`, wantErr: "matches every series"},
+		{name: "regex too long", query: `up{pod=~"` + longRegex + `"}`, wantErr: "too large"},
+		{name: "too many alternatives", query: `up{pod=~"` + manyValues + `"}`, wantErr: "too large"},
+		{name: "raw counter", query: `sum(http_requests_total)`, wantErr: "is a counter"},
+		{name: "raw counter comparison", query: `errors_total > 0`, wantErr: "is a counter"},
+		{name: "window too long", query: `rate(http_requests_total[30d])`, wantErr: "longer than the 7d maximum"},
+		{name: "subquery too long", query: `max_over_time(up[2w:1h])`, wantErr: "longer than the 7d maximum"},
+		{name: "unbalanced", query: `sum(rate(x[5m])`, wantErr: "unclosed"},
+		{name: "unterminated string", query: `up{job="api}`, wantErr: "unterminated string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, notes, err := checkPromQL(tt.query)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := tt.want
+			if want == "" {
+				want = tt.query
+			}
+			if got != want || len(notes) != tt.wantNotes {
+				t.Errorf("checkPromQL = %q, %q; want %q with %d notes", got, notes, want, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestPrometheusQuery_Guardrails(t *testing.T) {
+	t.Parallel()
+
+	var sent []string
+	prom := newTestPrometheus(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.URL.Query().Get("query"))
+		_, _ = fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	})
+
+	if _, err := prom.Execute(context.Background(), json.RawMessage(`{"query":"sum(errors_total)"}`)); err == nil || !strings.Contains(err.Error(), "query rejected") {
+		t.Fatalf("err = %v, want query rejected", err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("rejected query reached prometheus: %q", sent)
+	}
+
+	out, err := prom.Execute(context.Background(), json.RawMessage(`{"query":"rate(errors_total)"}`))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var parsed struct {
+		Query    string   `json:"query"`
+		Rewrites []string `json:"rewrites"`
+	}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "rate(errors_total[5m])" || parsed.Query != sent[0] || len(parsed.Rewrites) != 1 {
+		t.Errorf("sent %q, output %+v; want the rewritten query reported", sent, parsed)
+	}
+}
+
+func FuzzCheckPromQL(f *testing.F) {
+	f.Add(`up`)
+	f.Add(`sum by (job) (rate(http_requests_total{job="api"}[5m]))`)
+	f.Add(`max_over_time(rate(x[5m])[1h:1m]) @ start()`)
+	f.Add(`{__name__=~".+"}`)
+	f.Add(`count(x) by (a) )`)
+	f.Add(`{"quoted"} or on() vector(1)`)
+
+	f.Fuzz(func(_ *testing.T, q string) {
+		// Must not panic
+		_, _, _ = checkPromQL(q)
+	})
+}
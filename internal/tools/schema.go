@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrOutputSchema marks tool output that does not match the tool's declared
+// OutputSchema. The output is withheld from the LLM.
+var ErrOutputSchema = errors.New("tool output does not match its schema")
+
+// OutputContract is implemented by tools that declare the shape of their
+// output. The Registry validates every successful result against the schema
+// and turns a mismatch into an error wrapping ErrOutputSchema, so a malformed
+// data source response is reported rather than handed to the model.
+type OutputContract interface {
+	// OutputSchema returns a JSON Schema for the tool's output. Only type,
+	// enum, properties, required, additionalProperties and items are
+	// enforced; other keywords are accepted and ignored.
+	OutputSchema() json.RawMessage
+}
+
+// schema is a compiled subset of JSON Schema.
+type schema struct {
+	types      []string
+	enum       []any
+	properties map[string]*schema
+	required   []string
+	// additional validates properties not listed in properties; nil allows
+	// anything. noAdditional rejects them outright.
+	additional   *schema
+	noAdditional bool
+	items        *schema
+}
+
+var schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// compileSchema parses raw into a schema.
+func compileSchema(raw json.RawMessage) (*schema, error) {
+	var doc struct {
+		Type                 json.RawMessage            `json:"type"`
+		Enum                 []json.RawMessage          `json:"enum"`
+		Properties           map[string]json.RawMessage `json:"properties"`
+		Required             []string                   `json:"required"`
+		AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+		Items                json.RawMessage            `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	s := &schema{required: doc.Required}
+	if len(doc.Type) > 0 {
+		var one string
+		if err := json.Unmarshal(doc.Type, &one); err == nil {
+			s.types = []string{one}
+		} else if err := json.Unmarshal(doc.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("type: want a string or array of strings")
+		}
+		for _, t := range s.types {
+			if !slices.Contains(schemaTypes, t) {
+				return nil, fmt.Errorf("type: unknown type %q", t)
+			}
+		}
+	}
+	for _, e := range doc.Enum {
+		v, err := decodeJSON(e)
+		if err != nil {
+			return nil, fmt.Errorf("enum: %w", err)
+		}
+		s.enum = append(s.enum, v)
+	}
+	if len(doc.Properties) > 0 {
+		s.properties = make(map[string]*schema, len(doc.Properties))
+		for name, p := range doc.Properties {
+			ps, err := compileSchema(p)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %w", name, err)
+			}
+			s.properties[name] = ps
+		}
+	}
+	if len(doc.AdditionalProperties) > 0 {
+		var allow bool
+		if err := json.Unmarshal(doc.AdditionalProperties, &allow); err == nil {
+			s.noAdditional = !allow
+		} else {
+			as, err := compileSchema(doc.AdditionalProperties)
+			if err != nil {
+				return nil, fmt.Errorf("additionalProperties: %w", err)
+			}
+			s.additional = as
+		}
+	}
+	if len(doc.Items) > 0 {
+		is, err := compileSchema(doc.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		s.items = is
+	}
+	return s, nil
+}
+
+// mustCompileSchema is compileSchema for schemas fixed at build time.
+func mustCompileSchema(name string, raw json.RawMessage) *schema {
+	s, err := compileSchema(raw)
+	if err != nil {
+		panic(fmt.Sprintf("tools: invalid output schema for %s: %v", name, err))
+	}
+	return s
+}
+
+// validate checks that data is a single JSON value matching s.
+func (s *schema) validate(data []byte) error {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("$: %w", err)
+	}
+	return s.check("$", v)
+}
+
+func (s *schema) check(path string, v any) error {
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return isType(v, t) }) {
+		return fmt.Errorf("%s: want %s, got %s", path, strings.Join(s.types, " or "), typeOf(v))
+	}
+	if len(s.enum) > 0 && !slices.ContainsFunc(s.enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		return fmt.Errorf("%s: value %v is not one of the allowed values", path, v)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		// Sorted so the first reported violation is deterministic.
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			ps, ok := s.properties[name]
+			switch {
+			case ok:
+			case s.noAdditional:
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			case s.additional != nil:
+				ps = s.additional
+			default:
+				continue
+			}
+			if err := ps.check(path+"."+name, v[name]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.items == nil {
+			return nil
+		}
+		for i, item := range v {
+			if err := s.items.check(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeJSON decodes exactly one JSON value, keeping numbers as json.Number
+// so integers can be told apart from fractions.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, errors.New("invalid JSON: trailing data after value")
+	}
+	return v, nil
+}
+
+func isType(v any, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	default:
+		return typeOf(v) == t
+	}
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// validatedTool checks a tool's successful output against its schema.
+type validatedTool struct {
+	Tool
+	schema *schema
+}
+
+func (v *validatedTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	out, err := v.Tool.Execute(ctx, params)
+	if err != nil {
+		return out, err
+	}
+	if err := v.schema.validate(out); err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", v.Name(), ErrOutputSchema, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{
+		"type": "object",
+		"properties": {
+			"kind": {"enum": ["vector", "matrix"]},
+			"count": {"type": "integer"},
+			"ratio": {"type": ["number", "null"]},
+			"items": {"type": "array", "items": {"type": "object", "required": ["ts"], "additionalProperties": false, "properties": {"ts": {"type": "string"}}}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"required": ["kind", "count"]
+	}`
+	s, err := compileSchema(json.RawMessage(doc))
+	if err != nil {
+		t.Fatalf("compileSchema: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		data      string
+		errSubstr string
+	}{
+		{name: "minimal", data: `{"kind":"vector","count":3}`},
+		{name: "full", data: `{"kind":"matrix","count":0,"ratio":0.5,"items":[{"ts":"1"}],"labels":{"job":"api"},"extra":true}`},
+		{name: "null in type list", data: `{"kind":"vector","count":1,"ratio":null}`},
+		{name: "whole float is integer", data: `{"kind":"vector","count":2.0}`},
+		{name: "not an object", data: `"oops"`, errSubstr: "$: want object, got string"},
+		{name: "missing required", data: `{"kind":"vector"}`, errSubstr: `missing required property "count"`},
+		{name: "enum", data: `{"kind":"scalar","count":1}`, errSubstr: "$.kind: value scalar is not one of"},
+		{name: "fraction is not integer", data: `{"kind":"vector","count":1.5}`, errSubstr: "$.count: want integer, got number"},
+		{name: "item type", data: `{"kind":"vector","count":1,"items":[{"ts":"1"},{"ts":2}]}`, errSubstr: "$.items[1].ts: want string"},
+		{name: "no additional", data: `{"kind":"vector","count":1,"items":[{"ts":"1","x":1}]}`, errSubstr: `$.items[0]: unexpected property "x"`},
+		{name: "additional schema", data: `{"kind":"vector","count":1,"labels":{"job":1}}`, errSubstr: "$.labels.job: want string"},
+		{name: "invalid json", data: `{"kind":`, errSubstr: "invalid JSON"},
+		{name: "trailing data", data: `{"kind":"vector","count":1} {}`, errSubstr: "trailing data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := s.validate([]byte(tt.data))
+			if tt.errSubstr == "" {
+				if err != nil {
+					t.Fatalf("validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Fatalf("validate = %v, want error containing %q", err, tt.errSubstr)
+			}
+		})
+	}
+}
+
+func TestCompileSchema_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, doc := range []string{
+		`[]`,
+		`{"type":"text"}`,
+		`{"type":7}`,
+		`{"properties":{"a":{"type":"strnig"}}}`,
+		`{"items":{"additionalProperties":{"type":["object","map"]}}}`,
+	} {
+		if _, err := compileSchema(json.RawMessage(doc)); err == nil {
+			t.Errorf("compileSchema(%s) succeeded, want error", doc)
+		}
+	}
+}
+
+// contractTool is a stubTool with an output schema and fixed output.
+type contractTool struct {
+	stubTool
+	schema string
+	output string
+}
+
+func (c *contractTool) OutputSchema() json.RawMessage { return json.RawMessage(c.schema) }
+func (c *contractTool) Execute(context.Context, json.RawMessage) (json.RawMessage, error) {
+	return json.RawMessage(c.output), nil
+}
+
+func TestRegistry_ValidatesOutput(t *testing.T) {
+	t.Parallel()
+
+	const schema = `{"type":"object","required":["ok"]}`
+	r := NewRegistry(WithCircuitBreaker(BreakerConfig{Threshold: 1}))
+	r.Register(&contractTool{stubTool: stubTool{name: "good"}, schema: schema, output: `{"ok":true}`})
+	r.Register(&contractTool{stubTool: stubTool{name: "bad"}, schema: schema, output: `{"status":"error"}`})
+
+	good, _ := r.Get("good")
+	if out, err := good.Execute(context.Background(), nil); err != nil || string(out) != `{"ok":true}` {
+		t.Errorf("good = %s, %v", out, err)
+	}
+
+	bad, _ := r.Get("bad")
+	out, err := bad.Execute(context.Background(), nil)
+	if !errors.Is(err, ErrOutputSchema) || out != nil {
+		t.Fatalf("bad = %s, %v, want ErrOutputSchema and no output", out, err)
+	}
+	// A violation says nothing about the data source being down.
+	if errors.Is(err, ErrUnavailable) || len(r.Unavailable()) != 0 {
+		t.Errorf("violation tripped the breaker: %v, unavailable = %v", err, r.Unavailable())
+	}
+}
+
+func TestRegistry_InvalidOutputSchemaPanics(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register did not panic on an invalid output schema")
+		}
+	}()
+	NewRegistry().Register(&contractTool{stubTool: stubTool{name: "broken"}, schema: `{"type":"text"}`})
+}
+
+func TestPrometheusQuery_OutputSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "vector", body: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"1"]}]}}`},
+		{name: "scalar", body: `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`},
+		// Parsed, but missing the data the model needs to interpret it.
+		{name: "no result type", body: `{"status":"success","data":{}}`, wantErr: true},
+		// Unparsable bodies are passed through raw, which the schema catches.
+		{name: "not json", body: `<html>bad gateway</html>`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRegistry()
+			r.Register(newTestPrometheus(t, func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			tool, _ := r.Get("query_metrics")
+			_, err := tool.Execute(context.Background(), json.RawMessage(`{"query":"up"}`))
+			if got := errors.Is(err, ErrOutputSchema); got != tt.wantErr {
+				t.Fatalf("err = %v, want schema violation %v", err, tt.wantErr)
+			}
+		})
+	}
+}
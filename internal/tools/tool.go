@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"slices"
+	"time"
 )
 
 // Tool is a capability Vigil can offer to the AI during triage.
@@ -22,16 +24,75 @@ type ToolDef struct {
 
 // Registry holds available tools and converts them to the AI API format.
 type Registry struct {
-	tools map[string]Tool
+	tools         map[string]Tool
+	breakers      map[string]*breaker
+	stats         map[string]*callStats
+	outputSchemas map[string]json.RawMessage
+	breaker       *BreakerConfig
+	cache         *resultCache
+	limits        *LimitConfig
+	now           func() time.Time
+}
+
+// RegistryOption configures optional Registry behavior.
+type RegistryOption func(*Registry)
+
+// WithCircuitBreaker gives each registered tool a circuit breaker. A
+// threshold below 1 leaves circuit breaking disabled.
+func WithCircuitBreaker(c BreakerConfig) RegistryOption {
+	return func(r *Registry) {
+		if c.Threshold > 0 {
+			r.breaker = &c
+		}
+	}
 }
 
 // NewRegistry creates an empty tool registry.
-func NewRegistry() *Registry {
-	return &Registry{tools: make(map[string]Tool)}
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		tools:         make(map[string]Tool),
+		breakers:      make(map[string]*breaker),
+		stats:         make(map[string]*callStats),
+		outputSchemas: make(map[string]json.RawMessage),
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// Register adds a tool to the registry, keyed by its Name.
+// Register adds a tool to the registry, keyed by its Name. A tool that
+// implements OutputContract has its output validated against the schema,
+// and Register panics if the schema is invalid. With circuit breaking
+// enabled the stored tool is wrapped so its calls feed the breaker, and
+// with a concurrency limit for the tool its calls wait for a slot. Every
+// tool's recent outcomes are kept for Catalog and ToToolDefs. With the cache
+// enabled, tools with a TTL are served from it, and cache hits count toward
+// neither the breaker nor the outcomes.
 func (r *Registry) Register(t Tool) {
+	if c, ok := t.(OutputContract); ok {
+		r.outputSchemas[t.Name()] = c.OutputSchema()
+		t = &validatedTool{Tool: t, schema: mustCompileSchema(t.Name(), c.OutputSchema())}
+	}
+	if r.limits != nil {
+		if n := r.limits.Limits[t.Name()]; n > 0 {
+			t = &limitedTool{Tool: t, sem: make(chan struct{}, n), onWait: r.limits.OnWait}
+		}
+	}
+	if r.breaker != nil {
+		b := &breaker{name: t.Name(), cfg: r.breaker, now: func() time.Time { return r.now() }}
+		r.breakers[t.Name()] = b
+		t = &guardedTool{Tool: t, b: b}
+	}
+	s := &callStats{}
+	r.stats[t.Name()] = s
+	t = &trackedTool{Tool: t, stats: s}
+	if r.cache != nil {
+		if ttl := r.cache.cfg.ttl(t.Name()); ttl > 0 {
+			t = &cachedTool{Tool: t, cache: r.cache, ttl: ttl}
+		}
+	}
 	r.tools[t.Name()] = t
 }
 
@@ -41,15 +102,33 @@ func (r *Registry) Get(name string) (Tool, bool) {
 	return t, ok
 }
 
-// ToToolDefs returns the tool definitions in Claude API format.
+// ToToolDefs returns the tool definitions in Claude API format, leaving out
+// tools whose circuit breaker is open. Tools that mostly failed recently say
+// so in their description.
 func (r *Registry) ToToolDefs() []ToolDef {
 	out := make([]ToolDef, 0, len(r.tools))
-	for _, t := range r.tools {
+	for name, t := range r.tools {
+		if b := r.breakers[name]; b != nil && !b.available() {
+			continue
+		}
 		out = append(out, ToolDef{
 			Name:        t.Name(),
-			Description: t.Description(),
+			Description: r.describe(name, t),
 			InputSchema: t.Parameters(),
 		})
 	}
 	return out
 }
+
+// Unavailable returns the sorted names of tools currently withheld by their
+// circuit breaker.
+func (r *Registry) Unavailable() []string {
+	var out []string
+	for name, b := range r.breakers {
+		if !b.available() {
+			out = append(out, name)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,212 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// MaxSuggestedActions caps the actions kept from one analysis; the model is
+// asked for the few that matter, and anything past the cap is dropped.
+const MaxSuggestedActions = 5
+
+// SuggestedAction is a remediation the model proposed from the action
+// catalog. It is never run by the service on its own: an operator executes
+// it through the API, at most once, and the outcome is recorded on it.
+type SuggestedAction struct {
+	// Action is the catalog name, such as "restart_unit".
+	Action string            `json:"action"`
+	Params map[string]string `json:"params,omitempty"`
+	// Reason is the model's one-line case for the action.
+	Reason string `json:"reason,omitempty"`
+	// ExecutedAt and ExecutedBy are set when an operator runs the action,
+	// whether or not it succeeds.
+	ExecutedAt time.Time `json:"executed_at,omitzero"`
+	ExecutedBy string    `json:"executed_by,omitempty"`
+	// Output is what the action printed or returned, Error why it failed.
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Executed reports whether the action has been run.
+func (a *SuggestedAction) Executed() bool {
+	return !a.ExecutedAt.IsZero()
+}
+
+// String formats the action for logs and audit details, e.g.
+// "restart_unit unit=nginx.service".
+func (a *SuggestedAction) String() string {
+	var b strings.Builder
+	b.WriteString(a.Action)
+	for _, k := range slices.Sorted(maps.Keys(a.Params)) {
+		fmt.Fprintf(&b, " %s=%s", k, a.Params[k])
+	}
+	return b.String()
+}
+
+// ActionCatalog is the allow-list of remediations the model may suggest and
+// operators may execute.
+type ActionCatalog interface {
+	// Prompt describes the actions and their parameters to the model.
+	Prompt() string
+	// Validate returns why a is not a catalog action with acceptable
+	// parameters, nil if it is.
+	Validate(a SuggestedAction) error
+	// Execute runs a, which has passed Validate, returning its output.
+	Execute(ctx context.Context, a SuggestedAction) (string, error)
+}
+
+// WithActions lets the model suggest remediations from c, which are stored
+// on the result for ExecuteAction. Suggestions outside the catalog are
+// dropped.
+func WithActions(c ActionCatalog) ServiceOption {
+	return func(s *Service) { s.actions = c }
+}
+
+// actionsInstruction introduces the catalog in the system prompt; the
+// catalog's own description follows it.
+const actionsInstruction = `
+
+If one of the remediation actions below would fix or mitigate the problem you found, you may suggest it. Actions are never run automatically: an operator reviews and approves each one. Suggest only actions your evidence supports, at most 5, by ending your analysis with a fenced block tagged vigil-actions holding a JSON array, for example:
+` + "```vigil-actions" + `
+[{"action": "<name>", "params": {"<param>": "<value>"}, "reason": "<one line on why>"}]
+` + "```" + `
+Leave the block out when no action fits. Available actions:
+`
+
+// withActions describes the action catalog to the model.
+func withActions(prompt string) RunOption {
+	return func(c *runConfig) { c.actions = prompt }
+}
+
+// actionsBlock matches a fenced vigil-actions block and the newlines around
+// it.
+var actionsBlock = regexp.MustCompile("(?s)\n*```vigil-actions[ \t]*\n(.*?)\n?```[ \t]*\n?")
+
+// ParseActions returns analysis without its vigil-actions blocks and the
+// actions of the last one, reporting false if there is none or it is not a
+// JSON array of actions.
+func ParseActions(analysis string) (string, []SuggestedAction, bool) {
+	ms := actionsBlock.FindAllStringSubmatchIndex(analysis, -1)
+	if len(ms) == 0 {
+		return analysis, nil, false
+	}
+	last := ms[len(ms)-1]
+	var actions []SuggestedAction
+	err := json.Unmarshal([]byte(analysis[last[2]:last[3]]), &actions)
+	rest := strings.TrimSpace(actionsBlock.ReplaceAllString(analysis, "\n\n"))
+	if err != nil {
+		return rest, nil, false
+	}
+	return rest, actions, true
+}
+
+// suggestActions moves the actions suggested in r's analysis onto r,
+// keeping only those the catalog accepts. Invalid suggestions are logged
+// and dropped.
+func (s *Service) suggestActions(ctx context.Context, logger log.Logger, r *Result) {
+	rest, actions, ok := ParseActions(r.Analysis)
+	r.Analysis = rest
+	if !ok {
+		return
+	}
+	for _, a := range actions {
+		// Execution state only ever comes from an operator.
+		a = SuggestedAction{Action: a.Action, Params: a.Params, Reason: a.Reason}
+		if err := s.actions.Validate(a); err != nil {
+			logger.Warn(ctx, "dropping suggested action", "action", a.String(), "err", err)
+			continue
+		}
+		if len(r.Actions) == MaxSuggestedActions {
+			logger.Warn(ctx, "dropping suggested actions past the limit", "limit", MaxSuggestedActions)
+			break
+		}
+		r.Actions = append(r.Actions, a)
+	}
+	if len(r.Actions) > 0 {
+		logger.Info(ctx, "remediation actions suggested", "actions", len(r.Actions))
+	}
+}
+
+// Errors returned by ExecuteAction.
+var (
+	// ErrActionExecuted is returned for an action that has already run or
+	// is running.
+	ErrActionExecuted = errors.New("action already executed")
+	// ErrActionInvalid is returned for an action the catalog no longer
+	// accepts, such as one removed since it was suggested.
+	ErrActionInvalid = errors.New("action not in catalog")
+)
+
+// ExecuteAction runs the n-th action suggested for triage id on behalf of
+// the API caller and records the outcome on the action and in the audit
+// trail. It reports false if there is no such triage for the caller's tenant,
+// it has no action n, or actions are disabled. An action that fails is still returned, with its
+// Error set; the returned error is for the request itself.
+//
+// An action runs at most once: it is claimed with Store.ClaimAction before
+// it runs, which only one caller across replicas can win, so a crash mid-run
+// leaves it executed rather than runnable again. The result read to find the
+// action may be stale, so the claim and outcome are written to the action
+// alone rather than put back with it.
+func (s *Service) ExecuteAction(ctx context.Context, id string, n int) (*SuggestedAction, bool, error) {
+	if s.actions == nil {
+		return nil, false, nil
+	}
+	r, ok, err := s.Get(ctx, id)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if n < 0 || n >= len(r.Actions) {
+		return nil, false, nil
+	}
+	a := r.Actions[n]
+	if a.Executed() {
+		return nil, false, ErrActionExecuted
+	}
+	if err := s.actions.Validate(a); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrActionInvalid, err)
+	}
+
+	actor := apiActor(ctx)
+	a.ExecutedAt = time.Now()
+	a.ExecutedBy = actor
+	claimed, err := s.store.ClaimAction(ctx, id, n, actor, a.ExecutedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("claim action: %w", err)
+	}
+	if !claimed {
+		return nil, false, ErrActionExecuted
+	}
+	s.audit(ctx, id, r.TenantID, AuditActionApproved, actor, fmt.Sprintf("#%d %s", n, &a))
+
+	// A caller hanging up must not leave the outcome unrecorded; the catalog
+	// bounds how long an action may take.
+	ctx = context.WithoutCancel(ctx)
+	out, err := s.actions.Execute(ctx, a)
+	a.Output = out
+	detail := fmt.Sprintf("#%d %s: ok", n, &a)
+	event := AuditActionExecuted
+	if err != nil {
+		a.Error = err.Error()
+		detail = fmt.Sprintf("#%d %s: %v", n, &a, err)
+		event = AuditActionFailed
+		s.logger.Warn(ctx, "remediation action failed", "triage_id", id, "action", a.String(), "actor", actor, "err", err)
+	} else {
+		s.logger.Info(ctx, "remediation action executed", "triage_id", id, "action", a.String(), "actor", actor)
+	}
+	s.audit(ctx, id, r.TenantID, event, actor, detail)
+
+	if err := s.store.RecordAction(ctx, id, n, a.Output, a.Error); err != nil {
+		return nil, false, fmt.Errorf("record action outcome: %w", err)
+	}
+	return &a, true, nil
+}
@@ -0,0 +1,253 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestParseActions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		analysis string
+		wantRest string
+		want     string
+		wantOK   bool
+	}{
+		{name: "none", analysis: "Disk full.", wantRest: "Disk full."},
+		{
+			name:     "block before verdict",
+			analysis: "Disk full.\n\n```vigil-actions\n[{\"action\": \"clear_cache\", \"params\": {\"cache\": \"pages\"}, \"reason\": \"cache fills the disk\"}]\n```\nVerdict: confidence=0.8 urgent=no",
+			wantRest: "Disk full.\n\nVerdict: confidence=0.8 urgent=no",
+			want:     "clear_cache cache=pages",
+			wantOK:   true,
+		},
+		{
+			name:     "last block wins",
+			analysis: "```vigil-actions\n[{\"action\": \"a\"}]\n```\nOn reflection:\n```vigil-actions\n[{\"action\": \"b\"}, {\"action\": \"c\"}]\n```",
+			wantRest: "On reflection:",
+			want:     "b,c",
+			wantOK:   true,
+		},
+		{
+			name:     "not json",
+			analysis: "Disk full.\n```vigil-actions\nrestart everything\n```",
+			wantRest: "Disk full.",
+		},
+		{name: "other fence kept", analysis: "```json\n[]\n```", wantRest: "```json\n[]\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rest, actions, ok := ParseActions(tt.analysis)
+			names := make([]string, len(actions))
+			for i := range actions {
+				names[i] = actions[i].String()
+			}
+			if rest != tt.wantRest || strings.Join(names, ",") != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseActions = %q, %v, %v; want %q, %q, %v", rest, names, ok, tt.wantRest, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// fakeCatalog accepts actions named in allowed and records what it runs.
+type fakeCatalog struct {
+	allowed map[string]bool
+	fail    error
+
+	mu   sync.Mutex
+	runs []string
+}
+
+func (f *fakeCatalog) Prompt() string { return "- restart_unit: Restart a unit.\n" }
+
+func (f *fakeCatalog) Validate(a SuggestedAction) error {
+	if !f.allowed[a.Action] {
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+	return nil
+}
+
+func (f *fakeCatalog) Execute(_ context.Context, a SuggestedAction) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.runs = append(f.runs, a.String())
+	return "done", f.fail
+}
+
+func TestRunTriage_SuggestedActions(t *testing.T) {
+	t.Parallel()
+
+	analysis := "nginx workers are wedged.\n\n```vigil-actions\n" +
+		`[{"action": "restart_unit", "params": {"unit": "nginx.service"}, "reason": "wedged workers", "executed_at": "2026-01-01T00:00:00Z"},` +
+		`{"action": "rm_rf", "params": {"path": "/"}}]` + "\n```"
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: analysis}},
+		StopReason: StopEnd,
+	}}}
+	store := newMockStore()
+	catalog := &fakeCatalog{allowed: map[string]bool{"restart_unit": true}}
+	svc := NewService(store, NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider(), WithActions(catalog))
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-actions",
+		Labels:      map[string]string{"alertname": "NginxDown"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	r := waitTerminal(t, store, sr.ID)
+	waitForFinish(t, svc, sr.ID)
+
+	if r.Analysis != "nginx workers are wedged." {
+		t.Errorf("analysis = %q, want the actions block removed", r.Analysis)
+	}
+	if len(r.Actions) != 1 || r.Actions[0].String() != "restart_unit unit=nginx.service" || r.Actions[0].Reason != "wedged workers" {
+		t.Fatalf("actions = %+v, want only the catalog action", r.Actions)
+	}
+	if r.Actions[0].Executed() {
+		t.Error("the model marked its own suggestion executed")
+	}
+	if len(catalog.runs) != 0 {
+		t.Errorf("suggested actions ran without approval: %v", catalog.runs)
+	}
+
+	provider.mu.Lock()
+	system := provider.reqs[0].System
+	provider.mu.Unlock()
+	if !strings.Contains(system, "vigil-actions") || !strings.Contains(system, "- restart_unit: Restart a unit.") {
+		t.Errorf("system prompt does not describe the catalog:\n%s", system)
+	}
+}
+
+func TestExecuteAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		n         int
+		allowed   bool
+		fail      error
+		wantOK    bool
+		wantErr   error
+		wantEvent string
+	}{
+		{name: "ok", allowed: true, wantOK: true, wantEvent: AuditActionExecuted},
+		{name: "action fails", allowed: true, fail: errors.New("unit not found"), wantOK: true, wantEvent: AuditActionFailed},
+		{name: "no such action", n: 1, allowed: true},
+		{name: "removed from catalog", wantErr: ErrActionInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMockStore()
+			_ = store.Put(context.Background(), &Result{
+				ID:      "t1",
+				Status:  StatusComplete,
+				Actions: []SuggestedAction{{Action: "restart_unit", Params: map[string]string{"unit": "nginx.service"}}},
+			})
+			al := &fakeAuditLog{}
+			catalog := &fakeCatalog{allowed: map[string]bool{"restart_unit": tt.allowed}, fail: tt.fail}
+			svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider(), WithActions(catalog), WithAuditLog(al))
+
+			a, ok, err := svc.ExecuteAction(context.Background(), "t1", tt.n)
+			if !errors.Is(err, tt.wantErr) || ok != tt.wantOK {
+				t.Fatalf("ExecuteAction = %+v, %v, %v; want ok %v, err %v", a, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if !ok {
+				if len(catalog.runs) != 0 {
+					t.Errorf("ran %v", catalog.runs)
+				}
+				return
+			}
+
+			if !a.Executed() || a.ExecutedBy != "api-token" || a.Output != "done" {
+				t.Errorf("action = %+v, want it executed by api-token with output", a)
+			}
+			if (a.Error != "") != (tt.fail != nil) {
+				t.Errorf("action error = %q, want %v", a.Error, tt.fail)
+			}
+			stored, _, _ := store.Get(context.Background(), "t1")
+			if !stored.Actions[0].Executed() || stored.Actions[0].Error != a.Error {
+				t.Errorf("stored action = %+v, want the outcome recorded", stored.Actions[0])
+			}
+
+			events, _ := al.ListAudit(context.Background(), "t1")
+			if len(events) != 2 || events[0].Event != AuditActionApproved || events[1].Event != tt.wantEvent {
+				t.Fatalf("audit events = %+v, want approved then %s", events, tt.wantEvent)
+			}
+			if want := "#0 restart_unit unit=nginx.service"; !strings.HasPrefix(events[1].Detail, want) || events[1].Actor != "api-token" {
+				t.Errorf("audit detail = %q by %q, want prefix %q", events[1].Detail, events[1].Actor, want)
+			}
+
+			if _, _, err := svc.ExecuteAction(context.Background(), "t1", 0); !errors.Is(err, ErrActionExecuted) {
+				t.Errorf("second ExecuteAction error = %v, want ErrActionExecuted", err)
+			}
+			if len(catalog.runs) != 1 {
+				t.Errorf("action ran %d times, want once", len(catalog.runs))
+			}
+		})
+	}
+}
+
+// staleStore serves Get from a snapshot taken before any action ran, as a
+// lagging replica or the store cache can.
+type staleStore struct {
+	*mockStore
+	snapshot Result
+}
+
+func (s *staleStore) Get(context.Context, string) (*Result, bool, error) {
+	cp := s.snapshot
+	return &cp, true, nil
+}
+
+func TestExecuteAction_StaleRead(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := Result{
+		ID:     "t1",
+		Status: StatusComplete,
+		Actions: []SuggestedAction{
+			{Action: "restart_unit", Params: map[string]string{"unit": "nginx.service"}},
+			{Action: "restart_unit", Params: map[string]string{"unit": "php-fpm.service"}},
+		},
+	}
+	store := &staleStore{mockStore: newMockStore(), snapshot: r}
+	_ = store.Put(ctx, &r)
+	catalog := &fakeCatalog{allowed: map[string]bool{"restart_unit": true}}
+	svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider(), WithActions(catalog))
+
+	for n := range 2 {
+		if _, ok, err := svc.ExecuteAction(ctx, "t1", n); !ok || err != nil {
+			t.Fatalf("ExecuteAction(%d) = %v, %v", n, ok, err)
+		}
+	}
+	// The stale read still shows action 0 runnable; the claim must not.
+	if _, _, err := svc.ExecuteAction(ctx, "t1", 0); !errors.Is(err, ErrActionExecuted) {
+		t.Errorf("ExecuteAction on a stale read = %v, want ErrActionExecuted", err)
+	}
+	if len(catalog.runs) != 2 {
+		t.Errorf("actions ran %d times, want 2", len(catalog.runs))
+	}
+	stored, _, _ := store.mockStore.Get(ctx, "t1")
+	for i, a := range stored.Actions {
+		if !a.Executed() || a.Output != "done" {
+			t.Errorf("stored action %d = %+v, want executed with its output", i, a)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package triage
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Audit events, the lifecycle transitions of a triage.
+const (
+	AuditSubmitted = "submitted"
+	// AuditDuplicate is recorded on the active triage an alert was folded
+	// into by deduplication.
+	AuditDuplicate = "duplicate_skipped"
+	AuditStarted   = "started"
+	AuditCompleted = "completed"
+	AuditFailed    = "failed"
+	AuditCancelled = "cancelled"
+	AuditDeleted   = "deleted"
+	AuditRestored  = "restored"
+	// AuditNotifyGate records whether a notification gate sent or held the
+	// result, with the rule and the reason in the detail.
+	AuditNotifyGate = "notify_gate"
+	// AuditActionApproved records an operator approving a suggested
+	// remediation, before it runs; AuditActionExecuted or AuditActionFailed
+	// follows with the outcome.
+	AuditActionApproved = "action_approved"
+	AuditActionExecuted = "action_executed"
+	AuditActionFailed   = "action_failed"
+)
+
+// Audit actors. Transitions made on behalf of an API caller name the token
+// they authenticated with, see apiActor.
+const (
+	ActorSystem = "system"
+	ActorAdmin  = "admin-token"
+)
+
+// AuditEvent is one entry in a triage's audit trail. Entries are only ever
+// appended, never updated or removed.
+type AuditEvent struct {
+	ID       string `json:"id"`
+	TriageID string `json:"triage_id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Event    string `json:"event"`
+	// Actor is ActorSystem for transitions the service makes on its own,
+	// otherwise the token that asked for it.
+	Actor string `json:"actor"`
+	// Detail adds context where there is any, such as the final status or
+	// the fingerprint of a folded duplicate.
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLog persists audit events.
+type AuditLog interface {
+	RecordAudit(ctx context.Context, e *AuditEvent) error
+	// ListAudit returns the events of a triage, oldest first.
+	ListAudit(ctx context.Context, triageID string) ([]*AuditEvent, error)
+}
+
+// WithAuditLog records every lifecycle transition in a.
+func WithAuditLog(a AuditLog) ServiceOption {
+	return func(s *Service) {
+		s.auditLog = a
+	}
+}
+
+// Audit returns the audit trail of the triage with the given ID, oldest
+// first, reporting false if there is no such triage for the caller's tenant.
+// Deleted triages are not found, though their trail is kept.
+func (s *Service) Audit(ctx context.Context, id string) ([]*AuditEvent, bool, error) {
+	if _, ok, err := s.Get(ctx, id); err != nil || !ok {
+		return nil, false, err
+	}
+	if s.auditLog == nil {
+		return nil, true, nil
+	}
+	events, err := s.auditLog.ListAudit(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	return events, true, nil
+}
+
+// apiActor names the token behind an API call: the global API token for the
+// default tenant, otherwise the tenant's own.
+func apiActor(ctx context.Context) string {
+	if tenant := TenantFrom(ctx); tenant != "" {
+		return "tenant-token:" + tenant
+	}
+	return "api-token"
+}
+
+// audit records event for triage id. A failed write is logged and does not
+// fail the transition.
+func (s *Service) audit(ctx context.Context, id, tenant, event, actor, detail string) {
+	if s.auditLog == nil {
+		return
+	}
+	e := &AuditEvent{
+		ID:        ulid.Make().String(),
+		TriageID:  id,
+		TenantID:  tenant,
+		Event:     event,
+		Actor:     actor,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	}
+	if err := s.auditLog.RecordAudit(ctx, e); err != nil {
+		s.logger.Warn(ctx, "failed to record audit event", "err", err, "triage_id", id, "event", event)
+	}
+}
+
+// auditFinish records the end of a run. Runs that stopped short, such as on
+// max turns or budget, still completed; only failed and error are failures.
+// The detail is the final status either way.
+func (s *Service) auditFinish(ctx context.Context, r *Result) {
+	event := AuditCompleted
+	if r.Status == StatusFailed || r.Status == StatusError {
+		event = AuditFailed
+	}
+	s.audit(ctx, r.ID, r.TenantID, event, ActorSystem, string(r.Status))
+}
+
+// auditRestore records a restore. Restore is not scoped to a tenant, so the
+// triage's own is looked up.
+func (s *Service) auditRestore(ctx context.Context, id string) {
+	if s.auditLog == nil {
+		return
+	}
+	r, ok, err := s.store.Get(ctx, id)
+	if err != nil || !ok {
+		s.logger.Warn(ctx, "failed to record audit event", "err", err, "triage_id", id, "event", AuditRestored)
+		return
+	}
+	s.audit(ctx, id, r.TenantID, AuditRestored, ActorAdmin, "")
+}
@@ -0,0 +1,149 @@
+package triage
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+type fakeAuditLog struct {
+	mu     sync.Mutex
+	events []*AuditEvent
+}
+
+func (f *fakeAuditLog) RecordAudit(_ context.Context, e *AuditEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, e)
+	return nil
+}
+
+func (f *fakeAuditLog) ListAudit(_ context.Context, triageID string) ([]*AuditEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*AuditEvent
+	for _, e := range f.events {
+		if e.TriageID == triageID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestAudit_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	al := &fakeAuditLog{}
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(provider.release)
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithAuditLog(al))
+	ctx := WithTenant(context.Background(), "acme")
+
+	firing := &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-audit",
+		Labels:      map[string]string{"alertname": "DiskFull"},
+	}
+	sr, err := svc.Submit(ctx, firing)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("triage did not start")
+	}
+	if dup, err := svc.Submit(ctx, firing); err != nil || !dup.Skipped || dup.ID != sr.ID {
+		t.Fatalf("second Submit = %+v, %v, want a duplicate of %s", dup, err, sr.ID)
+	}
+	if ok, err := svc.Cancel(ctx, sr.ID); err != nil || !ok {
+		t.Fatalf("Cancel = %v, %v", ok, err)
+	}
+	waitForFinish(t, svc, sr.ID)
+	if ok, err := svc.Delete(ctx, sr.ID); err != nil || !ok {
+		t.Fatalf("Delete = %v, %v", ok, err)
+	}
+	if _, ok, _ := svc.Audit(ctx, sr.ID); ok {
+		t.Error("Audit found a deleted triage")
+	}
+	if ok, err := svc.Restore(context.Background(), sr.ID); err != nil || !ok {
+		t.Fatalf("Restore = %v, %v", ok, err)
+	}
+
+	events, ok, err := svc.Audit(ctx, sr.ID)
+	if err != nil || !ok {
+		t.Fatalf("Audit = %v, %v", ok, err)
+	}
+	type entry struct{ event, actor, detail string }
+	var got []entry
+	for _, e := range events {
+		if e.TenantID != "acme" {
+			t.Errorf("%s tenant = %q, want acme", e.Event, e.TenantID)
+		}
+		got = append(got, entry{e.Event, e.Actor, e.Detail})
+	}
+	want := []entry{
+		{AuditSubmitted, "tenant-token:acme", ""},
+		{AuditStarted, ActorSystem, ""},
+		{AuditDuplicate, "tenant-token:acme", ""},
+		{AuditCancelled, "tenant-token:acme", ""},
+		{AuditFailed, ActorSystem, string(StatusError)},
+		{AuditDeleted, "tenant-token:acme", ""},
+		{AuditRestored, ActorAdmin, ""},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("audit trail =\n%v\nwant\n%v", got, want)
+	}
+
+	// Other tenants cannot read the trail.
+	if _, ok, _ := svc.Audit(context.Background(), sr.ID); ok {
+		t.Error("default tenant read another tenant's audit trail")
+	}
+}
+
+func TestAudit_Completed(t *testing.T) {
+	t.Parallel()
+
+	al := &fakeAuditLog{}
+	store := newMockStore()
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+	}}}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithAuditLog(al))
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-audit-ok",
+		Labels:      map[string]string{"alertname": "DiskFull"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitForFinish(t, svc, sr.ID)
+
+	events, _, err := svc.Audit(context.Background(), sr.ID)
+	if err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Event)
+	}
+	if want := []string{AuditSubmitted, AuditStarted, AuditCompleted}; !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if last := events[len(events)-1]; last.Actor != ActorSystem || last.Detail != string(StatusComplete) {
+		t.Errorf("completed event = %+v", last)
+	}
+}
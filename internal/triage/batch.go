@@ -0,0 +1,21 @@
+package triage
+
+// WithBatch runs alerts whose severity label is one of severities on engine,
+// typically one whose provider sends requests through a batch API. Such
+// triages can take hours, so they stay in StatusInProgress without holding
+// one of the WithMaxConcurrent run slots. A profile with its own Engine
+// overrides this.
+func WithBatch(engine Engine, severities []string) ServiceOption {
+	return func(s *Service) {
+		s.batchEngine = engine
+		s.batchSeverities = make(map[string]bool, len(severities))
+		for _, sev := range severities {
+			s.batchSeverities[sev] = true
+		}
+	}
+}
+
+// batched reports whether an alert of severity runs on the batch engine.
+func (s *Service) batched(severity string) bool {
+	return s.batchEngine != nil && s.batchSeverities[severity]
+}
@@ -0,0 +1,52 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestSubmit_BatchSeverities(t *testing.T) {
+	t.Parallel()
+
+	// The only run slot is held by a critical triage that never finishes.
+	interactive := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(interactive.release)
+	batchProvider := &mockProvider{}
+	notifier := newMockNotifier()
+	svc := NewService(newMockStore(), NewEngine(interactive, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, notifier, noop.NewTracerProvider(),
+		WithMaxConcurrent(1),
+		WithBatch(NewEngine(batchProvider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), []string{"info"}),
+	)
+
+	ctx := context.Background()
+	if _, err := svc.Submit(ctx, &alert.Alert{Status: "firing", Fingerprint: "fp-crit", Labels: map[string]string{"alertname": "Down", "severity": "critical"}}); err != nil {
+		t.Fatalf("Submit critical: %v", err)
+	}
+	<-interactive.started
+
+	sr, err := svc.Submit(ctx, &alert.Alert{Status: "firing", Fingerprint: "fp-info", Labels: map[string]string{"alertname": "CertExpiring", "severity": "info"}})
+	if err != nil {
+		t.Fatalf("Submit info: %v", err)
+	}
+	select {
+	case <-notifier.called:
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch triage did not complete while the run slot was taken")
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if notifier.last.ID != sr.ID || notifier.last.Status != StatusComplete {
+		t.Errorf("notified %s %s, want %s complete", notifier.last.ID, notifier.last.Status, sr.ID)
+	}
+	if len(batchProvider.reqs) != 1 {
+		t.Errorf("batch provider calls = %d, want 1", len(batchProvider.reqs))
+	}
+}
@@ -0,0 +1,75 @@
+package triage
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// Alert annotations that override the run budget for one alert.
+const (
+	AnnotationMaxToolRounds   = "vigil.io/max-tool-rounds"
+	AnnotationMaxInputTokens  = "vigil.io/max-input-tokens"
+	AnnotationMaxOutputTokens = "vigil.io/max-output-tokens"
+)
+
+// AnnotationToolCache set to "off" makes every tool call of the alert's
+// triage skip the tool result cache, for alerts that must see live data.
+const AnnotationToolCache = "vigil.io/tool-cache"
+
+// BudgetCeiling caps budgets set through annotations and flags, so one
+// alert rule cannot buy an unbounded investigation.
+var BudgetCeiling = Budget{
+	ToolCalls:    100,
+	InputTokens:  2_000_000,
+	OutputTokens: 500_000,
+}
+
+// WithDefaultBudget sets the limits for runs that don't override them. Zero
+// fields keep MaxToolRounds, MaxInputTokens and MaxOutputTokens.
+func WithDefaultBudget(b Budget) EngineOption {
+	return func(e *LLMEngine) { e.budget = b }
+}
+
+// or fills zero fields of b from d.
+func (b Budget) or(d Budget) Budget {
+	if b.ToolCalls <= 0 {
+		b.ToolCalls = d.ToolCalls
+	}
+	if b.InputTokens <= 0 {
+		b.InputTokens = d.InputTokens
+	}
+	if b.OutputTokens <= 0 {
+		b.OutputTokens = d.OutputTokens
+	}
+	return b
+}
+
+// withBudgetOverride replaces only the non-zero fields of the run budget,
+// keeping whatever a profile or the noise downgrade set for the rest.
+func withBudgetOverride(b Budget) RunOption {
+	return func(c *runConfig) { c.budget = b.or(c.budget) }
+}
+
+// annotationBudget reads the budget annotations of al, clamping each value
+// to 1..BudgetCeiling. It returns the names of annotations that are not
+// integers, which are ignored.
+func annotationBudget(al *alert.Alert) (b Budget, invalid []string) {
+	read := func(name string, ceiling int) int {
+		v, ok := al.Annotations[name]
+		if !ok {
+			return 0
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			invalid = append(invalid, name)
+			return 0
+		}
+		return min(max(n, 1), ceiling)
+	}
+	b.ToolCalls = read(AnnotationMaxToolRounds, BudgetCeiling.ToolCalls)
+	b.InputTokens = read(AnnotationMaxInputTokens, BudgetCeiling.InputTokens)
+	b.OutputTokens = read(AnnotationMaxOutputTokens, BudgetCeiling.OutputTokens)
+	return b, invalid
+}
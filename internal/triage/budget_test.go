@@ -0,0 +1,132 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+func TestAnnotationBudget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        Budget
+		wantInvalid []string
+	}{
+		{name: "none", annotations: map[string]string{"summary": "x"}},
+		{
+			name:        "all set",
+			annotations: map[string]string{AnnotationMaxToolRounds: "25", AnnotationMaxInputTokens: " 400000 ", AnnotationMaxOutputTokens: "10000"},
+			want:        Budget{ToolCalls: 25, InputTokens: 400000, OutputTokens: 10000},
+		},
+		{
+			name:        "clamped",
+			annotations: map[string]string{AnnotationMaxToolRounds: "1000", AnnotationMaxOutputTokens: "0"},
+			want:        Budget{ToolCalls: BudgetCeiling.ToolCalls, OutputTokens: 1},
+		},
+		{
+			name:        "not an integer",
+			annotations: map[string]string{AnnotationMaxToolRounds: "lots", AnnotationMaxInputTokens: "5000"},
+			want:        Budget{InputTokens: 5000},
+			wantInvalid: []string{AnnotationMaxToolRounds},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, invalid := annotationBudget(&alert.Alert{Annotations: tt.annotations})
+			if got != tt.want {
+				t.Errorf("budget = %+v, want %+v", got, tt.want)
+			}
+			if !slices.Equal(invalid, tt.wantInvalid) {
+				t.Errorf("invalid = %v, want %v", invalid, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+// loopingEngine returns an engine whose provider calls a tool on every turn.
+func loopingEngine(opts ...EngineOption) *LLMEngine {
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "loop_tool", output: json.RawMessage(`"ok"`)})
+	responses := make([]*LLMResponse, BudgetCeiling.ToolCalls)
+	for i := range responses {
+		responses[i] = &LLMResponse{
+			Content:    []ContentBlock{{Type: "tool_use", ID: fmt.Sprintf("call-%d", i), Name: "loop_tool", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+			Usage:      Usage{InputTokens: 10, OutputTokens: 5},
+		}
+	}
+	return NewEngine(&mockProvider{responses: responses}, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), opts...)
+}
+
+func TestRun_DefaultBudget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []RunOption
+		wantCalls int
+	}{
+		{name: "engine default", wantCalls: 4},
+		{name: "other fields overridden", opts: []RunOption{WithBudget(Budget{InputTokens: 1000})}, wantCalls: 4},
+		{name: "run budget", opts: []RunOption{WithBudget(Budget{ToolCalls: 2})}, wantCalls: 2},
+		{name: "override over run budget", opts: []RunOption{WithBudget(Budget{ToolCalls: 2}), withBudgetOverride(Budget{ToolCalls: 6})}, wantCalls: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine := loopingEngine(WithDefaultBudget(Budget{ToolCalls: 4}))
+			rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil, tt.opts...)
+			if rr.Status != StatusMaxTurns || rr.ToolCalls != tt.wantCalls {
+				t.Errorf("result = %s after %d tool calls, want max_turns after %d", rr.Status, rr.ToolCalls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSubmit_AnnotationBudget(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	svc := NewService(store, loopingEngine(), log.Nop(), nil, nil, noop.NewTracerProvider())
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-deep",
+		Labels:      map[string]string{"alertname": "Important"},
+		Annotations: map[string]string{AnnotationMaxToolRounds: "25"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r, ok, _ := store.Get(context.Background(), sr.ID)
+		if ok && r.Status.IsTerminal() {
+			if r.Status != StatusMaxTurns || r.ToolCalls != 25 {
+				t.Errorf("result = %s with %d tool calls, want max_turns after 25", r.Status, r.ToolCalls)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("triage did not complete within deadline")
+}
@@ -0,0 +1,261 @@
+package triage
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrFingerprintMismatch is returned by Compare when the two triages are not
+// for the same alert.
+var ErrFingerprintMismatch = errors.New("triages have different fingerprints")
+
+// metricTools are the tools whose queries are compared as metric findings.
+var metricTools = map[string]bool{"query_metrics": true, "query_metrics_range": true}
+
+// Comparison is a structured diff between two triages of the same alert.
+// Deltas are other minus base, so positive numbers mean the later run cost more.
+type Comparison struct {
+	Fingerprint string           `json:"fingerprint"`
+	Base        ComparedRun      `json:"base"`
+	Other       ComparedRun      `json:"other"`
+	RootCause   RootCauseDiff    `json:"root_cause"`
+	Metrics     []MetricFinding  `json:"metrics"`
+	Tools       ListDiff         `json:"tools"`
+	Deltas      ComparisonDeltas `json:"deltas"`
+}
+
+// ComparedRun identifies one side of a comparison.
+type ComparedRun struct {
+	ID        string    `json:"id"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	Model     string    `json:"model,omitempty"`
+}
+
+// RootCauseDiff compares the root cause sections of the two analyses. When an
+// analysis has no recognizable root cause section the whole analysis is used.
+type RootCauseDiff struct {
+	Changed bool   `json:"changed"`
+	Base    string `json:"base"`
+	Other   string `json:"other"`
+	// Similarity is the Jaccard similarity of the two texts' word sets, from
+	// 0 (nothing in common) to 1 (same words).
+	Similarity float64 `json:"similarity"`
+}
+
+// MetricFinding compares one metric query across the two runs. A nil side
+// means that run did not issue the query. Changed tracks whether the query
+// started or stopped returning data or errors; sample values drift between
+// any two runs, so they are reported but not compared.
+type MetricFinding struct {
+	Query   string        `json:"query"`
+	Changed bool          `json:"changed"`
+	Base    *MetricResult `json:"base,omitempty"`
+	Other   *MetricResult `json:"other,omitempty"`
+}
+
+// MetricResult summarizes what a metric query returned.
+type MetricResult struct {
+	Series int  `json:"series"`
+	Error  bool `json:"error,omitempty"`
+	// Value is set for instant queries that returned exactly one sample.
+	Value string `json:"value,omitempty"`
+}
+
+// ListDiff reports entries only present on one side.
+type ListDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// ComparisonDeltas are the resource differences between the runs.
+type ComparisonDeltas struct {
+	Duration  float64 `json:"duration_seconds"`
+	LLMTime   float64 `json:"llm_time_seconds"`
+	ToolTime  float64 `json:"tool_time_seconds"`
+	TokensIn  int     `json:"tokens_in"`
+	TokensOut int     `json:"tokens_out"`
+	ToolCalls int     `json:"tool_calls"`
+}
+
+// Compare diffs two triages of the same fingerprint.
+func Compare(base, other *Result) (*Comparison, error) {
+	if base.Fingerprint != other.Fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+
+	c := &Comparison{
+		Fingerprint: base.Fingerprint,
+		Base:        ComparedRun{ID: base.ID, Status: base.Status, CreatedAt: base.CreatedAt, Model: base.Model},
+		Other:       ComparedRun{ID: other.ID, Status: other.Status, CreatedAt: other.CreatedAt, Model: other.Model},
+		Tools:       diffLists(base.ToolsUsed, other.ToolsUsed),
+		Deltas: ComparisonDeltas{
+			Duration:  other.Duration - base.Duration,
+			LLMTime:   other.LLMTime - base.LLMTime,
+			ToolTime:  other.ToolTime - base.ToolTime,
+			TokensIn:  other.TokensIn - base.TokensIn,
+			TokensOut: other.TokensOut - base.TokensOut,
+			ToolCalls: other.ToolCalls - base.ToolCalls,
+		},
+	}
+
+	bc, oc := RootCause(base.Analysis), RootCause(other.Analysis)
+	c.RootCause = RootCauseDiff{
+		Changed:    normalizeText(bc) != normalizeText(oc),
+		Base:       bc,
+		Other:      oc,
+		Similarity: jaccard(bc, oc),
+	}
+
+	bm, om := metricResults(base.Conversation), metricResults(other.Conversation)
+	queries := make([]string, 0, len(bm)+len(om))
+	for q := range bm {
+		queries = append(queries, q)
+	}
+	for q := range om {
+		if _, ok := bm[q]; !ok {
+			queries = append(queries, q)
+		}
+	}
+	slices.Sort(queries)
+	c.Metrics = make([]MetricFinding, 0, len(queries))
+	for _, q := range queries {
+		f := MetricFinding{Query: q, Base: bm[q], Other: om[q]}
+		f.Changed = f.Base == nil || f.Other == nil || f.Base.Series != f.Other.Series || f.Base.Error != f.Other.Error
+		c.Metrics = append(c.Metrics, f)
+	}
+	return c, nil
+}
+
+// rootCauseHeading matches the line that opens the root cause section, in the
+// numbered or markdown heading styles the system prompt tends to produce.
+var rootCauseHeading = regexp.MustCompile(`(?im)^\s*(?:#+\s*|\d+[.)]\s*|\*\*)*\s*(?:likely\s+)?root\s+cause\b[^\n]*$`)
+
+// sectionStart matches the start of the next section after the root cause.
+var sectionStart = regexp.MustCompile(`(?m)^\s*(?:#+\s+|\d+[.)]\s+|\*\*[^*\n]+\*\*\s*:?\s*$)`)
+
+// RootCause extracts the root cause section of an analysis, falling back to
+// the whole analysis.
+func RootCause(analysis string) string {
+	loc := rootCauseHeading.FindStringIndex(analysis)
+	if loc == nil {
+		return strings.TrimSpace(analysis)
+	}
+	heading := analysis[loc[0]:loc[1]]
+	rest := analysis[loc[1]:]
+	if end := sectionStart.FindStringIndex(rest); end != nil {
+		rest = rest[:end[0]]
+	}
+	// Text on the heading line itself ("2. Likely root cause: disk full").
+	if _, after, ok := strings.Cut(heading, ":"); ok {
+		rest = after + "\n" + rest
+	}
+	if s := strings.TrimSpace(strings.Trim(strings.TrimSpace(rest), "*")); s != "" {
+		return s
+	}
+	return strings.TrimSpace(analysis)
+}
+
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}_.:/-]+`)
+
+func jaccard(a, b string) float64 {
+	wa, wb := wordSet(a), wordSet(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	inter := 0
+	for w := range wa {
+		if wb[w] {
+			inter++
+		}
+	}
+	union := len(wa) + len(wb) - inter
+	return float64(inter) / float64(union)
+}
+
+func wordSet(s string) map[string]bool {
+	out := make(map[string]bool)
+	for _, w := range wordRe.FindAllString(strings.ToLower(s), -1) {
+		out[w] = true
+	}
+	return out
+}
+
+// metricOutput is the subset of the Prometheus tool output that findings use.
+type metricOutput struct {
+	ResultType  string `json:"result_type"`
+	ResultCount int    `json:"result_count"`
+	Results     []struct {
+		Value [2]any `json:"value"`
+	} `json:"results"`
+}
+
+// metricResults maps each metric query in a conversation to the result of its
+// last execution.
+func metricResults(conv *Conversation) map[string]*MetricResult {
+	out := make(map[string]*MetricResult)
+	if conv == nil {
+		return out
+	}
+	queries := make(map[string]string) // tool_use ID -> query
+	for _, turn := range conv.Turns {
+		for _, b := range turn.Content {
+			switch {
+			case b.Type == "tool_use" && metricTools[b.Name]:
+				var in struct {
+					Query string `json:"query"`
+				}
+				if json.Unmarshal(b.Input, &in) == nil && in.Query != "" {
+					queries[b.ID] = strings.TrimSpace(in.Query)
+				}
+			case b.Type == "tool_result":
+				q, ok := queries[b.ToolUseID]
+				if !ok {
+					continue
+				}
+				out[q] = summarizeMetricOutput(b)
+			}
+		}
+	}
+	return out
+}
+
+func summarizeMetricOutput(b ContentBlock) *MetricResult {
+	if b.IsError {
+		return &MetricResult{Error: true}
+	}
+	var mo metricOutput
+	if err := json.Unmarshal([]byte(b.Content), &mo); err != nil {
+		return &MetricResult{}
+	}
+	r := &MetricResult{Series: mo.ResultCount}
+	if mo.ResultType == "vector" && mo.ResultCount == 1 && len(mo.Results) == 1 {
+		if v, ok := mo.Results[0].Value[1].(string); ok {
+			r.Value = v
+		}
+	}
+	return r
+}
+
+func diffLists(base, other []string) ListDiff {
+	d := ListDiff{Added: []string{}, Removed: []string{}}
+	for _, s := range other {
+		if !slices.Contains(base, s) {
+			d.Added = append(d.Added, s)
+		}
+	}
+	for _, s := range base {
+		if !slices.Contains(other, s) {
+			d.Removed = append(d.Removed, s)
+		}
+	}
+	return d
+}
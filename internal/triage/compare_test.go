@@ -0,0 +1,145 @@
+package triage
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func metricConv(calls ...[3]string) *Conversation {
+	conv := &Conversation{}
+	for i, c := range calls {
+		id := string(rune('a' + i))
+		conv.Turns = append(conv.Turns,
+			Turn{Role: "assistant", Content: []ContentBlock{{
+				Type: "tool_use", ID: id, Name: c[0], Input: json.RawMessage(`{"query":` + mustJSON(c[1]) + `}`),
+			}}},
+			Turn{Role: "user", Content: []ContentBlock{{
+				Type: "tool_result", ToolUseID: id, Content: c[2], IsError: c[2] == "error",
+			}}},
+		)
+	}
+	return conv
+}
+
+func mustJSON(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	base := &Result{
+		ID: "a", Fingerprint: "fp", Status: StatusComplete,
+		Analysis:  "1. What is happening\nCPU is high.\n\n2. Likely root cause\nA runaway cron job on web-1.\n\n3. Recommended actions\nKill it.",
+		ToolsUsed: []string{"query_logs", "query_metrics"},
+		Duration:  10, TokensIn: 1000, TokensOut: 200, ToolCalls: 3,
+		Conversation: metricConv(
+			[3]string{"query_metrics", "up", `{"result_type":"vector","result_count":1,"results":[{"metric":{},"value":[1,"1"]}]}`},
+			[3]string{"query_metrics", "node_load1", `{"result_type":"vector","result_count":2,"results":[]}`},
+			[3]string{"query_logs", "{job=\"cron\"}", `{}`},
+		),
+	}
+	other := &Result{
+		ID: "b", Fingerprint: "fp", Status: StatusComplete,
+		Analysis:  "**Root cause:** Memory pressure from the cache service on web-1.\n\n**Recommended actions**\nRestart the cache.",
+		ToolsUsed: []string{"query_metrics", "query_metrics_range"},
+		Duration:  14.5, TokensIn: 1500, TokensOut: 150, ToolCalls: 2,
+		Conversation: metricConv(
+			[3]string{"query_metrics", "up", `{"result_type":"vector","result_count":1,"results":[{"metric":{},"value":[2,"0"]}]}`},
+			[3]string{"query_metrics_range", "rate(cpu[5m])", "error"},
+		),
+	}
+
+	c, err := Compare(base, other)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+
+	if !c.RootCause.Changed {
+		t.Error("root cause should be changed")
+	}
+	if c.RootCause.Base != "A runaway cron job on web-1." {
+		t.Errorf("base root cause = %q", c.RootCause.Base)
+	}
+	if c.RootCause.Other != "Memory pressure from the cache service on web-1." {
+		t.Errorf("other root cause = %q", c.RootCause.Other)
+	}
+	if c.RootCause.Similarity <= 0 || c.RootCause.Similarity >= 1 {
+		t.Errorf("similarity = %v, want between 0 and 1", c.RootCause.Similarity)
+	}
+
+	if c.Deltas.Duration != 4.5 || c.Deltas.TokensIn != 500 || c.Deltas.TokensOut != -50 || c.Deltas.ToolCalls != -1 {
+		t.Errorf("deltas = %+v", c.Deltas)
+	}
+	if len(c.Tools.Added) != 1 || c.Tools.Added[0] != "query_metrics_range" || len(c.Tools.Removed) != 1 || c.Tools.Removed[0] != "query_logs" {
+		t.Errorf("tools = %+v", c.Tools)
+	}
+
+	byQuery := make(map[string]MetricFinding)
+	for _, f := range c.Metrics {
+		byQuery[f.Query] = f
+	}
+	if len(c.Metrics) != 3 {
+		t.Fatalf("metrics = %+v, want 3 queries (logs excluded)", c.Metrics)
+	}
+	if f := byQuery["up"]; f.Changed || f.Base.Value != "1" || f.Other.Value != "0" {
+		t.Errorf("up finding = %+v base=%+v other=%+v", f, f.Base, f.Other)
+	}
+	if f := byQuery["node_load1"]; !f.Changed || f.Other != nil || f.Base.Series != 2 {
+		t.Errorf("node_load1 finding = %+v", f)
+	}
+	if f := byQuery["rate(cpu[5m])"]; !f.Changed || f.Base != nil || !f.Other.Error {
+		t.Errorf("rate finding = %+v", f)
+	}
+}
+
+func TestCompare_SameRootCause(t *testing.T) {
+	t.Parallel()
+
+	a := &Result{Fingerprint: "fp", Analysis: "Root cause: disk full on db-1"}
+	b := &Result{Fingerprint: "fp", Analysis: "root cause:   Disk full on db-1\n"}
+	c, err := Compare(a, b)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if c.RootCause.Changed || c.RootCause.Similarity != 1 {
+		t.Errorf("root cause = %+v, want unchanged", c.RootCause)
+	}
+	if c.Metrics == nil || c.Tools.Added == nil {
+		t.Error("empty lists should encode as arrays, not null")
+	}
+}
+
+func TestCompare_FingerprintMismatch(t *testing.T) {
+	t.Parallel()
+
+	_, err := Compare(&Result{Fingerprint: "a"}, &Result{Fingerprint: "b"})
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("err = %v, want ErrFingerprintMismatch", err)
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		analysis string
+		want     string
+	}{
+		{name: "no section", analysis: "  Everything is fine.  ", want: "Everything is fine."},
+		{name: "markdown heading", analysis: "## Summary\nx\n## Root Cause\nBad deploy.\n## Actions\nRoll back.", want: "Bad deploy."},
+		{name: "inline", analysis: "2. Likely root cause: OOM kills\n3. Recommended actions", want: "OOM kills"},
+		{name: "last section", analysis: "1. Happening\ny\n2. Root cause\nNetwork partition.", want: "Network partition."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := RootCause(tt.analysis); got != tt.want {
+				t.Errorf("rootCause = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
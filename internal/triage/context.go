@@ -0,0 +1,150 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// MaxSummaries is how many times one triage's conversation is summarized
+// to stay within the context window.
+const MaxSummaries = 2
+
+// Token estimate methods, the method label of vigil_llm_token_estimate_ratio.
+const (
+	EstimateLocal       = "local"
+	EstimateCountTokens = "count_tokens"
+)
+
+// TokenCounter counts the input tokens of a request without sending it,
+// such as through Anthropic's count_tokens endpoint.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *LLMRequest) (int, error)
+}
+
+// WithContextWindow checks before each LLM call whether the request would
+// come within a tenth of a context window of tokens, counting the response
+// it asks for. If so, the conversation is first summarized by the model and
+// the summary sent in its place. Values below 1 disable the check.
+func WithContextWindow(tokens int) EngineOption {
+	return func(e *LLMEngine) { e.contextWindow = max(tokens, 0) }
+}
+
+// WithTokenCounter counts each request's input tokens with c, rather than
+// estimating them from its size, for the context window check, the rate
+// limiter and the estimate drift metric. A failed count falls back to the
+// estimate.
+func WithTokenCounter(c TokenCounter) EngineOption {
+	return func(e *LLMEngine) { e.counter = c }
+}
+
+// countTokens returns the input tokens req is expected to use and how they
+// were arrived at.
+func (e *LLMEngine) countTokens(ctx context.Context, logger log.Logger, req *LLMRequest) (int, string) {
+	if e.counter != nil {
+		n, err := e.counter.CountTokens(ctx, req)
+		if err == nil {
+			return n, EstimateCountTokens
+		}
+		if ctx.Err() == nil {
+			logger.Warn(ctx, "token count failed, estimating locally", "err", err)
+		}
+	}
+	return estimateInputTokens(req), EstimateLocal
+}
+
+// nearContextWindow reports whether a request of inputTokens leaves less
+// than a tenth of the context window spare once its response is counted.
+func (e *LLMEngine) nearContextWindow(req *LLMRequest, inputTokens int) bool {
+	return e.contextWindow > 0 && inputTokens+req.MaxTokens > e.contextWindow*9/10
+}
+
+// summarizeNudge asks for the summary that replaces the conversation, and
+// summaryNudge continues the investigation from it.
+const (
+	summarizeNudge = "This conversation is close to the context limit and will be replaced by your summary. Without calling tools, " +
+		"summarize the investigation so far: what you checked, the key values and errors you found with their sources, " +
+		"what you ruled out, and what you still mean to check. Be specific, as the raw tool output will no longer be available."
+	summaryNudge = "The conversation above was summarized to fit the context limit. Continue the investigation from the summary."
+)
+
+var errEmptySummary = errors.New("summary response has no text")
+
+// summarize asks the model, without tools, for a summary of the
+// conversation in req. It is an ordinary LLM call for rate limits, hooks and
+// observers.
+func (e *LLMEngine) summarize(ctx context.Context, triageID string, al *alert.Alert, req *LLMRequest, seq, estInput int) (*LLMResponse, float64, error) {
+	sreq := *req
+	last := sreq.Messages[len(sreq.Messages)-1]
+	last.Content = append(slices.Clip(last.Content), ContentBlock{Type: "text", Text: summarizeNudge})
+	sreq.Messages = append(slices.Clone(sreq.Messages[:len(sreq.Messages)-1]), last)
+	sreq.ToolChoice = ToolChoice{Type: ToolChoiceNone}
+
+	ctx, span := e.tracer.Start(ctx, "llm.summarize", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.operation.name", "llm.summarize"),
+		attribute.String("gen_ai.provider.name", "anthropic"),
+		attribute.String("vigil.triage.id", triageID),
+		attribute.Int("vigil.chat.seq", seq),
+		attribute.Int("vigil.llm.estimated_input_tokens", estInput),
+	))
+	defer span.End()
+
+	err := e.waitForCapacity(ctx, span, estInput)
+	start := time.Now()
+	var resp *LLMResponse
+	if err == nil {
+		resp, err = e.provider.Send(ctx, &sreq)
+	}
+	dur := time.Since(start)
+	call := &LLMCall{TriageID: triageID, Alert: al, Seq: seq, Request: &sreq, Response: resp, Err: err, Duration: dur}
+	e.observe(ctx, call)
+	if err == nil && strings.TrimSpace(responseText(resp)) == "" {
+		err = errEmptySummary
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, dur.Seconds(), err
+	}
+	if e.limiter != nil {
+		e.limiter.Record(estInput, resp.Usage)
+	}
+	e.hooks.llmCall(ctx, resp.Usage.InputTokens, resp.Usage.OutputTokens, dur.Seconds())
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.Int("gen_ai.usage.input_tokens", resp.Usage.InputTokens),
+		attribute.Int("gen_ai.usage.output_tokens", resp.Usage.OutputTokens),
+	)
+	span.SetStatus(codes.Ok, "")
+	return resp, dur.Seconds(), nil
+}
+
+// summarizedMessages replaces everything after the initial prompt with the
+// summary, followed by nudge.
+func summarizedMessages(messages []Message, summary, nudge string) []Message {
+	return []Message{
+		messages[0],
+		{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: summary}}},
+		{Role: "user", Content: []ContentBlock{{Type: "text", Text: nudge}}},
+	}
+}
+
+// responseText joins the text blocks of resp.
+func responseText(resp *LLMResponse) string {
+	var parts []string
+	for _, b := range resp.Content {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,123 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// fakeCounter counts a request as large once it carries a tool result, or
+// fails when err is set.
+type fakeCounter struct {
+	err error
+}
+
+func (f fakeCounter) CountTokens(_ context.Context, req *LLMRequest) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	for _, m := range req.Messages {
+		for _, b := range m.Content {
+			if b.Type == "tool_result" {
+				return 15000, nil
+			}
+		}
+	}
+	return 2000, nil
+}
+
+type estimate struct {
+	method            string
+	estimated, actual int
+}
+
+func TestRun_SummarizesNearContextWindow(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_logs", output: json.RawMessage(`"disk full"`)})
+	provider := &mockProvider{responses: []*LLMResponse{
+		{Content: []ContentBlock{{Type: "tool_use", ID: "call-1", Name: "query_logs", Input: json.RawMessage(`{}`)}}, StopReason: StopToolUse, Usage: Usage{InputTokens: 1900}},
+		{Content: []ContentBlock{{Type: "text", Text: "Logs show the disk is full."}}, StopReason: StopEnd, Usage: Usage{InputTokens: 15500, OutputTokens: 40}},
+		{Content: []ContentBlock{{Type: "text", Text: "The disk filled up."}}, StopReason: StopEnd, Usage: Usage{InputTokens: 2100}},
+	}}
+	var estimates []estimate
+	var summaries int
+	hooks := EngineHooks{
+		OnTokenEstimate: func(method string, estimated, actual int) {
+			estimates = append(estimates, estimate{method, estimated, actual})
+		},
+		OnContextSummarized: func() { summaries++ },
+	}
+	engine := NewEngine(provider, registry, log.Nop(), hooks, noop.NewTracerProvider(),
+		WithContextWindow(20000), WithTokenCounter(fakeCounter{}))
+
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+	if rr.Status != StatusComplete || rr.Analysis != "The disk filled up." {
+		t.Fatalf("result = %q %q", rr.Status, rr.Analysis)
+	}
+	if rr.InputTokensUsed != 1900+15500+2100 {
+		t.Errorf("input tokens = %d, want the summary call counted", rr.InputTokensUsed)
+	}
+	if len(provider.reqs) != 3 {
+		t.Fatalf("LLM calls = %d, want 3", len(provider.reqs))
+	}
+
+	sum := provider.reqs[1]
+	last := sum.Messages[len(sum.Messages)-1].Content
+	if sum.ToolChoice.Type != ToolChoiceNone || last[0].Type != "tool_result" || last[len(last)-1].Text != summarizeNudge {
+		t.Errorf("summary request = %+v, last message %+v", sum.ToolChoice, last)
+	}
+
+	msgs := provider.reqs[2].Messages
+	if len(msgs) != 3 || msgs[0].Role != "user" || msgs[1].Content[0].Text != "Logs show the disk is full." || msgs[2].Content[0].Text != summaryNudge {
+		t.Errorf("messages after the summary = %+v", msgs)
+	}
+	if summaries != 1 {
+		t.Errorf("OnContextSummarized called %d times, want 1", summaries)
+	}
+	want := []estimate{{EstimateCountTokens, 2000, 1900}, {EstimateCountTokens, 2000, 2100}}
+	if !slices.Equal(estimates, want) {
+		t.Errorf("estimates = %+v, want %+v", estimates, want)
+	}
+}
+
+func TestRun_ContextWindowEstimates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []EngineOption
+		wantMethod string
+	}{
+		{"no counter", nil, EstimateLocal},
+		{"failed count", []EngineOption{WithTokenCounter(fakeCounter{err: errors.New("boom")})}, EstimateLocal},
+		{"counted", []EngineOption{WithTokenCounter(fakeCounter{})}, EstimateCountTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got []estimate
+			hooks := EngineHooks{OnTokenEstimate: func(method string, estimated, actual int) {
+				got = append(got, estimate{method, estimated, actual})
+			}}
+			provider := &mockProvider{}
+			engine := NewEngine(provider, nil, log.Nop(), hooks, noop.NewTracerProvider(), append(tt.opts, WithContextWindow(20000))...)
+
+			rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+			if rr.Status != StatusComplete || len(provider.reqs) != 1 {
+				t.Fatalf("result = %q after %d calls, want complete after one", rr.Status, len(provider.reqs))
+			}
+			if len(got) != 1 || got[0].method != tt.wantMethod || got[0].estimated <= 0 || got[0].actual != 10 {
+				t.Errorf("estimates = %+v, want one by %s", got, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package triage
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// Decision outcomes.
+const (
+	DecisionAccepted = "accepted"
+	DecisionSkipped  = "skipped"
+)
+
+// Decision records why Submit accepted or skipped one alert, so a missing
+// triage can be explained long after the logs are gone.
+type Decision struct {
+	ID          string `json:"id"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	Alert       string `json:"alert_name"`
+	Receiver    string `json:"receiver,omitempty"`
+	// Decision is DecisionAccepted or DecisionSkipped.
+	Decision string `json:"decision"`
+	// Reason is the SubmitResult reason, empty when accepted.
+	Reason string `json:"reason,omitempty"`
+	// Rule names what decided: the filter rule that skipped or downgraded
+	// the alert, the profile for a profile skip or an accepted routed alert,
+	// the suppression ID, the maintenance window that skipped or
+	// lightened it, or the guardrail that shed the alert.
+	Rule string `json:"rule,omitempty"`
+	// TriageID is the accepted triage, or the active one a duplicate was
+	// folded into.
+	TriageID  string    `json:"triage_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DecisionLog persists decisions.
+type DecisionLog interface {
+	RecordDecision(ctx context.Context, d *Decision) error
+	// ListDecisions returns matching decisions, newest first.
+	ListDecisions(ctx context.Context, f DecisionFilter) ([]*Decision, error)
+	// PurgeDecisions removes decisions made before the cutoff and returns
+	// how many were removed.
+	PurgeDecisions(ctx context.Context, before time.Time) (int, error)
+}
+
+// DecisionFilter narrows a ListDecisions query. Zero values match
+// everything, except Tenant, whose zero value is the default tenant.
+type DecisionFilter struct {
+	Tenant      string
+	Fingerprint string
+	Alert       string
+	Decision    string
+	// Before and BeforeID page through decisions like ListFilter's: set
+	// them to the last decision's CreatedAt and ID.
+	Before   time.Time
+	BeforeID string
+	Limit    int
+}
+
+// EffectiveLimit returns Limit clamped like ListFilter.EffectiveLimit.
+func (f DecisionFilter) EffectiveLimit() int {
+	return ListFilter{Limit: f.Limit}.EffectiveLimit()
+}
+
+// Matches reports whether d satisfies the filter, ignoring Limit.
+func (f DecisionFilter) Matches(d *Decision) bool {
+	if f.Tenant != AnyTenant && d.TenantID != f.Tenant {
+		return false
+	}
+	if f.Fingerprint != "" && d.Fingerprint != f.Fingerprint {
+		return false
+	}
+	if f.Alert != "" && d.Alert != f.Alert {
+		return false
+	}
+	if f.Decision != "" && d.Decision != f.Decision {
+		return false
+	}
+	if !f.Before.IsZero() {
+		if c := d.CreatedAt.Compare(f.Before); c > 0 || c == 0 && d.ID >= f.BeforeID {
+			return false
+		}
+	}
+	return true
+}
+
+// WithDecisionLog records every Submit decision in d.
+func WithDecisionLog(d DecisionLog) ServiceOption {
+	return func(s *Service) {
+		s.decisions = d
+	}
+}
+
+// Decisions lists the decisions of the tenant in ctx, newest first. It
+// returns none when no decision log is configured.
+func (s *Service) Decisions(ctx context.Context, f DecisionFilter) ([]*Decision, error) {
+	if s.decisions == nil {
+		return nil, nil
+	}
+	f.Tenant = TenantFrom(ctx)
+	return s.decisions.ListDecisions(ctx, f)
+}
+
+// RunDecisionPurger removes decisions older than retention, checking every
+// interval until ctx is done.
+func (s *Service) RunDecisionPurger(ctx context.Context, retention, interval time.Duration) {
+	if s.decisions == nil {
+		return
+	}
+	s.lead(ctx, "purge-decisions", func(ctx context.Context) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			switch n, err := s.decisions.PurgeDecisions(ctx, time.Now().Add(-retention)); {
+			case err != nil && ctx.Err() == nil:
+				s.logger.Error(ctx, err, "failed to purge decisions")
+			case n > 0:
+				s.logger.Info(ctx, "purged decisions", "count", n, "retention", retention)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	})
+}
+
+// recordDecision logs the outcome of submitting al. A failed write is
+// logged and does not fail the submission.
+func (s *Service) recordDecision(ctx context.Context, al *alert.Alert, sr *SubmitResult, rule string) {
+	if s.decisions == nil {
+		return
+	}
+	d := &Decision{
+		ID:          ulid.Make().String(),
+		TenantID:    TenantFrom(ctx),
+		Fingerprint: al.Fingerprint,
+		Alert:       al.Labels["alertname"],
+		Receiver:    al.Receiver,
+		Decision:    DecisionAccepted,
+		Reason:      sr.Reason,
+		Rule:        rule,
+		TriageID:    sr.ID,
+		CreatedAt:   time.Now(),
+	}
+	if sr.Skipped {
+		d.Decision = DecisionSkipped
+	}
+	if err := s.decisions.RecordDecision(ctx, d); err != nil {
+		s.logger.Warn(ctx, "failed to record submit decision", "err", err, "fingerprint", al.Fingerprint, "decision", d.Decision)
+	}
+}
@@ -0,0 +1,101 @@
+package triage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+type fakeDecisionLog struct {
+	mu        sync.Mutex
+	decisions []*Decision
+}
+
+func (f *fakeDecisionLog) RecordDecision(_ context.Context, d *Decision) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decisions = append(f.decisions, d)
+	return nil
+}
+
+func (f *fakeDecisionLog) ListDecisions(_ context.Context, flt DecisionFilter) ([]*Decision, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*Decision
+	for _, d := range f.decisions {
+		if flt.Matches(d) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDecisionLog) PurgeDecisions(context.Context, time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestSubmit_RecordsDecisions(t *testing.T) {
+	t.Parallel()
+
+	dl := &fakeDecisionLog{}
+	provider := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(provider.release)
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(newMockStore(), engine, log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithDecisionLog(dl),
+		WithSuppressions(&fakeSuppressionStore{}),
+		WithProfiles(profileFunc(func(al *alert.Alert) *Profile {
+			if al.Receiver == "blackhole" {
+				return &Profile{Name: "blackhole", Skip: true}
+			}
+			return nil
+		})),
+	)
+	ctx := context.Background()
+	sn, err := svc.Suppress(ctx, Suppression{Fingerprint: "fp-snoozed", Reason: SuppressionSnoozed}, time.Hour)
+	if err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+
+	firing := func(fp, receiver string) *alert.Alert {
+		return &alert.Alert{Status: "firing", Fingerprint: fp, Receiver: receiver, Labels: map[string]string{"alertname": "A"}}
+	}
+	accepted, _ := svc.Submit(ctx, firing("fp-1", "team"))
+	_, _ = svc.Submit(ctx, firing("fp-1", "team"))
+	_, _ = svc.Submit(ctx, firing("fp-2", "blackhole"))
+	_, _ = svc.Submit(ctx, firing("fp-snoozed", "team"))
+	_, _ = svc.Submit(ctx, &alert.Alert{Status: "resolved", Fingerprint: "fp-3"})
+
+	want := []Decision{
+		{Fingerprint: "fp-1", Receiver: "team", Decision: DecisionAccepted, TriageID: accepted.ID},
+		{Fingerprint: "fp-1", Receiver: "team", Decision: DecisionSkipped, Reason: "duplicate", TriageID: accepted.ID},
+		{Fingerprint: "fp-2", Receiver: "blackhole", Decision: DecisionSkipped, Reason: "skipped by profile", Rule: "blackhole"},
+		{Fingerprint: "fp-snoozed", Receiver: "team", Decision: DecisionSkipped, Reason: "snoozed", Rule: sn.ID},
+		{Fingerprint: "fp-3", Decision: DecisionSkipped, Reason: "not firing"},
+	}
+	got, _ := svc.Decisions(ctx, DecisionFilter{})
+	if len(got) != len(want) {
+		t.Fatalf("decisions = %d, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Fingerprint != w.Fingerprint || g.Receiver != w.Receiver || g.Decision != w.Decision ||
+			g.Reason != w.Reason || g.Rule != w.Rule || g.TriageID != w.TriageID {
+			t.Errorf("decision %d = %+v, want %+v", i, g, w)
+		}
+		if g.ID == "" || g.CreatedAt.IsZero() {
+			t.Errorf("decision %d has no ID or time", i)
+		}
+	}
+
+	if got, _ := svc.Decisions(WithTenant(ctx, "acme"), DecisionFilter{}); len(got) != 0 {
+		t.Errorf("acme sees %d default-tenant decisions", len(got))
+	}
+}
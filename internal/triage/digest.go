@@ -0,0 +1,214 @@
+package triage
+
+import (
+	"cmp"
+	"context"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Digest periods.
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+const (
+	// digestJitter spreads the replicas' attempts to send a digest, so the
+	// one that wins the claim is not always the same.
+	digestJitter = 2 * time.Minute
+
+	// digestMaxItems caps each list in a digest.
+	digestMaxItems = 5
+)
+
+// Digest summarizes the triages of one period, across all tenants, for a
+// periodic notification.
+type Digest struct {
+	Period string
+	Stats  *Stats
+	// Recurring are the fingerprints triaged most often, most first.
+	Recurring []FingerprintCount
+	// Failures are the most recent runs that did not complete.
+	Failures []DigestRun
+	// Costliest are the runs with the highest list-price cost.
+	Costliest []DigestRun
+	// Noisiest are the alert names with the highest noise scores over the
+	// period.
+	Noisiest []NoiseScore
+}
+
+// FingerprintCount is how often one fingerprint was triaged.
+type FingerprintCount struct {
+	Fingerprint string
+	Alert       string
+	Triages     int
+}
+
+// DigestRun is a triage a digest points out.
+type DigestRun struct {
+	ID      string
+	Alert   string
+	Status  Status
+	CostUSD float64
+	// URL is the run's page in the web UI, empty without a base URL.
+	URL string
+}
+
+// DigestNotifier delivers digests.
+type DigestNotifier interface {
+	SendDigest(ctx context.Context, d *Digest) error
+}
+
+// DigestLog lets replicas agree on which of them sends a digest.
+type DigestLog interface {
+	// ClaimDigest records that the digest of period due at the given time
+	// is being sent, reporting false if another replica already claimed it.
+	ClaimDigest(ctx context.Context, period string, due time.Time) (bool, error)
+}
+
+// DigestConfig configures RunDigest.
+type DigestConfig struct {
+	// Period is DigestDaily or DigestWeekly.
+	Period string
+	// Hour is the UTC hour the digest is sent at. Weekly digests are sent
+	// on Mondays.
+	Hour     int
+	Notifier DigestNotifier
+	Log      DigestLog
+	// BaseURL is where Vigil is reachable, for links to runs in the web UI.
+	BaseURL string
+}
+
+// RunDigest sends a digest of the previous period at every period boundary
+// until ctx is done. Each replica tries after a random delay, and the one
+// whose claim lands first sends it; the claim also keeps a new leader from
+// resending a digest after a failover. A digest that fails to send after its
+// claim is not retried.
+func (s *Service) RunDigest(ctx context.Context, cfg DigestConfig) {
+	s.lead(ctx, "digest", func(ctx context.Context) {
+		for {
+			due := nextDigest(time.Now(), cfg.Period, cfg.Hour)
+			t := time.NewTimer(time.Until(due) + rand.N(digestJitter))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+			s.sendDigest(ctx, cfg, due)
+		}
+	})
+}
+
+func (s *Service) sendDigest(ctx context.Context, cfg DigestConfig, due time.Time) {
+	won, err := cfg.Log.ClaimDigest(ctx, cfg.Period, due)
+	if err != nil {
+		s.logger.Error(ctx, err, "failed to claim digest", "period", cfg.Period, "due", due)
+		return
+	}
+	if !won {
+		s.logger.Debug(ctx, "digest sent by another replica", "period", cfg.Period, "due", due)
+		return
+	}
+	d, err := s.buildDigest(ctx, cfg, due.Add(-digestLength(cfg.Period)), due)
+	if err != nil {
+		s.logger.Error(ctx, err, "failed to build digest", "period", cfg.Period, "due", due)
+		return
+	}
+	if err := cfg.Notifier.SendDigest(ctx, d); err != nil {
+		s.logger.Error(ctx, err, "failed to send digest", "period", cfg.Period, "due", due)
+		return
+	}
+	s.logger.Info(ctx, "digest sent", "period", cfg.Period, "triages", d.Stats.Triages)
+}
+
+// buildDigest summarizes the triages of all tenants created in [since, until).
+func (s *Service) buildDigest(ctx context.Context, cfg DigestConfig, since, until time.Time) (*Digest, error) {
+	st, err := s.store.Stats(ctx, StatsQuery{Tenant: AnyTenant, Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+	st.price()
+	history, err := s.history(ctx, ListFilter{Tenant: AnyTenant, Before: until}, since)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(history, func(a, b *Result) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	d := &Digest{Period: cfg.Period, Stats: st}
+	counts := map[string]*FingerprintCount{}
+	var costly []DigestRun
+	for _, r := range history {
+		if r.CreatedAt.Before(since) {
+			continue
+		}
+		fc := counts[r.Fingerprint]
+		if fc == nil {
+			fc = &FingerprintCount{Fingerprint: r.Fingerprint, Alert: r.Alert}
+			counts[r.Fingerprint] = fc
+		}
+		fc.Triages++
+
+		run := DigestRun{ID: r.ID, Alert: r.Alert, Status: r.Status, URL: runURL(cfg.BaseURL, r.ID)}
+		if p, ok := PriceOf(r.Model); ok {
+			run.CostUSD = p.Cost(int64(r.TokensIn), int64(r.TokensOut))
+		}
+		if r.Status.IsTerminal() && r.Status != StatusComplete && len(d.Failures) < digestMaxItems {
+			d.Failures = append(d.Failures, run)
+		}
+		if run.CostUSD > 0 {
+			costly = append(costly, run)
+		}
+	}
+
+	for _, fc := range counts {
+		// A fingerprint seen once is not recurring.
+		if fc.Triages > 1 {
+			d.Recurring = append(d.Recurring, *fc)
+		}
+	}
+	slices.SortFunc(d.Recurring, func(a, b FingerprintCount) int {
+		return cmp.Or(cmp.Compare(b.Triages, a.Triages), strings.Compare(a.Fingerprint, b.Fingerprint))
+	})
+	d.Recurring = d.Recurring[:min(len(d.Recurring), digestMaxItems)]
+	slices.SortStableFunc(costly, func(a, b DigestRun) int { return cmp.Compare(b.CostUSD, a.CostUSD) })
+	d.Costliest = costly[:min(len(costly), digestMaxItems)]
+	d.Noisiest = noisiest(history, since, until, digestMaxItems)
+	return d, nil
+}
+
+// nextDigest returns the first digest time after now: hour o'clock UTC the
+// next day, or on the next Monday for weekly digests.
+func nextDigest(now time.Time, period string, hour int) time.Time {
+	now = now.UTC()
+	due := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	days := 1
+	if period == DigestWeekly {
+		days = 7
+		due = due.AddDate(0, 0, -int((due.Weekday()+6)%7))
+	}
+	for !due.After(now) {
+		due = due.AddDate(0, 0, days)
+	}
+	return due
+}
+
+func digestLength(period string) time.Duration {
+	if period == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// runURL links to a triage in the web UI, or returns "" without a base URL.
+func runURL(base, id string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/ui/#/triage/" + url.PathEscape(id)
+}
@@ -0,0 +1,134 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+func TestNextDigest(t *testing.T) {
+	t.Parallel()
+
+	// 2026-03-11 is a Wednesday.
+	wed := func(h, m int) time.Time { return time.Date(2026, 3, 11, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		name   string
+		now    time.Time
+		period string
+		want   time.Time
+	}{
+		{"daily before hour", wed(8, 59), DigestDaily, wed(9, 0)},
+		{"daily at hour", wed(9, 0), DigestDaily, time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)},
+		{"daily after hour", wed(17, 0), DigestDaily, time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)},
+		{"weekly midweek", wed(8, 0), DigestWeekly, time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"weekly monday before hour", time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC), DigestWeekly, time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"weekly monday after hour", time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC), DigestWeekly, time.Date(2026, 3, 23, 9, 0, 0, 0, time.UTC)},
+		{"non-UTC now", time.Date(2026, 3, 11, 5, 0, 0, 0, time.FixedZone("EST", -5*3600)), DigestDaily, time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := nextDigest(tt.now, tt.period, 9); !got.Equal(tt.want) {
+				t.Errorf("nextDigest = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	t.Parallel()
+
+	until := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	store := newMockStore()
+	add := func(id, fp string, ago time.Duration, status Status, tokensOut int) {
+		store.results[id] = &Result{
+			ID: id, Fingerprint: fp, Alert: "Alert-" + fp, Status: status, TenantID: "t-" + id,
+			Model: "claude-sonnet-4-20250514", TokensOut: tokensOut, CreatedAt: until.Add(-ago),
+		}
+	}
+	add("a1", "a", time.Hour, StatusComplete, 100_000)
+	add("a2", "a", 2*time.Hour, StatusFailed, 10_000)
+	add("a3", "a", 3*time.Hour, StatusComplete, 0)
+	add("b1", "b", 4*time.Hour, StatusComplete, 1_000_000)
+	add("b2", "b", 5*time.Hour, StatusMaxTurns, 0)
+	add("c1", "c", 6*time.Hour, StatusComplete, 0)
+	add("old", "c", 48*time.Hour, StatusFailed, 5_000_000)
+
+	svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider())
+	d, err := svc.buildDigest(context.Background(), DigestConfig{Period: DigestDaily, BaseURL: "https://vigil.example.com/"}, until.Add(-24*time.Hour), until)
+	if err != nil {
+		t.Fatalf("buildDigest: %v", err)
+	}
+
+	if d.Stats.Triages != 6 {
+		t.Errorf("triages = %d, want 6 across tenants", d.Stats.Triages)
+	}
+	if len(d.Recurring) != 2 || d.Recurring[0].Fingerprint != "a" || d.Recurring[0].Triages != 3 || d.Recurring[1].Fingerprint != "b" {
+		t.Errorf("recurring = %+v, want a x3 then b x2", d.Recurring)
+	}
+	if len(d.Failures) != 2 || d.Failures[0].ID != "a2" || d.Failures[1].ID != "b2" {
+		t.Errorf("failures = %+v, want a2 then b2", d.Failures)
+	}
+	if len(d.Costliest) != 3 || d.Costliest[0].ID != "b1" || d.Costliest[0].CostUSD != 15 {
+		t.Errorf("costliest = %+v, want b1 at $15 first", d.Costliest)
+	}
+	// Without repeated analyses, noise follows frequency; the old triage of
+	// c is outside the period.
+	if len(d.Noisiest) != 3 || d.Noisiest[0].Alert != "Alert-a" || d.Noisiest[0].Triages != 3 || d.Noisiest[2].Alert != "Alert-c" || d.Noisiest[2].Triages != 1 {
+		t.Errorf("noisiest = %+v, want Alert-a, Alert-b, Alert-c", d.Noisiest)
+	}
+	if got := d.Failures[0].URL; got != "https://vigil.example.com/ui/#/triage/a2" {
+		t.Errorf("url = %q", got)
+	}
+}
+
+type fakeDigestLog struct{ claimed map[string]bool }
+
+func (f *fakeDigestLog) ClaimDigest(_ context.Context, period string, due time.Time) (bool, error) {
+	key := period + due.String()
+	if f.claimed[key] {
+		return false, nil
+	}
+	f.claimed[key] = true
+	return true, nil
+}
+
+type fakeDigestNotifier struct {
+	sent []*Digest
+	err  error
+}
+
+func (f *fakeDigestNotifier) SendDigest(_ context.Context, d *Digest) error {
+	f.sent = append(f.sent, d)
+	return f.err
+}
+
+func TestSendDigest_OncePerClaim(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(newMockStore(), nil, log.Nop(), nil, nil, noop.NewTracerProvider())
+	dlog := &fakeDigestLog{claimed: map[string]bool{}}
+	a, b := &fakeDigestNotifier{}, &fakeDigestNotifier{err: errors.New("boom")}
+	due := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+
+	// Two replicas race for the same digest; only the first sends it.
+	svc.sendDigest(context.Background(), DigestConfig{Period: DigestDaily, Notifier: a, Log: dlog}, due)
+	svc.sendDigest(context.Background(), DigestConfig{Period: DigestDaily, Notifier: b, Log: dlog}, due)
+	if len(a.sent) != 1 || len(b.sent) != 0 {
+		t.Fatalf("sent = %d and %d, want 1 and 0", len(a.sent), len(b.sent))
+	}
+	if got := a.sent[0].Stats.Since; !got.Equal(due.Add(-24 * time.Hour)) {
+		t.Errorf("since = %v, want a day before %v", got, due)
+	}
+
+	// The next day's digest is a new claim.
+	svc.sendDigest(context.Background(), DigestConfig{Period: DigestDaily, Notifier: b, Log: dlog}, due.AddDate(0, 0, 1))
+	if len(b.sent) != 1 {
+		t.Errorf("next digest sent %d times, want 1", len(b.sent))
+	}
+}
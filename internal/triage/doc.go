@@ -1,4 +1,5 @@
 // Package triage provides the business boundary for Vigil's alert triage system.
-// It defines the Service (dedup, lifecycle, async dispatch), Engine (pure LLM
-// orchestration), Store interface (persistence), and domain models.
+// It defines the Service (dedup, lifecycle, async dispatch), Engine interface
+// (a single run, with LLMEngine doing the LLM orchestration), Store interface
+// (persistence), and domain models.
 package triage
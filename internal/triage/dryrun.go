@@ -0,0 +1,19 @@
+package triage
+
+import "context"
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context whose submitted alerts are triaged and stored
+// as usual, but marked Result.DryRun and kept from the outside world: no
+// notification, issue, suggested action or incident. It is meant for
+// backfilling past alerts, which have long been handled.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx was returned by WithDryRun.
+func IsDryRun(ctx context.Context) bool {
+	dry, _ := ctx.Value(dryRunKey{}).(bool)
+	return dry
+}
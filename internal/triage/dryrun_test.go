@@ -0,0 +1,53 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestSubmit_DryRun(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	notifier := newMockNotifier()
+	tracker := &fakeIssueTracker{}
+	al := &fakeAuditLog{}
+	svc := NewService(store, NopEngine{}, log.Nop(), nil, notifier, noop.NewTracerProvider(),
+		WithIssueTracker(tracker, nil), WithAuditLog(al))
+
+	sr, err := svc.Submit(WithDryRun(context.Background()), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-dry",
+		Labels:      map[string]string{"alertname": "DiskFull", "severity": "critical"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	r := waitTerminal(t, store, sr.ID)
+	waitForFinish(t, svc, sr.ID)
+
+	if r.Status != StatusComplete || !r.DryRun {
+		t.Errorf("result = %s, dry run %v; want a complete dry run", r.Status, r.DryRun)
+	}
+	notifier.mu.Lock()
+	calls := notifier.calls
+	notifier.mu.Unlock()
+	if calls != 0 {
+		t.Errorf("notifier called %d times for a dry run", calls)
+	}
+	tracker.mu.Lock()
+	issues := len(tracker.calls)
+	tracker.mu.Unlock()
+	if issues != 0 || r.IssueURL != "" {
+		t.Errorf("dry run opened %d issues", issues)
+	}
+	events, _ := al.ListAudit(context.Background(), sr.ID)
+	if len(events) == 0 || events[0].Event != AuditSubmitted || events[0].Detail != "dry run" {
+		t.Errorf("audit events = %+v, want submitted as a dry run", events)
+	}
+}
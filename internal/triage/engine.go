@@ -3,8 +3,11 @@ package triage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -28,12 +31,35 @@ const (
 
 	// ResponseTokens is the max tokens we request from the LLM in a single response. this is separate from MaxTokens which is a global limit across all turns.
 	ResponseTokens = 4096
+
+	// MaxContinuations is how many times a response cut off at
+	// ResponseTokens is continued before the triage is stopped.
+	MaxContinuations = 2
+
+	// PartialFlushInterval and PartialFlushBytes bound how stale the partial
+	// text passed to a PartialCallback can get while a response streams.
+	PartialFlushInterval = 2 * time.Second
+	PartialFlushBytes    = 1024
 )
 
+// Engine runs the triage of one alert and reports the outcome; the Service
+// stores, notifies and audits around it. LLMEngine is the one that asks a
+// model. NopEngine and RecordingEngine stand in for it in tests and dry
+// runs, and a replaying or remote engine can do the same.
+type Engine interface {
+	// Run triages al. If onTurn is non-nil it is called after each turn is
+	// appended to the conversation. Options an engine does not support are
+	// ignored.
+	Run(ctx context.Context, triageID string, al *alert.Alert, onTurn TurnCallback, opts ...RunOption) *RunResult
+	// Tools describes the tools the engine offers the model.
+	Tools() []tools.ToolInfo
+}
+
 // RunResult is the outcome of a single Engine.Run invocation.
 type RunResult struct {
 	Status           Status
 	Analysis         string
+	Notes            []Note
 	ToolsUsed        []string
 	Conversation     *Conversation
 	CompletedAt      time.Time
@@ -42,42 +68,104 @@ type RunResult struct {
 	ToolTime         float64
 	InputTokensUsed  int
 	OutputTokensUsed int
-	ToolCalls        int
-	SystemPrompt     string
-	Model            string
+	// ThinkingTokensUsed is the part of OutputTokensUsed spent on extended
+	// thinking.
+	ThinkingTokensUsed int
+	ToolCalls          int
+	// Redactions is how many secrets the scrubber removed from tool output.
+	Redactions   int
+	SystemPrompt string
+	Model        string
+	// Provider is the LLMResponse.Provider of the last call.
+	Provider string
+	Strategy Strategy
 }
 
 // CompleteEvent is passed to the OnComplete hook with per-triage aggregates.
 type CompleteEvent struct {
-	Status    Status
-	Duration  float64
-	LLMTime   float64
-	ToolTime  float64
-	TokensIn  int
-	TokensOut int
-	ToolCalls int
-	Model     string
+	Status         Status
+	Duration       float64
+	LLMTime        float64
+	ToolTime       float64
+	TokensIn       int
+	TokensOut      int
+	TokensThinking int
+	ToolCalls      int
+	Model          string
+	Tenant         string
+	// SpanContext is the span the run was traced under, for linking the
+	// observations to the trace.
+	SpanContext trace.SpanContext
 }
 
 // EngineHooks provides optional callbacks for instrumenting engine operations.
-// All fields are optional, nil callbacks are safely ignored.
+// All fields are optional, nil callbacks are safely ignored. The context
+// passed to OnLLMCall and OnToolCall carries the span of the call.
 type EngineHooks struct {
-	OnLLMCall  func(inputTokens, outputTokens int, duration float64)
-	OnToolCall func(name string, duration float64, inputBytes, outputBytes int, isError bool)
-	OnComplete func(*CompleteEvent)
+	OnLLMCall          func(ctx context.Context, inputTokens, outputTokens int, duration float64)
+	OnLLMRateLimitWait func(seconds float64)
+	OnToolCall         func(ctx context.Context, name string, duration float64, inputBytes, outputBytes int, isError bool)
+	// OnToolOutputViolation is called when a tool's output fails its
+	// declared output schema, in addition to OnToolCall.
+	OnToolOutputViolation func(name string)
+	// OnToolRedactions is called with the number of secrets scrubbed from
+	// one output of the named tool, when there were any.
+	OnToolRedactions func(name string, n int)
+	// OnTokenEstimate is called after each LLM call with the input tokens
+	// estimated beforehand, by method, and those the provider reported.
+	OnTokenEstimate func(method string, estimated, actual int)
+	// OnContextSummarized is called when a conversation is summarized to
+	// stay within the context window.
+	OnContextSummarized func()
+	OnComplete          func(*CompleteEvent)
 }
 
 // llmCall is a helper to invoke the OnLLMCall hook if set.
-func (h *EngineHooks) llmCall(in, out int, dur float64) {
+func (h *EngineHooks) llmCall(ctx context.Context, in, out int, dur float64) {
 	if h.OnLLMCall != nil {
-		h.OnLLMCall(in, out, dur)
+		h.OnLLMCall(ctx, in, out, dur)
+	}
+}
+
+// llmRateLimitWait is a helper to invoke the OnLLMRateLimitWait hook if set.
+func (h *EngineHooks) llmRateLimitWait(seconds float64) {
+	if h.OnLLMRateLimitWait != nil {
+		h.OnLLMRateLimitWait(seconds)
 	}
 }
 
 // toolCall is a helper to invoke the OnToolCall hook if set.
-func (h *EngineHooks) toolCall(name string, dur float64, inBytes, outBytes int, isErr bool) {
+func (h *EngineHooks) toolCall(ctx context.Context, name string, dur float64, inBytes, outBytes int, isErr bool) {
 	if h.OnToolCall != nil {
-		h.OnToolCall(name, dur, inBytes, outBytes, isErr)
+		h.OnToolCall(ctx, name, dur, inBytes, outBytes, isErr)
+	}
+}
+
+// toolOutputViolation is a helper to invoke the OnToolOutputViolation hook if set.
+func (h *EngineHooks) toolOutputViolation(name string) {
+	if h.OnToolOutputViolation != nil {
+		h.OnToolOutputViolation(name)
+	}
+}
+
+// toolRedactions is a helper to invoke the OnToolRedactions hook if set.
+func (h *EngineHooks) toolRedactions(name string, n int) {
+	if h.OnToolRedactions != nil {
+		h.OnToolRedactions(name, n)
+	}
+}
+
+// tokenEstimate is a helper to invoke the OnTokenEstimate hook if set.
+func (h *EngineHooks) tokenEstimate(method string, estimated, actual int) {
+	if h.OnTokenEstimate != nil {
+		h.OnTokenEstimate(method, estimated, actual)
+	}
+}
+
+// contextSummarized is a helper to invoke the OnContextSummarized hook if set.
+func (h *EngineHooks) contextSummarized() {
+	if h.OnContextSummarized != nil {
+		h.OnContextSummarized()
 	}
 }
 
@@ -88,141 +176,377 @@ func (h *EngineHooks) complete(e *CompleteEvent) {
 	}
 }
 
-// Engine provides the core triage logic, orchestrating interactions between
-// the LLM provider and tool registry.
-type Engine struct {
-	provider Provider
-	registry *tools.Registry
-	logger   log.Logger
-	hooks    EngineHooks
-	tracer   trace.Tracer
+// LLMEngine is the Engine that triages with a model, orchestrating
+// interactions between the LLM provider and tool registry.
+type LLMEngine struct {
+	provider        Provider
+	registry        *tools.Registry
+	logger          log.Logger
+	hooks           EngineHooks
+	tracer          trace.Tracer
+	toolConcurrency int
+	limiter         *RateLimiter
+	middleware      []PromptMiddleware
+	budget          Budget
+	thinkingBudget  int
+	temperature     *float64
+	scrubber        Scrubber
+	observers       []LLMObserver
+	toolObservers   []ToolObserver
+	// contextWindow is the request size, in tokens, at which the
+	// conversation is summarized; 0 disables the check.
+	contextWindow int
+	counter       TokenCounter
+}
+
+// EngineOption configures optional LLMEngine behavior.
+type EngineOption func(*LLMEngine)
+
+// WithToolConcurrency sets how many tool calls from a single LLM response may
+// execute in parallel. Values below 1 are treated as 1 (sequential).
+func WithToolConcurrency(n int) EngineOption {
+	return func(e *LLMEngine) { e.toolConcurrency = max(n, 1) }
 }
 
-// NewEngine creates a new triage engine with the given dependencies.
-func NewEngine(provider Provider, registry *tools.Registry, logger log.Logger, hooks EngineHooks, tp trace.TracerProvider) *Engine {
-	return &Engine{
-		provider: provider,
-		registry: registry,
-		logger:   logger,
-		hooks:    hooks,
-		tracer:   tp.Tracer("github.com/linnemanlabs/vigil/internal/triage"),
+// WithThinking enables extended thinking, letting the model reason for up
+// to budget tokens before each response. The budget is added to
+// ResponseTokens for each request, and counts against the run's output
+// token budget. Values below 1 leave thinking disabled.
+func WithThinking(budget int) EngineOption {
+	return func(e *LLMEngine) { e.thinkingBudget = max(budget, 0) }
+}
+
+// WithTemperature sets the sampling temperature of every request, in place
+// of the provider's default. It is not sent with extended thinking, which
+// requires the default.
+func WithTemperature(t float64) EngineOption {
+	return func(e *LLMEngine) { e.temperature = &t }
+}
+
+// NewEngine creates a new LLM triage engine with the given dependencies.
+func NewEngine(provider Provider, registry *tools.Registry, logger log.Logger, hooks EngineHooks, tp trace.TracerProvider, opts ...EngineOption) *LLMEngine {
+	e := &LLMEngine{
+		provider:        provider,
+		registry:        registry,
+		logger:          logger,
+		hooks:           hooks,
+		tracer:          tp.Tracer("github.com/linnemanlabs/vigil/internal/triage"),
+		toolConcurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
+}
+
+// Tools describes the engine's tools with their recent success rates.
+func (e *LLMEngine) Tools() []tools.ToolInfo {
+	if e.registry == nil {
+		return []tools.ToolInfo{}
+	}
+	return e.registry.Catalog()
+}
+
+// toolNames returns the sorted names of the tools offered to the model.
+func (e *LLMEngine) toolNames() []string {
+	if e.registry == nil {
+		return nil
+	}
+	var names []string
+	for _, d := range e.registry.ToToolDefs() {
+		names = append(names, d.Name)
+	}
+	slices.Sort(names)
+	return names
 }
 
 // Run executes the triage process for a given alert. It returns a RunResult
 // containing the outcome; the caller is responsible for persisting it.
 // If onTurn is non-nil it is called after each turn is appended to the
 // conversation; errors are logged but do not abort the triage loop.
-func (e *Engine) Run(ctx context.Context, triageID string, al *alert.Alert, onTurn TurnCallback) *RunResult {
+func (e *LLMEngine) Run(ctx context.Context, triageID string, al *alert.Alert, onTurn TurnCallback, opts ...RunOption) *RunResult {
 	start := time.Now()
 
+	var rc runConfig
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	budget := rc.budget.or(e.budget).withDefaults()
+	strategy := rc.strategy
+	if strategy == "" {
+		strategy = StrategyReAct
+	}
+
 	L := e.logger.With(
 		"alert", al.Labels["alertname"],
 		"fingerprint", al.Fingerprint,
 	)
 
+	initialPrompt := rc.prompt
+	if initialPrompt == "" {
+		initialPrompt = buildInitialPrompt(al, rc.metadata, rc.maintenance)
+	}
 	messages := []Message{
 		{Role: "user", Content: []ContentBlock{
-			{Type: "text", Text: buildInitialPrompt(al)},
+			{Type: "text", Text: initialPrompt},
 		}},
 	}
+	// A plan-execute run plans in a first call without tools, then runs the
+	// usual loop with the plan in the conversation.
+	planning := strategy == StrategyPlanExecute
+	if planning {
+		messages[0].Content = append(messages[0].Content, ContentBlock{Type: "text", Text: planInstruction(e.toolNames())})
+	}
 
 	conv := &Conversation{}
-	var totalInputTokens, totalOutputTokens int
-	var totalToolCalls int
+	var notes []Note
+	var totalInputTokens, totalOutputTokens, totalThinkingTokens int
+	var totalToolCalls, totalRedactions int
 	var totalLLMTime, totalToolTime float64
-	var lastModel string
+	var lastModel, lastProvider string
 	var chatSeq int
+	var retried, wrappingUp bool
+	// truncated is the text of responses cut off at the token limit, which
+	// the next response continues.
+	var truncated string
+	var continuations, summaries int
+	// keepTruncated saves truncated text as a note when the response that
+	// continued it was not the analysis.
+	keepTruncated := func() {
+		if text := strings.TrimSpace(truncated); text != "" {
+			notes = append(notes, Note{Turn: len(conv.Turns) - 1, Text: text, Timestamp: time.Now()})
+		}
+		truncated = ""
+	}
 	toolsUsedSet := make(map[string]struct{})
 
-	systemPrompt := buildSystemPrompt(al)
+	basePrompt := buildSystemPrompt(al, rc.instructions)
+	// The verdict line is asked for last, so it stays the final line after
+	// any actions block.
+	if rc.actions != "" {
+		basePrompt += actionsInstruction + rc.actions
+	}
+	if rc.verdict {
+		basePrompt += verdictInstruction
+	}
+	if strategy == StrategySingleShot {
+		basePrompt += singleShotInstruction
+	}
+	systemPrompt := basePrompt
 
 	budgetResult := func(status Status, analysis string) *RunResult {
 		dur := time.Since(start).Seconds()
 		e.hooks.complete(&CompleteEvent{
 			Status: status, Duration: dur, LLMTime: totalLLMTime, ToolTime: totalToolTime,
-			TokensIn: totalInputTokens, TokensOut: totalOutputTokens, ToolCalls: totalToolCalls, Model: lastModel,
+			TokensIn: totalInputTokens, TokensOut: totalOutputTokens, TokensThinking: totalThinkingTokens, ToolCalls: totalToolCalls, Model: lastModel,
+			Tenant: TenantFrom(ctx), SpanContext: trace.SpanContextFromContext(ctx),
 		})
 		return &RunResult{
-			Status:           status,
-			Analysis:         analysis,
-			ToolsUsed:        sortedKeys(toolsUsedSet),
-			Conversation:     conv,
-			CompletedAt:      time.Now(),
-			Duration:         dur,
-			LLMTime:          totalLLMTime,
-			ToolTime:         totalToolTime,
-			InputTokensUsed:  totalInputTokens,
-			OutputTokensUsed: totalOutputTokens,
-			ToolCalls:        totalToolCalls,
-			SystemPrompt:     systemPrompt,
-			Model:            lastModel,
+			Status:             status,
+			Analysis:           analysis,
+			Notes:              notes,
+			ToolsUsed:          sortedKeys(toolsUsedSet),
+			Conversation:       conv,
+			CompletedAt:        time.Now(),
+			Duration:           dur,
+			LLMTime:            totalLLMTime,
+			ToolTime:           totalToolTime,
+			InputTokensUsed:    totalInputTokens,
+			OutputTokensUsed:   totalOutputTokens,
+			ThinkingTokensUsed: totalThinkingTokens,
+			ToolCalls:          totalToolCalls,
+			Redactions:         totalRedactions,
+			SystemPrompt:       systemPrompt,
+			Model:              lastModel,
+			Provider:           lastProvider,
+			Strategy:           strategy,
 		}
 	}
 
+	// The "Triage terminated: " analyses below are matched by the pgstore
+	// schema to reclassify rows stored before these statuses existed.
 	for {
-		if totalToolCalls >= MaxToolRounds {
-			L.Warn(ctx, "triage hit tool call limit", "limit", MaxToolRounds)
-			return budgetResult(StatusMaxTurns, "Triage terminated: tool call budget exhausted")
+		if ctx.Err() != nil {
+			cause := context.Cause(ctx)
+			L.Warn(ctx, "triage cancelled", "cause", cause)
+			return budgetResult(StatusError, "Triage terminated: "+cause.Error())
 		}
-		if totalInputTokens >= MaxInputTokens {
-			L.Warn(ctx, "triage hit input token limit", "limit", MaxInputTokens, "used", totalInputTokens)
+		// At the tool call limit the model gets one more call, without tools,
+		// to analyze what it has found so far.
+		if totalToolCalls >= budget.ToolCalls {
+			if wrappingUp {
+				L.Warn(ctx, "triage hit tool call limit", "limit", budget.ToolCalls)
+				return budgetResult(StatusMaxTurns, "Triage terminated: tool call budget exhausted")
+			}
+			wrappingUp = true
+			L.Info(ctx, "triage hit tool call limit, wrapping up", "limit", budget.ToolCalls)
+			last := messages[len(messages)-1]
+			last.Content = append(slices.Clip(last.Content), ContentBlock{Type: "text", Text: wrapUpNudge})
+			messages = append(slices.Clone(messages[:len(messages)-1]), last)
+			conv.Turns = append(conv.Turns, Turn{
+				Role:      "user",
+				Content:   []ContentBlock{{Type: "text", Text: wrapUpNudge}},
+				Timestamp: time.Now(),
+			})
+			notifyTurn(ctx, L, onTurn, conv)
+		}
+		if totalInputTokens >= budget.InputTokens {
+			L.Warn(ctx, "triage hit input token limit", "limit", budget.InputTokens, "used", totalInputTokens)
 			return budgetResult(StatusBudgetExceeded, "Triage terminated: input token budget exhausted")
 		}
-		if totalOutputTokens >= MaxOutputTokens {
-			L.Warn(ctx, "triage hit output token limit", "limit", MaxOutputTokens, "used", totalOutputTokens)
+		if totalOutputTokens >= budget.OutputTokens {
+			L.Warn(ctx, "triage hit output token limit", "limit", budget.OutputTokens, "used", totalOutputTokens)
 			return budgetResult(StatusBudgetExceeded, "Triage terminated: output token budget exhausted")
 		}
 
+		// Tools withheld by a circuit breaker are dropped from the request and
+		// called out in the prompt so the model works around the gap. Single
+		// shot runs never get tools, and plan-execute runs only once planned.
+		// The prompt is rebuilt every call, so middleware additions to it do
+		// not pile up.
 		var toolDefs []tools.ToolDef
-		if e.registry != nil {
-			toolDefs = e.registry.ToToolDefs()
+		systemPrompt = basePrompt
+		if e.registry != nil && strategy != StrategySingleShot {
+			if !planning {
+				toolDefs = e.registry.ToToolDefs()
+			}
+			systemPrompt += unavailableToolsNote(e.registry.Unavailable())
 		}
 
 		// call LLM provider with current conversation
 		llmStart := time.Now()
 		req := &LLMRequest{
-			MaxTokens: ResponseTokens,
-			System:    systemPrompt,
-			Messages:  messages,
-			Tools:     toolDefs,
+			MaxTokens:      ResponseTokens + e.thinkingBudget,
+			System:         systemPrompt,
+			Messages:       messages,
+			Tools:          toolDefs,
+			ThinkingBudget: e.thinkingBudget,
+		}
+		if wrappingUp {
+			req.ToolChoice = ToolChoice{Type: ToolChoiceNone}
+		}
+		if e.temperature != nil && e.thinkingBudget == 0 {
+			req.Temperature = e.temperature
+		}
+		mwErr := e.applyMiddleware(ctx, req, PromptInfo{TriageID: triageID, Alert: al, Call: chatSeq})
+		messages, systemPrompt = req.Messages, req.System
+
+		// A request nearing the context window is sent with the conversation
+		// summarized instead, before the provider has to refuse it. A
+		// response being continued is left alone, as it relies on the text
+		// before it.
+		estInput, estMethod := e.countTokens(ctx, L, req)
+		if mwErr == nil && e.nearContextWindow(req, estInput) && len(messages) > 1 && truncated == "" && summaries < MaxSummaries {
+			L.Info(ctx, "request near the context window, summarizing the conversation",
+				"estimated_input_tokens", estInput, "method", estMethod, "context_window", e.contextWindow)
+			resp, dur, err := e.summarize(ctx, triageID, al, req, chatSeq, estInput)
+			chatSeq++
+			totalLLMTime += dur
+			if resp != nil {
+				totalInputTokens += resp.Usage.InputTokens
+				totalOutputTokens += resp.Usage.OutputTokens
+				totalThinkingTokens += resp.Usage.ThinkingTokens
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				L.Warn(ctx, "conversation summary failed, sending it in full", "err", err)
+			} else {
+				summaries++
+				e.hooks.contextSummarized()
+				conv.Turns = append(conv.Turns, Turn{
+					Role:      "user",
+					Content:   []ContentBlock{{Type: "text", Text: summarizeNudge}},
+					Timestamp: time.Now(),
+				})
+				notifyTurn(ctx, L, onTurn, conv)
+				conv.Turns = append(conv.Turns, Turn{
+					Role:       "assistant",
+					Content:    resp.Content,
+					Timestamp:  time.Now(),
+					Usage:      &resp.Usage,
+					StopReason: string(resp.StopReason),
+					Duration:   dur,
+					Model:      resp.Model,
+				})
+				notifyTurn(ctx, L, onTurn, conv)
+				nudge := summaryNudge
+				if wrappingUp {
+					nudge = wrapUpNudge
+				}
+				messages = summarizedMessages(messages, responseText(resp), nudge)
+				conv.Turns = append(conv.Turns, Turn{
+					Role:      "user",
+					Content:   []ContentBlock{{Type: "text", Text: nudge}},
+					Timestamp: time.Now(),
+				})
+				notifyTurn(ctx, L, onTurn, conv)
+				req.Messages = messages
+				estInput, estMethod = e.countTokens(ctx, L, req)
+				L.Info(ctx, "conversation summarized", "summaries", summaries, "estimated_input_tokens", estInput)
+			}
 		}
 		llmCtx, llmSpan := e.tracer.Start(ctx, "llm.call", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
 			attribute.String("gen_ai.operation.name", "llm.call"),
 			attribute.String("gen_ai.provider.name", "anthropic"),
-			attribute.Int("gen_ai.request.max_tokens", ResponseTokens),
+			attribute.Int("gen_ai.request.max_tokens", req.MaxTokens),
 			attribute.String("vigil.triage.id", triageID),
 			attribute.String("vigil.alert.fingerprint", al.Fingerprint),
 			attribute.Int("vigil.chat.seq", chatSeq),
+			attribute.Int("vigil.llm.estimated_input_tokens", estInput),
 		))
 		llmSpan.AddEvent("llm.request", trace.WithAttributes(
 			attribute.String("llm.request.body", marshalMessages(req.Messages)),
 		))
-		resp, err := e.provider.Send(llmCtx, req)
+		err := mwErr
+		if err == nil {
+			err = e.waitForCapacity(llmCtx, llmSpan, estInput)
+		}
+		llmStart = time.Now() // queueing for rate limits is not LLM time
+		var resp *LLMResponse
+		if err == nil {
+			resp, err = e.send(llmCtx, L, req, rc.onPartial)
+		}
+		call := &LLMCall{TriageID: triageID, Alert: al, Seq: chatSeq, Request: req, Err: err, Duration: time.Since(llmStart)}
+		if err == nil {
+			call.Response = resp
+		}
+		e.observe(llmCtx, call)
 		if err != nil {
 			llmSpan.RecordError(err)
 			llmSpan.SetStatus(codes.Error, err.Error())
 			llmSpan.End()
+			if ctx.Err() != nil {
+				// Cancelled mid-call; the check at the top of the loop reports it.
+				continue
+			}
 			L.Error(ctx, err, "llm call failed")
 			dur := time.Since(start).Seconds()
 			e.hooks.complete(&CompleteEvent{
 				Status: StatusFailed, Duration: dur, LLMTime: totalLLMTime, ToolTime: totalToolTime,
-				TokensIn: totalInputTokens, TokensOut: totalOutputTokens, ToolCalls: totalToolCalls, Model: lastModel,
+				TokensIn: totalInputTokens, TokensOut: totalOutputTokens, TokensThinking: totalThinkingTokens, ToolCalls: totalToolCalls, Model: lastModel,
+				Tenant: TenantFrom(ctx), SpanContext: trace.SpanContextFromContext(ctx),
 			})
 			return &RunResult{
-				Status:           StatusFailed,
-				Analysis:         fmt.Sprintf("LLM error: %v", err),
-				ToolsUsed:        sortedKeys(toolsUsedSet),
-				Conversation:     conv,
-				CompletedAt:      time.Now(),
-				Duration:         dur,
-				LLMTime:          totalLLMTime,
-				ToolTime:         totalToolTime,
-				InputTokensUsed:  totalInputTokens,
-				OutputTokensUsed: totalOutputTokens,
-				ToolCalls:        totalToolCalls,
-				SystemPrompt:     systemPrompt,
-				Model:            lastModel,
+				Status:             StatusFailed,
+				Analysis:           fmt.Sprintf("LLM error: %v", err),
+				Notes:              notes,
+				ToolsUsed:          sortedKeys(toolsUsedSet),
+				Conversation:       conv,
+				CompletedAt:        time.Now(),
+				Duration:           dur,
+				LLMTime:            totalLLMTime,
+				ToolTime:           totalToolTime,
+				InputTokensUsed:    totalInputTokens,
+				OutputTokensUsed:   totalOutputTokens,
+				ThinkingTokensUsed: totalThinkingTokens,
+				ToolCalls:          totalToolCalls,
+				Redactions:         totalRedactions,
+				SystemPrompt:       systemPrompt,
+				Model:              lastModel,
+				Provider:           lastProvider,
+				Strategy:           strategy,
 			}
 		}
 
@@ -230,18 +554,25 @@ func (e *Engine) Run(ctx context.Context, triageID string, al *alert.Alert, onTu
 			attribute.String("llm.response.body", marshalContent(resp.Content)),
 		))
 
+		if e.limiter != nil {
+			e.limiter.Record(estInput, resp.Usage)
+		}
+
 		llmDur := time.Since(llmStart).Seconds()
 		totalLLMTime += llmDur
 		totalInputTokens += resp.Usage.InputTokens
 		totalOutputTokens += resp.Usage.OutputTokens
-		lastModel = resp.Model
-		e.hooks.llmCall(resp.Usage.InputTokens, resp.Usage.OutputTokens, llmDur)
+		totalThinkingTokens += resp.Usage.ThinkingTokens
+		lastModel, lastProvider = resp.Model, resp.Provider
+		e.hooks.llmCall(llmCtx, resp.Usage.InputTokens, resp.Usage.OutputTokens, llmDur)
+		e.hooks.tokenEstimate(estMethod, estInput, resp.Usage.InputTokens)
 
 		llmSpan.SetAttributes(
 			attribute.String("gen_ai.response.model", resp.Model),
 			attribute.String("gen_ai.request.model", resp.Model),
 			attribute.Int("gen_ai.usage.input_tokens", resp.Usage.InputTokens),
 			attribute.Int("gen_ai.usage.output_tokens", resp.Usage.OutputTokens),
+			attribute.Int("vigil.llm.thinking_tokens", resp.Usage.ThinkingTokens),
 			attribute.StringSlice("gen_ai.response.finish_reasons", []string{string(resp.StopReason)}),
 		)
 		llmSpan.SetStatus(codes.Ok, "")
@@ -253,6 +584,9 @@ func (e *Engine) Run(ctx context.Context, triageID string, al *alert.Alert, onTu
 			"duration", llmDur,
 			"input_tokens", resp.Usage.InputTokens,
 			"output_tokens", resp.Usage.OutputTokens,
+			"thinking_tokens", resp.Usage.ThinkingTokens,
+			"estimated_input_tokens", estInput,
+			"estimate_method", estMethod,
 			"total_tokens", totalInputTokens+totalOutputTokens,
 		)
 
@@ -268,47 +602,148 @@ func (e *Engine) Run(ctx context.Context, triageID string, al *alert.Alert, onTu
 		})
 		notifyTurn(ctx, L, onTurn, conv)
 
+		// A refusal or an empty answer is retried once with a nudge. The
+		// response stays in the record but is not sent back to the model.
+		// A continuation with nothing left to add is not a failure.
+		if kind := unusableResponse(resp); kind != "" && (kind != responseEmpty || resp.StopReason != StopEnd || truncated == "") {
+			if wrappingUp {
+				L.Warn(ctx, "llm wrap-up response unusable", "kind", kind)
+				return budgetResult(StatusMaxTurns, "Triage terminated: tool call budget exhausted")
+			}
+			if retried {
+				L.Warn(ctx, "llm response unusable after retry", "kind", kind)
+				if kind == responseRefused {
+					return budgetResult(StatusRefused, refusalAnalysis(resp))
+				}
+				return budgetResult(StatusFailed, "LLM error: empty response")
+			}
+			retried = true
+			L.Warn(ctx, "llm response unusable, retrying", "kind", kind)
+			nudge := retryNudges[kind]
+			last := messages[len(messages)-1]
+			last.Content = append(slices.Clip(last.Content), ContentBlock{Type: "text", Text: nudge})
+			messages = append(slices.Clone(messages[:len(messages)-1]), last)
+			conv.Turns = append(conv.Turns, Turn{
+				Role:      "user",
+				Content:   []ContentBlock{{Type: "text", Text: nudge}},
+				Timestamp: time.Now(),
+			})
+			notifyTurn(ctx, L, onTurn, conv)
+			continue
+		}
+
+		// A response cut off at the token limit is continued by the next
+		// call. Only its text is sent back, as a cut-off tool call cannot be
+		// run, and the text is stitched onto the analysis.
+		if resp.StopReason == StopMaxTokens {
+			if continuations >= MaxContinuations {
+				L.Warn(ctx, "llm response still truncated", "continuations", continuations)
+				return budgetResult(StatusBudgetExceeded, fmt.Sprintf("Triage terminated: response truncated at the token limit after %d continuations", continuations))
+			}
+			continuations++
+			L.Info(ctx, "llm response truncated, continuing", "continuation", continuations)
+			var partial []ContentBlock
+			last := -1
+			for i, b := range resp.Content {
+				if b.Type == "text" && b.Text != "" {
+					partial = append(partial, b)
+					last = i
+				}
+			}
+			notes = appendNotes(notes, &conv.Turns[len(conv.Turns)-1], len(conv.Turns)-1, last)
+			nudge := truncatedNudge
+			if len(partial) > 0 {
+				truncated += partial[len(partial)-1].Text
+				nudge = continueNudge
+				messages = append(messages,
+					Message{Role: "assistant", Content: partial},
+					Message{Role: "user", Content: []ContentBlock{{Type: "text", Text: nudge}}},
+				)
+			} else {
+				prev := messages[len(messages)-1]
+				prev.Content = append(slices.Clip(prev.Content), ContentBlock{Type: "text", Text: nudge})
+				messages = append(slices.Clone(messages[:len(messages)-1]), prev)
+			}
+			conv.Turns = append(conv.Turns, Turn{
+				Role:      "user",
+				Content:   []ContentBlock{{Type: "text", Text: nudge}},
+				Timestamp: time.Now(),
+			})
+			notifyTurn(ctx, L, onTurn, conv)
+			continue
+		}
+		continuations = 0
+
 		// append assistant response to messages
 		messages = append(messages, Message{
 			Role:    "assistant",
 			Content: resp.Content,
 		})
 
-		// done - extract final analysis
+		// the plan is kept as a note and the investigation starts
+		if resp.StopReason == StopEnd && planning {
+			planning = false
+			keepTruncated()
+			notes = appendNotes(notes, &conv.Turns[len(conv.Turns)-1], len(conv.Turns)-1, -1)
+			nudge := []ContentBlock{{Type: "text", Text: executeNudge}}
+			conv.Turns = append(conv.Turns, Turn{Role: "user", Content: nudge, Timestamp: time.Now()})
+			notifyTurn(ctx, L, onTurn, conv)
+			messages = append(messages, Message{Role: "user", Content: nudge})
+			continue
+		}
+
+		// done - extract final analysis; any earlier text is kept as notes
 		if resp.StopReason == StopEnd {
 			var analysis string
+			final := -1
 			for i := len(resp.Content) - 1; i >= 0; i-- {
 				if resp.Content[i].Type == "text" {
 					analysis = resp.Content[i].Text
+					final = i
 					break
 				}
 			}
+			notes = appendNotes(notes, &conv.Turns[len(conv.Turns)-1], len(conv.Turns)-1, final)
+			analysis = truncated + analysis
+			if wrappingUp {
+				L.Warn(ctx, "triage hit tool call limit", "limit", budget.ToolCalls)
+				return budgetResult(StatusMaxTurns, wrapUpPrefix+analysis)
+			}
 			dur := time.Since(start).Seconds()
 			e.hooks.complete(&CompleteEvent{
 				Status: StatusComplete, Duration: dur, LLMTime: totalLLMTime, ToolTime: totalToolTime,
-				TokensIn: totalInputTokens, TokensOut: totalOutputTokens, ToolCalls: totalToolCalls, Model: lastModel,
+				TokensIn: totalInputTokens, TokensOut: totalOutputTokens, TokensThinking: totalThinkingTokens, ToolCalls: totalToolCalls, Model: lastModel,
+				Tenant: TenantFrom(ctx), SpanContext: trace.SpanContextFromContext(ctx),
 			})
 			return &RunResult{
-				Status:           StatusComplete,
-				Analysis:         analysis,
-				ToolsUsed:        sortedKeys(toolsUsedSet),
-				Conversation:     conv,
-				CompletedAt:      time.Now(),
-				Duration:         dur,
-				LLMTime:          totalLLMTime,
-				ToolTime:         totalToolTime,
-				InputTokensUsed:  totalInputTokens,
-				OutputTokensUsed: totalOutputTokens,
-				ToolCalls:        totalToolCalls,
-				SystemPrompt:     systemPrompt,
-				Model:            lastModel,
+				Status:             StatusComplete,
+				Analysis:           analysis,
+				Notes:              notes,
+				ToolsUsed:          sortedKeys(toolsUsedSet),
+				Conversation:       conv,
+				CompletedAt:        time.Now(),
+				Duration:           dur,
+				LLMTime:            totalLLMTime,
+				ToolTime:           totalToolTime,
+				InputTokensUsed:    totalInputTokens,
+				OutputTokensUsed:   totalOutputTokens,
+				ThinkingTokensUsed: totalThinkingTokens,
+				ToolCalls:          totalToolCalls,
+				Redactions:         totalRedactions,
+				SystemPrompt:       systemPrompt,
+				Model:              lastModel,
+				Provider:           lastProvider,
+				Strategy:           strategy,
 			}
 		}
 
-		// handle tool calls
-		if resp.StopReason == StopToolUse {
-			toolResults, calls, batchToolDur := e.executeToolCalls(ctx, L, resp.Content, toolsUsedSet, triageID, al.Fingerprint)
+		// handle tool calls; none are run while wrapping up
+		if resp.StopReason == StopToolUse && !wrappingUp {
+			keepTruncated()
+			notes = appendNotes(notes, &conv.Turns[len(conv.Turns)-1], len(conv.Turns)-1, -1)
+			toolResults, calls, redactions, batchToolDur := e.executeToolCalls(ctx, L, resp.Content, toolsUsedSet, triageID, al.Fingerprint)
 			totalToolCalls += calls
+			totalRedactions += redactions
 			totalToolTime += batchToolDur
 
 			// record tool results turn
@@ -328,6 +763,138 @@ func (e *Engine) Run(ctx context.Context, triageID string, al *alert.Alert, onTu
 	}
 }
 
+// send calls the provider, streaming the response if onPartial is set and the
+// provider supports it. Text is flushed to onPartial every PartialFlushInterval
+// or PartialFlushBytes, whichever comes first, and cleared once the response
+// is complete, as the engine then records it as a turn.
+func (e *LLMEngine) send(ctx context.Context, logger log.Logger, req *LLMRequest, onPartial PartialCallback) (*LLMResponse, error) {
+	sp, ok := e.provider.(StreamingProvider)
+	if !ok || onPartial == nil {
+		return e.provider.Send(ctx, req)
+	}
+
+	var text strings.Builder
+	flushed := 0
+	lastFlush := time.Now()
+	save := func(s string) {
+		if err := onPartial(ctx, s); err != nil {
+			logger.Warn(ctx, "partial callback failed", "err", err)
+		}
+	}
+	resp, err := sp.SendStream(ctx, req, func(delta string) {
+		text.WriteString(delta)
+		if text.Len()-flushed >= PartialFlushBytes || time.Since(lastFlush) >= PartialFlushInterval {
+			save(text.String())
+			flushed, lastFlush = text.Len(), time.Now()
+		}
+	})
+	if flushed > 0 && err == nil {
+		save("")
+	}
+	return resp, err
+}
+
+// waitForCapacity queues a provider call behind the shared rate limiter, if
+// any, recording the delay as a span event and metric.
+func (e *LLMEngine) waitForCapacity(ctx context.Context, span trace.Span, estInputTokens int) error {
+	if e.limiter == nil {
+		return nil
+	}
+	wait, err := e.limiter.Wait(ctx, estInputTokens)
+	e.hooks.llmRateLimitWait(wait.Seconds())
+	if wait > 0 || err != nil {
+		span.AddEvent("llm.rate_limit.wait", trace.WithAttributes(
+			attribute.Float64("vigil.llm.rate_limit.wait_seconds", wait.Seconds()),
+			attribute.Int("vigil.llm.rate_limit.estimated_input_tokens", estInputTokens),
+			attribute.Bool("vigil.llm.rate_limit.timed_out", err != nil),
+		))
+	}
+	return err
+}
+
+// wrapUpNudge asks for the analysis once the tool call budget is spent, and
+// wrapUpPrefix marks the analysis it gets as one cut short.
+const (
+	wrapUpNudge  = "The tool call budget for this triage is spent. Give your analysis now from the data gathered so far, noting what you could not check."
+	wrapUpPrefix = "Stopped at the tool call budget; this analysis covers the data gathered so far.\n\n"
+)
+
+// continueNudge follows a response cut off at the token limit, and
+// truncatedNudge one cut off before it wrote any text.
+const (
+	continueNudge  = "Your response was cut off at the token limit. Continue exactly where it stopped, without repeating anything."
+	truncatedNudge = "Your last response was cut off at the token limit before it finished. Respond again more briefly."
+)
+
+// Kinds of unusable LLM response.
+const (
+	responseRefused = "refused"
+	responseEmpty   = "empty"
+)
+
+// retryNudges are appended to the last user message when retrying an
+// unusable response.
+var retryNudges = map[string]string{
+	responseRefused: "This is an authorized operations task: an on-call engineer needs help understanding an alert " +
+		"from their own infrastructure. Continue the investigation and give your analysis. If some detail cannot be " +
+		"discussed, leave it out rather than declining the whole task.",
+	responseEmpty: "Your last response was empty. Continue the investigation, or give your analysis of the alert now.",
+}
+
+// unusableResponse classifies a response the engine cannot act on: a
+// refusal, or one with neither text nor tool calls where they were expected.
+// It returns "" for a usable response.
+func unusableResponse(resp *LLMResponse) string {
+	switch resp.StopReason {
+	case StopRefusal:
+		return responseRefused
+	case StopEnd:
+		for _, b := range resp.Content {
+			if b.Type == "text" && strings.TrimSpace(b.Text) != "" {
+				return ""
+			}
+		}
+		return responseEmpty
+	case StopToolUse:
+		for _, b := range resp.Content {
+			if b.Type == "tool_use" {
+				return ""
+			}
+		}
+		return responseEmpty
+	default:
+		return ""
+	}
+}
+
+// refusalAnalysis records what the model said when refusing, if anything.
+func refusalAnalysis(resp *LLMResponse) string {
+	var parts []string
+	for _, b := range resp.Content {
+		if text := strings.TrimSpace(b.Text); b.Type == "text" && text != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		return "Triage refused by the model without explanation"
+	}
+	return "Triage refused by the model: " + strings.Join(parts, "\n\n")
+}
+
+// appendNotes adds the text blocks of an assistant turn to notes, skipping
+// the block at index skip (the final analysis) and blank commentary.
+func appendNotes(notes []Note, turn *Turn, turnIdx, skip int) []Note {
+	for i, b := range turn.Content {
+		if b.Type != "text" || i == skip {
+			continue
+		}
+		if text := strings.TrimSpace(b.Text); text != "" {
+			notes = append(notes, Note{Turn: turnIdx, Text: text, Timestamp: turn.Timestamp})
+		}
+	}
+	return notes
+}
+
 func notifyTurn(ctx context.Context, logger log.Logger, onTurn TurnCallback, conv *Conversation) {
 	if onTurn == nil {
 		return
@@ -338,113 +905,157 @@ func notifyTurn(ctx context.Context, logger log.Logger, onTurn TurnCallback, con
 	}
 }
 
-func (e *Engine) executeToolCalls(ctx context.Context, logger log.Logger, content []ContentBlock, seen map[string]struct{}, triageID, fingerprint string) (results []ContentBlock, calls int, totalDur float64) {
+// executeToolCalls runs every tool_use block in content and returns the
+// tool_result blocks in the same order. Up to toolConcurrency calls run in
+// parallel; redactions is the number of secrets scrubbed from their outputs
+// and totalDur is the summed execution time across calls.
+func (e *LLMEngine) executeToolCalls(ctx context.Context, logger log.Logger, content []ContentBlock, seen map[string]struct{}, triageID, fingerprint string) (results []ContentBlock, calls, redactions int, totalDur float64) {
+	var blocks []*ContentBlock
 	for i := range content {
-		block := &content[i]
-		if block.Type != "tool_use" {
-			continue
+		if content[i].Type == "tool_use" {
+			blocks = append(blocks, &content[i])
+			seen[content[i].Name] = struct{}{}
 		}
+	}
+	if len(blocks) == 0 {
+		return nil, 0, 0, 0
+	}
 
-		calls++
-		seen[block.Name] = struct{}{}
-		logger.Info(ctx, "executing tool", "tool", block.Name, "call_number", calls)
-
-		tool, ok := e.registry.Get(block.Name)
-		if !ok {
-			_, toolSpan := e.tracer.Start(ctx, "tool.execute", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
-				attribute.String("gen_ai.operation.name", "tool.execute"),
-				attribute.String("gen_ai.tool.name", block.Name),
-				attribute.String("gen_ai.tool.call.id", block.ID),
-				attribute.Bool("vigil.tool.is_error", true),
-				attribute.String("vigil.triage.id", triageID),
-				attribute.String("vigil.alert.fingerprint", fingerprint),
-				attribute.String("vigil.tool.input", truncateSpanField(string(block.Input), 1024)),
-			))
-			toolSpan.AddEvent("tool.request", trace.WithAttributes(
-				attribute.String("tool.request.body", string(block.Input)),
-			))
-			toolSpan.AddEvent("tool.result", trace.WithAttributes(
-				attribute.String("tool.result.body", fmt.Sprintf("unknown tool: %s", block.Name)),
-			))
-			toolSpan.SetStatus(codes.Error, "unknown tool")
-			toolSpan.End()
-
-			e.hooks.toolCall(block.Name, 0, len(block.Input), 0, true)
-			results = append(results, ContentBlock{
-				Type:      "tool_result",
-				ToolUseID: block.ID,
-				Content:   fmt.Sprintf("unknown tool: %s", block.Name),
-				IsError:   true,
-			})
-			continue
-		}
+	results = make([]ContentBlock, len(blocks))
+	scrubbed := make([]int, len(blocks))
+	sem := make(chan struct{}, max(e.toolConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, block := range blocks {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			results[i], scrubbed[i] = e.executeTool(ctx, logger, block, i+1, triageID, fingerprint)
+		})
+	}
+	wg.Wait()
 
+	for i := range results {
+		totalDur += results[i].Duration
+		redactions += scrubbed[i]
+	}
+	return results, len(blocks), redactions, totalDur
+}
+
+// executeTool runs a single tool_use block and converts the outcome into a
+// tool_result block, scrubbed of secrets. It also returns how many were
+// removed.
+func (e *LLMEngine) executeTool(ctx context.Context, logger log.Logger, block *ContentBlock, callNumber int, triageID, fingerprint string) (ContentBlock, int) {
+	logger.Info(ctx, "executing tool", "tool", block.Name, "call_number", callNumber)
+
+	tool, ok := e.registry.Get(block.Name)
+	if !ok {
 		toolCtx, toolSpan := e.tracer.Start(ctx, "tool.execute", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
 			attribute.String("gen_ai.operation.name", "tool.execute"),
 			attribute.String("gen_ai.tool.name", block.Name),
 			attribute.String("gen_ai.tool.call.id", block.ID),
-			attribute.Int("vigil.tool.input_bytes", len(block.Input)),
+			attribute.Bool("vigil.tool.is_error", true),
 			attribute.String("vigil.triage.id", triageID),
 			attribute.String("vigil.alert.fingerprint", fingerprint),
 			attribute.String("vigil.tool.input", truncateSpanField(string(block.Input), 1024)),
 		))
-
 		toolSpan.AddEvent("tool.request", trace.WithAttributes(
 			attribute.String("tool.request.body", string(block.Input)),
 		))
+		toolSpan.AddEvent("tool.result", trace.WithAttributes(
+			attribute.String("tool.result.body", fmt.Sprintf("unknown tool: %s", block.Name)),
+		))
+		toolSpan.SetStatus(codes.Error, "unknown tool")
+		toolSpan.End()
 
-		toolStart := time.Now()
-		output, err := tool.Execute(toolCtx, block.Input)
-		toolDur := time.Since(toolStart).Seconds()
+		e.hooks.toolCall(toolCtx, block.Name, 0, len(block.Input), 0, true)
+		return ContentBlock{
+			Type:      "tool_result",
+			ToolUseID: block.ID,
+			Content:   fmt.Sprintf("unknown tool: %s", block.Name),
+			IsError:   true,
+		}, 0
+	}
 
-		toolSpan.SetAttributes(attribute.Float64("vigil.tool.duration_s", toolDur))
+	toolCtx, toolSpan := e.tracer.Start(ctx, "tool.execute", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.operation.name", "tool.execute"),
+		attribute.String("gen_ai.tool.name", block.Name),
+		attribute.String("gen_ai.tool.call.id", block.ID),
+		attribute.Int("vigil.tool.input_bytes", len(block.Input)),
+		attribute.String("vigil.triage.id", triageID),
+		attribute.String("vigil.alert.fingerprint", fingerprint),
+		attribute.String("vigil.tool.input", truncateSpanField(string(block.Input), 1024)),
+	))
 
-		totalDur += toolDur
+	toolSpan.AddEvent("tool.request", trace.WithAttributes(
+		attribute.String("tool.request.body", string(block.Input)),
+	))
 
-		if err != nil {
+	toolStart := time.Now()
+	output, err := tool.Execute(toolCtx, block.Input)
+	toolDur := time.Since(toolStart).Seconds()
+
+	toolSpan.SetAttributes(attribute.Float64("vigil.tool.duration_s", toolDur))
+
+	if err != nil {
+		schemaViolation := errors.Is(err, tools.ErrOutputSchema)
+		msg, redacted := e.scrub(block.Name, err.Error())
+		if redacted > 0 {
+			// Keep the secrets out of the logs and the span as well.
+			err = errors.New(msg)
+		}
+		if schemaViolation {
+			// The tool ran but returned something other than it promised,
+			// usually a malformed data source response.
+			logger.Warn(ctx, "tool output failed schema validation", "tool", block.Name, "duration", toolDur, "err", err)
+			toolSpan.SetAttributes(attribute.Bool("vigil.tool.schema_violation", true))
+			e.hooks.toolOutputViolation(block.Name)
+		} else {
 			logger.Error(ctx, err, "tool execution failed", "tool", block.Name, "duration", toolDur)
-			toolSpan.AddEvent("tool.result", trace.WithAttributes(
-				attribute.String("tool.result.body", err.Error()),
-			))
-			toolSpan.SetAttributes(
-				attribute.Int("vigil.tool.output_bytes", 0),
-				attribute.Bool("vigil.tool.is_error", true),
-			)
-			toolSpan.RecordError(err)
-			toolSpan.SetStatus(codes.Error, err.Error())
-			toolSpan.End()
-
-			e.hooks.toolCall(block.Name, toolDur, len(block.Input), 0, true)
-			results = append(results, ContentBlock{
-				Type:      "tool_result",
-				ToolUseID: block.ID,
-				Content:   fmt.Sprintf("tool error: %v", err),
-				IsError:   true,
-				Duration:  toolDur,
-			})
-			continue
 		}
-
 		toolSpan.AddEvent("tool.result", trace.WithAttributes(
-			attribute.String("tool.result.body", string(output)),
+			attribute.String("tool.result.body", msg),
 		))
 		toolSpan.SetAttributes(
-			attribute.Int("vigil.tool.output_bytes", len(output)),
-			attribute.Bool("vigil.tool.is_error", false),
+			attribute.Int("vigil.tool.output_bytes", 0),
+			attribute.Bool("vigil.tool.is_error", true),
+			attribute.Int("vigil.tool.redactions", redacted),
 		)
-		toolSpan.SetStatus(codes.Ok, "")
+		toolSpan.RecordError(err)
+		toolSpan.SetStatus(codes.Error, msg)
 		toolSpan.End()
 
-		logger.Info(ctx, "tool complete", "tool", block.Name, "duration", toolDur)
-		e.hooks.toolCall(block.Name, toolDur, len(block.Input), len(output), false)
-		results = append(results, ContentBlock{
+		e.hooks.toolCall(toolCtx, block.Name, toolDur, len(block.Input), 0, true)
+		e.observeTool(toolCtx, triageID, block, msg, true, toolDur)
+		return ContentBlock{
 			Type:      "tool_result",
 			ToolUseID: block.ID,
-			Content:   string(output),
+			Content:   "tool error: " + msg,
+			IsError:   true,
 			Duration:  toolDur,
-		})
+		}, redacted
 	}
-	return results, calls, totalDur
+
+	content, redacted := e.scrub(block.Name, string(output))
+	toolSpan.AddEvent("tool.result", trace.WithAttributes(
+		attribute.String("tool.result.body", content),
+	))
+	toolSpan.SetAttributes(
+		attribute.Int("vigil.tool.output_bytes", len(output)),
+		attribute.Bool("vigil.tool.is_error", false),
+		attribute.Int("vigil.tool.redactions", redacted),
+	)
+	toolSpan.SetStatus(codes.Ok, "")
+	toolSpan.End()
+
+	logger.Info(ctx, "tool complete", "tool", block.Name, "duration", toolDur)
+	e.hooks.toolCall(toolCtx, block.Name, toolDur, len(block.Input), len(output), false)
+	e.observeTool(toolCtx, triageID, block, content, false, toolDur)
+	return ContentBlock{
+		Type:      "tool_result",
+		ToolUseID: block.ID,
+		Content:   content,
+		Duration:  toolDur,
+	}, redacted
 }
 
 func sortedKeys(m map[string]struct{}) []string {
@@ -473,9 +1084,10 @@ func marshalContent(blocks []ContentBlock) string {
 	return string(b)
 }
 
-// buildSystemPrompt constructs the system prompt for the LLM.
-func buildSystemPrompt(_ *alert.Alert) string {
-	return `You are Vigil, an infrastructure triage AI. You analyze alerts and diagnose root causes.
+// buildSystemPrompt constructs the system prompt for the LLM. Profile
+// instructions, if any, are appended as a separate section.
+func buildSystemPrompt(_ *alert.Alert, instructions string) string {
+	prompt := `You are Vigil, an infrastructure triage AI. You analyze alerts and diagnose root causes.
 
 You have access to tools that let you query metrics, read logs, and inspect infrastructure.
 Use them to investigate the alert, then provide a concise analysis with:
@@ -485,10 +1097,27 @@ Use them to investigate the alert, then provide a concise analysis with:
 4. Severity assessment (is this urgent or can it wait?)
 
 Be concise and operational. This goes to an engineer's Slack channel.`
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		prompt += "\n\nTeam-specific guidance:\n" + instructions
+	}
+	return prompt
+}
+
+// unavailableToolsNote tells the model which tools are withheld because
+// their data source is failing. It returns "" when every tool is available.
+func unavailableToolsNote(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return "\n\nUnavailable data sources: the following tools are temporarily disabled because their backends are failing: " +
+		strings.Join(names, ", ") +
+		". Investigate with the remaining tools and state in your analysis which data could not be checked."
 }
 
-// buildInitialPrompt constructs the initial user message for the LLM.
-func buildInitialPrompt(al *alert.Alert) string {
+// buildInitialPrompt constructs the initial user message for the LLM,
+// including the operator's metadata for the alert and the maintenance window
+// it fired in when there are any.
+func buildInitialPrompt(al *alert.Alert, md *Metadata, mw *MaintenanceWindow) string {
 	labels, _ := json.MarshalIndent(al.Labels, "", "  ")
 	annotations, _ := json.MarshalIndent(al.Annotations, "", "  ")
 
@@ -503,7 +1132,7 @@ Labels:
 Annotations:
 %s
 
-Generator: %s
+Generator: %s%s%s
 
 Please investigate this alert using the available tools and provide your analysis.`,
 		al.Labels["alertname"],
@@ -513,5 +1142,7 @@ Please investigate this alert using the available tools and provide your analysi
 		string(labels),
 		string(annotations),
 		al.GeneratorURL,
+		metadataSection(md),
+		maintenanceSection(mw),
 	)
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
@@ -23,13 +27,15 @@ type mockProvider struct {
 	responses []*LLMResponse
 	errs      []error
 	callIdx   int
+	reqs      []*LLMRequest
 }
 
 const claudeTestModel = "claude-sonnet-4-20250514"
 
-func (m *mockProvider) Send(_ context.Context, _ *LLMRequest) (*LLMResponse, error) {
+func (m *mockProvider) Send(_ context.Context, req *LLMRequest) (*LLMResponse, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.reqs = append(m.reqs, req)
 
 	idx := m.callIdx
 	m.callIdx++
@@ -275,6 +281,104 @@ func TestRun_ToolExecutionError(t *testing.T) {
 	}
 }
 
+func TestRun_CircuitBreakerWithholdsTool(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry(tools.WithCircuitBreaker(tools.BreakerConfig{Threshold: 1, Cooldown: time.Hour}))
+	registry.Register(&mockTool{
+		name: "query_logs",
+		err:  fmt.Errorf("loki query failed: %w", tools.ErrUnavailable),
+	})
+
+	provider := &mockProvider{
+		responses: []*LLMResponse{
+			{
+				Content: []ContentBlock{
+					{Type: "tool_use", ID: "call-1", Name: "query_logs", Input: json.RawMessage(`{}`)},
+				},
+				StopReason: StopToolUse,
+				Usage:      Usage{InputTokens: 50, OutputTokens: 30},
+			},
+			{
+				Content:    []ContentBlock{{Type: "text", Text: "logs unavailable"}},
+				StopReason: StopEnd,
+				Usage:      Usage{InputTokens: 100, OutputTokens: 60},
+			},
+		},
+	}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil)
+
+	if rr.Status != StatusComplete {
+		t.Fatalf("status = %q, want %q", rr.Status, StatusComplete)
+	}
+	if len(provider.reqs) != 2 {
+		t.Fatalf("llm calls = %d, want 2", len(provider.reqs))
+	}
+	first, second := provider.reqs[0], provider.reqs[1]
+	if len(first.Tools) != 1 || strings.Contains(first.System, "Unavailable data sources") {
+		t.Errorf("first request should offer the tool without a note: tools=%d", len(first.Tools))
+	}
+	if len(second.Tools) != 0 {
+		t.Errorf("second request tools = %v, want none", second.Tools)
+	}
+	if !strings.Contains(second.System, "Unavailable data sources") || !strings.Contains(second.System, "query_logs") {
+		t.Errorf("second system prompt missing unavailable note:\n%s", second.System)
+	}
+	if rr.SystemPrompt != second.System {
+		t.Error("recorded system prompt should be the last one sent")
+	}
+}
+
+func TestRun_InvestigationNotes(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_metrics", output: json.RawMessage(`{"value": 42}`)})
+
+	provider := &mockProvider{
+		responses: []*LLMResponse{
+			{
+				Content: []ContentBlock{
+					{Type: "text", Text: "CPU alerts on this host are usually cron. Checking load first."},
+					{Type: "tool_use", ID: "call-1", Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)},
+				},
+				StopReason: StopToolUse,
+				Usage:      Usage{InputTokens: 50, OutputTokens: 30},
+			},
+			{
+				Content: []ContentBlock{
+					{Type: "text", Text: "  "},
+					{Type: "text", Text: "Load is flat, so it is not cron."},
+					{Type: "text", Text: "Root cause: a memory leak."},
+				},
+				StopReason: StopEnd,
+				Usage:      Usage{InputTokens: 100, OutputTokens: 60},
+			},
+		},
+	}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil)
+
+	if rr.Analysis != "Root cause: a memory leak." {
+		t.Errorf("analysis = %q", rr.Analysis)
+	}
+	if len(rr.Notes) != 2 {
+		t.Fatalf("notes = %+v, want 2", rr.Notes)
+	}
+	if rr.Notes[0].Turn != 0 || !strings.HasPrefix(rr.Notes[0].Text, "CPU alerts") {
+		t.Errorf("notes[0] = %+v", rr.Notes[0])
+	}
+	if rr.Notes[1].Turn != 2 || rr.Notes[1].Text != "Load is flat, so it is not cron." {
+		t.Errorf("notes[1] = %+v", rr.Notes[1])
+	}
+	if rr.Notes[0].Timestamp.IsZero() {
+		t.Error("note timestamp not set")
+	}
+}
+
 func TestRun_LLMError(t *testing.T) {
 	t.Parallel()
 
@@ -331,6 +435,227 @@ func TestRun_MaxToolRoundsLimit(t *testing.T) {
 	}
 }
 
+func TestRun_WithBudget(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{
+		name:   "loop_tool",
+		output: json.RawMessage(`"ok"`),
+	})
+
+	responses := make([]*LLMResponse, MaxToolRounds)
+	for i := range MaxToolRounds {
+		responses[i] = &LLMResponse{
+			Content: []ContentBlock{
+				{Type: "tool_use", ID: "call-" + strings.Repeat("x", i+1), Name: "loop_tool", Input: json.RawMessage(`{}`)},
+			},
+			StopReason: StopToolUse,
+			Usage:      Usage{InputTokens: 10, OutputTokens: 5},
+		}
+	}
+
+	provider := &mockProvider{responses: responses}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil, WithBudget(Budget{ToolCalls: 3}))
+
+	if rr.Status != StatusMaxTurns {
+		t.Errorf("status = %q, want %q", rr.Status, StatusMaxTurns)
+	}
+	if rr.ToolCalls != 3 {
+		t.Errorf("tool_calls = %d, want 3", rr.ToolCalls)
+	}
+}
+
+func TestRun_WrapUpAtToolBudget(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "loop_tool", output: json.RawMessage(`"ok"`)})
+	toolUse := func(id string) *LLMResponse {
+		return &LLMResponse{
+			Content:    []ContentBlock{{Type: "tool_use", ID: id, Name: "loop_tool", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		}
+	}
+
+	t.Run("text analysis", func(t *testing.T) {
+		t.Parallel()
+		provider := &mockProvider{responses: []*LLMResponse{
+			toolUse("call-1"),
+			toolUse("call-2"),
+			{Content: []ContentBlock{{Type: "text", Text: "disk is nearly full"}}, StopReason: StopEnd},
+		}}
+		engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+		rr := engine.Run(context.Background(), "t1", testAlert(), nil, WithBudget(Budget{ToolCalls: 2}))
+		if rr.Status != StatusMaxTurns || rr.Analysis != wrapUpPrefix+"disk is nearly full" {
+			t.Errorf("result = %q %q", rr.Status, rr.Analysis)
+		}
+		if len(provider.reqs) != 3 {
+			t.Fatalf("LLM calls = %d, want 3", len(provider.reqs))
+		}
+		if got := provider.reqs[1].ToolChoice; got.Type != "" {
+			t.Errorf("tool choice before the limit = %+v, want default", got)
+		}
+		wrap := provider.reqs[2]
+		if wrap.ToolChoice.Type != ToolChoiceNone {
+			t.Errorf("wrap-up tool choice = %+v, want none", wrap.ToolChoice)
+		}
+		last := wrap.Messages[len(wrap.Messages)-1].Content
+		if last[0].Type != "tool_result" || last[len(last)-1].Text != wrapUpNudge {
+			t.Errorf("wrap-up message = %+v", last)
+		}
+	})
+
+	t.Run("tool call ignored", func(t *testing.T) {
+		t.Parallel()
+		provider := &mockProvider{responses: []*LLMResponse{toolUse("call-1"), toolUse("call-2")}}
+		engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+		rr := engine.Run(context.Background(), "t1", testAlert(), nil, WithBudget(Budget{ToolCalls: 1}))
+		if rr.Status != StatusMaxTurns || rr.ToolCalls != 1 || !strings.Contains(rr.Analysis, "tool call budget exhausted") {
+			t.Errorf("result = %q after %d tool calls: %q", rr.Status, rr.ToolCalls, rr.Analysis)
+		}
+	})
+}
+
+func TestRun_ContinuesTruncatedResponses(t *testing.T) {
+	t.Parallel()
+
+	cutOff := func(text string) *LLMResponse {
+		return &LLMResponse{Content: []ContentBlock{{Type: "text", Text: text}}, StopReason: StopMaxTokens}
+	}
+
+	t.Run("stitches the analysis", func(t *testing.T) {
+		t.Parallel()
+		provider := &mockProvider{responses: []*LLMResponse{
+			cutOff("The disk filled up because "),
+			cutOff("the log rotation job "),
+			{Content: []ContentBlock{{Type: "text", Text: "stopped running."}}, StopReason: StopEnd},
+		}}
+		engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+		rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+		want := "The disk filled up because the log rotation job stopped running."
+		if rr.Status != StatusComplete || rr.Analysis != want {
+			t.Errorf("result = %q %q, want complete %q", rr.Status, rr.Analysis, want)
+		}
+		if len(rr.Notes) != 0 {
+			t.Errorf("notes = %+v, want none", rr.Notes)
+		}
+		req := provider.reqs[1]
+		msgs := req.Messages
+		if len(msgs) != 3 || msgs[1].Role != "assistant" || msgs[2].Content[0].Text != continueNudge {
+			t.Errorf("continuation messages = %+v", msgs)
+		}
+	})
+
+	t.Run("cut-off tool call dropped", func(t *testing.T) {
+		t.Parallel()
+		provider := &mockProvider{responses: []*LLMResponse{
+			{Content: []ContentBlock{{Type: "tool_use", ID: "call-1", Name: "query_logs"}}, StopReason: StopMaxTokens},
+			{Content: []ContentBlock{{Type: "text", Text: "done"}}, StopReason: StopEnd},
+		}}
+		engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+		rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+		if rr.Status != StatusComplete || rr.Analysis != "done" {
+			t.Errorf("result = %q %q", rr.Status, rr.Analysis)
+		}
+		msgs := provider.reqs[1].Messages
+		if len(msgs) != 1 || msgs[0].Content[len(msgs[0].Content)-1].Text != truncatedNudge {
+			t.Errorf("retry messages = %+v", msgs)
+		}
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		t.Parallel()
+		provider := &mockProvider{responses: []*LLMResponse{cutOff("a"), cutOff("b"), cutOff("c"), cutOff("d")}}
+		engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+		rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+		if rr.Status != StatusBudgetExceeded || !strings.Contains(rr.Analysis, "truncated at the token limit") {
+			t.Errorf("result = %q %q", rr.Status, rr.Analysis)
+		}
+		if len(provider.reqs) != MaxContinuations+1 {
+			t.Errorf("LLM calls = %d, want %d", len(provider.reqs), MaxContinuations+1)
+		}
+	})
+}
+
+func TestRun_Temperature(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{}
+	NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithTemperature(0.3)).
+		Run(context.Background(), "t1", testAlert(), nil)
+	if temp := provider.reqs[0].Temperature; temp == nil || *temp != 0.3 {
+		t.Errorf("temperature = %v, want 0.3", temp)
+	}
+
+	provider = &mockProvider{}
+	NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithTemperature(0.3), WithThinking(2048)).
+		Run(context.Background(), "t1", testAlert(), nil)
+	if temp := provider.reqs[0].Temperature; temp != nil {
+		t.Errorf("temperature = %v with thinking, want unset", *temp)
+	}
+}
+
+func TestRun_UnusableResponses(t *testing.T) {
+	t.Parallel()
+
+	refusal := &LLMResponse{
+		Content:    []ContentBlock{{Type: "text", Text: "I can't help with that."}},
+		StopReason: StopRefusal,
+	}
+	empty := &LLMResponse{StopReason: StopEnd, Content: []ContentBlock{{Type: "text", Text: "  "}}}
+	answer := &LLMResponse{
+		Content:    []ContentBlock{{Type: "text", Text: "Disk is full."}},
+		StopReason: StopEnd,
+	}
+
+	tests := []struct {
+		name         string
+		responses    []*LLMResponse
+		wantStatus   Status
+		wantAnalysis string
+		wantNudge    string
+	}{
+		{"refusal then answer", []*LLMResponse{refusal, answer}, StatusComplete, "Disk is full.", "authorized operations task"},
+		{"refused twice", []*LLMResponse{refusal, refusal}, StatusRefused, "Triage refused by the model: I can't help with that.", "authorized operations task"},
+		{"empty then answer", []*LLMResponse{empty, answer}, StatusComplete, "Disk is full.", "last response was empty"},
+		{"empty twice", []*LLMResponse{empty, empty}, StatusFailed, "LLM error: empty response", "last response was empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			provider := &mockProvider{responses: tt.responses}
+			engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+			rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil)
+
+			if rr.Status != tt.wantStatus || rr.Analysis != tt.wantAnalysis {
+				t.Errorf("result = %q %q, want %q %q", rr.Status, rr.Analysis, tt.wantStatus, tt.wantAnalysis)
+			}
+			if len(provider.reqs) != 2 {
+				t.Fatalf("provider calls = %d, want 2", len(provider.reqs))
+			}
+			// The retry resends the original prompt plus the nudge, without
+			// the unusable response.
+			retry := provider.reqs[1].Messages
+			if len(retry) != 1 || len(retry[0].Content) != 2 || !strings.Contains(retry[0].Content[1].Text, tt.wantNudge) {
+				t.Errorf("retry messages = %+v, want the prompt and a nudge containing %q", retry, tt.wantNudge)
+			}
+			if len(provider.reqs[0].Messages[0].Content) != 1 {
+				t.Error("nudge leaked into the first request")
+			}
+		})
+	}
+}
+
 func TestRun_MaxInputTokensLimit(t *testing.T) { //nolint:dupl // intentionally similar to TestRun_MaxOutputTokensLimit but exercises a different code path
 	t.Parallel()
 
@@ -414,7 +739,7 @@ func TestRun_MaxOutputTokensLimit(t *testing.T) { //nolint:dupl // intentionally
 func TestBuildSystemPrompt(t *testing.T) {
 	t.Parallel()
 
-	prompt := buildSystemPrompt(testAlert())
+	prompt := buildSystemPrompt(testAlert(), "")
 	if prompt == "" {
 		t.Fatal("expected non-empty system prompt")
 	}
@@ -423,11 +748,23 @@ func TestBuildSystemPrompt(t *testing.T) {
 	}
 }
 
+func TestBuildSystemPrompt_Instructions(t *testing.T) {
+	t.Parallel()
+
+	prompt := buildSystemPrompt(testAlert(), "  Check the payments-db replicas first.\n")
+	if !strings.HasSuffix(prompt, "Team-specific guidance:\nCheck the payments-db replicas first.") {
+		t.Errorf("instructions not appended:\n%s", prompt)
+	}
+	if strings.Contains(buildSystemPrompt(testAlert(), " "), "Team-specific") {
+		t.Error("blank instructions should not add a guidance section")
+	}
+}
+
 func TestBuildInitialPrompt(t *testing.T) {
 	t.Parallel()
 
 	al := testAlert()
-	prompt := buildInitialPrompt(al)
+	prompt := buildInitialPrompt(al, nil, nil)
 
 	for _, want := range []string{"TestAlert", "critical", "firing", "test summary"} {
 		if !strings.Contains(prompt, want) {
@@ -649,14 +986,14 @@ func TestRun_HooksCalled(t *testing.T) {
 	)
 
 	hooks := EngineHooks{
-		OnLLMCall: func(in, out int, _ float64) {
+		OnLLMCall: func(_ context.Context, in, out int, _ float64) {
 			mu.Lock()
 			defer mu.Unlock()
 			llmCalls++
 			totalTokensIn += in
 			totalTokensOut += out
 		},
-		OnToolCall: func(name string, _ float64, _, _ int, isErr bool) {
+		OnToolCall: func(_ context.Context, name string, _ float64, _, _ int, isErr bool) {
 			mu.Lock()
 			defer mu.Unlock()
 			toolCalls++
@@ -857,3 +1194,243 @@ func TestRun_CreatesSpans(t *testing.T) { //nolint:gocognit // its a complex tes
 		t.Errorf("OutputTokensUsed = %d, want 130", rr.OutputTokensUsed)
 	}
 }
+
+// barrierTool blocks until `want` calls are executing concurrently, proving parallel dispatch.
+type barrierTool struct {
+	name    string
+	want    int32
+	running atomic.Int32
+	release chan struct{}
+	once    sync.Once
+}
+
+func (b *barrierTool) Name() string                { return b.name }
+func (b *barrierTool) Description() string         { return "barrier tool" }
+func (b *barrierTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (b *barrierTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	if b.running.Add(1) >= b.want {
+		b.once.Do(func() { close(b.release) })
+	}
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return params, nil
+}
+
+func TestRun_ParallelToolCallsPreserveOrder(t *testing.T) {
+	t.Parallel()
+
+	bt := &barrierTool{name: "barrier", want: 3, release: make(chan struct{})}
+	registry := tools.NewRegistry()
+	registry.Register(bt)
+
+	provider := &mockProvider{
+		responses: []*LLMResponse{
+			{
+				Content: []ContentBlock{
+					{Type: "tool_use", ID: "call-1", Name: "barrier", Input: json.RawMessage(`{"n":1}`)},
+					{Type: "tool_use", ID: "call-2", Name: "barrier", Input: json.RawMessage(`{"n":2}`)},
+					{Type: "tool_use", ID: "call-3", Name: "barrier", Input: json.RawMessage(`{"n":3}`)},
+				},
+				StopReason: StopToolUse,
+			},
+			{
+				Content:    []ContentBlock{{Type: "text", Text: "done"}},
+				StopReason: StopEnd,
+			},
+		},
+	}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithToolConcurrency(3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	rr := engine.Run(ctx, "test-triage-id", testAlert(), nil)
+
+	if rr.ToolCalls != 3 {
+		t.Fatalf("tool_calls = %d, want 3", rr.ToolCalls)
+	}
+	results := rr.Conversation.Turns[1].Content
+	for i, want := range []string{"call-1", "call-2", "call-3"} {
+		if results[i].ToolUseID != want {
+			t.Errorf("results[%d].ToolUseID = %q, want %q", i, results[i].ToolUseID, want)
+		}
+		if results[i].IsError {
+			t.Errorf("results[%d] is error: %s (tools did not run in parallel)", i, results[i].Content)
+		}
+	}
+}
+
+func TestWithToolConcurrency_ClampsToOne(t *testing.T) {
+	t.Parallel()
+
+	e := NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithToolConcurrency(-5))
+	if e.toolConcurrency != 1 {
+		t.Errorf("toolConcurrency = %d, want 1", e.toolConcurrency)
+	}
+}
+
+func TestRun_CancelledContext(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrCancelled)
+	rr := engine.Run(ctx, "cancelled-id", testAlert(), nil)
+
+	if rr.Status != StatusError {
+		t.Errorf("status = %q, want %q", rr.Status, StatusError)
+	}
+	if rr.Analysis != "Triage terminated: cancelled by operator" {
+		t.Errorf("analysis = %q", rr.Analysis)
+	}
+	if len(provider.reqs) != 0 {
+		t.Errorf("provider called %d times after cancellation", len(provider.reqs))
+	}
+}
+
+// streamingProvider streams the text of each mockProvider response in chunks.
+type streamingProvider struct {
+	mockProvider
+	chunk    int
+	streamed int
+}
+
+func (p *streamingProvider) SendStream(ctx context.Context, req *LLMRequest, onText func(string)) (*LLMResponse, error) {
+	resp, err := p.Send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.streamed++
+	p.mu.Unlock()
+	for _, b := range resp.Content {
+		for s := b.Text; s != ""; {
+			n := min(p.chunk, len(s))
+			onText(s[:n])
+			s = s[n:]
+		}
+	}
+	return resp, nil
+}
+
+func TestRun_StreamsPartialText(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Repeat("a", 2500)
+	newProvider := func() *streamingProvider {
+		return &streamingProvider{chunk: 100, mockProvider: mockProvider{responses: []*LLMResponse{{
+			Content:    []ContentBlock{{Type: "text", Text: text}},
+			StopReason: StopEnd,
+		}}}}
+	}
+
+	provider := newProvider()
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	var partials []int
+	rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil, WithPartial(func(_ context.Context, s string) error {
+		partials = append(partials, len(s))
+		return nil
+	}))
+	if rr.Status != StatusComplete || rr.Analysis != text {
+		t.Fatalf("result = %q with %d bytes of analysis, want complete with the full text", rr.Status, len(rr.Analysis))
+	}
+	// Flushed each time PartialFlushBytes more arrived, then cleared.
+	if want := []int{1100, 2200, 0}; !slices.Equal(partials, want) {
+		t.Errorf("partial lengths = %v, want %v", partials, want)
+	}
+
+	// Without a partial callback the response is not streamed.
+	provider = newProvider()
+	engine = NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	if rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil); rr.Status != StatusComplete || provider.streamed != 0 {
+		t.Errorf("status = %q, streamed = %d; want complete without streaming", rr.Status, provider.streamed)
+	}
+}
+
+// contractTool is a mockTool that declares an output schema.
+type contractTool struct {
+	mockTool
+}
+
+func (c *contractTool) OutputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","required":["result_count"]}`)
+}
+
+func TestRun_ToolOutputViolation(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&contractTool{mockTool{name: "strict_tool", output: json.RawMessage(`{"error":"upstream returned HTML"}`)}})
+
+	provider := &mockProvider{
+		responses: []*LLMResponse{
+			{
+				Content: []ContentBlock{
+					{Type: "tool_use", ID: "call-1", Name: "strict_tool", Input: json.RawMessage(`{}`)},
+				},
+				StopReason: StopToolUse,
+				Usage:      Usage{InputTokens: 50, OutputTokens: 30},
+			},
+		},
+	}
+
+	var (
+		mu         sync.Mutex
+		violations []string
+		toolErr    bool
+	)
+	hooks := EngineHooks{
+		OnToolCall: func(_ context.Context, _ string, _ float64, _, _ int, isErr bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			toolErr = isErr
+		},
+		OnToolOutputViolation: func(name string) {
+			mu.Lock()
+			defer mu.Unlock()
+			violations = append(violations, name)
+		},
+	}
+	engine := NewEngine(provider, registry, log.Nop(), hooks, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil)
+	if rr.Status != StatusComplete {
+		t.Fatalf("status = %q, want %q", rr.Status, StatusComplete)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(violations) != 1 || violations[0] != "strict_tool" {
+		t.Errorf("violations = %v, want [strict_tool]", violations)
+	}
+	if !toolErr {
+		t.Error("tool call not reported as an error")
+	}
+
+	// The malformed output never reaches the model, only the error does.
+	msgs := provider.reqs[1].Messages
+	result := msgs[len(msgs)-1].Content[0]
+	if !result.IsError || strings.Contains(result.Content, "upstream returned HTML") {
+		t.Errorf("tool result = %+v, want schema error without the output", result)
+	}
+}
+
+func TestRun_RecordsProvider(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "ok"}},
+		StopReason: StopEnd,
+		Model:      "claude-haiku-4-5",
+		Provider:   "fallback",
+	}}}
+	rr := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()).
+		Run(context.Background(), "t1", testAlert(), nil)
+	if rr.Provider != "fallback" || rr.Model != "claude-haiku-4-5" {
+		t.Errorf("provider = %q, model = %q; want fallback, claude-haiku-4-5", rr.Provider, rr.Model)
+	}
+}
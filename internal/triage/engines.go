@@ -0,0 +1,71 @@
+package triage
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// NopEngine completes every triage at once without calling a model or a
+// tool, for exercising the Service around the engine.
+type NopEngine struct{}
+
+// Run implements Engine with an empty, complete result.
+func (NopEngine) Run(context.Context, string, *alert.Alert, TurnCallback, ...RunOption) *RunResult {
+	return &RunResult{Status: StatusComplete, CompletedAt: time.Now()}
+}
+
+// Tools implements Engine. A NopEngine has none.
+func (NopEngine) Tools() []tools.ToolInfo {
+	return []tools.ToolInfo{}
+}
+
+// EngineCall is one Run of a RecordingEngine.
+type EngineCall struct {
+	TriageID string
+	Alert    *alert.Alert
+	Result   *RunResult
+}
+
+// RecordingEngine passes runs to another engine and keeps each call and its
+// result, so a test can check what the Service ran and got back. It is safe
+// for concurrent use.
+type RecordingEngine struct {
+	next Engine
+
+	mu    sync.Mutex
+	calls []EngineCall
+}
+
+// NewRecordingEngine records the runs of next, a NopEngine if nil.
+func NewRecordingEngine(next Engine) *RecordingEngine {
+	if next == nil {
+		next = NopEngine{}
+	}
+	return &RecordingEngine{next: next}
+}
+
+// Run implements Engine.
+func (r *RecordingEngine) Run(ctx context.Context, triageID string, al *alert.Alert, onTurn TurnCallback, opts ...RunOption) *RunResult {
+	rr := r.next.Run(ctx, triageID, al, onTurn, opts...)
+	r.mu.Lock()
+	r.calls = append(r.calls, EngineCall{TriageID: triageID, Alert: al, Result: rr})
+	r.mu.Unlock()
+	return rr
+}
+
+// Tools implements Engine with the tools of the wrapped engine.
+func (r *RecordingEngine) Tools() []tools.ToolInfo {
+	return r.next.Tools()
+}
+
+// Calls returns the runs so far, oldest first.
+func (r *RecordingEngine) Calls() []EngineCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
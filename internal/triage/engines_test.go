@@ -0,0 +1,47 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestRecordingEngine(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	notifier := newMockNotifier()
+	engine := NewRecordingEngine(nil)
+	svc := NewService(store, engine, log.Nop(), nil, notifier, noop.NewTracerProvider())
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-recording",
+		Labels:      map[string]string{"alertname": "RecordingTest"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	r := waitTerminal(t, store, sr.ID)
+	waitForFinish(t, svc, sr.ID)
+
+	if r.Status != StatusComplete {
+		t.Errorf("status = %s, want complete from the NopEngine", r.Status)
+	}
+	calls := engine.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("recorded %d runs, want 1", len(calls))
+	}
+	if calls[0].TriageID != sr.ID || calls[0].Alert.Fingerprint != "fp-recording" || calls[0].Result.Status != StatusComplete {
+		t.Errorf("call = %+v, want the submitted triage and its result", calls[0])
+	}
+
+	tl, err := svc.Tools(context.Background())
+	if err != nil || tl == nil || len(tl) != 0 {
+		t.Errorf("Tools = %v, %v; want none", tl, err)
+	}
+}
@@ -0,0 +1,78 @@
+package triage
+
+import (
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// Metadata is operator-provided context about what an alert is for, which
+// the alert's own labels rarely carry.
+type Metadata struct {
+	// Owner is the team or person responsible for the service.
+	Owner string `json:"owner,omitempty"`
+	// Tier is the service's criticality, such as "tier-1".
+	Tier string `json:"tier,omitempty"`
+	// Runbook links to the service's runbook.
+	Runbook string `json:"runbook,omitempty"`
+	// Dependencies are the services it relies on, worth checking when it
+	// misbehaves.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Enricher looks up the metadata for an alert. Returning nil means none is
+// known.
+type Enricher interface {
+	Enrich(al *alert.Alert) *Metadata
+}
+
+// WithEnricher sets the enricher consulted for every accepted alert. Its
+// metadata is stored on the Result and given to the model with the alert.
+func WithEnricher(e Enricher) ServiceOption {
+	return func(s *Service) {
+		s.enricher = e
+	}
+}
+
+// enrich returns the metadata for al, or nil.
+func (s *Service) enrich(al *alert.Alert) *Metadata {
+	if s.enricher == nil {
+		return nil
+	}
+	md := s.enricher.Enrich(al)
+	if md == nil || md.empty() {
+		return nil
+	}
+	return md
+}
+
+func (m *Metadata) empty() bool {
+	return m.Owner == "" && m.Tier == "" && m.Runbook == "" && len(m.Dependencies) == 0
+}
+
+// withMetadata adds md to the initial user message.
+func withMetadata(md *Metadata) RunOption {
+	return func(c *runConfig) { c.metadata = md }
+}
+
+// metadataSection renders md for the initial prompt. It returns "" for nil.
+func metadataSection(md *Metadata) string {
+	if md == nil || md.empty() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nService context (from the operator's inventory):\n")
+	if md.Owner != "" {
+		b.WriteString("Owner: " + md.Owner + "\n")
+	}
+	if md.Tier != "" {
+		b.WriteString("Tier: " + md.Tier + "\n")
+	}
+	if md.Runbook != "" {
+		b.WriteString("Runbook: " + md.Runbook + "\n")
+	}
+	if len(md.Dependencies) > 0 {
+		b.WriteString("Dependencies: " + strings.Join(md.Dependencies, ", ") + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,88 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+type enricherFunc func(*alert.Alert) *Metadata
+
+func (f enricherFunc) Enrich(al *alert.Alert) *Metadata { return f(al) }
+
+func TestSubmit_EnrichesResultAndPrompt(t *testing.T) {
+	t.Parallel()
+
+	enricher := enricherFunc(func(al *alert.Alert) *Metadata {
+		if al.Labels["service"] != "checkout" {
+			return &Metadata{}
+		}
+		return &Metadata{Owner: "team-payments", Tier: "tier-1", Dependencies: []string{"payments-db", "stripe"}}
+	})
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+	}}}
+	store := newMockStore()
+	notifier := newMockNotifier()
+	svc := NewService(store, NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, notifier, noop.NewTracerProvider(),
+		WithEnricher(enricher))
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-checkout",
+		Labels:      map[string]string{"alertname": "CheckoutErrors", "service": "checkout"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	select {
+	case <-notifier.called:
+	case <-time.After(2 * time.Second):
+		t.Fatal("notifier was not called within deadline")
+	}
+
+	r := waitTerminal(t, store, sr.ID)
+	if r.Metadata == nil || r.Metadata.Owner != "team-payments" {
+		t.Errorf("Metadata = %+v, want the enricher's", r.Metadata)
+	}
+	provider.mu.Lock()
+	prompt := provider.reqs[0].Messages[0].Content[0].Text
+	provider.mu.Unlock()
+	for _, want := range []string{"Owner: team-payments", "Tier: tier-1", "Dependencies: payments-db, stripe"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("initial prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Runbook:") {
+		t.Errorf("initial prompt has an empty runbook line:\n%s", prompt)
+	}
+
+	// Empty metadata is dropped rather than stored.
+	sr, err = svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-other",
+		Labels:      map[string]string{"alertname": "Other"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if r := waitTerminal(t, store, sr.ID); r.Metadata != nil {
+		t.Errorf("Metadata = %+v, want nil", r.Metadata)
+	}
+}
+
+func TestBuildInitialPrompt_NoMetadata(t *testing.T) {
+	t.Parallel()
+
+	prompt := buildInitialPrompt(&alert.Alert{Labels: map[string]string{"alertname": "X"}}, nil, nil)
+	if strings.Contains(prompt, "Service context") {
+		t.Errorf("prompt without metadata has a service context section:\n%s", prompt)
+	}
+}
@@ -0,0 +1,83 @@
+package triage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// WithMaxPerAlertname caps how many triages of the same alertname run at
+// once, e.g. HighCPU firing on many instances together. Simultaneous runs
+// of one alert type usually reach the same conclusion, so the rest wait in
+// StatusPending until one finishes, without holding a WithMaxConcurrent
+// slot. n <= 0 means unbounded.
+func WithMaxPerAlertname(n int) ServiceOption {
+	return func(s *Service) {
+		if n > 0 {
+			s.families = &familyLimiter{limit: n, sems: make(map[string]*familySem)}
+		}
+	}
+}
+
+// familyLimiter holds one semaphore per alertname, dropped once no triage
+// of that name is running or waiting.
+type familyLimiter struct {
+	limit int
+
+	mu   sync.Mutex
+	sems map[string]*familySem
+}
+
+type familySem struct {
+	slots chan struct{}
+	users int
+}
+
+// acquire blocks until a triage of name may run and returns the func that
+// frees its place. If ctx ends first it returns without holding a place,
+// and the run that follows stops at once on the same ctx.
+func (f *familyLimiter) acquire(ctx context.Context, name string) (release func()) {
+	f.mu.Lock()
+	sem := f.sems[name]
+	if sem == nil {
+		sem = &familySem{slots: make(chan struct{}, f.limit)}
+		f.sems[name] = sem
+	}
+	sem.users++
+	f.mu.Unlock()
+
+	done := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if sem.users--; sem.users == 0 {
+			delete(f.sems, name)
+		}
+	}
+	select {
+	case sem.slots <- struct{}{}:
+		return func() {
+			<-sem.slots
+			done()
+		}
+	case <-ctx.Done():
+		done()
+		return func() {}
+	}
+}
+
+// waitForFamily blocks until fewer than the per-alertname cap of triages
+// named name are running.
+func (s *Service) waitForFamily(ctx context.Context, L log.Logger, name string, span trace.Span) (release func()) {
+	start := time.Now()
+	release = s.families.acquire(ctx, name)
+	if wait := time.Since(start); wait >= time.Second {
+		L.Info(ctx, "triage waited for others of its alertname", "wait", wait, "limit", s.families.limit)
+		span.SetAttributes(attribute.Float64("vigil.triage.alertname_wait_seconds", wait.Seconds()))
+	}
+	return release
+}
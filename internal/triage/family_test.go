@@ -0,0 +1,116 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestFamilyLimiter(t *testing.T) {
+	t.Parallel()
+
+	f := &familyLimiter{limit: 2, sems: make(map[string]*familySem)}
+	ctx := context.Background()
+	r1 := f.acquire(ctx, "HighCPU")
+	r2 := f.acquire(ctx, "HighCPU")
+
+	// Another alertname is not held up by a full family.
+	f.acquire(ctx, "DiskFull")()
+
+	acquired := make(chan func())
+	go func() { acquired <- f.acquire(ctx, "HighCPU") }()
+	select {
+	case <-acquired:
+		t.Fatal("third HighCPU triage acquired past the limit of 2")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	f.acquire(cctx, "HighCPU")() // returns at once without a place
+
+	r1()
+	var r3 func()
+	select {
+	case r3 = <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiting triage not admitted after a release")
+	}
+	r2()
+	r3()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sems) != 0 {
+		t.Errorf("%d semaphores left after all released, want 0", len(f.sems))
+	}
+}
+
+func TestSubmit_MaxPerAlertnameKeepsExcessPending(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 3), release: make(chan struct{})}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithMaxPerAlertname(1))
+
+	submit := func(fp, name string) string {
+		t.Helper()
+		sr, err := svc.Submit(context.Background(), &alert.Alert{
+			Status:      "firing",
+			Fingerprint: fp,
+			Labels:      map[string]string{"alertname": name},
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return sr.ID
+	}
+	waitStarted := func(what string) {
+		t.Helper()
+		select {
+		case <-provider.started:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s did not start", what)
+		}
+	}
+
+	ids := []string{submit("fp-cpu-1", "HighCPU")}
+	waitStarted("first HighCPU triage")
+	ids = append(ids, submit("fp-disk", "DiskFull"))
+	waitStarted("DiskFull triage")
+	second := submit("fp-cpu-2", "HighCPU")
+	ids = append(ids, second)
+
+	select {
+	case <-provider.started:
+		t.Fatal("second HighCPU triage started while the first was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if r, _, _ := store.Get(context.Background(), second); r.Status != StatusPending {
+		t.Errorf("second HighCPU status = %q, want %q", r.Status, StatusPending)
+	}
+
+	close(provider.release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		done := 0
+		for _, id := range ids {
+			if r, _, _ := store.Get(context.Background(), id); r.Status.IsTerminal() {
+				done++
+			}
+		}
+		if done == len(ids) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("triages did not complete within deadline")
+}
@@ -0,0 +1,68 @@
+package triage
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// Filter rule actions.
+const (
+	// FilterTriage triages the alert as usual. It stops later rules from
+	// matching, to carve exceptions out of broader skip rules.
+	FilterTriage = "triage"
+	// FilterSkip drops the alert without triaging it.
+	FilterSkip = "skip"
+	// FilterDowngrade triages the alert as a dry run, see WithDryRun.
+	FilterDowngrade = "downgrade"
+)
+
+// FilterRule is the rule a Filter matched for an alert.
+type FilterRule struct {
+	// Name identifies the rule in logs, metrics, and submit decisions.
+	Name   string
+	Action string
+	// DryRun rules are logged and counted but not applied, so a new rule
+	// can be watched before it takes effect.
+	DryRun bool
+}
+
+// Filter decides at ingestion how an alert is handled. Returning nil means
+// no rule matched and the alert is triaged as usual.
+type Filter interface {
+	Match(al *alert.Alert) *FilterRule
+}
+
+// WithFilter sets the filter consulted for every firing alert before
+// profiles, suppressions, and dedup.
+func WithFilter(f Filter) ServiceOption {
+	return func(s *Service) {
+		s.filter = f
+	}
+}
+
+// filterRule returns the rule to apply to al, or nil when no rule matched
+// or the matching rule is a dry run.
+func (s *Service) filterRule(ctx context.Context, al *alert.Alert) *FilterRule {
+	if s.filter == nil {
+		return nil
+	}
+	rule := s.filter.Match(al)
+	if rule == nil {
+		return nil
+	}
+	if s.metrics != nil {
+		s.metrics.FilterMatchesTotal.WithLabelValues(rule.Name, rule.Action, strconv.FormatBool(rule.DryRun)).Inc()
+	}
+	if rule.DryRun {
+		s.logger.Info(ctx, "filter rule matched in dry run, not applied",
+			"rule", rule.Name,
+			"action", rule.Action,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+		return nil
+	}
+	return rule
+}
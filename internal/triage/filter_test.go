@@ -0,0 +1,116 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+type filterFunc func(*alert.Alert) *FilterRule
+
+func (f filterFunc) Match(al *alert.Alert) *FilterRule { return f(al) }
+
+func TestSubmit_Filter(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "loop_tool", output: json.RawMessage(`"ok"`)})
+	responses := make([]*LLMResponse, 2*MaxToolRounds)
+	for i := range responses {
+		responses[i] = &LLMResponse{
+			Content:    []ContentBlock{{Type: "tool_use", ID: fmt.Sprintf("call-%d", i), Name: "loop_tool", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		}
+	}
+	store := newMockStore()
+	dl := &fakeDecisionLog{}
+	engine := NewEngine(&mockProvider{responses: responses}, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithDecisionLog(dl),
+		WithFilter(filterFunc(func(al *alert.Alert) *FilterRule {
+			switch al.Labels["alertname"] {
+			case "Watchdog":
+				return &FilterRule{Name: "watchdog", Action: FilterSkip}
+			case "DevNoise":
+				return &FilterRule{Name: "dev", Action: FilterDowngrade}
+			case "Trial":
+				return &FilterRule{Name: "trial", Action: FilterSkip, DryRun: true}
+			}
+			return nil
+		})),
+	)
+	ctx := context.Background()
+	firing := func(name string) *alert.Alert {
+		return &alert.Alert{Status: "firing", Fingerprint: "fp-" + name, Labels: map[string]string{"alertname": name}}
+	}
+
+	sr, err := svc.Submit(ctx, firing("Watchdog"))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !sr.Skipped || sr.Reason != "filtered: watchdog" {
+		t.Errorf("Watchdog = %+v, want filtered by watchdog", sr)
+	}
+
+	downgraded, err := svc.Submit(ctx, firing("DevNoise"))
+	if err != nil || downgraded.Skipped {
+		t.Fatalf("DevNoise = %+v, %v; want accepted", downgraded, err)
+	}
+	if r := waitTerminal(t, store, downgraded.ID); !r.DryRun {
+		t.Error("downgraded triage is not a dry run")
+	}
+
+	// A dry-run rule is counted but the alert is triaged as usual.
+	trial, err := svc.Submit(ctx, firing("Trial"))
+	if err != nil || trial.Skipped {
+		t.Fatalf("Trial = %+v, %v; want accepted", trial, err)
+	}
+	waitTerminal(t, store, trial.ID)
+
+	got, _ := svc.Decisions(ctx, DecisionFilter{})
+	want := map[string]string{"fp-Watchdog": "watchdog", "fp-DevNoise": "dev", "fp-Trial": ""}
+	if len(got) != len(want) {
+		t.Fatalf("decisions = %d, want %d", len(got), len(want))
+	}
+	for _, d := range got {
+		if d.Rule != want[d.Fingerprint] {
+			t.Errorf("decision for %s has rule %q, want %q", d.Fingerprint, d.Rule, want[d.Fingerprint])
+		}
+	}
+}
+
+func TestSubmit_FilterDowngradeNotifiesNobody(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	notifier := newMockNotifier()
+	svc := NewService(store, NopEngine{}, log.Nop(), nil, notifier, noop.NewTracerProvider(),
+		WithFilter(filterFunc(func(*alert.Alert) *FilterRule {
+			return &FilterRule{Name: "dev", Action: FilterDowngrade}
+		})),
+	)
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{Status: "firing", Fingerprint: "fp-dev", Labels: map[string]string{"alertname": "DevNoise"}})
+	if err != nil || sr.Skipped {
+		t.Fatalf("Submit = %+v, %v; want accepted", sr, err)
+	}
+	r := waitTerminal(t, store, sr.ID)
+	waitForFinish(t, svc, sr.ID)
+
+	if r.Status != StatusComplete || !r.DryRun {
+		t.Errorf("result = %s, dry run %v; want a complete dry run", r.Status, r.DryRun)
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if notifier.calls != 0 {
+		t.Errorf("notifier called %d times for a downgraded alert", notifier.calls)
+	}
+}
@@ -0,0 +1,65 @@
+package triage
+
+// Guardrails cap the triage work a process holds in memory during an alert
+// storm. Once a limit is reached Submit sheds new alerts, reporting them as
+// skipped, until enough in-flight triages finish. Zero fields are unlimited.
+type Guardrails struct {
+	// MaxInFlight caps pending and running triages. Each holds a goroutine
+	// for its whole life, plus tool call goroutines while it runs.
+	MaxInFlight int
+
+	// MaxConversationBytes caps the conversation text, tool inputs and tool
+	// results held by in-flight triages.
+	MaxConversationBytes int64
+}
+
+// WithGuardrails sheds new submissions while either guardrail is reached.
+func WithGuardrails(g Guardrails) ServiceOption {
+	return func(s *Service) {
+		s.guard = g
+	}
+}
+
+// Shed reasons, also the suffix of the vigil_submits_total result label.
+const (
+	shedInFlight          = "in_flight"
+	shedConversationBytes = "conversation_bytes"
+)
+
+// shedReason reports which guardrail a new submission would cross, or "" if
+// it can be accepted.
+func (s *Service) shedReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.guard.MaxInFlight > 0 && len(s.running) >= s.guard.MaxInFlight:
+		return shedInFlight
+	case s.guard.MaxConversationBytes > 0 && s.convTotal >= s.guard.MaxConversationBytes:
+		return shedConversationBytes
+	}
+	return ""
+}
+
+// addConversation counts n more conversation bytes against a running triage.
+func (s *Service) addConversation(id string, n int64) {
+	s.mu.Lock()
+	if _, ok := s.running[id]; ok {
+		s.convBytes[id] += n
+		s.convTotal += n
+	} else {
+		n = 0
+	}
+	s.mu.Unlock()
+	if s.metrics != nil && n > 0 {
+		s.metrics.ConversationBytes.Add(float64(n))
+	}
+}
+
+// turnBytes approximates the memory a turn holds by the size of its content.
+func turnBytes(t *Turn) int64 {
+	var n int
+	for _, b := range t.Content {
+		n += len(b.Text) + len(b.Input) + len(b.Content)
+	}
+	return int64(n)
+}
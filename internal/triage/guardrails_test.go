@@ -0,0 +1,83 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestSubmit_ShedsAtInFlightLimit(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 2), release: make(chan struct{})}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithGuardrails(Guardrails{MaxInFlight: 1}))
+
+	submit := func(fp string) *SubmitResult {
+		t.Helper()
+		sr, err := svc.Submit(context.Background(), &alert.Alert{
+			Status:      "firing",
+			Fingerprint: fp,
+			Labels:      map[string]string{"alertname": "Storm"},
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return sr
+	}
+
+	first := submit("fp-storm-1")
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first triage did not start")
+	}
+
+	if sr := submit("fp-storm-2"); !sr.Skipped || sr.Reason != "shed: in_flight" {
+		t.Fatalf("second submit = %+v, want shed for in_flight", sr)
+	}
+
+	close(provider.release)
+	waitTerminal(t, store, first.ID)
+	deadline := time.Now().Add(2 * time.Second)
+	for svc.shedReason() != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("guardrail still reached after the triage finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sr := submit("fp-storm-3"); sr.Skipped {
+		t.Errorf("submit after triage finished = %+v, want accepted", sr)
+	}
+}
+
+func TestGuardrails_ConversationBytes(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(newMockStore(), nil, log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithGuardrails(Guardrails{MaxConversationBytes: 100}))
+	svc.running["t1"] = func(error) {}
+
+	onTurn := svc.buildOnTurn(context.Background(), "t1")
+	_ = onTurn(context.Background(), 0, &Turn{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: strings.Repeat("x", 60)}}})
+	if got := svc.shedReason(); got != "" {
+		t.Fatalf("shedReason at 60 bytes = %q, want none", got)
+	}
+	_ = onTurn(context.Background(), 1, &Turn{Role: "user", Content: []ContentBlock{{Type: "tool_result", Content: strings.Repeat("y", 40)}}})
+	if got := svc.shedReason(); got != shedConversationBytes {
+		t.Fatalf("shedReason at 100 bytes = %q, want %q", got, shedConversationBytes)
+	}
+
+	svc.finish("t1")
+	if got := svc.shedReason(); got != "" || svc.convTotal != 0 {
+		t.Errorf("after finish shedReason = %q, total = %d, want none and 0", got, svc.convTotal)
+	}
+}
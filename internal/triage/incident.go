@@ -0,0 +1,213 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// IncidentAlertName is the alert name of incident-level meta-triages.
+const IncidentAlertName = "VigilIncident"
+
+// incidentAnalysisLimit caps how much of each child analysis the meta-triage
+// sees, so a large incident stays within one prompt.
+const incidentAnalysisLimit = 1500
+
+// IncidentConfig enables incident mode: when Threshold triages of related
+// alerts complete within Window, a meta-triage is run over their analyses.
+// Alerts are related when they share the values of every GroupBy label; with
+// no GroupBy labels every alert is related. Alerts missing all GroupBy labels
+// are never grouped.
+type IncidentConfig struct {
+	Threshold int
+	Window    time.Duration
+	GroupBy   []string
+}
+
+// WithIncidents enables incident mode. A Threshold below 2 or a non-positive
+// Window leaves it disabled.
+func WithIncidents(c IncidentConfig) ServiceOption {
+	return func(s *Service) {
+		if c.Threshold >= 2 && c.Window > 0 {
+			s.incidents = &incidentTracker{cfg: c, groups: make(map[string]*incidentGroup)}
+		}
+	}
+}
+
+// incidentMember is the part of a completed triage a meta-triage is given.
+type incidentMember struct {
+	id, alert, severity, summary, analysis string
+	completedAt                            time.Time
+}
+
+type incidentGroup struct {
+	labels  map[string]string
+	members []incidentMember
+	// quietUntil suppresses a second incident for the group right after one.
+	quietUntil time.Time
+}
+
+// incidentTracker collects completed triages per group and reports when a
+// group crosses the threshold.
+type incidentTracker struct {
+	cfg IncidentConfig
+
+	mu     sync.Mutex
+	groups map[string]*incidentGroup
+}
+
+// groupKey returns the group of al and its grouping labels, or false when al
+// has none of the GroupBy labels.
+func (t *incidentTracker) groupKey(al *alert.Alert) (string, map[string]string, bool) {
+	labels := make(map[string]string, len(t.cfg.GroupBy))
+	parts := make([]string, 0, len(t.cfg.GroupBy))
+	for _, name := range t.cfg.GroupBy {
+		v := al.Labels[name]
+		if v != "" {
+			labels[name] = v
+		}
+		parts = append(parts, name+"="+v)
+	}
+	if len(t.cfg.GroupBy) > 0 && len(labels) == 0 {
+		return "", nil, false
+	}
+	return strings.Join(parts, ","), labels, true
+}
+
+// observe records a completed triage. When its group reaches the threshold
+// within the window it returns the members for a meta-triage and resets the
+// group. Triages of different tenants are never grouped together.
+func (t *incidentTracker) observe(al *alert.Alert, tenant string, m incidentMember) (string, map[string]string, []incidentMember) {
+	key, labels, ok := t.groupKey(al)
+	if !ok {
+		return "", nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	gk := tenant + "\x00" + key
+	g := t.groups[gk]
+	if g == nil {
+		g = &incidentGroup{labels: labels}
+		t.groups[gk] = g
+	}
+	cutoff := m.completedAt.Add(-t.cfg.Window)
+	g.members = slices.DeleteFunc(g.members, func(x incidentMember) bool { return x.completedAt.Before(cutoff) })
+	g.members = append(g.members, m)
+
+	if len(g.members) < t.cfg.Threshold || m.completedAt.Before(g.quietUntil) {
+		return "", nil, nil
+	}
+	members := g.members
+	g.members = nil
+	g.quietUntil = m.completedAt.Add(t.cfg.Window)
+	return key, g.labels, members
+}
+
+// observeIncident feeds a finished triage to the incident tracker and starts
+// a meta-triage when its group crosses the threshold. Meta-triages and
+// unsuccessful triages are not counted.
+func (s *Service) observeIncident(ctx context.Context, al *alert.Alert, r *Result) {
+	if s.incidents == nil || r.Status != StatusComplete || len(r.Children) > 0 || r.DryRun {
+		return
+	}
+	key, labels, members := s.incidents.observe(al, TenantFrom(ctx), incidentMember{
+		id:          r.ID,
+		alert:       r.Alert,
+		severity:    r.Severity,
+		summary:     r.Summary,
+		analysis:    r.Analysis,
+		completedAt: r.CompletedAt,
+	})
+	if members == nil {
+		return
+	}
+	if err := s.startIncident(ctx, key, labels, members); err != nil {
+		s.logger.Error(ctx, err, "failed to start incident meta-triage", "group", key, "triages", len(members))
+	}
+}
+
+// startIncident creates the incident Result and runs the meta-triage.
+func (s *Service) startIncident(ctx context.Context, key string, labels map[string]string, members []incidentMember) error {
+	ids := make([]string, len(members))
+	names := make([]string, 0, len(members))
+	severity := ""
+	for i, m := range members {
+		ids[i] = m.id
+		if !slices.Contains(names, m.alert) {
+			names = append(names, m.alert)
+		}
+		if severity == "" || bandRank(SeverityBand(m.severity)) < bandRank(SeverityBand(severity)) {
+			severity = m.severity
+		}
+	}
+
+	al := &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "incident:" + key,
+		Labels:      map[string]string{"alertname": IncidentAlertName, "severity": severity},
+		Annotations: map[string]string{"summary": fmt.Sprintf("%d related alerts: %s", len(members), strings.Join(names, ", "))},
+	}
+	for k, v := range labels {
+		al.Labels[k] = v
+	}
+
+	id := ulid.Make().String()
+	now := time.Now()
+	result := &Result{
+		ID:          id,
+		Fingerprint: al.Fingerprint,
+		Status:      StatusPending,
+		Alert:       IncidentAlertName,
+		Severity:    severity,
+		Summary:     al.Annotations["summary"],
+		Labels:      al.Labels,
+		Annotations: al.Annotations,
+		CreatedAt:   now,
+		Children:    ids,
+		TenantID:    TenantFrom(ctx),
+	}
+	if _, created, err := s.store.CreateIfNotActive(ctx, result); err != nil || !created {
+		return err
+	}
+
+	s.logger.Info(ctx, "incident meta-triage started", "triage_id", id, "group", key, "children", ids)
+	s.audit(ctx, id, result.TenantID, AuditSubmitted, ActorSystem, fmt.Sprintf("incident of %d triages", len(ids)))
+	s.start(ctx, id, al, now, s.tenants[TenantFrom(ctx)], WithInstructions(incidentInstructions), withPrompt(buildIncidentPrompt(al, members)))
+	return nil
+}
+
+const incidentInstructions = `You are coordinating an incident. Several related alerts fired close together and
+each was triaged on its own. Decide whether they share a common root cause. Lead with that root cause, or say
+plainly that the alerts look unrelated. Then give the order in which to act. Use tools only to confirm or rule out
+a shared cause; the individual triages already covered each alert.`
+
+// buildIncidentPrompt gives the meta-triage each child's summary and analysis,
+// not its conversation.
+func buildIncidentPrompt(al *alert.Alert, members []incidentMember) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Incident: %s\n", al.Annotations["summary"])
+	for _, k := range slices.Sorted(maps.Keys(al.Labels)) {
+		if k != "alertname" && k != "severity" {
+			fmt.Fprintf(&b, "Shared label: %s=%s\n", k, al.Labels[k])
+		}
+	}
+	for i, m := range members {
+		analysis := m.analysis
+		if len(analysis) > incidentAnalysisLimit {
+			analysis = analysis[:incidentAnalysisLimit] + " [truncated]"
+		}
+		fmt.Fprintf(&b, "\n## Triage %d: %s (%s), completed %s\nTriage ID: %s\nSummary: %s\nAnalysis:\n%s\n",
+			i+1, m.alert, m.severity, m.completedAt.UTC().Format(time.RFC3339), m.id, m.summary, analysis)
+	}
+	b.WriteString("\nIdentify the common root cause across these triages and provide an incident-level analysis.")
+	return b.String()
+}
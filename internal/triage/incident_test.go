@@ -0,0 +1,124 @@
+package triage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestIncidentTracker(t *testing.T) {
+	t.Parallel()
+
+	tr := &incidentTracker{
+		cfg:    IncidentConfig{Threshold: 3, Window: 10 * time.Minute, GroupBy: []string{"cluster"}},
+		groups: make(map[string]*incidentGroup),
+	}
+	base := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	observe := func(id, cluster string, at time.Duration) []incidentMember {
+		al := &alert.Alert{Labels: map[string]string{"alertname": id, "cluster": cluster}}
+		_, _, members := tr.observe(al, "", incidentMember{id: id, completedAt: base.Add(at)})
+		return members
+	}
+	ids := func(ms []incidentMember) []string {
+		out := make([]string, len(ms))
+		for i, m := range ms {
+			out[i] = m.id
+		}
+		return out
+	}
+
+	if observe("a", "eu", 0) != nil || observe("b", "us", time.Minute) != nil || observe("c", "", 2*time.Minute) != nil {
+		t.Fatal("incident before threshold")
+	}
+	// a ages out of the window before the group reaches three.
+	if observe("d", "eu", 11*time.Minute) != nil || observe("e", "eu", 12*time.Minute) != nil {
+		t.Fatal("incident counted a triage outside the window")
+	}
+	if got := ids(observe("f", "eu", 13*time.Minute)); !slices.Equal(got, []string{"d", "e", "f"}) {
+		t.Fatalf("incident members = %v, want [d e f]", got)
+	}
+	// The group stays quiet for a window after an incident.
+	for i, id := range []string{"g", "h", "i"} {
+		if observe(id, "eu", 14*time.Minute+time.Duration(i)*time.Minute) != nil {
+			t.Fatalf("second incident during quiet period at %s", id)
+		}
+	}
+	if got := ids(observe("j", "eu", 24*time.Minute)); !slices.Equal(got, []string{"g", "h", "i", "j"}) {
+		t.Errorf("incident after quiet period = %v, want [g h i j]", got)
+	}
+}
+
+func TestSubmit_StartsIncidentMetaTriage(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &mockProvider{}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithIncidents(IncidentConfig{Threshold: 2, Window: time.Hour, GroupBy: []string{"cluster"}}))
+
+	var children []string
+	for _, name := range []string{"APIErrors", "DBConnections"} {
+		sr, err := svc.Submit(context.Background(), &alert.Alert{
+			Status:      "firing",
+			Fingerprint: "fp-" + name,
+			Labels:      map[string]string{"alertname": name, "cluster": "eu-1", "severity": "warning"},
+			Annotations: map[string]string{"summary": name + " summary"},
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		children = append(children, sr.ID)
+		waitTerminal(t, store, sr.ID)
+	}
+
+	var incident *Result
+	deadline := time.Now().Add(2 * time.Second)
+	for incident == nil || !incident.Status.IsTerminal() {
+		if time.Now().After(deadline) {
+			t.Fatalf("incident meta-triage did not complete: %+v", incident)
+		}
+		time.Sleep(10 * time.Millisecond)
+		rs, _ := store.List(context.Background(), ListFilter{Alert: IncidentAlertName})
+		if len(rs) > 0 {
+			incident = rs[0]
+		}
+	}
+
+	if !slices.Equal(incident.Children, children) || incident.Fingerprint != "incident:cluster=eu-1" {
+		t.Errorf("incident = %+v, want children %v", incident, children)
+	}
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	last := provider.reqs[len(provider.reqs)-1]
+	prompt := last.Messages[0].Content[0].Text
+	for _, want := range []string{"APIErrors summary", "DBConnections summary", "Shared label: cluster=eu-1", "fallback"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("meta-triage prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if !strings.Contains(last.System, "coordinating an incident") {
+		t.Errorf("system prompt = %q, want incident instructions", last.System)
+	}
+}
+
+// waitTerminal polls the store until the triage finishes and returns it.
+func waitTerminal(t *testing.T, store *mockStore, id string) *Result {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if r, ok, _ := store.Get(context.Background(), id); ok && r.Status.IsTerminal() {
+			return r
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("triage %s did not complete within deadline", id)
+	return nil
+}
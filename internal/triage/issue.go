@@ -0,0 +1,76 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// issueTimeout bounds opening an issue, which holds up storing the result.
+const issueTimeout = 15 * time.Second
+
+// IssueTracker opens an issue for a triage in a project's issue tracker,
+// such as GitHub or GitLab, and returns its URL.
+type IssueTracker interface {
+	CreateIssue(ctx context.Context, r *Result) (string, error)
+}
+
+// WithIssueTracker opens an issue in t for every triage that completes for
+// an alert of one of severities, before the result is stored and notified,
+// so both carry the issue's URL. Empty severities opens one for every
+// completed triage.
+func WithIssueTracker(t IssueTracker, severities []string) ServiceOption {
+	return func(s *Service) {
+		s.issues = t
+		s.issueSeverities = make(map[string]bool, len(severities))
+		for _, sev := range severities {
+			s.issueSeverities[strings.ToLower(sev)] = true
+		}
+	}
+}
+
+// wantsIssue reports whether r meets the criteria for opening an issue.
+// Only complete triages qualify: one cut short has no verdict to file.
+func (s *Service) wantsIssue(r *Result) bool {
+	if s.issues == nil || r.Status != StatusComplete || r.IssueURL != "" || r.DryRun {
+		return false
+	}
+	return len(s.issueSeverities) == 0 || s.issueSeverities[strings.ToLower(r.Severity)]
+}
+
+// openIssue creates an issue for r under an issue.create span and records
+// its URL on r. A failure is logged and leaves r without an issue.
+func (s *Service) openIssue(ctx context.Context, logger log.Logger, r *Result) {
+	ctx, span := s.tracer.Start(ctx, "issue.create", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("vigil.triage.id", r.ID),
+	))
+	defer span.End()
+	ctx, cancel := context.WithTimeout(ctx, issueTimeout)
+	defer cancel()
+
+	url, err := s.issues.CreateIssue(ctx, r)
+	if err != nil {
+		logger.Warn(ctx, "failed to open issue", "err", err)
+		s.incIssues("error")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	r.IssueURL = url
+	logger.Info(ctx, "issue opened", "issue_url", url)
+	s.incIssues("created")
+	span.SetAttributes(attribute.String("vigil.issue.url", url))
+	span.SetStatus(codes.Ok, "")
+}
+
+func (s *Service) incIssues(outcome string) {
+	if s.metrics != nil {
+		s.metrics.IssuesTotal.WithLabelValues(outcome).Inc()
+	}
+}
@@ -0,0 +1,125 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+type fakeIssueTracker struct {
+	mu    sync.Mutex
+	calls []string
+	err   error
+}
+
+func (f *fakeIssueTracker) CreateIssue(_ context.Context, r *Result) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.ID)
+	if f.err != nil {
+		return "", f.err
+	}
+	return "https://github.com/acme/ops/issues/" + r.ID, nil
+}
+
+func TestRunTriage_OpensIssue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		severity   string
+		severities []string
+		err        error
+		wantCalls  int
+		wantURL    bool
+	}{
+		{"matching severity", "Critical", []string{"critical"}, nil, 1, true},
+		{"other severity", "warning", []string{"critical"}, nil, 0, false},
+		{"all severities", "info", nil, nil, 1, true},
+		{"tracker error", "critical", []string{"critical"}, errors.New("bad credentials"), 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMockStore()
+			notifier := newMockNotifier()
+			tracker := &fakeIssueTracker{err: tt.err}
+			engine := NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+			svc := NewService(store, engine, log.Nop(), nil, notifier, noop.NewTracerProvider(),
+				WithIssueTracker(tracker, tt.severities))
+
+			sr, err := svc.Submit(context.Background(), &alert.Alert{
+				Status:      "firing",
+				Fingerprint: "fp-issue",
+				Labels:      map[string]string{"alertname": "DiskFull", "severity": tt.severity},
+			})
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			select {
+			case <-notifier.called:
+			case <-time.After(2 * time.Second):
+				t.Fatal("notifier was not called within deadline")
+			}
+
+			r := waitTerminal(t, store, sr.ID)
+			if r.Status != StatusComplete {
+				t.Fatalf("status = %s, want complete", r.Status)
+			}
+			tracker.mu.Lock()
+			calls := len(tracker.calls)
+			tracker.mu.Unlock()
+			if calls != tt.wantCalls {
+				t.Errorf("CreateIssue calls = %d, want %d", calls, tt.wantCalls)
+			}
+
+			wantURL := ""
+			if tt.wantURL {
+				wantURL = "https://github.com/acme/ops/issues/" + sr.ID
+			}
+			if r.IssueURL != wantURL {
+				t.Errorf("stored IssueURL = %q, want %q", r.IssueURL, wantURL)
+			}
+			notifier.mu.Lock()
+			defer notifier.mu.Unlock()
+			if notifier.last.IssueURL != wantURL {
+				t.Errorf("notified IssueURL = %q, want %q", notifier.last.IssueURL, wantURL)
+			}
+		})
+	}
+}
+
+func TestWantsIssue(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{}
+	if svc.wantsIssue(&Result{Status: StatusComplete}) {
+		t.Error("wantsIssue without a tracker = true")
+	}
+
+	WithIssueTracker(&fakeIssueTracker{}, []string{"critical"})(svc)
+	tests := []struct {
+		name string
+		r    Result
+		want bool
+	}{
+		{"complete critical", Result{Status: StatusComplete, Severity: "critical"}, true},
+		{"cut short", Result{Status: StatusMaxTurns, Severity: "critical"}, false},
+		{"failed", Result{Status: StatusFailed, Severity: "critical"}, false},
+		{"already filed", Result{Status: StatusComplete, Severity: "critical", IssueURL: "https://x"}, false},
+	}
+	for _, tt := range tests {
+		if got := svc.wantsIssue(&tt.r); got != tt.want {
+			t.Errorf("%s: wantsIssue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
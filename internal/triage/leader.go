@@ -0,0 +1,38 @@
+package triage
+
+import (
+	"context"
+)
+
+// Elector picks one replica at a time to run a job that must not run twice,
+// such as purging or sending digests.
+type Elector interface {
+	// Lead calls run whenever this replica holds the lease on name, with a
+	// context that is cancelled when the lease is lost, until ctx is done.
+	// It blocks while another replica holds the lease.
+	Lead(ctx context.Context, name string, run func(ctx context.Context))
+}
+
+// WithElector runs the service's background jobs only on the replica that
+// leads each of them. Without an elector every replica runs them.
+func WithElector(e Elector) ServiceOption {
+	return func(s *Service) {
+		s.elector = e
+	}
+}
+
+// lead runs job under the elector's lease on name, or directly without an
+// elector. A job whose lease is lost is restarted once it is won again.
+func (s *Service) lead(ctx context.Context, name string, job func(ctx context.Context)) {
+	if s.elector == nil {
+		job(ctx)
+		return
+	}
+	s.elector.Lead(ctx, name, func(ctx context.Context) {
+		s.logger.Info(ctx, "leading background job", "job", name)
+		job(ctx)
+		if ctx.Err() != nil {
+			s.logger.Info(ctx, "stopped leading background job", "job", name)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// fakeElector grants the leases in held and blocks on the rest.
+type fakeElector struct{ held map[string]bool }
+
+func (f *fakeElector) Lead(ctx context.Context, name string, run func(ctx context.Context)) {
+	if f.held[name] {
+		run(ctx)
+		return
+	}
+	<-ctx.Done()
+}
+
+func TestRunPurger_OnlyOnLeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		elector Elector
+		purged  bool
+	}{
+		{"no elector", nil, true},
+		{"leader", &fakeElector{held: map[string]bool{"purge": true}}, true},
+		{"follower", &fakeElector{held: map[string]bool{"digest": true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMockStore()
+			store.results["old"] = &Result{ID: "old", Status: StatusComplete}
+			store.deleted["old"] = time.Now().Add(-48 * time.Hour)
+			var opts []ServiceOption
+			if tt.elector != nil {
+				opts = append(opts, WithElector(tt.elector))
+			}
+			svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider(), opts...)
+
+			// A cancelled context runs at most one purge pass.
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			svc.RunPurger(ctx, 24*time.Hour, time.Hour)
+
+			store.mu.Lock()
+			_, kept := store.results["old"]
+			store.mu.Unlock()
+			if kept == tt.purged {
+				t.Errorf("purged = %v, want %v", !kept, tt.purged)
+			}
+		})
+	}
+}
@@ -32,12 +32,55 @@ type Provider interface {
 	Send(ctx context.Context, req *LLMRequest) (*LLMResponse, error)
 }
 
+// StreamingProvider is a Provider that can also stream its response, calling
+// onText with each piece of text as it is generated.
+type StreamingProvider interface {
+	Provider
+	SendStream(ctx context.Context, req *LLMRequest, onText func(text string)) (*LLMResponse, error)
+}
+
 // LLMRequest represents the input to the LLM provider, including the conversation history and available tools.
 type LLMRequest struct {
 	MaxTokens int
 	System    string
 	Messages  []Message
 	Tools     []tools.ToolDef
+	// ThinkingBudget enables extended thinking with up to this many tokens
+	// of reasoning, counted within MaxTokens. Zero disables thinking.
+	ThinkingBudget int
+	// ToolChoice constrains tool use; the zero value leaves it to the model.
+	ToolChoice ToolChoice
+	// Temperature overrides the provider's sampling temperature when set.
+	Temperature *float64
+	// StopSequences end the response early when the model generates one,
+	// with StopStopSequence.
+	StopSequences []string
+}
+
+// ToolChoiceType selects how the model may use the tools in a request.
+type ToolChoiceType string
+
+const (
+	// ToolChoiceAuto lets the model decide whether to call tools.
+	ToolChoiceAuto ToolChoiceType = "auto"
+
+	// ToolChoiceAny makes the model call at least one tool.
+	ToolChoiceAny ToolChoiceType = "any"
+
+	// ToolChoiceNone keeps the model from calling tools, so it answers in
+	// text even though tools are defined.
+	ToolChoiceNone ToolChoiceType = "none"
+
+	// ToolChoiceTool makes the model call the tool named in ToolChoice.Name.
+	ToolChoiceTool ToolChoiceType = "tool"
+)
+
+// ToolChoice is the tool choice of a request. The zero value is the
+// provider's default, ToolChoiceAuto.
+type ToolChoice struct {
+	Type ToolChoiceType
+	// Name is the tool to call with ToolChoiceTool.
+	Name string
 }
 
 // LLMResponse represents the output from the LLM provider, including the generated content, stop reason, and token usage.
@@ -46,6 +89,9 @@ type LLMResponse struct {
 	StopReason StopReason
 	Usage      Usage
 	Model      string
+	// Provider names the provider that answered when a wrapper chooses
+	// between several, and is empty otherwise.
+	Provider string
 }
 
 // StopReason indicates why the LLM stopped generating content, such as reaching the end of the response or requesting a tool call.
@@ -59,9 +105,16 @@ type Message struct {
 
 // ContentBlock represents a block of content in the LLM response, which can be text, a tool call, or an error message.
 // It also includes metadata such as duration for tool calls.
+//
+// Extended thinking produces "thinking" blocks, whose Signature must be sent
+// back unchanged with the Thinking text, and "redacted_thinking" blocks,
+// whose reasoning the provider returns encrypted in Data.
 type ContentBlock struct {
 	Type      string          `json:"type"`
 	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	Data      string          `json:"data,omitempty"`
 	ID        string          `json:"id,omitempty"`
 	Name      string          `json:"name,omitempty"`
 	Input     json.RawMessage `json:"input,omitempty"`
@@ -75,4 +128,8 @@ type ContentBlock struct {
 type Usage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+	// ThinkingTokens is the part of OutputTokens spent on extended thinking.
+	// Providers that don't report it separately estimate it from the
+	// thinking text they return.
+	ThinkingTokens int `json:"thinking_tokens,omitempty"`
 }
@@ -0,0 +1,82 @@
+package triage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// Maintenance modes decide what Submit does with an alert firing inside a
+// declared maintenance window.
+const (
+	// MaintenanceSkip skips the alert with reason "maintenance".
+	MaintenanceSkip = "skip"
+	// MaintenanceLightweight triages it on the reduced budget used for
+	// downgraded alerts, telling the model about the maintenance.
+	MaintenanceLightweight = "lightweight"
+)
+
+// MaintenanceWindow is declared maintenance covering an alert.
+type MaintenanceWindow struct {
+	// Name identifies the window in logs, metrics, and submit decisions.
+	Name string
+	// Mode is MaintenanceSkip or MaintenanceLightweight.
+	Mode string
+	// Comment describes the work, as written by whoever declared it.
+	Comment string
+	// Source is where the window was declared, such as "config" or
+	// "alertmanager".
+	Source   string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// MaintenanceChecker reports the maintenance window covering an alert at
+// now, or nil if none does.
+type MaintenanceChecker interface {
+	Maintenance(al *alert.Alert, now time.Time) *MaintenanceWindow
+}
+
+// WithMaintenance sets the checker consulted for every firing alert after
+// suppressions. Alerts in a window are skipped or triaged in
+// lightweight mode, as the window's Mode says.
+func WithMaintenance(m MaintenanceChecker) ServiceOption {
+	return func(s *Service) {
+		s.maintenance = m
+	}
+}
+
+// maintenanceWindow returns the window covering al, or nil.
+func (s *Service) maintenanceWindow(al *alert.Alert) *MaintenanceWindow {
+	if s.maintenance == nil {
+		return nil
+	}
+	return s.maintenance.Maintenance(al, time.Now())
+}
+
+// withMaintenance adds w to the initial user message.
+func withMaintenance(w *MaintenanceWindow) RunOption {
+	return func(c *runConfig) { c.maintenance = w }
+}
+
+// maintenanceSection renders w for the initial prompt. It returns "" for nil.
+func maintenanceSection(w *MaintenanceWindow) string {
+	if w == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\nMaintenance in progress: this alert fired during the declared maintenance window " + w.Name)
+	if w.Source != "" {
+		b.WriteString(" (from " + w.Source + ")")
+	}
+	b.WriteString(".\n")
+	if w.Comment != "" {
+		b.WriteString("Comment: " + w.Comment + "\n")
+	}
+	if !w.StartsAt.IsZero() && !w.EndsAt.IsZero() {
+		b.WriteString("Window: " + w.StartsAt.UTC().Format(time.RFC3339) + " to " + w.EndsAt.UTC().Format(time.RFC3339) + "\n")
+	}
+	b.WriteString("Consider whether the maintenance explains the alert before looking for another cause.")
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+type maintenanceFunc func(*alert.Alert, time.Time) *MaintenanceWindow
+
+func (f maintenanceFunc) Maintenance(al *alert.Alert, now time.Time) *MaintenanceWindow {
+	return f(al, now)
+}
+
+func TestSubmit_Maintenance(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "loop_tool", output: json.RawMessage(`"ok"`)})
+	responses := make([]*LLMResponse, 2*MaxToolRounds)
+	for i := range responses {
+		responses[i] = &LLMResponse{
+			Content:    []ContentBlock{{Type: "tool_use", ID: fmt.Sprintf("call-%d", i), Name: "loop_tool", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		}
+	}
+	provider := &mockProvider{responses: responses}
+	store := newMockStore()
+	dl := &fakeDecisionLog{}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithDecisionLog(dl),
+		WithMaintenance(maintenanceFunc(func(al *alert.Alert, _ time.Time) *MaintenanceWindow {
+			switch al.Labels["cluster"] {
+			case "db-upgrade":
+				return &MaintenanceWindow{Name: "db-upgrade", Mode: MaintenanceSkip, Source: "config"}
+			case "node-drain":
+				return &MaintenanceWindow{Name: "node-drain", Mode: MaintenanceLightweight, Source: "alertmanager", Comment: "draining nodes for the kernel patch"}
+			}
+			return nil
+		})),
+	)
+	ctx := context.Background()
+	firing := func(cluster string) *alert.Alert {
+		return &alert.Alert{Status: "firing", Fingerprint: "fp-" + cluster, Labels: map[string]string{"alertname": "NodeDown", "cluster": cluster}}
+	}
+
+	sr, err := svc.Submit(ctx, firing("db-upgrade"))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !sr.Skipped || sr.Reason != "maintenance" {
+		t.Errorf("db-upgrade = %+v, want skipped for maintenance", sr)
+	}
+
+	light, err := svc.Submit(ctx, firing("node-drain"))
+	if err != nil || light.Skipped {
+		t.Fatalf("node-drain = %+v, %v; want accepted", light, err)
+	}
+	if r := waitTerminal(t, store, light.ID); r.ToolCalls != noisyBudget.ToolCalls {
+		t.Errorf("lightweight triage made %d tool calls, want the reduced budget of %d", r.ToolCalls, noisyBudget.ToolCalls)
+	}
+	provider.mu.Lock()
+	prompt := provider.reqs[0].Messages[0].Content[0].Text
+	provider.mu.Unlock()
+	for _, want := range []string{"maintenance window node-drain (from alertmanager)", "Comment: draining nodes for the kernel patch"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("initial prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	got, _ := svc.Decisions(ctx, DecisionFilter{})
+	want := map[string]string{"fp-db-upgrade": "db-upgrade", "fp-node-drain": "node-drain"}
+	if len(got) != len(want) {
+		t.Fatalf("decisions = %d, want %d", len(got), len(want))
+	}
+	for _, d := range got {
+		if d.Rule != want[d.Fingerprint] {
+			t.Errorf("decision for %s has rule %q, want %q", d.Fingerprint, d.Rule, want[d.Fingerprint])
+		}
+	}
+}
+
+func TestMaintenanceSection(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		w    *MaintenanceWindow
+		want []string
+		not  []string
+	}{
+		{name: "nil", w: nil, not: []string{"Maintenance"}},
+		{
+			name: "full",
+			w:    &MaintenanceWindow{Name: "db-upgrade", Source: "config", Comment: "postgres 17", StartsAt: start, EndsAt: start.Add(2 * time.Hour)},
+			want: []string{"window db-upgrade (from config).", "Comment: postgres 17", "Window: 2026-03-01T02:00:00Z to 2026-03-01T04:00:00Z"},
+		},
+		{
+			name: "open ended",
+			w:    &MaintenanceWindow{Name: "drain"},
+			want: []string{"window drain."},
+			not:  []string{"Comment:", "Window:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := maintenanceSection(tt.w)
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("section missing %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.not {
+				if strings.Contains(got, s) {
+					t.Errorf("section has %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
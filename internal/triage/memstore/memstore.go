@@ -2,8 +2,13 @@
 package memstore
 
 import (
+	"cmp"
 	"context"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
@@ -12,7 +17,18 @@ import (
 type Store struct {
 	mu      sync.RWMutex
 	results map[string]*triage.Result // triage ID -> result
-	seen    map[string]string         // alert fingerprint -> triage ID (dedup)
+	seen    map[string]string         // seenKey -> triage ID (dedup)
+	deleted map[string]time.Time      // triage ID -> soft delete time
+
+	decisions []*triage.Decision   // in recording order
+	audit     []*triage.AuditEvent // in recording order
+	digests   map[string]bool      // claimed digests
+
+	suppressions map[string]*triage.Suppression // suppression ID -> suppression
+
+	leases map[string]chan struct{} // job name -> lease held while full
+
+	notifications map[string]*triage.Notification // triage ID -> outbox entry
 }
 
 // New initializes a new in-memory Store.
@@ -20,6 +36,13 @@ func New() *Store {
 	return &Store{
 		results: make(map[string]*triage.Result),
 		seen:    make(map[string]string),
+		deleted: make(map[string]time.Time),
+		digests: make(map[string]bool),
+		leases:  make(map[string]chan struct{}),
+
+		suppressions: make(map[string]*triage.Suppression),
+
+		notifications: make(map[string]*triage.Notification),
 	}
 }
 
@@ -28,19 +51,22 @@ func (s *Store) Get(_ context.Context, id string) (*triage.Result, bool, error)
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	r, ok := s.results[id]
-	if !ok {
+	if !ok || s.isDeleted(id) {
 		return nil, false, nil
 	}
 	cp := *r
 	return &cp, true, nil
 }
 
-// GetByFingerprint retrieves a triage result by alert fingerprint, for deduplication. Returns a copy.
-func (s *Store) GetByFingerprint(_ context.Context, fp string) (*triage.Result, bool, error) {
+// GetByFingerprint retrieves a triage result by alert fingerprint, for
+// deduplication, within the tenant in ctx. Returns a copy. Only the latest
+// triage per fingerprint is tracked, so a deleted latest triage hides older
+// ones.
+func (s *Store) GetByFingerprint(ctx context.Context, fp string) (*triage.Result, bool, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	id, ok := s.seen[fp]
-	if !ok {
+	id, ok := s.seen[seenKey(triage.TenantFrom(ctx), fp)]
+	if !ok || s.isDeleted(id) {
 		return nil, false, nil
 	}
 	r := s.results[id]
@@ -51,17 +77,69 @@ func (s *Store) GetByFingerprint(_ context.Context, fp string) (*triage.Result,
 // Put stores a copy of the triage result. If the incoming result has a nil
 // Conversation, any previously stored conversation is preserved (so a
 // metadata-only Put does not wipe incrementally-built conversation data).
+// Partial text is cleared.
 func (s *Store) Put(_ context.Context, r *triage.Result) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.put(r)
+	return nil
+}
+
+func (s *Store) put(r *triage.Result) {
 	cp := *r
+	cp.Partial = ""
 	if cp.Conversation == nil {
 		if existing, ok := s.results[r.ID]; ok && existing.Conversation != nil {
 			cp.Conversation = existing.Conversation
 		}
 	}
 	s.results[r.ID] = &cp
-	s.seen[r.Fingerprint] = r.ID
+	s.seen[seenKey(r.TenantID, r.Fingerprint)] = r.ID
+}
+
+// CreateIfNotActive stores a copy of r unless the latest triage for its
+// fingerprint is pending or in progress. The write lock makes the check and
+// insert atomic.
+func (s *Store) CreateIfNotActive(_ context.Context, r *triage.Result) (*triage.Result, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.seen[seenKey(r.TenantID, r.Fingerprint)]; ok && !s.isDeleted(id) {
+		if existing := s.results[id]; existing.Status == triage.StatusPending || existing.Status == triage.StatusInProgress {
+			cp := *existing
+			return &cp, false, nil
+		}
+	}
+	cp := *r
+	s.results[r.ID] = &cp
+	s.seen[seenKey(r.TenantID, r.Fingerprint)] = r.ID
+	return nil, true, nil
+}
+
+// ClaimAction marks action n of triage id executed unless it already is.
+// The write lock makes the check and write atomic.
+func (s *Store) ClaimAction(_ context.Context, id string, n int, actor string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.results[id]
+	if !ok || s.isDeleted(id) || n < 0 || n >= len(r.Actions) || r.Actions[n].Executed() {
+		return false, nil
+	}
+	// Copies handed out by Get share the old slice.
+	r.Actions = slices.Clone(r.Actions)
+	r.Actions[n].ExecutedAt, r.Actions[n].ExecutedBy = at, actor
+	return true, nil
+}
+
+// RecordAction sets the outcome of action n of triage id.
+func (s *Store) RecordAction(_ context.Context, id string, n int, output, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.results[id]
+	if !ok || n < 0 || n >= len(r.Actions) {
+		return nil
+	}
+	r.Actions = slices.Clone(r.Actions)
+	r.Actions[n].Output, r.Actions[n].Error = output, errMsg
 	return nil
 }
 
@@ -90,3 +168,323 @@ func (s *Store) AppendTurn(_ context.Context, triageID string, seq int, turn *tr
 func (s *Store) AppendToolCalls(_ context.Context, _ string, _, _ int, _ *triage.Turn, _ map[string]*triage.ContentBlock) error {
 	return nil
 }
+
+// AppendTurnWithToolCalls is AppendTurn; the tool data is in the turn.
+func (s *Store) AppendTurnWithToolCalls(ctx context.Context, triageID string, seq int, turn *triage.Turn, _, _ int, _ *triage.Turn, _ map[string]*triage.ContentBlock) (int, error) {
+	return s.AppendTurn(ctx, triageID, seq, turn)
+}
+
+// SavePartial replaces the partial response text of a stored result.
+func (s *Store) SavePartial(_ context.Context, triageID, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.results[triageID]; ok {
+		r.Partial = text
+	}
+	return nil
+}
+
+// List returns copies of results matching the filter, newest first, without
+// conversations.
+func (s *Store) List(_ context.Context, f triage.ListFilter) ([]*triage.Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*triage.Result, 0, len(s.results))
+	for id, r := range s.results {
+		if s.isDeleted(id) || !f.Matches(r) {
+			continue
+		}
+		cp := *r
+		cp.Conversation = nil
+		out = append(out, &cp)
+	}
+	slices.SortFunc(out, func(a, b *triage.Result) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	if limit := f.EffectiveLimit(); len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Search scans every live result for the words of the query, see
+// triage.MatchSearch.
+func (s *Store) Search(_ context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*triage.SearchHit
+	for id, r := range s.results {
+		if s.isDeleted(id) || !(triage.ListFilter{Tenant: q.Tenant}).Matches(r) {
+			continue
+		}
+		if hit := triage.MatchSearch(r, q.Text); hit != nil {
+			out = append(out, hit)
+		}
+	}
+	slices.SortFunc(out, func(a, b *triage.SearchHit) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+	if limit := q.EffectiveLimit(); len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Stats aggregates every live result in q's window, see triage.ComputeStats.
+func (s *Store) Stats(_ context.Context, q triage.StatsQuery) (*triage.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	live := make([]*triage.Result, 0, len(s.results))
+	for id, r := range s.results {
+		if !s.isDeleted(id) {
+			live = append(live, r)
+		}
+	}
+	return triage.ComputeStats(live, q), nil
+}
+
+// Delete marks a result deleted.
+func (s *Store) Delete(_ context.Context, id string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.results[id]; !ok || s.isDeleted(id) {
+		return false, nil
+	}
+	s.deleted[id] = at
+	return true, nil
+}
+
+// Restore clears a result's deletion mark.
+func (s *Store) Restore(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isDeleted(id) {
+		return false, nil
+	}
+	delete(s.deleted, id)
+	return true, nil
+}
+
+// Purge drops results deleted before the cutoff.
+func (s *Store) Purge(_ context.Context, deletedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for id, at := range s.deleted {
+		if !at.Before(deletedBefore) {
+			continue
+		}
+		if key := seenKey(s.results[id].TenantID, s.results[id].Fingerprint); s.seen[key] == id {
+			delete(s.seen, key)
+		}
+		delete(s.results, id)
+		delete(s.deleted, id)
+		delete(s.notifications, id)
+		n++
+	}
+	return n, nil
+}
+
+// RecordDecision stores a copy of d.
+func (s *Store) RecordDecision(_ context.Context, d *triage.Decision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *d
+	s.decisions = append(s.decisions, &cp)
+	return nil
+}
+
+// ListDecisions returns copies of decisions matching the filter, newest first.
+func (s *Store) ListDecisions(_ context.Context, f triage.DecisionFilter) ([]*triage.Decision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*triage.Decision
+	for _, d := range slices.Backward(s.decisions) {
+		if len(out) == f.EffectiveLimit() {
+			break
+		}
+		if f.Matches(d) {
+			cp := *d
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+// PurgeDecisions drops decisions made before the cutoff.
+func (s *Store) PurgeDecisions(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.decisions)
+	s.decisions = slices.DeleteFunc(s.decisions, func(d *triage.Decision) bool { return d.CreatedAt.Before(before) })
+	return n - len(s.decisions), nil
+}
+
+// CreateSuppression stores a copy of sup, dropping expired suppressions.
+func (s *Store) CreateSuppression(_ context.Context, sup *triage.Suppression) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, old := range s.suppressions {
+		if !sup.CreatedAt.Before(old.ExpiresAt) {
+			delete(s.suppressions, id)
+		}
+	}
+	cp := *sup
+	cp.Matchers = maps.Clone(sup.Matchers)
+	s.suppressions[cp.ID] = &cp
+	return nil
+}
+
+// ActiveSuppressions returns copies of the tenant's suppressions expiring
+// after now, soonest to expire first.
+func (s *Store) ActiveSuppressions(_ context.Context, tenant string, now time.Time) ([]*triage.Suppression, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*triage.Suppression
+	for _, sup := range s.suppressions {
+		if sup.TenantID == tenant && now.Before(sup.ExpiresAt) {
+			cp := *sup
+			cp.Matchers = maps.Clone(sup.Matchers)
+			out = append(out, &cp)
+		}
+	}
+	slices.SortFunc(out, func(a, b *triage.Suppression) int {
+		return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), cmp.Compare(a.ID, b.ID))
+	})
+	return out, nil
+}
+
+// DeleteSuppression removes a suppression of the tenant.
+func (s *Store) DeleteSuppression(_ context.Context, id, tenant string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sup, ok := s.suppressions[id]
+	if !ok || sup.TenantID != tenant {
+		return false, nil
+	}
+	delete(s.suppressions, id)
+	return true, nil
+}
+
+// RecordAudit stores a copy of e.
+func (s *Store) RecordAudit(_ context.Context, e *triage.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *e
+	s.audit = append(s.audit, &cp)
+	return nil
+}
+
+// ListAudit returns copies of the events of a triage, oldest first.
+func (s *Store) ListAudit(_ context.Context, triageID string) ([]*triage.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*triage.AuditEvent
+	for _, e := range s.audit {
+		if e.TriageID == triageID {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+// seenKey scopes a fingerprint to its tenant, so tenants deduplicate
+// independently.
+func seenKey(tenant, fingerprint string) string {
+	if tenant == "" {
+		return fingerprint
+	}
+	return tenant + "\x00" + fingerprint
+}
+
+func (s *Store) isDeleted(id string) bool {
+	_, ok := s.deleted[id]
+	return ok
+}
+
+// ClaimDigest reports whether the digest has not been claimed before.
+func (s *Store) ClaimDigest(_ context.Context, period string, due time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := period + "\x00" + due.UTC().Format(time.RFC3339)
+	if s.digests[key] {
+		return false, nil
+	}
+	s.digests[key] = true
+	return true, nil
+}
+
+// Lead calls run once no other caller holds the lease on name, and holds it
+// until run returns. Leases only exclude callers sharing this Store.
+func (s *Store) Lead(ctx context.Context, name string, run func(ctx context.Context)) {
+	s.mu.Lock()
+	lease, ok := s.leases[name]
+	if !ok {
+		lease = make(chan struct{}, 1)
+		s.leases[name] = lease
+	}
+	s.mu.Unlock()
+
+	select {
+	case lease <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-lease }()
+	run(ctx)
+}
+
+// PutNotifying stores r and a copy of n under one lock. A triage that
+// already has a notification keeps it.
+func (s *Store) PutNotifying(_ context.Context, r *triage.Result, n *triage.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(r)
+	if _, ok := s.notifications[n.TriageID]; !ok {
+		cp := *n
+		s.notifications[n.TriageID] = &cp
+	}
+	return nil
+}
+
+// DueNotifications returns copies of the pending notifications due by now,
+// oldest first.
+func (s *Store) DueNotifications(_ context.Context, now time.Time, limit int) ([]*triage.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*triage.Notification
+	for _, n := range s.notifications {
+		if n.Status == triage.NotificationPending && !n.NextAttempt.After(now) {
+			cp := *n
+			out = append(out, &cp)
+		}
+	}
+	slices.SortFunc(out, func(a, b *triage.Notification) int {
+		return cmp.Or(a.NextAttempt.Compare(b.NextAttempt), strings.Compare(a.TriageID, b.TriageID))
+	})
+	return out[:min(len(out), limit)], nil
+}
+
+// UpdateNotification replaces the stored notification for n's triage.
+func (s *Store) UpdateNotification(_ context.Context, n *triage.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.notifications[n.TriageID]; ok {
+		cp := *n
+		s.notifications[n.TriageID] = &cp
+	}
+	return nil
+}
+
+// Notification returns a copy of the notification queued for triageID.
+func (s *Store) Notification(_ context.Context, triageID string) (*triage.Notification, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.notifications[triageID]
+	if !ok {
+		return nil, false, nil
+	}
+	cp := *n
+	return &cp, true, nil
+}
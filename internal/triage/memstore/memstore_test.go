@@ -3,8 +3,10 @@ package memstore
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/linnemanlabs/vigil/internal/triage"
 )
@@ -172,6 +174,183 @@ func TestStore_PutPreservesConversation(t *testing.T) {
 	}
 }
 
+func TestStore_SavePartial(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	_ = s.Put(ctx, &triage.Result{ID: "t-sp", Fingerprint: "fp-sp", Status: triage.StatusInProgress})
+
+	_ = s.SavePartial(ctx, "t-sp", "The disk")
+	_ = s.SavePartial(ctx, "t-sp", "The disk on db-1 is full")
+	if err := s.SavePartial(ctx, "missing", "ignored"); err != nil {
+		t.Fatalf("SavePartial for unknown ID: %v", err)
+	}
+	got, _, _ := s.Get(ctx, "t-sp")
+	if got.Partial != "The disk on db-1 is full" {
+		t.Errorf("Partial = %q, want the latest text", got.Partial)
+	}
+
+	_ = s.Put(ctx, &triage.Result{ID: "t-sp", Fingerprint: "fp-sp", Status: triage.StatusComplete, Partial: "stale"})
+	got, _, _ = s.Get(ctx, "t-sp")
+	if got.Partial != "" {
+		t.Errorf("Partial after Put = %q, want cleared", got.Partial)
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, st := range []triage.Status{triage.StatusComplete, triage.StatusFailed, triage.StatusComplete, triage.StatusComplete} {
+		_ = s.Put(ctx, &triage.Result{
+			ID:          fmt.Sprintf("t-%d", i),
+			Fingerprint: fmt.Sprintf("fp-%d", i),
+			Status:      st,
+			Alert:       "A",
+			Labels:      map[string]string{"namespace": []string{"prod", "dev", "prod", "dev"}[i], "team": "db"},
+			CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	_, _ = s.AppendTurn(ctx, "t-3", 0, &triage.Turn{Role: "assistant"})
+
+	tests := []struct {
+		name   string
+		filter triage.ListFilter
+		want   []string
+	}{
+		{"all newest first", triage.ListFilter{}, []string{"t-3", "t-2", "t-1", "t-0"}},
+		{"status", triage.ListFilter{Status: triage.StatusComplete}, []string{"t-3", "t-2", "t-0"}},
+		{"limit", triage.ListFilter{Limit: 2}, []string{"t-3", "t-2"}},
+		{"before", triage.ListFilter{Before: base.Add(2 * time.Minute)}, []string{"t-1", "t-0"}},
+		{"alert mismatch", triage.ListFilter{Alert: "B"}, nil},
+		{"labels", triage.ListFilter{Labels: map[string]string{"namespace": "prod", "team": "db"}}, []string{"t-2", "t-0"}},
+		{"label mismatch", triage.ListFilter{Labels: map[string]string{"namespace": "prod", "team": "web"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := s.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			var ids []string
+			for _, r := range got {
+				if r.Conversation != nil {
+					t.Errorf("result %s has conversation, want summary only", r.ID)
+				}
+				ids = append(ids, r.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_ListPagesThroughTies(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		_ = s.Put(ctx, &triage.Result{ID: fmt.Sprintf("t-%d", i), Fingerprint: fmt.Sprintf("fp-%d", i), CreatedAt: at})
+	}
+	_ = s.Put(ctx, &triage.Result{ID: "t-old", Fingerprint: "fp-old", CreatedAt: at.Add(-time.Minute)})
+
+	var ids []string
+	f := triage.ListFilter{Limit: 2}
+	for range 10 {
+		page, err := s.List(ctx, f)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		for _, r := range page {
+			ids = append(ids, r.ID)
+		}
+		if len(page) < f.Limit {
+			break
+		}
+		f.Before, f.BeforeID = page[len(page)-1].CreatedAt, page[len(page)-1].ID
+	}
+	want := []string{"t-4", "t-3", "t-2", "t-1", "t-0", "t-old"}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("paged ids = %v, want %v", ids, want)
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, r := range []*triage.Result{
+		{Alert: "DiskErrors", Analysis: "XFS metadata corruption on /dev/sdb."},
+		{Alert: "NodeDown", Analysis: "Kernel panic after xfs corruption was detected."},
+		{Alert: "HighCPU", Analysis: "A runaway batch job."},
+		{Alert: "XFSCorruption", Summary: "xfs corruption", TenantID: "team-a"},
+	} {
+		r.ID = fmt.Sprintf("t-%d", i)
+		r.Fingerprint = r.ID
+		r.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		_ = s.Put(ctx, r)
+	}
+	_, _ = s.Delete(ctx, "t-1", base)
+
+	got, err := s.Search(ctx, triage.SearchQuery{Text: "XFS corruption"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "t-0" {
+		t.Fatalf("hits = %+v, want only t-0 (t-1 deleted, t-3 another tenant)", got)
+	}
+	if want := "**XFS** metadata **corruption** on /dev/sdb."; got[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", got[0].Snippet, want)
+	}
+
+	got, _ = s.Search(ctx, triage.SearchQuery{Tenant: triage.AnyTenant, Text: "xfs corruption"})
+	if len(got) != 2 || got[0].ID != "t-3" {
+		t.Errorf("hits = %+v, want alert name match t-3 first", got)
+	}
+}
+
+func TestStore_Stats(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, st := range []triage.Status{triage.StatusComplete, triage.StatusFailed, triage.StatusComplete} {
+		_ = s.Put(ctx, &triage.Result{
+			ID:          fmt.Sprintf("t-%d", i),
+			Fingerprint: fmt.Sprintf("fp-%d", i),
+			Status:      st,
+			Alert:       "A",
+			CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	_, _ = s.AppendTurn(ctx, "t-0", 0, &triage.Turn{Role: "assistant", Content: []triage.ContentBlock{{Type: "tool_use", ID: "c1", Name: "query_logs"}}})
+	_, _ = s.AppendTurn(ctx, "t-0", 1, &triage.Turn{Role: "user", Content: []triage.ContentBlock{{Type: "tool_result", ToolUseID: "c1", IsError: true}}})
+	_, _ = s.Delete(ctx, "t-2", base)
+
+	st, err := s.Stats(ctx, triage.StatsQuery{Since: base, Until: base.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if st.Triages != 2 || st.ByStatus[triage.StatusComplete] != 1 {
+		t.Errorf("counts = %d %v, want 2 with the deleted triage left out", st.Triages, st.ByStatus)
+	}
+	if len(st.Tools) != 1 || st.Tools[0].Errors != 1 {
+		t.Errorf("tools = %+v", st.Tools)
+	}
+}
+
 func TestStore_ConcurrentAccess(t *testing.T) {
 	t.Parallel()
 
@@ -200,3 +379,405 @@ func TestStore_ConcurrentAccess(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestStore_SoftDelete(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	now := time.Now()
+	for _, r := range []*triage.Result{
+		{ID: "t-1", Fingerprint: "fp-1", Status: triage.StatusComplete, CreatedAt: now},
+		{ID: "t-2", Fingerprint: "fp-2", Status: triage.StatusComplete, CreatedAt: now},
+	} {
+		if err := s.Put(ctx, r); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	if ok, err := s.Delete(ctx, "t-1", now); err != nil || !ok {
+		t.Fatalf("Delete = %v, %v; want true", ok, err)
+	}
+	if ok, _ := s.Delete(ctx, "t-1", now); ok {
+		t.Error("second Delete should report false")
+	}
+	if ok, _ := s.Delete(ctx, "missing", now); ok {
+		t.Error("Delete of unknown ID should report false")
+	}
+
+	if _, ok, _ := s.Get(ctx, "t-1"); ok {
+		t.Error("deleted result visible to Get")
+	}
+	if _, ok, _ := s.GetByFingerprint(ctx, "fp-1"); ok {
+		t.Error("deleted result visible to GetByFingerprint")
+	}
+	list, _ := s.List(ctx, triage.ListFilter{})
+	if len(list) != 1 || list[0].ID != "t-2" {
+		t.Errorf("List = %v, want only t-2", list)
+	}
+
+	if ok, err := s.Restore(ctx, "t-1"); err != nil || !ok {
+		t.Fatalf("Restore = %v, %v; want true", ok, err)
+	}
+	if ok, _ := s.Restore(ctx, "t-1"); ok {
+		t.Error("Restore of live result should report false")
+	}
+	if _, ok, _ := s.Get(ctx, "t-1"); !ok {
+		t.Error("restored result not visible to Get")
+	}
+}
+
+func TestStore_Purge(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	now := time.Now()
+	for _, id := range []string{"old", "recent", "live"} {
+		if err := s.Put(ctx, &triage.Result{ID: id, Fingerprint: "fp-" + id, Status: triage.StatusComplete}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	_, _ = s.Delete(ctx, "old", now.Add(-48*time.Hour))
+	_, _ = s.Delete(ctx, "recent", now.Add(-time.Hour))
+
+	n, err := s.Purge(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("purged %d, want 1", n)
+	}
+	if ok, _ := s.Restore(ctx, "old"); ok {
+		t.Error("purged result should not be restorable")
+	}
+	if ok, _ := s.Restore(ctx, "recent"); !ok {
+		t.Error("recently deleted result should still be restorable")
+	}
+	if _, ok, _ := s.Get(ctx, "live"); !ok {
+		t.Error("live result should survive purge")
+	}
+}
+
+func TestStore_ClaimDigest(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	due := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	if won, _ := s.ClaimDigest(ctx, "daily", due); !won {
+		t.Fatal("first claim should win")
+	}
+	if won, _ := s.ClaimDigest(ctx, "daily", due.In(time.FixedZone("EST", -5*3600))); won {
+		t.Error("second claim of the same digest should lose")
+	}
+	if won, _ := s.ClaimDigest(ctx, "weekly", due); !won {
+		t.Error("another period's digest should be claimable")
+	}
+}
+
+func TestStore_Lead(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	leading := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Lead(ctx, "purge", func(ctx context.Context) {
+			close(leading)
+			<-ctx.Done()
+		})
+	}()
+	<-leading
+
+	// The held lease blocks a second leader until its context ends.
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer waitCancel()
+	s.Lead(waitCtx, "purge", func(context.Context) { t.Error("second leader ran while the lease was held") })
+
+	var ran bool
+	s.Lead(context.Background(), "digest", func(context.Context) { ran = true })
+	if !ran {
+		t.Error("another job's lease should be free")
+	}
+
+	cancel()
+	<-done
+	ran = false
+	s.Lead(context.Background(), "purge", func(context.Context) { ran = true })
+	if !ran {
+		t.Error("released lease should be taken")
+	}
+}
+
+func TestStore_Outbox(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	now := time.Now()
+	for i, id := range []string{"b", "a", "later"} {
+		n := &triage.Notification{TriageID: id, Status: triage.NotificationPending, NextAttempt: now.Add(-time.Minute)}
+		if i == 2 {
+			n.NextAttempt = now.Add(time.Minute)
+		}
+		if err := s.PutNotifying(ctx, &triage.Result{ID: id, Fingerprint: "fp-" + id, Status: triage.StatusComplete}, n); err != nil {
+			t.Fatalf("PutNotifying: %v", err)
+		}
+	}
+	if _, ok, _ := s.Get(ctx, "a"); !ok {
+		t.Fatal("PutNotifying should store the result")
+	}
+
+	due, _ := s.DueNotifications(ctx, now, 10)
+	if len(due) != 2 || due[0].TriageID != "a" || due[1].TriageID != "b" {
+		t.Fatalf("due = %+v, want a and b", due)
+	}
+	due[0].Status = triage.NotificationDelivered
+	if err := s.UpdateNotification(ctx, due[0]); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+	// Putting again keeps the existing notification.
+	_ = s.PutNotifying(ctx, &triage.Result{ID: "a", Fingerprint: "fp-a"}, &triage.Notification{TriageID: "a", Status: triage.NotificationPending})
+	if due, _ := s.DueNotifications(ctx, now, 10); len(due) != 1 || due[0].TriageID != "b" {
+		t.Errorf("due = %+v, want b only", due)
+	}
+	if n, ok, _ := s.Notification(ctx, "a"); !ok || n.Status != triage.NotificationDelivered {
+		t.Errorf("Notification(a) = %+v, %v; want delivered", n, ok)
+	}
+	if _, ok, _ := s.Notification(ctx, "missing"); ok {
+		t.Error("Notification(missing) found")
+	}
+}
+
+func TestStore_CreateIfNotActive(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	const n = 50
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners []string
+	)
+	for i := range n {
+		wg.Go(func() {
+			r := &triage.Result{ID: fmt.Sprintf("id-%d", i), Fingerprint: "fp-race", Status: triage.StatusPending}
+			active, created, err := s.CreateIfNotActive(ctx, r)
+			if err != nil {
+				t.Errorf("CreateIfNotActive: %v", err)
+				return
+			}
+			if !created && active == nil {
+				t.Error("lost without returning the active triage")
+			}
+			if created {
+				mu.Lock()
+				winners = append(winners, r.ID)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(winners) != 1 {
+		t.Fatalf("winners = %v, want exactly one", winners)
+	}
+
+	// Once the winner finishes, the fingerprint is free again.
+	done := &triage.Result{ID: winners[0], Fingerprint: "fp-race", Status: triage.StatusComplete}
+	if err := s.Put(ctx, done); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, created, err := s.CreateIfNotActive(ctx, &triage.Result{ID: "next", Fingerprint: "fp-race", Status: triage.StatusPending}); err != nil || !created {
+		t.Errorf("CreateIfNotActive after completion = %v, %v, want created", created, err)
+	}
+}
+
+func TestStore_Tenants(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	acme := triage.WithTenant(context.Background(), "acme")
+	globex := triage.WithTenant(context.Background(), "globex")
+
+	for _, r := range []*triage.Result{
+		{ID: "a", Fingerprint: "fp", Status: triage.StatusPending, TenantID: "acme"},
+		{ID: "g", Fingerprint: "fp", Status: triage.StatusPending, TenantID: "globex"},
+	} {
+		if _, created, err := s.CreateIfNotActive(context.Background(), r); err != nil || !created {
+			t.Fatalf("CreateIfNotActive %s = %v, %v, want created", r.ID, created, err)
+		}
+	}
+
+	if r, ok, _ := s.GetByFingerprint(acme, "fp"); !ok || r.ID != "a" {
+		t.Errorf("acme GetByFingerprint = %+v, %v, want a", r, ok)
+	}
+	if r, ok, _ := s.GetByFingerprint(globex, "fp"); !ok || r.ID != "g" {
+		t.Errorf("globex GetByFingerprint = %+v, %v, want g", r, ok)
+	}
+	if _, ok, _ := s.GetByFingerprint(context.Background(), "fp"); ok {
+		t.Error("default tenant GetByFingerprint found another tenant's triage")
+	}
+
+	got, _ := s.List(context.Background(), triage.ListFilter{Tenant: "acme"})
+	if len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("List acme = %+v, want a", got)
+	}
+	if got, _ := s.List(context.Background(), triage.ListFilter{Tenant: triage.AnyTenant}); len(got) != 2 {
+		t.Errorf("List any tenant = %d results, want 2", len(got))
+	}
+}
+
+func TestStore_Decisions(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, d := range []triage.Decision{
+		{ID: "d1", Fingerprint: "fp-a", Alert: "DiskFull", Decision: triage.DecisionAccepted},
+		{ID: "d2", Fingerprint: "fp-a", Alert: "DiskFull", Decision: triage.DecisionSkipped, Reason: "duplicate"},
+		{ID: "d3", Fingerprint: "fp-b", Alert: "HighCPU", Decision: triage.DecisionSkipped, Reason: "snoozed"},
+		{ID: "d4", TenantID: "acme", Fingerprint: "fp-a", Alert: "DiskFull", Decision: triage.DecisionAccepted},
+	} {
+		d.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := s.RecordDecision(ctx, &d); err != nil {
+			t.Fatalf("RecordDecision: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		f    triage.DecisionFilter
+		want []string
+	}{
+		{name: "default tenant newest first", want: []string{"d3", "d2", "d1"}},
+		{name: "fingerprint", f: triage.DecisionFilter{Fingerprint: "fp-a"}, want: []string{"d2", "d1"}},
+		{name: "skipped", f: triage.DecisionFilter{Decision: triage.DecisionSkipped}, want: []string{"d3", "d2"}},
+		{name: "alert", f: triage.DecisionFilter{Alert: "HighCPU"}, want: []string{"d3"}},
+		{name: "before", f: triage.DecisionFilter{Before: base.Add(2 * time.Minute)}, want: []string{"d2", "d1"}},
+		{name: "before id", f: triage.DecisionFilter{Before: base.Add(2 * time.Minute), BeforeID: "d4"}, want: []string{"d3", "d2", "d1"}},
+		{name: "limit", f: triage.DecisionFilter{Limit: 1}, want: []string{"d3"}},
+		{name: "tenant", f: triage.DecisionFilter{Tenant: "acme"}, want: []string{"d4"}},
+		{name: "any tenant", f: triage.DecisionFilter{Tenant: triage.AnyTenant}, want: []string{"d4", "d3", "d2", "d1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := s.ListDecisions(ctx, tt.f)
+			if err != nil {
+				t.Fatalf("ListDecisions: %v", err)
+			}
+			var ids []string
+			for _, d := range got {
+				ids = append(ids, d.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	t.Run("purge", func(t *testing.T) {
+		s := New()
+		for i := range 3 {
+			_ = s.RecordDecision(ctx, &triage.Decision{ID: fmt.Sprint(i), CreatedAt: base.Add(time.Duration(i) * time.Hour)})
+		}
+		n, err := s.PurgeDecisions(ctx, base.Add(90*time.Minute))
+		if err != nil || n != 2 {
+			t.Fatalf("PurgeDecisions = %d, %v; want 2", n, err)
+		}
+		if got, _ := s.ListDecisions(ctx, triage.DecisionFilter{}); len(got) != 1 || got[0].ID != "2" {
+			t.Errorf("remaining = %+v, want only 2", got)
+		}
+	})
+}
+
+func TestStore_Audit(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	for _, e := range []triage.AuditEvent{
+		{ID: "e1", TriageID: "t1", Event: triage.AuditSubmitted, Actor: "api-token"},
+		{ID: "e2", TriageID: "t2", Event: triage.AuditSubmitted, Actor: "api-token"},
+		{ID: "e3", TriageID: "t1", Event: triage.AuditStarted, Actor: triage.ActorSystem},
+	} {
+		if err := s.RecordAudit(ctx, &e); err != nil {
+			t.Fatalf("RecordAudit: %v", err)
+		}
+	}
+
+	got, err := s.ListAudit(ctx, "t1")
+	if err != nil {
+		t.Fatalf("ListAudit: %v", err)
+	}
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+	if !slices.Equal(ids, []string{"e1", "e3"}) {
+		t.Errorf("ids = %v, want [e1 e3]", ids)
+	}
+
+	// Callers get copies.
+	got[0].Event = "mutated"
+	if again, _ := s.ListAudit(ctx, "t1"); again[0].Event != triage.AuditSubmitted {
+		t.Errorf("stored event changed through a returned copy: %+v", again[0])
+	}
+}
+
+func TestStore_Suppressions(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, sup := range []triage.Suppression{
+		{ID: "late", Fingerprint: "fp-a", CreatedAt: now, ExpiresAt: now.Add(2 * time.Hour)},
+		{ID: "soon", Matchers: map[string]string{"alertname": "Noisy"}, CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "acme", TenantID: "acme", Fingerprint: "fp-a", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := s.CreateSuppression(ctx, &sup); err != nil {
+			t.Fatalf("CreateSuppression: %v", err)
+		}
+	}
+
+	ids := func(tenant string, at time.Time) []string {
+		t.Helper()
+		got, err := s.ActiveSuppressions(ctx, tenant, at)
+		if err != nil {
+			t.Fatalf("ActiveSuppressions: %v", err)
+		}
+		var out []string
+		for _, sup := range got {
+			out = append(out, sup.ID)
+		}
+		return out
+	}
+	if got := ids("", now); !slices.Equal(got, []string{"soon", "late"}) {
+		t.Errorf("active = %v, want soonest to expire first", got)
+	}
+	if got := ids("", now.Add(time.Hour)); !slices.Equal(got, []string{"late"}) {
+		t.Errorf("active after an hour = %v, want only late", got)
+	}
+	if got := ids("acme", now); !slices.Equal(got, []string{"acme"}) {
+		t.Errorf("acme active = %v", got)
+	}
+
+	if ok, _ := s.DeleteSuppression(ctx, "acme", ""); ok {
+		t.Error("deleted another tenant's suppression")
+	}
+	if ok, _ := s.DeleteSuppression(ctx, "late", ""); !ok {
+		t.Error("DeleteSuppression(late) = false")
+	}
+	if got := ids("", now); !slices.Equal(got, []string{"soon"}) {
+		t.Errorf("active after delete = %v", got)
+	}
+}
@@ -0,0 +1,178 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// PromptMiddleware adjusts each request before the engine sends it, so prompt
+// behavior can be composed without changing the engine loop. Middlewares run
+// in registration order on every provider call.
+//
+// The system prompt is rebuilt by the engine before every call, but
+// req.Messages carries over: the engine continues the next turn from whatever
+// messages the chain leaves. Messages and their content blocks are shared with the
+// recorded conversation, so a middleware must replace them rather than
+// modify them in place. An error fails the triage.
+type PromptMiddleware interface {
+	Process(ctx context.Context, req *LLMRequest, info PromptInfo) error
+}
+
+// PromptMiddlewareFunc adapts a function to PromptMiddleware.
+type PromptMiddlewareFunc func(ctx context.Context, req *LLMRequest, info PromptInfo) error
+
+// Process calls f.
+func (f PromptMiddlewareFunc) Process(ctx context.Context, req *LLMRequest, info PromptInfo) error {
+	return f(ctx, req, info)
+}
+
+// PromptInfo describes the triage a request belongs to.
+type PromptInfo struct {
+	TriageID string
+	Alert    *alert.Alert
+	// Call counts the provider calls already made, so 0 is the first request.
+	Call int
+}
+
+// WithPromptMiddleware appends middlewares to the chain run before each
+// provider call.
+func WithPromptMiddleware(mw ...PromptMiddleware) EngineOption {
+	return func(e *LLMEngine) { e.middleware = append(e.middleware, mw...) }
+}
+
+// applyMiddleware runs the chain over req.
+func (e *LLMEngine) applyMiddleware(ctx context.Context, req *LLMRequest, info PromptInfo) error {
+	for _, mw := range e.middleware {
+		if err := mw.Process(ctx, req, info); err != nil {
+			return fmt.Errorf("prompt middleware: %w", err)
+		}
+	}
+	return nil
+}
+
+// EnrichPrompt adds the text fn returns for the alert to the initial prompt,
+// such as ownership, runbook or recent deploy details. fn runs once, before
+// the first call; an empty result adds nothing.
+func EnrichPrompt(fn func(ctx context.Context, al *alert.Alert) (string, error)) PromptMiddleware {
+	return PromptMiddlewareFunc(func(ctx context.Context, req *LLMRequest, info PromptInfo) error {
+		if info.Call != 0 || len(req.Messages) == 0 {
+			return nil
+		}
+		text, err := fn(ctx, info.Alert)
+		if err != nil {
+			return fmt.Errorf("enrich prompt: %w", err)
+		}
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		msgs := append([]Message(nil), req.Messages...)
+		first := msgs[0]
+		first.Content = append(append([]ContentBlock(nil), first.Content...), ContentBlock{
+			Type: "text",
+			Text: "## Additional Context\n" + text,
+		})
+		msgs[0] = first
+		req.Messages = msgs
+		return nil
+	})
+}
+
+// redactedText replaces whatever a RedactPrompt pattern matches.
+const redactedText = "[REDACTED]"
+
+// RedactPrompt replaces matches of the patterns with [REDACTED] in the system
+// prompt, message text and tool results before they leave the process. Tool
+// inputs are left alone, as they are the model's own words and must stay
+// valid JSON.
+func RedactPrompt(patterns ...*regexp.Regexp) PromptMiddleware {
+	redact := func(s string) string {
+		for _, re := range patterns {
+			s = re.ReplaceAllString(s, redactedText)
+		}
+		return s
+	}
+	return PromptMiddlewareFunc(func(_ context.Context, req *LLMRequest, _ PromptInfo) error {
+		req.System = redact(req.System)
+		req.Messages = mapBlocks(req.Messages, len(req.Messages), func(b ContentBlock) ContentBlock {
+			b.Text = redact(b.Text)
+			b.Content = redact(b.Content)
+			return b
+		})
+		return nil
+	})
+}
+
+// FewShotPrompt appends worked examples to the system prompt, such as past
+// analyses the team considers good.
+func FewShotPrompt(examples ...string) PromptMiddleware {
+	var b strings.Builder
+	if len(examples) > 0 {
+		b.WriteString("\n\n## Examples\nPast analyses written the way this team expects:\n")
+		for _, ex := range examples {
+			b.WriteString("\n<example>\n")
+			b.WriteString(strings.TrimSpace(ex))
+			b.WriteString("\n</example>\n")
+		}
+	}
+	section := b.String()
+	return PromptMiddlewareFunc(func(_ context.Context, req *LLMRequest, _ PromptInfo) error {
+		req.System += section
+		return nil
+	})
+}
+
+// compactedMarker precedes the omitted byte count CompactPrompt appends.
+const compactedMarker = "\n[compacted: "
+
+// CompactPrompt shortens tool results older than the last keepRecent
+// messages to at most maxBytes, keeping the head of each. Tool calls and
+// their results stay paired, so the conversation remains valid; only the
+// request shrinks, the recorded conversation keeps the full output.
+func CompactPrompt(keepRecent, maxBytes int) PromptMiddleware {
+	return PromptMiddlewareFunc(func(_ context.Context, req *LLMRequest, _ PromptInfo) error {
+		end := len(req.Messages) - max(keepRecent, 0)
+		req.Messages = mapBlocks(req.Messages, end, func(b ContentBlock) ContentBlock {
+			// Compacted messages carry over, so each result is cut only once.
+			if b.Type == "tool_result" && len(b.Content) > maxBytes && !strings.Contains(b.Content, compactedMarker) {
+				head := strings.ToValidUTF8(b.Content[:maxBytes], "")
+				b.Content = head + fmt.Sprintf(compactedMarker+"%d bytes omitted]", len(b.Content)-len(head))
+			}
+			return b
+		})
+		return nil
+	})
+}
+
+// mapBlocks applies fn to the content blocks of msgs[:n], copying only
+// the messages fn changes so shared slices are never written.
+func mapBlocks(msgs []Message, n int, fn func(ContentBlock) ContentBlock) []Message {
+	var out []Message
+	for i := 0; i < n && i < len(msgs); i++ {
+		var blocks []ContentBlock
+		for j, b := range msgs[i].Content {
+			nb := fn(b)
+			if nb.Text == b.Text && nb.Content == b.Content {
+				continue
+			}
+			if blocks == nil {
+				blocks = append([]ContentBlock(nil), msgs[i].Content...)
+			}
+			blocks[j] = nb
+		}
+		if blocks == nil {
+			continue
+		}
+		if out == nil {
+			out = append([]Message(nil), msgs...)
+		}
+		out[i].Content = blocks
+	}
+	if out == nil {
+		return msgs
+	}
+	return out
+}
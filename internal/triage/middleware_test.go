@@ -0,0 +1,180 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+func TestRun_PromptMiddleware(t *testing.T) {
+	t.Parallel()
+
+	secret := strings.Repeat("s", 20)
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_logs", output: json.RawMessage(`"token=` + secret + ` ` + strings.Repeat("x", 200) + `"`)})
+
+	provider := &mockProvider{responses: []*LLMResponse{
+		{
+			Content:    []ContentBlock{{Type: "tool_use", ID: "c1", Name: "query_logs", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		},
+		{
+			Content:    []ContentBlock{{Type: "tool_use", ID: "c2", Name: "query_logs", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		},
+	}}
+
+	var calls []int
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithPromptMiddleware(
+		PromptMiddlewareFunc(func(_ context.Context, _ *LLMRequest, info PromptInfo) error {
+			calls = append(calls, info.Call)
+			return nil
+		}),
+		EnrichPrompt(func(_ context.Context, al *alert.Alert) (string, error) {
+			return "owner: team-" + al.Labels["alertname"], nil
+		}),
+		RedactPrompt(regexp.MustCompile(`token=\S+`)),
+		FewShotPrompt("Root cause: disk full."),
+		CompactPrompt(2, 50),
+	))
+
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+	if rr.Status != StatusComplete {
+		t.Fatalf("status = %q, want complete", rr.Status)
+	}
+	if len(calls) != 3 || calls[0] != 0 || calls[2] != 2 {
+		t.Errorf("middleware calls = %v, want 0, 1, 2", calls)
+	}
+
+	last := provider.reqs[2]
+	if !strings.Contains(last.System, "<example>\nRoot cause: disk full.\n</example>") || !strings.Contains(rr.SystemPrompt, "## Examples") {
+		t.Errorf("system prompt missing examples: %q", last.System)
+	}
+	if strings.Count(last.System, "## Examples") != 1 {
+		t.Errorf("examples added more than once: %q", last.System)
+	}
+	if first := last.Messages[0].Content; len(first) != 2 || first[1].Text != "## Additional Context\nowner: team-TestAlert" {
+		t.Errorf("initial prompt = %+v, want enrichment appended once", first)
+	}
+
+	old := last.Messages[2].Content[0].Content
+	recent := last.Messages[4].Content[0].Content
+	if strings.Contains(old, secret) || strings.Contains(recent, secret) || !strings.Contains(recent, redactedText) {
+		t.Errorf("tool results not redacted: %q, %q", old, recent)
+	}
+	if !strings.Contains(old, "[compacted:") || strings.Contains(recent, "[compacted:") {
+		t.Errorf("compaction: old = %q, recent = %q; want only the old result compacted", old, recent)
+	}
+
+	// The record keeps what the model and tools actually produced.
+	if got := rr.Conversation.Turns[1].Content[0].Content; !strings.Contains(got, secret) || strings.Contains(got, "[compacted:") {
+		t.Errorf("recorded tool result was modified: %q", got)
+	}
+}
+
+func TestRun_FewShotPromptSingleShotContinuation(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{responses: []*LLMResponse{
+		{Content: []ContentBlock{{Type: "text", Text: "The disk filled up because "}}, StopReason: StopMaxTokens},
+		{Content: []ContentBlock{{Type: "text", Text: "log rotation stopped."}}, StopReason: StopEnd},
+	}}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider(),
+		WithPromptMiddleware(FewShotPrompt("Root cause: disk full.")))
+
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil, WithStrategy(StrategySingleShot))
+	if rr.Status != StatusComplete || len(provider.reqs) != 2 {
+		t.Fatalf("result = %q after %d calls, want complete after 2", rr.Status, len(provider.reqs))
+	}
+	for i, req := range provider.reqs {
+		if n := strings.Count(req.System, "## Examples"); n != 1 {
+			t.Errorf("call %d system prompt has the examples %d times, want once", i, n)
+		}
+	}
+	if n := strings.Count(rr.SystemPrompt, "## Examples"); n != 1 {
+		t.Errorf("recorded system prompt has the examples %d times, want once", n)
+	}
+}
+
+func TestRun_PromptMiddlewareError(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithPromptMiddleware(
+		EnrichPrompt(func(context.Context, *alert.Alert) (string, error) {
+			return "", errors.New("cmdb down")
+		}),
+	))
+
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+	if rr.Status != StatusFailed || !strings.Contains(rr.Analysis, "cmdb down") {
+		t.Errorf("result = %q %q, want failed with the middleware error", rr.Status, rr.Analysis)
+	}
+	if len(provider.reqs) != 0 {
+		t.Errorf("provider called %d times, want 0", len(provider.reqs))
+	}
+}
+
+func TestCompactPrompt(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("é", 30) // 60 bytes
+	tests := []struct {
+		name       string
+		keepRecent int
+		msgs       int
+		compacted  int
+	}{
+		{name: "keeps recent", keepRecent: 2, msgs: 4, compacted: 2},
+		{name: "keeps all", keepRecent: 10, msgs: 4, compacted: 0},
+		{name: "compacts all", keepRecent: 0, msgs: 3, compacted: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			msgs := make([]Message, tt.msgs)
+			for i := range msgs {
+				msgs[i] = Message{Role: "user", Content: []ContentBlock{
+					{Type: "text", Text: long},
+					{Type: "tool_result", ToolUseID: "c", Content: long},
+				}}
+			}
+			req := &LLMRequest{Messages: msgs}
+			// The second pass sees carried-over messages and must not cut again.
+			for range 2 {
+				if err := CompactPrompt(tt.keepRecent, 11).Process(context.Background(), req, PromptInfo{}); err != nil {
+					t.Fatalf("Process: %v", err)
+				}
+			}
+
+			compacted := 0
+			for i, m := range req.Messages {
+				if m.Content[0].Text != long {
+					t.Errorf("message %d text block changed", i)
+				}
+				if c := m.Content[1].Content; c != long {
+					compacted++
+					if !strings.HasPrefix(c, strings.Repeat("é", 5)+"\n[compacted: 50 bytes omitted]") || strings.Count(c, compactedMarker) != 1 {
+						t.Errorf("message %d compacted to %q", i, c)
+					}
+				}
+				if msgs[i].Content[1].Content != long {
+					t.Errorf("message %d modified in place", i)
+				}
+			}
+			if compacted != tt.compacted {
+				t.Errorf("compacted %d results, want %d", compacted, tt.compacted)
+			}
+		})
+	}
+}
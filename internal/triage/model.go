@@ -26,12 +26,15 @@ const (
 
 	// StatusBudgetExceeded means the triage hit input or output token limits
 	StatusBudgetExceeded Status = "budget_exceeded"
+
+	// StatusRefused means the model refused to triage the alert, even after a retry
+	StatusRefused Status = "refused"
 )
 
 // IsTerminal reports whether the status represents a final state.
 func (s Status) IsTerminal() bool {
 	switch s {
-	case StatusComplete, StatusFailed, StatusError, StatusMaxTurns, StatusBudgetExceeded:
+	case StatusComplete, StatusFailed, StatusError, StatusMaxTurns, StatusBudgetExceeded, StatusRefused:
 		return true
 	case StatusPending, StatusInProgress:
 		return false
@@ -42,25 +45,83 @@ func (s Status) IsTerminal() bool {
 
 // Result is the outcome of a triage run.
 type Result struct {
-	ID           string        `json:"id"`
-	Fingerprint  string        `json:"fingerprint"`
-	Status       Status        `json:"status"`
-	Alert        string        `json:"alert_name"`
-	Severity     string        `json:"severity"`
-	Summary      string        `json:"summary"`
-	Analysis     string        `json:"analysis,omitempty"`
+	ID           string `json:"id"`
+	Fingerprint  string `json:"fingerprint"`
+	Status       Status `json:"status"`
+	Alert        string `json:"alert_name"`
+	Severity     string `json:"severity"`
+	Summary      string `json:"summary"`
+	GeneratorURL string `json:"generator_url,omitempty"`
+	// Labels and Annotations are the alert's as received, kept so triages
+	// can be found by any label rather than only the alert name.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Analysis    string            `json:"analysis,omitempty"`
+	// Partial is the text of the LLM response being streamed while the
+	// triage runs. It is left behind if the process dies mid-response.
+	Partial      string        `json:"partial,omitempty"`
+	Notes        []Note        `json:"investigation_notes,omitempty"`
 	ToolsUsed    []string      `json:"tools_used,omitempty"`
 	Conversation *Conversation `json:"conversation,omitempty"`
-	CreatedAt    time.Time     `json:"created_at"`
-	CompletedAt  time.Time     `json:"completed_at,omitempty"`
-	Duration     float64       `json:"duration_seconds,omitempty"`
-	LLMTime      float64       `json:"llm_time_seconds,omitempty"`
-	ToolTime     float64       `json:"tool_time_seconds,omitempty"`
-	TokensIn     int           `json:"tokens_in,omitempty"`
-	TokensOut    int           `json:"tokens_out,omitempty"`
-	ToolCalls    int           `json:"tool_calls,omitempty"`
-	SystemPrompt string        `json:"system_prompt,omitempty"`
-	Model        string        `json:"model,omitempty"`
+	// ReceivedAt is when Vigil received the alert, before it was submitted
+	// at CreatedAt. It is zero in triages stored before it was tracked.
+	ReceivedAt time.Time `json:"received_at,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// StartedAt is when the triage left the queue and began its first LLM
+	// call. It is zero until then, and in triages stored before it was
+	// tracked.
+	StartedAt   time.Time `json:"started_at,omitempty"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	Duration    float64   `json:"duration_seconds,omitempty"`
+	LLMTime     float64   `json:"llm_time_seconds,omitempty"`
+	ToolTime    float64   `json:"tool_time_seconds,omitempty"`
+	TokensIn    int       `json:"tokens_in,omitempty"`
+	TokensOut   int       `json:"tokens_out,omitempty"`
+	// TokensThinking is the estimated part of TokensOut spent on extended
+	// thinking.
+	TokensThinking int    `json:"tokens_thinking,omitempty"`
+	ToolCalls      int    `json:"tool_calls,omitempty"`
+	SystemPrompt   string `json:"system_prompt,omitempty"`
+	Model          string `json:"model,omitempty"`
+	// Redactions is how many secrets were scrubbed from tool output before
+	// the model saw it.
+	Redactions int `json:"redactions,omitempty"`
+	// Strategy is how the engine investigated the alert. It is empty in
+	// triages stored before strategies existed, which used StrategyReAct.
+	Strategy Strategy `json:"strategy,omitempty"`
+	// Provider names the provider that wrote the analysis when a fallback
+	// provider is configured: "primary" or "fallback".
+	Provider string `json:"provider,omitempty"`
+	// TenantID is the tenant the alert was submitted by, empty for the
+	// default tenant.
+	TenantID string `json:"tenant_id,omitempty"`
+	// IssueURL is the issue opened for the triage in the issue tracker, if
+	// any.
+	IssueURL string `json:"issue_url,omitempty"`
+	// TraceID is the OpenTelemetry trace ID of the triage's root span, empty
+	// when tracing is disabled.
+	TraceID string `json:"trace_id,omitempty"`
+	// DryRun marks a triage that was run without notifying, opening an
+	// issue or suggesting actions, such as one of a backfill of past alerts.
+	DryRun bool `json:"dry_run,omitempty"`
+	// Actions are the remediations the model suggested from the action
+	// catalog, in its order, with the outcome of any an operator executed.
+	Actions []SuggestedAction `json:"suggested_actions,omitempty"`
+	// Metadata is the operator's context for the alert, such as its owner
+	// and runbook, when an enricher knew of any.
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Children lists the triages an incident-level meta-triage summarized.
+	// It is empty for triages of a single alert.
+	Children []string `json:"children,omitempty"`
+}
+
+// Note is commentary the model wrote while investigating, before its final
+// analysis, such as what it expects a query to show or why it is changing
+// direction. Turn is the index of the assistant turn it came from.
+type Note struct {
+	Turn      int       `json:"turn"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // Conversation records the full LLM interaction during a triage run.
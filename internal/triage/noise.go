@@ -0,0 +1,188 @@
+package triage
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultNoiseWindow is how much triage history noise scores look at.
+	DefaultNoiseWindow = 7 * 24 * time.Hour
+
+	// noiseFrequencyCeiling is the triage rate, per day, at which the
+	// frequency half of the score saturates.
+	noiseFrequencyCeiling = 24.0
+
+	// historyMaxResults bounds the history read for one scoring pass or
+	// digest.
+	historyMaxResults = 10 * MaxListLimit
+)
+
+// noisyBudget is the reduced budget for alerts at or above the noise
+// threshold: enough to confirm a known pattern, not to investigate from
+// scratch.
+var noisyBudget = Budget{
+	ToolCalls:    MaxToolRounds / 3,
+	InputTokens:  MaxInputTokens / 4,
+	OutputTokens: MaxOutputTokens / 4,
+}
+
+// NoiseScore rates how noisy an alertname has been over a window, from 0
+// (rare, with varied findings) to 1 (firing constantly with the same
+// analysis every time). Score averages two signals: PerDay against a ceiling
+// of 24 triages a day, and RepeatRatio, the share of completed analyses that
+// repeat an earlier one once numbers are ignored.
+type NoiseScore struct {
+	Alert       string  `json:"alert_name"`
+	Score       float64 `json:"score"`
+	Triages     int     `json:"triages"`
+	PerDay      float64 `json:"per_day"`
+	RepeatRatio float64 `json:"repeat_ratio"`
+}
+
+// ScoreNoise computes a NoiseScore per alertname from results created within
+// window, noisiest first.
+func ScoreNoise(results []*Result, window time.Duration, now time.Time) []NoiseScore {
+	since := now.Add(-window)
+	byAlert := make(map[string][]*Result)
+	for _, r := range results {
+		if r.Alert == "" || r.CreatedAt.Before(since) {
+			continue
+		}
+		byAlert[r.Alert] = append(byAlert[r.Alert], r)
+	}
+
+	days := max(window.Hours()/24, 1.0/24)
+	out := make([]NoiseScore, 0, len(byAlert))
+	for name, rs := range byAlert {
+		slices.SortFunc(rs, func(a, b *Result) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+		seen := make(map[string]struct{})
+		var analyses, repeats int
+		for _, r := range rs {
+			if r.Status != StatusComplete || r.Analysis == "" {
+				continue
+			}
+			analyses++
+			key := normalizeAnalysis(r.Analysis)
+			if _, ok := seen[key]; ok {
+				repeats++
+			}
+			seen[key] = struct{}{}
+		}
+
+		ns := NoiseScore{Alert: name, Triages: len(rs), PerDay: float64(len(rs)) / days}
+		if analyses > 1 {
+			ns.RepeatRatio = float64(repeats) / float64(analyses-1)
+		}
+		freq := math.Min(ns.PerDay/noiseFrequencyCeiling, 1)
+		ns.Score = round2((freq + ns.RepeatRatio) / 2)
+		ns.PerDay = round2(ns.PerDay)
+		ns.RepeatRatio = round2(ns.RepeatRatio)
+		out = append(out, ns)
+	}
+	slices.SortFunc(out, func(a, b NoiseScore) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Alert, b.Alert))
+	})
+	return out
+}
+
+// noisiest scores the alert names of history triaged in [since, until),
+// keeping the n noisiest. history must not hold results from until on.
+func noisiest(history []*Result, since, until time.Time, n int) []NoiseScore {
+	scores := ScoreNoise(history, until.Sub(since), until)
+	return scores[:min(len(scores), n)]
+}
+
+var analysisDigits = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+
+// normalizeAnalysis reduces an analysis to the text that identifies its
+// finding, so "disk at 91%" and "disk at 93%" count as the same analysis.
+func normalizeAnalysis(s string) string {
+	s = analysisDigits.ReplaceAllString(strings.ToLower(s), "#")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// NoiseScores scores every alertname the tenant triaged within window,
+// noisiest first.
+func (s *Service) NoiseScores(ctx context.Context, window time.Duration) ([]NoiseScore, error) {
+	return s.noiseScores(ctx, TenantFrom(ctx), window)
+}
+
+func (s *Service) noiseScores(ctx context.Context, tenant string, window time.Duration) ([]NoiseScore, error) {
+	now := time.Now()
+	history, err := s.history(ctx, ListFilter{Tenant: tenant}, now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	return ScoreNoise(history, window, now), nil
+}
+
+// history pages through the results matching f, newest first, until it
+// reaches ones created before since or historyMaxResults. The last page may
+// hold results older than since.
+func (s *Service) history(ctx context.Context, f ListFilter, since time.Time) ([]*Result, error) {
+	var out []*Result
+	f.Limit = MaxListLimit
+	for len(out) < historyMaxResults {
+		page, err := s.store.List(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+		if len(page) < MaxListLimit || page[len(page)-1].CreatedAt.Before(since) {
+			break
+		}
+		f.Before, f.BeforeID = page[len(page)-1].CreatedAt, page[len(page)-1].ID
+	}
+	return out, nil
+}
+
+// RunNoiseScorer recomputes noise scores every interval until ctx is done,
+// for WithNoiseDowngrade.
+func (s *Service) RunNoiseScorer(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s.refreshNoise(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Service) refreshNoise(ctx context.Context) {
+	// The downgrade is keyed by alert name alone, so score across tenants.
+	scores, err := s.noiseScores(ctx, AnyTenant, s.noiseWindow)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error(ctx, err, "failed to compute noise scores")
+		}
+		return
+	}
+	m := make(map[string]float64, len(scores))
+	for _, ns := range scores {
+		m[ns.Alert] = ns.Score
+	}
+	s.noise.Store(&m)
+}
+
+// noiseScore returns the last computed score for an alertname, 0 if unknown.
+func (s *Service) noiseScore(alertname string) float64 {
+	m := s.noise.Load()
+	if m == nil {
+		return 0
+	}
+	return (*m)[alertname]
+}
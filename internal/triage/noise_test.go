@@ -0,0 +1,115 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+func TestScoreNoise(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	var results []*Result
+	add := func(alert string, ago time.Duration, status Status, analysis string) {
+		results = append(results, &Result{Alert: alert, Status: status, Analysis: analysis, CreatedAt: now.Add(-ago)})
+	}
+	// Flapping fires hourly for a day with the same finding each time.
+	for i := range 24 {
+		add("Flapping", time.Duration(i)*time.Hour, StatusComplete, fmt.Sprintf("Disk on db-1 at %d%%, no action needed.", 80+i%10))
+	}
+	// Rare fired twice with different findings.
+	add("Rare", time.Hour, StatusComplete, "Certificate expired on api-1.")
+	add("Rare", 2*time.Hour, StatusComplete, "Upstream DNS outage.")
+	// Old is outside the window.
+	add("Old", 48*time.Hour, StatusComplete, "whatever")
+
+	scores := ScoreNoise(results, 24*time.Hour, now)
+	if len(scores) != 2 {
+		t.Fatalf("scores = %+v, want Flapping and Rare", scores)
+	}
+	flap, rare := scores[0], scores[1]
+	if flap.Alert != "Flapping" || flap.Triages != 24 || flap.PerDay != 24 || flap.RepeatRatio != 1 || flap.Score != 1 {
+		t.Errorf("Flapping = %+v, want score 1", flap)
+	}
+	if rare.Alert != "Rare" || rare.RepeatRatio != 0 || rare.Score != 0.04 {
+		t.Errorf("Rare = %+v, want score 0.04", rare)
+	}
+}
+
+func TestScoreNoise_IgnoresUnfinishedAnalyses(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	results := []*Result{
+		{Alert: "A", Status: StatusComplete, Analysis: "same", CreatedAt: now.Add(-3 * time.Hour)},
+		{Alert: "A", Status: StatusBudgetExceeded, Analysis: "Triage terminated: input token budget exhausted", CreatedAt: now.Add(-2 * time.Hour)},
+		{Alert: "A", Status: StatusBudgetExceeded, Analysis: "Triage terminated: input token budget exhausted", CreatedAt: now.Add(-time.Hour)},
+	}
+	scores := ScoreNoise(results, DefaultNoiseWindow, now)
+	if len(scores) != 1 || scores[0].Triages != 3 || scores[0].RepeatRatio != 0 {
+		t.Errorf("scores = %+v, want 3 triages and no repeats", scores)
+	}
+}
+
+func TestNoiseDowngrade(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	now := time.Now()
+	for i := range 30 {
+		id := fmt.Sprintf("old-%d", i)
+		store.results[id] = &Result{ID: id, Alert: "Flapping", Status: StatusComplete, Analysis: "known flap", CreatedAt: now.Add(-time.Duration(i) * time.Hour)}
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "loop_tool", output: json.RawMessage(`"ok"`)})
+	responses := make([]*LLMResponse, MaxToolRounds)
+	for i := range MaxToolRounds {
+		responses[i] = &LLMResponse{
+			Content:    []ContentBlock{{Type: "tool_use", ID: fmt.Sprintf("call-%d", i), Name: "loop_tool", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+			Usage:      Usage{InputTokens: 10, OutputTokens: 5},
+		}
+	}
+	engine := NewEngine(&mockProvider{responses: responses}, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithNoiseDowngrade(0.8, 24*time.Hour))
+
+	// A cancelled context runs exactly one scoring pass.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.RunNoiseScorer(ctx, time.Hour)
+	if got := svc.noiseScore("Flapping"); got < 0.8 {
+		t.Fatalf("noise score = %v, want >= 0.8", got)
+	}
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-flap",
+		Labels:      map[string]string{"alertname": "Flapping"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r, ok, _ := store.Get(context.Background(), sr.ID)
+		if ok && r.Status.IsTerminal() {
+			if r.Status != StatusMaxTurns || r.ToolCalls != noisyBudget.ToolCalls {
+				t.Errorf("result = %s with %d tool calls, want max_turns after %d", r.Status, r.ToolCalls, noisyBudget.ToolCalls)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("triage did not complete within deadline")
+}
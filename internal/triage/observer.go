@@ -0,0 +1,86 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// LLMCall is one provider call as seen by an LLMObserver.
+type LLMCall struct {
+	TriageID string
+	Alert    *alert.Alert
+	// Seq counts the provider calls already made in the run, so 0 is the
+	// first.
+	Seq     int
+	Request *LLMRequest
+	// Response is only set when Err is nil.
+	Response *LLMResponse
+	Err      error
+	Duration time.Duration
+}
+
+// LLMObserver sees every provider call, for exporting runs to LLM
+// observability platforms. ctx carries the call's llm.call span. It runs
+// on the triage's goroutine, so it must not block; the request and response
+// are shared with the conversation and must not be modified.
+type LLMObserver interface {
+	ObserveLLMCall(ctx context.Context, call *LLMCall)
+}
+
+// WithLLMObserver passes every provider call to o. Given more than once,
+// every observer sees every call, in the order they were given.
+func WithLLMObserver(o LLMObserver) EngineOption {
+	return func(e *LLMEngine) { e.observers = append(e.observers, o) }
+}
+
+// observe passes a finished call to the observers, if any.
+func (e *LLMEngine) observe(ctx context.Context, call *LLMCall) {
+	for _, o := range e.observers {
+		o.ObserveLLMCall(ctx, call)
+	}
+}
+
+// ToolExecution is one executed tool call as seen by a ToolObserver.
+type ToolExecution struct {
+	TriageID string
+	Tool     string
+	Input    json.RawMessage
+	// Output is the scrubbed result the model was shown, or the scrubbed
+	// error message when IsError is set.
+	Output   string
+	IsError  bool
+	Duration time.Duration
+}
+
+// ToolObserver sees every tool call the engine executes. Calls to tools
+// the registry does not know are not executed and not observed. With tool
+// concurrency above 1 it is called from several goroutines at once.
+type ToolObserver interface {
+	ObserveToolCall(ctx context.Context, call *ToolExecution)
+}
+
+// WithToolObserver passes every executed tool call to o.
+func WithToolObserver(o ToolObserver) EngineOption {
+	return func(e *LLMEngine) { e.toolObservers = append(e.toolObservers, o) }
+}
+
+// observeTool passes a finished tool call to the tool observers, if any.
+func (e *LLMEngine) observeTool(ctx context.Context, triageID string, block *ContentBlock, output string, isError bool, dur float64) {
+	if len(e.toolObservers) == 0 {
+		return
+	}
+	call := &ToolExecution{
+		TriageID: triageID,
+		Tool:     block.Name,
+		Input:    block.Input,
+		Output:   output,
+		IsError:  isError,
+		Duration: time.Duration(dur * float64(time.Second)),
+	}
+	for _, o := range e.toolObservers {
+		o.ObserveToolCall(ctx, call)
+	}
+}
@@ -0,0 +1,124 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// callRecorder is an LLMObserver keeping every call it sees.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []LLMCall
+}
+
+func (r *callRecorder) ObserveLLMCall(_ context.Context, call *LLMCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, *call)
+}
+
+func TestRun_LLMObserver(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_metrics", output: json.RawMessage(`{"up":1}`)})
+	provider := &mockProvider{
+		responses: []*LLMResponse{
+			{
+				Content:    []ContentBlock{{Type: "tool_use", ID: "call-1", Name: "query_metrics", Input: json.RawMessage(`{}`)}},
+				StopReason: StopToolUse,
+			},
+			nil,
+		},
+		errs: []error{nil, errors.New("overloaded")},
+	}
+	rec := &callRecorder{}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithLLMObserver(rec))
+
+	engine.Run(context.Background(), "t-observe", testAlert(), nil)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.calls) != 2 {
+		t.Fatalf("observed %d calls, want 2", len(rec.calls))
+	}
+	first, second := rec.calls[0], rec.calls[1]
+	if first.TriageID != "t-observe" || first.Seq != 0 || first.Response == nil || first.Err != nil {
+		t.Errorf("first call = %+v", first)
+	}
+	if second.Seq != 1 || second.Response != nil || second.Err == nil {
+		t.Errorf("second call = %+v, want the error without a response", second)
+	}
+	if len(second.Request.Messages) != 3 {
+		t.Errorf("second request has %d messages, want the alert, tool call and result", len(second.Request.Messages))
+	}
+}
+
+// toolRecorder is a ToolObserver keeping every call it sees.
+type toolRecorder struct {
+	mu    sync.Mutex
+	calls []ToolExecution
+}
+
+func (r *toolRecorder) ObserveToolCall(_ context.Context, call *ToolExecution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, *call)
+}
+
+func TestRun_ToolObserver(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_metrics", output: json.RawMessage(`{"up":1}`)})
+	registry.Register(&mockTool{name: "query_logs", err: errors.New("loki unreachable")})
+	provider := &mockProvider{
+		responses: []*LLMResponse{
+			{
+				Content: []ContentBlock{
+					{Type: "tool_use", ID: "call-1", Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)},
+					{Type: "tool_use", ID: "call-2", Name: "query_logs", Input: json.RawMessage(`{}`)},
+					{Type: "tool_use", ID: "call-3", Name: "no_such_tool", Input: json.RawMessage(`{}`)},
+				},
+				StopReason: StopToolUse,
+			},
+			{Content: []ContentBlock{{Type: "text", Text: "done"}}, StopReason: StopEnd},
+		},
+	}
+	rec := &toolRecorder{}
+	llm := &callRecorder{}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider(),
+		WithToolObserver(rec), WithLLMObserver(llm), WithLLMObserver(llm))
+
+	engine.Run(context.Background(), "t-tools", testAlert(), nil)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.calls) != 2 {
+		t.Fatalf("observed %d tool calls, want 2 (unknown tools are not executed)", len(rec.calls))
+	}
+	ok, failed := rec.calls[0], rec.calls[1]
+	if ok.TriageID != "t-tools" || ok.Tool != "query_metrics" || string(ok.Input) != `{"query":"up"}` || ok.Output != `{"up":1}` || ok.IsError {
+		t.Errorf("successful call = %+v", ok)
+	}
+	if failed.Tool != "query_logs" || failed.Output != "loki unreachable" || !failed.IsError {
+		t.Errorf("failed call = %+v", failed)
+	}
+
+	llm.mu.Lock()
+	defer llm.mu.Unlock()
+	if len(llm.calls) != 4 {
+		t.Errorf("observed %d LLM calls, want each of 2 calls by both observers", len(llm.calls))
+	}
+	if llm.calls[0].Alert == nil || llm.calls[0].Alert.Fingerprint != "fp-test" {
+		t.Errorf("LLM call alert = %+v", llm.calls[0].Alert)
+	}
+}
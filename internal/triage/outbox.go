@@ -0,0 +1,178 @@
+package triage
+
+import (
+	"context"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// Notification delivery states.
+const (
+	NotificationPending   = "pending"
+	NotificationDelivered = "delivered"
+	// NotificationFailed is final: delivery was given up after
+	// outboxMaxAttempts.
+	NotificationFailed = "failed"
+)
+
+const (
+	// outboxInlineGrace delays the dispatcher's first look at a new
+	// notification, so it does not race the attempt runTriage makes itself.
+	outboxInlineGrace = time.Minute
+
+	// outboxBackoff doubles from its base after each failed attempt, up to
+	// its cap, over outboxMaxAttempts attempts: about four hours in all.
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+	outboxMaxAttempts = 10
+
+	// outboxBatch caps the notifications one dispatcher pass sends.
+	outboxBatch = 50
+)
+
+// Notification is the delivery of one finished triage to its notifier.
+type Notification struct {
+	TriageID string
+	TenantID string
+	// Receiver is the alert's Alertmanager receiver, so a retry reaches the
+	// notifier of the profile it was routed to.
+	Receiver    string
+	Status      string
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+	CreatedAt   time.Time
+	DeliveredAt time.Time
+}
+
+// Outbox keeps notifications in the store until they are delivered, so a
+// notifier outage delays them instead of losing them.
+type Outbox interface {
+	// PutNotifying stores result like Store.Put and queues n for it in the
+	// same transaction.
+	PutNotifying(ctx context.Context, result *Result, n *Notification) error
+	// DueNotifications returns up to limit pending notifications whose next
+	// attempt is due by now, oldest first.
+	DueNotifications(ctx context.Context, now time.Time, limit int) ([]*Notification, error)
+	// UpdateNotification records the outcome of a delivery attempt.
+	UpdateNotification(ctx context.Context, n *Notification) error
+	// Notification returns the notification queued for a triage, if any.
+	Notification(ctx context.Context, triageID string) (*Notification, bool, error)
+}
+
+// WithOutbox queues every notification in o before it is sent, and has
+// RunOutbox retry those that fail.
+func WithOutbox(o Outbox) ServiceOption {
+	return func(s *Service) {
+		s.outbox = o
+	}
+}
+
+// RunOutbox sends due notifications every interval until ctx is done.
+func (s *Service) RunOutbox(ctx context.Context, interval time.Duration) {
+	if s.outbox == nil {
+		return
+	}
+	s.lead(ctx, "outbox", func(ctx context.Context) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			s.dispatch(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	})
+}
+
+// dispatch makes one attempt at each due notification.
+func (s *Service) dispatch(ctx context.Context) {
+	due, err := s.outbox.DueNotifications(ctx, time.Now(), outboxBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Error(ctx, err, "failed to load due notifications")
+		}
+		return
+	}
+	for _, n := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		L := s.logger.With("triage_id", n.TriageID, "attempt", n.Attempts+1)
+		tctx := WithTenant(ctx, n.TenantID)
+		r, ok, err := s.store.Get(tctx, n.TriageID)
+		switch {
+		case err != nil:
+			L.Error(ctx, err, "failed to load triage for notification")
+			continue
+		case !ok:
+			// Deleted since it finished; there is nothing left to announce.
+			n.Status, n.LastError = NotificationFailed, "triage was deleted"
+			if err := s.outbox.UpdateNotification(ctx, n); err != nil {
+				L.Error(ctx, err, "failed to record notification outcome", "outcome", "failed")
+			}
+			continue
+		}
+		s.recordDelivery(ctx, L, n, s.sendNotification(tctx, L, s.notifierFor(n.TenantID, n.Receiver), r))
+		if n.Status == NotificationDelivered {
+			s.observeDelivered(r, n.DeliveredAt)
+		}
+	}
+}
+
+// notifierFor returns the notifier a triage of tenant and receiver was
+// routed to, resolving profiles the way Submit does.
+func (s *Service) notifierFor(tenant, receiver string) Notifier {
+	profile, ok := s.tenants[tenant]
+	if !ok && s.profiles != nil {
+		profile = s.profiles.Resolve(&alert.Alert{Receiver: receiver})
+	}
+	if profile != nil && profile.Notifier != nil {
+		return profile.Notifier
+	}
+	return s.notifier
+}
+
+// recordDelivery stores the outcome of an attempt to send n, scheduling the
+// next one with backoff or giving up after outboxMaxAttempts.
+func (s *Service) recordDelivery(ctx context.Context, logger log.Logger, n *Notification, sendErr error) {
+	now := time.Now()
+	n.Attempts++
+	outcome := "delivered"
+	switch {
+	case sendErr == nil:
+		n.Status, n.DeliveredAt, n.LastError = NotificationDelivered, now, ""
+	case n.Attempts >= outboxMaxAttempts:
+		n.Status, n.LastError = NotificationFailed, sendErr.Error()
+		outcome = "failed"
+		logger.Error(ctx, sendErr, "notification given up", "attempts", n.Attempts)
+	default:
+		n.LastError = sendErr.Error()
+		n.NextAttempt = now.Add(outboxDelay(n.Attempts))
+		outcome = "retry"
+		logger.Info(ctx, "notification will be retried", "attempts", n.Attempts, "next_attempt", n.NextAttempt)
+	}
+	if s.metrics != nil {
+		s.metrics.NotificationsTotal.WithLabelValues(outcome).Inc()
+	}
+	if err := s.outbox.UpdateNotification(ctx, n); err != nil {
+		logger.Error(ctx, err, "failed to record notification outcome", "outcome", outcome)
+	}
+}
+
+// outboxDelay is the wait before the attempt after the given number of
+// failed ones.
+func outboxDelay(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for range attempts - 1 {
+		d *= 2
+		if d >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return d
+}
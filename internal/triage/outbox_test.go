@@ -0,0 +1,217 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// mockOutbox queues notifications next to a mockStore.
+type mockOutbox struct {
+	store *mockStore
+	mu    sync.Mutex
+	queue map[string]*Notification
+}
+
+func newMockOutbox(store *mockStore) *mockOutbox {
+	return &mockOutbox{store: store, queue: map[string]*Notification{}}
+}
+
+func (m *mockOutbox) PutNotifying(ctx context.Context, r *Result, n *Notification) error {
+	if err := m.store.Put(ctx, r); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *n
+	m.queue[n.TriageID] = &cp
+	return nil
+}
+
+func (m *mockOutbox) DueNotifications(_ context.Context, now time.Time, _ int) ([]*Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Notification
+	for _, n := range m.queue {
+		if n.Status == NotificationPending && !n.NextAttempt.After(now) {
+			cp := *n
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockOutbox) UpdateNotification(_ context.Context, n *Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *n
+	m.queue[n.TriageID] = &cp
+	return nil
+}
+
+func (m *mockOutbox) Notification(_ context.Context, id string) (*Notification, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.queue[id]
+	if !ok {
+		return nil, false, nil
+	}
+	cp := *n
+	return &cp, true, nil
+}
+
+func (m *mockOutbox) get(id string) Notification {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n := m.queue[id]; n != nil {
+		return *n
+	}
+	return Notification{}
+}
+
+func TestOutbox_RetriesFailedNotification(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	outbox := newMockOutbox(store)
+	notifier := newMockNotifier()
+	notifier.err = errors.New("webhook down")
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "analysis"}},
+		StopReason: StopEnd,
+	}}}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, notifier, noop.NewTracerProvider(), WithOutbox(outbox))
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-outbox",
+		Receiver:    "team-a",
+		Labels:      map[string]string{"alertname": "OutboxTest"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	// The inline attempt fails and is recorded for retry.
+	deadline := time.Now().Add(2 * time.Second)
+	var n Notification
+	for n = outbox.get(sr.ID); n.Attempts == 0 && time.Now().Before(deadline); n = outbox.get(sr.ID) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n.Status != NotificationPending || n.Attempts != 1 || n.LastError != "webhook down" || n.Receiver != "team-a" {
+		t.Fatalf("after inline attempt = %+v, want pending after 1 attempt", n)
+	}
+	if wait := time.Until(n.NextAttempt); wait < outboxBaseBackoff-time.Second || wait > outboxBaseBackoff {
+		t.Errorf("next attempt in %v, want %v", wait, outboxBaseBackoff)
+	}
+
+	// Not due yet: the dispatcher leaves it alone.
+	svc.dispatch(context.Background())
+	if got := outbox.get(sr.ID); got.Attempts != 1 {
+		t.Fatalf("attempts = %d before the retry was due, want 1", got.Attempts)
+	}
+
+	notifier.mu.Lock()
+	notifier.err = nil
+	notifier.mu.Unlock()
+	n.NextAttempt = time.Now()
+	_ = outbox.UpdateNotification(context.Background(), &n)
+	svc.dispatch(context.Background())
+
+	got := outbox.get(sr.ID)
+	if got.Status != NotificationDelivered || got.Attempts != 2 || got.LastError != "" || got.DeliveredAt.IsZero() {
+		t.Errorf("after retry = %+v, want delivered on attempt 2", got)
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if notifier.calls != 2 || notifier.last.Analysis != "analysis" {
+		t.Errorf("notifier calls = %d, last = %+v; want 2 with the stored result", notifier.calls, notifier.last)
+	}
+}
+
+func TestOutbox_GivesUp(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	store.results["t1"] = &Result{ID: "t1", Status: StatusComplete}
+	outbox := newMockOutbox(store)
+	notifier := newMockNotifier()
+	notifier.err = errors.New("webhook down")
+	svc := NewService(store, nil, log.Nop(), nil, notifier, noop.NewTracerProvider(), WithOutbox(outbox))
+
+	outbox.queue["t1"] = &Notification{TriageID: "t1", Status: NotificationPending, Attempts: outboxMaxAttempts - 1}
+	svc.dispatch(context.Background())
+	if got := outbox.get("t1"); got.Status != NotificationFailed || got.Attempts != outboxMaxAttempts {
+		t.Errorf("notification = %+v, want failed after %d attempts", got, outboxMaxAttempts)
+	}
+
+	// A deleted triage is given up without calling the notifier.
+	store.results["t2"] = &Result{ID: "t2", Status: StatusComplete}
+	store.deleted["t2"] = time.Now()
+	outbox.queue["t2"] = &Notification{TriageID: "t2", Status: NotificationPending}
+	svc.dispatch(context.Background())
+	if got := outbox.get("t2"); got.Status != NotificationFailed || got.LastError != "triage was deleted" {
+		t.Errorf("notification = %+v, want failed for the deleted triage", got)
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if notifier.calls != 1 {
+		t.Errorf("notifier calls = %d, want 1", notifier.calls)
+	}
+}
+
+func TestOutbox_RoutesRetryToProfileNotifier(t *testing.T) {
+	t.Parallel()
+
+	defaultNotifier, teamNotifier, tenantNotifier := newMockNotifier(), newMockNotifier(), newMockNotifier()
+	resolver := profileFunc(func(al *alert.Alert) *Profile {
+		if al.Receiver == "team-a" {
+			return &Profile{Name: "team-a", Notifier: teamNotifier}
+		}
+		return nil
+	})
+	svc := NewService(newMockStore(), nil, log.Nop(), nil, defaultNotifier, noop.NewTracerProvider(),
+		WithProfiles(resolver), WithTenants(map[string]*Profile{"acme": {Name: "acme", Notifier: tenantNotifier}}))
+
+	tests := []struct {
+		tenant, receiver string
+		want             Notifier
+	}{
+		{"", "team-a", teamNotifier},
+		{"", "other", defaultNotifier},
+		{"acme", "team-a", tenantNotifier},
+	}
+	for _, tt := range tests {
+		if got := svc.notifierFor(tt.tenant, tt.receiver); got != tt.want {
+			t.Errorf("notifierFor(%q, %q) = %p, want %p", tt.tenant, tt.receiver, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{8, time.Hour},
+		{9, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := outboxDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
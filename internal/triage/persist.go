@@ -0,0 +1,119 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+const (
+	// storeAttempts is how many times runTriage tries each store read or
+	// write before giving up on it. The wait between attempts doubles from
+	// storeBaseBackoff: about three seconds in all.
+	storeAttempts    = 5
+	storeBaseBackoff = 200 * time.Millisecond
+)
+
+// Persist failure stages, the points in a run where the store can fail.
+const (
+	persistStageFetch  = "fetch"  // reading the created result
+	persistStageStart  = "start"  // marking it in progress
+	persistStageResult = "result" // writing the finished result
+)
+
+// errResultNotFound is returned by getResult when the created result is
+// missing, which no retry will fix.
+var errResultNotFound = errors.New("triage result not found")
+
+// storeAbortedAnalysis is the analysis of a triage marked failed because its
+// result could not be stored.
+const storeAbortedAnalysis = "Triage aborted: the result could not be saved to the store."
+
+// withStoreRetry runs op until it succeeds, up to storeAttempts times,
+// doubling the wait between attempts. It stops early when ctx is done or op
+// returns errResultNotFound.
+func (s *Service) withStoreRetry(ctx context.Context, logger log.Logger, what string, op func(context.Context) error) error {
+	wait := s.storeBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(ctx); err == nil || errors.Is(err, errResultNotFound) || attempt == storeAttempts {
+			return err
+		}
+		logger.Warn(ctx, "store operation failed, retrying", "op", what, "attempt", attempt, "retry_in", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// getResult fetches the created result for a run, retrying store errors.
+func (s *Service) getResult(ctx context.Context, logger log.Logger, id string) (*Result, error) {
+	var result *Result
+	err := s.withStoreRetry(ctx, logger, "get", func(ctx context.Context) error {
+		r, ok, err := s.store.Get(ctx, id)
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			return errResultNotFound
+		}
+		result = r
+		return nil
+	})
+	return result, err
+}
+
+// persistError marks triage id StatusError after the store failed at stage,
+// so it does not sit pending or in progress forever. It starts from r, or
+// from the alert when the result could not be read at all, so the record
+// keeps enough to find it by. The analysis and notes are replaced by a short
+// explanation, in case their size was what the store rejected. The write is
+// retried like any other; if it still fails, the triage is lost and counted
+// as such.
+func (s *Service) persistError(ctx context.Context, logger log.Logger, stage, id string, r *Result, al *alert.Alert, created time.Time) {
+	var failed Result
+	if r != nil {
+		failed = *r
+	} else {
+		failed = Result{
+			ID:           id,
+			Fingerprint:  al.Fingerprint,
+			Alert:        al.Labels["alertname"],
+			Severity:     al.Labels["severity"],
+			Summary:      al.Annotations["summary"],
+			GeneratorURL: al.GeneratorURL,
+			Labels:       al.Labels,
+			Annotations:  al.Annotations,
+			CreatedAt:    created,
+			TenantID:     TenantFrom(ctx),
+		}
+	}
+	failed.Status = StatusError
+	failed.Analysis = storeAbortedAnalysis
+	failed.Notes = nil
+	failed.Conversation = nil
+	failed.Partial = ""
+	failed.CompletedAt = time.Now()
+
+	outcome := "marked_error"
+	err := s.withStoreRetry(ctx, logger, "put error status", func(ctx context.Context) error {
+		return s.store.Put(ctx, &failed)
+	})
+	if err != nil {
+		outcome = "lost"
+		logger.Error(ctx, err, "failed to persist error status, triage is lost", "stage", stage)
+	} else {
+		logger.Warn(ctx, "triage marked as error after store failure", "stage", stage)
+		s.audit(ctx, id, failed.TenantID, AuditFailed, ActorSystem, "store failure at "+stage)
+	}
+	if s.metrics != nil {
+		s.metrics.PersistFailures.WithLabelValues(stage, outcome).Inc()
+	}
+}
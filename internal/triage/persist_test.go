@@ -0,0 +1,169 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// flakyPutStore fails the first fails[status] writes of each status.
+type flakyPutStore struct {
+	*mockStore
+	mu    sync.Mutex
+	fails map[Status]int
+	puts  map[Status]int
+}
+
+func (f *flakyPutStore) Put(ctx context.Context, r *Result) error {
+	f.mu.Lock()
+	f.puts[r.Status]++
+	fail := f.fails[r.Status] > 0
+	if fail {
+		f.fails[r.Status]--
+	}
+	f.mu.Unlock()
+	if fail {
+		return errors.New("connection reset")
+	}
+	return f.mockStore.Put(ctx, r)
+}
+
+// waitForFinish waits until the run of triage id has returned.
+func waitForFinish(t *testing.T, s *Service, id string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		_, running := s.running[id]
+		s.mu.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("triage %s did not finish within deadline", id)
+}
+
+func TestRunTriage_StoreFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		fails       map[Status]int
+		wantStatus  Status // "" when nothing terminal is stored
+		wantOutcome string // persist failure outcome counted, "" for none
+		wantStage   string
+	}{
+		{
+			name:       "transient result write",
+			fails:      map[Status]int{StatusComplete: storeAttempts - 1},
+			wantStatus: StatusComplete,
+		},
+		{
+			name:        "result write never succeeds",
+			fails:       map[Status]int{StatusComplete: storeAttempts},
+			wantStatus:  StatusError,
+			wantOutcome: "marked_error",
+			wantStage:   persistStageResult,
+		},
+		{
+			name:        "in progress write never succeeds",
+			fails:       map[Status]int{StatusInProgress: storeAttempts},
+			wantStatus:  StatusError,
+			wantOutcome: "marked_error",
+			wantStage:   persistStageStart,
+		},
+		{
+			name:        "error status write fails too",
+			fails:       map[Status]int{StatusComplete: storeAttempts, StatusError: storeAttempts},
+			wantOutcome: "lost",
+			wantStage:   persistStageResult,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &flakyPutStore{mockStore: newMockStore(), fails: tt.fails, puts: make(map[Status]int)}
+			metrics := NewMetrics(prometheus.NewRegistry())
+			notifier := newMockNotifier()
+			engine := NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+			svc := NewService(store, engine, log.Nop(), metrics, notifier, noop.NewTracerProvider())
+			svc.storeBackoff = time.Millisecond
+
+			sr, err := svc.Submit(context.Background(), &alert.Alert{
+				Status:      "firing",
+				Fingerprint: "fp-store",
+				Labels:      map[string]string{"alertname": "DiskFull", "severity": "critical"},
+			})
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			waitForFinish(t, svc, sr.ID)
+
+			r, ok, _ := store.Get(context.Background(), sr.ID)
+			if !ok {
+				t.Fatal("result missing from the store")
+			}
+			switch {
+			case tt.wantStatus != "" && r.Status != tt.wantStatus:
+				t.Errorf("status = %s, want %s", r.Status, tt.wantStatus)
+			case tt.wantStatus == "" && r.Status.IsTerminal():
+				t.Errorf("status = %s, want the write to have been lost", r.Status)
+			}
+			if tt.wantStatus == StatusError && (r.Alert != "DiskFull" || r.Analysis != storeAbortedAnalysis) {
+				t.Errorf("error result = %+v, want the alert kept and the abort explained", r)
+			}
+
+			for _, stage := range []string{persistStageFetch, persistStageStart, persistStageResult} {
+				for _, outcome := range []string{"marked_error", "lost"} {
+					want := 0.0
+					if stage == tt.wantStage && outcome == tt.wantOutcome {
+						want = 1
+					}
+					if got := testutil.ToFloat64(metrics.PersistFailures.WithLabelValues(stage, outcome)); got != want {
+						t.Errorf("persist failures{%s,%s} = %v, want %v", stage, outcome, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunTriage_FetchFailure(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	engine := NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), metrics, nil, noop.NewTracerProvider())
+	svc.storeBackoff = time.Millisecond
+	store.getErr = errors.New("connection refused")
+
+	// Started directly, as if Submit had created the result before reads
+	// began failing.
+	svc.start(context.Background(), "t-fetch", &alert.Alert{
+		Fingerprint: "fp-fetch",
+		Labels:      map[string]string{"alertname": "DiskFull"},
+	}, time.Now(), nil)
+	waitForFinish(t, svc, "t-fetch")
+
+	store.mu.Lock()
+	r := store.results["t-fetch"]
+	store.mu.Unlock()
+	if r == nil || r.Status != StatusError || r.Alert != "DiskFull" {
+		t.Fatalf("result = %+v, want an error result rebuilt from the alert", r)
+	}
+	if got := testutil.ToFloat64(metrics.PersistFailures.WithLabelValues(persistStageFetch, "marked_error")); got != 1 {
+		t.Errorf("persist failures = %v, want 1", got)
+	}
+}
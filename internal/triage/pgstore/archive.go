@@ -0,0 +1,300 @@
+package pgstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/linnemanlabs/vigil/internal/archive"
+)
+
+// ExportFilter bounds an export by triage creation time. Zero values are open-ended.
+type ExportFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+func (f ExportFilter) args() (from, to *time.Time) {
+	if !f.From.IsZero() {
+		from = &f.From
+	}
+	if !f.To.IsZero() {
+		to = &f.To
+	}
+	return from, to
+}
+
+// runFilter selects triage_runs created in [$1, $2); either bound may be NULL.
+const runFilter = `($1::timestamptz IS NULL OR r.created_at >= $1) AND ($2::timestamptz IS NULL OR r.created_at < $2)`
+
+// Export writes all triage runs in the filter window, with their messages and
+// tool calls, to the archive in dependency order.
+func (s *Store) Export(ctx context.Context, w *archive.Writer, f ExportFilter) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Export", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	// A repeatable-read snapshot keeps the three passes consistent with each
+	// other while triages keep running.
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // read-only tx, rollback is the normal end
+
+	if err := exportRows(ctx, tx, w, f); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	c := w.Counts()
+	span.SetAttributes(
+		attribute.Int("vigil.archive.runs", c.Runs),
+		attribute.Int("vigil.archive.messages", c.Messages),
+		attribute.Int("vigil.archive.tool_calls", c.ToolCalls),
+	)
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+func exportRows(ctx context.Context, tx pgx.Tx, w *archive.Writer, f ExportFilter) error {
+	from, to := f.args()
+
+	rows, err := tx.Query(ctx, `SELECT r.id, r.fingerprint, r.status, r.alert_name, r.severity, r.summary, r.analysis,
+		r.tools_used, r.created_at, r.completed_at, r.duration_s, r.llm_time_s, r.tool_time_s, r.tokens_in, r.tokens_out,
+		r.tokens_thinking, r.tool_calls, r.system_prompt, r.model, r.generator_url, r.investigation_notes, r.incident_children, r.deleted_at,
+		r.tenant_id, r.started_at, r.issue_url, r.alert_metadata, r.redactions, r.strategy, r.provider, r.received_at,
+		r.alert_labels, r.alert_annotations, r.suggested_actions, r.trace_id, r.dry_run
+		FROM triage_runs r WHERE `+runFilter+` ORDER BY r.created_at, r.id`, from, to)
+	if err != nil {
+		return fmt.Errorf("query triage_runs: %w", err)
+	}
+	var run archive.Run
+	_, err = pgx.ForEachRow(rows, []any{
+		&run.ID, &run.Fingerprint, &run.Status, &run.AlertName, &run.Severity, &run.Summary, &run.Analysis,
+		&run.ToolsUsed, &run.CreatedAt, &run.CompletedAt, &run.DurationS, &run.LLMTimeS, &run.ToolTimeS, &run.TokensIn, &run.TokensOut,
+		&run.TokensThinking, &run.ToolCalls, &run.SystemPrompt, &run.Model, &run.GeneratorURL, &run.Notes, &run.Children, &run.DeletedAt,
+		&run.TenantID, &run.StartedAt, &run.IssueURL, &run.Metadata, &run.Redactions, &run.Strategy, &run.Provider, &run.ReceivedAt,
+		&run.Labels, &run.Annotations, &run.Actions, &run.TraceID, &run.DryRun,
+	}, func() error {
+		return w.WriteRun(&run)
+	})
+	if err != nil {
+		return fmt.Errorf("export triage_runs: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `SELECT m.id, m.triage_id, m.seq, m.role, m.content, m.tokens_in, m.tokens_out, m.tokens_thinking,
+		m.created_at, m.duration_s, m.stop_reason, m.model
+		FROM messages m JOIN triage_runs r ON r.id = m.triage_id
+		WHERE `+runFilter+` ORDER BY m.triage_id, m.seq, m.id`, from, to)
+	if err != nil {
+		return fmt.Errorf("query messages: %w", err)
+	}
+	var msg archive.Message
+	_, err = pgx.ForEachRow(rows, []any{
+		&msg.ID, &msg.TriageID, &msg.Seq, &msg.Role, &msg.Content, &msg.TokensIn, &msg.TokensOut, &msg.TokensThinking,
+		&msg.CreatedAt, &msg.DurationS, &msg.StopReason, &msg.Model,
+	}, func() error {
+		return w.WriteMessage(&msg)
+	})
+	if err != nil {
+		return fmt.Errorf("export messages: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `SELECT t.triage_id, t.message_id, t.message_seq, t.tool_name, t.input, t.output,
+		t.input_bytes, t.output_bytes, t.is_error, t.duration_s, t.created_at
+		FROM tool_calls t JOIN triage_runs r ON r.id = t.triage_id
+		WHERE `+runFilter+` ORDER BY t.triage_id, t.message_seq, t.id`, from, to)
+	if err != nil {
+		return fmt.Errorf("query tool_calls: %w", err)
+	}
+	var tc archive.ToolCall
+	_, err = pgx.ForEachRow(rows, []any{
+		&tc.TriageID, &tc.MessageID, &tc.MessageSeq, &tc.ToolName, &tc.Input, &tc.Output,
+		&tc.InputBytes, &tc.OutputBytes, &tc.IsError, &tc.DurationS, &tc.CreatedAt,
+	}, func() error {
+		return w.WriteToolCall(&tc)
+	})
+	if err != nil {
+		return fmt.Errorf("export tool_calls: %w", err)
+	}
+	return nil
+}
+
+// ImportResult reports what an Import wrote and skipped.
+type ImportResult struct {
+	Imported archive.Counts `json:"imported"`
+	// SkippedRuns counts runs whose ID (or active fingerprint) already existed;
+	// their messages and tool calls are skipped too so re-imports are idempotent.
+	SkippedRuns int `json:"skipped_runs"`
+}
+
+// Import loads an archive in a single transaction. Message IDs are reassigned
+// and tool calls are relinked to the new IDs.
+func (s *Store) Import(ctx context.Context, r *archive.Reader) (*ImportResult, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Import", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // rollback after commit is harmless
+
+	res, err := importRecords(ctx, tx, r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("vigil.archive.runs", res.Imported.Runs),
+		attribute.Int("vigil.archive.messages", res.Imported.Messages),
+		attribute.Int("vigil.archive.tool_calls", res.Imported.ToolCalls),
+		attribute.Int("vigil.archive.skipped_runs", res.SkippedRuns),
+	)
+	span.SetStatus(codes.Ok, "")
+	return res, nil
+}
+
+func importRecords(ctx context.Context, tx pgx.Tx, r *archive.Reader) (*ImportResult, error) {
+	res := &ImportResult{}
+	imported := make(map[string]bool) // run IDs inserted by this import
+	msgIDs := make(map[int]int)       // archive message ID -> new message ID
+
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case rec.Run != nil:
+			ok, err := insertRun(ctx, tx, rec.Run)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				res.SkippedRuns++
+				continue
+			}
+			imported[rec.Run.ID] = true
+			res.Imported.Runs++
+
+		case rec.Message != nil:
+			m := rec.Message
+			if !imported[m.TriageID] {
+				continue
+			}
+			var id int
+			err := tx.QueryRow(ctx,
+				`INSERT INTO messages (triage_id, seq, role, content, tokens_in, tokens_out, tokens_thinking, created_at, duration_s, stop_reason, model)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				 RETURNING id`,
+				m.TriageID, m.Seq, m.Role, m.Content, m.TokensIn, m.TokensOut, m.TokensThinking, m.CreatedAt, m.DurationS, m.StopReason, m.Model,
+			).Scan(&id)
+			if err != nil {
+				return nil, fmt.Errorf("insert message %s seq %d: %w", m.TriageID, m.Seq, err)
+			}
+			msgIDs[m.ID] = id
+			res.Imported.Messages++
+
+		case rec.ToolCall != nil:
+			tc := rec.ToolCall
+			if !imported[tc.TriageID] {
+				continue
+			}
+			msgID, ok := msgIDs[tc.MessageID]
+			if !ok {
+				return nil, fmt.Errorf("tool_call %s for triage %s references unknown message %d", tc.ToolName, tc.TriageID, tc.MessageID)
+			}
+			_, err := tx.Exec(ctx,
+				`INSERT INTO tool_calls (triage_id, message_id, message_seq, tool_name, input, output, input_bytes, output_bytes, is_error, duration_s, created_at)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+				tc.TriageID, msgID, tc.MessageSeq, tc.ToolName, tc.Input, tc.Output, tc.InputBytes, tc.OutputBytes, tc.IsError, tc.DurationS, tc.CreatedAt,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("insert tool_call %s for triage %s: %w", tc.ToolName, tc.TriageID, err)
+			}
+			res.Imported.ToolCalls++
+		}
+	}
+}
+
+// insertRun inserts a run unless it conflicts with an existing ID or active
+// fingerprint, reporting whether it was inserted.
+func insertRun(ctx context.Context, tx pgx.Tx, run *archive.Run) (bool, error) {
+	toolsUsed := run.ToolsUsed
+	if len(toolsUsed) == 0 {
+		toolsUsed = []byte("[]")
+	}
+	notes := run.Notes
+	if len(notes) == 0 {
+		notes = []byte("[]")
+	}
+	children := run.Children
+	if len(children) == 0 {
+		children = []byte("[]")
+	}
+	labels := run.Labels
+	if len(labels) == 0 {
+		labels = []byte("{}")
+	}
+	annotations := run.Annotations
+	if len(annotations) == 0 {
+		annotations = []byte("{}")
+	}
+	actions := run.Actions
+	if len(actions) == 0 {
+		actions = []byte("[]")
+	}
+	var metadata any
+	if len(run.Metadata) > 0 {
+		metadata = []byte(run.Metadata)
+	}
+	tag, err := tx.Exec(ctx, `INSERT INTO triage_runs (
+		id, fingerprint, status, alert_name, severity, summary, analysis,
+		tools_used, created_at, completed_at, duration_s, llm_time_s, tool_time_s, tokens_in, tokens_out, tokens_thinking, tool_calls, system_prompt, model,
+		generator_url, investigation_notes, incident_children, deleted_at, tenant_id, started_at, issue_url, alert_metadata, redactions, strategy, provider, received_at,
+		alert_labels, alert_annotations, suggested_actions, trace_id, dry_run
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35,$36)
+	ON CONFLICT DO NOTHING`,
+		run.ID, run.Fingerprint, run.Status, run.AlertName, run.Severity, run.Summary, run.Analysis,
+		toolsUsed, run.CreatedAt, run.CompletedAt, run.DurationS, run.LLMTimeS, run.ToolTimeS, run.TokensIn, run.TokensOut,
+		run.TokensThinking, run.ToolCalls, run.SystemPrompt, run.Model, run.GeneratorURL, notes, children, run.DeletedAt,
+		run.TenantID, run.StartedAt, run.IssueURL, metadata, run.Redactions, run.Strategy, run.Provider, run.ReceivedAt,
+		labels, annotations, actions, run.TraceID, run.DryRun,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert triage %s: %w", run.ID, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,74 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// RecordAudit appends an audit event.
+func (s *Store) RecordAudit(ctx context.Context, e *triage.AuditEvent) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.RecordAudit", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	_, err := s.pool.Exec(ctx, `INSERT INTO triage_audit
+		(id, triage_id, tenant_id, event, actor, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		e.ID, e.TriageID, e.TenantID, e.Event, e.Actor, e.Detail, e.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("insert audit event: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// ListAudit returns the audit events of a triage, oldest first.
+func (s *Store) ListAudit(ctx context.Context, triageID string) ([]*triage.AuditEvent, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.ListAudit", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+		attribute.String("vigil.triage.id", triageID),
+	))
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, `SELECT id, triage_id, tenant_id, event, actor, detail, created_at
+		FROM triage_audit
+		WHERE triage_id = $1
+		ORDER BY created_at, id`, triageID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*triage.AuditEvent
+	for rows.Next() {
+		var e triage.AuditEvent
+		if err := rows.Scan(&e.ID, &e.TriageID, &e.TenantID, &e.Event, &e.Actor, &e.Detail, &e.CreatedAt); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+		out = append(out, &e)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("iterate audit events: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(out)))
+	span.SetStatus(codes.Ok, "")
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// RecordDecision inserts a submit decision.
+func (s *Store) RecordDecision(ctx context.Context, d *triage.Decision) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.RecordDecision", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	_, err := s.pool.Exec(ctx, `INSERT INTO decisions
+		(id, tenant_id, fingerprint, alert_name, receiver, decision, reason, rule, triage_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		d.ID, d.TenantID, d.Fingerprint, d.Alert, d.Receiver, d.Decision, d.Reason, d.Rule, d.TriageID, d.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("insert decision: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// ListDecisions returns decisions matching the filter, newest first.
+func (s *Store) ListDecisions(ctx context.Context, f triage.DecisionFilter) ([]*triage.Decision, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.ListDecisions", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	var before *time.Time
+	if !f.Before.IsZero() {
+		before = &f.Before
+	}
+
+	rows, err := s.pool.Query(ctx, `SELECT id, tenant_id, fingerprint, alert_name, receiver, decision, reason, rule, triage_id, created_at
+		FROM decisions
+		WHERE ($1 = '*' OR tenant_id = $1)
+		  AND ($2 = '' OR fingerprint = $2)
+		  AND ($3 = '' OR alert_name = $3)
+		  AND ($4 = '' OR decision = $4)
+		  AND ($5::timestamptz IS NULL OR (created_at, id) < ($5, $7))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $6`,
+		f.Tenant, f.Fingerprint, f.Alert, f.Decision, before, f.EffectiveLimit(), f.BeforeID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("query decisions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*triage.Decision
+	for rows.Next() {
+		var d triage.Decision
+		if err := rows.Scan(&d.ID, &d.TenantID, &d.Fingerprint, &d.Alert, &d.Receiver, &d.Decision, &d.Reason, &d.Rule, &d.TriageID, &d.CreatedAt); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("scan decision: %w", err)
+		}
+		out = append(out, &d)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("iterate decisions: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(out)))
+	span.SetStatus(codes.Ok, "")
+	return out, nil
+}
+
+// PurgeDecisions deletes decisions made before the cutoff.
+func (s *Store) PurgeDecisions(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.PurgeDecisions", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "DELETE"),
+	))
+	defer span.End()
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM decisions WHERE created_at < $1`, before)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("purge decisions: %w", err)
+	}
+	n := int(tag.RowsAffected())
+	span.SetAttributes(attribute.Int("db.response.affected_rows", n))
+	span.SetStatus(codes.Ok, "")
+	return n, nil
+}
@@ -0,0 +1,29 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ClaimDigest inserts the digest's row, reporting false if it exists.
+func (s *Store) ClaimDigest(ctx context.Context, period string, due time.Time) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.ClaimDigest", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	tag, err := s.pool.Exec(ctx, `INSERT INTO digests (period, due_at) VALUES ($1, $2) ON CONFLICT DO NOTHING`, period, due)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("insert digest: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return tag.RowsAffected() == 1, nil
+}
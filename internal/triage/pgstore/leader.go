@@ -0,0 +1,114 @@
+package pgstore
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// leaseRetry is how often a replica without a lease tries to take it.
+	leaseRetry = 15 * time.Second
+	// leaseCheck is how often the leader checks that the session holding
+	// its lock is still alive.
+	leaseCheck = 5 * time.Second
+	// leaseUnlockTimeout bounds releasing the lock once the job has stopped.
+	leaseUnlockTimeout = 5 * time.Second
+)
+
+// leaseKey maps a job name to its advisory lock key.
+func leaseKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("vigil:" + name))
+	return int64(h.Sum64()) //nolint:gosec // G115: any 64 bits make a key
+}
+
+// Lead holds a session advisory lock for name on a connection of its own
+// and calls run while it does. Postgres drops the lock with the session, so
+// a replica that dies or loses its connection gives up the lease at once,
+// and another replica takes it within leaseRetry.
+func (s *Store) Lead(ctx context.Context, name string, run func(ctx context.Context)) {
+	for {
+		s.leadOnce(ctx, name, run)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaseRetry):
+		}
+	}
+}
+
+// leadOnce takes the lease if it is free and runs run until ctx is done, run
+// returns, or the lock's session fails.
+func (s *Store) leadOnce(ctx context.Context, name string, run func(ctx context.Context)) {
+	conn, held := s.tryLock(ctx, name)
+	if !held {
+		return
+	}
+	defer conn.Release()
+
+	leaseCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(leaseCtx)
+	}()
+
+	t := time.NewTicker(leaseCheck)
+	defer t.Stop()
+	for alive := true; alive; {
+		select {
+		case <-ctx.Done():
+			alive = false
+		case <-done:
+			alive = false
+		case <-t.C:
+			alive = conn.Ping(ctx) == nil
+		}
+	}
+	cancel()
+	<-done
+
+	// A session that cannot unlock is closed, which drops the lock with it.
+	uctx, ucancel := context.WithTimeout(context.WithoutCancel(ctx), leaseUnlockTimeout)
+	defer ucancel()
+	if _, err := conn.Exec(uctx, `SELECT pg_advisory_unlock($1)`, leaseKey(name)); err != nil {
+		_ = conn.Hijack().Close(uctx)
+	}
+}
+
+// tryLock takes the advisory lock for name without waiting, returning the
+// connection that holds it.
+func (s *Store) tryLock(ctx context.Context, name string) (*pgxpool.Conn, bool) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.TryLead", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+		attribute.String("vigil.lease", name),
+	))
+	defer span.End()
+
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, false
+	}
+	var held bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, leaseKey(name)).Scan(&held); err != nil {
+		conn.Release()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, false
+	}
+	if !held {
+		conn.Release()
+	}
+	span.SetAttributes(attribute.Bool("vigil.lease.held", held))
+	span.SetStatus(codes.Ok, "")
+	return conn, held
+}
@@ -0,0 +1,158 @@
+package pgstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// Truncation reasons reported to ContentLimits.OnTruncate.
+const (
+	TruncatedTurn         = "turn"
+	TruncatedConversation = "conversation"
+)
+
+// ContentLimits bound the conversation stored for a triage. Zero fields are
+// unlimited.
+type ContentLimits struct {
+	// MaxTurnBytes caps the JSON content stored for one turn.
+	MaxTurnBytes int
+	// MaxConversationBytes caps the JSON content stored for all turns of a
+	// triage. A turn arriving once the budget is spent keeps its structure
+	// but none of its text.
+	MaxConversationBytes int64
+
+	// OnTruncate, if set, is called for every turn stored truncated, with
+	// TruncatedConversation when the conversation budget was the tighter
+	// limit and TruncatedTurn otherwise.
+	OnTruncate func(reason string)
+}
+
+// WithContentLimits truncates the text and tool results of turns that would
+// exceed the limits before they are stored, so a pathological tool output
+// cannot bloat the messages table. Only what is stored is cut; the model
+// sees the turn as it was.
+func WithContentLimits(l ContentLimits) Option {
+	return func(s *Store) {
+		s.limits = l
+	}
+}
+
+// truncationMarker ends a text cut to fit the content limits.
+const truncationMarker = "\n[truncated by vigil: %d bytes omitted]"
+
+// limitTurn returns turn, or a copy cut to fit the content limits, with its
+// content marshaled.
+func (s *Store) limitTurn(ctx context.Context, tx pgx.Tx, triageID string, turn *triage.Turn) (*triage.Turn, []byte, error) {
+	content, err := json.Marshal(turn.Content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal content: %w", err)
+	}
+
+	limit, reason := s.limits.MaxTurnBytes, TruncatedTurn
+	if s.limits.MaxConversationBytes > 0 {
+		var used int64
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(SUM(content_bytes), 0) FROM messages WHERE triage_id = $1`, triageID).Scan(&used); err != nil {
+			return nil, nil, fmt.Errorf("sum conversation bytes: %w", err)
+		}
+		left := max(s.limits.MaxConversationBytes-used, 0)
+		if limit <= 0 || left < int64(limit) {
+			limit, reason = int(left), TruncatedConversation
+		}
+	} else if limit <= 0 {
+		return turn, content, nil
+	}
+	if len(content) <= limit {
+		return turn, content, nil
+	}
+
+	cut, content, err := truncateTurn(turn, content, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.limits.OnTruncate != nil {
+		s.limits.OnTruncate(reason)
+	}
+	return cut, content, nil
+}
+
+// truncateTurn cuts the longest texts and tool results of a copy of turn,
+// whose content marshals to content, until it marshals to at most limit
+// bytes or nothing is left to cut. Each cut text ends with a marker saying
+// how much was omitted.
+func truncateTurn(turn *triage.Turn, content []byte, limit int) (*triage.Turn, []byte, error) {
+	cp := *turn
+	cp.Content = append([]triage.ContentBlock(nil), turn.Content...)
+
+	type field struct {
+		s    *string
+		orig string
+		keep int
+	}
+	var fields []*field
+	for i := range cp.Content {
+		b := &cp.Content[i]
+		for _, s := range []*string{&b.Text, &b.Content} {
+			if *s != "" {
+				fields = append(fields, &field{s: s, orig: *s, keep: len(*s)})
+			}
+		}
+	}
+
+	// Cutting the longest field by the excess can fall short, since JSON
+	// escaping makes a text longer marshaled than in memory, so this may
+	// take a few rounds.
+	for len(content) > limit {
+		var longest *field
+		for _, f := range fields {
+			if f.keep > 0 && (longest == nil || f.keep > longest.keep) {
+				longest = f
+			}
+		}
+		if longest == nil {
+			break
+		}
+		f := longest
+		marker := fmt.Sprintf(truncationMarker, len(f.orig))
+		f.keep = len(truncateUTF8(f.orig, max(f.keep-(len(content)-limit)-len(marker), 0)))
+		*f.s = f.orig[:f.keep] + fmt.Sprintf(truncationMarker, len(f.orig)-f.keep)
+
+		var err error
+		if content, err = json.Marshal(cp.Content); err != nil {
+			return nil, nil, fmt.Errorf("marshal content: %w", err)
+		}
+	}
+	return &cp, content, nil
+}
+
+// storedToolResults maps the tool results in toolResults to their blocks in
+// stored, a truncated copy of the turn they came from.
+func storedToolResults(stored *triage.Turn, toolResults map[string]*triage.ContentBlock) map[string]*triage.ContentBlock {
+	out := make(map[string]*triage.ContentBlock, len(toolResults))
+	for id, b := range toolResults {
+		out[id] = b
+	}
+	for i := range stored.Content {
+		b := &stored.Content[i]
+		if _, ok := out[b.ToolUseID]; ok && b.Type == "tool_result" {
+			out[b.ToolUseID] = b
+		}
+	}
+	return out
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,95 @@
+package pgstore
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+func TestTruncateTurn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content []triage.ContentBlock
+		limit   int
+		// keep lists whether each block's text or tool result survives
+		// uncut.
+		keep []bool
+	}{
+		{
+			name: "longest block cut",
+			content: []triage.ContentBlock{
+				{Type: "text", Text: "checking metrics"},
+				{Type: "tool_result", ToolUseID: "tc_1", Content: strings.Repeat("a", 10000)},
+			},
+			limit: 2000,
+			keep:  []bool{true, false},
+		},
+		{
+			name: "escaped text",
+			content: []triage.ContentBlock{
+				{Type: "tool_result", ToolUseID: "tc_1", Content: strings.Repeat("<\"\n", 3000)},
+			},
+			limit: 1000,
+			keep:  []bool{false},
+		},
+		{
+			name: "multibyte text",
+			content: []triage.ContentBlock{
+				{Type: "text", Text: strings.Repeat("日本語", 2000)},
+			},
+			limit: 1000,
+			keep:  []bool{false},
+		},
+		{
+			name: "budget spent",
+			content: []triage.ContentBlock{
+				{Type: "text", Text: strings.Repeat("a", 300)},
+				{Type: "tool_result", ToolUseID: "tc_1", Content: strings.Repeat("b", 300)},
+			},
+			limit: 0,
+			keep:  []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			turn := &triage.Turn{Role: "user", Content: tt.content}
+			orig := append([]triage.ContentBlock(nil), tt.content...)
+			content, err := json.Marshal(turn.Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cut, got, err := truncateTurn(turn, content, tt.limit)
+			if err != nil {
+				t.Fatalf("truncateTurn: %v", err)
+			}
+			if tt.limit > 0 && len(got) > tt.limit {
+				t.Errorf("content is %d bytes, want at most %d", len(got), tt.limit)
+			}
+			if want, _ := json.Marshal(cut.Content); string(want) != string(got) {
+				t.Error("returned content does not match the returned turn")
+			}
+			for i, b := range cut.Content {
+				if b.Type != orig[i].Type || b.ToolUseID != orig[i].ToolUseID {
+					t.Errorf("block %d is %s %q, want %s %q", i, b.Type, b.ToolUseID, orig[i].Type, orig[i].ToolUseID)
+				}
+				if turn.Content[i].Text != orig[i].Text || turn.Content[i].Content != orig[i].Content {
+					t.Errorf("block %d of the original turn was modified", i)
+				}
+				text := b.Text + b.Content
+				if uncut := text == orig[i].Text+orig[i].Content; uncut != tt.keep[i] {
+					t.Errorf("block %d uncut = %v, want %v", i, uncut, tt.keep[i])
+				}
+				if !tt.keep[i] && (!strings.HasSuffix(text, " bytes omitted]") || !utf8.ValidString(text)) {
+					t.Errorf("block %d = %q, want valid UTF-8 ending in the truncation marker", i, text[max(len(text)-60, 0):])
+				}
+			}
+		})
+	}
+}
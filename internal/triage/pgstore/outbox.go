@@ -0,0 +1,145 @@
+package pgstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// PutNotifying upserts r and queues n in one transaction. A triage that
+// already has a notification keeps it.
+func (s *Store) PutNotifying(ctx context.Context, r *triage.Result, n *triage.Notification) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.PutNotifying", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPSERT"),
+	))
+	defer span.End()
+
+	if err := s.putNotifying(ctx, r, n); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+func (s *Store) putNotifying(ctx context.Context, r *triage.Result, n *triage.Notification) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // rollback after commit is harmless
+
+	if err := s.upsertTriage(ctx, tx, r); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO notifications (triage_id, tenant_id, receiver, status, attempts, next_attempt_at, last_error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (triage_id) DO NOTHING`,
+		n.TriageID, n.TenantID, n.Receiver, n.Status, n.Attempts, n.NextAttempt, n.LastError, n.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("insert notification: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// DueNotifications returns pending notifications due by now, oldest first.
+func (s *Store) DueNotifications(ctx context.Context, now time.Time, limit int) ([]*triage.Notification, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.DueNotifications", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, `SELECT triage_id, tenant_id, receiver, status, attempts, next_attempt_at, last_error, created_at
+		FROM notifications WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at, triage_id LIMIT $2`, now, limit)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("query notifications: %w", err)
+	}
+	var out []*triage.Notification
+	var n triage.Notification
+	if _, err := pgx.ForEachRow(rows, []any{&n.TriageID, &n.TenantID, &n.Receiver, &n.Status, &n.Attempts, &n.NextAttempt, &n.LastError, &n.CreatedAt}, func() error {
+		cp := n
+		out = append(out, &cp)
+		return nil
+	}); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("scan notifications: %w", err)
+	}
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(out)))
+	span.SetStatus(codes.Ok, "")
+	return out, nil
+}
+
+// UpdateNotification stores the outcome of a delivery attempt.
+func (s *Store) UpdateNotification(ctx context.Context, n *triage.Notification) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.UpdateNotification", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPDATE"),
+	))
+	defer span.End()
+
+	var delivered *time.Time
+	if !n.DeliveredAt.IsZero() {
+		delivered = &n.DeliveredAt
+	}
+	if _, err := s.pool.Exec(ctx, `UPDATE notifications
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, delivered_at = $6
+		WHERE triage_id = $1`,
+		n.TriageID, n.Status, n.Attempts, n.NextAttempt, n.LastError, delivered,
+	); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("update notification: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// Notification returns the notification queued for triageID.
+func (s *Store) Notification(ctx context.Context, triageID string) (*triage.Notification, bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Notification", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	var (
+		n         triage.Notification
+		delivered *time.Time
+	)
+	err := s.pool.QueryRow(ctx, `SELECT triage_id, tenant_id, receiver, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+		FROM notifications WHERE triage_id = $1`, triageID,
+	).Scan(&n.TriageID, &n.TenantID, &n.Receiver, &n.Status, &n.Attempts, &n.NextAttempt, &n.LastError, &n.CreatedAt, &delivered)
+	if errors.Is(err, pgx.ErrNoRows) {
+		span.SetStatus(codes.Ok, "")
+		return nil, false, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, false, fmt.Errorf("get notification: %w", err)
+	}
+	if delivered != nil {
+		n.DeliveredAt = *delivered
+	}
+	span.SetStatus(codes.Ok, "")
+	return &n, true, nil
+}
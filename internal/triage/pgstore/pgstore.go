@@ -26,15 +26,48 @@ var schema string
 type Store struct {
 	pool   *pgxpool.Pool
 	tracer trace.Tracer
+
+	// read picks the pool of read-only queries, nil means pool.
+	read   ReadPool
+	limits ContentLimits
+}
+
+// ReadPool picks the pool read-only queries run on, such as a replica while
+// it is healthy.
+type ReadPool interface {
+	Read() *pgxpool.Pool
+}
+
+// Option configures optional Store behavior.
+type Option func(*Store)
+
+// WithReadPool runs Get, GetByFingerprint, List, Search and Stats on the pool
+// r picks. Writes, and reads that decide a write, stay on the primary pool.
+func WithReadPool(r ReadPool) Option {
+	return func(s *Store) {
+		s.read = r
+	}
 }
 
 // New applies the schema on the given pool and returns a ready Store.
-func New(ctx context.Context, pool *pgxpool.Pool, tp trace.TracerProvider) (*Store, error) {
+func New(ctx context.Context, pool *pgxpool.Pool, tp trace.TracerProvider, opts ...Option) (*Store, error) {
 	if _, err := pool.Exec(ctx, schema); err != nil {
 		return nil, fmt.Errorf("apply schema: %w", err)
 	}
 
-	return &Store{pool: pool, tracer: tp.Tracer("github.com/linnemanlabs/vigil/internal/triage/pgstore")}, nil
+	s := &Store{pool: pool, tracer: tp.Tracer("github.com/linnemanlabs/vigil/internal/triage/pgstore")}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// reader returns the pool for a read-only query.
+func (s *Store) reader() *pgxpool.Pool {
+	if s.read == nil {
+		return s.pool
+	}
+	return s.read.Read()
 }
 
 // Close shuts down the connection pool.
@@ -43,7 +76,9 @@ func (s *Store) Close() {
 }
 
 const triageColumns = `id, fingerprint, status, alert_name, severity, summary, analysis,
-	tools_used, created_at, completed_at, duration_s, llm_time_s, tool_time_s, tokens_in, tokens_out, tool_calls, system_prompt, model`
+	tools_used, created_at, completed_at, duration_s, llm_time_s, tool_time_s, tokens_in, tokens_out, tokens_thinking, tool_calls, system_prompt, model, generator_url,
+	investigation_notes, incident_children, partial_text, tenant_id, started_at, issue_url, alert_metadata, redactions, strategy, provider, received_at,
+	alert_labels, alert_annotations, suggested_actions, trace_id, dry_run`
 
 // Get retrieves a triage result by ID.
 //
@@ -55,8 +90,14 @@ func (s *Store) Get(ctx context.Context, id string) (*triage.Result, bool, error
 	))
 	defer span.End()
 
-	query := `SELECT ` + triageColumns + ` FROM triage_runs WHERE id = $1`
-	r, err := s.scanTriageRow(s.pool.QueryRow(ctx, query, id))
+	query := `SELECT ` + triageColumns + ` FROM triage_runs WHERE id = $1 AND deleted_at IS NULL`
+	pool := s.reader()
+	r, err := s.scanTriageRow(pool.QueryRow(ctx, query, id))
+	if err == nil && r == nil && pool != s.pool {
+		// A triage created moments ago may not have reached the replica.
+		pool = s.pool
+		r, err = s.scanTriageRow(pool.QueryRow(ctx, query, id))
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -66,7 +107,7 @@ func (s *Store) Get(ctx context.Context, id string) (*triage.Result, bool, error
 		return nil, false, nil
 	}
 
-	if err := s.loadConversation(ctx, r); err != nil {
+	if err := s.loadConversation(ctx, pool, r); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, false, err
@@ -76,7 +117,8 @@ func (s *Store) Get(ctx context.Context, id string) (*triage.Result, bool, error
 	return r, true, nil
 }
 
-// GetByFingerprint retrieves the most recent triage result for a fingerprint.
+// GetByFingerprint retrieves the most recent triage result for a fingerprint
+// within the tenant in ctx.
 //
 //nolint:dupl // similar structure to Get is intentional
 func (s *Store) GetByFingerprint(ctx context.Context, fingerprint string) (*triage.Result, bool, error) {
@@ -86,8 +128,9 @@ func (s *Store) GetByFingerprint(ctx context.Context, fingerprint string) (*tria
 	))
 	defer span.End()
 
-	query := `SELECT ` + triageColumns + ` FROM triage_runs WHERE fingerprint = $1 ORDER BY created_at DESC LIMIT 1`
-	r, err := s.scanTriageRow(s.pool.QueryRow(ctx, query, fingerprint))
+	query := `SELECT ` + triageColumns + ` FROM triage_runs WHERE fingerprint = $1 AND tenant_id = $2 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1`
+	pool := s.reader()
+	r, err := s.scanTriageRow(pool.QueryRow(ctx, query, fingerprint, triage.TenantFrom(ctx)))
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -97,7 +140,7 @@ func (s *Store) GetByFingerprint(ctx context.Context, fingerprint string) (*tria
 		return nil, false, nil
 	}
 
-	if err := s.loadConversation(ctx, r); err != nil {
+	if err := s.loadConversation(ctx, pool, r); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, false, err
@@ -107,6 +150,114 @@ func (s *Store) GetByFingerprint(ctx context.Context, fingerprint string) (*tria
 	return r, true, nil
 }
 
+// List returns triage results matching the filter, newest first. Conversations
+// are not loaded; use Get for the full record.
+func (s *Store) List(ctx context.Context, f triage.ListFilter) ([]*triage.Result, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.List", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	var before *time.Time
+	if !f.Before.IsZero() {
+		before = &f.Before
+	}
+
+	query := `SELECT ` + triageColumns + ` FROM triage_runs
+		WHERE deleted_at IS NULL
+		  AND ($1 = '' OR status = $1)
+		  AND ($2 = '' OR alert_name = $2)
+		  AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $7))
+		  AND ($5 = '*' OR tenant_id = $5)
+		  AND alert_labels @> $6
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4`
+	labels, err := marshalStringMap(f.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("marshal label filter: %w", err)
+	}
+	rows, err := s.reader().Query(ctx, query, string(f.Status), f.Alert, before, f.EffectiveLimit(), f.Tenant, labels, f.BeforeID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("query triage_runs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*triage.Result
+	for rows.Next() {
+		r, err := s.scanTriageRow(rows)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("iterate triage_runs: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(out)))
+	span.SetStatus(codes.Ok, "")
+	return out, nil
+}
+
+// searchHeadline configures ts_headline to match the snippets of
+// triage.MatchSearch.
+const searchHeadline = `StartSel=**, StopSel=**, MinWords=15, MaxWords=35, MaxFragments=2, FragmentDelimiter=" … "`
+
+// Search runs q through the search_tsv index with websearch_to_tsquery, so
+// quoted phrases, "or" and -word work as on a search engine.
+func (s *Store) Search(ctx context.Context, q triage.SearchQuery) ([]*triage.SearchHit, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Search", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	query := `SELECT id, status, alert_name, severity, summary, created_at, ts_rank(search_tsv, q),
+		ts_headline('english', summary || E'\n' || analysis, q, '` + searchHeadline + `')
+		FROM triage_runs, websearch_to_tsquery('english', $1) q
+		WHERE search_tsv @@ q
+		  AND deleted_at IS NULL
+		  AND ($2 = '*' OR tenant_id = $2)
+		ORDER BY 7 DESC, created_at DESC, id DESC
+		LIMIT $3`
+	rows, err := s.reader().Query(ctx, query, q.Text, q.Tenant, q.EffectiveLimit())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("search triage_runs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*triage.SearchHit
+	for rows.Next() {
+		var hit triage.SearchHit
+		var rank float32
+		if err := rows.Scan(&hit.ID, &hit.Status, &hit.Alert, &hit.Severity, &hit.Summary, &hit.CreatedAt, &rank, &hit.Snippet); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("scan search hit: %w", err)
+		}
+		hit.Rank = float64(rank)
+		out = append(out, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("iterate search hits: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(out)))
+	span.SetStatus(codes.Ok, "")
+	return out, nil
+}
+
 // Put inserts or updates a triage result (upsert on triage_runs only).
 func (s *Store) Put(ctx context.Context, r *triage.Result) error {
 	ctx, span := s.tracer.Start(ctx, "pgstore.Put", trace.WithAttributes(
@@ -138,6 +289,195 @@ func (s *Store) Put(ctx context.Context, r *triage.Result) error {
 	return nil
 }
 
+// createAttempts bounds CreateIfNotActive's retries when the conflicting
+// triage finishes between the insert and the lookup.
+const createAttempts = 3
+
+// CreateIfNotActive inserts r unless an active triage of its tenant holds its
+// fingerprint. The partial unique index idx_triage_runs_active_tenant_fingerprint arbitrates
+// between concurrent inserts, so exactly one wins.
+func (s *Store) CreateIfNotActive(ctx context.Context, r *triage.Result) (*triage.Result, bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.CreateIfNotActive", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	args, err := triageArgs(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, false, err
+	}
+
+	insert := insertTriageSQL + `
+	ON CONFLICT (tenant_id, fingerprint) WHERE status IN ('pending', 'in_progress') DO NOTHING`
+	active := `SELECT ` + triageColumns + ` FROM triage_runs
+		WHERE tenant_id = $1 AND fingerprint = $2 AND status IN ('pending', 'in_progress') AND deleted_at IS NULL`
+
+	for range createAttempts {
+		tag, err := s.pool.Exec(ctx, insert, args...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, false, fmt.Errorf("insert triage: %w", err)
+		}
+		if tag.RowsAffected() == 1 {
+			span.SetAttributes(attribute.Bool("vigil.triage.created", true))
+			span.SetStatus(codes.Ok, "")
+			return nil, true, nil
+		}
+
+		existing, err := s.scanTriageRow(s.pool.QueryRow(ctx, active, r.TenantID, r.Fingerprint))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, false, err
+		}
+		if existing != nil {
+			span.SetAttributes(attribute.Bool("vigil.triage.created", false))
+			span.SetStatus(codes.Ok, "")
+			return existing, false, nil
+		}
+		// The active triage finished after our insert lost; try again.
+	}
+
+	err = fmt.Errorf("insert triage: fingerprint %s still contended after %d attempts", r.Fingerprint, createAttempts)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return nil, false, err
+}
+
+// actionPatchSQL merges the JSON object $3 into element $2 of a triage's
+// suggested_actions, leaving the other actions and columns as stored.
+const actionPatchSQL = `UPDATE triage_runs
+	SET suggested_actions = jsonb_set(suggested_actions, ARRAY[$2::int::text], (suggested_actions -> $2::int) || $3::jsonb)
+	WHERE id = $1 AND $2::int >= 0 AND $2::int < jsonb_array_length(suggested_actions)`
+
+// ClaimAction marks action n executed with a conditional update on the
+// primary; of concurrent claims, only the first to update the row finds
+// executed_at unset.
+func (s *Store) ClaimAction(ctx context.Context, id string, n int, actor string, at time.Time) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.ClaimAction", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPDATE"),
+	))
+	defer span.End()
+
+	patch, err := json.Marshal(map[string]any{"executed_at": at, "executed_by": actor})
+	if err != nil {
+		return false, fmt.Errorf("marshal action claim: %w", err)
+	}
+	tag, err := s.pool.Exec(ctx, actionPatchSQL+` AND deleted_at IS NULL AND NOT (suggested_actions -> $2::int ? 'executed_at')`, id, n, patch)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("claim action: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return tag.RowsAffected() == 1, nil
+}
+
+// RecordAction sets the output and error of action n.
+func (s *Store) RecordAction(ctx context.Context, id string, n int, output, errMsg string) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.RecordAction", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPDATE"),
+	))
+	defer span.End()
+
+	patch, err := json.Marshal(map[string]string{"output": output, "error": errMsg})
+	if err != nil {
+		return fmt.Errorf("marshal action outcome: %w", err)
+	}
+	if _, err := s.pool.Exec(ctx, actionPatchSQL, id, n, patch); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("record action: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// Delete soft-deletes a triage by setting deleted_at.
+func (s *Store) Delete(ctx context.Context, id string, at time.Time) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Delete", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPDATE"),
+	))
+	defer span.End()
+
+	tag, err := s.pool.Exec(ctx, `UPDATE triage_runs SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`, id, at)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("soft delete triage: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return tag.RowsAffected() == 1, nil
+}
+
+// Restore clears deleted_at on a soft-deleted triage.
+func (s *Store) Restore(ctx context.Context, id string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Restore", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPDATE"),
+	))
+	defer span.End()
+
+	tag, err := s.pool.Exec(ctx, `UPDATE triage_runs SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("restore triage: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return tag.RowsAffected() == 1, nil
+}
+
+// Purge deletes triages soft-deleted before the cutoff, children first to
+// satisfy the foreign keys.
+func (s *Store) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Purge", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "DELETE"),
+	))
+	defer span.End()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // rollback after commit is harmless
+
+	const doomed = `SELECT id FROM triage_runs WHERE deleted_at < $1`
+	for _, table := range []string{"tool_calls", "messages", "notifications"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE triage_id IN (`+doomed+`)`, deletedBefore); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return 0, fmt.Errorf("purge %s: %w", table, err)
+		}
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM triage_runs WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("purge triage_runs: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	n := int(tag.RowsAffected())
+	span.SetAttributes(attribute.Int("db.response.affected_rows", n))
+	span.SetStatus(codes.Ok, "")
+	return n, nil
+}
+
 // AppendTurn inserts a single message row and returns its database ID.
 func (s *Store) AppendTurn(ctx context.Context, triageID string, seq int, turn *triage.Turn) (int, error) {
 	ctx, span := s.tracer.Start(ctx, "pgstore.AppendTurn", trace.WithAttributes(
@@ -154,7 +494,7 @@ func (s *Store) AppendTurn(ctx context.Context, triageID string, seq int, turn *
 	}
 	defer tx.Rollback(ctx) //nolint:errcheck // rollback after commit is harmless
 
-	msgID, err := s.insertMessage(ctx, tx, triageID, seq, turn)
+	msgID, _, err := s.insertMessage(ctx, tx, triageID, seq, turn)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -202,25 +542,97 @@ func (s *Store) AppendToolCalls(ctx context.Context, triageID string, messageID,
 	return nil
 }
 
-func (s *Store) upsertTriage(ctx context.Context, tx pgx.Tx, r *triage.Result) error {
+// insertTriageSQL inserts one triage_runs row from triageArgs.
+const insertTriageSQL = `INSERT INTO triage_runs (
+		id, fingerprint, status, alert_name, severity, summary, analysis,
+		tools_used, created_at, completed_at, duration_s, llm_time_s, tool_time_s, tokens_in, tokens_out, tokens_thinking, tool_calls, system_prompt, model,
+		generator_url, investigation_notes, incident_children, tenant_id, started_at, issue_url, alert_metadata, redactions, strategy, provider, received_at,
+		alert_labels, alert_annotations, suggested_actions, trace_id, dry_run
+	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35)`
+
+// triageArgs returns the insertTriageSQL arguments for r.
+func triageArgs(r *triage.Result) ([]any, error) {
 	toolsUsed := r.ToolsUsed
 	if toolsUsed == nil {
 		toolsUsed = []string{}
 	}
 	toolsUsedJSON, err := json.Marshal(toolsUsed)
 	if err != nil {
-		return fmt.Errorf("marshal tools_used: %w", err)
+		return nil, fmt.Errorf("marshal tools_used: %w", err)
+	}
+
+	notes := r.Notes
+	if notes == nil {
+		notes = []triage.Note{}
+	}
+	notesJSON, err := json.Marshal(notes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal investigation_notes: %w", err)
+	}
+
+	children := r.Children
+	if children == nil {
+		children = []string{}
+	}
+	childrenJSON, err := json.Marshal(children)
+	if err != nil {
+		return nil, fmt.Errorf("marshal incident_children: %w", err)
 	}
 
-	var completedAt *time.Time
+	labelsJSON, err := marshalStringMap(r.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("marshal alert_labels: %w", err)
+	}
+	annotationsJSON, err := marshalStringMap(r.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("marshal alert_annotations: %w", err)
+	}
+
+	actions := r.Actions
+	if actions == nil {
+		actions = []triage.SuggestedAction{}
+	}
+	actionsJSON, err := json.Marshal(actions)
+	if err != nil {
+		return nil, fmt.Errorf("marshal suggested_actions: %w", err)
+	}
+
+	// NULL when no metadata was known.
+	var metadataJSON any
+	if r.Metadata != nil {
+		b, err := json.Marshal(r.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("marshal alert_metadata: %w", err)
+		}
+		metadataJSON = b
+	}
+
+	var receivedAt, startedAt, completedAt *time.Time
+	if !r.ReceivedAt.IsZero() {
+		receivedAt = &r.ReceivedAt
+	}
+	if !r.StartedAt.IsZero() {
+		startedAt = &r.StartedAt
+	}
 	if !r.CompletedAt.IsZero() {
 		completedAt = &r.CompletedAt
 	}
 
-	query := `INSERT INTO triage_runs (
-		id, fingerprint, status, alert_name, severity, summary, analysis,
-		tools_used, created_at, completed_at, duration_s, llm_time_s, tool_time_s, tokens_in, tokens_out, tool_calls, system_prompt, model
-	) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
+	return []any{
+		r.ID, r.Fingerprint, string(r.Status), r.Alert, r.Severity, r.Summary, r.Analysis,
+		toolsUsedJSON, r.CreatedAt, completedAt, r.Duration, r.LLMTime, r.ToolTime, r.TokensIn, r.TokensOut, r.TokensThinking, r.ToolCalls,
+		r.SystemPrompt, r.Model, r.GeneratorURL, notesJSON, childrenJSON, r.TenantID, startedAt, r.IssueURL, metadataJSON, r.Redactions, string(r.Strategy), r.Provider, receivedAt,
+		labelsJSON, annotationsJSON, actionsJSON, r.TraceID, r.DryRun,
+	}, nil
+}
+
+func (s *Store) upsertTriage(ctx context.Context, tx pgx.Tx, r *triage.Result) error {
+	args, err := triageArgs(r)
+	if err != nil {
+		return err
+	}
+
+	query := insertTriageSQL + `
 	ON CONFLICT (id) DO UPDATE SET
 		fingerprint   = EXCLUDED.fingerprint,
 		status        = EXCLUDED.status,
@@ -235,48 +647,82 @@ func (s *Store) upsertTriage(ctx context.Context, tx pgx.Tx, r *triage.Result) e
 		tool_time_s   = EXCLUDED.tool_time_s,
 		tokens_in     = EXCLUDED.tokens_in,
 		tokens_out    = EXCLUDED.tokens_out,
+		tokens_thinking = EXCLUDED.tokens_thinking,
 		tool_calls    = EXCLUDED.tool_calls,
 		system_prompt = EXCLUDED.system_prompt,
-		model         = EXCLUDED.model`
-
-	_, err = tx.Exec(ctx, query,
-		r.ID, r.Fingerprint, string(r.Status), r.Alert, r.Severity, r.Summary, r.Analysis,
-		toolsUsedJSON, r.CreatedAt, completedAt, r.Duration, r.LLMTime, r.ToolTime, r.TokensIn, r.TokensOut, r.ToolCalls,
-		r.SystemPrompt, r.Model,
-	)
-	if err != nil {
+		model         = EXCLUDED.model,
+		generator_url = EXCLUDED.generator_url,
+		investigation_notes = EXCLUDED.investigation_notes,
+		incident_children = EXCLUDED.incident_children,
+		tenant_id     = EXCLUDED.tenant_id,
+		started_at    = EXCLUDED.started_at,
+		issue_url     = EXCLUDED.issue_url,
+		alert_metadata = EXCLUDED.alert_metadata,
+		redactions    = EXCLUDED.redactions,
+		strategy      = EXCLUDED.strategy,
+		provider      = EXCLUDED.provider,
+		received_at   = EXCLUDED.received_at,
+		alert_labels  = EXCLUDED.alert_labels,
+		alert_annotations = EXCLUDED.alert_annotations,
+		suggested_actions = EXCLUDED.suggested_actions,
+		trace_id      = EXCLUDED.trace_id,
+		dry_run       = EXCLUDED.dry_run,
+		partial_text  = ''`
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
 		return fmt.Errorf("upsert triage: %w", err)
 	}
 	return nil
 }
 
-func (s *Store) insertMessage(ctx context.Context, tx pgx.Tx, triageID string, seq int, turn *triage.Turn) (int, error) {
-	contentJSON, err := json.Marshal(turn.Content)
+// SavePartial replaces the partial response text of a triage.
+func (s *Store) SavePartial(ctx context.Context, triageID, text string) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.SavePartial", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "UPDATE"),
+	))
+	defer span.End()
+
+	if _, err := s.pool.Exec(ctx, `UPDATE triage_runs SET partial_text = $2 WHERE id = $1`, triageID, text); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("save partial: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// insertMessage stores turn, cut to the content limits, and returns the
+// message ID and the turn as stored.
+func (s *Store) insertMessage(ctx context.Context, tx pgx.Tx, triageID string, seq int, turn *triage.Turn) (int, *triage.Turn, error) {
+	turn, contentJSON, err := s.limitTurn(ctx, tx, triageID, turn)
 	if err != nil {
-		return 0, fmt.Errorf("marshal content seq %d: %w", seq, err)
+		return 0, nil, fmt.Errorf("prepare message seq %d: %w", seq, err)
 	}
 
-	var tokensIn, tokensOut *int
+	var tokensIn, tokensOut, tokensThinking *int
 	if turn.Usage != nil {
 		tokensIn = &turn.Usage.InputTokens
 		tokensOut = &turn.Usage.OutputTokens
+		tokensThinking = &turn.Usage.ThinkingTokens
 	}
 
 	var messageID int
 	err = tx.QueryRow(ctx,
-		`INSERT INTO messages (triage_id, seq, role, content, tokens_in, tokens_out, created_at, duration_s, stop_reason, model)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`INSERT INTO messages (triage_id, seq, role, content, content_bytes, tokens_in, tokens_out, tokens_thinking, created_at, duration_s, stop_reason, model)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		 RETURNING id`,
-		triageID, seq, turn.Role, contentJSON, tokensIn, tokensOut, turn.Timestamp,
+		triageID, seq, turn.Role, contentJSON, len(contentJSON), tokensIn, tokensOut, tokensThinking, turn.Timestamp,
 		turn.Duration, turn.StopReason, turn.Model,
 	).Scan(&messageID)
 	if err != nil {
-		return 0, fmt.Errorf("insert message seq %d: %w", seq, err)
+		return 0, nil, fmt.Errorf("insert message seq %d: %w", seq, err)
 	}
-	return messageID, nil
+	return messageID, turn, nil
 }
 
 func (s *Store) insertToolCalls(ctx context.Context, tx pgx.Tx, triageID string, messageID, seq int, turn *triage.Turn, toolResults map[string]*triage.ContentBlock) error {
+	batch := &pgx.Batch{}
 	for i := range turn.Content {
 		block := &turn.Content[i]
 		if block.Type != "tool_use" {
@@ -296,22 +742,67 @@ func (s *Store) insertToolCalls(ctx context.Context, tx pgx.Tx, triageID string,
 			duration = result.Duration
 		}
 
-		_, err := tx.Exec(ctx,
+		batch.Queue(
 			`INSERT INTO tool_calls (triage_id, message_id, message_seq, tool_name, input, output, input_bytes, output_bytes, is_error, duration_s, created_at)
 			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
 			triageID, messageID, seq, block.Name, block.Input, output, inputBytes, outputBytes, isError, duration, turn.Timestamp,
 		)
-		if err != nil {
-			return fmt.Errorf("insert tool_call %s seq %d: %w", block.Name, seq, err)
-		}
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	// One round trip for all of the turn's tool calls.
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("insert tool_calls seq %d: %w", seq, err)
 	}
 	return nil
 }
 
+// AppendTurnWithToolCalls inserts the message row of a tool result turn and
+// the tool_call rows of the assistant turn it answers in one transaction, and
+// returns the message's database ID.
+func (s *Store) AppendTurnWithToolCalls(ctx context.Context, triageID string, seq int, turn *triage.Turn, messageID, messageSeq int, assistant *triage.Turn, toolResults map[string]*triage.ContentBlock) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.AppendTurnWithToolCalls", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // rollback after commit is harmless
+
+	msgID, stored, err := s.insertMessage(ctx, tx, triageID, seq, turn)
+	if err == nil {
+		if stored != turn {
+			// Store the tool results as cut to the limits, not in full.
+			toolResults = storedToolResults(stored, toolResults)
+		}
+		err = s.insertToolCalls(ctx, tx, triageID, messageID, messageSeq, assistant, toolResults)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return msgID, nil
+}
+
 // loadConversation reads messages and reconstructs the Conversation on a Result.
-func (s *Store) loadConversation(ctx context.Context, r *triage.Result) error {
-	rows, err := s.pool.Query(ctx,
-		`SELECT seq, role, content, tokens_in, tokens_out, created_at, duration_s, stop_reason, model
+func (s *Store) loadConversation(ctx context.Context, pool *pgxpool.Pool, r *triage.Result) error {
+	rows, err := pool.Query(ctx,
+		`SELECT seq, role, content, tokens_in, tokens_out, tokens_thinking, created_at, duration_s, stop_reason, model
 		 FROM messages WHERE triage_id = $1 ORDER BY seq`,
 		r.ID,
 	)
@@ -328,12 +819,13 @@ func (s *Store) loadConversation(ctx context.Context, r *triage.Result) error {
 			contentJSON []byte
 			tokensIn    *int
 			tokensOut   *int
+			tokensThink *int
 			createdAt   time.Time
 			durationS   float64
 			stopReason  string
 			model       string
 		)
-		if err := rows.Scan(&seq, &role, &contentJSON, &tokensIn, &tokensOut, &createdAt, &durationS, &stopReason, &model); err != nil {
+		if err := rows.Scan(&seq, &role, &contentJSON, &tokensIn, &tokensOut, &tokensThink, &createdAt, &durationS, &stopReason, &model); err != nil {
 			return fmt.Errorf("scan message: %w", err)
 		}
 
@@ -358,6 +850,9 @@ func (s *Store) loadConversation(ctx context.Context, r *triage.Result) error {
 			if tokensOut != nil {
 				turn.Usage.OutputTokens = *tokensOut
 			}
+			if tokensThink != nil {
+				turn.Usage.ThinkingTokens = *tokensThink
+			}
 		}
 		turns = append(turns, turn)
 	}
@@ -365,8 +860,56 @@ func (s *Store) loadConversation(ctx context.Context, r *triage.Result) error {
 		return fmt.Errorf("iterate messages: %w", err)
 	}
 
-	if len(turns) > 0 {
-		r.Conversation = &triage.Conversation{Turns: turns}
+	if len(turns) == 0 {
+		return nil
+	}
+	if err := s.loadToolDurations(ctx, pool, r.ID, turns); err != nil {
+		return err
+	}
+	r.Conversation = &triage.Conversation{Turns: turns}
+	return nil
+}
+
+// loadToolDurations sets Duration on the tool_result blocks of turns from
+// tool_calls, which the content JSON does not carry. Tool calls are stored
+// in the order of their tool_use blocks in the assistant turn at message_seq.
+func (s *Store) loadToolDurations(ctx context.Context, pool *pgxpool.Pool, triageID string, turns []triage.Turn) error {
+	rows, err := pool.Query(ctx,
+		`SELECT message_seq, duration_s FROM tool_calls WHERE triage_id = $1 ORDER BY id`,
+		triageID,
+	)
+	if err != nil {
+		return fmt.Errorf("query tool_calls: %w", err)
+	}
+	durations := map[int][]float64{}
+	var (
+		seq      int
+		duration float64
+	)
+	_, err = pgx.ForEachRow(rows, []any{&seq, &duration}, func() error {
+		durations[seq] = append(durations[seq], duration)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan tool_calls: %w", err)
+	}
+
+	for seq, ds := range durations {
+		if seq < 0 || seq+1 >= len(turns) {
+			continue
+		}
+		byID := map[string]float64{}
+		for _, b := range turns[seq].Content {
+			if b.Type == "tool_use" && len(ds) > 0 {
+				byID[b.ID], ds = ds[0], ds[1:]
+			}
+		}
+		results := turns[seq+1].Content
+		for i := range results {
+			if d, ok := byID[results[i].ToolUseID]; ok && results[i].Type == "tool_result" {
+				results[i].Duration = d
+			}
+		}
 	}
 	return nil
 }
@@ -377,14 +920,24 @@ func (s *Store) scanTriageRow(row pgx.Row) (*triage.Result, error) {
 	var (
 		r             triage.Result
 		status        string
+		strategy      string
 		toolsUsedJSON []byte
+		notesJSON     []byte
+		childrenJSON  []byte
+		metadataJSON  []byte
+		labelsJSON    []byte
+		annotJSON     []byte
+		actionsJSON   []byte
+		receivedAt    *time.Time
+		startedAt     *time.Time
 		completedAt   *time.Time
 	)
 
 	err := row.Scan(
 		&r.ID, &r.Fingerprint, &status, &r.Alert, &r.Severity, &r.Summary, &r.Analysis,
-		&toolsUsedJSON, &r.CreatedAt, &completedAt, &r.Duration, &r.LLMTime, &r.ToolTime, &r.TokensIn, &r.TokensOut, &r.ToolCalls,
-		&r.SystemPrompt, &r.Model,
+		&toolsUsedJSON, &r.CreatedAt, &completedAt, &r.Duration, &r.LLMTime, &r.ToolTime, &r.TokensIn, &r.TokensOut, &r.TokensThinking, &r.ToolCalls,
+		&r.SystemPrompt, &r.Model, &r.GeneratorURL, &notesJSON, &childrenJSON, &r.Partial, &r.TenantID, &startedAt, &r.IssueURL, &metadataJSON, &r.Redactions, &strategy, &r.Provider, &receivedAt,
+		&labelsJSON, &annotJSON, &actionsJSON, &r.TraceID, &r.DryRun,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -394,7 +947,14 @@ func (s *Store) scanTriageRow(row pgx.Row) (*triage.Result, error) {
 	}
 
 	r.Status = triage.Status(status)
+	r.Strategy = triage.Strategy(strategy)
 
+	if receivedAt != nil {
+		r.ReceivedAt = *receivedAt
+	}
+	if startedAt != nil {
+		r.StartedAt = *startedAt
+	}
 	if completedAt != nil {
 		r.CompletedAt = *completedAt
 	}
@@ -402,6 +962,49 @@ func (s *Store) scanTriageRow(row pgx.Row) (*triage.Result, error) {
 	if err := json.Unmarshal(toolsUsedJSON, &r.ToolsUsed); err != nil {
 		return nil, fmt.Errorf("unmarshal tools_used: %w", err)
 	}
+	if err := json.Unmarshal(notesJSON, &r.Notes); err != nil {
+		return nil, fmt.Errorf("unmarshal investigation_notes: %w", err)
+	}
+	if len(r.Notes) == 0 {
+		r.Notes = nil
+	}
+	if err := json.Unmarshal(childrenJSON, &r.Children); err != nil {
+		return nil, fmt.Errorf("unmarshal incident_children: %w", err)
+	}
+	if len(r.Children) == 0 {
+		r.Children = nil
+	}
+	if metadataJSON != nil {
+		if err := json.Unmarshal(metadataJSON, &r.Metadata); err != nil {
+			return nil, fmt.Errorf("unmarshal alert_metadata: %w", err)
+		}
+	}
+	if err := json.Unmarshal(labelsJSON, &r.Labels); err != nil {
+		return nil, fmt.Errorf("unmarshal alert_labels: %w", err)
+	}
+	if len(r.Labels) == 0 {
+		r.Labels = nil
+	}
+	if err := json.Unmarshal(annotJSON, &r.Annotations); err != nil {
+		return nil, fmt.Errorf("unmarshal alert_annotations: %w", err)
+	}
+	if len(r.Annotations) == 0 {
+		r.Annotations = nil
+	}
+	if err := json.Unmarshal(actionsJSON, &r.Actions); err != nil {
+		return nil, fmt.Errorf("unmarshal suggested_actions: %w", err)
+	}
+	if len(r.Actions) == 0 {
+		r.Actions = nil
+	}
 
 	return &r, nil
 }
+
+// marshalStringMap encodes m as a JSON object, {} when m is nil.
+func marshalStringMap(m map[string]string) ([]byte, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
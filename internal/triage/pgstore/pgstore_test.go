@@ -1,20 +1,27 @@
 package pgstore_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"go.opentelemetry.io/otel/trace/noop"
 
+	"github.com/linnemanlabs/vigil/internal/archive"
 	"github.com/linnemanlabs/vigil/internal/postgres"
 	"github.com/linnemanlabs/vigil/internal/triage"
 	"github.com/linnemanlabs/vigil/internal/triage/pgstore"
 )
 
-func openStore(t *testing.T) *pgstore.Store {
+func openStore(t *testing.T, opts ...pgstore.Option) *pgstore.Store {
 	t.Helper()
 	dsn := os.Getenv("VIGIL_TEST_DATABASE_URL")
 	if dsn == "" {
@@ -25,7 +32,7 @@ func openStore(t *testing.T) *pgstore.Store {
 	if err != nil {
 		t.Fatalf("postgres.NewPool: %v", err)
 	}
-	s, err := pgstore.New(ctx, pool, noop.NewTracerProvider())
+	s, err := pgstore.New(ctx, pool, noop.NewTracerProvider(), opts...)
 	if err != nil {
 		pool.Close()
 		t.Fatalf("pgstore.New: %v", err)
@@ -40,21 +47,40 @@ func TestPutAndGet(t *testing.T) {
 
 	now := time.Now().Truncate(time.Microsecond).UTC()
 	r := &triage.Result{
-		ID:          "test-put-get-001",
-		Fingerprint: "fp-put-get",
-		Status:      triage.StatusPending,
-		Alert:       "HighCPU",
-		Severity:    "critical",
-		Summary:     "CPU too high",
-		Analysis:    "Looks like a runaway process",
-		ToolsUsed:   []string{"query_logs", "query_metrics"},
-		CreatedAt:   now,
-		Duration:    1.23,
-		LLMTime:     0.85,
-		ToolTime:    0.38,
-		TokensIn:    300,
-		TokensOut:   200,
-		ToolCalls:   3,
+		ID:           "test-put-get-001",
+		Fingerprint:  "fp-put-get",
+		Status:       triage.StatusPending,
+		Alert:        "HighCPU",
+		Severity:     "critical",
+		Summary:      "CPU too high",
+		Analysis:     "Looks like a runaway process",
+		GeneratorURL: "https://prometheus.example.com/graph?g0.expr=up",
+		Labels:       map[string]string{"alertname": "HighCPU", "namespace": "prod"},
+		Annotations:  map[string]string{"summary": "CPU too high", "runbook_url": "https://runbooks.example.com/cpu"},
+		Notes:        []triage.Note{{Turn: 0, Text: "Checking node CPU first.", Timestamp: now}},
+		ToolsUsed:    []string{"query_logs", "query_metrics"},
+		Children:     []string{"child-a", "child-b"},
+		IssueURL:     "https://github.com/acme/ops/issues/7",
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		DryRun:       true,
+		Actions: []triage.SuggestedAction{{
+			Action: "restart_unit", Params: map[string]string{"unit": "nginx.service"}, Reason: "workers are wedged",
+			ExecutedAt: now, ExecutedBy: "api-token", Output: "ok",
+		}},
+		Metadata:       &triage.Metadata{Owner: "team-db", Dependencies: []string{"etcd"}},
+		Redactions:     3,
+		Strategy:       triage.StrategyPlanExecute,
+		Provider:       "fallback",
+		ReceivedAt:     now.Add(-time.Second),
+		CreatedAt:      now,
+		StartedAt:      now.Add(2 * time.Second),
+		Duration:       1.23,
+		LLMTime:        0.85,
+		ToolTime:       0.38,
+		TokensIn:       300,
+		TokensOut:      200,
+		TokensThinking: 50,
+		ToolCalls:      3,
 	}
 
 	if err := s.Put(ctx, r); err != nil {
@@ -75,12 +101,41 @@ func TestPutAndGet(t *testing.T) {
 	assertEqual(t, "Alert", r.Alert, got.Alert)
 	assertEqual(t, "Severity", r.Severity, got.Severity)
 	assertEqual(t, "Summary", r.Summary, got.Summary)
+	assertEqual(t, "GeneratorURL", r.GeneratorURL, got.GeneratorURL)
 	assertEqual(t, "Analysis", r.Analysis, got.Analysis)
+	assertEqual(t, "IssueURL", r.IssueURL, got.IssueURL)
+	assertEqual(t, "TraceID", r.TraceID, got.TraceID)
+	assertEqual(t, "DryRun", r.DryRun, got.DryRun)
+	assertEqual(t, "Redactions", r.Redactions, got.Redactions)
+	assertEqual(t, "Strategy", r.Strategy, got.Strategy)
+	assertEqual(t, "Provider", r.Provider, got.Provider)
+	if got.Metadata == nil || got.Metadata.Owner != "team-db" || len(got.Metadata.Dependencies) != 1 {
+		t.Errorf("Metadata = %+v, want %+v", got.Metadata, r.Metadata)
+	}
+	if len(got.Notes) != 1 || got.Notes[0].Text != r.Notes[0].Text || !got.Notes[0].Timestamp.Equal(now) {
+		t.Errorf("Notes = %+v, want %+v", got.Notes, r.Notes)
+	}
+	if !slices.Equal(got.Children, r.Children) {
+		t.Errorf("Children = %v, want %v", got.Children, r.Children)
+	}
+	if !maps.Equal(got.Labels, r.Labels) || !maps.Equal(got.Annotations, r.Annotations) {
+		t.Errorf("Labels, Annotations = %v, %v; want %v, %v", got.Labels, got.Annotations, r.Labels, r.Annotations)
+	}
+	if len(got.Actions) != 1 || got.Actions[0].String() != r.Actions[0].String() || !got.Actions[0].ExecutedAt.Equal(now) || got.Actions[0].Output != "ok" {
+		t.Errorf("Actions = %+v, want %+v", got.Actions, r.Actions)
+	}
+	if !got.ReceivedAt.Equal(r.ReceivedAt) {
+		t.Errorf("ReceivedAt = %v, want %v", got.ReceivedAt, r.ReceivedAt)
+	}
+	if !got.StartedAt.Equal(r.StartedAt) {
+		t.Errorf("StartedAt = %v, want %v", got.StartedAt, r.StartedAt)
+	}
 	assertEqual(t, "Duration", r.Duration, got.Duration)
 	assertEqual(t, "LLMTime", r.LLMTime, got.LLMTime)
 	assertEqual(t, "ToolTime", r.ToolTime, got.ToolTime)
 	assertEqual(t, "TokensIn", r.TokensIn, got.TokensIn)
 	assertEqual(t, "TokensOut", r.TokensOut, got.TokensOut)
+	assertEqual(t, "TokensThinking", r.TokensThinking, got.TokensThinking)
 	assertEqual(t, "ToolCalls", r.ToolCalls, got.ToolCalls)
 
 	if len(got.ToolsUsed) != 2 || got.ToolsUsed[0] != "query_logs" || got.ToolsUsed[1] != "query_metrics" {
@@ -177,6 +232,7 @@ func TestUpsert(t *testing.T) {
 	r.ToolTime = 12.5
 	r.TokensIn = 800
 	r.TokensOut = 400
+	r.TokensThinking = 120
 	r.ToolCalls = 5
 
 	if err := s.Put(ctx, r); err != nil {
@@ -198,9 +254,77 @@ func TestUpsert(t *testing.T) {
 	assertEqual(t, "ToolTime", 12.5, got.ToolTime)
 	assertEqual(t, "TokensIn", 800, got.TokensIn)
 	assertEqual(t, "TokensOut", 400, got.TokensOut)
+	assertEqual(t, "TokensThinking", 120, got.TokensThinking)
 	assertEqual(t, "ToolCalls", 5, got.ToolCalls)
 }
 
+func TestLegacyStatusBackfill(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	legacy := []struct {
+		id       string
+		status   triage.Status
+		analysis string
+		want     triage.Status
+	}{
+		{"test-backfill-rounds", triage.StatusComplete, "Triage terminated: tool call budget exhausted", triage.StatusMaxTurns},
+		{"test-backfill-input", triage.StatusFailed, "Triage terminated: input token budget exhausted", triage.StatusBudgetExceeded},
+		{"test-backfill-cancel", triage.StatusFailed, "Triage terminated: context canceled", triage.StatusError},
+		{"test-backfill-done", triage.StatusComplete, "Disk filled by logs.", triage.StatusComplete},
+	}
+	for _, l := range legacy {
+		r := &triage.Result{ID: l.id, Fingerprint: "fp-" + l.id, Status: l.status, Analysis: l.analysis, CreatedAt: now}
+		if err := s.Put(ctx, r); err != nil {
+			t.Fatalf("Put %s: %v", l.id, err)
+		}
+	}
+
+	// Opening the store again applies the schema, and with it the backfill.
+	s = openStore(t)
+	for _, l := range legacy {
+		got, ok, err := s.Get(ctx, l.id)
+		if err != nil || !ok {
+			t.Fatalf("Get %s: ok=%v err=%v", l.id, ok, err)
+		}
+		assertEqual(t, l.id+" Status", string(l.want), string(got.Status))
+	}
+}
+
+func TestSavePartial(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	r := &triage.Result{
+		ID:          "test-partial-001",
+		Fingerprint: "fp-partial",
+		Status:      triage.StatusInProgress,
+		CreatedAt:   time.Now().Truncate(time.Microsecond).UTC(),
+	}
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.SavePartial(ctx, r.ID, "The disk on db-1"); err != nil {
+		t.Fatalf("SavePartial: %v", err)
+	}
+	got, _, err := s.Get(ctx, r.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertEqual(t, "Partial", "The disk on db-1", got.Partial)
+
+	r.Status = triage.StatusComplete
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put complete: %v", err)
+	}
+	got, _, err = s.Get(ctx, r.ID)
+	if err != nil {
+		t.Fatalf("Get after Put: %v", err)
+	}
+	assertEqual(t, "Partial after Put", "", got.Partial)
+}
+
 func TestConversationRoundTrip(t *testing.T) {
 	s := openStore(t)
 	ctx := context.Background()
@@ -330,7 +454,7 @@ func TestAppendTurnAndToolCalls(t *testing.T) {
 
 	// Now append tool_calls
 	toolResults := map[string]*triage.ContentBlock{
-		"tc_1": {Type: "tool_result", ToolUseID: "tc_1", Content: "up=1"},
+		"tc_1": {Type: "tool_result", ToolUseID: "tc_1", Content: "up=1", Duration: 0.25},
 	}
 	if err := s.AppendToolCalls(ctx, r.ID, msgID, 0, &assistantTurn, toolResults); err != nil {
 		t.Fatalf("AppendToolCalls: %v", err)
@@ -352,6 +476,334 @@ func TestAppendTurnAndToolCalls(t *testing.T) {
 	}
 	assertEqual(t, "turn[0].Role", "assistant", got.Conversation.Turns[0].Role)
 	assertEqual(t, "turn[1].Role", "user", got.Conversation.Turns[1].Role)
+	// The tool duration is not in the content JSON; it comes from tool_calls.
+	assertEqual(t, "tool_result Duration", 0.25, got.Conversation.Turns[1].Content[0].Duration)
+}
+
+func TestAppendTurnWithToolCalls(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	r := &triage.Result{
+		ID:          "test-append-batch-001",
+		Fingerprint: "fp-append-batch",
+		Status:      triage.StatusInProgress,
+		CreatedAt:   now,
+	}
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	assistantTurn := triage.Turn{
+		Role: "assistant",
+		Content: []triage.ContentBlock{
+			{Type: "tool_use", ID: "tc_1", Name: "query_metrics", Input: json.RawMessage(`{"q":"up"}`)},
+			{Type: "tool_use", ID: "tc_2", Name: "query_logs", Input: json.RawMessage(`{"q":"{app=\"api\"}"}`)},
+		},
+		Timestamp: now.Add(time.Second),
+	}
+	msgID, err := s.AppendTurn(ctx, r.ID, 0, &assistantTurn)
+	if err != nil {
+		t.Fatalf("AppendTurn assistant: %v", err)
+	}
+
+	userTurn := triage.Turn{
+		Role: "user",
+		Content: []triage.ContentBlock{
+			{Type: "tool_result", ToolUseID: "tc_1", Content: "up=1", Duration: 0.25},
+			{Type: "tool_result", ToolUseID: "tc_2", Content: "no logs", Duration: 0.5, IsError: true},
+		},
+		Timestamp: now.Add(2 * time.Second),
+	}
+	toolResults := map[string]*triage.ContentBlock{
+		"tc_1": &userTurn.Content[0],
+		"tc_2": &userTurn.Content[1],
+	}
+	if _, err := s.AppendTurnWithToolCalls(ctx, r.ID, 1, &userTurn, msgID, 0, &assistantTurn, toolResults); err != nil {
+		t.Fatalf("AppendTurnWithToolCalls: %v", err)
+	}
+
+	got, ok, err := s.Get(ctx, r.ID)
+	if err != nil || !ok {
+		t.Fatalf("Get: ok=%v err=%v", ok, err)
+	}
+	if got.Conversation == nil || len(got.Conversation.Turns) != 2 {
+		t.Fatalf("conversation = %+v, want 2 turns", got.Conversation)
+	}
+	results := got.Conversation.Turns[1].Content
+	assertEqual(t, "tc_1 Duration", 0.25, results[0].Duration)
+	assertEqual(t, "tc_2 Duration", 0.5, results[1].Duration)
+}
+
+func TestContentLimits(t *testing.T) {
+	var truncations []string
+	s := openStore(t, pgstore.WithContentLimits(pgstore.ContentLimits{
+		MaxTurnBytes:         1000,
+		MaxConversationBytes: 1500,
+		OnTruncate:           func(reason string) { truncations = append(truncations, reason) },
+	}))
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	r := &triage.Result{ID: "test-limits-001", Fingerprint: "fp-limits", Status: triage.StatusInProgress, CreatedAt: now}
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	big := strings.Repeat("x", 5000)
+	for seq, text := range []string{"short", big, big} {
+		turn := &triage.Turn{Role: "user", Content: []triage.ContentBlock{{Type: "text", Text: text}}, Timestamp: now}
+		if _, err := s.AppendTurn(ctx, r.ID, seq, turn); err != nil {
+			t.Fatalf("AppendTurn %d: %v", seq, err)
+		}
+		if turn.Content[0].Text != text {
+			t.Fatalf("AppendTurn %d modified the caller's turn", seq)
+		}
+	}
+
+	got, ok, err := s.Get(ctx, r.ID)
+	if err != nil || !ok {
+		t.Fatalf("Get: ok=%v err=%v", ok, err)
+	}
+	turns := got.Conversation.Turns
+	if len(turns) != 3 {
+		t.Fatalf("got %d turns, want 3", len(turns))
+	}
+	assertEqual(t, "turn 0", "short", turns[0].Content[0].Text)
+	if text := turns[1].Content[0].Text; len(text) > 1000 || !strings.HasPrefix(text, "xxx") || !strings.Contains(text, "bytes omitted]") {
+		t.Errorf("turn 1 = %d bytes %q, want it cut below the turn limit", len(text), text[max(len(text)-60, 0):])
+	}
+	if text := turns[2].Content[0].Text; len(text) > 500 || !strings.Contains(text, "[truncated by vigil: ") {
+		t.Errorf("turn 2 = %d bytes, want it cut to the rest of the conversation budget", len(text))
+	}
+	if !slices.Equal(truncations, []string{pgstore.TruncatedTurn, pgstore.TruncatedConversation}) {
+		t.Errorf("truncations = %v, want [turn conversation]", truncations)
+	}
+}
+
+func TestList(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	alert := "ListTestAlert"
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	for i, st := range []triage.Status{triage.StatusComplete, triage.StatusFailed, triage.StatusComplete} {
+		r := &triage.Result{
+			ID:          "test-list-" + string(rune('a'+i)),
+			Fingerprint: "fp-list-" + string(rune('a'+i)),
+			Status:      st,
+			Alert:       alert,
+			Labels:      map[string]string{"namespace": []string{"prod", "dev", "prod"}[i], "team": "db"},
+			CreatedAt:   now.Add(time.Duration(i) * time.Minute),
+		}
+		if err := s.Put(ctx, r); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	got, err := s.List(ctx, triage.ListFilter{Alert: alert, Status: triage.StatusComplete})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("List returned %d results, want 2", len(got))
+	}
+	assertEqual(t, "first ID", "test-list-c", got[0].ID)
+	assertEqual(t, "second ID", "test-list-a", got[1].ID)
+
+	got, err = s.List(ctx, triage.ListFilter{Alert: alert, Before: now.Add(time.Minute), Limit: 5})
+	if err != nil {
+		t.Fatalf("List before: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "test-list-a" {
+		t.Errorf("List before returned %v, want [test-list-a]", got)
+	}
+
+	// Results created at the same instant page by ID.
+	tied := alert + "Tied"
+	for _, id := range []string{"test-list-tie-a", "test-list-tie-b", "test-list-tie-c"} {
+		if err := s.Put(ctx, &triage.Result{ID: id, Fingerprint: "fp-" + id, Status: triage.StatusComplete, Alert: tied, CreatedAt: now}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	got, err = s.List(ctx, triage.ListFilter{Alert: tied, Limit: 2})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("List first page = %d, %v; want 2", len(got), err)
+	}
+	got, err = s.List(ctx, triage.ListFilter{Alert: tied, Before: got[1].CreatedAt, BeforeID: got[1].ID, Limit: 2})
+	if err != nil {
+		t.Fatalf("List second page: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "test-list-tie-a" {
+		t.Errorf("List second page returned %v, want [test-list-tie-a]", got)
+	}
+
+	got, err = s.List(ctx, triage.ListFilter{Alert: alert, Labels: map[string]string{"namespace": "dev", "team": "db"}})
+	if err != nil {
+		t.Fatalf("List labels: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "test-list-b" {
+		t.Errorf("List labels returned %v, want [test-list-b]", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for i, r := range []*triage.Result{
+		{Alert: "SearchTestDisk", Analysis: "Quuxfs journal corrupted on /dev/sdb after a power loss."},
+		{Alert: "SearchTestNode", Summary: "quuxfs corruption suspected", Analysis: "The node rebooted."},
+		{Alert: "SearchTestCPU", Analysis: "A runaway quuxfs scrub job."},
+	} {
+		r.ID = "test-search-" + string(rune('a'+i))
+		r.Fingerprint = "fp-" + r.ID
+		r.Status = triage.StatusComplete
+		r.CreatedAt = now
+		if err := s.Put(ctx, r); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	// Stemming matches "corrupted" and "corruption" alike; summary matches
+	// outrank analysis matches.
+	got, err := s.Search(ctx, triage.SearchQuery{Text: "quuxfs corrupt"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Search returned %d hits, want 2: %+v", len(got), got)
+	}
+	assertEqual(t, "first ID", "test-search-b", got[0].ID)
+	assertEqual(t, "second ID", "test-search-a", got[1].ID)
+	if !strings.Contains(got[1].Snippet, "**Quuxfs**") {
+		t.Errorf("snippet = %q, want the match highlighted", got[1].Snippet)
+	}
+
+	got, err = s.Search(ctx, triage.SearchQuery{Text: "quuxfs -corrupt", Limit: 5})
+	if err != nil {
+		t.Fatalf("Search excluding: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "test-search-c" {
+		t.Errorf("Search excluding returned %+v, want [test-search-c]", got)
+	}
+
+	if got, _ := s.Search(ctx, triage.SearchQuery{Tenant: "other", Text: "quuxfs"}); len(got) != 0 {
+		t.Errorf("other tenant sees %d hits, want 0", len(got))
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	// A tenant of its own keeps rows from other tests out of the numbers.
+	tenant := fmt.Sprintf("stats-%d", time.Now().UnixNano())
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	for i, r := range []*triage.Result{
+		{Status: triage.StatusComplete, Alert: "HighCPU", Duration: 10, Model: "claude-sonnet-4-20250514", TokensIn: 1000, TokensOut: 100},
+		{Status: triage.StatusComplete, Alert: "HighCPU", Duration: 20, Model: "claude-sonnet-4-20250514", TokensIn: 2000, TokensOut: 200, TokensThinking: 30},
+		{Status: triage.StatusFailed, Alert: "DiskFull", Duration: 40},
+		{Status: triage.StatusInProgress, Alert: "DiskFull"},
+	} {
+		r.ID = fmt.Sprintf("%s-%d", tenant, i)
+		r.Fingerprint = "fp-" + r.ID
+		r.TenantID = tenant
+		r.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		if err := s.Put(ctx, r); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	turn := &triage.Turn{Role: "assistant", Content: []triage.ContentBlock{{Type: "tool_use", ID: "c1", Name: "query_logs", Input: json.RawMessage(`{}`)}}}
+	msgID, err := s.AppendTurn(ctx, tenant+"-0", 0, turn)
+	if err != nil {
+		t.Fatalf("AppendTurn: %v", err)
+	}
+	if err := s.AppendToolCalls(ctx, tenant+"-0", msgID, 0, turn, map[string]*triage.ContentBlock{
+		"c1": {Type: "tool_result", ToolUseID: "c1", Content: "timeout", IsError: true},
+	}); err != nil {
+		t.Fatalf("AppendToolCalls: %v", err)
+	}
+
+	st, err := s.Stats(ctx, triage.StatsQuery{Tenant: tenant, Since: now, Until: now.Add(time.Minute), TopAlerts: 1})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	assertEqual(t, "Triages", 4, st.Triages)
+	assertEqual(t, "complete", 2, st.ByStatus[triage.StatusComplete])
+	assertEqual(t, "DurationP50", 20.0, st.DurationP50)
+	if len(st.Models) != 1 || st.Models[0].TokensIn != 3000 || st.Models[0].TokensThinking != 30 {
+		t.Errorf("models = %+v", st.Models)
+	}
+	if len(st.TopAlerts) != 1 || st.TopAlerts[0].Alert != "DiskFull" || st.TopAlerts[0].Triages != 2 {
+		t.Errorf("top alerts = %+v", st.TopAlerts)
+	}
+	if len(st.Tools) != 1 || st.Tools[0].Tool != "query_logs" || st.Tools[0].ErrorRate != 1 {
+		t.Errorf("tools = %+v", st.Tools)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	// Use a time window far from other tests so the export only sees this run.
+	created := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	suffix := time.Now().Format("150405.000000")
+	r := &triage.Result{
+		ID:          "test-export-" + suffix,
+		Fingerprint: "fp-export-" + suffix,
+		Status:      triage.StatusComplete,
+		Alert:       "ExportTest",
+		CreatedAt:   created,
+	}
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	assistant := triage.Turn{
+		Role:      "assistant",
+		Content:   []triage.ContentBlock{{Type: "tool_use", ID: "tu-1", Name: "query_logs", Input: json.RawMessage(`{"q":"x"}`)}},
+		Timestamp: created,
+	}
+	msgID, err := s.AppendTurn(ctx, r.ID, 0, &assistant)
+	if err != nil {
+		t.Fatalf("AppendTurn: %v", err)
+	}
+	results := map[string]*triage.ContentBlock{"tu-1": {Type: "tool_result", ToolUseID: "tu-1", Content: "ok"}}
+	if err := s.AppendToolCalls(ctx, r.ID, msgID, 0, &assistant, results); err != nil {
+		t.Fatalf("AppendToolCalls: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(&buf, archive.Header{})
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	filter := pgstore.ExportFilter{From: created, To: created.Add(time.Second)}
+	if err := s.Export(ctx, w, filter); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := w.Counts(); got.Runs < 1 || got.Messages < 1 || got.ToolCalls < 1 {
+		t.Fatalf("export counts = %+v, want at least one of each", got)
+	}
+
+	// Importing into the same database skips existing runs and their children.
+	ar, err := archive.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	res, err := s.Import(ctx, ar)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Imported.Runs != 0 || res.Imported.Messages != 0 || res.SkippedRuns < 1 {
+		t.Errorf("re-import result = %+v, want all runs skipped", res)
+	}
 }
 
 func assertEqual[T comparable](t *testing.T, field string, want, got T) {
@@ -360,3 +812,430 @@ func assertEqual[T comparable](t *testing.T, field string, want, got T) {
 		t.Errorf("%s: got %v, want %v", field, got, want)
 	}
 }
+
+func TestSoftDeleteRestorePurge(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond).UTC()
+	id := "test-soft-delete-" + now.Format("150405.000000")
+	r := &triage.Result{ID: id, Fingerprint: "fp-" + id, Status: triage.StatusComplete, Alert: "DiskFull", CreatedAt: now}
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := s.AppendTurn(ctx, id, 0, &triage.Turn{Role: "user", Content: []triage.ContentBlock{{Type: "text", Text: "hi"}}, Timestamp: now}); err != nil {
+		t.Fatalf("AppendTurn: %v", err)
+	}
+
+	if ok, err := s.Delete(ctx, id, now.Add(-48*time.Hour)); err != nil || !ok {
+		t.Fatalf("Delete = %v, %v; want true", ok, err)
+	}
+	if _, ok, _ := s.Get(ctx, id); ok {
+		t.Error("deleted triage visible to Get")
+	}
+	if _, ok, _ := s.GetByFingerprint(ctx, r.Fingerprint); ok {
+		t.Error("deleted triage visible to GetByFingerprint")
+	}
+	list, err := s.List(ctx, triage.ListFilter{Alert: "DiskFull", Limit: triage.MaxListLimit})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, got := range list {
+		if got.ID == id {
+			t.Error("deleted triage visible to List")
+		}
+	}
+
+	if ok, err := s.Restore(ctx, id); err != nil || !ok {
+		t.Fatalf("Restore = %v, %v; want true", ok, err)
+	}
+	if _, ok, _ := s.Get(ctx, id); !ok {
+		t.Fatal("restored triage not visible")
+	}
+
+	if _, err := s.Delete(ctx, id, now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	n, err := s.Purge(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if n < 1 {
+		t.Errorf("purged %d rows, want at least 1", n)
+	}
+	if ok, _ := s.Restore(ctx, id); ok {
+		t.Error("purged triage still restorable")
+	}
+}
+
+func TestCreateIfNotActive(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	fp := fmt.Sprintf("fp-create-race-%d", time.Now().UnixNano())
+	const n = 10
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners []string
+	)
+	for i := range n {
+		wg.Go(func() {
+			r := &triage.Result{ID: fmt.Sprintf("%s-%d", fp, i), Fingerprint: fp, Status: triage.StatusPending, CreatedAt: time.Now()}
+			active, created, err := s.CreateIfNotActive(ctx, r)
+			if err != nil {
+				t.Errorf("CreateIfNotActive: %v", err)
+				return
+			}
+			if !created && (active == nil || active.Fingerprint != fp) {
+				t.Errorf("lost without returning the active triage: %+v", active)
+			}
+			if created {
+				mu.Lock()
+				winners = append(winners, r.ID)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(winners) != 1 {
+		t.Fatalf("winners = %v, want exactly one", winners)
+	}
+
+	done := &triage.Result{ID: winners[0], Fingerprint: fp, Status: triage.StatusComplete, CreatedAt: time.Now()}
+	if err := s.Put(ctx, done); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	next := &triage.Result{ID: fp + "-next", Fingerprint: fp, Status: triage.StatusPending, CreatedAt: time.Now()}
+	if _, created, err := s.CreateIfNotActive(ctx, next); err != nil || !created {
+		t.Errorf("CreateIfNotActive after completion = %v, %v, want created", created, err)
+	}
+}
+
+func TestClaimAction(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	id := fmt.Sprintf("test-claim-action-%d", time.Now().UnixNano())
+	r := &triage.Result{
+		ID: id, Fingerprint: id, Status: triage.StatusComplete, CreatedAt: time.Now(),
+		Actions: []triage.SuggestedAction{
+			{Action: "restart_unit", Params: map[string]string{"unit": "nginx.service"}},
+			{Action: "restart_unit", Params: map[string]string{"unit": "php-fpm.service"}},
+		},
+	}
+	if err := s.Put(ctx, r); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	const n = 10
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners []string
+	)
+	for i := range n {
+		wg.Go(func() {
+			actor := fmt.Sprintf("actor-%d", i)
+			claimed, err := s.ClaimAction(ctx, id, 0, actor, time.Now())
+			if err != nil {
+				t.Errorf("ClaimAction: %v", err)
+				return
+			}
+			if claimed {
+				mu.Lock()
+				winners = append(winners, actor)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if len(winners) != 1 {
+		t.Fatalf("winners = %v, want exactly one", winners)
+	}
+
+	if err := s.RecordAction(ctx, id, 0, "restarted", ""); err != nil {
+		t.Fatalf("RecordAction: %v", err)
+	}
+	if claimed, err := s.ClaimAction(ctx, id, 1, "actor-b", time.Now()); err != nil || !claimed {
+		t.Fatalf("ClaimAction of the second action = %v, %v, want claimed", claimed, err)
+	}
+	if err := s.RecordAction(ctx, id, 1, "", "unit not found"); err != nil {
+		t.Fatalf("RecordAction: %v", err)
+	}
+	if claimed, err := s.ClaimAction(ctx, id, 2, "actor-c", time.Now()); err != nil || claimed {
+		t.Errorf("ClaimAction past the last action = %v, %v, want not claimed", claimed, err)
+	}
+
+	got, _, err := s.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	a, b := got.Actions[0], got.Actions[1]
+	if a.ExecutedBy != winners[0] || a.Output != "restarted" || a.Action != "restart_unit" || a.Params["unit"] != "nginx.service" {
+		t.Errorf("first action = %+v, want claimed by %s with its output", a, winners[0])
+	}
+	if !b.Executed() || b.ExecutedBy != "actor-b" || b.Error != "unit not found" {
+		t.Errorf("second action = %+v, want its own outcome", b)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	fp := fmt.Sprintf("fp-tenants-%d", time.Now().UnixNano())
+
+	for _, tenant := range []string{"acme", "globex"} {
+		r := &triage.Result{ID: fp + "-" + tenant, Fingerprint: fp, Alert: fp, Status: triage.StatusPending, CreatedAt: time.Now(), TenantID: tenant}
+		if _, created, err := s.CreateIfNotActive(ctx, r); err != nil || !created {
+			t.Fatalf("CreateIfNotActive %s = %v, %v, want created", tenant, created, err)
+		}
+	}
+
+	got, ok, err := s.GetByFingerprint(triage.WithTenant(ctx, "globex"), fp)
+	if err != nil || !ok {
+		t.Fatalf("GetByFingerprint = %v, %v", ok, err)
+	}
+	assertEqual(t, "TenantID", "globex", got.TenantID)
+	if _, ok, _ := s.GetByFingerprint(ctx, fp); ok {
+		t.Error("default tenant GetByFingerprint found another tenant's triage")
+	}
+
+	list, err := s.List(ctx, triage.ListFilter{Alert: fp, Tenant: "acme"})
+	if err != nil || len(list) != 1 || list[0].ID != fp+"-acme" {
+		t.Errorf("List acme = %v, %v, want only %s-acme", list, err, fp)
+	}
+	if list, _ := s.List(ctx, triage.ListFilter{Alert: fp, Tenant: triage.AnyTenant}); len(list) != 2 {
+		t.Errorf("List any tenant = %d results, want 2", len(list))
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	fp := fmt.Sprintf("fp-decisions-%d", time.Now().UnixNano())
+	now := time.Now().Truncate(time.Microsecond).UTC()
+
+	want := &triage.Decision{
+		ID: fp + "-2", TenantID: "acme", Fingerprint: fp, Alert: "DiskFull", Receiver: "team-a",
+		Decision: triage.DecisionSkipped, Reason: "snoozed", Rule: "snooze-1", TriageID: "", CreatedAt: now,
+	}
+	for _, d := range []*triage.Decision{
+		{ID: fp + "-1", TenantID: "acme", Fingerprint: fp, Alert: "DiskFull", Decision: triage.DecisionAccepted, TriageID: "t1", CreatedAt: now.Add(-time.Hour)},
+		want,
+		{ID: fp + "-3", Fingerprint: fp, Alert: "DiskFull", Decision: triage.DecisionAccepted, CreatedAt: now},
+	} {
+		if err := s.RecordDecision(ctx, d); err != nil {
+			t.Fatalf("RecordDecision: %v", err)
+		}
+	}
+
+	got, err := s.ListDecisions(ctx, triage.DecisionFilter{Tenant: "acme", Fingerprint: fp})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("ListDecisions = %d, %v; want 2", len(got), err)
+	}
+	assertEqual(t, "ID", want.ID, got[0].ID)
+	assertEqual(t, "Receiver", want.Receiver, got[0].Receiver)
+	assertEqual(t, "Reason", want.Reason, got[0].Reason)
+	assertEqual(t, "Rule", want.Rule, got[0].Rule)
+	assertEqual(t, "CreatedAt", want.CreatedAt, got[0].CreatedAt.UTC())
+
+	if got, _ := s.ListDecisions(ctx, triage.DecisionFilter{Tenant: "acme", Fingerprint: fp, Decision: triage.DecisionAccepted}); len(got) != 1 || got[0].TriageID != "t1" {
+		t.Errorf("accepted = %+v, want t1 only", got)
+	}
+
+	// Decisions made at the same instant page by ID.
+	if got, _ := s.ListDecisions(ctx, triage.DecisionFilter{Tenant: triage.AnyTenant, Fingerprint: fp, Before: now, BeforeID: fp + "-3"}); len(got) != 2 || got[0].ID != want.ID {
+		t.Errorf("page before %s-3 = %+v, want %s and %s-1", fp, got, want.ID, fp)
+	}
+
+	if _, err := s.PurgeDecisions(ctx, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("PurgeDecisions: %v", err)
+	}
+	if got, _ := s.ListDecisions(ctx, triage.DecisionFilter{Tenant: triage.AnyTenant, Fingerprint: fp}); len(got) != 2 {
+		t.Errorf("after purge = %d decisions, want 2", len(got))
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	tenant := fmt.Sprintf("suppress-%d", time.Now().UnixNano())
+	now := time.Now().Truncate(time.Microsecond).UTC()
+
+	want := &triage.Suppression{
+		ID: tenant + "-soon", TenantID: tenant, Matchers: map[string]string{"alertname": "Noisy"},
+		Comment: "known flapper", Reason: triage.SuppressionSnoozed, CreatedBy: "api-token", CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	}
+	for _, sup := range []*triage.Suppression{
+		{ID: tenant + "-late", TenantID: tenant, Fingerprint: "fp-1", CreatedAt: now, ExpiresAt: now.Add(2 * time.Hour)},
+		want,
+		{ID: tenant + "-expired", TenantID: tenant, Fingerprint: "fp-2", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	} {
+		if err := s.CreateSuppression(ctx, sup); err != nil {
+			t.Fatalf("CreateSuppression: %v", err)
+		}
+	}
+
+	got, err := s.ActiveSuppressions(ctx, tenant, now)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("ActiveSuppressions = %d, %v; want 2", len(got), err)
+	}
+	assertEqual(t, "ID", want.ID, got[0].ID)
+	assertEqual(t, "Matchers", want.Matchers["alertname"], got[0].Matchers["alertname"])
+	assertEqual(t, "Comment", want.Comment, got[0].Comment)
+	assertEqual(t, "Reason", want.Reason, got[0].Reason)
+	assertEqual(t, "CreatedBy", want.CreatedBy, got[0].CreatedBy)
+	assertEqual(t, "ExpiresAt", want.ExpiresAt, got[0].ExpiresAt.UTC())
+	assertEqual(t, "Fingerprint", "fp-1", got[1].Fingerprint)
+
+	if ok, err := s.DeleteSuppression(ctx, want.ID, "other"); err != nil || ok {
+		t.Errorf("DeleteSuppression other tenant = %v, %v; want false", ok, err)
+	}
+	if ok, err := s.DeleteSuppression(ctx, want.ID, tenant); err != nil || !ok {
+		t.Errorf("DeleteSuppression = %v, %v; want true", ok, err)
+	}
+	if got, _ := s.ActiveSuppressions(ctx, tenant, now); len(got) != 1 {
+		t.Errorf("after delete = %d suppressions, want 1", len(got))
+	}
+}
+
+func TestAudit(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	id := fmt.Sprintf("audit-%d", time.Now().UnixNano())
+	now := time.Now().Truncate(time.Microsecond).UTC()
+
+	want := []*triage.AuditEvent{
+		{ID: id + "-1", TriageID: id, TenantID: "acme", Event: triage.AuditSubmitted, Actor: "tenant-token:acme", CreatedAt: now},
+		{ID: id + "-2", TriageID: id, TenantID: "acme", Event: triage.AuditCompleted, Actor: triage.ActorSystem, Detail: "complete", CreatedAt: now.Add(time.Second)},
+	}
+	// Recorded out of order; listed oldest first.
+	for _, e := range []*triage.AuditEvent{want[1], want[0]} {
+		if err := s.RecordAudit(ctx, e); err != nil {
+			t.Fatalf("RecordAudit: %v", err)
+		}
+	}
+
+	got, err := s.ListAudit(ctx, id)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("ListAudit = %d, %v; want 2", len(got), err)
+	}
+	for i := range want {
+		assertEqual(t, "ID", want[i].ID, got[i].ID)
+		assertEqual(t, "Event", want[i].Event, got[i].Event)
+		assertEqual(t, "Actor", want[i].Actor, got[i].Actor)
+		assertEqual(t, "Detail", want[i].Detail, got[i].Detail)
+		assertEqual(t, "CreatedAt", want[i].CreatedAt, got[i].CreatedAt.UTC())
+	}
+}
+
+func TestClaimDigest(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	period := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	due := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+
+	for i, want := range []bool{true, false} {
+		won, err := s.ClaimDigest(ctx, period, due)
+		if err != nil {
+			t.Fatalf("ClaimDigest: %v", err)
+		}
+		if won != want {
+			t.Errorf("claim %d = %v, want %v", i, won, want)
+		}
+	}
+	if won, _ := s.ClaimDigest(ctx, period, due.AddDate(0, 0, 1)); !won {
+		t.Error("next period's digest should be claimable")
+	}
+}
+
+func TestLead(t *testing.T) {
+	s := openStore(t)
+	name := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+	leading := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Lead(ctx, name, func(ctx context.Context) {
+			close(leading)
+			<-ctx.Done()
+		})
+	}()
+	<-leading
+
+	// The lock's session excludes a second leader, even on the same pool.
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer waitCancel()
+	s.Lead(waitCtx, name, func(context.Context) { t.Error("second leader ran while the lock was held") })
+
+	cancel()
+	<-done
+	ranCtx, ranCancel := context.WithCancel(context.Background())
+	s.Lead(ranCtx, name, func(context.Context) { ranCancel() })
+	if ranCtx.Err() == nil {
+		t.Error("released lock should be taken")
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	s := openStore(t)
+	ctx := context.Background()
+	id := fmt.Sprintf("outbox-%d", time.Now().UnixNano())
+	now := time.Now().Truncate(time.Microsecond).UTC()
+
+	r := &triage.Result{ID: id, Fingerprint: "fp-" + id, Status: triage.StatusComplete, Alert: "DiskFull", CreatedAt: now}
+	n := &triage.Notification{TriageID: id, Receiver: "team-a", Status: triage.NotificationPending, NextAttempt: now.Add(time.Minute), CreatedAt: now}
+	if err := s.PutNotifying(ctx, r, n); err != nil {
+		t.Fatalf("PutNotifying: %v", err)
+	}
+	if got, ok, err := s.Get(ctx, id); err != nil || !ok || got.Status != triage.StatusComplete {
+		t.Fatalf("Get = %+v, %v, %v; want the stored result", got, ok, err)
+	}
+
+	find := func(at time.Time) *triage.Notification {
+		due, err := s.DueNotifications(ctx, at, 1000)
+		if err != nil {
+			t.Fatalf("DueNotifications: %v", err)
+		}
+		for _, d := range due {
+			if d.TriageID == id {
+				return d
+			}
+		}
+		return nil
+	}
+	if find(now) != nil {
+		t.Fatal("notification due before its next attempt")
+	}
+	got := find(now.Add(time.Minute))
+	if got == nil {
+		t.Fatal("notification not due at its next attempt")
+	}
+	assertEqual(t, "Receiver", "team-a", got.Receiver)
+
+	got.Attempts, got.Status, got.DeliveredAt = 1, triage.NotificationDelivered, now
+	if err := s.UpdateNotification(ctx, got); err != nil {
+		t.Fatalf("UpdateNotification: %v", err)
+	}
+	if find(now.Add(time.Hour)) != nil {
+		t.Error("delivered notification is still due")
+	}
+	stored, ok, err := s.Notification(ctx, id)
+	if err != nil || !ok || stored.Status != triage.NotificationDelivered || !stored.DeliveredAt.Equal(now) {
+		t.Errorf("Notification = %+v, %v, %v; want delivered at %v", stored, ok, err, now)
+	}
+	if _, ok, err := s.Notification(ctx, "no-such-triage"); err != nil || ok {
+		t.Errorf("Notification of a missing triage = %v, %v; want not found", ok, err)
+	}
+
+	// Deleting and purging the triage removes its notification with it.
+	if _, err := s.Delete(ctx, id, now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Purge(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+}
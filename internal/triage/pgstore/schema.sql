@@ -1,5 +1,5 @@
 -- Triage results track the lifecycle of each alert investigation.
--- The partial unique index on fingerprint prevents concurrent triage of the same alert.
+-- The partial unique index on tenant and fingerprint prevents concurrent triage of the same alert.
 CREATE TABLE IF NOT EXISTS triage_runs (
     id           TEXT PRIMARY KEY,
     fingerprint  TEXT NOT NULL,
@@ -20,12 +20,64 @@ CREATE TABLE IF NOT EXISTS triage_runs (
     system_prompt TEXT NOT NULL DEFAULT '',
     model         TEXT NOT NULL DEFAULT '');
 
+-- Columns added after the initial schema; ADD COLUMN IF NOT EXISTS keeps startup idempotent.
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS generator_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS investigation_notes JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS incident_children JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS partial_text TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS tokens_thinking INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS issue_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS alert_metadata JSONB;
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS redactions INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS strategy TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS received_at TIMESTAMPTZ;
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS alert_labels JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS alert_annotations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS suggested_actions JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS trace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS dry_run BOOLEAN NOT NULL DEFAULT FALSE;
+
 CREATE INDEX IF NOT EXISTS idx_triage_runs_fingerprint ON triage_runs (fingerprint);
 CREATE INDEX IF NOT EXISTS idx_triage_runs_status ON triage_runs (status);
+-- List pages on (created_at, id), so the index covers both.
+DROP INDEX IF EXISTS idx_triage_runs_created_at;
+CREATE INDEX IF NOT EXISTS idx_triage_runs_created_at_id ON triage_runs (created_at DESC, id DESC);
+-- Label filters are containment queries (alert_labels @> '{"namespace":"prod"}'),
+-- which jsonb_path_ops indexes more compactly than the default operator class.
+CREATE INDEX IF NOT EXISTS idx_triage_runs_alert_labels ON triage_runs USING GIN (alert_labels jsonb_path_ops);
+
+-- Full-text search document, weighted so alert name matches rank above
+-- summary matches, which rank above analysis matches. Alert names are
+-- identifiers, so they are not stemmed.
+ALTER TABLE triage_runs ADD COLUMN IF NOT EXISTS search_tsv tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', alert_name), 'A') ||
+    setweight(to_tsvector('english', summary), 'B') ||
+    setweight(to_tsvector('english', analysis), 'C')) STORED;
+CREATE INDEX IF NOT EXISTS idx_triage_runs_search ON triage_runs USING GIN (search_tsv);
+
+-- Runs stopped by a budget or cancellation were once stored as complete or
+-- failed with the reason only in the analysis text. They are reclassified on
+-- startup; rows already moved out of complete and failed are left alone, so
+-- this is a no-op after the first run.
+UPDATE triage_runs SET status = CASE
+        WHEN analysis LIKE 'Triage terminated: tool call budget exhausted%' THEN 'max_turns'
+        WHEN analysis LIKE 'Triage terminated: % token budget exhausted%' THEN 'budget_exceeded'
+        ELSE 'error'
+    END
+WHERE status IN ('complete', 'failed') AND analysis LIKE 'Triage terminated: %';
 
--- Partial index to enforce uniqueness of active triage results by fingerprint, allowing multiple completed triages for the same alert.
-CREATE UNIQUE INDEX IF NOT EXISTS idx_triage_runs_active_fingerprint
-ON triage_runs(fingerprint)
+-- Soft-deleted rows wait here for the purge job.
+CREATE INDEX IF NOT EXISTS idx_triage_runs_deleted_at ON triage_runs (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Partial index to enforce uniqueness of active triage results by tenant and fingerprint, allowing multiple completed triages for the same alert.
+-- It replaces the fingerprint-only index, which would let one tenant's active triage block another's.
+DROP INDEX IF EXISTS idx_triage_runs_active_fingerprint;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_triage_runs_active_tenant_fingerprint
+ON triage_runs(tenant_id, fingerprint)
 WHERE status IN ('pending', 'in_progress');
 
 -- Messages capture the full LLM conversation for replay and analysis.
@@ -60,5 +112,84 @@ CREATE TABLE IF NOT EXISTS tool_calls (
     created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS tokens_thinking INTEGER;
+-- Size of content as written, summed against the conversation byte budget.
+-- NULL in rows written before it was tracked.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_bytes INTEGER;
+
 CREATE INDEX IF NOT EXISTS idx_messages_triage_id ON messages(triage_id);
 CREATE INDEX IF NOT EXISTS idx_tool_calls_triage_id ON tool_calls(triage_id);
+
+-- Decisions explain why each submitted alert was or was not triaged. Rows are
+-- removed by age, not with the triage they point at.
+CREATE TABLE IF NOT EXISTS decisions (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL DEFAULT '',
+    fingerprint TEXT NOT NULL,
+    alert_name  TEXT NOT NULL DEFAULT '',
+    receiver    TEXT NOT NULL DEFAULT '',
+    decision    TEXT NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    rule        TEXT NOT NULL DEFAULT '',
+    triage_id   TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_decisions_tenant_created_at ON decisions (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_decisions_fingerprint ON decisions (fingerprint);
+
+-- Suppressions skip triage of matching alerts until they expire. Expired
+-- rows are deleted whenever a suppression is created.
+CREATE TABLE IF NOT EXISTS suppressions (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL DEFAULT '',
+    fingerprint TEXT NOT NULL DEFAULT '',
+    matchers    JSONB NOT NULL DEFAULT '{}',
+    comment     TEXT NOT NULL DEFAULT '',
+    reason      TEXT NOT NULL DEFAULT 'suppressed',
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_suppressions_tenant_expires_at ON suppressions (tenant_id, expires_at);
+
+-- The audit trail records every lifecycle transition of a triage. It is
+-- append-only: rows are never updated, and outlive the triage they point at,
+-- so there is no foreign key.
+CREATE TABLE IF NOT EXISTS triage_audit (
+    id         TEXT PRIMARY KEY,
+    triage_id  TEXT NOT NULL,
+    tenant_id  TEXT NOT NULL DEFAULT '',
+    event      TEXT NOT NULL,
+    actor      TEXT NOT NULL,
+    detail     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_triage_audit_triage_id ON triage_audit (triage_id, created_at);
+
+-- Digests record which periodic digests have been sent, so only one replica
+-- sends each.
+CREATE TABLE IF NOT EXISTS digests (
+    period  TEXT NOT NULL,
+    due_at  TIMESTAMPTZ NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (period, due_at)
+);
+
+-- Notifications is the outbox of finished triages, written with their final
+-- status so a notifier outage delays a notification instead of losing it.
+CREATE TABLE IF NOT EXISTS notifications (
+    triage_id       TEXT PRIMARY KEY REFERENCES triage_runs(id),
+    tenant_id       TEXT NOT NULL DEFAULT '',
+    receiver        TEXT NOT NULL DEFAULT '',
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications (next_attempt_at) WHERE status = 'pending';
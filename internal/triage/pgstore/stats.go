@@ -0,0 +1,116 @@
+package pgstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// statsRuns selects the live runs of the window; $1 and $2 bound created_at
+// and $3 is the tenant.
+const statsRuns = `deleted_at IS NULL AND created_at >= $1 AND created_at < $2 AND ($3 = '*' OR tenant_id = $3)`
+
+// Stats aggregates the window in one read-only snapshot, so the parts of the
+// answer agree with each other.
+func (s *Store) Stats(ctx context.Context, q triage.StatsQuery) (*triage.Stats, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.Stats", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	st, err := s.stats(ctx, q)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("vigil.stats.triages", st.Triages))
+	span.SetStatus(codes.Ok, "")
+	return st, nil
+}
+
+func (s *Store) stats(ctx context.Context, q triage.StatsQuery) (*triage.Stats, error) {
+	tx, err := s.reader().BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // read-only tx, rollback is the normal end
+
+	args := []any{q.Since, q.Until, q.Tenant}
+	st := &triage.Stats{Since: q.Since, Until: q.Until, ByStatus: map[triage.Status]int{}}
+
+	rows, err := tx.Query(ctx, `SELECT status, count(*) FROM triage_runs WHERE `+statsRuns+` GROUP BY status`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("count by status: %w", err)
+	}
+	var status triage.Status
+	var n int
+	if _, err := pgx.ForEachRow(rows, []any{&status, &n}, func() error {
+		st.ByStatus[status] = n
+		st.Triages += n
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("count by status: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `SELECT
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY duration_s), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY duration_s), 0)
+		FROM triage_runs WHERE `+statsRuns+` AND status NOT IN ('pending', 'in_progress')`, args...,
+	).Scan(&st.DurationP50, &st.DurationP95)
+	if err != nil {
+		return nil, fmt.Errorf("duration percentiles: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `SELECT model, count(*), COALESCE(sum(tokens_in), 0), COALESCE(sum(tokens_out), 0), COALESCE(sum(tokens_thinking), 0)
+		FROM triage_runs WHERE `+statsRuns+` AND model <> ''
+		GROUP BY model ORDER BY count(*) DESC, model`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("usage by model: %w", err)
+	}
+	var m triage.ModelUsage
+	if _, err := pgx.ForEachRow(rows, []any{&m.Model, &m.Triages, &m.TokensIn, &m.TokensOut, &m.TokensThinking}, func() error {
+		st.Models = append(st.Models, m)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("usage by model: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `SELECT alert_name, count(*) FROM triage_runs WHERE `+statsRuns+`
+		GROUP BY alert_name ORDER BY count(*) DESC, alert_name LIMIT $4`, append(args, q.EffectiveTopAlerts())...)
+	if err != nil {
+		return nil, fmt.Errorf("top alerts: %w", err)
+	}
+	var a triage.AlertCount
+	if _, err := pgx.ForEachRow(rows, []any{&a.Alert, &a.Triages}, func() error {
+		st.TopAlerts = append(st.TopAlerts, a)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("top alerts: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `SELECT c.tool_name, count(*), count(*) FILTER (WHERE c.is_error)
+		FROM tool_calls c JOIN triage_runs r ON r.id = c.triage_id
+		WHERE r.deleted_at IS NULL AND r.created_at >= $1 AND r.created_at < $2 AND ($3 = '*' OR r.tenant_id = $3)
+		GROUP BY c.tool_name ORDER BY count(*) DESC, c.tool_name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("tool usage: %w", err)
+	}
+	var t triage.ToolUsage
+	if _, err := pgx.ForEachRow(rows, []any{&t.Tool, &t.Calls, &t.Errors}, func() error {
+		t.ErrorRate = float64(t.Errors) / float64(t.Calls)
+		st.Tools = append(st.Tools, t)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("tool usage: %w", err)
+	}
+
+	return st, nil
+}
@@ -0,0 +1,115 @@
+package pgstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linnemanlabs/vigil/internal/triage"
+)
+
+// CreateSuppression inserts a suppression, deleting expired ones in the same
+// statement.
+func (s *Store) CreateSuppression(ctx context.Context, sup *triage.Suppression) error {
+	ctx, span := s.tracer.Start(ctx, "pgstore.CreateSuppression", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "INSERT"),
+	))
+	defer span.End()
+
+	matchers := sup.Matchers
+	if matchers == nil {
+		matchers = map[string]string{}
+	}
+	matchersJSON, err := json.Marshal(matchers)
+	if err != nil {
+		return fmt.Errorf("marshal matchers: %w", err)
+	}
+
+	_, err = s.pool.Exec(ctx, `WITH expired AS (
+			DELETE FROM suppressions WHERE expires_at <= $7
+		)
+		INSERT INTO suppressions
+		(id, tenant_id, fingerprint, matchers, comment, created_by, created_at, expires_at, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		sup.ID, sup.TenantID, sup.Fingerprint, matchersJSON, sup.Comment, sup.CreatedBy, sup.CreatedAt, sup.ExpiresAt, sup.Reason)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("insert suppression: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// ActiveSuppressions returns the tenant's suppressions expiring after now,
+// soonest to expire first.
+func (s *Store) ActiveSuppressions(ctx context.Context, tenant string, now time.Time) ([]*triage.Suppression, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.ActiveSuppressions", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "SELECT"),
+	))
+	defer span.End()
+
+	rows, err := s.pool.Query(ctx, `SELECT id, tenant_id, fingerprint, matchers, comment, created_by, created_at, expires_at, reason
+		FROM suppressions
+		WHERE tenant_id = $1 AND expires_at > $2
+		ORDER BY expires_at, id`,
+		tenant, now)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("query suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*triage.Suppression
+	for rows.Next() {
+		var sup triage.Suppression
+		var matchersJSON []byte
+		if err := rows.Scan(&sup.ID, &sup.TenantID, &sup.Fingerprint, &matchersJSON, &sup.Comment, &sup.CreatedBy, &sup.CreatedAt, &sup.ExpiresAt, &sup.Reason); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("scan suppression: %w", err)
+		}
+		if err := json.Unmarshal(matchersJSON, &sup.Matchers); err != nil {
+			return nil, fmt.Errorf("unmarshal suppression matchers: %w", err)
+		}
+		if len(sup.Matchers) == 0 {
+			sup.Matchers = nil
+		}
+		out = append(out, &sup)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("iterate suppressions: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("db.response.returned_rows", len(out)))
+	span.SetStatus(codes.Ok, "")
+	return out, nil
+}
+
+// DeleteSuppression deletes a suppression of the tenant.
+func (s *Store) DeleteSuppression(ctx context.Context, id, tenant string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "pgstore.DeleteSuppression", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", "DELETE"),
+	))
+	defer span.End()
+
+	tag, err := s.pool.Exec(ctx, `DELETE FROM suppressions WHERE id = $1 AND tenant_id = $2`, id, tenant)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, fmt.Errorf("delete suppression: %w", err)
+	}
+	span.SetStatus(codes.Ok, "")
+	return tag.RowsAffected() > 0, nil
+}
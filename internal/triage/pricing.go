@@ -0,0 +1,42 @@
+package triage
+
+import "strings"
+
+// ModelPrice is a model's list price in US dollars per million tokens.
+// Thinking tokens are billed as output.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// Cost returns the list price of the given token counts.
+func (p ModelPrice) Cost(tokensIn, tokensOut int64) float64 {
+	return (float64(tokensIn)*p.Input + float64(tokensOut)*p.Output) / 1e6
+}
+
+// modelPrices are matched against model names in order, so more specific
+// prefixes come first. Batch discounts and prompt caching are not
+// accounted for, so costs are an upper bound.
+var modelPrices = []struct {
+	prefix string
+	price  ModelPrice
+}{
+	{"claude-opus-4-5", ModelPrice{Input: 5, Output: 25}},
+	{"claude-opus-4", ModelPrice{Input: 15, Output: 75}},
+	{"claude-sonnet-4", ModelPrice{Input: 3, Output: 15}},
+	{"claude-haiku-4-5", ModelPrice{Input: 1, Output: 5}},
+	{"claude-3-7-sonnet", ModelPrice{Input: 3, Output: 15}},
+	{"claude-3-5-sonnet", ModelPrice{Input: 3, Output: 15}},
+	{"claude-3-5-haiku", ModelPrice{Input: 0.8, Output: 4}},
+}
+
+// PriceOf returns the list price of model, reporting false for models it
+// does not know.
+func PriceOf(model string) (ModelPrice, bool) {
+	for _, mp := range modelPrices {
+		if strings.HasPrefix(model, mp.prefix) {
+			return mp.price, true
+		}
+	}
+	return ModelPrice{}, false
+}
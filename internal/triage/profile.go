@@ -0,0 +1,115 @@
+package triage
+
+import "github.com/linnemanlabs/vigil/internal/alert"
+
+// Profile tailors how an alert is triaged and where the result goes, usually
+// per owning team.
+type Profile struct {
+	// Name identifies the profile in logs, spans, and metrics.
+	Name string
+
+	// Skip drops matching alerts without triaging them.
+	Skip bool
+
+	// Instructions are appended to the system prompt, e.g. team runbooks or
+	// which services to look at first.
+	Instructions string
+
+	// Notifier overrides the service notifier when non-nil.
+	Notifier Notifier
+
+	// Engine overrides the service engine when non-nil, e.g. to give a
+	// tenant its own datasources and model.
+	Engine Engine
+
+	// Budget overrides the run limits; zero fields keep the defaults.
+	Budget Budget
+
+	// NotifyMinConfidence overrides the service's notification gate, see
+	// WithNotifyMinConfidence; 0 keeps it.
+	NotifyMinConfidence float64
+
+	// Strategy selects how the engine investigates; empty means
+	// StrategyReAct.
+	Strategy Strategy
+}
+
+// ProfileResolver selects the profile for an alert. Returning nil means the
+// service defaults apply.
+type ProfileResolver interface {
+	Resolve(al *alert.Alert) *Profile
+}
+
+// WithProfiles sets the resolver consulted for every submitted alert.
+func WithProfiles(r ProfileResolver) ServiceOption {
+	return func(s *Service) {
+		s.profiles = r
+	}
+}
+
+// RunOption configures a single Engine.Run call.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	instructions string
+	budget       Budget
+	prompt       string
+	metadata     *Metadata
+	maintenance  *MaintenanceWindow
+	verdict      bool
+	actions      string
+	strategy     Strategy
+	onPartial    PartialCallback
+}
+
+// Budget bounds a single run. Zero fields keep the engine's defaults, set
+// with WithDefaultBudget, or else the package limits MaxToolRounds,
+// MaxInputTokens and MaxOutputTokens.
+type Budget struct {
+	ToolCalls    int `json:"tool_calls,omitempty"`
+	InputTokens  int `json:"input_tokens,omitempty"`
+	OutputTokens int `json:"output_tokens,omitempty"`
+}
+
+// withDefaults fills unset fields from the package limits.
+func (b Budget) withDefaults() Budget {
+	if b.ToolCalls <= 0 {
+		b.ToolCalls = MaxToolRounds
+	}
+	if b.InputTokens <= 0 {
+		b.InputTokens = MaxInputTokens
+	}
+	if b.OutputTokens <= 0 {
+		b.OutputTokens = MaxOutputTokens
+	}
+	return b
+}
+
+// WithInstructions appends extra guidance to the system prompt for one run.
+// Given more than once, the instructions are joined in order.
+func WithInstructions(s string) RunOption {
+	return func(c *runConfig) {
+		if c.instructions != "" && s != "" {
+			s = c.instructions + "\n\n" + s
+		}
+		if s != "" {
+			c.instructions = s
+		}
+	}
+}
+
+// withPrompt replaces the initial user message built from the alert.
+func withPrompt(p string) RunOption {
+	return func(c *runConfig) { c.prompt = p }
+}
+
+// WithPartial streams responses when the provider supports it, passing the
+// text received so far to fn every PartialFlushInterval or PartialFlushBytes.
+func WithPartial(fn PartialCallback) RunOption {
+	return func(c *runConfig) { c.onPartial = fn }
+}
+
+// WithBudget overrides the tool call and token limits for one run.
+func WithBudget(b Budget) RunOption {
+	return func(c *runConfig) { c.budget = b }
+}
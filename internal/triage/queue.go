@@ -0,0 +1,136 @@
+package triage
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPriorityAging is how long a triage must wait before it outranks a
+// fresh triage one severity band above it.
+const DefaultPriorityAging = 5 * time.Minute
+
+// Severity bands used to order pending triages, most urgent first.
+const (
+	BandCritical = "critical"
+	BandWarning  = "warning"
+	BandInfo     = "info"
+)
+
+// SeverityBand maps a severity label to a scheduling band. Unknown or missing
+// severities are treated as warning so unlabeled alerts are not starved.
+func SeverityBand(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "page", "p1", "p2", "high", "error":
+		return BandCritical
+	case "info", "informational", "low", "none", "p4", "p5":
+		return BandInfo
+	default:
+		return BandWarning
+	}
+}
+
+func bandRank(band string) int {
+	switch band {
+	case BandCritical:
+		return 0
+	case BandInfo:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// scheduler hands out a fixed number of run slots. When none are free,
+// waiters are granted in order of a virtual deadline: enqueue time plus
+// aging per band below critical. Critical alerts jump ahead of queued
+// info alerts, while an old info alert still beats a critical one that
+// arrived more than two aging periods later.
+type scheduler struct {
+	aging time.Duration
+
+	mu      sync.Mutex
+	free    int
+	waiting waitHeap
+	seq     uint64
+}
+
+func newScheduler(slots int, aging time.Duration) *scheduler {
+	return &scheduler{free: slots, aging: aging}
+}
+
+type waiter struct {
+	deadline time.Time
+	seq      uint64
+	ready    chan struct{}
+}
+
+// acquire blocks until a slot is granted to this caller and reports whether
+// it holds one. If ctx ends first the caller leaves the queue and acquire
+// returns false; the run that follows stops at once on the same ctx.
+func (s *scheduler) acquire(ctx context.Context, band string, enqueued time.Time) bool {
+	s.mu.Lock()
+	if s.free > 0 && len(s.waiting) == 0 {
+		s.free--
+		s.mu.Unlock()
+		return true
+	}
+	s.seq++
+	w := &waiter{
+		deadline: enqueued.Add(time.Duration(bandRank(band)) * s.aging),
+		seq:      s.seq,
+		ready:    make(chan struct{}),
+	}
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := slices.Index(s.waiting, w); i >= 0 {
+		heap.Remove(&s.waiting, i)
+		return false
+	}
+	// release granted the slot as ctx ended; keep it so the caller's
+	// release returns it.
+	return true
+}
+
+// release returns a slot, handing it directly to the highest priority waiter.
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) == 0 {
+		s.free++
+		return
+	}
+	w := heap.Pop(&s.waiting).(*waiter) //nolint:forcetypeassert // heap only holds *waiter
+	close(w.ready)
+}
+
+// waitHeap orders waiters by deadline, then arrival.
+type waitHeap []*waiter
+
+func (h waitHeap) Len() int { return len(h) }
+func (h waitHeap) Less(i, j int) bool {
+	if !h[i].deadline.Equal(h[j].deadline) {
+		return h[i].deadline.Before(h[j].deadline)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h waitHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *waitHeap) Push(x any)   { *h = append(*h, x.(*waiter)) } //nolint:forcetypeassert // heap only holds *waiter
+func (h *waitHeap) Pop() any {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,153 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSeverityBand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		severity string
+		want     string
+	}{
+		{"critical", BandCritical},
+		{"Critical", BandCritical},
+		{"page", BandCritical},
+		{"warning", BandWarning},
+		{"", BandWarning},
+		{"something-custom", BandWarning},
+		{"info", BandInfo},
+		{" none ", BandInfo},
+	}
+	for _, tt := range tests {
+		if got := SeverityBand(tt.severity); got != tt.want {
+			t.Errorf("SeverityBand(%q) = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestScheduler_GrantsBySeverityThenAge(t *testing.T) {
+	t.Parallel()
+
+	s := newScheduler(1, 5*time.Minute)
+	s.acquire(context.Background(), BandWarning, time.Now()) // hold the only slot
+
+	t0 := time.Now()
+	waiters := []struct {
+		name     string
+		band     string
+		enqueued time.Time
+	}{
+		{"new-info", BandInfo, t0},
+		{"critical", BandCritical, t0.Add(time.Second)},
+		{"warning", BandWarning, t0.Add(2 * time.Second)},
+		{"aged-info", BandInfo, t0.Add(-20 * time.Minute)},
+	}
+
+	granted := make(chan string, len(waiters))
+	for _, w := range waiters {
+		go func() {
+			s.acquire(context.Background(), w.band, w.enqueued)
+			granted <- w.name
+		}()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.waiting)
+		s.mu.Unlock()
+		if n == len(waiters) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d waiters queued", n, len(waiters))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	want := []string{"aged-info", "critical", "warning", "new-info"}
+	for i, w := range want {
+		s.release()
+		select {
+		case got := <-granted:
+			if got != w {
+				t.Fatalf("grant %d = %q, want %q", i, got, w)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("grant %d: nothing granted", i)
+		}
+	}
+
+	s.release()
+	if s.free != 1 {
+		t.Errorf("free = %d after all releases, want 1", s.free)
+	}
+}
+
+func TestScheduler_FreeSlotsDoNotBlock(t *testing.T) {
+	t.Parallel()
+
+	s := newScheduler(2, time.Minute)
+	done := make(chan struct{})
+	go func() {
+		s.acquire(context.Background(), BandInfo, time.Now())
+		s.acquire(context.Background(), BandInfo, time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("acquire blocked with free slots")
+	}
+}
+
+func TestScheduler_CancelledWaiterLeavesQueue(t *testing.T) {
+	t.Parallel()
+
+	s := newScheduler(1, time.Minute)
+	s.acquire(context.Background(), BandWarning, time.Now()) // hold the only slot
+
+	ctx, cancel := context.WithCancel(context.Background())
+	granted := make(chan bool, 1)
+	go func() { granted <- s.acquire(ctx, BandCritical, time.Now()) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.waiting)
+		s.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("waiter never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case ok := <-granted:
+		if ok {
+			t.Error("cancelled acquire reported a slot")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled acquire did not return")
+	}
+	s.mu.Lock()
+	left := len(s.waiting)
+	s.mu.Unlock()
+	if left != 0 {
+		t.Errorf("%d waiters left after cancel, want 0", left)
+	}
+
+	// The released slot goes back to the pool, not to the cancelled waiter.
+	s.release()
+	if s.free != 1 {
+		t.Errorf("free = %d after release, want 1", s.free)
+	}
+}
@@ -0,0 +1,133 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimits are provider-wide limits shared by all concurrent triages,
+// matching the per-minute limits of the provider's API tier. Zero disables a limit.
+type RateLimits struct {
+	RequestsPerMinute     int
+	InputTokensPerMinute  int
+	OutputTokensPerMinute int
+
+	// MaxWait bounds how long a single call may queue for capacity before it
+	// fails; zero leaves it bounded only by the caller's context.
+	MaxWait time.Duration
+}
+
+// RateLimiter is a set of token buckets governing provider calls. Buckets
+// refill continuously and hold up to one minute of capacity, the same model
+// Anthropic uses for its tier limits.
+type RateLimiter struct {
+	requests *rate.Limiter
+	input    *rate.Limiter
+	output   *rate.Limiter
+	maxWait  time.Duration
+}
+
+// NewRateLimiter returns a limiter for l, or nil if no limit is set.
+func NewRateLimiter(l RateLimits) *RateLimiter {
+	if l.RequestsPerMinute <= 0 && l.InputTokensPerMinute <= 0 && l.OutputTokensPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		requests: perMinute(l.RequestsPerMinute),
+		input:    perMinute(l.InputTokensPerMinute),
+		output:   perMinute(l.OutputTokensPerMinute),
+		maxWait:  l.MaxWait,
+	}
+}
+
+func perMinute(n int) *rate.Limiter {
+	if n <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(float64(n)/60), n)
+}
+
+// Wait blocks until there is capacity for req, returning how long it queued.
+// Input tokens are reserved from an estimate of the request size; output
+// tokens are only known afterwards, so Wait just waits out any debt left by
+// earlier calls and Record charges the actual usage.
+func (l *RateLimiter) Wait(ctx context.Context, estInputTokens int) (time.Duration, error) {
+	now := time.Now()
+	var reservations []*rate.Reservation
+	var delay time.Duration
+	reserve := func(lim *rate.Limiter, n int) {
+		if lim == nil {
+			return
+		}
+		r := lim.ReserveN(now, min(max(n, 1), lim.Burst()))
+		reservations = append(reservations, r)
+		delay = max(delay, r.DelayFrom(now))
+	}
+	reserve(l.requests, 1)
+	reserve(l.input, estInputTokens)
+	if l.output != nil {
+		if tokens := l.output.TokensAt(now); tokens < 1 {
+			delay = max(delay, time.Duration((1-tokens)/float64(l.output.Limit())*float64(time.Second)))
+		}
+	}
+
+	cancel := func() {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+	}
+
+	if delay == 0 {
+		return 0, nil
+	}
+	if l.maxWait > 0 && delay > l.maxWait {
+		cancel()
+		return 0, fmt.Errorf("llm rate limit: would wait %s, over the %s limit", delay.Round(time.Millisecond), l.maxWait)
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return delay, nil
+	case <-ctx.Done():
+		cancel()
+		return time.Since(now), fmt.Errorf("llm rate limit: %w", ctx.Err())
+	}
+}
+
+// Record charges actual usage after a call: output tokens in full, and any
+// input tokens beyond the estimate reserved by Wait. Charges can push a bucket
+// into debt, which later calls wait out.
+func (l *RateLimiter) Record(estInputTokens int, usage Usage) {
+	now := time.Now()
+	if l.input != nil && usage.InputTokens > estInputTokens {
+		l.input.ReserveN(now, min(usage.InputTokens-estInputTokens, l.input.Burst()))
+	}
+	if l.output != nil && usage.OutputTokens > 0 {
+		l.output.ReserveN(now, min(usage.OutputTokens, l.output.Burst()))
+	}
+}
+
+// estimateInputTokens approximates the input token count of a request at
+// four bytes per token over everything the provider tokenizes.
+func estimateInputTokens(req *LLMRequest) int {
+	n := len(req.System)
+	if b, err := json.Marshal(req.Messages); err == nil {
+		n += len(b)
+	}
+	if b, err := json.Marshal(req.Tools); err == nil {
+		n += len(b)
+	}
+	return n / 4
+}
+
+// WithRateLimiter makes every provider call wait on l. A single limiter should
+// be shared by everything calling the same API key. Nil disables rate limiting.
+func WithRateLimiter(l *RateLimiter) EngineOption {
+	return func(e *LLMEngine) { e.limiter = l }
+}
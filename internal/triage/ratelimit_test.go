@@ -0,0 +1,128 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+func TestNewRateLimiter_NoLimits(t *testing.T) {
+	t.Parallel()
+
+	if l := NewRateLimiter(RateLimits{MaxWait: time.Second}); l != nil {
+		t.Errorf("NewRateLimiter with no limits = %v, want nil", l)
+	}
+}
+
+func TestRateLimiter_RequestsMaxWait(t *testing.T) {
+	t.Parallel()
+
+	l := NewRateLimiter(RateLimits{RequestsPerMinute: 1, MaxWait: time.Second})
+	if wait, err := l.Wait(context.Background(), 0); err != nil || wait != 0 {
+		t.Fatalf("first Wait = %s, %v; want immediate", wait, err)
+	}
+	for range 2 { // a rejected wait must not leave its reservation behind
+		_, err := l.Wait(context.Background(), 0)
+		if err == nil || !strings.Contains(err.Error(), "over the 1s limit") {
+			t.Fatalf("err = %v, want max wait error", err)
+		}
+	}
+}
+
+func TestRateLimiter_ContextCancel(t *testing.T) {
+	t.Parallel()
+
+	l := NewRateLimiter(RateLimits{RequestsPerMinute: 1})
+	_, _ = l.Wait(context.Background(), 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestRateLimiter_InputEstimate(t *testing.T) {
+	t.Parallel()
+
+	l := NewRateLimiter(RateLimits{InputTokensPerMinute: 60000}) // 1000/s
+	if wait, err := l.Wait(context.Background(), 60000); err != nil || wait != 0 {
+		t.Fatalf("Wait for full bucket = %s, %v; want immediate", wait, err)
+	}
+	wait, err := l.Wait(context.Background(), 50)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if wait < 30*time.Millisecond || wait > time.Second {
+		t.Errorf("wait = %s, want ~50ms", wait)
+	}
+}
+
+func TestRateLimiter_OutputDebt(t *testing.T) {
+	t.Parallel()
+
+	l := NewRateLimiter(RateLimits{OutputTokensPerMinute: 6000}) // 100/s
+	if wait, _ := l.Wait(context.Background(), 0); wait != 0 {
+		t.Fatalf("Wait before any output = %s, want 0", wait)
+	}
+	l.Record(0, Usage{OutputTokens: 6000})
+	wait, err := l.Wait(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("wait = %s, want a short wait for output debt", wait)
+	}
+}
+
+func TestRun_RateLimitedCallFails(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var waits []float64
+	hooks := EngineHooks{OnLLMRateLimitWait: func(s float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		waits = append(waits, s)
+	}}
+	limiter := NewRateLimiter(RateLimits{RequestsPerMinute: 1, MaxWait: 10 * time.Millisecond})
+
+	newEngine := func() *LLMEngine {
+		provider := &mockProvider{responses: []*LLMResponse{{
+			Content:    []ContentBlock{{Type: "text", Text: "ok"}},
+			StopReason: StopEnd,
+		}}}
+		return NewEngine(provider, nil, log.Nop(), hooks, noop.NewTracerProvider(), WithRateLimiter(limiter))
+	}
+
+	if rr := newEngine().Run(context.Background(), "t1", testAlert(), nil); rr.Status != StatusComplete {
+		t.Fatalf("first run status = %q, want complete", rr.Status)
+	}
+	rr := newEngine().Run(context.Background(), "t2", testAlert(), nil)
+	if rr.Status != StatusFailed || !strings.Contains(rr.Analysis, "llm rate limit") {
+		t.Errorf("second run = %q %q, want failed on rate limit", rr.Status, rr.Analysis)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(waits) != 2 {
+		t.Errorf("rate limit hook calls = %d, want 2", len(waits))
+	}
+}
+
+func TestEstimateInputTokens(t *testing.T) {
+	t.Parallel()
+
+	small := estimateInputTokens(&LLMRequest{System: "x"})
+	big := estimateInputTokens(&LLMRequest{System: strings.Repeat("x", 4000)})
+	if big-small != 1000 {
+		t.Errorf("estimate grew by %d for 4000 bytes, want 1000", big-small)
+	}
+}
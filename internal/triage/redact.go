@@ -0,0 +1,28 @@
+package triage
+
+// Scrubber removes secrets from text, returning the scrubbed text and how
+// many values it replaced.
+type Scrubber interface {
+	Scrub(text string) (string, int)
+}
+
+// WithScrubber runs every tool output, and every tool error, through s
+// before it is added to the conversation. Unlike RedactPrompt, which only
+// rewrites requests, redacted values never reach the store, traces or
+// notifications either. The number replaced is reported per triage in
+// RunResult.Redactions.
+func WithScrubber(s Scrubber) EngineOption {
+	return func(e *LLMEngine) { e.scrubber = s }
+}
+
+// scrub runs text from the named tool through the scrubber, if any.
+func (e *LLMEngine) scrub(tool, text string) (string, int) {
+	if e.scrubber == nil {
+		return text, 0
+	}
+	text, n := e.scrubber.Scrub(text)
+	if n > 0 {
+		e.hooks.toolRedactions(tool, n)
+	}
+	return text, n
+}
@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+// wordScrubber redacts every occurrence of one word.
+type wordScrubber string
+
+func (w wordScrubber) Scrub(text string) (string, int) {
+	n := strings.Count(text, string(w))
+	return strings.ReplaceAll(text, string(w), "[REDACTED]"), n
+}
+
+func TestRun_ScrubsToolOutput(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_logs", output: json.RawMessage(`{"lines":["login password=hunter2","retry password=hunter2"]}`)})
+	registry.Register(&mockTool{name: "query_metrics", err: errors.New("auth failed for hunter2")})
+
+	provider := &mockProvider{responses: []*LLMResponse{
+		{
+			Content: []ContentBlock{
+				{Type: "tool_use", ID: "call-1", Name: "query_logs", Input: json.RawMessage(`{}`)},
+				{Type: "tool_use", ID: "call-2", Name: "query_metrics", Input: json.RawMessage(`{}`)},
+			},
+			StopReason: StopToolUse,
+		},
+		{Content: []ContentBlock{{Type: "text", Text: "done"}}, StopReason: StopEnd},
+	}}
+	var mu sync.Mutex
+	hooked := map[string]int{}
+	hooks := EngineHooks{OnToolRedactions: func(name string, n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		hooked[name] += n
+	}}
+	engine := NewEngine(provider, registry, log.Nop(), hooks, noop.NewTracerProvider(), WithScrubber(wordScrubber("hunter2")))
+
+	rr := engine.Run(context.Background(), "t-scrub", testAlert(), nil)
+
+	if rr.Redactions != 3 {
+		t.Errorf("Redactions = %d, want 3", rr.Redactions)
+	}
+	if hooked["query_logs"] != 2 || hooked["query_metrics"] != 1 {
+		t.Errorf("redaction hook counts = %v", hooked)
+	}
+	results := rr.Conversation.Turns[1].Content
+	if len(results) != 2 || !strings.Contains(results[0].Content, "password=[REDACTED]") || results[1].Content != "tool error: auth failed for [REDACTED]" {
+		t.Fatalf("tool results = %+v", results)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	sent, err := json.Marshal(provider.reqs[1].Messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(sent), "hunter2") {
+		t.Errorf("secret sent to the LLM: %s", sent)
+	}
+}
+
+func TestRun_NoScrubber(t *testing.T) {
+	t.Parallel()
+
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_logs", output: json.RawMessage(`{"line":"password=hunter2"}`)})
+	provider := &mockProvider{responses: []*LLMResponse{
+		{
+			Content:    []ContentBlock{{Type: "tool_use", ID: "call-1", Name: "query_logs", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		},
+		{Content: []ContentBlock{{Type: "text", Text: "done"}}, StopReason: StopEnd},
+	}}
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "t-plain", testAlert(), nil)
+	if rr.Redactions != 0 || !strings.Contains(rr.Conversation.Turns[1].Content[0].Content, "hunter2") {
+		t.Errorf("output changed without a scrubber: %+v", rr.Conversation.Turns[1].Content[0])
+	}
+}
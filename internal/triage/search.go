@@ -0,0 +1,158 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrEmptySearch is returned by Search for a query without any words.
+var ErrEmptySearch = errors.New("search query is empty")
+
+// SearchQuery is a full-text search over the alert name, summary and
+// analysis of triage results.
+type SearchQuery struct {
+	// Tenant restricts results like ListFilter.Tenant.
+	Tenant string
+	Text   string
+	Limit  int
+}
+
+// EffectiveLimit returns Limit clamped like ListFilter.EffectiveLimit.
+func (q SearchQuery) EffectiveLimit() int {
+	return ListFilter{Limit: q.Limit}.EffectiveLimit()
+}
+
+// SearchHit is one triage matching a SearchQuery. Snippet is an excerpt of
+// the summary and analysis with the matched words in **bold**.
+type SearchHit struct {
+	ID        string    `json:"id"`
+	Status    Status    `json:"status"`
+	Alert     string    `json:"alert_name"`
+	Severity  string    `json:"severity"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+	Rank      float64   `json:"rank"`
+	Snippet   string    `json:"snippet"`
+}
+
+// Search returns the tenant's triages matching q, best match first.
+func (s *Service) Search(ctx context.Context, q SearchQuery) ([]*SearchHit, error) {
+	if strings.TrimSpace(q.Text) == "" {
+		return nil, ErrEmptySearch
+	}
+	q.Tenant = TenantFrom(ctx)
+	return s.store.Search(ctx, q)
+}
+
+// Weights of a match in each field, so a hit on the alert name outranks one
+// buried in a long analysis.
+const (
+	searchWeightAlert    = 1.0
+	searchWeightSummary  = 0.4
+	searchWeightAnalysis = 0.2
+)
+
+// snippetBytes is roughly how much text MatchSearch puts in a snippet.
+const snippetBytes = 200
+
+// MatchSearch scores r against the words of text by case-insensitive
+// substring matching, for stores without a full-text index. Every word must
+// occur somewhere; it returns nil when one does not.
+func MatchSearch(r *Result, text string) *SearchHit {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) == 0 {
+		return nil
+	}
+	alert, summary, analysis := strings.ToLower(r.Alert), strings.ToLower(r.Summary), strings.ToLower(r.Analysis)
+	var rank float64
+	for _, w := range words {
+		n := searchWeightAlert*float64(strings.Count(alert, w)) +
+			searchWeightSummary*float64(strings.Count(summary, w)) +
+			searchWeightAnalysis*float64(strings.Count(analysis, w))
+		if n == 0 {
+			return nil
+		}
+		rank += n
+	}
+	return &SearchHit{
+		ID:        r.ID,
+		Status:    r.Status,
+		Alert:     r.Alert,
+		Severity:  r.Severity,
+		Summary:   r.Summary,
+		CreatedAt: r.CreatedAt,
+		Rank:      rank,
+		Snippet:   snippet(strings.TrimSpace(r.Summary+"\n"+r.Analysis), words),
+	}
+}
+
+// snippet cuts about snippetBytes of s around the first occurrence of any
+// of words and highlights every occurrence in the cut.
+func snippet(s string, words []string) string {
+	lower := strings.ToLower(s)
+	if len(lower) != len(s) {
+		// Case folding changed byte offsets; match case-sensitively.
+		lower = s
+	}
+	first := -1
+	for _, w := range words {
+		if i := strings.Index(lower, w); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	start := 0
+	if len(s) > snippetBytes && first > snippetBytes/4 {
+		start = wordStart(s, min(first-snippetBytes/4, len(s)-snippetBytes))
+	}
+	end := min(len(s), start+snippetBytes)
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(highlight(s[start:end], lower[start:end], words))
+	if end < len(s) {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// wordStart moves i forward to the start of the next word, unless that would
+// skip most of the text.
+func wordStart(s string, i int) int {
+	if j := strings.IndexAny(s[i:], " \n\t"); j >= 0 && j < 20 {
+		return i + j + 1
+	}
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return i
+}
+
+// highlight wraps each occurrence of words in s in **. lower is s in lower
+// case, with the same byte offsets.
+func highlight(s, lower string, words []string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		n := 0
+		for _, w := range words {
+			if strings.HasPrefix(lower[i:], w) && len(w) > n {
+				n = len(w)
+			}
+		}
+		if n == 0 {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		b.WriteString("**" + s[i:i+n] + "**")
+		i += n
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+func TestMatchSearch(t *testing.T) {
+	t.Parallel()
+
+	r := &Result{
+		ID:       "01A",
+		Alert:    "DiskErrors",
+		Summary:  "I/O errors on sdb",
+		Analysis: "XFS reported metadata corruption. The disk is failing.",
+	}
+	tests := []struct {
+		name    string
+		text    string
+		match   bool
+		rank    float64
+		snippet string
+	}{
+		{"every word must match", "xfs network", false, 0, ""},
+		{"case insensitive", "XFS Corruption", true, 0.4, "I/O errors on sdb **XFS** reported metadata **corruption**. The disk is failing."},
+		{"alert name weighs most", "disk", true, 1.2, "I/O errors on sdb XFS reported metadata corruption. The **disk** is failing."},
+		{"blank", "  ", false, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			hit := MatchSearch(r, tt.text)
+			if (hit != nil) != tt.match {
+				t.Fatalf("MatchSearch(%q) = %+v, want match %v", tt.text, hit, tt.match)
+			}
+			if hit == nil {
+				return
+			}
+			if diff := hit.Rank - tt.rank; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("rank = %v, want %v", hit.Rank, tt.rank)
+			}
+			if hit.Snippet != tt.snippet {
+				t.Errorf("snippet = %q, want %q", hit.Snippet, tt.snippet)
+			}
+		})
+	}
+}
+
+func TestSnippet_CutsAroundFirstMatch(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("filler words here ", 30) + "the xfs log is corrupt " + strings.Repeat("more text after ", 30)
+	got := snippet(long, []string{"xfs"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet = %q, want ellipses on both ends", got)
+	}
+	if !strings.Contains(got, "the **xfs** log") {
+		t.Errorf("snippet = %q, want the match highlighted", got)
+	}
+	if len(got) > snippetBytes+20 {
+		t.Errorf("snippet is %d bytes, want about %d", len(got), snippetBytes)
+	}
+}
+
+func TestService_Search(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	ctx := context.Background()
+	_ = store.Put(ctx, &Result{ID: "a", Alert: "DiskErrors", Analysis: "xfs corruption"})
+	_ = store.Put(ctx, &Result{ID: "b", Alert: "DiskErrors", Analysis: "xfs corruption", TenantID: "team-b"})
+	svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider())
+
+	if _, err := svc.Search(ctx, SearchQuery{Text: " "}); !errors.Is(err, ErrEmptySearch) {
+		t.Errorf("blank query error = %v, want ErrEmptySearch", err)
+	}
+	hits, err := svc.Search(WithTenant(ctx, "team-b"), SearchQuery{Text: "xfs", Tenant: AnyTenant})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].ID != "b" {
+		t.Errorf("hits = %+v, want only the tenant's triage", hits)
+	}
+}
@@ -1,7 +1,14 @@
 package triage
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -10,10 +17,12 @@ import (
 
 	"github.com/linnemanlabs/go-core/log"
 	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
 	"github.com/oklog/ulid/v2"
 )
 
-// SubmitResult is the outcome of submitting an alert for triage.
+// SubmitResult is the outcome of submitting an alert for triage. ID is the new
+// triage, or for a duplicate the active triage it was folded into.
 type SubmitResult struct {
 	ID      string
 	Skipped bool
@@ -23,74 +32,381 @@ type SubmitResult struct {
 // Service is the business boundary for triage operations.
 type Service struct {
 	store    Store
-	engine   *Engine
+	engine   Engine
 	logger   log.Logger
 	metrics  *Metrics
 	notifier Notifier
 	tracer   trace.Tracer
+	profiles ProfileResolver
+	tenants  map[string]*Profile
+
+	// running holds the cancel func of every triage submitted by this
+	// process that has not finished, for Cancel.
+	mu      sync.Mutex
+	running map[string]context.CancelCauseFunc
+
+	// guard sheds submissions once in-flight work is too large. convBytes
+	// tracks the conversation size of each running triage for it, under mu.
+	guard     Guardrails
+	convBytes map[string]int64
+	convTotal int64
+
+	// suppressions are alerts skipped by Submit, nil when disabled.
+	suppressions SuppressionStore
+
+	// notifyMinConfidence gates notifications on the verdict confidence;
+	// 0 disables the gate.
+	notifyMinConfidence float64
+
+	// maintenance reports declared maintenance covering an alert, nil when
+	// disabled.
+	maintenance MaintenanceChecker
+
+	// noise holds the alertname scores from the last RunNoiseScorer pass.
+	// Alerts at or above noiseThreshold run on noisyBudget; 0 disables this.
+	noise          atomic.Pointer[map[string]float64]
+	noiseThreshold float64
+	noiseWindow    time.Duration
+
+	// incidents groups completed triages for meta-triage, nil when disabled.
+	incidents *incidentTracker
+
+	// storm measures the submit rate and groups alerts during a storm, nil
+	// when disabled.
+	storm *stormTracker
+
+	// batchEngine runs alerts of batchSeverities, nil when disabled.
+	batchEngine     Engine
+	batchSeverities map[string]bool
+
+	// decisions records every Submit outcome, nil when disabled.
+	decisions DecisionLog
+
+	// auditLog records every lifecycle transition, nil when disabled.
+	auditLog AuditLog
+
+	// filter decides per alert whether to triage, skip, or downgrade it, nil
+	// means every alert is triaged.
+	filter Filter
+
+	// enricher supplies the operator's metadata for accepted alerts, nil
+	// when disabled.
+	enricher Enricher
+
+	// issues opens an issue for triages of issueSeverities, nil when
+	// disabled.
+	issues          IssueTracker
+	issueSeverities map[string]bool
+
+	// actions is the remediation catalog the model may suggest from, nil
+	// when disabled.
+	actions ActionCatalog
+
+	// outbox queues notifications for retry, nil means they are sent once.
+	outbox Outbox
+
+	// elector picks the replica that runs background jobs, nil means every
+	// replica runs them.
+	elector Elector
+
+	// families caps running triages per alertname, nil means unbounded.
+	families *familyLimiter
+
+	// redactThinking strips extended thinking text from persisted turns.
+	redactThinking bool
+
+	// storeBackoff is the first wait between store retries in a run.
+	storeBackoff time.Duration
+
+	// sched bounds the number of concurrently running triages, nil means unbounded.
+	// Triages waiting for a slot remain in StatusPending and are started by
+	// severity band and age rather than arrival order.
+	sched         *scheduler
+	maxConcurrent int
+	aging         time.Duration
+}
+
+// ServiceOption configures optional Service behavior.
+type ServiceOption func(*Service)
+
+// WithMaxConcurrent caps how many triages run at once. Submissions beyond the
+// cap are accepted and wait in StatusPending for a free slot, critical alerts
+// first. n <= 0 means unbounded.
+func WithMaxConcurrent(n int) ServiceOption {
+	return func(s *Service) {
+		s.maxConcurrent = n
+	}
+}
+
+// WithPriorityAging sets how long a pending triage waits before it outranks a
+// new triage one severity band higher. d <= 0 keeps DefaultPriorityAging.
+func WithPriorityAging(d time.Duration) ServiceOption {
+	return func(s *Service) {
+		if d > 0 {
+			s.aging = d
+		}
+	}
+}
+
+// WithNoiseDowngrade runs alerts whose noise score over window is at least
+// threshold on a reduced tool call and token budget. Scores are refreshed by
+// RunNoiseScorer. threshold <= 0 disables the downgrade; window <= 0 keeps
+// DefaultNoiseWindow.
+func WithNoiseDowngrade(threshold float64, window time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.noiseThreshold = threshold
+		if window > 0 {
+			s.noiseWindow = window
+		}
+	}
 }
 
 // NewService creates a new triage service. Metrics and notifier may be nil.
-func NewService(store Store, engine *Engine, logger log.Logger, metrics *Metrics, notifier Notifier, tp trace.TracerProvider) *Service {
+func NewService(store Store, engine Engine, logger log.Logger, metrics *Metrics, notifier Notifier, tp trace.TracerProvider, opts ...ServiceOption) *Service {
 	if notifier == nil {
 		notifier = nopNotifier{}
 	}
-	return &Service{
-		store:    store,
-		engine:   engine,
-		logger:   logger,
-		metrics:  metrics,
-		notifier: notifier,
-		tracer:   tp.Tracer("github.com/linnemanlabs/vigil/internal/triage"),
+	s := &Service{
+		store:        store,
+		engine:       engine,
+		logger:       logger,
+		metrics:      metrics,
+		notifier:     notifier,
+		tracer:       tp.Tracer("github.com/linnemanlabs/vigil/internal/triage"),
+		aging:        DefaultPriorityAging,
+		storeBackoff: storeBaseBackoff,
+		noiseWindow:  DefaultNoiseWindow,
+		running:      make(map[string]context.CancelCauseFunc),
+		convBytes:    make(map[string]int64),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxConcurrent > 0 {
+		s.sched = newScheduler(s.maxConcurrent, s.aging)
+	}
+	return s
 }
 
-// Submit accepts an alert for triage, handling dedup and lifecycle.
-//
-//nolint:spancheck // triageSpan is ended in the runTriage goroutine via defer
+// Submit accepts an alert for triage, handling dedup and lifecycle. Each
+// decision is recorded in the decision log when one is configured.
 func (s *Service) Submit(ctx context.Context, al *alert.Alert) (*SubmitResult, error) {
+	sr, rule, err := s.submit(ctx, al)
+	if err != nil {
+		return nil, err
+	}
+	s.recordDecision(ctx, al, sr, rule)
+	return sr, nil
+}
+
+// submit is Submit without the decision log. rule names what decided the
+// outcome, see Decision.Rule.
+func (s *Service) submit(ctx context.Context, al *alert.Alert) (*SubmitResult, string, error) {
+	tenant := TenantFrom(ctx)
+
 	// skip resolved alerts
 	if al.Status != "firing" {
-		s.incSubmit("skipped_not_firing")
-		return &SubmitResult{Skipped: true, Reason: "not firing"}, nil
+		s.incSubmit(tenant, "skipped_not_firing")
+		return &SubmitResult{Skipped: true, Reason: "not firing"}, "", nil
+	}
+	// Dry runs are backfilled at their own pace and notify nobody, so they
+	// neither count towards nor take part in a storm.
+	stormed := s.storm != nil && !IsDryRun(ctx)
+	if stormed {
+		s.observeStorm(ctx, time.Now())
+	}
+
+	rule := s.filterRule(ctx, al)
+	if rule != nil && rule.Action == FilterSkip {
+		s.logger.Info(ctx, "triage skipped by filter rule",
+			"rule", rule.Name,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+		s.incSubmit(tenant, "skipped_filter")
+		return &SubmitResult{Skipped: true, Reason: "filtered: " + rule.Name}, rule.Name, nil
+	}
+	// A downgraded alert is triaged as a dry run: analysed and stored, but
+	// it notifies nobody and suggests no actions.
+	downgraded := rule != nil && rule.Action == FilterDowngrade
+	if downgraded {
+		ctx = WithDryRun(ctx)
+	}
+
+	profile, ok := s.tenants[tenant]
+	if !ok && s.profiles != nil {
+		profile = s.profiles.Resolve(al)
+	}
+	if profile != nil && profile.Skip {
+		s.logger.Info(ctx, "triage skipped by profile",
+			"profile", profile.Name,
+			"receiver", al.Receiver,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+		s.incSubmit(tenant, "skipped_profile")
+		return &SubmitResult{Skipped: true, Reason: "skipped by profile"}, profile.Name, nil
+	}
+
+	if sup := s.suppression(ctx, al, tenant); sup != nil {
+		reason := cmp.Or(sup.Reason, SuppressionSuppressed)
+		s.logger.Info(ctx, "triage skipped: "+reason,
+			"suppression_id", sup.ID,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+			"expires_at", sup.ExpiresAt,
+		)
+		s.incSubmit(tenant, "skipped_"+reason)
+		return &SubmitResult{Skipped: true, Reason: reason}, sup.ID, nil
+	}
+
+	mw := s.maintenanceWindow(al)
+	if mw != nil && mw.Mode == MaintenanceSkip {
+		s.logger.Info(ctx, "triage skipped: maintenance",
+			"window", mw.Name,
+			"source", mw.Source,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+		s.incSubmit(tenant, "skipped_maintenance")
+		return &SubmitResult{Skipped: true, Reason: "maintenance"}, mw.Name, nil
+	}
+
+	if reason := s.shedReason(); reason != "" {
+		s.logger.Warn(ctx, "triage shed: guardrail reached",
+			"guardrail", reason,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+		s.incSubmit(tenant, "shed_"+reason)
+		return &SubmitResult{Skipped: true, Reason: "shed: " + reason}, reason, nil
+	}
+
+	var stormKey string
+	if stormed && !downgraded {
+		key, rep, first, ok := s.storm.join(tenant, al)
+		if ok && !first {
+			s.logger.Info(ctx, "triage skipped: storm grouped",
+				"group", key,
+				"representative_id", rep,
+				"alert", al.Labels["alertname"],
+				"fingerprint", al.Fingerprint,
+			)
+			s.incSubmit(tenant, "skipped_storm")
+			return &SubmitResult{ID: rep, Skipped: true, Reason: "storm: grouped"}, key, nil
+		}
+		if ok {
+			stormKey = key
+		}
+	}
+
+	id := ulid.Make().String()
+	now := time.Now()
+	received := al.ReceivedAt
+	if received.IsZero() {
+		received = now
+	}
+	md := s.enrich(al)
+	result := &Result{
+		ID:           id,
+		Fingerprint:  al.Fingerprint,
+		Status:       StatusPending,
+		Alert:        al.Labels["alertname"],
+		Severity:     al.Labels["severity"],
+		Summary:      al.Annotations["summary"],
+		GeneratorURL: al.GeneratorURL,
+		Labels:       al.Labels,
+		Annotations:  al.Annotations,
+		ReceivedAt:   received,
+		CreatedAt:    now,
+		TenantID:     tenant,
+		Metadata:     md,
+		DryRun:       IsDryRun(ctx),
 	}
 
 	// dedup: skip if already pending or in progress
-	if existing, ok, err := s.store.GetByFingerprint(ctx, al.Fingerprint); err != nil {
-		return nil, err
-	} else if ok && (existing.Status == StatusPending || existing.Status == StatusInProgress) {
+	existing, created, err := s.store.CreateIfNotActive(ctx, result)
+	if stormKey != "" {
+		switch {
+		case err != nil:
+			s.storm.release(tenant, stormKey)
+		case created:
+			s.storm.represent(tenant, stormKey, id, true)
+		default:
+			s.storm.represent(tenant, stormKey, existing.ID, false)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if !created {
 		s.logger.Info(ctx, "triage skipped: active triage exists",
 			"fingerprint", al.Fingerprint,
 			"alert", al.Labels["alertname"],
 			"existing_id", existing.ID,
 			"existing_status", existing.Status,
 		)
-		s.incSubmit("skipped_duplicate")
-		return &SubmitResult{Skipped: true, Reason: "duplicate"}, nil
+		s.incSubmit(tenant, "skipped_duplicate")
+		s.audit(ctx, existing.ID, tenant, AuditDuplicate, apiActor(ctx), "")
+		return &SubmitResult{ID: existing.ID, Skipped: true, Reason: "duplicate"}, "", nil
 	}
+	s.observePhase(PhaseIntake, now.Sub(received).Seconds())
 
-	id := ulid.Make().String()
-	result := &Result{
-		ID:          id,
-		Fingerprint: al.Fingerprint,
-		Status:      StatusPending,
-		Alert:       al.Labels["alertname"],
-		Severity:    al.Labels["severity"],
-		Summary:     al.Annotations["summary"],
-		CreatedAt:   time.Now(),
+	var opts []RunOption
+	if md != nil {
+		opts = append(opts, withMetadata(md))
 	}
-
-	if err := s.store.Put(ctx, result); err != nil {
-		return nil, err
+	if downgraded {
+		s.logger.Info(ctx, "triage downgraded to a dry run by filter rule",
+			"rule", rule.Name,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+	}
+	if mw != nil {
+		s.logger.Info(ctx, "triage in lightweight mode: maintenance",
+			"window", mw.Name,
+			"source", mw.Source,
+			"alert", al.Labels["alertname"],
+			"fingerprint", al.Fingerprint,
+		)
+		opts = append(opts, WithBudget(noisyBudget), withMaintenance(mw))
+	}
+	var detail string
+	if result.DryRun {
+		detail = "dry run"
+	}
+	s.audit(ctx, id, tenant, AuditSubmitted, apiActor(ctx), detail)
+	s.start(ctx, id, al, now, profile, opts...)
+
+	s.incSubmit(tenant, "accepted")
+	switch {
+	case downgraded:
+		return &SubmitResult{ID: id}, rule.Name, nil
+	case mw != nil:
+		return &SubmitResult{ID: id}, mw.Name, nil
+	case profile != nil:
+		return &SubmitResult{ID: id}, profile.Name, nil
 	}
+	return &SubmitResult{ID: id}, "", nil
+}
 
+// start runs a created triage in the background under a new root span linked
+// to the span in ctx.
+//
+//nolint:spancheck // triageSpan is ended in the runTriage goroutine via defer
+func (s *Service) start(ctx context.Context, id string, al *alert.Alert, now time.Time, profile *Profile, opts ...RunOption) {
 	// Start a new root span for the triage, linked back to the HTTP request span.
 	// We use a fresh context (not WithoutCancel) so that the pyroscope tracer
 	// wrapper treats this as a genuine root span and adds pyroscope.profile.id.
-	// The logger is the only value we carry forward.
+	// The logger, tenant and dry run mark are the only values we carry forward.
 	httpSpanCtx := trace.SpanFromContext(ctx).SpanContext()
+	base := WithTenant(log.WithContext(context.Background(), log.FromContext(ctx)), TenantFrom(ctx))
+	if IsDryRun(ctx) {
+		base = WithDryRun(base)
+	}
 	triageCtx, triageSpan := s.tracer.Start(
-		log.WithContext(context.Background(), log.FromContext(ctx)),
+		base,
 		"triage",
 		trace.WithNewRoot(),
 		trace.WithLinks(trace.Link{SpanContext: httpSpanCtx}),
@@ -104,51 +420,283 @@ func (s *Service) Submit(ctx context.Context, al *alert.Alert) (*SubmitResult, e
 			attribute.String("vigil.triage.severity", al.Labels["severity"]),
 		),
 	)
+	if profile != nil {
+		triageSpan.SetAttributes(attribute.String("vigil.triage.profile", profile.Name))
+	}
+	if tenant := TenantFrom(ctx); tenant != "" {
+		triageSpan.SetAttributes(attribute.String("vigil.tenant.id", tenant))
+	}
 
-	go s.runTriage(triageCtx, id, al, triageSpan)
+	// The engine runs under its own cancelable context so Cancel can stop it
+	// while the final store write and notification still go through.
+	runCtx, cancel := context.WithCancelCause(triageCtx)
+	s.mu.Lock()
+	s.running[id] = cancel
+	s.mu.Unlock()
+	if s.metrics != nil {
+		s.metrics.InFlight.Inc()
+	}
+
+	go s.runTriage(triageCtx, runCtx, id, al, now, profile, triageSpan, opts)
+}
 
-	s.incSubmit("accepted")
-	return &SubmitResult{ID: id}, nil
+func (s *Service) incSubmit(tenant, result string) {
+	if s.metrics != nil {
+		s.metrics.SubmitsTotal.WithLabelValues(result, tenant).Inc()
+	}
 }
 
-func (s *Service) incSubmit(result string) {
+func (s *Service) observePhase(phase string, seconds float64) {
 	if s.metrics != nil {
-		s.metrics.SubmitsTotal.WithLabelValues(result).Inc()
+		s.metrics.PhaseDuration.WithLabelValues(phase).Observe(seconds)
 	}
 }
 
-// Get retrieves a triage result by ID.
+// observeDelivered records the notify phase of r, whose notification was
+// delivered at the given time, and the alert's latency end to end.
+func (s *Service) observeDelivered(r *Result, at time.Time) {
+	if !r.CompletedAt.IsZero() {
+		s.observePhase(PhaseNotify, at.Sub(r.CompletedAt).Seconds())
+	}
+	if s.metrics != nil && !r.ReceivedAt.IsZero() {
+		s.metrics.AlertLatency.Observe(at.Sub(r.ReceivedAt).Seconds())
+	}
+}
+
+// Get retrieves a triage result by ID. Triages of other tenants are not
+// found.
 func (s *Service) Get(ctx context.Context, id string) (*Result, bool, error) {
-	return s.store.Get(ctx, id)
+	r, ok, err := s.store.Get(ctx, id)
+	if err != nil || !ok || !owns(ctx, r) {
+		return nil, false, err
+	}
+	return r, true, nil
 }
 
-func (s *Service) runTriage(ctx context.Context, id string, al *alert.Alert, triageSpan trace.Span) {
+// List returns the tenant's triage results matching the filter, newest first.
+func (s *Service) List(ctx context.Context, f ListFilter) ([]*Result, error) {
+	f.Tenant = TenantFrom(ctx)
+	return s.store.List(ctx, f)
+}
+
+// ErrTriageActive is returned by Delete for a triage that has not finished.
+var ErrTriageActive = errors.New("triage is still running")
+
+// Delete soft-deletes a finished triage, reporting false if there is no live
+// triage with the ID. Deleted triages disappear from the API until restored
+// or purged.
+func (s *Service) Delete(ctx context.Context, id string) (bool, error) {
+	r, ok, err := s.Get(ctx, id)
+	if err != nil || !ok {
+		return false, err
+	}
+	if !r.Status.IsTerminal() {
+		return false, ErrTriageActive
+	}
+	ok, err = s.store.Delete(ctx, id, time.Now())
+	if err == nil && ok {
+		s.logger.Info(ctx, "triage deleted", "triage_id", id, "alert", r.Alert)
+		s.audit(ctx, id, r.TenantID, AuditDeleted, apiActor(ctx), "")
+	}
+	return ok, err
+}
+
+// Restore undoes Delete for a triage that has not been purged yet. It backs
+// an admin route and is not scoped to a tenant.
+func (s *Service) Restore(ctx context.Context, id string) (bool, error) {
+	ok, err := s.store.Restore(ctx, id)
+	if err == nil && ok {
+		s.logger.Info(ctx, "triage restored", "triage_id", id)
+		s.auditRestore(ctx, id)
+	}
+	return ok, err
+}
+
+// ErrCancelled is the cause recorded on a triage stopped through Cancel.
+var ErrCancelled = errors.New("cancelled by operator")
+
+// ErrTriageNotRunning is returned by Cancel for a triage that has finished or
+// is not running in this process.
+var ErrTriageNotRunning = errors.New("triage is not running")
+
+// Cancel stops a pending or in-progress triage. The engine notices at the next
+// turn boundary, or sooner if it is waiting on the LLM, and the triage ends in
+// StatusError. Cancel reports false if there is no triage with the ID.
+func (s *Service) Cancel(ctx context.Context, id string) (bool, error) {
+	r, ok, err := s.Get(ctx, id)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.running[id]
+	s.mu.Unlock()
+	if !ok {
+		return false, ErrTriageNotRunning
+	}
+	cancel(ErrCancelled)
+	s.logger.Info(ctx, "triage cancel requested", "triage_id", id)
+	s.audit(ctx, id, r.TenantID, AuditCancelled, apiActor(ctx), "")
+	return true, nil
+}
+
+// Tools describes the tools triages of the tenant in ctx can call, from the
+// tenant's own engine if it has one.
+func (s *Service) Tools(ctx context.Context) ([]tools.ToolInfo, error) {
+	engine := s.engine
+	if p := s.tenants[TenantFrom(ctx)]; p != nil && p.Engine != nil {
+		engine = p.Engine
+	}
+	return engine.Tools(), nil
+}
+
+// RunPurger permanently removes triages deleted more than retention ago,
+// checking every interval until ctx is done.
+func (s *Service) RunPurger(ctx context.Context, retention, interval time.Duration) {
+	s.lead(ctx, "purge", func(ctx context.Context) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			s.purge(ctx, retention)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	})
+}
+
+func (s *Service) purge(ctx context.Context, retention time.Duration) {
+	n, err := s.store.Purge(ctx, time.Now().Add(-retention))
+	switch {
+	case err != nil && ctx.Err() == nil:
+		s.logger.Error(ctx, err, "failed to purge deleted triages")
+	case n > 0:
+		s.logger.Info(ctx, "purged deleted triages", "count", n, "retention", retention)
+	}
+}
+
+func (s *Service) runTriage(ctx, runCtx context.Context, id string, al *alert.Alert, enqueued time.Time, profile *Profile, triageSpan trace.Span, extra []RunOption) {
 	defer triageSpan.End()
+	defer s.finish(id)
 
 	L := s.logger.With("triage_id", id, "alert", al.Labels["alertname"])
 
-	result, ok, err := s.store.Get(ctx, id)
-	if err != nil || !ok {
+	engine, notifier := s.engine, s.notifier
+	batch := s.batched(al.Labels["severity"])
+	if batch {
+		engine = s.batchEngine
+	}
+	runOpts := slices.Clone(extra)
+	if profile != nil {
+		L = L.With("profile", profile.Name)
+		runOpts = append(runOpts, WithInstructions(profile.Instructions))
+		if profile.Notifier != nil {
+			notifier = profile.Notifier
+		}
+		if profile.Engine != nil {
+			engine, batch = profile.Engine, false
+		}
+		if profile.Budget != (Budget{}) {
+			runOpts = append(runOpts, WithBudget(profile.Budget))
+		}
+		if profile.Strategy != "" {
+			runOpts = append(runOpts, WithStrategy(profile.Strategy))
+		}
+	}
+	notifyMin, notifyRule := s.notifyThreshold(profile)
+	if notifyMin > 0 {
+		runOpts = append(runOpts, withVerdict())
+	}
+	if s.actions != nil && !IsDryRun(ctx) {
+		runOpts = append(runOpts, withActions(s.actions.Prompt()))
+	}
+	if s.noiseThreshold > 0 {
+		if score := s.noiseScore(al.Labels["alertname"]); score >= s.noiseThreshold {
+			L.Info(ctx, "noisy alert, running on reduced budget", "noise_score", score, "threshold", s.noiseThreshold)
+			triageSpan.SetAttributes(attribute.Float64("vigil.triage.noise_score", score))
+			runOpts = append(runOpts, WithBudget(noisyBudget))
+		}
+	}
+	// Annotations come last so an alert rule can buy back depth the noise
+	// downgrade took away.
+	b, invalid := annotationBudget(al)
+	if len(invalid) > 0 {
+		L.Warn(ctx, "ignoring non-integer budget annotations", "annotations", invalid)
+	}
+	if b != (Budget{}) {
+		L.Info(ctx, "budget overridden by alert annotations", "tool_calls", b.ToolCalls, "input_tokens", b.InputTokens, "output_tokens", b.OutputTokens)
+		triageSpan.SetAttributes(attribute.Bool("vigil.triage.budget_override", true))
+		runOpts = append(runOpts, withBudgetOverride(b))
+	}
+
+	if strings.EqualFold(strings.TrimSpace(al.Annotations[AnnotationToolCache]), "off") {
+		runCtx = tools.WithoutCache(runCtx)
+		triageSpan.SetAttributes(attribute.Bool("vigil.triage.tool_cache_bypass", true))
+	}
+
+	// Wait for others of the alertname before taking a run slot, so a
+	// queued family does not hold slots other alerts could use.
+	if s.families != nil {
+		release := s.waitForFamily(runCtx, L, al.Labels["alertname"], triageSpan)
+		defer release()
+	}
+	if batch {
+		L.Info(ctx, "triage running in batch mode")
+		triageSpan.SetAttributes(attribute.Bool("vigil.triage.batch", true))
+	} else if s.sched != nil && s.waitForSlot(runCtx, al.Labels["severity"], enqueued, triageSpan) {
+		defer s.sched.release()
+	}
+
+	result, err := s.getResult(ctx, L, id)
+	if err != nil {
 		L.Error(ctx, err, "failed to fetch result for triage")
 		triageSpan.RecordError(err)
 		triageSpan.SetStatus(codes.Error, "failed to fetch result")
-		s.persistError(ctx, L, id, al.Fingerprint)
+		// A missing result was deleted while queued; writing one back would
+		// resurrect it.
+		if !errors.Is(err, errResultNotFound) {
+			s.persistError(ctx, L, persistStageFetch, id, nil, al, enqueued)
+		}
 		return
 	}
 
+	if result.DryRun {
+		L.Info(ctx, "triage running as a dry run")
+		triageSpan.SetAttributes(attribute.Bool("vigil.triage.dry_run", true))
+	}
 	result.Status = StatusInProgress
-	if err := s.store.Put(ctx, result); err != nil {
+	result.StartedAt = time.Now()
+	if sc := triageSpan.SpanContext(); sc.HasTraceID() {
+		result.TraceID = sc.TraceID().String()
+	}
+	s.observePhase(PhaseQueue, result.StartedAt.Sub(enqueued).Seconds())
+	if err := s.withStoreRetry(ctx, L, "put in_progress", func(ctx context.Context) error {
+		return s.store.Put(ctx, result)
+	}); err != nil {
 		L.Error(ctx, err, "failed to update status to in_progress")
 		triageSpan.RecordError(err)
 		triageSpan.SetStatus(codes.Error, "failed to update status")
-		s.persistError(ctx, L, id, al.Fingerprint)
+		s.persistError(ctx, L, persistStageStart, id, result, al, enqueued)
 		return
 	}
-
-	rr := s.engine.Run(ctx, id, al, s.buildOnTurn(ctx, id))
+	s.audit(ctx, id, result.TenantID, AuditStarted, ActorSystem, "")
+
+	// Streamed text is saved as it arrives so a crash mid-response leaves it
+	// behind and readers of an in-progress triage see it.
+	runOpts = append(runOpts, WithPartial(func(_ context.Context, text string) error {
+		return s.store.SavePartial(ctx, id, text)
+	}))
+	rr := engine.Run(runCtx, id, al, s.buildOnTurn(ctx, id), runOpts...)
+	s.observePhase(PhaseRun, rr.Duration)
+	if errors.Is(context.Cause(runCtx), ErrCancelled) {
+		triageSpan.SetAttributes(attribute.Bool("vigil.triage.cancelled", true))
+	}
 
 	result.Status = rr.Status
 	result.Analysis = rr.Analysis
+	result.Notes = rr.Notes
 	result.ToolsUsed = rr.ToolsUsed
 	result.CompletedAt = rr.CompletedAt
 	result.Duration = rr.Duration
@@ -156,12 +704,59 @@ func (s *Service) runTriage(ctx context.Context, id string, al *alert.Alert, tri
 	result.ToolTime = rr.ToolTime
 	result.TokensIn = rr.InputTokensUsed
 	result.TokensOut = rr.OutputTokensUsed
+	result.TokensThinking = rr.ThinkingTokensUsed
 	result.ToolCalls = rr.ToolCalls
+	result.Redactions = rr.Redactions
+	result.Strategy = rr.Strategy
+	result.Provider = rr.Provider
 	result.SystemPrompt = rr.SystemPrompt
 	result.Model = rr.Model
+	if s.actions != nil && result.Status == StatusComplete && !result.DryRun {
+		s.suggestActions(ctx, L, result)
+	}
+	if s.wantsIssue(result) {
+		s.openIssue(ctx, L, result)
+	}
+	// A dry run is stored like any other triage, but nobody hears of it.
+	if result.DryRun {
+		notifier = nopNotifier{}
+	}
+	// A storm's representatives are notified once, in its summary.
+	if s.storm != nil && s.storm.silenced(id) {
+		L.Info(ctx, "notification left to the storm summary")
+		notifier = nopNotifier{}
+	}
+
+	// A held result is stored as usual but never reaches the notifier or
+	// the outbox.
+	var gate string
+	if _, nop := notifier.(nopNotifier); !nop && notifyMin > 0 {
+		var send bool
+		send, gate = s.gateNotification(ctx, L, result, notifyMin, notifyRule)
+		if !send {
+			notifier = nopNotifier{}
+		}
+	}
 
-	if err := s.store.Put(ctx, result); err != nil {
-		L.Error(ctx, err, "failed to persist triage result")
+	var notification *Notification
+	if _, nop := notifier.(nopNotifier); !nop && s.outbox != nil {
+		now := time.Now()
+		notification = &Notification{
+			TriageID:    id,
+			TenantID:    result.TenantID,
+			Receiver:    al.Receiver,
+			Status:      NotificationPending,
+			NextAttempt: now.Add(outboxInlineGrace),
+			CreatedAt:   now,
+		}
+	}
+	if err := s.putResult(ctx, L, result, notification); err != nil {
+		s.persistError(ctx, L, persistStageResult, id, result, al, enqueued)
+	} else {
+		s.auditFinish(ctx, result)
+		if gate != "" {
+			s.audit(ctx, id, result.TenantID, AuditNotifyGate, ActorSystem, gate)
+		}
 	}
 
 	triageSpan.SetAttributes(
@@ -170,6 +765,9 @@ func (s *Service) runTriage(ctx context.Context, id string, al *alert.Alert, tri
 		attribute.Int("gen_ai.usage.output_tokens", rr.OutputTokensUsed),
 		attribute.String("vigil.triage.status", string(rr.Status)),
 		attribute.Int("vigil.triage.tool_calls", rr.ToolCalls),
+		attribute.Int("vigil.triage.redactions", rr.Redactions),
+		attribute.String("vigil.triage.strategy", string(rr.Strategy)),
+		attribute.String("vigil.triage.provider", rr.Provider),
 		attribute.String("vigil.triage.system_prompt", rr.SystemPrompt),
 	)
 	if rr.Status == StatusFailed || rr.Status == StatusError {
@@ -178,12 +776,16 @@ func (s *Service) runTriage(ctx context.Context, id string, al *alert.Alert, tri
 		triageSpan.SetStatus(codes.Ok, "")
 	}
 
-	if err := s.notifier.Send(ctx, result); err != nil {
-		L.Warn(ctx, "notification failed", "err", err)
-	} else if _, nop := s.notifier.(nopNotifier); nop {
-		L.Debug(ctx, "notification skipped, no notifier configured")
-	} else {
-		L.Info(ctx, "notification sent", "triage_id", id)
+	// Notifiers get the full conversation so they can draw on tool results;
+	// the stored result keeps it out of the metadata write above.
+	notice := *result
+	notice.Conversation = rr.Conversation
+	err = s.sendNotification(ctx, L, notifier, &notice)
+	if _, nop := notifier.(nopNotifier); err == nil && !nop {
+		s.observeDelivered(result, time.Now())
+	}
+	if notification != nil {
+		s.recordDelivery(ctx, L, notification, err)
 	}
 
 	L.Info(ctx, "triage complete",
@@ -196,64 +798,145 @@ func (s *Service) runTriage(ctx context.Context, id string, al *alert.Alert, tri
 		"tool_calls", rr.ToolCalls,
 		"model", rr.Model,
 	)
+
+	s.observeIncident(ctx, al, result)
+}
+
+// finish forgets a triage's cancel func and conversation size once it is no
+// longer running.
+func (s *Service) finish(id string) {
+	s.mu.Lock()
+	cancel := s.running[id]
+	delete(s.running, id)
+	n := s.convBytes[id]
+	delete(s.convBytes, id)
+	s.convTotal -= n
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel(nil)
+	}
+	if s.metrics != nil {
+		s.metrics.InFlight.Dec()
+		s.metrics.ConversationBytes.Sub(float64(n))
+	}
+}
+
+// putResult persists the finished result under a store.put span, so a slow
+// or failing write shows up in the triage trace. A non-nil n is queued in
+// the outbox with it. Failed writes are retried; the last error is returned.
+func (s *Service) putResult(ctx context.Context, logger log.Logger, result *Result, n *Notification) error {
+	ctx, span := s.tracer.Start(ctx, "store.put", trace.WithAttributes(
+		attribute.String("vigil.triage.id", result.ID),
+		attribute.String("vigil.triage.status", string(result.Status)),
+	))
+	defer span.End()
+
+	put := s.store.Put
+	if n != nil {
+		put = func(ctx context.Context, r *Result) error { return s.outbox.PutNotifying(ctx, r, n) }
+	}
+	if err := s.withStoreRetry(ctx, logger, "put result", func(ctx context.Context) error {
+		return put(ctx, result)
+	}); err != nil {
+		logger.Error(ctx, err, "failed to persist triage result")
+		span.SetAttributes(attribute.String("vigil.store.outcome", "error"))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetAttributes(attribute.String("vigil.store.outcome", "ok"))
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// sendNotification delivers the result under a notify.send span. Outcome is
+// ok, error, or skipped when no notifier is configured.
+func (s *Service) sendNotification(ctx context.Context, logger log.Logger, notifier Notifier, result *Result) error {
+	ctx, span := s.tracer.Start(ctx, "notify.send", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("vigil.triage.id", result.ID),
+		attribute.String("vigil.notify.notifier", fmt.Sprintf("%T", notifier)),
+	))
+	defer span.End()
+
+	if _, nop := notifier.(nopNotifier); nop {
+		logger.Debug(ctx, "notification skipped, no notifier configured")
+		span.SetAttributes(attribute.String("vigil.notify.outcome", "skipped"))
+		return nil
+	}
+	if err := notifier.Send(ctx, result); err != nil {
+		logger.Warn(ctx, "notification failed", "err", err)
+		span.SetAttributes(attribute.String("vigil.notify.outcome", "error"))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	logger.Info(ctx, "notification sent", "triage_id", result.ID)
+	span.SetAttributes(attribute.String("vigil.notify.outcome", "ok"))
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// waitForSlot blocks until the scheduler grants a run slot or ctx ends,
+// recording queue depth and wait time for the alert's severity band. It
+// reports whether a slot is held and must be released.
+func (s *Service) waitForSlot(ctx context.Context, severity string, enqueued time.Time, span trace.Span) bool {
+	band := SeverityBand(severity)
+	if s.metrics != nil {
+		s.metrics.QueueDepth.WithLabelValues(band).Inc()
+	}
+	granted := s.sched.acquire(ctx, band, enqueued)
+	wait := time.Since(enqueued).Seconds()
+	if s.metrics != nil {
+		s.metrics.QueueDepth.WithLabelValues(band).Dec()
+		s.metrics.QueueWait.WithLabelValues(band).Observe(wait)
+	}
+	span.SetAttributes(
+		attribute.String("vigil.triage.severity_band", band),
+		attribute.Float64("vigil.triage.queue_wait_seconds", wait),
+	)
+	return granted
 }
 
 // buildOnTurn returns a TurnCallback that persists each turn incrementally.
 // For assistant turns it calls AppendTurn and stashes the returned messageID.
-// For user turns (tool results) it calls AppendTurn for the message, then
-// AppendToolCalls using the stashed assistant messageID and turn.
+// User turns answering one (tool results) are written with the assistant
+// turn's tool calls through AppendTurnWithToolCalls, other turns through
+// AppendTurn.
 func (s *Service) buildOnTurn(ctx context.Context, triageID string) TurnCallback {
-	L := s.logger.With("triage_id", triageID)
-
 	var lastAssistantMsgID int
 	var lastAssistantSeq int
 	var lastAssistantTurn *Turn
 
 	return func(_ context.Context, seq int, turn *Turn) error {
-		msgID, err := s.store.AppendTurn(ctx, triageID, seq, turn)
-		if err != nil {
-			return err
-		}
+		s.addConversation(triageID, turnBytes(turn))
 
-		if turn.Role == "assistant" {
-			lastAssistantMsgID = msgID
-			lastAssistantSeq = seq
-			lastAssistantTurn = turn
-			return nil
+		stored := turn
+		if s.redactThinking {
+			stored = redactThinking(turn)
 		}
-
 		// user turn with tool results - attach tool_calls to the preceding assistant message
-		if lastAssistantTurn == nil {
-			return nil
-		}
-
-		toolResults := make(map[string]*ContentBlock)
-		for i := range turn.Content {
-			block := &turn.Content[i]
-			if block.Type == "tool_result" {
-				toolResults[block.ToolUseID] = block
+		if turn.Role != "assistant" && lastAssistantTurn != nil {
+			toolResults := make(map[string]*ContentBlock)
+			for i := range turn.Content {
+				block := &turn.Content[i]
+				if block.Type == "tool_result" {
+					toolResults[block.ToolUseID] = block
+				}
 			}
+			_, err := s.store.AppendTurnWithToolCalls(ctx, triageID, seq, stored, lastAssistantMsgID, lastAssistantSeq, lastAssistantTurn, toolResults)
+			lastAssistantTurn = nil
+			return err
 		}
 
-		if err := s.store.AppendToolCalls(ctx, triageID, lastAssistantMsgID, lastAssistantSeq, lastAssistantTurn, toolResults); err != nil {
-			L.Warn(ctx, "failed to persist tool calls", "seq", seq, "err", err)
+		msgID, err := s.store.AppendTurn(ctx, triageID, seq, stored)
+		if err != nil {
+			return err
+		}
+		if turn.Role == "assistant" {
+			lastAssistantMsgID = msgID
+			lastAssistantSeq = seq
+			lastAssistantTurn = stored
 		}
-
-		lastAssistantTurn = nil
 		return nil
 	}
 }
-
-// persistError attempts to set a triage result to StatusError. This is
-// best-effort: if the store write fails we log and move on.
-func (s *Service) persistError(ctx context.Context, logger log.Logger, id, fingerprint string) {
-	r := &Result{
-		ID:          id,
-		Fingerprint: fingerprint,
-		Status:      StatusError,
-		CompletedAt: time.Now(),
-	}
-	if err := s.store.Put(ctx, r); err != nil {
-		logger.Warn(ctx, "failed to persist error status", "err", err)
-	}
-}
@@ -2,16 +2,26 @@ package triage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace/noop"
 
 	"github.com/linnemanlabs/go-core/log"
 	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // mockStore implements Store for testing.
@@ -19,14 +29,18 @@ type mockStore struct {
 	mu      sync.Mutex
 	results map[string]*Result
 	seen    map[string]*Result
+	deleted map[string]time.Time
 	putErr  error
 	getErr  error
+
+	partials []string // every SavePartial text, in order
 }
 
 func newMockStore() *mockStore {
 	return &mockStore{
 		results: make(map[string]*Result),
 		seen:    make(map[string]*Result),
+		deleted: make(map[string]time.Time),
 	}
 }
 
@@ -37,20 +51,20 @@ func (m *mockStore) Get(_ context.Context, id string) (*Result, bool, error) {
 		return nil, false, m.getErr
 	}
 	r, ok := m.results[id]
-	if !ok {
+	if _, gone := m.deleted[id]; !ok || gone {
 		return nil, false, nil
 	}
 	cp := *r
 	return &cp, true, nil
 }
 
-func (m *mockStore) GetByFingerprint(_ context.Context, fp string) (*Result, bool, error) {
+func (m *mockStore) GetByFingerprint(ctx context.Context, fp string) (*Result, bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if m.getErr != nil {
 		return nil, false, m.getErr
 	}
-	r, ok := m.seen[fp]
+	r, ok := m.seen[mockSeenKey(TenantFrom(ctx), fp)]
 	if !ok {
 		return nil, false, nil
 	}
@@ -65,13 +79,14 @@ func (m *mockStore) Put(_ context.Context, r *Result) error {
 		return m.putErr
 	}
 	cp := *r
+	cp.Partial = ""
 	if cp.Conversation == nil {
 		if existing, ok := m.results[r.ID]; ok && existing.Conversation != nil {
 			cp.Conversation = existing.Conversation
 		}
 	}
 	m.results[r.ID] = &cp
-	m.seen[r.Fingerprint] = &cp
+	m.seen[mockSeenKey(r.TenantID, r.Fingerprint)] = &cp
 	return nil
 }
 
@@ -90,10 +105,167 @@ func (m *mockStore) AppendTurn(_ context.Context, triageID string, seq int, turn
 	return seq, nil
 }
 
+func (m *mockStore) CreateIfNotActive(_ context.Context, r *Result) (*Result, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.getErr != nil {
+		return nil, false, m.getErr
+	}
+	if m.putErr != nil {
+		return nil, false, m.putErr
+	}
+	if existing, ok := m.seen[mockSeenKey(r.TenantID, r.Fingerprint)]; ok && (existing.Status == StatusPending || existing.Status == StatusInProgress) {
+		cp := *existing
+		return &cp, false, nil
+	}
+	cp := *r
+	m.results[r.ID] = &cp
+	m.seen[mockSeenKey(r.TenantID, r.Fingerprint)] = &cp
+	return nil, true, nil
+}
+
+func (m *mockStore) ClaimAction(_ context.Context, id string, n int, actor string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.putErr != nil {
+		return false, m.putErr
+	}
+	r, ok := m.results[id]
+	if !ok || n < 0 || n >= len(r.Actions) || r.Actions[n].Executed() {
+		return false, nil
+	}
+	r.Actions = slices.Clone(r.Actions)
+	r.Actions[n].ExecutedAt, r.Actions[n].ExecutedBy = at, actor
+	return true, nil
+}
+
+func (m *mockStore) RecordAction(_ context.Context, id string, n int, output, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.putErr != nil {
+		return m.putErr
+	}
+	if r, ok := m.results[id]; ok && n >= 0 && n < len(r.Actions) {
+		r.Actions = slices.Clone(r.Actions)
+		r.Actions[n].Output, r.Actions[n].Error = output, errMsg
+	}
+	return nil
+}
+
 func (m *mockStore) AppendToolCalls(_ context.Context, _ string, _, _ int, _ *Turn, _ map[string]*ContentBlock) error {
 	return nil
 }
 
+func (m *mockStore) AppendTurnWithToolCalls(ctx context.Context, triageID string, seq int, turn *Turn, _, _ int, _ *Turn, _ map[string]*ContentBlock) (int, error) {
+	return m.AppendTurn(ctx, triageID, seq, turn)
+}
+
+// mockSeenKey scopes fingerprints to tenants like the real stores.
+func mockSeenKey(tenant, fp string) string {
+	if tenant == "" {
+		return fp
+	}
+	return tenant + "\x00" + fp
+}
+
+func (m *mockStore) SavePartial(_ context.Context, triageID, text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.partials = append(m.partials, text)
+	if r, ok := m.results[triageID]; ok {
+		r.Partial = text
+	}
+	return nil
+}
+
+func (m *mockStore) List(_ context.Context, f ListFilter) ([]*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var out []*Result
+	for id, r := range m.results {
+		if _, gone := m.deleted[id]; !gone && f.Matches(r) {
+			cp := *r
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) Search(_ context.Context, q SearchQuery) ([]*SearchHit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var out []*SearchHit
+	for id, r := range m.results {
+		if _, gone := m.deleted[id]; !gone && (ListFilter{Tenant: q.Tenant}).Matches(r) {
+			if hit := MatchSearch(r, q.Text); hit != nil {
+				out = append(out, hit)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *mockStore) Stats(_ context.Context, q StatsQuery) (*Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var live []*Result
+	for id, r := range m.results {
+		if _, gone := m.deleted[id]; !gone {
+			live = append(live, r)
+		}
+	}
+	return ComputeStats(live, q), nil
+}
+
+func (m *mockStore) Delete(_ context.Context, id string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.putErr != nil {
+		return false, m.putErr
+	}
+	if _, ok := m.results[id]; !ok {
+		return false, nil
+	}
+	if _, gone := m.deleted[id]; gone {
+		return false, nil
+	}
+	m.deleted[id] = at
+	return true, nil
+}
+
+func (m *mockStore) Restore(_ context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, gone := m.deleted[id]; !gone {
+		return false, nil
+	}
+	delete(m.deleted, id)
+	return true, nil
+}
+
+func (m *mockStore) Purge(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for id, at := range m.deleted {
+		if at.Before(before) {
+			delete(m.results, id)
+			delete(m.deleted, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
 // mockNotifier tracks Send calls for testing.
 type mockNotifier struct {
 	mu     sync.Mutex
@@ -183,6 +355,42 @@ func TestSubmit_DedupInProgress(t *testing.T) {
 	}
 }
 
+func TestSubmit_ConcurrentDedup(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(provider.release)
+	svc := NewService(store, NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider())
+
+	const n = 20
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for range n {
+		wg.Go(func() {
+			sr, err := svc.Submit(context.Background(), &alert.Alert{
+				Status:      "firing",
+				Fingerprint: "fp-race",
+				Labels:      map[string]string{"alertname": "Race"},
+			})
+			if err != nil {
+				t.Errorf("Submit: %v", err)
+				return
+			}
+			if !sr.Skipped {
+				accepted.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := accepted.Load(); got != 1 {
+		t.Errorf("accepted = %d, want exactly 1", got)
+	}
+}
+
 func TestSubmit_AllowsRetriageTerminalStatuses(t *testing.T) {
 	t.Parallel()
 
@@ -278,6 +486,95 @@ func TestGet_NotFound(t *testing.T) {
 	}
 }
 
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  Status
+		id      string
+		wantOK  bool
+		wantErr error
+	}{
+		{"complete", StatusComplete, "t-1", true, nil},
+		{"failed", StatusFailed, "t-1", true, nil},
+		{"pending", StatusPending, "t-1", false, ErrTriageActive},
+		{"in progress", StatusInProgress, "t-1", false, ErrTriageActive},
+		{"missing", StatusComplete, "nope", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMockStore()
+			store.results["t-1"] = &Result{ID: "t-1", Fingerprint: "fp-1", Status: tt.status}
+			svc := NewService(store, NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider())
+
+			ok, err := svc.Delete(context.Background(), tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			_, visible, _ := svc.Get(context.Background(), "t-1")
+			if visible == tt.wantOK {
+				t.Errorf("visible after delete = %v", visible)
+			}
+		})
+	}
+}
+
+func TestDelete_RestoreAndPurge(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	store.results["t-1"] = &Result{ID: "t-1", Fingerprint: "fp-1", Status: StatusComplete}
+	svc := NewService(store, NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider())
+	ctx := context.Background()
+
+	if ok, err := svc.Delete(ctx, "t-1"); err != nil || !ok {
+		t.Fatalf("Delete = %v, %v", ok, err)
+	}
+	if ok, err := svc.Restore(ctx, "t-1"); err != nil || !ok {
+		t.Fatalf("Restore = %v, %v", ok, err)
+	}
+	if _, ok, _ := svc.Get(ctx, "t-1"); !ok {
+		t.Fatal("restored triage not visible")
+	}
+
+	// A purge inside the retention window keeps the deleted triage restorable.
+	_, _ = svc.Delete(ctx, "t-1")
+	svc.purge(ctx, time.Hour)
+	if ok, _ := svc.Restore(ctx, "t-1"); !ok {
+		t.Fatal("triage purged before retention elapsed")
+	}
+
+	_, _ = svc.Delete(ctx, "t-1")
+	svc.purge(ctx, -time.Second)
+	if ok, _ := svc.Restore(ctx, "t-1"); ok {
+		t.Error("triage still restorable after purge")
+	}
+}
+
+func TestRunPurger_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(newMockStore(), NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunPurger(ctx, time.Hour, time.Hour)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunPurger did not return after cancel")
+	}
+}
+
 func TestSubmit_AsyncTriageCompletes(t *testing.T) {
 	t.Parallel()
 
@@ -311,6 +608,9 @@ func TestSubmit_AsyncTriageCompletes(t *testing.T) {
 			if r.Analysis != "done analyzing" {
 				t.Errorf("analysis = %q, want %q", r.Analysis, "done analyzing")
 			}
+			if r.StartedAt.Before(r.CreatedAt) || r.CompletedAt.Before(r.StartedAt) {
+				t.Errorf("created %v, started %v, completed %v; want them in order", r.CreatedAt, r.StartedAt, r.CompletedAt)
+			}
 			return
 		}
 		time.Sleep(10 * time.Millisecond)
@@ -318,6 +618,40 @@ func TestSubmit_AsyncTriageCompletes(t *testing.T) {
 	t.Fatal("triage did not complete within deadline")
 }
 
+func TestSubmit_SavesStreamedPartialText(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	text := strings.Repeat("b", 1500)
+	provider := &streamingProvider{chunk: 600, mockProvider: mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: text}},
+		StopReason: StopEnd,
+	}}}}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider())
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-partial",
+		Labels:      map[string]string{"alertname": "PartialTest"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitTerminal(t, store, sr.ID)
+
+	r, _, _ := store.Get(context.Background(), sr.ID)
+	store.mu.Lock()
+	partials := slices.Clone(store.partials)
+	store.mu.Unlock()
+	if len(partials) != 2 || partials[0] != text[:1200] || partials[1] != "" {
+		t.Errorf("saved %d partials, want the first 1200 bytes then a clear", len(partials))
+	}
+	if r.Partial != "" || r.Analysis != text {
+		t.Errorf("final result partial = %q, analysis %d bytes; want no partial and the full analysis", r.Partial, len(r.Analysis))
+	}
+}
+
 func TestSubmit_NotifiesOnCompletion(t *testing.T) {
 	t.Parallel()
 
@@ -364,6 +698,44 @@ func TestSubmit_NotifiesOnCompletion(t *testing.T) {
 	if notifier.last.Analysis != "notified analysis" {
 		t.Errorf("notifier result analysis = %q, want %q", notifier.last.Analysis, "notified analysis")
 	}
+	if notifier.last.Conversation == nil || len(notifier.last.Conversation.Turns) == 0 {
+		t.Error("expected notifier result to carry the conversation")
+	}
+}
+
+func TestSubmit_PhaseMetrics(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	provider := &mockProvider{
+		responses: []*LLMResponse{{
+			Content:    []ContentBlock{{Type: "text", Text: "analysis"}},
+			StopReason: StopEnd,
+		}},
+	}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), metrics, newMockNotifier(), noop.NewTracerProvider())
+
+	received := time.Now().Add(-time.Second)
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-phases",
+		Labels:      map[string]string{"alertname": "PhaseTest"},
+		ReceivedAt:  received,
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitForFinish(t, svc, sr.ID)
+
+	r, _, _ := store.Get(context.Background(), sr.ID)
+	if !r.ReceivedAt.Equal(received) {
+		t.Errorf("ReceivedAt = %v, want %v", r.ReceivedAt, received)
+	}
+	if n := testutil.CollectAndCount(metrics.PhaseDuration); n != 4 {
+		t.Errorf("phases observed = %d, want intake, queue, run and notify", n)
+	}
 }
 
 func TestSubmit_NotifierErrorDoesNotFail(t *testing.T) {
@@ -414,3 +786,399 @@ func TestSubmit_NotifierErrorDoesNotFail(t *testing.T) {
 		t.Fatal("triage did not complete within deadline")
 	}
 }
+
+func TestSubmit_StoreAndNotifySpans(t *testing.T) {
+	t.Parallel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	store := newMockStore()
+	notifier := newMockNotifier()
+	notifier.err = errors.New("webhook down")
+	provider := &mockProvider{
+		responses: []*LLMResponse{{
+			Content:    []ContentBlock{{Type: "text", Text: "done"}},
+			StopReason: StopEnd,
+		}},
+	}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, tp)
+	svc := NewService(store, engine, log.Nop(), nil, notifier, tp)
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-spans",
+		Labels:      map[string]string{"alertname": "SpanTest"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	byName := make(map[string]tracetest.SpanStub)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, s := range exporter.GetSpans() {
+			byName[s.Name] = s
+		}
+		if _, ok := byName["triage"]; ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	root, ok := byName["triage"]
+	if !ok {
+		t.Fatal("triage span was not exported within deadline")
+	}
+	if r := waitTerminal(t, store, sr.ID); r.TraceID != root.SpanContext.TraceID().String() {
+		t.Errorf("result TraceID = %q, want the triage span's %s", r.TraceID, root.SpanContext.TraceID())
+	}
+
+	tests := []struct {
+		name    string
+		attr    string
+		outcome string
+		status  codes.Code
+	}{
+		{"store.put", "vigil.store.outcome", "ok", codes.Ok},
+		{"notify.send", "vigil.notify.outcome", "error", codes.Error},
+	}
+	for _, tt := range tests {
+		s, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("%s span missing", tt.name)
+			continue
+		}
+		if s.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("%s parent = %s, want triage span %s", tt.name, s.Parent.SpanID(), root.SpanContext.SpanID())
+		}
+		attrs := make(map[string]string)
+		for _, kv := range s.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		if attrs["vigil.triage.id"] != sr.ID {
+			t.Errorf("%s vigil.triage.id = %q, want %q", tt.name, attrs["vigil.triage.id"], sr.ID)
+		}
+		if attrs[tt.attr] != tt.outcome {
+			t.Errorf("%s %s = %q, want %q", tt.name, tt.attr, attrs[tt.attr], tt.outcome)
+		}
+		if s.Status.Code != tt.status {
+			t.Errorf("%s status = %v, want %v", tt.name, s.Status.Code, tt.status)
+		}
+	}
+}
+
+// blockingProvider blocks every Send until release is closed.
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingProvider) Send(ctx context.Context, _ *LLMRequest) (*LLMResponse, error) {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &LLMResponse{
+		Content:    []ContentBlock{{Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+	}, nil
+}
+
+func TestSubmit_MaxConcurrentKeepsExcessPending(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 2), release: make(chan struct{})}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithMaxConcurrent(1))
+
+	submit := func(fp string) string {
+		t.Helper()
+		sr, err := svc.Submit(context.Background(), &alert.Alert{
+			Status:      "firing",
+			Fingerprint: fp,
+			Labels:      map[string]string{"alertname": "Cap"},
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return sr.ID
+	}
+
+	first := submit("fp-cap-1")
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first triage did not start")
+	}
+	second := submit("fp-cap-2")
+
+	// The second triage must not reach the provider while the first holds the only slot.
+	select {
+	case <-provider.started:
+		t.Fatal("second triage started while first was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if r, _, _ := store.Get(context.Background(), second); r.Status != StatusPending {
+		t.Errorf("second status = %q, want %q", r.Status, StatusPending)
+	}
+
+	close(provider.release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r1, _, _ := store.Get(context.Background(), first)
+		r2, _, _ := store.Get(context.Background(), second)
+		if r1.Status.IsTerminal() && r2.Status.IsTerminal() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("triages did not complete within deadline")
+}
+
+func TestCancel_QueuedTriageLeavesQueue(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 2), release: make(chan struct{})}
+	defer close(provider.release)
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithMaxConcurrent(1))
+	ctx := context.Background()
+
+	submit := func(fp string) string {
+		t.Helper()
+		sr, err := svc.Submit(ctx, &alert.Alert{Status: "firing", Fingerprint: fp, Labels: map[string]string{"alertname": "Cap"}})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return sr.ID
+	}
+	submit("fp-queued-1")
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first triage did not start")
+	}
+	queued := submit("fp-queued-2")
+
+	if ok, err := svc.Cancel(ctx, queued); err != nil || !ok {
+		t.Fatalf("Cancel = %v, %v, want true", ok, err)
+	}
+	// The first triage still holds the only slot, so the queued one can
+	// only finish by leaving the queue.
+	r := waitTerminal(t, store, queued)
+	if r.Status != StatusError || !strings.Contains(r.Analysis, "cancelled by operator") {
+		t.Errorf("queued triage = %s %q, want cancelled", r.Status, r.Analysis)
+	}
+	select {
+	case <-provider.started:
+		t.Error("cancelled triage reached the provider")
+	default:
+	}
+	svc.sched.mu.Lock()
+	defer svc.sched.mu.Unlock()
+	if n := len(svc.sched.waiting); n != 0 {
+		t.Errorf("%d waiters left in the scheduler, want 0", n)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(provider.release)
+	svc := NewService(store, NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider())
+	ctx := context.Background()
+
+	sr, err := svc.Submit(ctx, &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-cancel",
+		Labels:      map[string]string{"alertname": "Runaway"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	select {
+	case <-provider.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("triage did not start")
+	}
+
+	ok, err := svc.Cancel(ctx, sr.ID)
+	if err != nil || !ok {
+		t.Fatalf("Cancel = %v, %v, want true", ok, err)
+	}
+
+	var r *Result
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r, _, _ = store.Get(ctx, sr.ID)
+		if r.Status.IsTerminal() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r.Status != StatusError {
+		t.Fatalf("status = %q, want %q", r.Status, StatusError)
+	}
+	if !strings.Contains(r.Analysis, "cancelled by operator") {
+		t.Errorf("analysis = %q, want cancellation note", r.Analysis)
+	}
+
+	// Once finished, the triage can no longer be cancelled.
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		_, err = svc.Cancel(ctx, sr.ID)
+		if errors.Is(err, ErrTriageNotRunning) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !errors.Is(err, ErrTriageNotRunning) {
+		t.Errorf("Cancel finished = %v, want ErrTriageNotRunning", err)
+	}
+	if ok, err := svc.Cancel(ctx, "missing"); ok || err != nil {
+		t.Errorf("Cancel missing = %v, %v, want false, nil", ok, err)
+	}
+}
+
+type profileFunc func(*alert.Alert) *Profile
+
+func (f profileFunc) Resolve(al *alert.Alert) *Profile { return f(al) }
+
+func TestSubmit_ProfileSkip(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	resolver := profileFunc(func(al *alert.Alert) *Profile {
+		if al.Receiver == "null" {
+			return &Profile{Name: "silenced", Skip: true}
+		}
+		return nil
+	})
+	svc := NewService(store, NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithProfiles(resolver))
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{Status: "firing", Fingerprint: "fp-null", Receiver: "null"})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !sr.Skipped || sr.Reason != "skipped by profile" {
+		t.Errorf("result = %+v, want skipped by profile", sr)
+	}
+	if len(store.results) != 0 {
+		t.Errorf("skipped alert was stored: %v", store.results)
+	}
+}
+
+func TestSubmit_ProfileInstructionsAndNotifier(t *testing.T) {
+	t.Parallel()
+
+	defaultNotifier := newMockNotifier()
+	teamNotifier := newMockNotifier()
+	resolver := profileFunc(func(*alert.Alert) *Profile {
+		return &Profile{Name: "payments", Instructions: "Check payments-db first.", Notifier: teamNotifier}
+	})
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+	}}}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(newMockStore(), engine, log.Nop(), nil, defaultNotifier, noop.NewTracerProvider(),
+		WithProfiles(resolver))
+
+	if _, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-payments",
+		Receiver:    "payments-pager",
+		Labels:      map[string]string{"alertname": "PaymentsLatency"},
+	}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	select {
+	case <-teamNotifier.called:
+	case <-time.After(2 * time.Second):
+		t.Fatal("profile notifier was not called within deadline")
+	}
+
+	teamNotifier.mu.Lock()
+	defer teamNotifier.mu.Unlock()
+	if !strings.Contains(teamNotifier.last.SystemPrompt, "Check payments-db first.") {
+		t.Errorf("system prompt missing profile instructions:\n%s", teamNotifier.last.SystemPrompt)
+	}
+
+	defaultNotifier.mu.Lock()
+	defer defaultNotifier.mu.Unlock()
+	if defaultNotifier.calls != 0 {
+		t.Errorf("default notifier calls = %d, want 0", defaultNotifier.calls)
+	}
+}
+
+// countingTool counts its calls.
+type countingTool struct {
+	mockTool
+	calls atomic.Int32
+}
+
+func (c *countingTool) Execute(ctx context.Context, params json.RawMessage) (json.RawMessage, error) {
+	c.calls.Add(1)
+	return c.mockTool.Execute(ctx, params)
+}
+
+func TestRunTriage_ToolCacheAnnotation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		annotation string
+		wantCalls  int32
+	}{
+		{"cached", "", 1},
+		{"bypassed", "off", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tool := &countingTool{mockTool: mockTool{name: "query_metrics", output: json.RawMessage(`"up"`)}}
+			registry := tools.NewRegistry(tools.WithCache(tools.CacheConfig{Default: time.Hour}))
+			registry.Register(tool)
+			var responses []*LLMResponse
+			for i := range 2 {
+				responses = append(responses,
+					&LLMResponse{
+						Content:    []ContentBlock{{Type: "tool_use", ID: fmt.Sprintf("call-%d", i), Name: "query_metrics", Input: json.RawMessage(`{"query":"up"}`)}},
+						StopReason: StopToolUse,
+					},
+					&LLMResponse{Content: []ContentBlock{{Type: "text", Text: "done"}}, StopReason: StopEnd},
+				)
+			}
+			store := newMockStore()
+			engine := NewEngine(&mockProvider{responses: responses}, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+			svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider())
+
+			for i := range 2 {
+				sr, err := svc.Submit(context.Background(), &alert.Alert{
+					Status:      "firing",
+					Fingerprint: fmt.Sprintf("fp-cache-%d", i),
+					Labels:      map[string]string{"alertname": "CacheTest"},
+					Annotations: map[string]string{AnnotationToolCache: tt.annotation},
+				})
+				if err != nil {
+					t.Fatalf("Submit: %v", err)
+				}
+				waitTerminal(t, store, sr.ID)
+			}
+			if got := tool.calls.Load(); got != tt.wantCalls {
+				t.Errorf("tool calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package triage
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultStatsTopAlerts is how many alert names Stats ranks by default.
+const DefaultStatsTopAlerts = 10
+
+// StatsQuery selects the triages Stats aggregates: those created in
+// [Since, Until).
+type StatsQuery struct {
+	// Tenant restricts triages like ListFilter.Tenant.
+	Tenant string
+	Since  time.Time
+	Until  time.Time
+	// TopAlerts is how many alert names to rank.
+	TopAlerts int
+}
+
+// EffectiveTopAlerts returns TopAlerts, defaulting to DefaultStatsTopAlerts.
+func (q StatsQuery) EffectiveTopAlerts() int {
+	if q.TopAlerts <= 0 {
+		return DefaultStatsTopAlerts
+	}
+	return q.TopAlerts
+}
+
+// Stats aggregates the triages of a time window for reporting.
+type Stats struct {
+	Since    time.Time      `json:"since"`
+	Until    time.Time      `json:"until"`
+	Triages  int            `json:"triages"`
+	ByStatus map[Status]int `json:"by_status"`
+	// DurationP50 and DurationP95 are over finished triages, in seconds.
+	DurationP50 float64 `json:"duration_p50_seconds"`
+	DurationP95 float64 `json:"duration_p95_seconds"`
+	// CostUSD sums the cost of every model with a known price.
+	CostUSD float64 `json:"cost_usd"`
+	// Models, TopAlerts and Tools are sorted by use, most first.
+	Models    []ModelUsage `json:"models"`
+	TopAlerts []AlertCount `json:"top_alerts"`
+	Tools     []ToolUsage  `json:"tools"`
+	// Noisiest scores the alert names of the window, noisiest first, as
+	// many as TopAlerts ranks.
+	Noisiest []NoiseScore `json:"noisiest"`
+}
+
+// ModelUsage is the token usage of triages that ended on one model.
+type ModelUsage struct {
+	Model          string `json:"model"`
+	Triages        int    `json:"triages"`
+	TokensIn       int64  `json:"tokens_in"`
+	TokensOut      int64  `json:"tokens_out"`
+	TokensThinking int64  `json:"tokens_thinking"`
+	// CostUSD is the list price of the tokens, absent for models without a
+	// known price.
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+}
+
+// AlertCount is how often one alert name was triaged.
+type AlertCount struct {
+	Alert   string `json:"alert_name"`
+	Triages int    `json:"triages"`
+}
+
+// ToolUsage counts the calls to one tool and how many of them failed.
+type ToolUsage struct {
+	Tool      string  `json:"tool"`
+	Calls     int     `json:"calls"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// Stats aggregates the tenant's triages created in q's window.
+func (s *Service) Stats(ctx context.Context, q StatsQuery) (*Stats, error) {
+	q.Tenant = TenantFrom(ctx)
+	st, err := s.store.Stats(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	st.price()
+	history, err := s.history(ctx, ListFilter{Tenant: q.Tenant, Before: q.Until}, q.Since)
+	if err != nil {
+		return nil, err
+	}
+	st.Noisiest = noisiest(history, q.Since, q.Until, q.EffectiveTopAlerts())
+	return st, nil
+}
+
+// price fills in the cost of each model with a known price.
+func (st *Stats) price() {
+	st.CostUSD = 0
+	for i := range st.Models {
+		m := &st.Models[i]
+		p, ok := PriceOf(m.Model)
+		if !ok {
+			m.CostUSD = nil
+			continue
+		}
+		c := p.Cost(m.TokensIn, m.TokensOut)
+		m.CostUSD = &c
+		st.CostUSD += c
+	}
+}
+
+// ComputeStats aggregates results the way the Postgres store does in SQL,
+// for stores without a query engine. It filters results by q itself, and
+// counts tool calls from their conversations.
+func ComputeStats(results []*Result, q StatsQuery) *Stats {
+	st := &Stats{Since: q.Since, Until: q.Until, ByStatus: map[Status]int{}}
+	var durations []float64
+	models := map[string]*ModelUsage{}
+	alerts := map[string]int{}
+	tools := map[string]*ToolUsage{}
+	for _, r := range results {
+		if !(ListFilter{Tenant: q.Tenant}).Matches(r) || r.CreatedAt.Before(q.Since) || !r.CreatedAt.Before(q.Until) {
+			continue
+		}
+		st.Triages++
+		st.ByStatus[r.Status]++
+		alerts[r.Alert]++
+		if r.Status.IsTerminal() {
+			durations = append(durations, r.Duration)
+		}
+		if r.Model != "" {
+			m := models[r.Model]
+			if m == nil {
+				m = &ModelUsage{Model: r.Model}
+				models[r.Model] = m
+			}
+			m.Triages++
+			m.TokensIn += int64(r.TokensIn)
+			m.TokensOut += int64(r.TokensOut)
+			m.TokensThinking += int64(r.TokensThinking)
+		}
+		countToolCalls(r.Conversation, tools)
+	}
+
+	slices.Sort(durations)
+	st.DurationP50 = percentile(durations, 0.5)
+	st.DurationP95 = percentile(durations, 0.95)
+
+	for _, m := range models {
+		st.Models = append(st.Models, *m)
+	}
+	slices.SortFunc(st.Models, func(a, b ModelUsage) int {
+		return cmp.Or(cmp.Compare(b.Triages, a.Triages), strings.Compare(a.Model, b.Model))
+	})
+	for name, n := range alerts {
+		st.TopAlerts = append(st.TopAlerts, AlertCount{Alert: name, Triages: n})
+	}
+	slices.SortFunc(st.TopAlerts, func(a, b AlertCount) int {
+		return cmp.Or(cmp.Compare(b.Triages, a.Triages), strings.Compare(a.Alert, b.Alert))
+	})
+	if top := q.EffectiveTopAlerts(); len(st.TopAlerts) > top {
+		st.TopAlerts = st.TopAlerts[:top]
+	}
+	for _, t := range tools {
+		t.ErrorRate = float64(t.Errors) / float64(t.Calls)
+		st.Tools = append(st.Tools, *t)
+	}
+	slices.SortFunc(st.Tools, func(a, b ToolUsage) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), strings.Compare(a.Tool, b.Tool))
+	})
+	return st
+}
+
+// ToolCallCounts tallies the tool calls of a conversation per tool, most
+// called first. It is empty for a nil conversation.
+func ToolCallCounts(conv *Conversation) []ToolUsage {
+	tools := make(map[string]*ToolUsage)
+	countToolCalls(conv, tools)
+	out := make([]ToolUsage, 0, len(tools))
+	for _, t := range tools {
+		out = append(out, *t)
+	}
+	slices.SortFunc(out, func(a, b ToolUsage) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), strings.Compare(a.Tool, b.Tool))
+	})
+	return out
+}
+
+// countToolCalls adds the tool calls of conv to tools, matching each
+// tool_result to the tool_use it answers.
+func countToolCalls(conv *Conversation, tools map[string]*ToolUsage) {
+	if conv == nil {
+		return
+	}
+	names := map[string]string{}
+	for _, turn := range conv.Turns {
+		for _, b := range turn.Content {
+			switch b.Type {
+			case "tool_use":
+				names[b.ID] = b.Name
+			case "tool_result":
+				name, ok := names[b.ToolUseID]
+				if !ok {
+					continue
+				}
+				t := tools[name]
+				if t == nil {
+					t = &ToolUsage{Tool: name}
+					tools[name] = t
+				}
+				t.Calls++
+				if b.IsError {
+					t.Errors++
+				}
+			}
+		}
+	}
+}
+
+// percentile interpolates the p-th percentile of sorted like Postgres's
+// percentile_cont, returning 0 for no values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
@@ -0,0 +1,168 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+func TestComputeStats(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	conv := &Conversation{Turns: []Turn{
+		{Role: "assistant", Content: []ContentBlock{
+			{Type: "tool_use", ID: "c1", Name: "query_metrics"},
+			{Type: "tool_use", ID: "c2", Name: "query_logs"},
+		}},
+		{Role: "user", Content: []ContentBlock{
+			{Type: "tool_result", ToolUseID: "c1"},
+			{Type: "tool_result", ToolUseID: "c2", IsError: true},
+		}},
+	}}
+	results := []*Result{
+		{Alert: "HighCPU", Status: StatusComplete, Duration: 10, Model: "claude-sonnet-4-20250514", TokensIn: 1000, TokensOut: 100, Conversation: conv},
+		{Alert: "HighCPU", Status: StatusComplete, Duration: 20, Model: "claude-sonnet-4-20250514", TokensIn: 2000, TokensOut: 200, TokensThinking: 50},
+		{Alert: "DiskFull", Status: StatusFailed, Duration: 40, Model: "claude-haiku-4-5-20251001", TokensIn: 500, TokensOut: 50},
+		{Alert: "DiskFull", Status: StatusInProgress},
+		// Outside the window or tenant.
+		{Alert: "HighCPU", Status: StatusComplete, Duration: 99, CreatedAt: base.Add(-time.Minute)},
+		{Alert: "HighCPU", Status: StatusComplete, Duration: 99, CreatedAt: base.Add(time.Hour)},
+		{Alert: "HighCPU", Status: StatusComplete, Duration: 99, TenantID: "team-b"},
+	}
+	for i, r := range results {
+		if r.CreatedAt.IsZero() {
+			r.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		}
+	}
+
+	st := ComputeStats(results, StatsQuery{Since: base, Until: base.Add(time.Hour), TopAlerts: 1})
+	if st.Triages != 4 || st.ByStatus[StatusComplete] != 2 || st.ByStatus[StatusFailed] != 1 || st.ByStatus[StatusInProgress] != 1 {
+		t.Errorf("counts = %d %v", st.Triages, st.ByStatus)
+	}
+	if st.DurationP50 != 20 || math.Abs(st.DurationP95-38) > 1e-9 {
+		t.Errorf("durations p50 %v p95 %v, want 20 and 38", st.DurationP50, st.DurationP95)
+	}
+	if len(st.Models) != 2 || st.Models[0].Model != "claude-sonnet-4-20250514" || st.Models[0].Triages != 2 ||
+		st.Models[0].TokensIn != 3000 || st.Models[0].TokensOut != 300 || st.Models[0].TokensThinking != 50 {
+		t.Errorf("models = %+v", st.Models)
+	}
+	if len(st.TopAlerts) != 1 || st.TopAlerts[0] != (AlertCount{Alert: "DiskFull", Triages: 2}) {
+		t.Errorf("top alerts = %+v, want DiskFull first on the name tie-break", st.TopAlerts)
+	}
+	if len(st.Tools) != 2 || st.Tools[0] != (ToolUsage{Tool: "query_logs", Calls: 1, Errors: 1, ErrorRate: 1}) {
+		t.Errorf("tools = %+v", st.Tools)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{nil, 0.5, 0},
+		{[]float64{7}, 0.95, 7},
+		{[]float64{1, 2, 3, 4}, 0.5, 2.5},
+		{[]float64{0, 10}, 0.95, 9.5},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.values, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPriceOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		want  ModelPrice
+		ok    bool
+	}{
+		{"claude-sonnet-4-20250514", ModelPrice{Input: 3, Output: 15}, true},
+		{"claude-opus-4-5-20251101", ModelPrice{Input: 5, Output: 25}, true},
+		{"claude-opus-4-1-20250805", ModelPrice{Input: 15, Output: 75}, true},
+		{"gpt-4o", ModelPrice{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := PriceOf(tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("PriceOf(%q) = %+v, %v, want %+v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestService_StatsCost(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	ctx := context.Background()
+	now := time.Now()
+	_ = store.Put(ctx, &Result{ID: "a", Status: StatusComplete, Model: "claude-sonnet-4-20250514", TokensIn: 1_000_000, TokensOut: 100_000, CreatedAt: now})
+	_ = store.Put(ctx, &Result{ID: "b", Status: StatusComplete, Model: "local-llama", TokensIn: 5000, TokensOut: 500, CreatedAt: now})
+	svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider())
+
+	st, err := svc.Stats(ctx, StatsQuery{Since: now.Add(-time.Hour), Until: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if math.Abs(st.CostUSD-4.5) > 1e-9 {
+		t.Errorf("cost = %v, want 4.5", st.CostUSD)
+	}
+	for _, m := range st.Models {
+		switch m.Model {
+		case "local-llama":
+			if m.CostUSD != nil {
+				t.Errorf("unknown model cost = %v, want none", *m.CostUSD)
+			}
+		default:
+			if m.CostUSD == nil || math.Abs(*m.CostUSD-4.5) > 1e-9 {
+				t.Errorf("model %s cost = %v, want 4.5", m.Model, m.CostUSD)
+			}
+		}
+	}
+}
+
+func TestService_StatsNoisiest(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	ctx := context.Background()
+	now := time.Now()
+	for i, analysis := range []string{"Disk at 91% on db-1.", "Disk at 93% on db-1.", "Disk at 95% on db-1."} {
+		_ = store.Put(ctx, &Result{ID: fmt.Sprintf("flap-%d", i), Alert: "Flappy", Status: StatusComplete, Analysis: analysis, CreatedAt: now.Add(-time.Duration(i) * time.Minute)})
+	}
+	_ = store.Put(ctx, &Result{ID: "rare", Alert: "Rare", Status: StatusComplete, Analysis: "Certificate expired.", CreatedAt: now})
+	_ = store.Put(ctx, &Result{ID: "before", Alert: "Rare", Status: StatusComplete, Analysis: "Certificate expired.", CreatedAt: now.Add(-2 * time.Hour)})
+	svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider())
+
+	st, err := svc.Stats(ctx, StatsQuery{Since: now.Add(-time.Hour), Until: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(st.Noisiest) != 2 || st.Noisiest[0].Alert != "Flappy" || st.Noisiest[0].RepeatRatio != 1 {
+		t.Fatalf("noisiest = %+v, want Flappy first with every analysis repeated", st.Noisiest)
+	}
+	// The triage of Rare before the window is not counted.
+	if rare := st.Noisiest[1]; rare.Alert != "Rare" || rare.Triages != 1 {
+		t.Errorf("noisiest[1] = %+v, want Rare with 1 triage", rare)
+	}
+
+	st, err = svc.Stats(ctx, StatsQuery{Since: now.Add(-time.Hour), Until: now.Add(time.Hour), TopAlerts: 1})
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if len(st.Noisiest) != 1 {
+		t.Errorf("noisiest = %+v, want only the top 1", st.Noisiest)
+	}
+}
@@ -1,10 +1,17 @@
 package triage
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // TurnCallback is invoked after each turn is appended during Engine.Run.
 type TurnCallback func(ctx context.Context, seq int, turn *Turn) error
 
+// PartialCallback is invoked during Engine.Run with the text of the response
+// being streamed so far.
+type PartialCallback func(ctx context.Context, text string) error
+
 // Notifier sends notifications about completed triages.
 type Notifier interface {
 	Send(ctx context.Context, result *Result) error
@@ -14,11 +21,109 @@ type nopNotifier struct{}
 
 func (nopNotifier) Send(context.Context, *Result) error { return nil }
 
-// Store is the persistence interface for triage results.
+// Store is the persistence interface for triage results. Soft-deleted results
+// are invisible to Get, GetByFingerprint and List until restored.
 type Store interface {
 	Get(ctx context.Context, id string) (*Result, bool, error)
+	// GetByFingerprint only considers triages of the tenant in ctx.
 	GetByFingerprint(ctx context.Context, fingerprint string) (*Result, bool, error)
 	Put(ctx context.Context, result *Result) error
+	// CreateIfNotActive inserts result unless a pending or in-progress triage
+	// exists for its tenant and fingerprint, in which case that triage is
+	// returned with created false. The check and insert are atomic, so concurrent callers for
+	// one fingerprint see exactly one winner.
+	CreateIfNotActive(ctx context.Context, result *Result) (active *Result, created bool, err error)
+	// ClaimAction marks suggested action n of live triage id executed by
+	// actor at the given time, reporting false if it already is or there is
+	// no such action. The check and write are atomic, so concurrent callers
+	// for one action see exactly one winner.
+	ClaimAction(ctx context.Context, id string, n int, actor string, at time.Time) (claimed bool, err error)
+	// RecordAction sets the output and error of claimed action n of triage
+	// id, leaving the rest of the stored result as it is.
+	RecordAction(ctx context.Context, id string, n int, output, errMsg string) error
 	AppendTurn(ctx context.Context, triageID string, seq int, turn *Turn) (messageID int, err error)
 	AppendToolCalls(ctx context.Context, triageID string, messageID, messageSeq int, turn *Turn, toolResults map[string]*ContentBlock) error
+	// AppendTurnWithToolCalls is AppendTurn of a turn of tool results and
+	// AppendToolCalls of the assistant turn it answers, in one write.
+	AppendTurnWithToolCalls(ctx context.Context, triageID string, seq int, turn *Turn, messageID, messageSeq int, assistant *Turn, toolResults map[string]*ContentBlock) (int, error)
+	// SavePartial records the response text streamed so far for a running
+	// triage, replacing the previous partial text. Put clears it.
+	SavePartial(ctx context.Context, triageID, text string) error
+	List(ctx context.Context, filter ListFilter) ([]*Result, error)
+	// Search returns live results matching q, best match first.
+	Search(ctx context.Context, q SearchQuery) ([]*SearchHit, error)
+	// Stats aggregates the live results selected by q. Costs are left for
+	// the caller to fill in.
+	Stats(ctx context.Context, q StatsQuery) (*Stats, error)
+
+	// Delete marks a result deleted at the given time, reporting false if no
+	// live result has the ID.
+	Delete(ctx context.Context, id string, at time.Time) (bool, error)
+	// Restore clears the deletion mark, reporting false if no deleted result
+	// has the ID.
+	Restore(ctx context.Context, id string) (bool, error)
+	// Purge permanently removes results deleted before the cutoff, along with
+	// their conversations, and returns how many were removed.
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+}
+
+// DefaultListLimit and MaxListLimit bound how many results List returns.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// ListFilter narrows a List query. Zero values match everything, except
+// Tenant, whose zero value is the default tenant.
+type ListFilter struct {
+	// Tenant restricts results to one tenant, "" being the default tenant.
+	// AnyTenant matches them all.
+	Tenant string
+	Status Status
+	Alert  string
+	// Labels restricts results to alerts carrying every one of these labels
+	// with these values.
+	Labels map[string]string
+	// Before and BeforeID page through results: only results created
+	// strictly before Before, or at Before with an ID sorting below BeforeID,
+	// match. Set them to the last result's CreatedAt and ID.
+	Before   time.Time
+	BeforeID string
+	Limit    int
+}
+
+// EffectiveLimit returns Limit clamped to (0, MaxListLimit], defaulting to DefaultListLimit.
+func (f ListFilter) EffectiveLimit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultListLimit
+	case f.Limit > MaxListLimit:
+		return MaxListLimit
+	default:
+		return f.Limit
+	}
+}
+
+// Matches reports whether r satisfies the filter, ignoring Limit.
+func (f ListFilter) Matches(r *Result) bool {
+	if f.Tenant != AnyTenant && r.TenantID != f.Tenant {
+		return false
+	}
+	if f.Status != "" && r.Status != f.Status {
+		return false
+	}
+	if f.Alert != "" && r.Alert != f.Alert {
+		return false
+	}
+	for k, v := range f.Labels {
+		if got, ok := r.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	if !f.Before.IsZero() {
+		if c := r.CreatedAt.Compare(f.Before); c > 0 || c == 0 && r.ID >= f.BeforeID {
+			return false
+		}
+	}
+	return true
 }
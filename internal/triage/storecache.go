@@ -0,0 +1,196 @@
+package triage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store cache lookup outcomes reported to StoreCacheConfig.OnLookup.
+const (
+	StoreCacheHit  = "hit"
+	StoreCacheMiss = "miss"
+)
+
+// DefaultStoreCacheMaxAge is how long a cached result is served when
+// StoreCacheConfig.MaxAge is zero.
+const DefaultStoreCacheMaxAge = time.Minute
+
+// StoreCacheConfig configures NewCachedStore.
+type StoreCacheConfig struct {
+	// MaxEntries bounds the number of cached results; the least recently
+	// read is evicted first.
+	MaxEntries int
+
+	// MaxAge bounds how long a result is served from the cache. Writes
+	// through this process invalidate at once, but writes by other
+	// processes sharing the database are only seen once the entry expires.
+	// Zero means DefaultStoreCacheMaxAge.
+	MaxAge time.Duration
+
+	// OnLookup, if set, is called for every Get with StoreCacheHit or
+	// StoreCacheMiss.
+	OnLookup func(result string)
+}
+
+// NewCachedStore returns a Store that keeps finished results read by Get in
+// an in-process LRU, so repeated reads of one triage skip reloading its
+// conversation. Results still in progress are never cached. Every write
+// through the returned Store invalidates the result it touches, and Purge
+// clears the cache. All other methods go straight to store.
+func NewCachedStore(store Store, c StoreCacheConfig) Store {
+	if c.MaxAge <= 0 {
+		c.MaxAge = DefaultStoreCacheMaxAge
+	}
+	return &cachedStore{
+		Store:   store,
+		cfg:     c,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+type cachedStore struct {
+	Store
+	cfg StoreCacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List // of *storeCacheEntry, most recently read first
+	entries map[string]*list.Element
+	// gen counts invalidations, so a Get that raced a write does not cache
+	// the result it read before the write.
+	gen uint64
+}
+
+type storeCacheEntry struct {
+	id      string
+	result  *Result
+	expires time.Time
+}
+
+func (c *cachedStore) Get(ctx context.Context, id string) (*Result, bool, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[id]; ok {
+		e := el.Value.(*storeCacheEntry)
+		if c.now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			cp := *e.result
+			c.mu.Unlock()
+			c.lookup(StoreCacheHit)
+			return &cp, true, nil
+		}
+		c.remove(el)
+	}
+	gen := c.gen
+	c.mu.Unlock()
+	c.lookup(StoreCacheMiss)
+
+	r, ok, err := c.Store.Get(ctx, id)
+	if err != nil || !ok || !r.Status.IsTerminal() {
+		return r, ok, err
+	}
+	cp := *r
+	c.mu.Lock()
+	if c.gen == gen {
+		c.add(id, &cp)
+	}
+	c.mu.Unlock()
+	return r, true, nil
+}
+
+func (c *cachedStore) Put(ctx context.Context, result *Result) error {
+	defer c.invalidate(result.ID)
+	return c.Store.Put(ctx, result)
+}
+
+func (c *cachedStore) ClaimAction(ctx context.Context, id string, n int, actor string, at time.Time) (bool, error) {
+	defer c.invalidate(id)
+	return c.Store.ClaimAction(ctx, id, n, actor, at)
+}
+
+func (c *cachedStore) RecordAction(ctx context.Context, id string, n int, output, errMsg string) error {
+	defer c.invalidate(id)
+	return c.Store.RecordAction(ctx, id, n, output, errMsg)
+}
+
+func (c *cachedStore) AppendTurn(ctx context.Context, triageID string, seq int, turn *Turn) (int, error) {
+	defer c.invalidate(triageID)
+	return c.Store.AppendTurn(ctx, triageID, seq, turn)
+}
+
+func (c *cachedStore) AppendToolCalls(ctx context.Context, triageID string, messageID, messageSeq int, turn *Turn, toolResults map[string]*ContentBlock) error {
+	defer c.invalidate(triageID)
+	return c.Store.AppendToolCalls(ctx, triageID, messageID, messageSeq, turn, toolResults)
+}
+
+func (c *cachedStore) AppendTurnWithToolCalls(ctx context.Context, triageID string, seq int, turn *Turn, messageID, messageSeq int, assistant *Turn, toolResults map[string]*ContentBlock) (int, error) {
+	defer c.invalidate(triageID)
+	return c.Store.AppendTurnWithToolCalls(ctx, triageID, seq, turn, messageID, messageSeq, assistant, toolResults)
+}
+
+func (c *cachedStore) SavePartial(ctx context.Context, triageID, text string) error {
+	defer c.invalidate(triageID)
+	return c.Store.SavePartial(ctx, triageID, text)
+}
+
+func (c *cachedStore) Delete(ctx context.Context, id string, at time.Time) (bool, error) {
+	defer c.invalidate(id)
+	return c.Store.Delete(ctx, id, at)
+}
+
+func (c *cachedStore) Restore(ctx context.Context, id string) (bool, error) {
+	defer c.invalidate(id)
+	return c.Store.Restore(ctx, id)
+}
+
+func (c *cachedStore) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	defer c.clear()
+	return c.Store.Purge(ctx, deletedBefore)
+}
+
+func (c *cachedStore) lookup(result string) {
+	if c.cfg.OnLookup != nil {
+		c.cfg.OnLookup(result)
+	}
+}
+
+// add caches r, evicting the least recently read entries over the limit.
+// c.mu must be held.
+func (c *cachedStore) add(id string, r *Result) {
+	e := &storeCacheEntry{id: id, result: r, expires: c.now().Add(c.cfg.MaxAge)}
+	if el, ok := c.entries[id]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[id] = c.lru.PushFront(e)
+	for c.lru.Len() > c.cfg.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops el from the cache. c.mu must be held.
+func (c *cachedStore) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*storeCacheEntry).id)
+}
+
+func (c *cachedStore) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if el, ok := c.entries[id]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *cachedStore) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.lru.Init()
+	clear(c.entries)
+}
@@ -0,0 +1,166 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingStore counts the Gets that reach the underlying store.
+type countingStore struct {
+	*mockStore
+	gets int
+}
+
+func (s *countingStore) Get(ctx context.Context, id string) (*Result, bool, error) {
+	s.gets++
+	return s.mockStore.Get(ctx, id)
+}
+
+func TestCachedStore_Get(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		status    Status
+		write     func(s Store) error // between the two Gets
+		wantGets  int
+		wantTally map[string]int
+	}{
+		{
+			name:      "complete result cached",
+			status:    StatusComplete,
+			wantGets:  1,
+			wantTally: map[string]int{StoreCacheMiss: 1, StoreCacheHit: 1},
+		},
+		{
+			name:      "running result not cached",
+			status:    StatusInProgress,
+			wantGets:  2,
+			wantTally: map[string]int{StoreCacheMiss: 2},
+		},
+		{
+			name:   "put invalidates",
+			status: StatusComplete,
+			write: func(s Store) error {
+				return s.Put(ctx, &Result{ID: "t1", Status: StatusComplete, Analysis: "updated"})
+			},
+			wantGets:  2,
+			wantTally: map[string]int{StoreCacheMiss: 2},
+		},
+		{
+			name:   "append turn invalidates",
+			status: StatusComplete,
+			write: func(s Store) error {
+				_, err := s.AppendTurn(ctx, "t1", 0, &Turn{Role: "user"})
+				return err
+			},
+			wantGets:  2,
+			wantTally: map[string]int{StoreCacheMiss: 2},
+		},
+		{
+			name:   "other triage written",
+			status: StatusComplete,
+			write: func(s Store) error {
+				return s.Put(ctx, &Result{ID: "t2", Status: StatusComplete})
+			},
+			wantGets:  1,
+			wantTally: map[string]int{StoreCacheMiss: 1, StoreCacheHit: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			inner := &countingStore{mockStore: newMockStore()}
+			inner.results["t1"] = &Result{ID: "t1", Status: tt.status, Analysis: "original"}
+			tally := map[string]int{}
+			s := NewCachedStore(inner, StoreCacheConfig{
+				MaxEntries: 10,
+				OnLookup:   func(result string) { tally[result]++ },
+			})
+
+			if _, ok, err := s.Get(ctx, "t1"); err != nil || !ok {
+				t.Fatalf("first Get = %v, %v", ok, err)
+			}
+			if tt.write != nil {
+				if err := tt.write(s); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			r, ok, err := s.Get(ctx, "t1")
+			if err != nil || !ok {
+				t.Fatalf("second Get = %v, %v", ok, err)
+			}
+			if want := inner.results["t1"].Analysis; r.Analysis != want {
+				t.Errorf("Analysis = %q, want %q", r.Analysis, want)
+			}
+			if inner.gets != tt.wantGets {
+				t.Errorf("store Gets = %d, want %d", inner.gets, tt.wantGets)
+			}
+			for k, v := range tt.wantTally {
+				if tally[k] != v {
+					t.Errorf("lookups = %v, want %v", tally, tt.wantTally)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCachedStore_EvictionAndExpiry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := &countingStore{mockStore: newMockStore()}
+	for _, id := range []string{"a", "b", "c"} {
+		inner.results[id] = &Result{ID: id, Status: StatusComplete}
+	}
+	s := NewCachedStore(inner, StoreCacheConfig{MaxEntries: 2, MaxAge: time.Minute}).(*cachedStore)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	get := func(id string) {
+		t.Helper()
+		if _, ok, err := s.Get(ctx, id); err != nil || !ok {
+			t.Fatalf("Get(%s) = %v, %v", id, ok, err)
+		}
+	}
+	get("a")
+	get("b")
+	get("a") // a is now the most recently read
+	get("c") // evicts b
+	if inner.gets != 3 {
+		t.Fatalf("store Gets = %d, want 3", inner.gets)
+	}
+	get("a")
+	if inner.gets != 3 {
+		t.Errorf("a was evicted; store Gets = %d, want 3", inner.gets)
+	}
+	get("b")
+	if inner.gets != 4 {
+		t.Errorf("b was not evicted; store Gets = %d, want 4", inner.gets)
+	}
+
+	now = now.Add(time.Minute)
+	get("b")
+	if inner.gets != 5 {
+		t.Errorf("expired entry served; store Gets = %d, want 5", inner.gets)
+	}
+}
+
+func TestCachedStore_ReturnsCopies(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	inner := newMockStore()
+	inner.results["t1"] = &Result{ID: "t1", Status: StatusComplete, Analysis: "original"}
+	s := NewCachedStore(inner, StoreCacheConfig{MaxEntries: 1})
+
+	r, _, _ := s.Get(ctx, "t1")
+	r.Analysis = "changed"
+	r, _, _ = s.Get(ctx, "t1")
+	if r.Analysis != "original" {
+		t.Errorf("Analysis = %q, want the cached copy unchanged", r.Analysis)
+	}
+}
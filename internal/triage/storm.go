@@ -0,0 +1,394 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// StormAlertName is the alert name of storm summaries.
+const StormAlertName = "VigilStorm"
+
+// DefaultStormGroupBy groups a storm's alerts when StormConfig.GroupBy is
+// empty.
+var DefaultStormGroupBy = []string{"alertname"}
+
+// StormConfig enables storm mode: once Threshold alerts are submitted within
+// a minute, only the first alert of each group is triaged, quietly, until
+// the rate falls below half the threshold, and a single summary of the storm
+// is notified when it ends. Alerts are grouped by the values of the GroupBy
+// labels, DefaultStormGroupBy when empty.
+type StormConfig struct {
+	Threshold int
+	GroupBy   []string
+}
+
+// WithStorms enables storm mode. A Threshold below 1 leaves it disabled.
+func WithStorms(c StormConfig) ServiceOption {
+	return func(s *Service) {
+		if c.Threshold < 1 {
+			return
+		}
+		if len(c.GroupBy) == 0 {
+			c.GroupBy = DefaultStormGroupBy
+		}
+		s.storm = &stormTracker{cfg: c, groups: make(map[string]*stormGroup), quiet: make(map[string]bool)}
+	}
+}
+
+// StormStatus is the storm state of this process, with the groups of the
+// caller's tenant.
+type StormStatus struct {
+	Active bool `json:"active"`
+	// Since is when the current storm started, zero when there is none.
+	Since time.Time `json:"since,omitzero"`
+	// Rate is how many alerts were submitted in the last minute.
+	Rate      int `json:"rate"`
+	Threshold int `json:"threshold"`
+	// Alerts is how many of the tenant's alerts arrived during the storm.
+	Alerts int          `json:"alerts"`
+	Groups []StormGroup `json:"groups"`
+}
+
+// StormGroup is the alerts of a storm sharing the GroupBy label values.
+type StormGroup struct {
+	Labels map[string]string `json:"labels"`
+	// Alert is the name of the group's first alert.
+	Alert string `json:"alert"`
+	// RepresentativeID is the triage run for the group, empty while it is
+	// being created.
+	RepresentativeID string `json:"representative_id,omitempty"`
+	Alerts           int    `json:"alerts"`
+}
+
+type stormGroup struct {
+	StormGroup
+	tenant   string
+	severity string
+	summary  string
+	// claimed is set once an alert is becoming the representative.
+	claimed bool
+}
+
+// stormBucket counts the submits of one second.
+type stormBucket struct {
+	sec int64
+	n   int
+}
+
+// stormTracker measures the submit rate and groups the alerts of a storm.
+type stormTracker struct {
+	cfg StormConfig
+
+	mu      sync.Mutex
+	buckets [60]stormBucket
+	active  bool
+	since   time.Time
+	groups  map[string]*stormGroup
+	order   []string
+	// quiet holds representatives that have not finished yet; they are
+	// triaged without notifying.
+	quiet map[string]bool
+}
+
+// rate returns the submits in the minute before now. t.mu must be held.
+func (t *stormTracker) rate(now time.Time) int {
+	n := 0
+	for _, b := range t.buckets {
+		if b.sec > now.Unix()-60 {
+			n += b.n
+		}
+	}
+	return n
+}
+
+// record counts a submit at now and reports whether it started a storm.
+func (t *stormTracker) record(now time.Time) (started bool, rate int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sec := now.Unix()
+	b := &t.buckets[sec%60]
+	if b.sec != sec {
+		*b = stormBucket{sec: sec}
+	}
+	b.n++
+	rate = t.rate(now)
+	if t.active || rate < t.cfg.Threshold {
+		return false, rate
+	}
+	t.active, t.since = true, now
+	clear(t.groups)
+	t.order = nil
+	return true, rate
+}
+
+// join adds al to its group during a storm. The first alert of a group
+// becomes its representative and must be followed by represent or release;
+// the others return the representative's ID, if known. ok is false outside
+// a storm.
+func (t *stormTracker) join(tenant string, al *alert.Alert) (key, rep string, first, ok bool) {
+	labels := make(map[string]string, len(t.cfg.GroupBy))
+	parts := make([]string, len(t.cfg.GroupBy))
+	for i, name := range t.cfg.GroupBy {
+		if v := al.Labels[name]; v != "" {
+			labels[name] = v
+		}
+		parts[i] = name + "=" + al.Labels[name]
+	}
+	key = strings.Join(parts, ",")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active {
+		return "", "", false, false
+	}
+	gk := tenant + "\x00" + key
+	g := t.groups[gk]
+	if g == nil {
+		g = &stormGroup{StormGroup: StormGroup{Labels: labels, Alert: al.Labels["alertname"]}, tenant: tenant}
+		t.groups[gk] = g
+		t.order = append(t.order, gk)
+	}
+	g.Alerts++
+	if g.severity == "" || bandRank(SeverityBand(al.Labels["severity"])) < bandRank(SeverityBand(g.severity)) {
+		g.severity = al.Labels["severity"]
+	}
+	if g.claimed {
+		return key, g.RepresentativeID, false, true
+	}
+	g.claimed = true
+	g.summary = al.Annotations["summary"]
+	return key, "", true, true
+}
+
+// represent records id as the triage of the group. A created representative
+// is triaged quietly.
+func (t *stormTracker) represent(tenant, key, id string, created bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g := t.groups[tenant+"\x00"+key]; g != nil {
+		g.RepresentativeID = id
+	}
+	if created {
+		t.quiet[id] = true
+	}
+}
+
+// release gives up a group's claim, so its next alert is triaged instead.
+func (t *stormTracker) release(tenant, key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g := t.groups[tenant+"\x00"+key]; g != nil && g.RepresentativeID == "" {
+		g.claimed = false
+	}
+}
+
+// silenced reports whether triage id is a storm representative, forgetting
+// it: it is only asked once, when the triage finishes.
+func (t *stormTracker) silenced(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	q := t.quiet[id]
+	delete(t.quiet, id)
+	return q
+}
+
+// stormSummary is a storm that ended.
+type stormSummary struct {
+	since, until time.Time
+	groups       []*stormGroup
+}
+
+// end finishes the storm once fewer than half the threshold were submitted
+// in the last minute.
+func (t *stormTracker) end(now time.Time) (*stormSummary, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.active || t.rate(now)*2 >= t.cfg.Threshold {
+		return nil, false
+	}
+	sum := &stormSummary{since: t.since, until: now}
+	for _, gk := range t.order {
+		sum.groups = append(sum.groups, t.groups[gk])
+	}
+	t.active, t.since = false, time.Time{}
+	t.groups = make(map[string]*stormGroup)
+	t.order = nil
+	return sum, true
+}
+
+// status reports the storm state with the groups of tenant.
+func (t *stormTracker) status(tenant string, now time.Time) *StormStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := &StormStatus{Active: t.active, Since: t.since, Rate: t.rate(now), Threshold: t.cfg.Threshold, Groups: []StormGroup{}}
+	for _, gk := range t.order {
+		if g := t.groups[gk]; g.tenant == tenant {
+			g := g.StormGroup
+			g.Labels = maps.Clone(g.Labels)
+			st.Groups = append(st.Groups, g)
+			st.Alerts += g.Alerts
+		}
+	}
+	return st
+}
+
+// Storm reports whether this process is in storm mode, with the groups of
+// the tenant in ctx. It returns nil when storm mode is disabled.
+func (s *Service) Storm(ctx context.Context) *StormStatus {
+	if s.storm == nil {
+		return nil
+	}
+	return s.storm.status(TenantFrom(ctx), time.Now())
+}
+
+// observeStorm counts a submitted alert towards the storm rate.
+func (s *Service) observeStorm(ctx context.Context, now time.Time) {
+	started, rate := s.storm.record(now)
+	if s.metrics != nil {
+		s.metrics.StormRate.Set(float64(rate))
+	}
+	if !started {
+		return
+	}
+	s.logger.Warn(ctx, "alert storm started, triaging one alert per group", "rate", rate, "threshold", s.storm.cfg.Threshold, "group_by", s.storm.cfg.GroupBy)
+	if s.metrics != nil {
+		s.metrics.StormActive.Set(1)
+		s.metrics.StormsTotal.Inc()
+	}
+}
+
+// RunStormWatch ends the storm once the submit rate subsides, checking
+// every interval until ctx is done, and notifies its summary.
+func (s *Service) RunStormWatch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.checkStorm(ctx, time.Now())
+		}
+	}
+}
+
+// checkStorm ends the storm if it has subsided and notifies each tenant
+// involved of its part.
+func (s *Service) checkStorm(ctx context.Context, now time.Time) {
+	s.storm.mu.Lock()
+	rate := s.storm.rate(now)
+	s.storm.mu.Unlock()
+	if s.metrics != nil {
+		s.metrics.StormRate.Set(float64(rate))
+	}
+	sum, ok := s.storm.end(now)
+	if !ok {
+		return
+	}
+	if s.metrics != nil {
+		s.metrics.StormActive.Set(0)
+	}
+	s.logger.Info(ctx, "alert storm ended", "since", sum.since, "groups", len(sum.groups), "rate", rate)
+
+	byTenant := make(map[string][]*stormGroup)
+	for _, g := range sum.groups {
+		byTenant[g.tenant] = append(byTenant[g.tenant], g)
+	}
+	for _, tenant := range slices.Sorted(maps.Keys(byTenant)) {
+		s.notifyStorm(WithTenant(ctx, tenant), sum, byTenant[tenant])
+	}
+}
+
+// notifyStorm stores the summary of the tenant's groups as a triage and
+// notifies it, through the outbox when there is one.
+func (s *Service) notifyStorm(ctx context.Context, sum *stormSummary, groups []*stormGroup) {
+	result := buildStormResult(sum, groups)
+	result.TenantID = TenantFrom(ctx)
+	L := s.logger.With("triage_id", result.ID, "alert", StormAlertName)
+
+	notifier := s.notifier
+	if p := s.tenants[result.TenantID]; p != nil && p.Notifier != nil {
+		notifier = p.Notifier
+	}
+	var notification *Notification
+	if _, nop := notifier.(nopNotifier); !nop && s.outbox != nil {
+		now := time.Now()
+		notification = &Notification{
+			TriageID:    result.ID,
+			TenantID:    result.TenantID,
+			Status:      NotificationPending,
+			NextAttempt: now.Add(outboxInlineGrace),
+			CreatedAt:   now,
+		}
+	}
+	if err := s.putResult(ctx, L, result, notification); err != nil {
+		L.Error(ctx, err, "failed to store storm summary")
+		return
+	}
+	s.audit(ctx, result.ID, result.TenantID, AuditCompleted, ActorSystem, fmt.Sprintf("storm of %d groups", len(groups)))
+
+	err := s.sendNotification(ctx, L, notifier, result)
+	if notification != nil {
+		s.recordDelivery(ctx, L, notification, err)
+	}
+}
+
+// buildStormResult summarizes a storm's groups as a complete triage whose
+// children are the representatives.
+func buildStormResult(sum *stormSummary, groups []*stormGroup) *Result {
+	var (
+		alerts   int
+		severity string
+		children []string
+		b        strings.Builder
+	)
+	for _, g := range groups {
+		alerts += g.Alerts
+		if severity == "" || bandRank(SeverityBand(g.severity)) < bandRank(SeverityBand(severity)) {
+			severity = g.severity
+		}
+	}
+	fmt.Fprintf(&b, "Alert storm from %s to %s: %d alerts in %d groups. One alert per group was triaged, without a notification of its own.\n\n",
+		sum.since.UTC().Format(time.RFC3339), sum.until.UTC().Format(time.RFC3339), alerts, len(groups))
+	for _, g := range groups {
+		var labels []string
+		for _, k := range slices.Sorted(maps.Keys(g.Labels)) {
+			labels = append(labels, k+"="+g.Labels[k])
+		}
+		fmt.Fprintf(&b, "- **%s** (%s): %d alerts", g.Alert, strings.Join(labels, ", "), g.Alerts)
+		if g.RepresentativeID != "" {
+			fmt.Fprintf(&b, ", triaged as %s", g.RepresentativeID)
+			children = append(children, g.RepresentativeID)
+		}
+		if g.summary != "" {
+			fmt.Fprintf(&b, ": %s", g.summary)
+		}
+		b.WriteString("\n")
+	}
+
+	id := ulid.Make().String()
+	return &Result{
+		ID:          id,
+		Fingerprint: "storm:" + id,
+		Status:      StatusComplete,
+		Alert:       StormAlertName,
+		Severity:    severity,
+		Summary:     fmt.Sprintf("Alert storm: %d alerts in %d groups", alerts, len(groups)),
+		Analysis:    strings.TrimSuffix(b.String(), "\n"),
+		Labels:      map[string]string{"alertname": StormAlertName, "severity": severity},
+		Annotations: map[string]string{},
+		Children:    children,
+		CreatedAt:   sum.until,
+		StartedAt:   sum.until,
+		CompletedAt: sum.until,
+	}
+}
@@ -0,0 +1,148 @@
+package triage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestStormTracker(t *testing.T) {
+	t.Parallel()
+
+	tr := &stormTracker{
+		cfg:    StormConfig{Threshold: 4, GroupBy: []string{"alertname"}},
+		groups: make(map[string]*stormGroup),
+		quiet:  make(map[string]bool),
+	}
+	base := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	// Three submits spread over more than a minute never reach four.
+	for i := range 3 {
+		if started, _ := tr.record(base.Add(time.Duration(i) * 40 * time.Second)); started {
+			t.Fatalf("storm started at submit %d", i)
+		}
+	}
+	if _, _, _, ok := tr.join("", &alert.Alert{Labels: map[string]string{"alertname": "A"}}); ok {
+		t.Fatal("join outside a storm")
+	}
+
+	now := base.Add(3 * time.Minute)
+	for i := range 4 {
+		started, rate := tr.record(now.Add(time.Duration(i) * time.Second))
+		if started != (i == 3) {
+			t.Fatalf("submit %d at rate %d: started = %v", i, rate, started)
+		}
+	}
+
+	key, _, first, ok := tr.join("", &alert.Alert{Labels: map[string]string{"alertname": "A", "severity": "warning"}})
+	if !ok || !first || key != "alertname=A" {
+		t.Fatalf("first join = %q, first %v, ok %v", key, first, ok)
+	}
+	if _, rep, first, _ := tr.join("", &alert.Alert{Labels: map[string]string{"alertname": "A"}}); first || rep != "" {
+		t.Fatalf("join before represent = %q, first %v; want grouped without an ID", rep, first)
+	}
+	tr.represent("", key, "rep-a", true)
+	if _, rep, first, _ := tr.join("", &alert.Alert{Labels: map[string]string{"alertname": "A", "severity": "critical"}}); first || rep != "rep-a" {
+		t.Fatalf("join after represent = %q, first %v", rep, first)
+	}
+	// A released claim lets the next alert of the group represent it.
+	keyB, _, _, _ := tr.join("other", &alert.Alert{Labels: map[string]string{"alertname": "B"}})
+	tr.release("other", keyB)
+	if _, _, first, _ := tr.join("other", &alert.Alert{Labels: map[string]string{"alertname": "B"}}); !first {
+		t.Fatal("released group not claimable")
+	}
+
+	st := tr.status("", now.Add(4*time.Second))
+	if !st.Active || st.Alerts != 3 || len(st.Groups) != 1 || st.Groups[0].RepresentativeID != "rep-a" {
+		t.Fatalf("status = %+v, want the tenant's one group of three", st)
+	}
+
+	if _, ok := tr.end(now.Add(30 * time.Second)); ok {
+		t.Fatal("storm ended while the rate was still high")
+	}
+	sum, ok := tr.end(now.Add(2 * time.Minute))
+	if !ok || len(sum.groups) != 2 || sum.groups[0].severity != "critical" {
+		t.Fatalf("end = %+v, %v; want both groups, A at its worst severity", sum, ok)
+	}
+	if !tr.silenced("rep-a") || tr.silenced("rep-a") {
+		t.Error("representative should be silenced exactly once")
+	}
+	if st := tr.status("", now.Add(2*time.Minute)); st.Active || len(st.Groups) != 0 {
+		t.Errorf("status after the storm = %+v", st)
+	}
+}
+
+func TestSubmit_Storm(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	notifier := newMockNotifier()
+	svc := NewService(store, NopEngine{}, log.Nop(), nil, notifier, noop.NewTracerProvider(),
+		WithStorms(StormConfig{Threshold: 2}))
+	ctx := context.Background()
+
+	submit := func(name, fp string) *SubmitResult {
+		t.Helper()
+		sr, err := svc.Submit(ctx, &alert.Alert{
+			Status:      "firing",
+			Fingerprint: fp,
+			Labels:      map[string]string{"alertname": name, "severity": "warning"},
+			Annotations: map[string]string{"summary": name + " on " + fp},
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		return sr
+	}
+
+	// The first alert comes before the storm and is triaged as usual.
+	before := submit("HighCPU", "fp-0")
+	waitForFinish(t, svc, before.ID)
+
+	repA := submit("HighCPU", "fp-1")
+	grouped := submit("HighCPU", "fp-2")
+	repB := submit("DiskFull", "fp-3")
+	if repA.Skipped || repB.Skipped {
+		t.Fatalf("representatives skipped: %+v, %+v", repA, repB)
+	}
+	if !grouped.Skipped || grouped.Reason != "storm: grouped" || grouped.ID != repA.ID {
+		t.Fatalf("grouped alert = %+v, want skipped in favour of %s", grouped, repA.ID)
+	}
+	waitForFinish(t, svc, repA.ID)
+	waitForFinish(t, svc, repB.ID)
+
+	if st := svc.Storm(ctx); !st.Active || st.Alerts != 3 || len(st.Groups) != 2 {
+		t.Fatalf("Storm() = %+v, want two groups of three alerts", st)
+	}
+
+	notifier.mu.Lock()
+	calls := notifier.calls
+	notifier.mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("notifier called %d times during the storm, want only the alert before it", calls)
+	}
+
+	svc.checkStorm(ctx, time.Now().Add(2*time.Minute))
+	notifier.mu.Lock()
+	calls, last := notifier.calls, notifier.last
+	notifier.mu.Unlock()
+	if calls != 2 || last.Alert != StormAlertName {
+		t.Fatalf("after the storm: %d notifications, last %+v; want one storm summary", calls, last)
+	}
+	if !slices.Equal(last.Children, []string{repA.ID, repB.ID}) || !strings.Contains(last.Analysis, "**HighCPU** (alertname=HighCPU): 2 alerts") {
+		t.Errorf("summary children %v, analysis:\n%s", last.Children, last.Analysis)
+	}
+	if r, _, _ := store.Get(ctx, last.ID); r == nil || r.Status != StatusComplete {
+		t.Errorf("stored summary = %+v, want complete", r)
+	}
+	if svc.Storm(ctx).Active {
+		t.Error("still storming after the rate subsided")
+	}
+}
@@ -0,0 +1,66 @@
+package triage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategy selects how the engine investigates an alert.
+type Strategy string
+
+const (
+	// StrategyReAct lets the model call tools turn by turn until it has an
+	// answer. It is the default.
+	StrategyReAct Strategy = "react"
+
+	// StrategySingleShot makes one LLM call without tools, answering from
+	// the alert alone. It suits low-severity alerts where a quick read is
+	// worth more than an investigation.
+	StrategySingleShot Strategy = "single_shot"
+
+	// StrategyPlanExecute is experimental: a first call without tools writes
+	// an investigation plan, then the ReAct loop carries it out.
+	StrategyPlanExecute Strategy = "plan_execute"
+)
+
+// Strategies lists the valid strategies, default first.
+var Strategies = []Strategy{StrategyReAct, StrategySingleShot, StrategyPlanExecute}
+
+// ParseStrategy validates a strategy name. The empty string is the default,
+// StrategyReAct.
+func ParseStrategy(s string) (Strategy, error) {
+	if s == "" {
+		return StrategyReAct, nil
+	}
+	for _, st := range Strategies {
+		if Strategy(s) == st {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("unknown strategy %q (want react, single_shot or plan_execute)", s)
+}
+
+// WithStrategy selects the strategy for one run; the zero value is
+// StrategyReAct.
+func WithStrategy(s Strategy) RunOption {
+	return func(c *runConfig) { c.strategy = s }
+}
+
+// singleShotInstruction is appended to the system prompt when no tools are
+// offered, so the model does not promise an investigation it cannot do.
+const singleShotInstruction = `
+
+No tools are available for this alert. Answer from the alert, its labels and annotations alone, and say which data you would check to confirm your assessment.`
+
+// executeNudge follows the plan of a StrategyPlanExecute run.
+const executeNudge = "Carry out the plan with the tools, revising it if the data points elsewhere, then give your analysis."
+
+// planInstruction asks for an investigation plan before any tool is called.
+// The tools are named since they are not offered in the planning request.
+func planInstruction(toolNames []string) string {
+	s := "Before investigating, write a short numbered plan: the likely causes worth checking and which queries would confirm or rule out each. Do not give an analysis yet."
+	if len(toolNames) > 0 {
+		s += " The tools you will have are: " + strings.Join(toolNames, ", ") + "."
+	}
+	return s
+}
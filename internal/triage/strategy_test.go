@@ -0,0 +1,132 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+func TestParseStrategy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    Strategy
+		wantErr bool
+	}{
+		{in: "", want: StrategyReAct},
+		{in: "react", want: StrategyReAct},
+		{in: "single_shot", want: StrategySingleShot},
+		{in: "plan_execute", want: StrategyPlanExecute},
+		{in: "Single_Shot", wantErr: true},
+		{in: "swarm", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseStrategy(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseStrategy(%q) = %q, %v; want %q, err %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func strategyRegistry() *tools.Registry {
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "query_logs", output: json.RawMessage(`"no errors"`)})
+	return registry
+}
+
+func TestRun_SingleShot(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "probably a blip"}},
+		StopReason: StopEnd,
+	}}}
+	engine := NewEngine(provider, strategyRegistry(), log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil, WithStrategy(StrategySingleShot))
+	if rr.Status != StatusComplete || rr.Analysis != "probably a blip" {
+		t.Fatalf("result = %q %q, want complete %q", rr.Status, rr.Analysis, "probably a blip")
+	}
+	if rr.Strategy != StrategySingleShot {
+		t.Errorf("Strategy = %q, want %q", rr.Strategy, StrategySingleShot)
+	}
+	if len(provider.reqs) != 1 {
+		t.Fatalf("LLM calls = %d, want 1", len(provider.reqs))
+	}
+	if req := provider.reqs[0]; len(req.Tools) != 0 || !strings.Contains(req.System, "No tools are available") {
+		t.Errorf("request offers %d tools, system prompt:\n%s", len(req.Tools), req.System)
+	}
+}
+
+func TestRun_PlanExecute(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{responses: []*LLMResponse{
+		{
+			Content:    []ContentBlock{{Type: "text", Text: "1. Check the logs for OOM kills."}},
+			StopReason: StopEnd,
+		},
+		{
+			Content:    []ContentBlock{{Type: "tool_use", ID: "call-1", Name: "query_logs", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+		},
+		{
+			Content:    []ContentBlock{{Type: "text", Text: "no OOM kills, a deploy restarted it"}},
+			StopReason: StopEnd,
+		},
+	}}
+	engine := NewEngine(provider, strategyRegistry(), log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil, WithStrategy(StrategyPlanExecute))
+	if rr.Status != StatusComplete || rr.Analysis != "no OOM kills, a deploy restarted it" {
+		t.Fatalf("result = %q %q", rr.Status, rr.Analysis)
+	}
+	if rr.Strategy != StrategyPlanExecute || rr.ToolCalls != 1 {
+		t.Errorf("Strategy = %q, ToolCalls = %d; want %q, 1", rr.Strategy, rr.ToolCalls, StrategyPlanExecute)
+	}
+	if len(provider.reqs) != 3 {
+		t.Fatalf("LLM calls = %d, want 3", len(provider.reqs))
+	}
+
+	plan := provider.reqs[0]
+	if len(plan.Tools) != 0 {
+		t.Errorf("planning request offers %d tools, want 0", len(plan.Tools))
+	}
+	if first := plan.Messages[0].Content; len(first) != 2 || !strings.Contains(first[1].Text, "The tools you will have are: query_logs.") {
+		t.Errorf("planning message = %+v", first)
+	}
+	if len(provider.reqs[1].Tools) != 1 {
+		t.Errorf("execution request offers %d tools, want 1", len(provider.reqs[1].Tools))
+	}
+	if len(rr.Notes) == 0 || rr.Notes[0].Text != "1. Check the logs for OOM kills." {
+		t.Errorf("notes = %+v, want the plan first", rr.Notes)
+	}
+	// plan, execute nudge, tool use, tool result, final
+	if len(rr.Conversation.Turns) != 5 || rr.Conversation.Turns[1].Content[0].Text != executeNudge {
+		t.Errorf("turns = %+v", rr.Conversation.Turns)
+	}
+}
+
+func TestRun_DefaultStrategy(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{}
+	engine := NewEngine(provider, strategyRegistry(), log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	rr := engine.Run(context.Background(), "t1", testAlert(), nil)
+	if rr.Strategy != StrategyReAct {
+		t.Errorf("Strategy = %q, want %q", rr.Strategy, StrategyReAct)
+	}
+	if len(provider.reqs[0].Tools) != 1 {
+		t.Errorf("request offers %d tools, want 1", len(provider.reqs[0].Tools))
+	}
+}
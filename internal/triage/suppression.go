@@ -0,0 +1,172 @@
+package triage
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// MaxSuppressionTTL bounds how long a single suppression may last.
+const MaxSuppressionTTL = 90 * 24 * time.Hour
+
+// ErrInvalidSuppression is wrapped by Suppress errors caused by the request.
+var ErrInvalidSuppression = errors.New("invalid suppression")
+
+// errNoSuppressionStore is returned by Suppress without a SuppressionStore.
+var errNoSuppressionStore = errors.New("no suppression store configured")
+
+// Skip reasons of suppressed alerts. Suppressions created through the
+// snooze endpoint record SuppressionSnoozed, the reason snoozes always had.
+const (
+	SuppressionSuppressed = "suppressed"
+	SuppressionSnoozed    = "snoozed"
+)
+
+// Suppression stops triage of matching alerts until ExpiresAt. An alert
+// matches when its fingerprint equals Fingerprint (if set) and it carries
+// every label in Matchers. Suppressions are persisted in the
+// SuppressionStore, so they hold for every replica and across restarts.
+type Suppression struct {
+	ID          string            `json:"id"`
+	TenantID    string            `json:"tenant_id,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Matchers    map[string]string `json:"matchers,omitempty"`
+	Comment     string            `json:"comment,omitempty"`
+	// Reason is the skip reason of matching alerts, SuppressionSuppressed
+	// or SuppressionSnoozed.
+	Reason string `json:"reason"`
+	// CreatedBy is the token that created the suppression, see apiActor.
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Matches reports whether the suppression covers al.
+func (s *Suppression) Matches(al *alert.Alert) bool {
+	return matchesAlert(s.Fingerprint, s.Matchers, al)
+}
+
+// matchesAlert reports whether al has the fingerprint, if set, and carries
+// every label in matchers.
+func matchesAlert(fingerprint string, matchers map[string]string, al *alert.Alert) bool {
+	if fingerprint != "" && fingerprint != al.Fingerprint {
+		return false
+	}
+	for k, v := range matchers {
+		if al.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Suppression) validate(ttl time.Duration) error {
+	if s.Reason != SuppressionSuppressed && s.Reason != SuppressionSnoozed {
+		return fmt.Errorf("%w: unknown reason %q", ErrInvalidSuppression, s.Reason)
+	}
+	if s.Fingerprint == "" && len(s.Matchers) == 0 {
+		return fmt.Errorf("%w: need a fingerprint or at least one label matcher", ErrInvalidSuppression)
+	}
+	if ttl <= 0 || ttl > MaxSuppressionTTL {
+		return fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidSuppression, MaxSuppressionTTL)
+	}
+	return nil
+}
+
+// SuppressionStore persists suppressions.
+type SuppressionStore interface {
+	CreateSuppression(ctx context.Context, s *Suppression) error
+	// ActiveSuppressions returns the tenant's suppressions expiring after
+	// now, soonest to expire first.
+	ActiveSuppressions(ctx context.Context, tenant string, now time.Time) ([]*Suppression, error)
+	// DeleteSuppression removes a suppression of the tenant, reporting false
+	// if it has none with the ID.
+	DeleteSuppression(ctx context.Context, id, tenant string) (bool, error)
+}
+
+// WithSuppressions makes Submit skip alerts covered by a suppression in
+// store, and enables Suppress.
+func WithSuppressions(store SuppressionStore) ServiceOption {
+	return func(s *Service) {
+		s.suppressions = store
+	}
+}
+
+// Suppress stops Submit from triaging the tenant's alerts matching sup for
+// ttl. The ID, tenant, creator and times of sup are assigned here, and an
+// empty Reason defaults to SuppressionSuppressed. Errors wrap
+// ErrInvalidSuppression when sup or ttl is unusable.
+func (s *Service) Suppress(ctx context.Context, sup Suppression, ttl time.Duration) (*Suppression, error) {
+	sup.Reason = cmp.Or(sup.Reason, SuppressionSuppressed)
+	if err := sup.validate(ttl); err != nil {
+		return nil, err
+	}
+	if s.suppressions == nil {
+		return nil, errNoSuppressionStore
+	}
+	sup.ID = ulid.Make().String()
+	sup.TenantID = TenantFrom(ctx)
+	sup.CreatedBy = apiActor(ctx)
+	sup.CreatedAt = time.Now()
+	sup.ExpiresAt = sup.CreatedAt.Add(ttl)
+	if err := s.suppressions.CreateSuppression(ctx, &sup); err != nil {
+		return nil, err
+	}
+	s.logger.Info(ctx, "suppression created",
+		"suppression_id", sup.ID,
+		"fingerprint", sup.Fingerprint,
+		"matchers", sup.Matchers,
+		"reason", sup.Reason,
+		"created_by", sup.CreatedBy,
+		"expires_at", sup.ExpiresAt,
+	)
+	return &sup, nil
+}
+
+// Suppressions returns the tenant's active suppressions, soonest to expire
+// first. It returns none when no suppression store is configured.
+func (s *Service) Suppressions(ctx context.Context) ([]*Suppression, error) {
+	if s.suppressions == nil {
+		return nil, nil
+	}
+	return s.suppressions.ActiveSuppressions(ctx, TenantFrom(ctx), time.Now())
+}
+
+// Unsuppress ends a suppression early, reporting false if the tenant has no
+// suppression with the ID.
+func (s *Service) Unsuppress(ctx context.Context, id string) (bool, error) {
+	if s.suppressions == nil {
+		return false, nil
+	}
+	ok, err := s.suppressions.DeleteSuppression(ctx, id, TenantFrom(ctx))
+	if ok {
+		s.logger.Info(ctx, "suppression removed", "suppression_id", id, "removed_by", apiActor(ctx))
+	}
+	return ok, err
+}
+
+// suppression returns the first active suppression of the tenant covering
+// al, or nil. A failed lookup is logged and suppresses nothing, so a store
+// outage does not silence alerts.
+func (s *Service) suppression(ctx context.Context, al *alert.Alert, tenant string) *Suppression {
+	if s.suppressions == nil {
+		return nil
+	}
+	active, err := s.suppressions.ActiveSuppressions(ctx, tenant, time.Now())
+	if err != nil {
+		s.logger.Error(ctx, err, "failed to check suppressions", "fingerprint", al.Fingerprint)
+		return nil
+	}
+	for _, sup := range active {
+		if sup.Matches(al) {
+			return sup
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+// fakeSuppressionStore keeps suppressions in memory; err fails every call.
+type fakeSuppressionStore struct {
+	mu    sync.Mutex
+	items []*Suppression
+	err   error
+}
+
+func (m *fakeSuppressionStore) CreateSuppression(_ context.Context, sup *Suppression) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	cp := *sup
+	m.items = append(m.items, &cp)
+	return nil
+}
+
+func (m *fakeSuppressionStore) ActiveSuppressions(_ context.Context, tenant string, now time.Time) ([]*Suppression, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	var out []*Suppression
+	for _, sup := range m.items {
+		if sup.TenantID == tenant && now.Before(sup.ExpiresAt) {
+			cp := *sup
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (m *fakeSuppressionStore) DeleteSuppression(_ context.Context, id, tenant string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, sup := range m.items {
+		if sup.ID == id && sup.TenantID == tenant {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, m.err
+}
+
+func TestSuppression_Matches(t *testing.T) {
+	t.Parallel()
+
+	al := &alert.Alert{Fingerprint: "fp-1", Labels: map[string]string{"alertname": "DiskFull", "env": "prod"}}
+	tests := []struct {
+		name string
+		sup  Suppression
+		want bool
+	}{
+		{"fingerprint", Suppression{Fingerprint: "fp-1"}, true},
+		{"other fingerprint", Suppression{Fingerprint: "fp-2"}, false},
+		{"labels", Suppression{Matchers: map[string]string{"alertname": "DiskFull"}}, true},
+		{"all labels", Suppression{Matchers: map[string]string{"alertname": "DiskFull", "env": "prod"}}, true},
+		{"label mismatch", Suppression{Matchers: map[string]string{"alertname": "DiskFull", "env": "staging"}}, false},
+		{"missing label", Suppression{Matchers: map[string]string{"team": "storage"}}, false},
+		{"fingerprint and labels", Suppression{Fingerprint: "fp-1", Matchers: map[string]string{"env": "staging"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.sup.Matches(al); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSuppress_SkipsTriageUntilRemoved(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	sups := &fakeSuppressionStore{}
+	decisions := &fakeDecisionLog{}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	svc := NewService(store, NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), metrics, nil, noop.NewTracerProvider(),
+		WithSuppressions(sups), WithDecisionLog(decisions))
+	ctx := context.Background()
+
+	for _, ttl := range []time.Duration{0, -time.Minute, MaxSuppressionTTL + time.Hour} {
+		if _, err := svc.Suppress(ctx, Suppression{Fingerprint: "fp-1"}, ttl); !errors.Is(err, ErrInvalidSuppression) {
+			t.Errorf("Suppress(%s) err = %v, want ErrInvalidSuppression", ttl, err)
+		}
+	}
+	if _, err := svc.Suppress(ctx, Suppression{}, time.Hour); !errors.Is(err, ErrInvalidSuppression) {
+		t.Errorf("Suppress without matcher err = %v, want ErrInvalidSuppression", err)
+	}
+
+	sup, err := svc.Suppress(ctx, Suppression{Matchers: map[string]string{"alertname": "Noisy"}, Comment: "known flapping"}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+	if sup.ID == "" || sup.CreatedBy != "api-token" || sup.Reason != SuppressionSuppressed || !sup.ExpiresAt.Equal(sup.CreatedAt.Add(24*time.Hour)) {
+		t.Errorf("suppression = %+v", sup)
+	}
+
+	noisy := &alert.Alert{Status: "firing", Fingerprint: "fp-noisy", Labels: map[string]string{"alertname": "Noisy"}}
+	sr, err := svc.Submit(ctx, noisy)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !sr.Skipped || sr.Reason != "suppressed" {
+		t.Errorf("result = %+v, want skipped as suppressed", sr)
+	}
+	if len(store.results) != 0 {
+		t.Errorf("suppressed alert was stored: %v", store.results)
+	}
+	if got := testutil.ToFloat64(metrics.SubmitsTotal.WithLabelValues("skipped_suppressed", "")); got != 1 {
+		t.Errorf("submits{skipped_suppressed} = %v, want 1", got)
+	}
+	decisions.mu.Lock()
+	if len(decisions.decisions) != 1 || decisions.decisions[0].Reason != "suppressed" || decisions.decisions[0].Rule != sup.ID {
+		t.Errorf("decisions = %+v, want the suppression as rule", decisions.decisions)
+	}
+	decisions.mu.Unlock()
+
+	active, _ := svc.Suppressions(ctx)
+	if len(active) != 1 || active[0].ID != sup.ID {
+		t.Errorf("Suppressions = %+v, want [%s]", active, sup.ID)
+	}
+	if ok, _ := svc.Unsuppress(ctx, sup.ID); !ok {
+		t.Fatal("Unsuppress reported not found")
+	}
+	sr, err = svc.Submit(ctx, noisy)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if sr.Skipped {
+		t.Errorf("result = %+v, want triage after Unsuppress", sr)
+	}
+}
+
+func TestSuppress_Snoozed(t *testing.T) {
+	t.Parallel()
+
+	sups := &fakeSuppressionStore{}
+	metrics := NewMetrics(prometheus.NewRegistry())
+	svc := NewService(newMockStore(), NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), metrics, nil, noop.NewTracerProvider(),
+		WithSuppressions(sups))
+	ctx := context.Background()
+
+	if _, err := svc.Suppress(ctx, Suppression{Fingerprint: "fp-1", Reason: "muted"}, time.Hour); !errors.Is(err, ErrInvalidSuppression) {
+		t.Errorf("Suppress with an unknown reason err = %v, want ErrInvalidSuppression", err)
+	}
+	if _, err := svc.Suppress(ctx, Suppression{Fingerprint: "fp-1", Reason: SuppressionSnoozed}, time.Hour); err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+	sr, err := svc.Submit(ctx, &alert.Alert{Status: "firing", Fingerprint: "fp-1", Labels: map[string]string{"alertname": "A"}})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if !sr.Skipped || sr.Reason != "snoozed" {
+		t.Errorf("result = %+v, want skipped as snoozed", sr)
+	}
+	if got := testutil.ToFloat64(metrics.SubmitsTotal.WithLabelValues("skipped_snoozed", "")); got != 1 {
+		t.Errorf("submits{skipped_snoozed} = %v, want 1", got)
+	}
+}
+
+func TestSuppress_StoreFailure(t *testing.T) {
+	t.Parallel()
+
+	sups := &fakeSuppressionStore{err: errors.New("db down")}
+	svc := NewService(newMockStore(), NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithSuppressions(sups))
+	ctx := context.Background()
+
+	if _, err := svc.Suppress(ctx, Suppression{Fingerprint: "fp-1"}, time.Hour); err == nil {
+		t.Error("Suppress succeeded with a failing store")
+	}
+	sr, err := svc.Submit(ctx, &alert.Alert{Status: "firing", Fingerprint: "fp-1", Labels: map[string]string{"alertname": "A"}})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if sr.Skipped {
+		t.Errorf("result = %+v, want triage when suppressions cannot be checked", sr)
+	}
+
+	plain := NewService(newMockStore(), nil, log.Nop(), nil, nil, noop.NewTracerProvider())
+	if _, err := plain.Suppress(ctx, Suppression{Fingerprint: "fp-1"}, time.Hour); err == nil {
+		t.Error("Suppress succeeded without a suppression store")
+	}
+}
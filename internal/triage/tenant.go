@@ -0,0 +1,37 @@
+package triage
+
+import "context"
+
+// AnyTenant is a ListFilter.Tenant value matching every tenant, for
+// service-internal scans. Tenant IDs are validated so none can equal it.
+const AnyTenant = "*"
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant the caller authenticated
+// as. The empty ID is the default tenant: callers using the global API token,
+// and everything stored before tenants existed.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// TenantFrom returns the tenant in ctx, or "" for the default tenant.
+func TenantFrom(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+// WithTenants gives each tenant its own profile, keyed by tenant ID. A
+// tenant's profile replaces receiver routing for its alerts, so one tenant's
+// Alertmanager receiver names cannot pick up another team's settings.
+// Tenants without an entry, including the default tenant, are routed as usual.
+func WithTenants(profiles map[string]*Profile) ServiceOption {
+	return func(s *Service) {
+		s.tenants = profiles
+	}
+}
+
+// owns reports whether r belongs to the tenant in ctx.
+func owns(ctx context.Context, r *Result) bool {
+	return r.TenantID == TenantFrom(ctx)
+}
@@ -0,0 +1,162 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+func TestService_ScopesTriagesToTenant(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &blockingProvider{started: make(chan struct{}, 3), release: make(chan struct{})}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider())
+	defer close(provider.release)
+
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+	al := &alert.Alert{Status: "firing", Fingerprint: "fp-shared", Labels: map[string]string{"alertname": "Shared"}}
+
+	a, err := svc.Submit(acme, al)
+	if err != nil || a.Skipped {
+		t.Fatalf("acme Submit = %+v, %v", a, err)
+	}
+	// The same alert firing for another tenant is its own triage, not a duplicate.
+	g, err := svc.Submit(globex, al)
+	if err != nil || g.Skipped {
+		t.Fatalf("globex Submit = %+v, %v", g, err)
+	}
+	if dup, _ := svc.Submit(acme, al); !dup.Skipped || dup.ID != a.ID {
+		t.Errorf("second acme Submit = %+v, want duplicate of %s", dup, a.ID)
+	}
+
+	if r, ok, _ := svc.Get(acme, a.ID); !ok || r.TenantID != "acme" {
+		t.Errorf("acme Get own triage = %+v, %v", r, ok)
+	}
+	if _, ok, _ := svc.Get(globex, a.ID); ok {
+		t.Error("globex can read acme's triage")
+	}
+	if _, ok, _ := svc.Get(context.Background(), a.ID); ok {
+		t.Error("default tenant can read acme's triage")
+	}
+	if ok, err := svc.Delete(globex, a.ID); ok || err != nil {
+		t.Errorf("globex Delete acme's triage = %v, %v; want not found", ok, err)
+	}
+	if ok, err := svc.Cancel(globex, a.ID); ok || err != nil {
+		t.Errorf("globex Cancel acme's triage = %v, %v; want not found", ok, err)
+	}
+
+	list, err := svc.List(globex, ListFilter{})
+	if err != nil || len(list) != 1 || list[0].ID != g.ID {
+		t.Errorf("globex List = %+v, %v; want only %s", list, err, g.ID)
+	}
+	if list, _ := svc.List(context.Background(), ListFilter{}); len(list) != 0 {
+		t.Errorf("default tenant List = %+v, want none", list)
+	}
+}
+
+func TestService_SuppressionsAreTenantScoped(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(&mockProvider{}, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(newMockStore(), engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithSuppressions(&fakeSuppressionStore{}))
+
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+	sup, err := svc.Suppress(acme, Suppression{Fingerprint: "fp-snooze", Reason: SuppressionSnoozed}, time.Hour)
+	if err != nil {
+		t.Fatalf("Suppress: %v", err)
+	}
+
+	al := &alert.Alert{Status: "firing", Fingerprint: "fp-snooze", Labels: map[string]string{"alertname": "Snoozy"}}
+	if sr, _ := svc.Submit(acme, al); sr.Reason != "snoozed" {
+		t.Errorf("acme Submit = %+v, want snoozed", sr)
+	}
+	if sr, _ := svc.Submit(globex, al); sr.Skipped {
+		t.Errorf("globex Submit = %+v, want accepted", sr)
+	}
+	if got, _ := svc.Suppressions(globex); len(got) != 0 {
+		t.Errorf("globex Suppressions = %+v, want none", got)
+	}
+	if ok, _ := svc.Unsuppress(globex, sup.ID); ok {
+		t.Error("globex removed acme's suppression")
+	}
+	if got, _ := svc.Suppressions(acme); len(got) != 1 {
+		t.Errorf("acme Suppressions = %+v, want its suppression", got)
+	}
+}
+
+func TestSubmit_TenantProfile(t *testing.T) {
+	t.Parallel()
+
+	defaultProvider := &mockProvider{}
+	tenantProvider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+	}}}
+	tenantNotifier := newMockNotifier()
+	engine := NewEngine(defaultProvider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider())
+	svc := NewService(newMockStore(), engine, log.Nop(), nil, newMockNotifier(), noop.NewTracerProvider(),
+		WithTenants(map[string]*Profile{"acme": {
+			Name:         "tenant:acme",
+			Instructions: "Acme runs on EKS.",
+			Notifier:     tenantNotifier,
+			Engine:       NewEngine(tenantProvider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()),
+			Budget:       Budget{ToolCalls: 3},
+		}}),
+		// Tenant profiles take precedence over receiver routing.
+		WithProfiles(profileFunc(func(*alert.Alert) *Profile { return &Profile{Name: "routed", Skip: true} })),
+	)
+
+	if _, err := svc.Submit(WithTenant(context.Background(), "acme"), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-acme",
+		Receiver:    "acme-pager",
+		Labels:      map[string]string{"alertname": "AcmeLatency"},
+	}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	select {
+	case <-tenantNotifier.called:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tenant notifier was not called within deadline")
+	}
+
+	tenantNotifier.mu.Lock()
+	defer tenantNotifier.mu.Unlock()
+	if r := tenantNotifier.last; r.TenantID != "acme" || !strings.Contains(r.SystemPrompt, "Acme runs on EKS.") {
+		t.Errorf("result = tenant %q, prompt %q; want acme with tenant instructions", r.TenantID, r.SystemPrompt)
+	}
+	if len(tenantProvider.reqs) != 1 || len(defaultProvider.reqs) != 0 {
+		t.Errorf("provider calls: tenant %d, default %d; want 1, 0", len(tenantProvider.reqs), len(defaultProvider.reqs))
+	}
+}
+
+func TestService_ToolsOfTenant(t *testing.T) {
+	t.Parallel()
+
+	shared, own := tools.NewRegistry(), tools.NewRegistry()
+	shared.Register(&mockTool{name: "query_metrics"})
+	own.Register(&mockTool{name: "query_logs"})
+	svc := NewService(newMockStore(), NewEngine(&mockProvider{}, shared, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, nil, noop.NewTracerProvider(),
+		WithTenants(map[string]*Profile{"acme": {Engine: NewEngine(&mockProvider{}, own, log.Nop(), EngineHooks{}, noop.NewTracerProvider())}}),
+	)
+
+	for tenant, want := range map[string]string{"": "query_metrics", "other": "query_metrics", "acme": "query_logs"} {
+		got, err := svc.Tools(WithTenant(context.Background(), tenant))
+		if err != nil || len(got) != 1 || got[0].Name != want {
+			t.Errorf("tenant %q: tools = %+v, %v, want %s", tenant, got, err, want)
+		}
+	}
+}
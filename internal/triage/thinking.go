@@ -0,0 +1,34 @@
+package triage
+
+// RedactedThinking replaces the text of thinking blocks persisted with
+// WithThinkingRedaction.
+const RedactedThinking = "[redacted]"
+
+// WithThinkingRedaction stores extended thinking blocks without their text
+// or signature, for deployments where the model's raw reasoning over
+// production data should not be kept. The run itself still sees the full
+// blocks.
+func WithThinkingRedaction() ServiceOption {
+	return func(s *Service) { s.redactThinking = true }
+}
+
+// redactThinking returns turn with the text of its thinking blocks
+// replaced, or turn itself when it has none.
+func redactThinking(turn *Turn) *Turn {
+	var cp *Turn
+	for i, b := range turn.Content {
+		if b.Type != "thinking" && b.Type != "redacted_thinking" {
+			continue
+		}
+		if cp == nil {
+			c := *turn
+			c.Content = append([]ContentBlock(nil), turn.Content...)
+			cp = &c
+		}
+		cp.Content[i] = ContentBlock{Type: b.Type, Thinking: RedactedThinking}
+	}
+	if cp == nil {
+		return turn
+	}
+	return cp
+}
@@ -0,0 +1,137 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+	"github.com/linnemanlabs/vigil/internal/tools"
+)
+
+func TestRedactThinking(t *testing.T) {
+	t.Parallel()
+
+	plain := &Turn{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "ok"}}}
+	if got := redactThinking(plain); got != plain {
+		t.Error("turn without thinking was copied")
+	}
+
+	turn := &Turn{Role: "assistant", Content: []ContentBlock{
+		{Type: "thinking", Thinking: "the password in the log is hunter2", Signature: "sig"},
+		{Type: "redacted_thinking", Data: "opaque"},
+		{Type: "text", Text: "summary"},
+	}}
+	got := redactThinking(turn)
+	if got == turn {
+		t.Fatal("turn with thinking was not copied")
+	}
+	for i, b := range got.Content[:2] {
+		if b.Thinking != RedactedThinking || b.Signature != "" || b.Data != "" {
+			t.Errorf("block %d = %+v, want only the redaction marker", i, b)
+		}
+	}
+	if got.Content[2].Text != "summary" {
+		t.Errorf("text block = %+v, want unchanged", got.Content[2])
+	}
+	if turn.Content[0].Thinking != "the password in the log is hunter2" {
+		t.Error("original turn was modified")
+	}
+}
+
+func TestRun_Thinking(t *testing.T) {
+	t.Parallel()
+
+	thinking := ContentBlock{Type: "thinking", Thinking: "look at latency first", Signature: "sig-1"}
+	provider := &mockProvider{responses: []*LLMResponse{
+		{
+			Content:    []ContentBlock{thinking, {Type: "tool_use", ID: "tu-1", Name: "test_tool", Input: json.RawMessage(`{}`)}},
+			StopReason: StopToolUse,
+			Usage:      Usage{InputTokens: 100, OutputTokens: 60, ThinkingTokens: 40},
+		},
+		{
+			Content:    []ContentBlock{{Type: "text", Text: "done"}},
+			StopReason: StopEnd,
+			Usage:      Usage{InputTokens: 200, OutputTokens: 30, ThinkingTokens: 10},
+		},
+	}}
+	registry := tools.NewRegistry()
+	registry.Register(&mockTool{name: "test_tool", output: json.RawMessage(`"ok"`)})
+	engine := NewEngine(provider, registry, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithThinking(2048))
+
+	rr := engine.Run(context.Background(), "test-triage-id", testAlert(), nil)
+	if rr.Status != StatusComplete {
+		t.Fatalf("status = %s, want complete", rr.Status)
+	}
+	if rr.ThinkingTokensUsed != 50 || rr.OutputTokensUsed != 90 {
+		t.Errorf("tokens = %d thinking of %d output, want 50 of 90", rr.ThinkingTokensUsed, rr.OutputTokensUsed)
+	}
+
+	if len(provider.reqs) != 2 {
+		t.Fatalf("provider calls = %d, want 2", len(provider.reqs))
+	}
+	for i, req := range provider.reqs {
+		if req.ThinkingBudget != 2048 || req.MaxTokens != ResponseTokens+2048 {
+			t.Errorf("request %d: thinking budget %d, max tokens %d", i, req.ThinkingBudget, req.MaxTokens)
+		}
+	}
+	// The signed thinking block must go back unchanged alongside its tool_use.
+	prev := provider.reqs[1].Messages[1]
+	if prev.Role != "assistant" || len(prev.Content) == 0 || prev.Content[0].Thinking != thinking.Thinking || prev.Content[0].Signature != thinking.Signature {
+		t.Errorf("second request assistant turn = %+v, want thinking block first", prev)
+	}
+}
+
+func TestSubmit_ThinkingRedaction(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "thinking", Thinking: "private reasoning", Signature: "sig"}, {Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+		Usage:      Usage{InputTokens: 10, OutputTokens: 5, ThinkingTokens: 3},
+	}}}
+	engine := NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider(), WithThinking(1024))
+	svc := NewService(store, engine, log.Nop(), nil, nil, noop.NewTracerProvider(), WithThinkingRedaction())
+
+	sr, err := svc.Submit(context.Background(), &alert.Alert{
+		Status:      "firing",
+		Fingerprint: "fp-think",
+		Labels:      map[string]string{"alertname": "HighCPU"},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r, ok, _ := store.Get(context.Background(), sr.ID)
+		if ok && r.Status.IsTerminal() {
+			if r.TokensThinking != 3 {
+				t.Errorf("TokensThinking = %d, want 3", r.TokensThinking)
+			}
+			var seen bool
+			for _, turn := range r.Conversation.Turns {
+				for _, b := range turn.Content {
+					if b.Type == "thinking" {
+						seen = true
+						if b.Thinking != RedactedThinking {
+							t.Errorf("stored thinking = %q, want %q", b.Thinking, RedactedThinking)
+						}
+					}
+				}
+			}
+			if !seen {
+				t.Error("no thinking block stored")
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("triage did not complete within deadline")
+}
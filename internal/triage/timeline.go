@@ -0,0 +1,133 @@
+package triage
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Timeline event types, in the order they happen within a triage.
+const (
+	EventReceived      = "received"
+	EventSubmitted     = "submitted"
+	EventStarted       = "started"
+	EventLLMCallStart  = "llm_call_start"
+	EventLLMCallEnd    = "llm_call_end"
+	EventToolCallStart = "tool_call_start"
+	EventToolCallEnd   = "tool_call_end"
+	EventCompleted     = "completed"
+	EventNotified      = "notified"
+)
+
+// Timeline is the ordered list of events of one triage, for seeing where its
+// time went.
+type Timeline struct {
+	TriageID string          `json:"id"`
+	Status   Status          `json:"status"`
+	Events   []TimelineEvent `json:"events"`
+}
+
+// TimelineEvent is one point in a triage's timeline. Duration is set on
+// events that end a phase: the intake on submitted, the queue wait on
+// started, the call on llm_call_end and tool_call_end, the whole run on
+// completed, and the delay after completion on notified.
+type TimelineEvent struct {
+	Type     string    `json:"type"`
+	At       time.Time `json:"at"`
+	Duration float64   `json:"duration_seconds,omitempty"`
+	// Turn is the conversation turn of an LLM or tool call event.
+	Turn    *int   `json:"turn,omitempty"`
+	Model   string `json:"model,omitempty"`
+	Tool    string `json:"tool,omitempty"`
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// Timeline returns the timeline of the triage with the given ID, reporting
+// false if there is none for the caller's tenant.
+func (s *Service) Timeline(ctx context.Context, id string) (*Timeline, bool, error) {
+	r, ok, err := s.Get(ctx, id)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var n *Notification
+	if s.outbox != nil {
+		if n, _, err = s.outbox.Notification(ctx, id); err != nil {
+			return nil, false, err
+		}
+	}
+	return BuildTimeline(r, n), true, nil
+}
+
+// BuildTimeline derives the timeline of r from its phase timestamps and
+// conversation, and of its notification n, which may be nil. Tool calls of
+// one turn run together, so each is placed at the end of the LLM call that
+// requested it; with a tool concurrency limit later calls may have started
+// somewhat after that.
+func BuildTimeline(r *Result, n *Notification) *Timeline {
+	tl := &Timeline{TriageID: r.ID, Status: r.Status, Events: []TimelineEvent{}}
+	add := func(ev TimelineEvent) {
+		tl.Events = append(tl.Events, ev)
+	}
+
+	submitted := TimelineEvent{Type: EventSubmitted, At: r.CreatedAt}
+	if !r.ReceivedAt.IsZero() {
+		add(TimelineEvent{Type: EventReceived, At: r.ReceivedAt})
+		submitted.Duration = r.CreatedAt.Sub(r.ReceivedAt).Seconds()
+	}
+	add(submitted)
+	if !r.StartedAt.IsZero() {
+		add(TimelineEvent{Type: EventStarted, At: r.StartedAt, Duration: r.StartedAt.Sub(r.CreatedAt).Seconds()})
+	}
+
+	if r.Conversation != nil {
+		turns := r.Conversation.Turns
+		for i := range turns {
+			turn := &turns[i]
+			if turn.Role != "assistant" {
+				continue
+			}
+			idx := i
+			start := turn.Timestamp.Add(-time.Duration(turn.Duration * float64(time.Second)))
+			add(TimelineEvent{Type: EventLLMCallStart, At: start, Turn: &idx, Model: turn.Model})
+			add(TimelineEvent{Type: EventLLMCallEnd, At: turn.Timestamp, Duration: turn.Duration, Turn: &idx, Model: turn.Model})
+
+			var results []ContentBlock
+			if i+1 < len(turns) {
+				results = turns[i+1].Content
+			}
+			for _, b := range turn.Content {
+				if b.Type != "tool_use" {
+					continue
+				}
+				add(TimelineEvent{Type: EventToolCallStart, At: turn.Timestamp, Turn: &idx, Tool: b.Name})
+				for _, res := range results {
+					if res.Type == "tool_result" && res.ToolUseID == b.ID {
+						add(TimelineEvent{
+							Type:     EventToolCallEnd,
+							At:       turn.Timestamp.Add(time.Duration(res.Duration * float64(time.Second))),
+							Duration: res.Duration,
+							Turn:     &idx,
+							Tool:     b.Name,
+							IsError:  res.IsError,
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+
+	if !r.CompletedAt.IsZero() {
+		add(TimelineEvent{Type: EventCompleted, At: r.CompletedAt, Duration: r.Duration})
+	}
+	if n != nil && n.Status == NotificationDelivered && !n.DeliveredAt.IsZero() {
+		ev := TimelineEvent{Type: EventNotified, At: n.DeliveredAt}
+		if !r.CompletedAt.IsZero() {
+			ev.Duration = n.DeliveredAt.Sub(r.CompletedAt).Seconds()
+		}
+		add(ev)
+	}
+
+	slices.SortStableFunc(tl.Events, func(a, b TimelineEvent) int { return a.At.Compare(b.At) })
+	return tl
+}
@@ -0,0 +1,116 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	at := func(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+	r := &Result{
+		ID:         "t1",
+		Status:     StatusComplete,
+		ReceivedAt: at(-0.5),
+		CreatedAt:  t0,
+		StartedAt:  at(2),
+		Conversation: &Conversation{Turns: []Turn{
+			{Role: "assistant", Timestamp: at(5), Duration: 3, Model: "m1", Content: []ContentBlock{
+				{Type: "tool_use", ID: "a", Name: "query_metrics"},
+				{Type: "tool_use", ID: "b", Name: "query_logs"},
+			}},
+			{Role: "user", Timestamp: at(7), Content: []ContentBlock{
+				{Type: "tool_result", ToolUseID: "b", Duration: 2, IsError: true},
+				{Type: "tool_result", ToolUseID: "a", Duration: 0.5},
+			}},
+			{Role: "assistant", Timestamp: at(10), Duration: 3, Model: "m1", Content: []ContentBlock{
+				{Type: "text", Text: "analysis"},
+			}},
+		}},
+		CompletedAt: at(10),
+		Duration:    8,
+	}
+	n := &Notification{TriageID: "t1", Status: NotificationDelivered, DeliveredAt: at(11)}
+
+	tl := BuildTimeline(r, n)
+
+	type event struct {
+		typ  string
+		at   float64
+		dur  float64
+		tool string
+	}
+	want := []event{
+		{EventReceived, -0.5, 0, ""},
+		{EventSubmitted, 0, 0.5, ""},
+		{EventStarted, 2, 2, ""},
+		{EventLLMCallStart, 2, 0, ""},
+		{EventLLMCallEnd, 5, 3, ""},
+		{EventToolCallStart, 5, 0, "query_metrics"},
+		{EventToolCallStart, 5, 0, "query_logs"},
+		{EventToolCallEnd, 5.5, 0.5, "query_metrics"},
+		{EventToolCallEnd, 7, 2, "query_logs"},
+		{EventLLMCallStart, 7, 0, ""},
+		{EventLLMCallEnd, 10, 3, ""},
+		{EventCompleted, 10, 8, ""},
+		{EventNotified, 11, 1, ""},
+	}
+	if tl.TriageID != "t1" || tl.Status != StatusComplete || len(tl.Events) != len(want) {
+		t.Fatalf("timeline = %+v, want %d events", tl, len(want))
+	}
+	for i, w := range want {
+		ev := tl.Events[i]
+		if ev.Type != w.typ || !ev.At.Equal(at(w.at)) || ev.Duration != w.dur || ev.Tool != w.tool {
+			t.Errorf("event %d = %s at %v (%gs, %q), want %s at %v (%gs, %q)",
+				i, ev.Type, ev.At.Sub(t0), ev.Duration, ev.Tool, w.typ, at(w.at).Sub(t0), w.dur, w.tool)
+		}
+	}
+	if ev := tl.Events[8]; !ev.IsError || ev.Turn == nil || *ev.Turn != 0 {
+		t.Errorf("failed tool call = %+v, want an error in turn 0", ev)
+	}
+	if ev := tl.Events[10]; ev.Turn == nil || *ev.Turn != 2 || ev.Model != "m1" {
+		t.Errorf("second LLM call = %+v, want turn 2 of m1", ev)
+	}
+}
+
+func TestBuildTimeline_Pending(t *testing.T) {
+	t.Parallel()
+
+	t0 := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	tl := BuildTimeline(&Result{ID: "t1", Status: StatusPending, CreatedAt: t0},
+		&Notification{TriageID: "t1", Status: NotificationPending})
+	if len(tl.Events) != 1 || tl.Events[0].Type != EventSubmitted {
+		t.Errorf("events = %+v, want only submitted", tl.Events)
+	}
+}
+
+func TestService_Timeline(t *testing.T) {
+	t.Parallel()
+
+	store := newMockStore()
+	outbox := newMockOutbox(store)
+	svc := NewService(store, nil, log.Nop(), nil, nil, noop.NewTracerProvider(), WithOutbox(outbox))
+
+	t0 := time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)
+	store.results["t1"] = &Result{ID: "t1", Status: StatusComplete, CreatedAt: t0, CompletedAt: t0.Add(time.Minute), TenantID: "acme"}
+	outbox.queue["t1"] = &Notification{TriageID: "t1", Status: NotificationDelivered, DeliveredAt: t0.Add(2 * time.Minute)}
+
+	tl, ok, err := svc.Timeline(WithTenant(context.Background(), "acme"), "t1")
+	if err != nil || !ok {
+		t.Fatalf("Timeline = %v, %v", ok, err)
+	}
+	if got := tl.Events[len(tl.Events)-1]; got.Type != EventNotified || got.Duration != 60 {
+		t.Errorf("last event = %+v, want notified a minute after completion", got)
+	}
+
+	if _, ok, err := svc.Timeline(context.Background(), "t1"); err != nil || ok {
+		t.Errorf("Timeline for another tenant = %v, %v; want not found", ok, err)
+	}
+}
@@ -1,25 +1,60 @@
 package triage
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Phases of an accepted alert, the phase label of vigil_triage_phase_seconds.
+const (
+	PhaseIntake = "intake"
+	PhaseQueue  = "queue"
+	PhaseRun    = "run"
+	PhaseNotify = "notify"
+)
 
 // Metrics holds Prometheus metrics for the triage subsystem.
 type Metrics struct {
-	TriagesTotal    *prometheus.CounterVec
-	TriageDuration  *prometheus.HistogramVec
-	TriageLLMTime   *prometheus.HistogramVec
-	TriageToolTime  prometheus.Histogram
-	TriageTokensIn  prometheus.Histogram
-	TriageTokensOut prometheus.Histogram
-	TriageToolCalls prometheus.Histogram
-	LLMCallsTotal   prometheus.Counter
-	LLMTokensIn     prometheus.Counter
-	LLMTokensOut    prometheus.Counter
-	LLMDuration     prometheus.Histogram
-	ToolCallsTotal  *prometheus.CounterVec
-	ToolDuration    *prometheus.HistogramVec
-	ToolInputBytes  *prometheus.HistogramVec
-	ToolOutputBytes *prometheus.HistogramVec
-	SubmitsTotal    *prometheus.CounterVec
+	TriagesTotal      *prometheus.CounterVec
+	TriageDuration    *prometheus.HistogramVec
+	TriageLLMTime     *prometheus.HistogramVec
+	TriageToolTime    prometheus.Histogram
+	TriageTokensIn    prometheus.Histogram
+	TriageTokensOut   prometheus.Histogram
+	TriageTokensThink prometheus.Histogram
+	TriageToolCalls   prometheus.Histogram
+	LLMCallsTotal     prometheus.Counter
+	LLMTokensIn       prometheus.Counter
+	LLMTokensOut      prometheus.Counter
+	LLMDuration       prometheus.Histogram
+	LLMRateLimit      prometheus.Histogram
+	LLMTokenEstimate  *prometheus.HistogramVec
+	ContextSummaries  prometheus.Counter
+	ToolCallsTotal    *prometheus.CounterVec
+	ToolDuration      *prometheus.HistogramVec
+	ToolInputBytes    *prometheus.HistogramVec
+	ToolOutputBytes   *prometheus.HistogramVec
+	ToolViolations    *prometheus.CounterVec
+	ToolRedactions    *prometheus.CounterVec
+	SubmitsTotal      *prometheus.CounterVec
+	QueueDepth        *prometheus.GaugeVec
+	QueueWait         *prometheus.HistogramVec
+	PhaseDuration     *prometheus.HistogramVec
+	AlertLatency      prometheus.Histogram
+	InFlight          prometheus.Gauge
+	ConversationBytes prometheus.Gauge
+
+	NotificationsTotal *prometheus.CounterVec
+	FilterMatchesTotal *prometheus.CounterVec
+	IssuesTotal        *prometheus.CounterVec
+	PersistFailures    *prometheus.CounterVec
+	NotifyGateTotal    *prometheus.CounterVec
+
+	StormActive prometheus.Gauge
+	StormsTotal prometheus.Counter
+	StormRate   prometheus.Gauge
 }
 
 // NewMetrics registers and returns triage metrics on the given registerer.
@@ -27,8 +62,8 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 	m := &Metrics{
 		TriagesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "vigil_triages_total",
-			Help: "Total triage runs by final status.",
-		}, []string{"status"}),
+			Help: "Total triage runs by final status and tenant.",
+		}, []string{"status", "tenant"}),
 		TriageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Name:    "vigil_triage_duration_seconds",
 			Help:    "Duration of triage runs in seconds.",
@@ -54,6 +89,11 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Help:    "Output tokens consumed per triage run.",
 			Buckets: prometheus.ExponentialBuckets(100, 2, 12), // 100 .. ~409600
 		}),
+		TriageTokensThink: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "vigil_triage_tokens_thinking",
+			Help:    "Estimated thinking tokens per triage run, included in vigil_triage_tokens_output.",
+			Buckets: prometheus.ExponentialBuckets(100, 2, 12), // 100 .. ~409600
+		}),
 		TriageToolCalls: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Name:    "vigil_triage_tool_calls",
 			Help:    "Tool calls per triage run.",
@@ -76,6 +116,11 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Help:    "Duration of individual LLM calls in seconds.",
 			Buckets: prometheus.ExponentialBuckets(0.5, 2, 8), // 0.5s .. ~64s
 		}),
+		LLMRateLimit: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "vigil_llm_rate_limit_wait_seconds",
+			Help:    "Time LLM calls queued for shared rate limit capacity, in seconds.",
+			Buckets: append([]float64{0}, prometheus.ExponentialBuckets(0.1, 2, 12)...), // 0, 0.1s .. ~205s
+		}),
 		ToolCallsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "vigil_tool_calls_total",
 			Help: "Total tool executions by tool name and status.",
@@ -95,10 +140,86 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Help:    "Size of tool output in bytes.",
 			Buckets: prometheus.ExponentialBuckets(64, 4, 8), // 64B .. ~1MB
 		}, []string{"tool"}),
+		LLMTokenEstimate: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "vigil_llm_token_estimate_ratio",
+			Help:    "Input tokens an LLM call used over those estimated before sending it, by estimate method: local or count_tokens.",
+			Buckets: []float64{0.5, 0.75, 0.9, 0.95, 1, 1.05, 1.1, 1.25, 1.5, 2},
+		}, []string{"method"}),
+		ContextSummaries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "vigil_triage_context_summaries_total",
+			Help: "Conversations summarized to stay within the LLM context window.",
+		}),
+		ToolViolations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_tool_output_violations_total",
+			Help: "Tool results rejected for not matching the tool's output schema, by tool name.",
+		}, []string{"tool"}),
+		ToolRedactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_tool_redactions_total",
+			Help: "Secrets scrubbed from tool output before it entered a conversation, by tool name.",
+		}, []string{"tool"}),
 		SubmitsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "vigil_submits_total",
-			Help: "Total alert submissions by result.",
-		}, []string{"result"}),
+			Help: "Total alert submissions by result and tenant.",
+		}, []string{"result", "tenant"}),
+		QueueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "vigil_triage_queue_depth",
+			Help: "Triages waiting for a run slot by severity band.",
+		}, []string{"severity_band"}),
+		QueueWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "vigil_triage_queue_wait_seconds",
+			Help:    "Time triages spent pending before a run slot was granted, by severity band.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 14), // 0.1s .. ~819s
+		}, []string{"severity_band"}),
+		PhaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "vigil_triage_phase_seconds",
+			Help:    "Time accepted alerts spent in each phase: intake (received to submitted), queue (submitted to started), run (engine run), and notify (completed to notification delivered).",
+			Buckets: prometheus.ExponentialBuckets(0.01, 3, 14), // 10ms .. ~4.4h
+		}, []string{"phase"}),
+		AlertLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "vigil_alert_latency_seconds",
+			Help:    "Time from receiving an alert to delivering its triage notification.",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 16), // 0.5s .. ~4.5h
+		}),
+		InFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_triage_in_flight",
+			Help: "Triages pending or running in this process.",
+		}),
+		ConversationBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_triage_conversation_bytes",
+			Help: "Conversation bytes held in memory by in-flight triages.",
+		}),
+		NotificationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_notifications_total",
+			Help: "Notification delivery attempts through the outbox by outcome: delivered, retry, or failed.",
+		}, []string{"outcome"}),
+		FilterMatchesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_filter_matches_total",
+			Help: "Alerts matched by an ingestion filter rule, by rule name, action, and whether the rule is a dry run.",
+		}, []string{"rule", "action", "dry_run"}),
+		IssuesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_issues_total",
+			Help: "Issues opened in the issue tracker for triages, by outcome: created or error.",
+		}, []string{"outcome"}),
+		NotifyGateTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_notify_gate_total",
+			Help: "Finished triages checked by a notification confidence gate, by decision (sent, held) and the rule whose threshold applied.",
+		}, []string{"decision", "rule"}),
+		PersistFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "vigil_triage_persist_failures_total",
+			Help: "Triages the store failed after retries, by stage (fetch, start, result) and outcome: marked_error when the error status was saved, lost when even that failed.",
+		}, []string{"stage", "outcome"}),
+		StormActive: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_storm_active",
+			Help: "1 while this process is in storm mode, triaging one alert per group, else 0.",
+		}),
+		StormsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "vigil_storms_total",
+			Help: "Alert storms that put this process in storm mode.",
+		}),
+		StormRate: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "vigil_storm_submit_rate",
+			Help: "Alerts submitted in the last minute, as measured against the storm threshold.",
+		}),
 	}
 
 	reg.MustRegister(
@@ -108,16 +229,36 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		m.TriageToolTime,
 		m.TriageTokensIn,
 		m.TriageTokensOut,
+		m.TriageTokensThink,
 		m.TriageToolCalls,
 		m.LLMCallsTotal,
 		m.LLMTokensIn,
 		m.LLMTokensOut,
 		m.LLMDuration,
+		m.LLMRateLimit,
+		m.LLMTokenEstimate,
+		m.ContextSummaries,
 		m.ToolCallsTotal,
 		m.ToolDuration,
 		m.ToolInputBytes,
 		m.ToolOutputBytes,
+		m.ToolViolations,
+		m.ToolRedactions,
 		m.SubmitsTotal,
+		m.QueueDepth,
+		m.QueueWait,
+		m.PhaseDuration,
+		m.AlertLatency,
+		m.InFlight,
+		m.ConversationBytes,
+		m.NotificationsTotal,
+		m.FilterMatchesTotal,
+		m.IssuesTotal,
+		m.PersistFailures,
+		m.NotifyGateTotal,
+		m.StormActive,
+		m.StormsTotal,
+		m.StormRate,
 	)
 
 	return m
@@ -126,30 +267,65 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 // Hooks returns an EngineHooks that increments the corresponding metrics.
 func (m *Metrics) Hooks() EngineHooks {
 	return EngineHooks{
-		OnLLMCall: func(inputTokens, outputTokens int, duration float64) {
+		OnLLMCall: func(ctx context.Context, inputTokens, outputTokens int, duration float64) {
 			m.LLMCallsTotal.Inc()
 			m.LLMTokensIn.Add(float64(inputTokens))
 			m.LLMTokensOut.Add(float64(outputTokens))
-			m.LLMDuration.Observe(duration)
+			observeWithTrace(m.LLMDuration, duration, trace.SpanContextFromContext(ctx))
+		},
+		OnLLMRateLimitWait: func(seconds float64) {
+			m.LLMRateLimit.Observe(seconds)
 		},
-		OnToolCall: func(name string, duration float64, inputBytes, outputBytes int, isError bool) {
+		OnToolCall: func(ctx context.Context, name string, duration float64, inputBytes, outputBytes int, isError bool) {
 			status := "success"
 			if isError {
 				status = "error"
 			}
 			m.ToolCallsTotal.WithLabelValues(name, status).Inc()
-			m.ToolDuration.WithLabelValues(name).Observe(duration)
+			observeWithTrace(m.ToolDuration.WithLabelValues(name), duration, trace.SpanContextFromContext(ctx))
 			m.ToolInputBytes.WithLabelValues(name).Observe(float64(inputBytes))
 			m.ToolOutputBytes.WithLabelValues(name).Observe(float64(outputBytes))
 		},
+		OnToolOutputViolation: func(name string) {
+			m.ToolViolations.WithLabelValues(name).Inc()
+		},
+		OnToolRedactions: func(name string, n int) {
+			m.ToolRedactions.WithLabelValues(name).Add(float64(n))
+		},
+		OnTokenEstimate: func(method string, estimated, actual int) {
+			if estimated > 0 {
+				m.LLMTokenEstimate.WithLabelValues(method).Observe(float64(actual) / float64(estimated))
+			}
+		},
+		OnContextSummarized: func() {
+			m.ContextSummaries.Inc()
+		},
 		OnComplete: func(e *CompleteEvent) {
-			m.TriagesTotal.WithLabelValues(string(e.Status)).Inc()
-			m.TriageDuration.WithLabelValues(string(e.Status), e.Model).Observe(e.Duration)
+			m.TriagesTotal.WithLabelValues(string(e.Status), e.Tenant).Inc()
+			observeWithTrace(m.TriageDuration.WithLabelValues(string(e.Status), e.Model), e.Duration, e.SpanContext)
 			m.TriageLLMTime.WithLabelValues(e.Model).Observe(e.LLMTime)
 			m.TriageToolTime.Observe(e.ToolTime)
 			m.TriageTokensIn.Observe(float64(e.TokensIn))
 			m.TriageTokensOut.Observe(float64(e.TokensOut))
+			if e.TokensThinking > 0 {
+				m.TriageTokensThink.Observe(float64(e.TokensThinking))
+			}
 			m.TriageToolCalls.Observe(float64(e.ToolCalls))
 		},
 	}
 }
+
+// observeWithTrace observes v on o with the trace of sc as an exemplar, so a
+// latency spike in Grafana links to the trace behind it. Traces that were not
+// sampled are observed without one, having nothing to link to.
+func observeWithTrace(o prometheus.Observer, v float64, sc trace.SpanContext) {
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if !ok || !sc.IsSampled() {
+		o.Observe(v)
+		return
+	}
+	eo.ObserveWithExemplar(v, prometheus.Labels{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	})
+}
@@ -0,0 +1,59 @@
+package triage
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestObserveWithTrace(t *testing.T) {
+	t.Parallel()
+
+	sampled := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c},
+		SpanID:     trace.SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31},
+		TraceFlags: trace.FlagsSampled,
+	})
+	unsampled := trace.NewSpanContext(trace.SpanContextConfig{TraceID: sampled.TraceID(), SpanID: sampled.SpanID()})
+
+	tests := []struct {
+		name      string
+		sc        trace.SpanContext
+		wantTrace string
+	}{
+		{name: "sampled", sc: sampled, wantTrace: "0af7651916cd43dd8448eb211c80319c"},
+		{name: "not sampled", sc: unsampled},
+		{name: "no span", sc: trace.SpanContext{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reg := prometheus.NewRegistry()
+			h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Buckets: []float64{1, 10}})
+			reg.MustRegister(h)
+
+			observeWithTrace(h, 2, tt.sc)
+
+			mfs, err := reg.Gather()
+			if err != nil {
+				t.Fatalf("Gather: %v", err)
+			}
+			hist := mfs[0].GetMetric()[0].GetHistogram()
+			if hist.GetSampleCount() != 1 {
+				t.Fatalf("sample count = %d, want 1", hist.GetSampleCount())
+			}
+			var gotTrace string
+			for _, b := range hist.GetBucket() {
+				for _, l := range b.GetExemplar().GetLabel() {
+					if l.GetName() == "trace_id" {
+						gotTrace = l.GetValue()
+					}
+				}
+			}
+			if gotTrace != tt.wantTrace {
+				t.Errorf("exemplar trace_id = %q, want %q", gotTrace, tt.wantTrace)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package triage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/linnemanlabs/go-core/log"
+)
+
+// Verdict is the model's structured assessment of its own analysis, parsed
+// from the verdict line it is asked to end with.
+type Verdict struct {
+	// Confidence in the root cause, from 0 to 1.
+	Confidence float64 `json:"confidence"`
+	// Urgent means the alert needs attention now rather than in working
+	// hours.
+	Urgent bool `json:"urgent"`
+}
+
+// verdictInstruction asks the model for the line ParseVerdict reads.
+const verdictInstruction = `
+
+End your analysis with a final line in exactly this form, with your confidence in the root cause from 0.0 to 1.0 and whether the alert needs attention now:
+Verdict: confidence=<0.0-1.0> urgent=<yes|no>`
+
+// verdictLine matches the verdict line, tolerating markdown emphasis around
+// the label and a comma between the fields.
+var verdictLine = regexp.MustCompile(`(?im)^[\s*_]*verdict[\s*_]*:[\s*_]*confidence\s*=\s*([0-9]*\.?[0-9]+)[\s,;]+urgent\s*=\s*(yes|no|true|false)\b`)
+
+// ParseVerdict returns the last verdict line in analysis, reporting false if
+// there is none or its confidence is outside 0..1.
+func ParseVerdict(analysis string) (Verdict, bool) {
+	ms := verdictLine.FindAllStringSubmatch(analysis, -1)
+	if len(ms) == 0 {
+		return Verdict{}, false
+	}
+	m := ms[len(ms)-1]
+	c, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || c < 0 || c > 1 {
+		return Verdict{}, false
+	}
+	u := strings.ToLower(m[2])
+	return Verdict{Confidence: c, Urgent: u == "yes" || u == "true"}, true
+}
+
+// withVerdict asks the model to end its analysis with a verdict line.
+func withVerdict() RunOption {
+	return func(c *runConfig) { c.verdict = true }
+}
+
+// Notify gate decisions, the decision label of vigil_notify_gate_total.
+const (
+	NotifySent = "sent"
+	NotifyHeld = "held"
+)
+
+// notifyDefaultRule names the service-wide threshold in audit details and
+// metrics, for alerts whose profile sets none.
+const notifyDefaultRule = "default"
+
+// WithNotifyMinConfidence holds back notifications of completed triages
+// whose verdict confidence is below min, unless the model judged the alert
+// urgent. Held results are still stored. A profile's NotifyMinConfidence
+// takes precedence; 0 disables the gate.
+func WithNotifyMinConfidence(min float64) ServiceOption {
+	return func(s *Service) {
+		s.notifyMinConfidence = min
+	}
+}
+
+// notifyThreshold returns the confidence threshold for a triage under
+// profile and the rule it comes from. A zero threshold means no gate.
+func (s *Service) notifyThreshold(profile *Profile) (float64, string) {
+	if profile != nil && profile.NotifyMinConfidence > 0 {
+		return profile.NotifyMinConfidence, profile.Name
+	}
+	if s.notifyMinConfidence > 0 {
+		return s.notifyMinConfidence, notifyDefaultRule
+	}
+	return 0, ""
+}
+
+// gateNotification decides whether a finished triage is sent to the
+// notifier under a threshold of min from rule, returning the decision's
+// audit detail. Triages that did not complete, or whose analysis has no
+// verdict, are sent: the gate only holds back what the model itself called
+// unconfident and not urgent. The decision is logged and counted.
+func (s *Service) gateNotification(ctx context.Context, logger log.Logger, result *Result, min float64, rule string) (send bool, detail string) {
+	decision, why := NotifySent, ""
+	v, ok := ParseVerdict(result.Analysis)
+	switch {
+	case result.Status != StatusComplete:
+		why = "status " + string(result.Status)
+	case !ok:
+		why = "no verdict"
+	case v.Urgent:
+		why = "urgent"
+	case v.Confidence >= min:
+		why = fmt.Sprintf("confidence %.2f at or above %.2f", v.Confidence, min)
+	default:
+		decision = NotifyHeld
+		why = fmt.Sprintf("confidence %.2f below %.2f, not urgent", v.Confidence, min)
+	}
+
+	logger.Info(ctx, "notification gate", "decision", decision, "rule", rule, "reason", why)
+	if s.metrics != nil {
+		s.metrics.NotifyGateTotal.WithLabelValues(decision, rule).Inc()
+	}
+	return decision == NotifySent, decision + " by " + rule + ": " + why
+}
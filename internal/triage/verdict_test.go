@@ -0,0 +1,151 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linnemanlabs/go-core/log"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/linnemanlabs/vigil/internal/alert"
+)
+
+func TestParseVerdict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		analysis string
+		want     Verdict
+		ok       bool
+	}{
+		{name: "plain", analysis: "Disk full.\nVerdict: confidence=0.85 urgent=yes", want: Verdict{Confidence: 0.85, Urgent: true}, ok: true},
+		{name: "markdown and comma", analysis: "**Verdict:** confidence=0.4, urgent=no\n", want: Verdict{Confidence: 0.4}, ok: true},
+		{name: "last line wins", analysis: "Verdict: confidence=0.9 urgent=yes\nrevised\nverdict: confidence=.3 urgent=false", want: Verdict{Confidence: 0.3}, ok: true},
+		{name: "missing", analysis: "Disk full. Fairly confident, not urgent."},
+		{name: "out of range", analysis: "Verdict: confidence=85 urgent=yes"},
+		{name: "mid line", analysis: "The Verdict: confidence=0.8 urgent=yes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := ParseVerdict(tt.analysis)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseVerdict = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRunTriage_NotifyGate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		analysis   string
+		profile    *Profile
+		wantSent   bool
+		wantDetail string
+	}{
+		{name: "confident", analysis: "Verdict: confidence=0.9 urgent=no", wantSent: true, wantDetail: "sent by default: confidence 0.90 at or above 0.70"},
+		{name: "urgent", analysis: "Verdict: confidence=0.2 urgent=yes", wantSent: true, wantDetail: "sent by default: urgent"},
+		{name: "unconfident", analysis: "Verdict: confidence=0.5 urgent=no", wantDetail: "held by default: confidence 0.50 below 0.70, not urgent"},
+		{name: "no verdict", analysis: "Disk full.", wantSent: true, wantDetail: "sent by default: no verdict"},
+		{
+			name:       "profile threshold",
+			analysis:   "Verdict: confidence=0.5 urgent=no",
+			profile:    &Profile{Name: "payments", NotifyMinConfidence: 0.4},
+			wantSent:   true,
+			wantDetail: "sent by payments: confidence 0.50 at or above 0.40",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			provider := &mockProvider{responses: []*LLMResponse{{
+				Content:    []ContentBlock{{Type: "text", Text: tt.analysis}},
+				StopReason: StopEnd,
+			}}}
+			store := newMockStore()
+			notifier := newMockNotifier()
+			al := &fakeAuditLog{}
+			opts := []ServiceOption{WithAuditLog(al), WithNotifyMinConfidence(0.7)}
+			if tt.profile != nil {
+				opts = append(opts, WithProfiles(profileFunc(func(*alert.Alert) *Profile { return tt.profile })))
+			}
+			svc := NewService(store, NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, notifier, noop.NewTracerProvider(), opts...)
+
+			sr, err := svc.Submit(context.Background(), &alert.Alert{
+				Status:      "firing",
+				Fingerprint: "fp-gate",
+				Labels:      map[string]string{"alertname": "DiskFull"},
+			})
+			if err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+			r := waitTerminal(t, store, sr.ID)
+			if r.Analysis != tt.analysis {
+				t.Errorf("stored analysis = %q, want %q", r.Analysis, tt.analysis)
+			}
+			waitForFinish(t, svc, sr.ID)
+
+			notifier.mu.Lock()
+			calls := notifier.calls
+			notifier.mu.Unlock()
+			if sent := calls > 0; sent != tt.wantSent {
+				t.Errorf("notified = %v, want %v", sent, tt.wantSent)
+			}
+
+			provider.mu.Lock()
+			system := provider.reqs[0].System
+			provider.mu.Unlock()
+			if !strings.Contains(system, "Verdict: confidence=") {
+				t.Errorf("system prompt does not ask for a verdict:\n%s", system)
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				events, _ := al.ListAudit(context.Background(), sr.ID)
+				var detail string
+				for _, e := range events {
+					if e.Event == AuditNotifyGate {
+						detail = e.Detail
+					}
+				}
+				if detail == tt.wantDetail {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("notify gate audit detail = %q, want %q", detail, tt.wantDetail)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestRunTriage_NoNotifyGate(t *testing.T) {
+	t.Parallel()
+
+	provider := &mockProvider{responses: []*LLMResponse{{
+		Content:    []ContentBlock{{Type: "text", Text: "done"}},
+		StopReason: StopEnd,
+	}}}
+	store := newMockStore()
+	svc := NewService(store, NewEngine(provider, nil, log.Nop(), EngineHooks{}, noop.NewTracerProvider()), log.Nop(), nil, newMockNotifier(), noop.NewTracerProvider())
+	sr, err := svc.Submit(context.Background(), &alert.Alert{Status: "firing", Fingerprint: "fp", Labels: map[string]string{"alertname": "X"}})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitTerminal(t, store, sr.ID)
+
+	provider.mu.Lock()
+	system := provider.reqs[0].System
+	provider.mu.Unlock()
+	if strings.Contains(system, "Verdict:") {
+		t.Errorf("system prompt asks for a verdict without a gate:\n%s", system)
+	}
+}
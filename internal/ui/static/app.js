@@ -0,0 +1,241 @@
+"use strict";
+
+// Vigil triage browser. Talks to /api/v1 with a bearer token kept in
+// sessionStorage; all rendering uses textContent so API data is never
+// interpreted as HTML.
+
+const TOKEN_KEY = "vigil.token";
+const PAGE_SIZE = 50;
+
+const $ = (id) => document.getElementById(id);
+
+function el(tag, attrs, ...children) {
+  const e = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    if (k === "class") e.className = v;
+    else e.setAttribute(k, v);
+  }
+  for (const c of children) {
+    if (c == null) continue;
+    e.append(c instanceof Node ? c : String(c));
+  }
+  return e;
+}
+
+function token() {
+  return sessionStorage.getItem(TOKEN_KEY);
+}
+
+function askToken() {
+  return new Promise((resolve) => {
+    const dlg = $("login");
+    $("login-form").onsubmit = () => {
+      sessionStorage.setItem(TOKEN_KEY, $("token").value.trim());
+      resolve();
+    };
+    dlg.showModal();
+  });
+}
+
+async function api(path) {
+  if (!token()) await askToken();
+  const resp = await fetch(path, { headers: { Authorization: "Bearer " + token() } });
+  if (resp.status === 401) {
+    sessionStorage.removeItem(TOKEN_KEY);
+    await askToken();
+    return api(path);
+  }
+  if (!resp.ok) {
+    let msg = resp.status + " " + resp.statusText;
+    try {
+      const body = await resp.json();
+      if (body.error && body.error.message) msg += ": " + body.error.message;
+    } catch (_) { /* not JSON */ }
+    throw new Error(msg);
+  }
+  return resp.json();
+}
+
+function showError(err) {
+  const p = $("error");
+  p.textContent = err ? String(err.message || err) : "";
+  p.hidden = !err;
+}
+
+function fmtTime(s) {
+  if (!s || s.startsWith("0001-")) return "";
+  return new Date(s).toLocaleString();
+}
+
+function fmtSeconds(s) {
+  if (!s) return "";
+  return s < 60 ? s.toFixed(1) + "s" : Math.floor(s / 60) + "m" + Math.round(s % 60) + "s";
+}
+
+function statusBadge(status) {
+  return el("span", { class: "status status-" + status }, status);
+}
+
+function safeURL(u) {
+  try {
+    const parsed = new URL(u);
+    return parsed.protocol === "http:" || parsed.protocol === "https:" ? parsed.href : null;
+  } catch (_) {
+    return null;
+  }
+}
+
+// list view
+
+// The last listed row, so "Load more" continues after it even when several
+// results share its created_at.
+let lastCreated = null;
+let lastID = null;
+
+function filters() {
+  const q = new URLSearchParams({ limit: PAGE_SIZE });
+  if ($("status").value) q.set("status", $("status").value);
+  if ($("alert").value.trim()) q.set("alert", $("alert").value.trim());
+  return q;
+}
+
+async function loadList(append) {
+  showError(null);
+  const q = filters();
+  if (append && lastCreated) {
+    q.set("before", lastCreated);
+    q.set("before_id", lastID);
+  }
+  try {
+    const data = await api("/api/v1/triage?" + q);
+    const rows = $("rows");
+    if (!append) rows.replaceChildren();
+    for (const r of data.results) {
+      const tr = el("tr", {},
+        el("td", {}, fmtTime(r.created_at)),
+        el("td", { class: "alert" }, r.alert_name || "(unnamed)"),
+        el("td", {}, r.severity),
+        el("td", {}, statusBadge(r.status)),
+        el("td", {}, fmtSeconds(r.duration_seconds)),
+        el("td", {}, (r.tokens_in || 0) + " / " + (r.tokens_out || 0)),
+        el("td", {}, r.tool_calls || 0),
+      );
+      tr.onclick = () => { location.hash = "#/triage/" + encodeURIComponent(r.id); };
+      rows.append(tr);
+      lastCreated = r.created_at;
+      lastID = r.id;
+    }
+    $("more").hidden = data.results.length < PAGE_SIZE;
+  } catch (err) {
+    showError(err);
+  }
+}
+
+// detail view
+
+function metaRow(dl, label, value) {
+  if (value === "" || value == null) return;
+  dl.append(el("dt", {}, label), el("dd", {}, value));
+}
+
+function renderTurn(turn, seq, toolNames) {
+  const head = [turn.role, fmtTime(turn.timestamp)];
+  if (turn.duration) head.push(fmtSeconds(turn.duration));
+  if (turn.usage) head.push("tokens " + turn.usage.input_tokens + " / " + turn.usage.output_tokens);
+  if (turn.stop_reason) head.push("stop: " + turn.stop_reason);
+
+  const div = el("div", { class: "turn turn-" + turn.role },
+    el("div", { class: "turn-head" }, "#" + seq + " " + head.filter(Boolean).join(" · ")));
+
+  for (const b of turn.content || []) {
+    if (b.type === "text") {
+      div.append(el("div", {}, b.text));
+    } else if (b.type === "tool_use") {
+      toolNames[b.id] = b.name;
+      const d = el("details", { open: "" },
+        el("summary", {}, "→ " + b.name),
+        el("pre", {}, JSON.stringify(b.input, null, 2)));
+      div.append(d);
+    } else if (b.type === "tool_result") {
+      const name = toolNames[b.tool_use_id] || b.tool_use_id;
+      const d = el("details", { open: "", class: b.is_error ? "is-error" : "" },
+        el("summary", {}, "← " + name + (b.is_error ? " (error)" : "")),
+        el("pre", {}, b.content));
+      div.append(d);
+    } else {
+      div.append(el("div", { class: "hint" }, "[" + b.type + "]"));
+    }
+  }
+  return div;
+}
+
+async function loadDetail(id) {
+  showError(null);
+  try {
+    const r = await api("/api/v1/triage/" + encodeURIComponent(id));
+    $("d-title").replaceChildren(r.alert_name || "(unnamed)", " ", statusBadge(r.status));
+    $("d-summary").textContent = r.summary || "";
+
+    const dl = $("d-meta");
+    dl.replaceChildren();
+    metaRow(dl, "ID", r.id);
+    metaRow(dl, "Fingerprint", r.fingerprint);
+    metaRow(dl, "Severity", r.severity);
+    metaRow(dl, "Created", fmtTime(r.created_at));
+    metaRow(dl, "Completed", fmtTime(r.completed_at));
+    metaRow(dl, "Duration", [fmtSeconds(r.duration_seconds), r.llm_time_seconds && "LLM " + fmtSeconds(r.llm_time_seconds), r.tool_time_seconds && "tools " + fmtSeconds(r.tool_time_seconds)].filter(Boolean).join(" · "));
+    metaRow(dl, "Tokens", (r.tokens_in || 0) + " in / " + (r.tokens_out || 0) + " out");
+    metaRow(dl, "Tool calls", r.tool_calls ? r.tool_calls + " (" + (r.tools_used || []).join(", ") + ")" : "");
+    metaRow(dl, "Model", r.model);
+    const src = safeURL(r.generator_url || "");
+    if (src) metaRow(dl, "Source", el("a", { href: src, target: "_blank", rel: "noopener noreferrer" }, src));
+    const children = r.children || [];
+    if (children.length) {
+      metaRow(dl, "Related triages", el("span", {}, ...children.flatMap((c, i) => [i ? ", " : "", el("a", { href: "#/triage/" + encodeURIComponent(c) }, c)])));
+    }
+
+    $("d-analysis").textContent = r.analysis || "(no analysis yet)";
+
+    const notes = r.investigation_notes || [];
+    $("d-notes").hidden = !notes.length;
+    $("d-notes").querySelector("summary").textContent = "Investigation notes (" + notes.length + ")";
+    $("d-notes-list").replaceChildren(...notes.map((n) => el("li", { class: "analysis" }, n.text)));
+
+    const conv = $("d-conversation");
+    conv.replaceChildren();
+    const toolNames = {};
+    const turns = (r.conversation && r.conversation.turns) || [];
+    turns.forEach((t, i) => conv.append(renderTurn(t, i, toolNames)));
+    if (!turns.length) conv.append(el("p", { class: "hint" }, "No conversation recorded."));
+  } catch (err) {
+    showError(err);
+  }
+}
+
+// routing
+
+function route() {
+  const m = location.hash.match(/^#\/triage\/(.+)$/);
+  $("list-view").hidden = !!m;
+  $("detail-view").hidden = !m;
+  if (m) {
+    loadDetail(decodeURIComponent(m[1]));
+  } else {
+    loadList(false);
+  }
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("filters").onsubmit = (e) => {
+    e.preventDefault();
+    if (location.hash && location.hash !== "#") location.hash = "";
+    else loadList(false);
+  };
+  $("more").onclick = () => loadList(true);
+  $("logout").onclick = () => {
+    sessionStorage.removeItem(TOKEN_KEY);
+    location.reload();
+  };
+  window.addEventListener("hashchange", route);
+  route();
+});
@@ -0,0 +1,39 @@
+// Package ui serves the embedded single-page triage browser. The page is
+// static; it calls the regular /api/v1 endpoints with a bearer token the user
+// enters, so the UI itself needs no authentication or server-side state.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//go:embed static
+var static embed.FS
+
+// Prefix is the path the UI is mounted under.
+const Prefix = "/ui"
+
+// RegisterRoutes mounts the UI under Prefix. Scripts and styles are separate
+// files so the page works under the strict Content-Security-Policy set by
+// httpmw.SecurityHeaders (no inline script or style).
+func RegisterRoutes(r chi.Router) {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // embedded path is fixed at compile time
+	}
+	files := http.StripPrefix(Prefix+"/", http.FileServerFS(sub))
+
+	r.Get(Prefix, func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, Prefix+"/", http.StatusMovedPermanently)
+	})
+	r.Get(Prefix+"/*", func(w http.ResponseWriter, req *http.Request) {
+		// the UI only ever fetches fresh data through the API, but make sure
+		// a redeploy is picked up without a hard refresh
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newTestRouter(t *testing.T) chi.Router {
+	t.Helper()
+	r := chi.NewRouter()
+	RegisterRoutes(r)
+	return r
+}
+
+func TestRegisterRoutes_ServesAssets(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(t)
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/ui/", "text/html", `<script src="app.js"`},
+		{"/ui/app.js", "javascript", "/api/v1/triage"},
+		{"/ui/app.css", "text/css", ".turn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("body missing %q", tt.contains)
+			}
+		})
+	}
+}
+
+func TestRegisterRoutes_RedirectsBarePrefix(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/ui", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/ui/" {
+		t.Errorf("Location = %q, want /ui/", loc)
+	}
+}
+
+func TestRegisterRoutes_MissingAsset(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/ui/nope.js", http.NoBody)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+// TestIndex_NoInlineCode guards the CSP: script-src and style-src are 'self',
+// so inline scripts, style blocks, style attributes and event handlers would be blocked.
+func TestIndex_NoInlineCode(t *testing.T) {
+	t.Parallel()
+
+	b, err := static.ReadFile("static/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(b)
+
+	for _, re := range []*regexp.Regexp{
+		regexp.MustCompile(`<script(?:\s[^>]*)?>\s*[^<\s]`),
+		regexp.MustCompile(`<style`),
+		regexp.MustCompile(`\sstyle=`),
+		regexp.MustCompile(`\son[a-z]+=`),
+	} {
+		if loc := re.FindStringIndex(html); loc != nil {
+			t.Errorf("index.html contains inline code at %d: %q", loc[0], html[loc[0]:min(loc[1]+20, len(html))])
+		}
+	}
+}